@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	goruntime "runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+const maxBenchCalls = 1000000000
+
+// benchResult is the result of a benchmark function.
+type benchResult struct {
+	name     string
+	n        int           // number of the calls
+	duration time.Duration // time spent on n calls
+	bytes    uint64        // bytes allocated by n calls
+	allocs   uint64        // number of the allocations by n calls
+	err      error
+}
+
+// doBench runs the benchmarks in the test files (*_test.tengo):
+//
+//	tengo bench [-benchtime d] [path ...]
+//
+// The paths are searched like doTest. It returns the exit code.
+func doBench(args []string) int {
+	var benchTime time.Duration
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	fs.DurationVar(&benchTime, "benchtime", time.Second, "Run each benchmark for the duration")
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println()
+		fmt.Println("	tengo bench [flags] [path ...]")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fmt.Println()
+		fs.SetOutput(os.Stdout)
+		fs.PrintDefaults()
+		fmt.Println()
+	}
+	paths := parseInterspersed(fs, args)
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := findTestFiles(paths)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	exitCode := 0
+	for _, file := range files {
+		start := time.Now()
+		results, err := runBenchFile(file, benchTime)
+		if err == nil && len(results) == 0 {
+			continue
+		}
+
+		var failed bool
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, r := range results {
+			if r.err != nil {
+				failed = true
+				_ = w.Flush()
+				fmt.Printf("--- FAIL: %s\n", r.name)
+				fmt.Printf("    %s\n", r.err.Error())
+				continue
+			}
+
+			n := uint64(r.n)
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%d ns/op\t%d B/op\t%d allocs/op\t\n",
+				r.name, r.n, r.duration.Nanoseconds()/int64(r.n), r.bytes/n, r.allocs/n)
+		}
+		_ = w.Flush()
+
+		elapsed := time.Since(start).Seconds()
+		switch {
+		case err != nil:
+			exitCode = 1
+			fmt.Printf("FAIL\t%s\t%.3fs\n", file, elapsed)
+			src, _ := ioutil.ReadFile(file)
+			printError(err, file, src)
+		case failed:
+			exitCode = 1
+			fmt.Printf("FAIL\t%s\t%.3fs\n", file, elapsed)
+		default:
+			fmt.Printf("ok\t%s\t%.3fs\n", file, elapsed)
+		}
+	}
+
+	return exitCode
+}
+
+// runBenchFile runs each benchmark function of the test file in a separate
+// VM like runTestFile.
+func runBenchFile(filename string, benchTime time.Duration) ([]benchResult, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	bytecode, err := compileTest(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	numBenches, err := runTestFunc(bytecode, filename, "bench", -1, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []benchResult
+	for i := 0; i < numBenches; i++ {
+		var result benchResult
+		_, err := runTestFunc(bytecode, filename, "bench", i, nil, func(rt objects.Interop, name string, fn objects.Object) error {
+			result.name = name
+			result.err = benchmark(rt, fn, benchTime, &result)
+
+			return result.err
+		})
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// benchmark calls fn repeatedly, increasing the number of the calls until
+// they take benchTime, and, records the time and the allocations of the last
+// round in the result.
+func benchmark(rt objects.Interop, fn objects.Object, benchTime time.Duration, result *benchResult) error {
+	n := 1
+	for {
+		var before, after goruntime.MemStats
+		goruntime.GC()
+		goruntime.ReadMemStats(&before)
+
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if _, err := rt.Call(fn); err != nil {
+				return err
+			}
+		}
+		duration := time.Since(start)
+
+		goruntime.ReadMemStats(&after)
+
+		result.n = n
+		result.duration = duration
+		result.bytes = after.TotalAlloc - before.TotalAlloc
+		result.allocs = after.Mallocs - before.Mallocs
+
+		if duration >= benchTime || n >= maxBenchCalls {
+			return nil
+		}
+
+		// predict the number of the calls that take benchTime (with 20% more),
+		// but, grow at most 100 times
+		next := n * 100
+		if duration > 0 {
+			next = int(math.Min(float64(next), float64(n)*1.2*float64(benchTime)/float64(duration)))
+		}
+		if next <= n {
+			next = n + 1
+		}
+		if next > maxBenchCalls {
+			next = maxBenchCalls
+		}
+		n = next
+	}
+}
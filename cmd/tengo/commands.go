@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// doBuild compiles the source file into the bytecode file:
+//
+//	tengo build [flags] {input-file}
+//
+// The flags can also follow the input file. It returns the exit code.
+func doBuild(args []string) int {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	fs.StringVar(&compileOutput, "o", "", "Output file (default: input file name with .tbc extension)")
+	fs.BoolVar(&stripDebug, "strip", false, "Strip debug information from compiled output")
+	fs.Var(constants, "D", "Define compile-time constant (name=value)")
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println()
+		fmt.Println("	tengo build [flags] {input-file}")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fmt.Println()
+		fs.SetOutput(os.Stdout)
+		fs.PrintDefaults()
+		fmt.Println()
+	}
+
+	inputs := parseInterspersed(fs, args)
+	if len(inputs) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	inputFile := inputs[0]
+	inputData, err := readInput(inputFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading input file: %s\n", err.Error())
+		return 1
+	}
+
+	if compileOutput == "" {
+		compileOutput = basename(inputFile) + bytecodeFileExt
+	}
+
+	if err := compileOnly(inputData, inputFile, compileOutput); err != nil {
+		printError(err, inputFile, inputData)
+		return 1
+	}
+
+	return 0
+}
+
+// doRun runs the bytecode file, or, the source file:
+//
+//	tengo run [flags] {input-file} [args...]
+//
+// The flags restrict the standard modules and the builtin functions, and,
+// limit the execution. The arguments after the input file are passed to the
+// script. It returns the exit code.
+func doRun(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	addSandboxFlags(fs)
+	addLimitFlags(fs)
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println()
+		fmt.Println("	tengo run [flags] {input-file} [args...]")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fmt.Println()
+		fs.SetOutput(os.Stdout)
+		fs.PrintDefaults()
+		fmt.Println()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if err := checkSandboxFlags(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+
+	inputFile := fs.Arg(0)
+	scriptArgs = fs.Args()
+
+	inputData, err := readInput(inputFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading input file: %s\n", err.Error())
+		return 1
+	}
+
+	if isSourceFile(inputFile, inputData) {
+		err = compileAndRun(inputData, inputFile)
+	} else {
+		err = runCompiled(inputData)
+	}
+	if err != nil {
+		printError(err, inputFile, inputData)
+		return exitCode(err)
+	}
+
+	return 0
+}
+
+// parseInterspersed parses the flags that can appear before or after the
+// other arguments, and, returns the other arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) (others []string) {
+	for {
+		_ = fs.Parse(args)
+		if fs.NArg() == 0 {
+			return
+		}
+
+		others = append(others, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/stdlib"
+)
+
+var importPrefix = regexp.MustCompile(`import\s*\(\s*"$`)
+
+// complete returns the sorted candidates for the word before the end of the
+// line, and, the position where the word starts. It completes the
+// meta-commands, the module names in the import expressions, the members of
+// the global maps (e.g. the imported modules) after a dot, and, the builtin
+// functions and the global variables defined so far otherwise.
+func (r *repl) complete(line []rune) (start int, candidates []string) {
+	if len(line) > 0 && line[0] == ':' && !strings.ContainsAny(string(line), " \t") {
+		for _, c := range replCommands {
+			if strings.HasPrefix(c.name, string(line)) {
+				candidates = append(candidates, c.name)
+			}
+		}
+
+		return 0, candidates
+	}
+
+	start = len(line)
+	for start > 0 && isWordChar(line[start-1]) {
+		start--
+	}
+
+	prefix := string(line[start:])
+	if prefix != "" && unicode.IsDigit(rune(prefix[0])) {
+		return start, nil
+	}
+
+	var names []string
+	switch before := string(line[:start]); {
+	case importPrefix.MatchString(before):
+		for name := range stdlib.Modules {
+			names = append(names, name)
+		}
+	case strings.HasSuffix(before, "."):
+		end := start - 1
+		identStart := end
+		for identStart > 0 && isWordChar(line[identStart-1]) {
+			identStart--
+		}
+		names = r.members(string(line[identStart:end]))
+	default:
+		names = r.symbolTable.Names()
+	}
+
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+
+	return start, candidates
+}
+
+// members returns the keys of the map stored in the global variable that
+// can be used in the selector expressions.
+func (r *repl) members(name string) []string {
+	symbol, _, ok := r.symbolTable.Resolve(name)
+	if !ok || symbol.Scope != compiler.ScopeGlobal || r.globals[symbol.Index] == nil {
+		return nil
+	}
+
+	var m map[string]objects.Object
+	switch obj := r.globals[symbol.Index].(type) {
+	case *objects.Map:
+		m = obj.Value
+	case *objects.ImmutableMap:
+		m = obj.Value
+	}
+
+	var keys []string
+	for key := range m {
+		if isIdent(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if !isWordChar(r) || (i == 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+
+	return s != ""
+}
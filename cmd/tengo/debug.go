@@ -0,0 +1,321 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+// debugCommands are the debugger commands, their short names and their
+// descriptions.
+var debugCommands = []struct {
+	name  string
+	short string
+	args  string
+	desc  string
+}{
+	{"break", "b", "[[file:]line]", "set a breakpoint, or, list the breakpoints"},
+	{"clear", "", "[file:]line", "remove the breakpoint"},
+	{"continue", "c", "", "run until the next breakpoint"},
+	{"step", "s", "", "run to the next line, stepping into the function calls"},
+	{"next", "n", "", "run to the next line, stepping over the function calls"},
+	{"out", "o", "", "run until the current function returns"},
+	{"print", "p", "expr", "print the value of the expression"},
+	{"locals", "l", "", "print the variables of the current function"},
+	{"stack", "bt", "", "print the call stack"},
+	{"quit", "q", "", "abort the script and exit"},
+	{"help", "h", "", "show the commands"},
+}
+
+// debugSession is the state of the debugger command loop.
+type debugSession struct {
+	in       lineReader
+	out      io.Writer
+	debugger *runtime.Debugger
+	filename string   // base name of the main source file
+	lines    []string // lines of the main source file
+	lastCmd  string
+	quit     bool
+}
+
+// doDebug runs the source file in the debugger:
+//
+//	tengo debug [flags] {input-file} [args...]
+//
+// The script stops before the first line, and, the debugger commands are
+// read from the standard input. It returns the exit code.
+func doDebug(args []string) int {
+	fs := flag.NewFlagSet("debug", flag.ExitOnError)
+	addSandboxFlags(fs)
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println()
+		fmt.Println("	tengo debug [flags] {input-file} [args...]")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fmt.Println()
+		fs.SetOutput(os.Stdout)
+		fs.PrintDefaults()
+		fmt.Println()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if err := checkSandboxFlags(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+
+	inputFile := fs.Arg(0)
+	scriptArgs = fs.Args()
+
+	inputData, err := readInput(inputFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading input file: %s\n", err.Error())
+		return 1
+	}
+
+	if !isSourceFile(inputFile, inputData) {
+		_, _ = fmt.Fprintf(os.Stderr, "Cannot debug bytecode file: %s\n", inputFile)
+		return 1
+	}
+
+	bytecode, err := compileSrc(inputData, inputFile)
+	if err != nil {
+		printError(err, inputFile, inputData)
+		return 1
+	}
+
+	d := &debugSession{
+		in:       newLineReader(os.Stdin, os.Stdout, nil),
+		out:      os.Stdout,
+		filename: filepath.Base(inputFile),
+		lines:    strings.Split(string(inputData), "\n"),
+	}
+	d.debugger = runtime.NewDebugger(d.stop)
+	d.debugger.Pause()
+
+	machine := runtime.NewVM(bytecode, nil, sandboxModules())
+	machine.SetDebugger(d.debugger)
+
+	if err := machine.Run(); err != nil && !(d.quit && err == runtime.ErrAborted) {
+		printError(err, inputFile, inputData)
+		return 1
+	}
+
+	if !d.quit {
+		_, _ = fmt.Fprintln(d.out, "script finished")
+	}
+
+	return 0
+}
+
+// stop shows where the script stopped, and, runs the debugger commands until
+// one of them continues the execution.
+func (d *debugSession) stop(state *runtime.DebugState) runtime.DebugAction {
+	pos := state.Position()
+	_, _ = fmt.Fprintf(d.out, "> %s:%d\n", pos.Filename, pos.Line)
+	if pos.Filename == d.filename && pos.Line <= len(d.lines) {
+		_, _ = fmt.Fprintf(d.out, "%4d\t%s\n", pos.Line, strings.TrimRight(d.lines[pos.Line-1], "\r"))
+	}
+
+	for {
+		line, err := d.in.readLine(debugPrompt)
+		if err == errInterrupted {
+			continue
+		} else if err != nil {
+			// end of the input
+			d.quit = true
+			state.Abort()
+			return runtime.DebugContinue
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// repeat the last command
+			line = d.lastCmd
+		} else {
+			d.in.addHistory(line)
+		}
+		d.lastCmd = line
+
+		if action, ok := d.command(state, line); ok {
+			return action
+		}
+	}
+}
+
+// command runs the debugger command. It returns true if the command continues
+// the execution.
+func (d *debugSession) command(state *runtime.DebugState, input string) (action runtime.DebugAction, ok bool) {
+	if input == "" {
+		return
+	}
+
+	name, arg := input, ""
+	if n := strings.IndexAny(input, " \t"); n >= 0 {
+		name, arg = input[:n], strings.TrimSpace(input[n:])
+	}
+
+	for _, c := range debugCommands {
+		if c.short == name {
+			name = c.name
+		}
+	}
+
+	switch name {
+	case "break":
+		if arg == "" {
+			for _, pos := range d.debugger.Breakpoints() {
+				_, _ = fmt.Fprintf(d.out, "%s:%d\n", pos.Filename, pos.Line)
+			}
+			return
+		}
+
+		if filename, line, err := d.parseLocation(arg); err != nil {
+			_, _ = fmt.Fprintf(d.out, "error: %s\n", err.Error())
+		} else {
+			d.debugger.SetBreakpoint(filename, line)
+		}
+	case "clear":
+		if arg == "" {
+			_, _ = fmt.Fprintln(d.out, "usage: clear [file:]line")
+		} else if filename, line, err := d.parseLocation(arg); err != nil {
+			_, _ = fmt.Fprintf(d.out, "error: %s\n", err.Error())
+		} else if !d.debugger.ClearBreakpoint(filename, line) {
+			_, _ = fmt.Fprintf(d.out, "no breakpoint at %s:%d\n", filename, line)
+		}
+	case "continue":
+		return runtime.DebugContinue, true
+	case "step":
+		return runtime.DebugStepIn, true
+	case "next":
+		return runtime.DebugStepOver, true
+	case "out":
+		return runtime.DebugStepOut, true
+	case "print":
+		if arg == "" {
+			_, _ = fmt.Fprintln(d.out, "usage: print expr")
+			return
+		}
+
+		value, err := evalDebugExpr(state, arg)
+		if err != nil {
+			_, _ = fmt.Fprintf(d.out, "error: %s\n", err.Error())
+			return
+		}
+		_, _ = fmt.Fprintln(d.out, value.String())
+	case "locals":
+		w := tabwriter.NewWriter(d.out, 0, 4, 2, ' ', 0)
+		for _, v := range state.Locals(0) {
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", v.Name, v.Value.String())
+		}
+		_ = w.Flush()
+	case "stack":
+		for i, pos := range state.Stack() {
+			_, _ = fmt.Fprintf(d.out, "#%d %s\n", i, pos)
+		}
+	case "quit":
+		d.quit = true
+		state.Abort()
+		return runtime.DebugContinue, true
+	case "help":
+		w := tabwriter.NewWriter(d.out, 0, 4, 2, ' ', 0)
+		for _, c := range debugCommands {
+			names := c.name
+			if c.short != "" {
+				names += ", " + c.short
+			}
+			_, _ = fmt.Fprintf(w, "%s %s\t%s\n", names, c.args, c.desc)
+		}
+		_ = w.Flush()
+	default:
+		_, _ = fmt.Fprintf(d.out, "unknown command: %s (help for the list of commands)\n", name)
+	}
+
+	return
+}
+
+// parseLocation parses the breakpoint location "[file:]line". The main
+// source file is used if the file is omitted.
+func (d *debugSession) parseLocation(s string) (filename string, line int, err error) {
+	filename = d.filename
+	if n := strings.LastIndexByte(s, ':'); n >= 0 {
+		filename, s = filepath.Base(s[:n]), s[n+1:]
+	}
+
+	line, err = strconv.Atoi(s)
+	if err != nil || line <= 0 {
+		return "", 0, fmt.Errorf("invalid line: %s", s)
+	}
+
+	return
+}
+
+// evalDebugExpr evaluates the expression in a separate VM where the variables
+// of the current function and the global variables are defined as the global
+// variables.
+func evalDebugExpr(state *runtime.DebugState, expr string) (objects.Object, error) {
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("debug", -1, len(expr))
+	parsed, err := parser.NewParser(srcFile, []byte(expr), nil).ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	for idx, fn := range objects.Builtins {
+		symbolTable.DefineBuiltin(idx, fn.Name)
+	}
+
+	globals := make([]objects.Object, runtime.GlobalsSize)
+	for _, v := range append(state.Globals(), state.Locals(0)...) {
+		// the local variables replace the global variables with the same name
+		symbol, _, ok := symbolTable.Resolve(v.Name)
+		if !ok || symbol.Scope != compiler.ScopeGlobal {
+			symbol = symbolTable.Define(v.Name)
+		}
+
+		globals[symbol.Index] = v.Value
+	}
+	result := symbolTable.Define(":result")
+
+	c := compiler.NewCompiler(srcFile, symbolTable, nil, nil, nil)
+	if err := c.Compile(&ast.File{
+		InputFile: srcFile,
+		Stmts: []ast.Stmt{&ast.AssignStmt{
+			LHS:   []ast.Expr{&ast.Ident{Name: ":result"}},
+			RHS:   []ast.Expr{parsed},
+			Token: token.Assign,
+		}},
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := runtime.NewVM(c.Bytecode(), globals, nil).Run(); err != nil {
+		return nil, err
+	}
+
+	if globals[result.Index] == nil {
+		return objects.UndefinedValue, nil
+	}
+
+	return globals[result.Index], nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	historyFileName = ".tengo_history"
+	historyEnvVar   = "TENGO_HISTORY"
+	maxHistory      = 1000
+)
+
+// history is the list of the lines entered in the REPL. The lines are
+// appended to the history file as they are entered, so the history persists
+// across the REPL sessions.
+type history struct {
+	entries []string
+	path    string
+}
+
+// historyPath returns the path of the history file: TENGO_HISTORY
+// environment variable if it's set, or, ".tengo_history" in the home
+// directory. It returns an empty string if the history should not be saved.
+func historyPath() string {
+	if path, ok := os.LookupEnv(historyEnvVar); ok {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, historyFileName)
+}
+
+// loadHistory reads the history file. The history is empty if the file does
+// not exist yet.
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, unescapeHistory(line))
+		}
+	}
+
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+
+	return h
+}
+
+// add appends the entry to the history unless it's blank or the same as the
+// last entry.
+func (h *history) add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	if h.path == "" {
+		return
+	}
+
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+
+	_, _ = f.WriteString(escapeHistory(entry) + "\n")
+	_ = f.Close()
+}
+
+// search returns the index of the latest entry before 'from' that contains
+// the query, or, -1 if there's none.
+func (h *history) search(query string, from int) int {
+	for i := from - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// escapeHistory escapes the backslashes and the newlines so each entry is
+// saved in a single line of the history file.
+func escapeHistory(entry string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(entry)
+}
+
+func unescapeHistory(line string) string {
+	var sb strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) {
+			i++
+			if line[i] == 'n' {
+				sb.WriteByte('\n')
+				continue
+			}
+		}
+		sb.WriteByte(line[i])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/d5/tengo/runtime"
+)
+
+// exit codes when the script exceeds the limits
+const (
+	exitTimeout          = 3
+	exitInstructionLimit = 4
+	exitMemoryLimit      = 5
+)
+
+var (
+	timeout   time.Duration
+	maxInsts  int64
+	maxMemory byteSize
+)
+
+var errTimeout = errors.New("timeout exceeded")
+
+// addLimitFlags defines the flags that limit the execution of the script.
+func addLimitFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&timeout, "timeout", 0, "Abort the script after the duration (e.g. 10s)")
+	fs.Int64Var(&maxInsts, "max-instructions", 0, "Abort the script after executing the number of instructions")
+	fs.Var(&maxMemory, "max-memory", "Abort the script when its values exceed the size (e.g. 64M)")
+}
+
+// byteSize is the number of bytes with an optional unit: K, M or G (the
+// powers of 1024).
+type byteSize uint64
+
+func (s *byteSize) String() string {
+	return strconv.FormatUint(uint64(*s), 10)
+}
+
+func (s *byteSize) Set(v string) error {
+	multiplier := uint64(1)
+	num := strings.TrimSuffix(strings.ToUpper(v), "B")
+	switch {
+	case strings.HasSuffix(num, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(num, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(num, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		num = num[:len(num)-1]
+	}
+
+	n, err := strconv.ParseUint(num, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid size: %s", v)
+	}
+	*s = byteSize(n * multiplier)
+
+	return nil
+}
+
+// runWithLimits runs the VM with the limits set by the flags. It returns
+// errTimeout if the script is aborted by the timeout.
+func runWithLimits(machine *runtime.VM) error {
+	machine.SetMaxInstructions(maxInsts)
+	machine.SetMaxMemory(uint64(maxMemory))
+
+	if timeout <= 0 {
+		return machine.Run()
+	}
+
+	var timedOut int32
+	timer := time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&timedOut, 1)
+		machine.Abort()
+	})
+	err := machine.Run()
+	timer.Stop()
+
+	if atomic.LoadInt32(&timedOut) != 0 {
+		return errTimeout
+	}
+
+	return err
+}
+
+// exitCode returns the exit code for the error of the script: the distinct
+// codes for the limits, and, 1 for the other errors.
+func exitCode(err error) int {
+	switch err {
+	case errTimeout:
+		return exitTimeout
+	case runtime.ErrInstructionLimit:
+		return exitInstructionLimit
+	case runtime.ErrMemoryLimit:
+		return exitMemoryLimit
+	default:
+		return 1
+	}
+}
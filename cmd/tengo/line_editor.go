@@ -0,0 +1,509 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// errInterrupted is returned by the line editor when Ctrl-C is pressed.
+var errInterrupted = errors.New("interrupted")
+
+// completeFunc returns the completion candidates for the word before the
+// end of the line, and, the position where the word starts.
+type completeFunc func(line []rune) (start int, candidates []string)
+
+// lineReader reads the lines of the REPL input.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+	addHistory(entry string)
+}
+
+// newLineReader returns the line editor if the input is a terminal, or, the
+// plain reader otherwise (e.g. the input is piped).
+func newLineReader(in io.Reader, out io.Writer, complete completeFunc) lineReader {
+	if f, ok := in.(*os.File); ok && isCharDevice(f) {
+		if restore, err := makeRaw(f); err == nil {
+			restore()
+			e := newLineEditor(f, out, loadHistory(historyPath()))
+			e.complete = complete
+			return e
+		}
+	}
+
+	return &plainReader{scanner: bufio.NewScanner(in), out: out}
+}
+
+// plainReader reads the lines without the editing.
+type plainReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r *plainReader) readLine(prompt string) (string, error) {
+	_, _ = io.WriteString(r.out, prompt)
+
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+
+	return r.scanner.Text(), nil
+}
+
+func (r *plainReader) addHistory(entry string) {}
+
+// lineEditor reads the lines from the terminal in the raw mode. The line
+// can contain the newlines (e.g. the multi-line history entries) shown on
+// the rows after the continuation prompt. It supports the cursor movement, the editing keys (Emacs-style), the history
+// navigation with the arrow keys, the incremental history search with
+// Ctrl-R, and, the completion with Tab.
+type lineEditor struct {
+	term     *os.File
+	in       *bufio.Reader
+	out      io.Writer
+	history  *history
+	complete completeFunc
+	prompt   string
+	buf      []rune
+	pos      int
+	histIdx  int    // index of the history entry shown, len(entries) for the new line
+	saved    []rune // the new line while the history entries are shown
+	pending  rune   // key to process before reading the next one (0 if none)
+	row      int    // row of the cursor relative to the prompt row
+}
+
+func newLineEditor(term *os.File, out io.Writer, h *history) *lineEditor {
+	return &lineEditor{
+		term:    term,
+		in:      bufio.NewReader(term),
+		out:     out,
+		history: h,
+	}
+}
+
+// readLine reads a line with the prompt. It returns io.EOF if Ctrl-D is
+// pressed on an empty line, and, errInterrupted if Ctrl-C is pressed.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(e.term)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	e.prompt = prompt
+	e.buf = nil
+	e.pos = 0
+	e.histIdx = len(e.history.entries)
+	e.saved = nil
+	e.row = 0
+	e.refresh()
+
+	for {
+		r, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case keyEnter, '\n':
+			e.pos = len(e.buf)
+			e.refresh()
+			e.print("\r\n")
+			return string(e.buf), nil
+		case keyCtrlC:
+			e.pos = len(e.buf)
+			e.refresh()
+			e.print("^C\r\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(e.buf) == 0 {
+				e.print("\r\n")
+				return "", io.EOF
+			}
+			e.deleteChar()
+		case keyCtrlA:
+			e.pos = 0
+		case keyCtrlE:
+			e.pos = len(e.buf)
+		case keyCtrlB:
+			e.moveLeft()
+		case keyCtrlF:
+			e.moveRight()
+		case keyCtrlH, keyBackspace:
+			if e.pos > 0 {
+				e.pos--
+				e.deleteChar()
+			}
+		case keyCtrlK:
+			e.buf = e.buf[:e.pos]
+		case keyCtrlU:
+			e.buf = append([]rune{}, e.buf[e.pos:]...)
+			e.pos = 0
+		case keyCtrlW:
+			start := e.wordStart()
+			e.buf = append(e.buf[:start], e.buf[e.pos:]...)
+			e.pos = start
+		case keyCtrlL:
+			e.print("\x1b[H\x1b[2J")
+			e.row = 0
+		case keyTab:
+			e.completeWord()
+		case keyCtrlP:
+			e.historyPrev()
+		case keyCtrlN:
+			e.historyNext()
+		case keyCtrlR:
+			accepted, err := e.reverseSearch()
+			if err != nil {
+				return "", err
+			}
+			if accepted {
+				e.pending = keyEnter
+			}
+		case keyEscape:
+			if err := e.escape(); err != nil {
+				return "", err
+			}
+		default:
+			if r >= ' ' {
+				e.insert(r)
+			}
+		}
+
+		e.refresh()
+	}
+}
+
+func (e *lineEditor) readKey() (rune, error) {
+	if r := e.pending; r != 0 {
+		e.pending = 0
+		return r, nil
+	}
+
+	r, _, err := e.in.ReadRune()
+
+	return r, err
+}
+
+// escape handles the escape sequences of the arrow keys and the other
+// special keys, and, the Alt key combinations.
+func (e *lineEditor) escape() error {
+	r, err := e.readKey()
+	if err != nil {
+		return err
+	}
+
+	switch r {
+	case 'b':
+		e.pos = e.wordStart()
+		return nil
+	case 'f':
+		e.pos = e.wordEnd()
+		return nil
+	case '[', 'O':
+	default:
+		return nil
+	}
+
+	var seq []rune
+	for {
+		r, err := e.readKey()
+		if err != nil {
+			return err
+		}
+
+		seq = append(seq, r)
+		if r < '0' || r > '9' {
+			break
+		}
+	}
+
+	switch string(seq) {
+	case "A":
+		e.historyPrev()
+	case "B":
+		e.historyNext()
+	case "C":
+		e.moveRight()
+	case "D":
+		e.moveLeft()
+	case "H", "1~", "7~":
+		e.pos = 0
+	case "F", "4~", "8~":
+		e.pos = len(e.buf)
+	case "3~":
+		e.deleteChar()
+	}
+
+	return nil
+}
+
+// reverseSearch searches the history incrementally as the query is typed.
+// Ctrl-R finds the next older match. Enter runs the match, Ctrl-G or Ctrl-C
+// cancels the search, and, the other keys stop the search leaving the match
+// for editing. It returns true if the match should be run.
+func (e *lineEditor) reverseSearch() (bool, error) {
+	var query []rune
+	match := -1
+	for {
+		var found string
+		if match >= 0 {
+			found = e.history.entries[match]
+		}
+		e.clear()
+		e.print(fmt.Sprintf("(reverse-i-search)`%s': %s\x1b[K",
+			string(query), strings.Replace(found, "\n", " ", -1)))
+
+		r, err := e.readKey()
+		if err != nil {
+			return false, err
+		}
+
+		switch r {
+		case keyCtrlR:
+			from := match
+			if from < 0 {
+				from = len(e.history.entries)
+			}
+			if m := e.history.search(string(query), from); m >= 0 {
+				match = m
+			}
+		case keyCtrlH, keyBackspace:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match = e.history.search(string(query), len(e.history.entries))
+			}
+		case keyCtrlG, keyCtrlC:
+			return false, nil
+		default:
+			if r >= ' ' {
+				query = append(query, r)
+
+				// the current match is kept if it contains the longer query
+				from := match + 1
+				if match < 0 {
+					from = len(e.history.entries)
+				}
+				match = e.history.search(string(query), from)
+				continue
+			}
+
+			if match >= 0 {
+				e.buf = []rune(found)
+				e.pos = len(e.buf)
+				e.histIdx = match
+			}
+
+			if r == keyEnter || r == '\n' {
+				return true, nil
+			}
+
+			// process the key as usual
+			e.pending = r
+
+			return false, nil
+		}
+	}
+}
+
+// completeWord completes the word before the cursor. If there are multiple
+// candidates, their common prefix is inserted, or, the candidates are listed
+// if the word is the common prefix already.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+
+	start, candidates := e.complete(e.buf[:e.pos])
+	if len(candidates) == 0 {
+		e.print("\a")
+		return
+	}
+
+	prefix := []rune(candidates[0])
+	for _, c := range candidates[1:] {
+		prefix = commonPrefix(prefix, []rune(c))
+	}
+
+	if string(prefix) != string(e.buf[start:e.pos]) {
+		rest := append(prefix, e.buf[e.pos:]...)
+		e.buf = append(e.buf[:start], rest...)
+		e.pos = start + len(prefix)
+		return
+	}
+
+	if len(candidates) > 1 {
+		e.pos = len(e.buf)
+		e.refresh()
+		e.print("\r\n" + strings.Join(candidates, "  ") + "\r\n")
+		e.row = 0
+	}
+}
+
+func (e *lineEditor) historyPrev() {
+	if e.histIdx == 0 {
+		return
+	}
+
+	if e.histIdx == len(e.history.entries) {
+		e.saved = e.buf
+	}
+
+	e.histIdx--
+	e.buf = []rune(e.history.entries[e.histIdx])
+	e.pos = len(e.buf)
+}
+
+func (e *lineEditor) historyNext() {
+	if e.histIdx >= len(e.history.entries) {
+		return
+	}
+
+	e.histIdx++
+	if e.histIdx == len(e.history.entries) {
+		e.buf = e.saved
+	} else {
+		e.buf = []rune(e.history.entries[e.histIdx])
+	}
+	e.pos = len(e.buf)
+}
+
+func (e *lineEditor) insert(r rune) {
+	e.buf = append(e.buf, 0)
+	copy(e.buf[e.pos+1:], e.buf[e.pos:])
+	e.buf[e.pos] = r
+	e.pos++
+}
+
+func (e *lineEditor) deleteChar() {
+	if e.pos < len(e.buf) {
+		e.buf = append(e.buf[:e.pos], e.buf[e.pos+1:]...)
+	}
+}
+
+func (e *lineEditor) moveLeft() {
+	if e.pos > 0 {
+		e.pos--
+	}
+}
+
+func (e *lineEditor) moveRight() {
+	if e.pos < len(e.buf) {
+		e.pos++
+	}
+}
+
+// wordStart returns the position of the start of the word before the
+// cursor.
+func (e *lineEditor) wordStart() int {
+	pos := e.pos
+	for pos > 0 && !isWordChar(e.buf[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordChar(e.buf[pos-1]) {
+		pos--
+	}
+
+	return pos
+}
+
+// wordEnd returns the position of the end of the word after the cursor.
+func (e *lineEditor) wordEnd() int {
+	pos := e.pos
+	for pos < len(e.buf) && !isWordChar(e.buf[pos]) {
+		pos++
+	}
+	for pos < len(e.buf) && isWordChar(e.buf[pos]) {
+		pos++
+	}
+
+	return pos
+}
+
+// addHistory adds the entry to the history.
+func (e *lineEditor) addHistory(entry string) {
+	e.history.add(entry)
+}
+
+// refresh redraws the line and moves the cursor to its position.
+func (e *lineEditor) refresh() {
+	e.clear()
+
+	lines := strings.Split(string(e.buf), "\n")
+	e.print(e.prompt + strings.Join(lines, "\r\n"+replContPrompt))
+
+	// move the cursor from the end of the line
+	row, col := 0, len(e.prompt)
+	for _, r := range e.buf[:e.pos] {
+		if r == '\n' {
+			row++
+			col = len(replContPrompt)
+		} else {
+			col++
+		}
+	}
+
+	if n := len(lines) - 1 - row; n > 0 {
+		e.print(fmt.Sprintf("\x1b[%dA", n))
+	}
+	e.print("\r")
+	if col > 0 {
+		e.print(fmt.Sprintf("\x1b[%dC", col))
+	}
+	e.row = row
+}
+
+// clear moves the cursor to the prompt row, and, clears the line.
+func (e *lineEditor) clear() {
+	if e.row > 0 {
+		e.print(fmt.Sprintf("\x1b[%dA", e.row))
+		e.row = 0
+	}
+	e.print("\r\x1b[J")
+}
+
+func (e *lineEditor) print(s string) {
+	_, _ = io.WriteString(e.out, s)
+}
+
+func commonPrefix(a, b []rune) []rune {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+
+	return a[:n]
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -1,14 +1,15 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/d5/tengo/compiler"
@@ -20,25 +21,76 @@ import (
 )
 
 const (
-	sourceFileExt = ".tengo"
-	replPrompt    = ">> "
+	sourceFileExt   = ".tengo"
+	bytecodeFileExt = ".tbc"
+	stdinFile       = "-"
+	replPrompt      = ">> "
+	replContPrompt  = "... "
+	debugPrompt     = "(debug) "
 )
 
 var (
 	compileOutput string
+	showCoverage  bool
+	disassemble   bool
+	lintOnly      bool
+	showProfile   bool
 	showHelp      bool
 	showVersion   bool
+	stripDebug    bool
+	constants     = make(constantFlags)
+	scriptArgs    []string // arguments returned by os.args()
 	version       = "dev"
 )
 
+// constantFlags are the host constants defined with the -D flags.
+type constantFlags map[string]objects.Object
+
+func (f constantFlags) String() string {
+	return ""
+}
+
+// Set parses the constant definition "name=value". The value is an int, a
+// float or a bool if it can be parsed as one, and, a string otherwise.
+func (f constantFlags) Set(s string) error {
+	idx := strings.Index(s, "=")
+	if idx <= 0 {
+		return fmt.Errorf("invalid constant definition: %s", s)
+	}
+
+	name, value := s[:idx], s[idx+1:]
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		f[name] = &objects.Int{Value: i}
+	} else if fl, err := strconv.ParseFloat(value, 64); err == nil {
+		f[name] = &objects.Float{Value: fl}
+	} else if value == "true" {
+		f[name] = objects.TrueValue
+	} else if value == "false" {
+		f[name] = objects.FalseValue
+	} else {
+		f[name] = &objects.String{Value: value}
+	}
+
+	return nil
+}
+
 func init() {
 	flag.BoolVar(&showHelp, "help", false, "Show help")
+	flag.Var(constants, "D", "Define compile-time constant (name=value)")
 	flag.StringVar(&compileOutput, "o", "", "Compile output file")
+	flag.BoolVar(&showCoverage, "cover", false, "Report line coverage after running source file")
+	flag.BoolVar(&disassemble, "dis", false, "Disassemble input file")
+	flag.BoolVar(&lintOnly, "lint", false, "Report lint issues in source file")
+	flag.BoolVar(&showProfile, "profile", false, "Report execution profile after running source file")
+	flag.BoolVar(&stripDebug, "strip", false, "Strip debug information from compiled output")
 	flag.BoolVar(&showVersion, "version", false, "Show version")
-	flag.Parse()
+	addSandboxFlags(flag.CommandLine)
+	addLimitFlags(flag.CommandLine)
 }
 
 func main() {
+	flag.Parse()
+
 	if showHelp {
 		doHelp()
 		os.Exit(2)
@@ -47,47 +99,139 @@ func main() {
 		return
 	}
 
-	inputFile := flag.Arg(0)
-	if inputFile == "" {
+	if err := checkSandboxFlags(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	switch flag.Arg(0) {
+	case "":
 		// REPL
 		runREPL(os.Stdin, os.Stdout)
 		return
+	case "build":
+		os.Exit(doBuild(flag.Args()[1:]))
+	case "run":
+		os.Exit(doRun(flag.Args()[1:]))
+	case "test":
+		os.Exit(doTest(flag.Args()[1:]))
+	case "bench":
+		os.Exit(doBench(flag.Args()[1:]))
+	case "debug":
+		os.Exit(doDebug(flag.Args()[1:]))
 	}
 
-	inputData, err := ioutil.ReadFile(inputFile)
+	inputFile := flag.Arg(0)
+	scriptArgs = flag.Args()
+
+	inputData, err := readInput(inputFile)
 	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Error reading input file: %s", err.Error())
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading input file: %s\n", err.Error())
 		os.Exit(1)
 	}
 
-	if compileOutput != "" {
+	if disassemble {
+		if err := doDisassemble(inputData, inputFile); err != nil {
+			printError(err, inputFile, inputData)
+			os.Exit(1)
+		}
+	} else if lintOnly {
+		numIssues, err := doLint(inputData, inputFile)
+		if err != nil {
+			printError(err, inputFile, inputData)
+			os.Exit(1)
+		}
+		if numIssues > 0 {
+			os.Exit(1)
+		}
+	} else if compileOutput != "" {
 		if err := compileOnly(inputData, inputFile, compileOutput); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			printError(err, inputFile, inputData)
 			os.Exit(1)
 		}
-	} else if filepath.Ext(inputFile) == sourceFileExt {
+	} else if isSourceFile(inputFile, inputData) {
 		if err := compileAndRun(inputData, inputFile); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
+			printError(err, inputFile, inputData)
+			os.Exit(exitCode(err))
 		}
 	} else {
 		if err := runCompiled(inputData); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
+			printError(err, inputFile, inputData)
+			os.Exit(exitCode(err))
 		}
 	}
 }
 
+// printError prints the error to the standard error. The positioned errors
+// are printed with the excerpt of the source code, and, the colors are used
+// if the standard error is a terminal.
+func printError(err error, inputFile string, inputData []byte) {
+	formatter := &source.ErrorFormatter{
+		Color: isTerminal(os.Stderr),
+		Source: func(filename string) ([]byte, error) {
+			if filename == filepath.Base(inputFile) {
+				return inputData, nil
+			}
+
+			// user modules
+			if data, err := ioutil.ReadFile(filename); err == nil {
+				return data, nil
+			}
+
+			return ioutil.ReadFile(filename + sourceFileExt)
+		},
+	}
+
+	_, _ = fmt.Fprintln(os.Stderr, formatter.Format(err))
+}
+
+func isTerminal(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	return isCharDevice(f)
+}
+
+func isCharDevice(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 func doHelp() {
 	fmt.Println("Usage:")
 	fmt.Println()
-	fmt.Println("	tengo [flags] {input-file}")
+	fmt.Println("	tengo [flags] {input-file} [args...]")
+	fmt.Println("	tengo build [flags] {input-file}")
+	fmt.Println("	tengo run {input-file} [args...]")
+	fmt.Println("	tengo test [-v] [-cover] [path ...]")
+	fmt.Println("	tengo bench [-benchtime d] [path ...]")
+	fmt.Println("	tengo debug {input-file} [args...]")
 	fmt.Println()
 	fmt.Println("Flags:")
 	fmt.Println()
+	fmt.Println("	-D        define compile-time constant (name=value)")
 	fmt.Println("	-o        compile output file")
+	fmt.Println("	-cover    report line coverage after running source file")
+	fmt.Println("	-dis      disassemble input file")
+	fmt.Println("	-lint     report lint issues in source file")
+	fmt.Println("	-profile  report execution profile after running source file")
+	fmt.Println("	-strip    strip debug information from compiled output")
 	fmt.Println("	-version  show version")
 	fmt.Println()
+	fmt.Println("	-no-os                  disable os module")
+	fmt.Println("	-no-exec                disable the functions of os module that run processes")
+	fmt.Println("	-allow module,...       allow only the listed standard modules")
+	fmt.Println("	-no-builtin func,...    disable the listed builtin functions")
+	fmt.Println()
+	fmt.Println("	-timeout d              abort the script after the duration (exit code 3)")
+	fmt.Println("	-max-instructions n     abort the script after n instructions (exit code 4)")
+	fmt.Println("	-max-memory size        abort the script when its values exceed the size (exit code 5)")
+	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println()
 	fmt.Println("	tengo")
@@ -103,19 +247,111 @@ func doHelp() {
 	fmt.Println()
 	fmt.Println("	          Compile source file (myapp.tengo) into bytecode file (myapp)")
 	fmt.Println()
+	fmt.Println("	tengo build myapp.tengo")
+	fmt.Println()
+	fmt.Println("	          Compile source file (myapp.tengo) into bytecode file (myapp.tbc)")
+	fmt.Println()
+	fmt.Println("	tengo run myapp.tbc foo bar")
+	fmt.Println()
+	fmt.Println("	          Run bytecode file (myapp.tbc) with the arguments")
+	fmt.Println()
+	fmt.Println("	tengo test ./lib")
+	fmt.Println()
+	fmt.Println("	          Run the tests in the test files (*_test.tengo) in lib directory")
+	fmt.Println()
+	fmt.Println("	tengo bench -benchtime 3s ./lib")
+	fmt.Println()
+	fmt.Println("	          Run the benchmarks in the test files (*_test.tengo) in lib directory")
+	fmt.Println()
+	fmt.Println("	tengo debug myapp.tengo")
+	fmt.Println()
+	fmt.Println("	          Run source file (myapp.tengo) in the debugger that stops before")
+	fmt.Println("	          the first line (type help for the debugger commands)")
+	fmt.Println()
+	fmt.Println("	tengo -strip -o myapp myapp.tengo")
+	fmt.Println()
+	fmt.Println("	          Compile source file (myapp.tengo) into smaller bytecode file (myapp)")
+	fmt.Println("	          without the source positions of the runtime errors")
+	fmt.Println()
+	fmt.Println("	tengo -D env=prod -D debug=false myapp.tengo")
+	fmt.Println()
+	fmt.Println("	          Compile and run source file (myapp.tengo) with the constants")
+	fmt.Println("	          'env' and 'debug' substituted at compile time")
+	fmt.Println()
+	fmt.Println("	tengo -no-exec -allow text,math,os third_party.tengo")
+	fmt.Println()
+	fmt.Println("	          Compile and run source file (third_party.tengo) that can import")
+	fmt.Println("	          only text, math and os modules, and, cannot run the processes")
+	fmt.Println()
+	fmt.Println("	tengo -timeout 10s -max-memory 64M third_party.tengo")
+	fmt.Println()
+	fmt.Println("	          Compile and run source file (third_party.tengo), and, abort it")
+	fmt.Println("	          if it runs longer than 10 seconds or uses more than 64 MiB")
+	fmt.Println()
+	fmt.Println("	tengo -cover myapp.tengo")
+	fmt.Println()
+	fmt.Println("	          Compile and run source file (myapp.tengo), and, report the")
+	fmt.Println("	          source lines that were not executed")
+	fmt.Println()
+	fmt.Println("	tengo -profile myapp.tengo")
+	fmt.Println()
+	fmt.Println("	          Compile and run source file (myapp.tengo), and, report the time")
+	fmt.Println("	          spent in each function and source line")
+	fmt.Println()
+	fmt.Println("	tengo myapp.tengo foo bar")
+	fmt.Println()
+	fmt.Println("	          Compile and run source file (myapp.tengo) with the arguments")
+	fmt.Println("	          (os.args() returns [\"myapp.tengo\", \"foo\", \"bar\"])")
+	fmt.Println()
 	fmt.Println("	tengo myapp")
 	fmt.Println()
-	fmt.Println("	          Run bytecode file (myapp)")
+	fmt.Println("	          Run bytecode file (myapp), or, source file (myapp) that starts")
+	fmt.Println("	          with the interpreter line (#!/usr/bin/env tengo)")
+	fmt.Println()
+	fmt.Println("	tengo - < myapp.tengo")
+	fmt.Println()
+	fmt.Println("	          Compile and run source code read from the standard input")
+	fmt.Println()
+	fmt.Println("	tengo -dis myapp.tengo")
+	fmt.Println()
+	fmt.Println("	          Print annotated bytecode listing of source or bytecode file")
 	fmt.Println()
+	fmt.Println("	tengo -lint myapp.tengo")
 	fmt.Println()
+	fmt.Println("	          Report lint issues in source file (myapp.tengo) without running it")
+	fmt.Println()
+	fmt.Println()
+}
+
+// readInput reads the input file, or, the standard input if the file name is
+// "-".
+func readInput(inputFile string) ([]byte, error) {
+	if inputFile == stdinFile {
+		return ioutil.ReadAll(os.Stdin)
+	}
+
+	return ioutil.ReadFile(inputFile)
+}
+
+// isSourceFile returns true if the input is the source code: the standard
+// input, the file with .tengo extension, or, the executable script that
+// starts with the interpreter line.
+func isSourceFile(inputFile string, data []byte) bool {
+	return inputFile == stdinFile ||
+		filepath.Ext(inputFile) == sourceFileExt ||
+		bytes.HasPrefix(data, []byte("#!"))
 }
 
 func compileOnly(data []byte, inputFile, outputFile string) (err error) {
-	bytecode, err := compileSrc(data, filepath.Base(inputFile))
+	bytecode, err := compileSrc(data, inputFile)
 	if err != nil {
 		return
 	}
 
+	if stripDebug {
+		bytecode.StripDebugInfo()
+	}
+
 	if outputFile == "" {
 		outputFile = basename(inputFile) + ".out"
 	}
@@ -142,32 +378,74 @@ func compileOnly(data []byte, inputFile, outputFile string) (err error) {
 	return
 }
 
-func compileAndRun(data []byte, inputFile string) (err error) {
-	bytecode, err := compileSrc(data, filepath.Base(inputFile))
+func doDisassemble(data []byte, inputFile string) (err error) {
+	var bytecode *compiler.Bytecode
+	if isSourceFile(inputFile, data) {
+		bytecode, err = compileSrc(data, inputFile)
+	} else {
+		bytecode = &compiler.Bytecode{}
+		err = bytecode.Decode(bytes.NewReader(data))
+	}
 	if err != nil {
 		return
 	}
 
-	machine := runtime.NewVM(bytecode, nil, nil)
+	fmt.Print(compiler.Disassemble(bytecode))
 
-	err = machine.Run()
+	return
+}
+
+func doLint(data []byte, inputFile string) (numIssues int, err error) {
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile(filepath.Base(inputFile), -1, len(data))
+
+	p := parser.NewParser(srcFile, data, nil)
+	file, err := p.ParseFile()
 	if err != nil {
 		return
 	}
 
-	return
+	c := compiler.NewCompiler(srcFile, nil, nil, nil, nil)
+	c.EnableLint(true)
+	c.EnableTypeCheck(true)
+	if err = defineConstants(c); err != nil {
+		return
+	}
+	if err = c.Compile(file); err != nil {
+		return
+	}
+
+	for _, issue := range c.LintIssues() {
+		fmt.Println(issue)
+	}
+	for _, d := range c.Diagnostics() {
+		fmt.Println(d)
+	}
+
+	return len(c.LintIssues()) + len(c.Diagnostics()), nil
 }
 
-func runCompiled(data []byte) (err error) {
-	bytecode := &compiler.Bytecode{}
-	err = bytecode.Decode(bytes.NewReader(data))
+func compileAndRun(data []byte, inputFile string) (err error) {
+	bytecode, err := compileSrc(data, inputFile)
 	if err != nil {
 		return
 	}
 
-	machine := runtime.NewVM(bytecode, nil, nil)
+	machine := runtime.NewVM(bytecode, nil, sandboxModules())
 
-	err = machine.Run()
+	if showCoverage {
+		coverage := runtime.NewCoverage()
+		machine.SetCoverage(coverage)
+		defer printCoverage(coverage)
+	}
+
+	if showProfile {
+		profile := runtime.NewProfile()
+		machine.SetProfile(profile)
+		defer func() { _ = profile.WriteText(os.Stderr) }()
+	}
+
+	err = runWithLimits(machine)
 	if err != nil {
 		return
 	}
@@ -175,63 +453,74 @@ func runCompiled(data []byte) (err error) {
 	return
 }
 
-func runREPL(in io.Reader, out io.Writer) {
-	stdin := bufio.NewScanner(in)
-
-	fileSet := source.NewFileSet()
-	globals := make([]*objects.Object, runtime.GlobalsSize)
-
-	symbolTable := compiler.NewSymbolTable()
-	for idx, fn := range objects.Builtins {
-		symbolTable.DefineBuiltin(idx, fn.Name)
+// printCoverage prints the percentage of the lines executed and the lines that
+// were not executed for each source file.
+func printCoverage(coverage *runtime.Coverage) {
+	for _, f := range coverage.Report() {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %.1f%% of lines covered (%d/%d)\n",
+			f.Filename, f.Percent(), f.Covered(), len(f.Lines))
+
+		var uncovered []string
+		for _, l := range f.Lines {
+			if l.Count == 0 {
+				uncovered = append(uncovered, strconv.Itoa(l.Line))
+			}
+		}
+		if len(uncovered) > 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "    not covered: %s\n", strings.Join(uncovered, ", "))
+		}
 	}
+}
 
-	var constants []objects.Object
-
-	for {
-		_, _ = fmt.Fprintf(out, replPrompt)
-
-		scanned := stdin.Scan()
-		if !scanned {
-			return
-		}
+func runCompiled(data []byte) (err error) {
+	if noBuiltins != "" {
+		// the builtin functions are resolved at compile time
+		return errors.New("cannot disable builtin functions of bytecode file")
+	}
 
-		line := stdin.Text()
+	bytecode := &compiler.Bytecode{}
+	err = bytecode.Decode(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
 
-		srcFile := fileSet.AddFile("repl", -1, len(line))
-		file, err := parser.ParseFile(srcFile, []byte(line), nil)
-		if err != nil {
-			_, _ = fmt.Fprintf(out, "error: %s\n", err.Error())
-			continue
-		}
+	machine := runtime.NewVM(bytecode, nil, sandboxModules())
 
-		file = addPrints(file)
+	err = runWithLimits(machine)
+	if err != nil {
+		return
+	}
 
-		c := compiler.NewCompiler(srcFile, symbolTable, constants, nil, nil)
-		if err := c.Compile(file); err != nil {
-			_, _ = fmt.Fprintf(out, "Compilation error:\n %s\n", err.Error())
-			continue
-		}
+	return
+}
 
-		bytecode := c.Bytecode()
+func runREPL(in io.Reader, out io.Writer) {
+	r := newREPL(out)
+	stdin := newLineReader(in, out, r.complete)
 
-		machine := runtime.NewVM(bytecode, globals, nil)
-		if err != nil {
-			_, _ = fmt.Fprintf(out, "VM error:\n %s\n", err.Error())
-			continue
+	for {
+		line, err := stdin.readLine(replPrompt)
+		for err == nil && isIncomplete(line) {
+			var next string
+			next, err = stdin.readLine(replContPrompt)
+			line += "\n" + next
 		}
-		if err := machine.Run(); err != nil {
-			_, _ = fmt.Fprintf(out, "Execution error:\n %s\n", err.Error())
+		if err == errInterrupted {
 			continue
+		} else if err != nil {
+			return
 		}
 
-		constants = bytecode.Constants
+		stdin.addHistory(line)
+		r.run(line)
 	}
 }
 
-func compileSrc(src []byte, filename string) (*compiler.Bytecode, error) {
+// compileSrc compiles the source file. The user modules are read relative to
+// the directory of the source file, and, they are compiled into the bytecode.
+func compileSrc(src []byte, inputFile string) (*compiler.Bytecode, error) {
 	fileSet := source.NewFileSet()
-	srcFile := fileSet.AddFile(filename, -1, len(src))
+	srcFile := fileSet.AddFile(filepath.Base(inputFile), -1, len(src))
 
 	p := parser.NewParser(srcFile, src, nil)
 	file, err := p.ParseFile()
@@ -239,7 +528,12 @@ func compileSrc(src []byte, filename string) (*compiler.Bytecode, error) {
 		return nil, err
 	}
 
-	c := compiler.NewCompiler(srcFile, nil, nil, nil, nil)
+	c := compiler.NewCompiler(srcFile, sandboxSymbolTable(), nil, sandboxModuleNames(), nil)
+	c.EnableOptimizer(true)
+	c.SetImportDir(filepath.Dir(inputFile))
+	if err := defineConstants(c); err != nil {
+		return nil, err
+	}
 	if err := c.Compile(file); err != nil {
 		return nil, err
 	}
@@ -247,6 +541,16 @@ func compileSrc(src []byte, filename string) (*compiler.Bytecode, error) {
 	return c.Bytecode(), nil
 }
 
+func defineConstants(c *compiler.Compiler) error {
+	for name, value := range constants {
+		if err := c.DefineConstant(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func addPrints(file *ast.File) *ast.File {
 	var stmts []ast.Stmt
 	for _, s := range file.Stmts {
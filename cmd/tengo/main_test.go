@@ -0,0 +1,103 @@
+//go:build !tengo_embedded && !tinygo
+// +build !tengo_embedded,!tinygo
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+)
+
+// TestMain runs the test binary as tengo CLI when TENGO_TEST_CLI is set, so
+// the tests can run the CLI with the flags in a separate process.
+func TestMain(m *testing.M) {
+	if os.Getenv("TENGO_TEST_CLI") == "1" {
+		main()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// runCLI runs tengo CLI with the arguments, and, returns its combined output.
+func runCLI(t *testing.T, args ...string) (string, error) {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "TENGO_TEST_CLI=1")
+	out, err := cmd.CombinedOutput()
+
+	return string(out), err
+}
+
+// writeScript writes the script to the directory, and, returns its path.
+func writeScript(t *testing.T, dir, name, src string) string {
+	filename := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(src), 0644))
+
+	return filename
+}
+
+func TestCLINoExec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tengo-cli")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	script := writeScript(t, dir, "exec.tengo", `
+os := import("os")
+print(is_undefined(os.exec), is_undefined(os.start_process), is_undefined(os.getenv))
+`)
+
+	out, err := runCLI(t, script)
+	assert.NoError(t, err, out)
+	assert.Equal(t, "false\nfalse\nfalse\n", out)
+
+	out, err = runCLI(t, "-no-exec", script)
+	assert.NoError(t, err, out)
+	assert.Equal(t, "true\ntrue\nfalse\n", out)
+
+	out, err = runCLI(t, "-no-os", "-no-exec", writeScript(t, dir, "noos.tengo", `print(1)`))
+	assert.NoError(t, err, out)
+	assert.Equal(t, "1\n", out)
+}
+
+func TestCLITestCover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tengo-cli")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	writeScript(t, dir, "math.tengo", `
+export {
+	add: func(a, b) {
+		return a + b
+	},
+	sub: func(a, b) {
+		return a - b
+	}
+}
+`)
+	writeScript(t, dir, "math_test.tengo", `
+math := import("./math")
+
+test("add", func() {
+	expect(math.add(1, 2) == 3)
+})
+`)
+
+	out, err := runCLI(t, "test", dir)
+	assert.NoError(t, err, out)
+	assert.False(t, strings.Contains(out, "covered"), out)
+
+	out, err = runCLI(t, "test", "-cover", dir)
+	assert.NoError(t, err, out)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Equal(t, 4, len(lines), out)
+	assert.True(t, strings.HasPrefix(lines[0], "ok\t"+filepath.Join(dir, "math_test.tengo")), out)
+	assert.True(t, strings.HasSuffix(lines[1], "math.tengo: 80.0% of lines covered (4/5)"), out)
+	assert.Equal(t, "    not covered: 7", lines[2])
+	assert.True(t, strings.HasSuffix(lines[3], "math_test.tengo: 100.0% of lines covered (3/3)"), out)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/d5/tengo/compiler/scanner"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+)
+
+// isIncomplete returns true if the REPL input has the unclosed brackets, or,
+// the unterminated raw string or block comment, so it continues on the next
+// line.
+func isIncomplete(src string) bool {
+	var unterminated bool
+	file := source.NewFileSet().AddFile("repl", -1, len(src))
+	s := scanner.NewScanner(file, []byte(src), func(_ source.FilePos, msg string) {
+		if msg == "raw string literal not terminated" || msg == "comment not terminated" {
+			unterminated = true
+		}
+	}, scanner.DontInsertSemis)
+
+	depth := 0
+	for {
+		tok, _, _ := s.Scan()
+		switch tok {
+		case token.EOF:
+			return unterminated || depth > 0
+		case token.LParen, token.LBrack, token.LBrace:
+			depth++
+		case token.RParen, token.RBrack, token.RBrace:
+			depth--
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build tengo_passwd && !tengo_no_passwd
+// +build tengo_passwd,!tengo_no_passwd
+
+package main
+
+import "github.com/d5/tengo/stdlib"
+
+// the CLI built with the build tag "tengo_passwd" enables passwd module, so
+// the scripts can import it unless it's disabled by -allow flag.
+func init() {
+	stdlib.Register("passwd", stdlib.PasswdModule)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+// replCommands are the REPL meta-commands and their descriptions.
+var replCommands = []struct {
+	name string
+	args string
+	desc string
+}{
+	{":load", "file", "run the script file in the current session"},
+	{":reset", "", "remove all the variables"},
+	{":vars", "", "list the global variables and their types"},
+	{":type", "expr", "show the type of the expression"},
+	{":time", "stmts", "run the statements and show the elapsed time"},
+	{":help", "", "show the commands"},
+}
+
+// repl is the REPL session. The global variables and the constants persist
+// across the inputs until the session is reset.
+type repl struct {
+	out         io.Writer
+	fileSet     *source.FileSet
+	symbolTable *compiler.SymbolTable
+	globals     []objects.Object
+	constants   []objects.Object
+}
+
+func newREPL(out io.Writer) *repl {
+	r := &repl{out: out}
+	r.reset()
+
+	return r
+}
+
+func (r *repl) reset() {
+	r.fileSet = source.NewFileSet()
+	r.globals = make([]objects.Object, runtime.GlobalsSize)
+	r.constants = nil
+
+	r.symbolTable = compiler.NewSymbolTable()
+	for idx, fn := range objects.Builtins {
+		r.symbolTable.DefineBuiltin(idx, fn.Name)
+	}
+}
+
+// run runs the input: either a meta-command starting with ':', or, the
+// statements whose values are printed.
+func (r *repl) run(input string) {
+	if trimmed := strings.TrimSpace(input); strings.HasPrefix(trimmed, ":") {
+		r.command(trimmed)
+		return
+	}
+
+	srcFile := r.fileSet.AddFile("repl", -1, len(input))
+	file, err := parser.ParseFile(srcFile, []byte(input), nil)
+	if err != nil {
+		_, _ = fmt.Fprintf(r.out, "error: %s\n", err.Error())
+		return
+	}
+
+	r.eval(srcFile, addPrints(file), "")
+}
+
+func (r *repl) command(input string) {
+	name, arg := input, ""
+	if n := strings.IndexAny(input, " \t\n"); n >= 0 {
+		name, arg = input[:n], strings.TrimSpace(input[n:])
+	}
+
+	for _, c := range replCommands {
+		if c.name == name && c.args != "" && arg == "" {
+			_, _ = fmt.Fprintf(r.out, "usage: %s %s\n", c.name, c.args)
+			return
+		}
+	}
+
+	switch name {
+	case ":load":
+		r.load(arg)
+	case ":reset":
+		r.reset()
+	case ":vars":
+		r.printVars()
+	case ":type":
+		srcFile := r.fileSet.AddFile("repl", -1, len(arg))
+		expr, err := parser.NewParser(srcFile, []byte(arg), nil).ParseExpr()
+		if err != nil {
+			_, _ = fmt.Fprintf(r.out, "error: %s\n", err.Error())
+			return
+		}
+
+		r.eval(srcFile, addPrints(&ast.File{
+			InputFile: srcFile,
+			Stmts: []ast.Stmt{&ast.ExprStmt{
+				Expr: &ast.CallExpr{
+					Func: &ast.Ident{Name: "type_name"},
+					Args: []ast.Expr{expr},
+				},
+			}},
+		}), "")
+	case ":time":
+		start := time.Now()
+		r.run(arg)
+		_, _ = fmt.Fprintf(r.out, "elapsed: %s\n", time.Since(start))
+	case ":help":
+		w := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+		for _, c := range replCommands {
+			_, _ = fmt.Fprintf(w, "%s %s\t%s\n", c.name, c.args, c.desc)
+		}
+		_ = w.Flush()
+	default:
+		_, _ = fmt.Fprintf(r.out, "unknown command: %s (:help for the list of commands)\n", name)
+	}
+}
+
+// load runs the script file. Unlike the REPL input, the values of the
+// statements are not printed, and, the user modules are imported relative to
+// the directory of the file.
+func (r *repl) load(filename string) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		_, _ = fmt.Fprintf(r.out, "error: %s\n", err.Error())
+		return
+	}
+
+	srcFile := r.fileSet.AddFile(filepath.Base(filename), -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if err != nil {
+		_, _ = fmt.Fprintf(r.out, "error: %s\n", err.Error())
+		return
+	}
+
+	r.eval(srcFile, file, filepath.Dir(filename))
+}
+
+func (r *repl) eval(srcFile *source.File, file *ast.File, importDir string) {
+	c := compiler.NewCompiler(srcFile, r.symbolTable, r.constants, nil, nil)
+	if importDir != "" {
+		c.SetImportDir(importDir)
+	}
+	if err := c.Compile(file); err != nil {
+		_, _ = fmt.Fprintf(r.out, "Compilation error:\n %s\n", err.Error())
+		return
+	}
+
+	bytecode := c.Bytecode()
+
+	machine := runtime.NewVM(bytecode, r.globals, nil)
+	if err := machine.Run(); err != nil {
+		_, _ = fmt.Fprintf(r.out, "Execution error:\n %s\n", err.Error())
+		return
+	}
+
+	r.constants = bytecode.Constants
+}
+
+func (r *repl) printVars() {
+	var names []string
+	for _, name := range r.symbolTable.Names() {
+		if symbol, _, _ := r.symbolTable.Resolve(name); symbol.Scope == compiler.ScopeGlobal {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		typeName := objects.UndefinedValue.TypeName()
+		if symbol, _, _ := r.symbolTable.Resolve(name); r.globals[symbol.Index] != nil {
+			typeName = r.globals[symbol.Index].TypeName()
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", name, typeName)
+	}
+	_ = w.Flush()
+}
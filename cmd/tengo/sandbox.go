@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/stdlib"
+)
+
+var (
+	noOS         bool
+	noExec       bool
+	allowModules string // comma separated standard module names
+	noBuiltins   string // comma separated builtin function names
+)
+
+// execFuncs are the functions of os module that run the other programs.
+var execFuncs = []string{"exec", "exec_look_path", "find_process", "start_process"}
+
+// addSandboxFlags defines the flags that restrict the standard modules and
+// the builtin functions available to the script.
+func addSandboxFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&noOS, "no-os", false, "Disable os module")
+	fs.BoolVar(&noExec, "no-exec", false, "Disable the functions of os module that run processes")
+	fs.StringVar(&allowModules, "allow", "", "Allow only the standard modules in the comma separated list")
+	fs.StringVar(&noBuiltins, "no-builtin", "", "Disable the builtin functions in the comma separated list")
+}
+
+// checkSandboxFlags returns an error if the sandbox flags have unknown module
+// or builtin function names.
+func checkSandboxFlags() error {
+	for _, name := range splitList(allowModules) {
+		if _, ok := stdlib.Modules[name]; !ok {
+			return fmt.Errorf("unknown standard module: %s", name)
+		}
+	}
+
+	for _, name := range splitList(noBuiltins) {
+		found := false
+		for _, fn := range objects.Builtins {
+			if fn.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown builtin function: %s", name)
+		}
+	}
+
+	return nil
+}
+
+// sandboxModules returns the standard modules enabled by the sandbox flags,
+// where os.args() returns the script arguments.
+func sandboxModules() map[string]*objects.Object {
+	modules := stdlib.ModulesWithArgs(scriptArgs)
+
+	if allowModules != "" {
+		allowed := make(map[string]bool)
+		for _, name := range splitList(allowModules) {
+			allowed[name] = true
+		}
+		for name := range modules {
+			if !allowed[name] {
+				delete(modules, name)
+			}
+		}
+	}
+
+	if noOS {
+		delete(modules, "os")
+	}
+
+	if osMod, ok := modules["os"]; ok && noExec {
+		osModule := (*osMod).(*objects.BuiltinModule)
+		var mod objects.Object = &objects.BuiltinModule{
+			Name: osModule.Name,
+			Members: func() map[string]objects.Object {
+				members := make(map[string]objects.Object)
+				for name, member := range osModule.Module().Value {
+					members[name] = member
+				}
+				for _, name := range execFuncs {
+					delete(members, name)
+				}
+
+				return members
+			},
+		}
+		modules["os"] = &mod
+	}
+
+	return modules
+}
+
+// sandboxModuleNames returns the names of the standard modules that the
+// compiler can import.
+func sandboxModuleNames() map[string]bool {
+	names := make(map[string]bool)
+	for name := range sandboxModules() {
+		names[name] = true
+	}
+
+	return names
+}
+
+// sandboxSymbolTable returns the symbol table with the builtin functions
+// that are not disabled by the sandbox flags.
+func sandboxSymbolTable() *compiler.SymbolTable {
+	disabled := make(map[string]bool)
+	for _, name := range splitList(noBuiltins) {
+		disabled[name] = true
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	for idx, fn := range objects.Builtins {
+		if !disabled[fn.Name] {
+			symbolTable.DefineBuiltin(idx, fn.Name)
+		}
+	}
+
+	return symbolTable
+}
+
+func splitList(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw is not supported on this platform: the REPL reads the lines
+// without the line editor.
+func makeRaw(f *os.File) (restore func(), err error) {
+	return nil, errors.New("raw terminal mode not supported")
+}
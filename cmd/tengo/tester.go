@@ -0,0 +1,346 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+	"github.com/d5/tengo/stdlib"
+)
+
+const testFileSuffix = "_test" + sourceFileExt
+
+// testBuiltins are the functions defined in the test files, in the order of
+// their global variable indexes.
+var testBuiltins = []string{"test", "bench", "expect", "fail"}
+
+// testResult is the result of a test function.
+type testResult struct {
+	name     string
+	duration time.Duration
+	err      error
+}
+
+// doTest runs the tests in the test files (*_test.tengo):
+//
+//	tengo test [-v] [-cover] [path ...]
+//
+// The directories are searched recursively, and, the current directory is
+// used if no path is given. It returns the exit code.
+func doTest(args []string) int {
+	var verbose, cover bool
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.BoolVar(&verbose, "v", false, "Report all the tests, not only the failed ones")
+	fs.BoolVar(&cover, "cover", false, "Report the line coverage of the test files and the modules they import")
+	fs.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println()
+		fmt.Println("	tengo test [flags] [path ...]")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fmt.Println()
+		fs.SetOutput(os.Stdout)
+		fs.PrintDefaults()
+		fmt.Println()
+	}
+	paths := parseInterspersed(fs, args)
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := findTestFiles(paths)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Println("no test files")
+		return 0
+	}
+
+	// the coverage of all the tests is merged
+	var coverage *runtime.Coverage
+	if cover {
+		coverage = runtime.NewCoverage()
+	}
+
+	exitCode := 0
+	for _, file := range files {
+		start := time.Now()
+		results, err := runTestFile(file, coverage)
+
+		var numFailed int
+		for _, r := range results {
+			if r.err != nil {
+				numFailed++
+			}
+			if r.err != nil || verbose {
+				status := "PASS"
+				if r.err != nil {
+					status = "FAIL"
+				}
+				fmt.Printf("--- %s: %s (%s)\n", status, r.name, r.duration)
+				if r.err != nil {
+					fmt.Printf("    %s\n", r.err.Error())
+				}
+			}
+		}
+
+		elapsed := time.Since(start).Seconds()
+		switch {
+		case err != nil:
+			exitCode = 1
+			fmt.Printf("FAIL\t%s\t%.3fs\n", file, elapsed)
+			src, _ := ioutil.ReadFile(file)
+			printError(err, file, src)
+		case numFailed > 0:
+			exitCode = 1
+			fmt.Printf("FAIL\t%s\t%.3fs\t(%d failed, %d passed)\n", file, elapsed, numFailed, len(results)-numFailed)
+		default:
+			fmt.Printf("ok\t%s\t%.3fs\t(%d passed)\n", file, elapsed, len(results))
+		}
+	}
+
+	if coverage != nil {
+		printCoverage(coverage)
+	}
+
+	return exitCode
+}
+
+// findTestFiles returns the test files in the paths, sorted by the name.
+func findTestFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				if p != path && strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if p == path || strings.HasSuffix(p, testFileSuffix) {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// runTestFile runs each test function of the test file in a separate VM, so
+// the tests don't share the state. The code outside the test functions runs
+// for each test. The executed lines are recorded to the coverage if it's not
+// nil. It returns the error if the file cannot be compiled, or, the code
+// outside the test functions fails.
+func runTestFile(filename string, coverage *runtime.Coverage) ([]testResult, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	bytecode, err := compileTest(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// find the number of the tests without running them
+	numTests, err := runTestFunc(bytecode, filename, "test", -1, coverage, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []testResult
+	for i := 0; i < numTests; i++ {
+		var result testResult
+		_, err := runTestFunc(bytecode, filename, "test", i, coverage, func(rt objects.Interop, name string, fn objects.Object) error {
+			start := time.Now()
+			_, result.err = rt.Call(fn)
+			result.name = name
+			result.duration = time.Since(start)
+
+			return result.err
+		})
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func compileTest(src []byte, filename string) (*compiler.Bytecode, error) {
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile(filepath.Base(filename), -1, len(src))
+
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	for idx, fn := range objects.Builtins {
+		symbolTable.DefineBuiltin(idx, fn.Name)
+	}
+	for _, name := range testBuiltins {
+		symbolTable.Define(name)
+	}
+
+	c := compiler.NewCompiler(srcFile, symbolTable, nil, nil, nil)
+	c.SetImportDir(filepath.Dir(filename))
+	if err := c.Compile(file); err != nil {
+		return nil, err
+	}
+
+	return c.Bytecode(), nil
+}
+
+// runTestFunc runs the test file calling only the function defined by 'kind'
+// function ("test" or "bench") at the index (none if it's negative) with
+// run. The executed lines are recorded to the coverage if it's not nil. It
+// returns the number of the functions defined by 'kind' function. The error
+// returned by run is not returned: run should record it.
+func runTestFunc(
+	bytecode *compiler.Bytecode,
+	filename, kind string,
+	index int,
+	coverage *runtime.Coverage,
+	run func(rt objects.Interop, name string, fn objects.Object) error,
+) (count int, err error) {
+	var ran bool
+	define := func(defKind string) objects.Object {
+		return &objects.InteropFunction{
+			Name: defKind,
+			Value: func(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+				if len(args) != 2 {
+					return nil, objects.ErrWrongNumArguments
+				}
+
+				name, ok := objects.ToString(args[0])
+				if !ok {
+					return nil, objects.ErrInvalidArgumentType{
+						Name:     "first",
+						Expected: "string(compatible)",
+						Found:    args[0].TypeName(),
+					}
+				}
+
+				if defKind != kind {
+					return nil, nil
+				}
+
+				count++
+				if count-1 != index {
+					return nil, nil
+				}
+
+				ran = true
+
+				return nil, run(rt, name, args[1])
+			},
+		}
+	}
+
+	globals := make([]objects.Object, runtime.GlobalsSize)
+	for i, fn := range []objects.Object{
+		define("test"),
+		define("bench"),
+		&objects.UserFunction{Name: "expect", Value: testExpect},
+		&objects.UserFunction{Name: "fail", Value: testFail},
+	} {
+		globals[i] = fn
+	}
+
+	machine := runtime.NewVM(bytecode, globals, stdlib.ModulesWithArgs([]string{filename}))
+	if coverage != nil {
+		machine.SetCoverage(coverage)
+	}
+	err = machine.Run()
+	if ran {
+		// the error of the function is recorded by run
+		err = nil
+	}
+
+	return
+}
+
+// testExpect fails the test if the condition is falsy:
+//
+//	expect(cond, format, args...)
+func testExpect(args ...objects.Object) (objects.Object, error) {
+	if len(args) == 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	if !args[0].IsFalsy() {
+		return nil, nil
+	}
+
+	msg, err := testMessage(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	if msg == "" {
+		return nil, errors.New("expectation failed")
+	}
+
+	return nil, fmt.Errorf("expectation failed: %s", msg)
+}
+
+// testFail fails the test:
+//
+//	fail(format, args...)
+func testFail(args ...objects.Object) (objects.Object, error) {
+	msg, err := testMessage(args)
+	if err != nil {
+		return nil, err
+	}
+	if msg == "" {
+		return nil, errors.New("failed")
+	}
+
+	return nil, fmt.Errorf("failed: %s", msg)
+}
+
+// testMessage formats the message like sprintf builtin function.
+func testMessage(args []objects.Object) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+
+	for _, fn := range objects.Builtins {
+		if fn.Name == "sprintf" {
+			s, err := fn.Func(args...)
+			if err != nil {
+				return "", err
+			}
+			msg, _ := objects.ToString(s)
+			return msg, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+
+	"github.com/d5/tengo/tengobind"
+)
+
+var (
+	importPath string
+	moduleName string
+	outputFile string
+	pkgName    string
+	showHelp   bool
+)
+
+func init() {
+	flag.BoolVar(&showHelp, "help", false, "Show help")
+	flag.StringVar(&importPath, "import", "", "Import path of the Go package")
+	flag.StringVar(&moduleName, "name", "", "Module name used in the type names")
+	flag.StringVar(&outputFile, "o", "", "Output file")
+	flag.StringVar(&pkgName, "pkg", os.Getenv("GOPACKAGE"), "Package name of the generated code")
+	flag.Parse()
+}
+
+func main() {
+	if showHelp || flag.NArg() != 1 {
+		doHelp()
+		os.Exit(2)
+	}
+
+	dir := flag.Arg(0)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		// not a directory: resolve it as an import path
+		wd, err := os.Getwd()
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		bp, err := build.Import(dir, wd, build.FindOnly)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		if importPath == "" {
+			importPath = bp.ImportPath
+		}
+		dir = bp.Dir
+	}
+
+	res, err := tengobind.Bind(dir, tengobind.Options{
+		ImportPath: importPath,
+		Package:    pkgName,
+		Module:     moduleName,
+	})
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	for _, s := range res.Skipped {
+		_, _ = fmt.Fprintf(os.Stderr, "skipped %s\n", s)
+	}
+
+	if outputFile == "" {
+		_, _ = os.Stdout.Write(res.Source)
+		return
+	}
+
+	if err := ioutil.WriteFile(outputFile, res.Source, 0644); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+func doHelp() {
+	fmt.Println("Usage:")
+	fmt.Println()
+	fmt.Println("	tengobind [flags] {dir | import-path}")
+	fmt.Println()
+	fmt.Println("Flags:")
+	fmt.Println()
+	fmt.Println("	-import   import path of the Go package (resolved from GOPATH by default)")
+	fmt.Println("	-name     module name used in the type names (Go package name by default)")
+	fmt.Println("	-o        output file (standard output by default)")
+	fmt.Println("	-pkg      package name of the generated code ($GOPACKAGE or")
+	fmt.Println("	          Go package name followed by \"mod\" by default)")
+	fmt.Println()
+	fmt.Println("The declarations that cannot be bound are reported to the standard error.")
+	fmt.Println()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/d5/tengo/tengodoc"
+)
+
+const sourceFileExt = ".tengo"
+
+var (
+	htmlOutput bool
+	showHelp   bool
+)
+
+func init() {
+	flag.BoolVar(&showHelp, "help", false, "Show help")
+	flag.BoolVar(&htmlOutput, "html", false, "Render HTML instead of Markdown")
+	flag.Parse()
+}
+
+func main() {
+	if showHelp || flag.NArg() == 0 {
+		doHelp()
+		os.Exit(2)
+	}
+
+	var modules []*tengodoc.Module
+	for _, arg := range flag.Args() {
+		err := filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() || (path != arg && filepath.Ext(path) != sourceFileExt) {
+				return nil
+			}
+
+			src, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			m, err := tengodoc.Parse(path, src)
+			if err != nil {
+				return err
+			}
+
+			modules = append(modules, m)
+
+			return nil
+		})
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if htmlOutput {
+		fmt.Println("<!DOCTYPE html>")
+		fmt.Println("<html>")
+		fmt.Println("<head><meta charset=\"utf-8\"><title>Tengo Documentation</title></head>")
+		fmt.Println("<body>")
+	}
+
+	for i, m := range modules {
+		if htmlOutput {
+			_, _ = os.Stdout.Write(m.HTML())
+			continue
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		_, _ = os.Stdout.Write(m.Markdown())
+	}
+
+	if htmlOutput {
+		fmt.Println("</body>")
+		fmt.Println("</html>")
+	}
+}
+
+func doHelp() {
+	fmt.Println("Usage:")
+	fmt.Println()
+	fmt.Println("	tengodoc [flags] path ...")
+	fmt.Println()
+	fmt.Println("Flags:")
+	fmt.Println()
+	fmt.Println("	-html     render HTML instead of Markdown")
+	fmt.Println()
+	fmt.Println("Directories are processed recursively (only .tengo files).")
+	fmt.Println("The documentation of all the files is written to the standard output.")
+	fmt.Println()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/d5/tengo/tengofmt"
+)
+
+const sourceFileExt = ".tengo"
+
+var (
+	listOnly  bool
+	overwrite bool
+	showHelp  bool
+)
+
+func init() {
+	flag.BoolVar(&showHelp, "help", false, "Show help")
+	flag.BoolVar(&listOnly, "l", false, "List files whose formatting differs")
+	flag.BoolVar(&overwrite, "w", false, "Write result to source file")
+	flag.Parse()
+}
+
+func main() {
+	if showHelp {
+		doHelp()
+		os.Exit(2)
+	}
+
+	if flag.NArg() == 0 {
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+
+		formatted, err := tengofmt.Format(src)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+
+		_, _ = os.Stdout.Write(formatted)
+		return
+	}
+
+	exitCode := 0
+	for _, arg := range flag.Args() {
+		err := filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() || (path != arg && filepath.Ext(path) != sourceFileExt) {
+				return nil
+			}
+
+			changed, err := formatFile(path, info.Mode())
+			if err != nil {
+				return err
+			}
+
+			if changed && listOnly {
+				exitCode = 1
+			}
+
+			return nil
+		})
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			exitCode = 2
+		}
+	}
+
+	os.Exit(exitCode)
+}
+
+func doHelp() {
+	fmt.Println("Usage:")
+	fmt.Println()
+	fmt.Println("	tengofmt [flags] [path ...]")
+	fmt.Println()
+	fmt.Println("Flags:")
+	fmt.Println()
+	fmt.Println("	-l        list files whose formatting differs from canonical style")
+	fmt.Println("	-w        write result to source file instead of stdout")
+	fmt.Println()
+	fmt.Println("Directories are processed recursively (only .tengo files).")
+	fmt.Println("Without a path, it formats the standard input.")
+	fmt.Println("With -l flag, it exits with status 1 if any file needs formatting.")
+	fmt.Println()
+}
+
+// formatFile formats a single file and returns true if the file was not
+// formatted in the canonical style.
+func formatFile(path string, mode os.FileMode) (changed bool, err error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	formatted, err := tengofmt.Format(src)
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	changed = !bytes.Equal(src, formatted)
+
+	if listOnly && changed {
+		fmt.Println(path)
+	}
+
+	if overwrite {
+		if changed {
+			err = ioutil.WriteFile(path, formatted, mode)
+		}
+		return
+	}
+
+	if !listOnly {
+		_, _ = os.Stdout.Write(formatted)
+	}
+
+	return
+}
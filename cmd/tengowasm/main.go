@@ -0,0 +1,129 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Tengowasm is the WebAssembly build of the interpreter. It defines the
+// global "tengo" object that runs the scripts from JavaScript:
+//
+//	const res = tengo.run(src, JSON.stringify({a: 1}))
+//	// res.globals: JSON of the global variables after the run
+//	// res.error: error message, or, null
+//
+// Build it with GOOS=js GOARCH=wasm, and, load it with wasm_exec.js of the
+// Go distribution.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/script"
+)
+
+func main() {
+	js.Global().Set("tengo", js.ValueOf(map[string]interface{}{
+		"run": js.FuncOf(run),
+	}))
+
+	// keep the functions available
+	select {}
+}
+
+// run(src [, vars]) => {globals, error}
+func run(this js.Value, args []js.Value) interface{} {
+	globals, err := runScript(args)
+	if err != nil {
+		return map[string]interface{}{"globals": nil, "error": err.Error()}
+	}
+
+	return map[string]interface{}{"globals": globals, "error": nil}
+}
+
+// runScript runs the source with the variables decoded from the JSON
+// object, and, returns the JSON of the global variables. The numbers of the
+// variables are floats like from_json.
+func runScript(args []js.Value) (string, error) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return "", errors.New("source must be a string")
+	}
+
+	s := script.New([]byte(args[0].String()))
+	s.DisableStdModule("os")
+
+	// the file system is not available
+	s.SetUserModuleLoader(func(moduleName string) ([]byte, error) {
+		return nil, fmt.Errorf("module '%s' not found", moduleName)
+	})
+
+	if len(args) > 1 && args[1].Type() != js.TypeUndefined && args[1].Type() != js.TypeNull {
+		vars, err := callBuiltin("from_json", &objects.String{Value: args[1].String()})
+		if err != nil {
+			return "", err
+		}
+
+		m, ok := vars.(*objects.Map)
+		if !ok {
+			return "", errors.New("variables must be a JSON object")
+		}
+
+		for name, value := range m.Value {
+			if err := s.Add(name, value); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	compiled, err := s.Run()
+	if err != nil {
+		return "", err
+	}
+
+	// the functions are not exported
+	globals := make(map[string]objects.Object)
+	for _, v := range compiled.GetAll() {
+		switch v.Object().(type) {
+		case *objects.CompiledFunction, *objects.Closure, objects.Callable, objects.InteropCallable:
+			continue
+		}
+		globals[v.Name()] = v.Object()
+	}
+
+	return toJSON(&objects.Map{Value: globals})
+}
+
+// toJSON encodes the object using the to_json builtin function, so the values
+// are encoded the same way as the scripts do.
+func toJSON(o objects.Object) (string, error) {
+	res, err := callBuiltin("to_json", o)
+	if err != nil {
+		return "", err
+	}
+
+	return string(res.(*objects.Bytes).Value), nil
+}
+
+// callBuiltin calls the builtin function. The error values returned by the
+// function are converted to the errors. The builtin JSON functions are used
+// instead of encoding/json package, so the interpreter can be built with
+// TinyGo too.
+func callBuiltin(name string, arg objects.Object) (objects.Object, error) {
+	for _, fn := range objects.Builtins {
+		if fn.Name != name {
+			continue
+		}
+
+		res, err := fn.Func(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if e, ok := res.(*objects.Error); ok {
+			return nil, errors.New(e.Value.String())
+		}
+
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("%s is not available", name)
+}
@@ -0,0 +1,153 @@
+package ast
+
+import "fmt"
+
+// Visitor visits the nodes of the AST. Walk calls the Visit method for every
+// node it encounters. If the returned visitor w is not nil, Walk visits each
+// of the children of the node with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the AST in depth-first order: it starts by calling
+// v.Visit(node); node must not be nil.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *File:
+		walkStmts(v, n.Stmts)
+	case *BadExpr, *BadStmt, *EmptyStmt, *Ident, *IntLit, *FloatLit, *CharLit,
+		*StringLit, *BoolLit, *UndefinedLit, *ImportExpr:
+		// no children
+	case *ArrayLit:
+		walkExprs(v, n.Elements)
+	case *MapLit:
+		for _, elem := range n.Elements {
+			Walk(v, elem)
+		}
+	case *MapElementLit:
+		Walk(v, n.Value)
+	case *FuncLit:
+		Walk(v, n.Type)
+		Walk(v, n.Body)
+	case *FuncType:
+		Walk(v, n.Params)
+	case *IdentList:
+		for _, ident := range n.List {
+			Walk(v, ident)
+		}
+	case *ParenExpr:
+		Walk(v, n.Expr)
+	case *UnaryExpr:
+		Walk(v, n.Expr)
+	case *BinaryExpr:
+		Walk(v, n.LHS)
+		Walk(v, n.RHS)
+	case *CondExpr:
+		Walk(v, n.Cond)
+		Walk(v, n.True)
+		Walk(v, n.False)
+	case *CallExpr:
+		Walk(v, n.Func)
+		walkExprs(v, n.Args)
+	case *IndexExpr:
+		Walk(v, n.Expr)
+		Walk(v, n.Index)
+	case *SliceExpr:
+		Walk(v, n.Expr)
+		if n.Low != nil {
+			Walk(v, n.Low)
+		}
+		if n.High != nil {
+			Walk(v, n.High)
+		}
+	case *SelectorExpr:
+		Walk(v, n.Expr)
+		Walk(v, n.Sel)
+	case *ErrorExpr:
+		Walk(v, n.Expr)
+	case *ImmutableExpr:
+		Walk(v, n.Expr)
+	case *ExprStmt:
+		Walk(v, n.Expr)
+	case *AssignStmt:
+		walkExprs(v, n.LHS)
+		walkExprs(v, n.RHS)
+	case *IncDecStmt:
+		Walk(v, n.Expr)
+	case *BranchStmt:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+	case *BlockStmt:
+		walkStmts(v, n.Stmts)
+	case *IfStmt:
+		if n.Init != nil {
+			Walk(v, n.Init)
+		}
+		Walk(v, n.Cond)
+		Walk(v, n.Body)
+		if n.Else != nil {
+			Walk(v, n.Else)
+		}
+	case *ForStmt:
+		if n.Init != nil {
+			Walk(v, n.Init)
+		}
+		if n.Cond != nil {
+			Walk(v, n.Cond)
+		}
+		if n.Post != nil {
+			Walk(v, n.Post)
+		}
+		Walk(v, n.Body)
+	case *ForInStmt:
+		Walk(v, n.Key)
+		Walk(v, n.Value)
+		Walk(v, n.Iterable)
+		Walk(v, n.Body)
+	case *ReturnStmt:
+		if n.Result != nil {
+			Walk(v, n.Result)
+		}
+	case *ExportStmt:
+		Walk(v, n.Result)
+	default:
+		panic(fmt.Errorf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkExprs(v Visitor, exprs []Expr) {
+	for _, expr := range exprs {
+		Walk(v, expr)
+	}
+}
+
+func walkStmts(v Visitor, stmts []Stmt) {
+	for _, stmt := range stmts {
+		Walk(v, stmt)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect traverses the AST in depth-first order: it starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the children of node, followed by a call of
+// f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,98 @@
+package ast_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+)
+
+const walkSrc = `
+os := import("os")
+a := [1, 2.5, "x", 'c', true, undefined]
+b := {k: a[0], l: a[1:], m: a[:2], n: os.args}
+f := func(x, y) {
+	if z := x + y; z > 0 {
+		return -z
+	} else if !x {
+		return error(x ? y : z)
+	}
+	for i := 0; i < 10; i++ {
+		continue
+	}
+	for k, v in b {
+		break
+	}
+	export immutable(a)
+}
+f(1, 2)
+`
+
+type depthVisitor struct {
+	depth    int
+	maxDepth int
+}
+
+func (v *depthVisitor) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		v.depth--
+		return nil
+	}
+
+	v.depth++
+	if v.depth > v.maxDepth {
+		v.maxDepth = v.depth
+	}
+
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	file := parse(t, walkSrc)
+
+	v := &depthVisitor{}
+	ast.Walk(v, file)
+	assert.Equal(t, 0, v.depth)
+	assert.True(t, v.maxDepth > 5)
+
+	var idents []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Ident:
+			idents = append(idents, n.Name)
+		case *ast.ForInStmt:
+			// skip the for-in statement
+			return false
+		}
+
+		return true
+	})
+	assert.Equal(t, "os a b a a a os f x y z x y z z x x y z i i i a f",
+		strings.Join(idents, " "))
+
+	var numLits int
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.IntLit, *ast.FloatLit, *ast.StringLit, *ast.CharLit, *ast.BoolLit, *ast.UndefinedLit:
+			numLits++
+		}
+
+		return true
+	})
+	assert.Equal(t, 15, numLits)
+}
+
+func parse(t *testing.T, input string) *ast.File {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("test", -1, len(input))
+
+	parsed, err := parser.ParseFile(file, []byte(input), nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return parsed
+}
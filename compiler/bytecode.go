@@ -1,15 +1,49 @@
 package compiler
 
 import (
-	"encoding/gob"
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
-	"reflect"
+	"io/ioutil"
 
 	"github.com/d5/tengo/compiler/source"
 	"github.com/d5/tengo/objects"
 )
 
+// BytecodeFormatVersion is the version of the serialized bytecode format.
+// It's increased whenever the format or the instruction set changes in an
+// incompatible way.
+const BytecodeFormatVersion = 3
+
+// bytecodeMagic is the magic number at the start of the serialized bytecode.
+var bytecodeMagic = [4]byte{'T', 'N', 'G', 'O'}
+
+var (
+	// ErrInvalidBytecode is returned when decoding the data that is not a
+	// serialized bytecode, e.g. a source file or a bytecode serialized by
+	// an older version without the header.
+	ErrInvalidBytecode = errors.New("invalid bytecode: bad magic number")
+
+	// ErrCorruptedBytecode is returned when decoding the serialized bytecode
+	// that does not match its checksum.
+	ErrCorruptedBytecode = errors.New("corrupted bytecode: checksum mismatch")
+
+	// ErrBytecodeUnsupported is returned when encoding or decoding the
+	// bytecode in the embedded builds (TinyGo or "tengo_embedded" build
+	// tag), which do not include the serialization.
+	ErrBytecodeUnsupported = errors.New("bytecode serialization is not supported in embedded builds")
+)
+
+// bytecodeHeader is written before the serialized bytecode.
+type bytecodeHeader struct {
+	Magic    [4]byte
+	Version  uint16
+	Checksum uint32 // CRC-32 (IEEE) of the data after the header
+}
+
 // Bytecode is a compiled instructions and constants.
 type Bytecode struct {
 	FileSet      *source.FileSet
@@ -17,46 +51,88 @@ type Bytecode struct {
 	Constants    []objects.Object
 }
 
-// Decode reads Bytecode data from the reader.
+// Decode reads Bytecode data from the reader. It returns an error if the
+// data is not a bytecode, it was serialized with an incompatible format
+// version, or, it's corrupted.
 func (b *Bytecode) Decode(r io.Reader) error {
-	dec := gob.NewDecoder(r)
-
-	if err := dec.Decode(&b.FileSet); err != nil {
+	var header bytecodeHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrInvalidBytecode
+		}
 		return err
 	}
-	// TODO: files in b.FileSet.File does not have their 'set' field properly set to b.FileSet
-	// as it's private field and not serialized by gob encoder/decoder.
 
-	if err := dec.Decode(&b.MainFunction); err != nil {
+	if header.Magic != bytecodeMagic {
+		return ErrInvalidBytecode
+	}
+
+	if header.Version != BytecodeFormatVersion {
+		return fmt.Errorf("incompatible bytecode format version: %d (supported: %d)",
+			header.Version, BytecodeFormatVersion)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
 		return err
 	}
 
-	if err := dec.Decode(&b.Constants); err != nil {
+	if crc32.ChecksumIEEE(data) != header.Checksum {
+		return ErrCorruptedBytecode
+	}
+
+	if err := b.decodeData(data); err != nil {
 		return err
 	}
 
-	// replace Bool and Undefined with known value
+	// replace Bool and Undefined with known value, and, prepare the
+	// constants to be shared by the VMs
 	for i, v := range b.Constants {
-		b.Constants[i] = cleanupObjects(v)
+		b.Constants[i] = objects.Freeze(cleanupObjects(v))
 	}
 
 	return nil
 }
 
-// Encode writes Bytecode data to the writer.
+// Encode writes Bytecode data to the writer. The data starts with a header
+// that contains the format version and the checksum of the data.
 func (b *Bytecode) Encode(w io.Writer) error {
-	enc := gob.NewEncoder(w)
-
-	if err := enc.Encode(b.FileSet); err != nil {
+	var data bytes.Buffer
+	if err := b.encodeData(&data); err != nil {
 		return err
 	}
 
-	if err := enc.Encode(b.MainFunction); err != nil {
+	header := bytecodeHeader{
+		Magic:    bytecodeMagic,
+		Version:  BytecodeFormatVersion,
+		Checksum: crc32.ChecksumIEEE(data.Bytes()),
+	}
+	if err := binary.Write(w, binary.BigEndian, &header); err != nil {
 		return err
 	}
 
-	// constants
-	return enc.Encode(b.Constants)
+	_, err := w.Write(data.Bytes())
+
+	return err
+}
+
+// StripDebugInfo removes the source maps, the variable information and the
+// positions of the compiled functions, and, the source file information from
+// the bytecode to reduce the size of the serialized bytecode. The runtime
+// errors of the stripped bytecode do not include the positions in the source
+// code.
+func (b *Bytecode) StripDebugInfo() {
+	b.FileSet = source.NewFileSet()
+	b.MainFunction.SourceMap = nil
+	b.MainFunction.Variables = nil
+
+	for _, c := range b.Constants {
+		if fn, ok := c.(*objects.CompiledFunction); ok {
+			fn.SourceMap = nil
+			fn.Variables = nil
+			fn.Pos = source.NoPos
+		}
+	}
 }
 
 // FormatInstructions returns human readable string representations of
@@ -76,7 +152,7 @@ func (b *Bytecode) FormatConstants() (output []string) {
 				output = append(output, fmt.Sprintf("     %s", l))
 			}
 		default:
-			output = append(output, fmt.Sprintf("[% 3d] %s (%s|%p)", cidx, cn, reflect.TypeOf(cn).Elem().Name(), &cn))
+			output = append(output, fmt.Sprintf("[% 3d] %s (%s|%p)", cidx, cn, typeName(cn), &cn))
 		}
 	}
 
@@ -104,31 +180,3 @@ func cleanupObjects(o objects.Object) objects.Object {
 
 	return o
 }
-
-func init() {
-	gob.Register(&source.FileSet{})
-	gob.Register(&source.File{})
-	gob.Register(&objects.Array{})
-	gob.Register(&objects.ArrayIterator{})
-	gob.Register(&objects.Bool{})
-	gob.Register(&objects.Break{})
-	gob.Register(&objects.BuiltinFunction{})
-	gob.Register(&objects.Bytes{})
-	gob.Register(&objects.Char{})
-	gob.Register(&objects.Closure{})
-	gob.Register(&objects.CompiledFunction{})
-	gob.Register(&objects.Continue{})
-	gob.Register(&objects.Error{})
-	gob.Register(&objects.Float{})
-	gob.Register(&objects.ImmutableArray{})
-	gob.Register(&objects.ImmutableMap{})
-	gob.Register(&objects.Int{})
-	gob.Register(&objects.Map{})
-	gob.Register(&objects.MapIterator{})
-	gob.Register(&objects.ReturnValue{})
-	gob.Register(&objects.String{})
-	gob.Register(&objects.StringIterator{})
-	gob.Register(&objects.Time{})
-	gob.Register(&objects.Undefined{})
-	gob.Register(&objects.UserFunction{})
-}
@@ -0,0 +1,19 @@
+//go:build tinygo || tengo_embedded
+// +build tinygo tengo_embedded
+
+package compiler
+
+import (
+	"io"
+)
+
+// encodeData returns ErrBytecodeUnsupported: gob encoding needs the full
+// reflection, which is not available in TinyGo.
+func (b *Bytecode) encodeData(w io.Writer) error {
+	return ErrBytecodeUnsupported
+}
+
+// decodeData returns ErrBytecodeUnsupported.
+func (b *Bytecode) decodeData(data []byte) error {
+	return ErrBytecodeUnsupported
+}
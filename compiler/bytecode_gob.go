@@ -0,0 +1,75 @@
+//go:build !tinygo && !tengo_embedded
+// +build !tinygo,!tengo_embedded
+
+package compiler
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
+
+// encodeData writes the file set, the main function and the constants of
+// the bytecode using gob encoding.
+func (b *Bytecode) encodeData(w io.Writer) error {
+	enc := gob.NewEncoder(w)
+
+	if err := enc.Encode(b.FileSet); err != nil {
+		return err
+	}
+
+	if err := enc.Encode(b.MainFunction); err != nil {
+		return err
+	}
+
+	// constants
+	return enc.Encode(b.Constants)
+}
+
+// decodeData reads the data written by encodeData.
+func (b *Bytecode) decodeData(data []byte) error {
+	dec := gob.NewDecoder(bytes.NewReader(data))
+
+	if err := dec.Decode(&b.FileSet); err != nil {
+		return err
+	}
+	// TODO: files in b.FileSet.File does not have their 'set' field properly set to b.FileSet
+	// as it's private field and not serialized by gob encoder/decoder.
+
+	if err := dec.Decode(&b.MainFunction); err != nil {
+		return err
+	}
+
+	return dec.Decode(&b.Constants)
+}
+
+func init() {
+	gob.Register(&source.FileSet{})
+	gob.Register(&source.File{})
+	gob.Register(&objects.Array{})
+	gob.Register(&objects.ArrayIterator{})
+	gob.Register(&objects.Bool{})
+	gob.Register(&objects.Break{})
+	gob.Register(&objects.BuiltinFunction{})
+	gob.Register(&objects.Bytes{})
+	gob.Register(&objects.Char{})
+	gob.Register(&objects.Closure{})
+	gob.Register(&objects.CompiledFunction{})
+	gob.Register(&objects.Continue{})
+	gob.Register(&objects.Error{})
+	gob.Register(&objects.Float{})
+	gob.Register(&objects.ImmutableArray{})
+	gob.Register(&objects.ImmutableMap{})
+	gob.Register(&objects.Int{})
+	gob.Register(&objects.Map{})
+	gob.Register(&objects.MapIterator{})
+	gob.Register(&objects.ReturnValue{})
+	gob.Register(&objects.String{})
+	gob.Register(&objects.StringIterator{})
+	gob.Register(&objects.Time{})
+	gob.Register(&objects.Undefined{})
+	gob.Register(&objects.UserFunction{})
+}
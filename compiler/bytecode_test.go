@@ -111,6 +111,9 @@ func bytecodeFileSet(instructions []byte, constants []objects.Object, fileSet *s
 func testBytecodeSerialization(t *testing.T, b *compiler.Bytecode) {
 	var buf bytes.Buffer
 	err := b.Encode(&buf)
+	if err == compiler.ErrBytecodeUnsupported {
+		t.Skip(err)
+	}
 	assert.NoError(t, err)
 
 	r := &compiler.Bytecode{}
@@ -121,3 +124,121 @@ func testBytecodeSerialization(t *testing.T, b *compiler.Bytecode) {
 	assert.Equal(t, b.MainFunction, r.MainFunction)
 	assert.Equal(t, b.Constants, r.Constants)
 }
+
+func TestBytecode_DecodeErrors(t *testing.T) {
+	var buf bytes.Buffer
+	b := bytecode(
+		concat(compiler.MakeInstruction(compiler.OpConstant, 0)),
+		objectsArray(intObject(1)))
+	err := b.Encode(&buf)
+	if err == compiler.ErrBytecodeUnsupported {
+		t.Skip(err)
+	}
+	assert.NoError(t, err)
+	data := buf.Bytes()
+
+	r := &compiler.Bytecode{}
+	assert.Equal(t, compiler.ErrInvalidBytecode, r.Decode(bytes.NewReader(nil)))
+	assert.Equal(t, compiler.ErrInvalidBytecode, r.Decode(bytes.NewReader([]byte(`a := 1; b := a + 1`))))
+
+	// format version
+	invalid := append([]byte{}, data...)
+	invalid[5] = compiler.BytecodeFormatVersion + 1
+	err = r.Decode(bytes.NewReader(invalid))
+	assert.Error(t, err)
+	assert.Equal(t, "incompatible bytecode format version: 4 (supported: 3)", err.Error())
+
+	// the bytecode with the instructions added in version 3 can't be read as
+	// the older versions
+	var buf3 bytes.Buffer
+	b = bytecode(
+		concat(
+			compiler.MakeInstruction(compiler.OpAddLocalConst, 0, 0),
+			compiler.MakeInstruction(compiler.OpJumpNotEqual, 0)),
+		objectsArray(intObject(1)))
+	assert.NoError(t, b.Encode(&buf3))
+	invalid = buf3.Bytes()
+	invalid[5] = 2
+	err = r.Decode(bytes.NewReader(invalid))
+	assert.Error(t, err)
+	assert.Equal(t, "incompatible bytecode format version: 2 (supported: 3)", err.Error())
+
+	// corrupted data
+	invalid = append([]byte{}, data...)
+	invalid[len(invalid)-1]++
+	assert.Equal(t, compiler.ErrCorruptedBytecode, r.Decode(bytes.NewReader(invalid)))
+	assert.Equal(t, compiler.ErrCorruptedBytecode, r.Decode(bytes.NewReader(data[:len(data)-1])))
+
+	assert.NoError(t, r.Decode(bytes.NewReader(data)))
+}
+
+func TestBytecode_Validate(t *testing.T) {
+	assert.NoError(t, bytecode(
+		concat(
+			compiler.MakeInstruction(compiler.OpTrue),
+			compiler.MakeInstruction(compiler.OpJumpFalsy, 11),
+			compiler.MakeInstruction(compiler.OpConstant, 0),
+			compiler.MakeInstruction(compiler.OpClosure, 1, 0),
+			compiler.MakeInstruction(compiler.OpPop)),
+		objectsArray(
+			intObject(1),
+			compiledFunction(1, 0,
+				compiler.MakeInstruction(compiler.OpGetLocal, 0),
+				compiler.MakeInstruction(compiler.OpGetBuiltin, 0),
+				compiler.MakeInstruction(compiler.OpReturnValue)))).Validate())
+
+	expectInvalid := func(b *compiler.Bytecode, expected string) {
+		err := b.Validate()
+		if assert.Error(t, err) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+
+	expectInvalid(bytecode([]byte{255}, nil),
+		"invalid bytecode: main function: 0000: unknown opcode 255")
+	expectInvalid(bytecode([]byte{compiler.OpConstant, 0}, objectsArray(intObject(1))),
+		"invalid bytecode: main function: 0000: truncated operands of CONST")
+	expectInvalid(bytecode(compiler.MakeInstruction(compiler.OpConstant, 1), objectsArray(intObject(1))),
+		"invalid bytecode: main function: 0000: constant index out of range: 1")
+	expectInvalid(bytecode(compiler.MakeInstruction(compiler.OpClosure, 0, 0), objectsArray(intObject(1))),
+		"invalid bytecode: main function: 0000: closure of non-function constant: 0")
+	expectInvalid(bytecode(compiler.MakeInstruction(compiler.OpGetBuiltin, 255), nil),
+		"invalid bytecode: main function: 0000: builtin function index out of range: 255")
+	expectInvalid(bytecode(
+		concat(
+			compiler.MakeInstruction(compiler.OpTrue),
+			compiler.MakeInstruction(compiler.OpJumpFalsy, 2)),
+		nil),
+		"invalid bytecode: main function: 0001: invalid jump target: 2")
+	expectInvalid(bytecode(concat(), objectsArray(
+		compiledFunction(1, 0,
+			compiler.MakeInstruction(compiler.OpGetLocal, 1),
+			compiler.MakeInstruction(compiler.OpReturnValue)))),
+		"invalid bytecode: constant 0: 0000: local variable index out of range: 1")
+	expectInvalid(bytecode(concat(), objectsArray(&objects.Array{Value: objectsArray(intObject(1))})),
+		"invalid bytecode: constant 0: unsupported type: array")
+	expectInvalid(bytecode(concat(), objectsArray(
+		compiledFunction(1, 0,
+			compiler.MakeInstruction(compiler.OpGetLocal, 0)))),
+		"invalid bytecode: constant 0: 0002: missing return at the end of the function")
+	expectInvalid(bytecode(concat(), objectsArray(
+		compiledFunction(1, 0,
+			compiler.MakeInstruction(compiler.OpGetLocal, 0),
+			compiler.MakeInstruction(compiler.OpJump, 5)))),
+		"invalid bytecode: constant 0: 0005: missing return at the end of the function")
+
+	// endless loop at the end of the function
+	assert.NoError(t, bytecode(concat(), objectsArray(
+		compiledFunction(1, 0,
+			compiler.MakeInstruction(compiler.OpGetLocal, 0),
+			compiler.MakeInstruction(compiler.OpJump, 0)))).Validate())
+
+	// global variable indexes
+	b := bytecode(compiler.MakeInstruction(compiler.OpGetGlobal, 10), nil)
+	assert.NoError(t, b.Validate())
+	assert.NoError(t, b.ValidateGlobals(11))
+	err := b.ValidateGlobals(10)
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid bytecode: main function: 0000: global variable index out of range: 10", err.Error())
+	}
+}
@@ -0,0 +1,153 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/d5/tengo/objects"
+)
+
+// Validate checks that the instructions of the main function and the
+// compiled functions in the constants are well-formed: every opcode is
+// known, the operands are not truncated, the constant indexes and the
+// builtin function indexes are in range, the closures refer to compiled
+// functions, the local variable indexes are less than the number of locals,
+// the jumps land on the instructions of the same function, and, the
+// compiled functions end with a return. It also checks that the constants
+// are of the immutable types the compiler emits, so the VMs running the
+// bytecode cannot modify the shared constants.
+func (b *Bytecode) Validate() error {
+	return b.validate(-1)
+}
+
+// ValidateGlobals is the same as Validate, but, it also checks that the
+// global variable indexes are less than numGlobals.
+func (b *Bytecode) ValidateGlobals(numGlobals int) error {
+	return b.validate(numGlobals)
+}
+
+func (b *Bytecode) validate(numGlobals int) error {
+	if b.MainFunction == nil {
+		return fmt.Errorf("invalid bytecode: missing main function")
+	}
+
+	if err := b.validateFunction(b.MainFunction, "main function", numGlobals); err != nil {
+		return err
+	}
+
+	for cidx, c := range b.Constants {
+		switch c := c.(type) {
+		case *objects.CompiledFunction:
+			if err := b.validateFunction(c, fmt.Sprintf("constant %d", cidx), numGlobals); err != nil {
+				return err
+			}
+		case *objects.Int, *objects.Float, *objects.String, *objects.Char, *objects.Bool, *objects.Undefined:
+		default:
+			return fmt.Errorf("invalid bytecode: constant %d: unsupported type: %s", cidx, c.TypeName())
+		}
+	}
+
+	return nil
+}
+
+func (b *Bytecode) validateFunction(fn *objects.CompiledFunction, name string, numGlobals int) error {
+	insts := fn.Instructions
+
+	invalid := func(pos int, format string, args ...interface{}) error {
+		return fmt.Errorf("invalid bytecode: %s: %04d: %s", name, pos, fmt.Sprintf(format, args...))
+	}
+
+	// instruction boundaries
+	starts := make(map[int]bool)
+	last := -1
+	for i := 0; i < len(insts); {
+		op := insts[i]
+		if int(op) >= len(OpcodeOperands) || OpcodeNames[op] == "" {
+			return invalid(i, "unknown opcode %d", op)
+		}
+
+		width := 0
+		for _, w := range OpcodeOperands[op] {
+			width += w
+		}
+		if i+1+width > len(insts) {
+			return invalid(i, "truncated operands of %s", OpcodeNames[op])
+		}
+
+		starts[i] = true
+		last = i
+		i += 1 + width
+	}
+
+	// the functions other than the main function must return before the
+	// end of the instructions, or, end with the jump of an endless loop (the
+	// optimizer removes the unreachable return after it)
+	if fn != b.MainFunction && !isTerminal(insts, last) {
+		return invalid(len(insts), "missing return at the end of the function")
+	}
+
+	for i := 0; i < len(insts); {
+		op := insts[i]
+		operands, read := ReadOperands(OpcodeOperands[op], insts[i+1:])
+
+		switch op {
+		case OpConstant:
+			if operands[0] >= len(b.Constants) {
+				return invalid(i, "constant index out of range: %d", operands[0])
+			}
+		case OpClosure:
+			if operands[0] >= len(b.Constants) {
+				return invalid(i, "constant index out of range: %d", operands[0])
+			}
+			if _, ok := b.Constants[operands[0]].(*objects.CompiledFunction); !ok {
+				return invalid(i, "closure of non-function constant: %d", operands[0])
+			}
+		case OpGetGlobal, OpSetGlobal, OpSetSelGlobal:
+			if numGlobals >= 0 && operands[0] >= numGlobals {
+				return invalid(i, "global variable index out of range: %d", operands[0])
+			}
+		case OpGetBuiltin:
+			if operands[0] >= len(objects.Builtins) {
+				return invalid(i, "builtin function index out of range: %d", operands[0])
+			}
+		case OpGetLocal, OpSetLocal, OpDefineLocal, OpSetSelLocal, OpGetLocalPtr:
+			if operands[0] >= fn.NumLocals {
+				return invalid(i, "local variable index out of range: %d", operands[0])
+			}
+		case OpAddLocalConst:
+			if operands[0] >= fn.NumLocals {
+				return invalid(i, "local variable index out of range: %d", operands[0])
+			}
+			if operands[1] >= len(b.Constants) {
+				return invalid(i, "constant index out of range: %d", operands[1])
+			}
+		case OpJump, OpJumpFalsy, OpAndJump, OpOrJump,
+			OpJumpNotEqual, OpJumpEqual, OpJumpNotGreater, OpJumpNotGreaterEqual:
+			// jumping to the end of the instructions is allowed
+			if target := operands[0]; target != len(insts) && !starts[target] {
+				return invalid(i, "invalid jump target: %d", target)
+			}
+		}
+
+		i += 1 + read
+	}
+
+	return nil
+}
+
+// isTerminal returns true if the instruction at pos does not continue to the
+// next instruction.
+func isTerminal(insts []byte, pos int) bool {
+	if pos < 0 {
+		return false
+	}
+
+	switch insts[pos] {
+	case OpReturn, OpReturnValue:
+		return true
+	case OpJump:
+		operands, _ := ReadOperands(OpcodeOperands[OpJump], insts[pos+1:])
+		return operands[0] < len(insts)
+	}
+
+	return false
+}
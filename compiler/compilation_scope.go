@@ -1,6 +1,9 @@
 package compiler
 
-import "github.com/d5/tengo/compiler/source"
+import (
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
 
 // CompilationScope represents a compiled instructions
 // and the last two instructions that were emitted.
@@ -9,4 +12,7 @@ type CompilationScope struct {
 	lastInstructions [2]EmittedInstruction
 	symbolInit       map[string]bool
 	sourceMap        map[int]source.Pos
+	variables        []objects.VariableInfo
+	blockEnds        []source.Pos // ends of the enclosing blocks
+	lastJumpTarget   int          // the farthest position that a jump targets
 }
@@ -3,9 +3,10 @@ package compiler
 import (
 	"fmt"
 	"io"
-	"reflect"
+	"strings"
 
 	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/lint"
 	"github.com/d5/tengo/compiler/source"
 	"github.com/d5/tengo/compiler/token"
 	"github.com/d5/tengo/objects"
@@ -18,16 +19,26 @@ type Compiler struct {
 	parent          *Compiler
 	moduleName      string
 	constants       []objects.Object
+	stringConsts    map[string]int // constant indexes of the strings
 	symbolTable     *SymbolTable
 	scopes          []CompilationScope
 	scopeIndex      int
 	moduleLoader    ModuleLoader
 	builtinModules  map[string]bool
-	compiledModules map[string]*objects.CompiledFunction
+	compiledModules map[string]int // constant indexes of the compiled modules
+	importDir       string
 	loops           []*Loop
 	loopIndex       int
 	trace           io.Writer
 	indent          int
+	lint            bool
+	lintIssues      []lint.Issue
+	typeCheck       bool
+	diagnostics     []Diagnostic
+	optimize        bool
+	warnings        []Warning
+	hostConstants   map[string]objects.Object
+	transformers    []Transformer
 }
 
 // NewCompiler creates a Compiler.
@@ -59,16 +70,27 @@ func NewCompiler(file *source.File, symbolTable *SymbolTable, constants []object
 		}
 	}
 
+	// the identical strings share a constant
+	stringConsts := make(map[string]int)
+	for idx, o := range constants {
+		if s, ok := o.(*objects.String); ok {
+			if _, exists := stringConsts[s.Value]; !exists {
+				stringConsts[s.Value] = idx
+			}
+		}
+	}
+
 	return &Compiler{
 		file:            file,
 		symbolTable:     symbolTable,
 		constants:       constants,
+		stringConsts:    stringConsts,
 		scopes:          []CompilationScope{mainScope},
 		scopeIndex:      0,
 		loopIndex:       -1,
 		trace:           trace,
 		builtinModules:  builtinModules,
-		compiledModules: make(map[string]*objects.CompiledFunction),
+		compiledModules: make(map[string]int),
 	}
 }
 
@@ -76,7 +98,7 @@ func NewCompiler(file *source.File, symbolTable *SymbolTable, constants []object
 func (c *Compiler) Compile(node ast.Node) error {
 	if c.trace != nil {
 		if node != nil {
-			defer un(trace(c, fmt.Sprintf("%s (%s)", node.String(), reflect.TypeOf(node).Elem().Name())))
+			defer un(trace(c, fmt.Sprintf("%s (%s)", node.String(), typeName(node))))
 		} else {
 			defer un(trace(c, "<nil>"))
 		}
@@ -84,6 +106,22 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 	switch node := node.(type) {
 	case *ast.File:
+		if len(c.transformers) > 0 {
+			transformed, err := c.transform(node)
+			if err != nil {
+				return err
+			}
+			node = transformed
+		}
+
+		if c.lint {
+			c.addLintIssues(lint.Check(node))
+		}
+
+		if c.typeCheck {
+			c.addDiagnostics(c.checkTypes(node))
+		}
+
 		for _, stmt := range node.Stmts {
 			if err := c.Compile(stmt); err != nil {
 				return err
@@ -110,6 +148,11 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 
 	case *ast.BinaryExpr:
+		if value, ok := c.constValue(node); ok {
+			c.compileConstant(node, value)
+			return nil
+		}
+
 		if node.Token == token.LAnd || node.Token == token.LOr {
 			return c.compileLogical(node)
 		}
@@ -204,6 +247,11 @@ func (c *Compiler) Compile(node ast.Node) error {
 		c.emit(node, OpNull)
 
 	case *ast.UnaryExpr:
+		if value, ok := c.constValue(node); ok {
+			c.compileConstant(node, value)
+			return nil
+		}
+
 		if err := c.Compile(node.Expr); err != nil {
 			return err
 		}
@@ -223,10 +271,8 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 	case *ast.IfStmt:
 		// open new symbol table for the statement
-		c.symbolTable = c.symbolTable.Fork(true)
-		defer func() {
-			c.symbolTable = c.symbolTable.Parent(false)
-		}()
+		c.enterBlock(node.End())
+		defer c.leaveBlock()
 
 		if node.Init != nil {
 			if err := c.Compile(node.Init); err != nil {
@@ -234,6 +280,15 @@ func (c *Compiler) Compile(node ast.Node) error {
 			}
 		}
 
+		if cond, ok := c.constValue(node.Cond); ok {
+			var els ast.Node
+			if node.Else != nil {
+				els = node.Else
+			}
+
+			return c.compileConstCond(node, cond, node.Body, els)
+		}
+
 		if err := c.Compile(node.Cond); err != nil {
 			return err
 		}
@@ -246,6 +301,9 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 
 		if node.Else != nil {
+			// the second jump is not reachable if the body returns
+			bodyReturns := c.lastInstructionIs(OpReturnValue) || c.lastInstructionIs(OpReturn)
+
 			// second jump placeholder
 			jumpPos2 := c.emit(node, OpJump, 0)
 
@@ -258,8 +316,12 @@ func (c *Compiler) Compile(node ast.Node) error {
 			}
 
 			// update second jump offset
+			lastJumpTarget := c.scopes[c.scopeIndex].lastJumpTarget
 			curPos = len(c.currentInstructions())
 			c.changeOperand(jumpPos2, curPos)
+			if bodyReturns {
+				c.scopes[c.scopeIndex].lastJumpTarget = lastJumpTarget
+			}
 		} else {
 			// update first jump offset
 			curPos := len(c.currentInstructions())
@@ -304,11 +366,24 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 
 	case *ast.Ident:
+		if value, ok := c.lookupConstant(node.Name); ok {
+			c.compileConstant(node, value)
+			break
+		}
+
 		symbol, _, ok := c.symbolTable.Resolve(node.Name)
 		if !ok {
 			return c.errorf(node, "unresolved reference '%s'", node.Name)
 		}
 
+		c.symbolTable.declaration(symbol).used = true
+
+		if symbol.Scope == ScopeBuiltin {
+			if notice := objects.Builtins[symbol.Index].Deprecated; notice != "" {
+				c.warnf(node, "'%s' is deprecated: %s", node.Name, notice)
+			}
+		}
+
 		switch symbol.Scope {
 		case ScopeGlobal:
 			c.emit(node, OpGetGlobal, symbol.Index)
@@ -392,6 +467,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 		for _, p := range node.Type.Params.List {
 			s := c.symbolTable.Define(p.Name)
+			c.addVariable(s, p.Pos())
 
 			// function arguments is not assigned directly.
 			s.LocalAssigned = true
@@ -408,7 +484,10 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 		freeSymbols := c.symbolTable.FreeSymbols()
 		numLocals := c.symbolTable.MaxSymbols()
-		instructions, sourceMap := c.leaveScope()
+		instructions, sourceMap, variables := c.leaveScope()
+		if c.optimize {
+			instructions, sourceMap = optimizeInstructions(instructions, sourceMap)
+		}
 
 		for _, s := range freeSymbols {
 			switch s.Scope {
@@ -426,7 +505,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 					//
 					// which translate into
 					//
-					//   0000 GETL    0
+					//   0000 GETLP   0
 					//   0002 CLOSURE ?     1
 					//   0006 DEFL    0
 					//
@@ -446,7 +525,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 					//
 					//   0000 NULL
 					//   0001 DEFL    0
-					//   0003 GETL    0
+					//   0003 GETLP   0
 					//   0005 CLOSURE ?     1
 					//   0009 SETL    0
 					//
@@ -457,9 +536,9 @@ func (c *Compiler) Compile(node ast.Node) error {
 					s.LocalAssigned = true
 				}
 
-				c.emit(node, OpGetLocal, s.Index)
+				c.emit(node, OpGetLocalPtr, s.Index)
 			case ScopeFree:
-				c.emit(node, OpGetFree, s.Index)
+				c.emit(node, OpGetFreePtr, s.Index)
 			}
 		}
 
@@ -468,6 +547,8 @@ func (c *Compiler) Compile(node ast.Node) error {
 			NumLocals:     numLocals,
 			NumParameters: len(node.Type.Params.List),
 			SourceMap:     sourceMap,
+			Variables:     variables,
+			Pos:           node.Pos(),
 		}
 
 		if len(freeSymbols) > 0 {
@@ -510,12 +591,12 @@ func (c *Compiler) Compile(node ast.Node) error {
 			c.emit(node, OpConstant, c.addConstant(&objects.String{Value: node.ModuleName}))
 			c.emit(node, OpGetBuiltinModule)
 		} else {
-			userMod, err := c.compileModule(node)
+			modIndex, err := c.compileModule(node)
 			if err != nil {
 				return err
 			}
 
-			c.emit(node, OpConstant, c.addConstant(userMod))
+			c.emit(node, OpConstant, modIndex)
 			c.emit(node, OpCall, 0)
 		}
 
@@ -552,6 +633,10 @@ func (c *Compiler) Compile(node ast.Node) error {
 		c.emit(node, OpImmutable)
 
 	case *ast.CondExpr:
+		if cond, ok := c.constValue(node.Cond); ok {
+			return c.compileConstCond(node, cond, node.True, node.False)
+		}
+
 		if err := c.Compile(node.Cond); err != nil {
 			return err
 		}
@@ -584,11 +669,17 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 // Bytecode returns a compiled bytecode.
 func (c *Compiler) Bytecode() *Bytecode {
+	instructions, sourceMap := c.currentInstructions(), c.currentSourceMap()
+	if c.optimize {
+		instructions, sourceMap = optimizeInstructions(instructions, sourceMap)
+	}
+
 	return &Bytecode{
 		FileSet: c.file.Set(),
 		MainFunction: &objects.CompiledFunction{
-			Instructions: c.currentInstructions(),
-			SourceMap:    c.currentSourceMap(),
+			Instructions: instructions,
+			SourceMap:    sourceMap,
+			Variables:    c.scopes[c.scopeIndex].variables,
 		},
 		Constants: c.constants,
 	}
@@ -601,11 +692,95 @@ func (c *Compiler) SetModuleLoader(moduleLoader ModuleLoader) {
 	c.moduleLoader = moduleLoader
 }
 
+// SetImportDir sets the directory of the source file being compiled. When
+// it's set, the default module loader (used when no module loader is set)
+// reads the user module files relative to the directory of the importing
+// file instead of the current working directory. The user modules are
+// compiled into the bytecode, so the bytecode of a source file can be run
+// without its module files.
+func (c *Compiler) SetImportDir(dir string) {
+	c.importDir = dir
+}
+
+// EnableLint enables or disables the static analysis of the compiled source
+// files including the user modules. The issues found are available through
+// LintIssues. Note that the linter does not affect the compilation result.
+func (c *Compiler) EnableLint(enabled bool) {
+	c.lint = enabled
+}
+
+// LintIssues returns the issues found by the linter.
+func (c *Compiler) LintIssues() []lint.Issue {
+	return c.lintIssues
+}
+
+// EnableTypeCheck enables or disables the type inference of the compiled
+// source files including the user modules. It's a best-effort analysis that
+// finds the operations that would certainly fail at runtime, e.g. adding a
+// string to an int, calling a value that is not a function, or calling a
+// function with a wrong number of arguments. The problems found are reported
+// as warnings through Diagnostics, and, they do not fail the compilation.
+func (c *Compiler) EnableTypeCheck(enabled bool) {
+	c.typeCheck = enabled
+}
+
+// Diagnostics returns the warnings found during the compilation.
+func (c *Compiler) Diagnostics() []Diagnostic {
+	return c.diagnostics
+}
+
+// EnableOptimizer enables or disables the optimization of the compiled code.
+// When enabled, the constant expressions (arithmetic, string concatenation,
+// comparisons and logical operations of literals) are evaluated at compile
+// time, the conditions known at compile time are not tested at runtime, and,
+// the unreachable instructions and the chains of jumps are removed from the
+// compiled code. The operations that would fail are left as they are so the
+// same errors are returned at runtime.
+func (c *Compiler) EnableOptimizer(enabled bool) {
+	c.optimize = enabled
+}
+
+// Warnings returns the non-fatal issues found during the compilation
+// including the user modules: the local variables that are declared but never
+// used, the variables that shadow the declarations in the outer scopes or the
+// builtin functions, and, the uses of the deprecated builtin functions.
+func (c *Compiler) Warnings() []Warning {
+	return c.warnings
+}
+
+// DefineConstant defines the host constant that can be referenced by name in
+// the compiled source files including the user modules. The constant is
+// substituted at compile time, and, the expressions and the conditions using
+// it are evaluated at compile time even if the optimizer is disabled, so e.g.
+// the branches of 'if env == "prod" { ... }' that are never taken are
+// compiled away. The value must be an int, float, string, char, bool or
+// undefined. A variable with the same name shadows the constant, and, the
+// constant shadows the builtin function with the same name.
+func (c *Compiler) DefineConstant(name string, value objects.Object) error {
+	if !isConstType(value) {
+		return fmt.Errorf("unsupported constant type: %s", value.TypeName())
+	}
+
+	if c.hostConstants == nil {
+		c.hostConstants = make(map[string]objects.Object)
+	}
+
+	c.hostConstants[name] = value
+
+	return nil
+}
+
 func (c *Compiler) fork(file *source.File, moduleName string, symbolTable *SymbolTable) *Compiler {
 	child := NewCompiler(file, symbolTable, nil, c.builtinModules, c.trace)
-	child.moduleName = moduleName       // name of the module to compile
-	child.parent = c                    // parent to set to current compiler
-	child.moduleLoader = c.moduleLoader // share module loader
+	child.moduleName = moduleName         // name of the module to compile
+	child.parent = c                      // parent to set to current compiler
+	child.moduleLoader = c.moduleLoader   // share module loader
+	child.importDir = c.importDir         // share import directory
+	child.lint = c.lint                   // lint modules too
+	child.typeCheck = c.typeCheck         // type check modules too
+	child.optimize = c.optimize           // optimize modules too
+	child.hostConstants = c.hostConstants // share host constants
+	child.transformers = c.transformers   // transform modules too
 
 	return child
 }
@@ -618,13 +793,71 @@ func (c *Compiler) errorf(node ast.Node, format string, args ...interface{}) err
 	}
 }
 
+func (c *Compiler) addLintIssues(issues []lint.Issue) {
+	if c.parent != nil {
+		// module compilers will report to their parent
+		c.parent.addLintIssues(issues)
+		return
+	}
+
+	c.lintIssues = append(c.lintIssues, issues...)
+}
+
+func (c *Compiler) addDiagnostics(diagnostics []Diagnostic) {
+	if c.parent != nil {
+		c.parent.addDiagnostics(diagnostics)
+		return
+	}
+
+	c.diagnostics = append(c.diagnostics, diagnostics...)
+}
+
+func (c *Compiler) warnf(node ast.Node, format string, args ...interface{}) {
+	c.addWarning(Warning{
+		Pos: c.file.Set().Position(node.Pos()),
+		Msg: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *Compiler) addWarning(w Warning) {
+	if c.parent != nil {
+		// module compilers will report to their parent
+		c.parent.addWarning(w)
+		return
+	}
+
+	c.warnings = append(c.warnings, w)
+}
+
+// checkUnusedSymbols reports the local variables of the current scope that
+// are declared but never used.
+func (c *Compiler) checkUnusedSymbols() {
+	for _, symbol := range c.symbolTable.unusedSymbols() {
+		c.addWarning(Warning{
+			Pos: c.file.Set().Position(symbol.pos),
+			Msg: fmt.Sprintf("'%s' declared but not used", symbol.Name),
+		})
+	}
+}
+
 func (c *Compiler) addConstant(o objects.Object) int {
 	if c.parent != nil {
 		// module compilers will use their parent's constants array
 		return c.parent.addConstant(o)
 	}
 
-	c.constants = append(c.constants, o)
+	s, isString := o.(*objects.String)
+	if isString {
+		if idx, exists := c.stringConsts[s.Value]; exists {
+			return idx
+		}
+	}
+
+	// the constants are shared by all VMs running the bytecode
+	c.constants = append(c.constants, objects.Freeze(o))
+	if isString {
+		c.stringConsts[s.Value] = len(c.constants) - 1
+	}
 
 	if c.trace != nil {
 		c.printTrace(fmt.Sprintf("CONST %04d %s", len(c.constants)-1, o))
@@ -653,6 +886,13 @@ func (c *Compiler) lastInstructionIs(op Opcode) bool {
 		return false
 	}
 
+	// the last instruction is not the end of all the paths if a jump
+	// targets the position after it (e.g. "if" without "else" at the end of
+	// a function)
+	if c.scopes[c.scopeIndex].lastJumpTarget == len(c.currentInstructions()) {
+		return false
+	}
+
 	return c.scopes[c.scopeIndex].lastInstructions[0].Opcode == op
 }
 
@@ -681,6 +921,10 @@ func (c *Compiler) changeOperand(opPos int, operand ...int) {
 	op := Opcode(c.currentInstructions()[opPos])
 	inst := MakeInstruction(op, operand...)
 
+	if operand[0] > c.scopes[c.scopeIndex].lastJumpTarget {
+		c.scopes[c.scopeIndex].lastJumpTarget = operand[0]
+	}
+
 	c.replaceInstruction(opPos, inst)
 }
 
@@ -729,3 +973,11 @@ func un(c *Compiler) {
 	c.indent--
 	c.printTrace("}")
 }
+
+// typeName returns the name of the type of v without the package and the
+// pointer (e.g. "Int" for *objects.Int). It does not use reflect package
+// directly, so it works with the limited reflection of TinyGo.
+func typeName(v interface{}) string {
+	name := fmt.Sprintf("%T", v)
+	return name[strings.LastIndexAny(name, ".*")+1:]
+}
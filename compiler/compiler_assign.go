@@ -24,22 +24,43 @@ func (c *Compiler) compileAssign(node ast.Node, lhs, rhs []ast.Expr, op token.To
 
 	symbol, depth, exists := c.symbolTable.Resolve(ident)
 	if op == token.Define {
-		if depth == 0 && exists {
+		// a global variable can shadow a builtin function (e.g. the
+		// scripts written before the builtin function was added)
+		if depth == 0 && exists && symbol.Scope != ScopeBuiltin {
 			return c.errorf(node, "'%s' redeclared in this block", ident)
 		}
 
+		if exists {
+			c.checkShadowing(lhs[0], symbol)
+		}
+
 		symbol = c.symbolTable.Define(ident)
+		symbol.pos = lhs[0].Pos()
+		c.addVariable(symbol, lhs[0].Pos())
 	} else {
+		if _, isConst := c.lookupConstant(ident); isConst {
+			return c.errorf(node, "cannot assign to constant '%s'", ident)
+		}
+
 		if !exists {
 			return c.errorf(node, "unresolved reference '%s'", ident)
 		}
 	}
 
+	if numSel > 0 {
+		// the variable is used to access its elements
+		c.symbolTable.declaration(symbol).used = true
+	}
+
 	// +=, -=, *=, /=
 	if op != token.Assign && op != token.Define {
+		// reading the variable to update it is not a use
+		decl := c.symbolTable.declaration(symbol)
+		used := decl.used
 		if err := c.Compile(lhs[0]); err != nil {
 			return err
 		}
+		decl.used = used
 	}
 
 	// compile RHSs
@@ -131,3 +152,19 @@ func resolveAssignLHS(expr ast.Expr) (name string, selectors []ast.Expr) {
 
 	return
 }
+
+func (c *Compiler) checkShadowing(node ast.Node, outer *Symbol) {
+	name := outer.Name
+
+	if outer.Scope == ScopeBuiltin {
+		c.warnf(node, "'%s' shadows builtin function", name)
+		return
+	}
+
+	if decl := c.symbolTable.declaration(outer); decl.pos.IsValid() {
+		c.warnf(node, "'%s' shadows declaration at %s", name, c.file.Set().Position(decl.pos))
+		return
+	}
+
+	c.warnf(node, "'%s' shadows declaration in outer scope", name)
+}
@@ -5,10 +5,8 @@ import (
 )
 
 func (c *Compiler) compileForStmt(stmt *ast.ForStmt) error {
-	c.symbolTable = c.symbolTable.Fork(true)
-	defer func() {
-		c.symbolTable = c.symbolTable.Parent(false)
-	}()
+	c.enterBlock(stmt.End())
+	defer c.leaveBlock()
 
 	// init statement
 	if stmt.Init != nil {
@@ -22,8 +20,15 @@ func (c *Compiler) compileForStmt(stmt *ast.ForStmt) error {
 
 	// condition expression
 	postCondPos := -1
-	if stmt.Cond != nil {
-		if err := c.Compile(stmt.Cond); err != nil {
+	cond := stmt.Cond
+	if cond != nil {
+		if value, ok := c.constValue(cond); ok && !value.IsFalsy() {
+			// no need to test the condition that is always true
+			cond = nil
+		}
+	}
+	if cond != nil {
+		if err := c.Compile(cond); err != nil {
 			return err
 		}
 		// condition jump position
@@ -72,10 +77,8 @@ func (c *Compiler) compileForStmt(stmt *ast.ForStmt) error {
 }
 
 func (c *Compiler) compileForInStmt(stmt *ast.ForInStmt) error {
-	c.symbolTable = c.symbolTable.Fork(true)
-	defer func() {
-		c.symbolTable = c.symbolTable.Parent(false)
-	}()
+	c.enterBlock(stmt.End())
+	defer c.leaveBlock()
 
 	// for-in statement is compiled like following:
 	//
@@ -122,6 +125,8 @@ func (c *Compiler) compileForInStmt(stmt *ast.ForInStmt) error {
 	// assign key variable
 	if stmt.Key.Name != "_" {
 		keySymbol := c.symbolTable.Define(stmt.Key.Name)
+		keySymbol.pos = stmt.Key.NamePos
+		c.addVariable(keySymbol, stmt.Key.NamePos)
 		if itSymbol.Scope == ScopeGlobal {
 			c.emit(stmt, OpGetGlobal, itSymbol.Index)
 		} else {
@@ -138,6 +143,8 @@ func (c *Compiler) compileForInStmt(stmt *ast.ForInStmt) error {
 	// assign value variable
 	if stmt.Value.Name != "_" {
 		valueSymbol := c.symbolTable.Define(stmt.Value.Name)
+		valueSymbol.pos = stmt.Value.NamePos
+		c.addVariable(valueSymbol, stmt.Value.NamePos)
 		if itSymbol.Scope == ScopeGlobal {
 			c.emit(stmt, OpGetGlobal, itSymbol.Index)
 		} else {
@@ -0,0 +1,50 @@
+package compiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+)
+
+func TestCompiler_Lint(t *testing.T) {
+	src := []byte(`
+mod := import("mod")
+out := func() { unused := 1; return mod }`)
+
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("main", -1, len(src))
+	parsed, err := parser.ParseFile(file, src, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := compiler.NewCompiler(file, nil, nil, nil, nil)
+	c.SetModuleLoader(func(moduleName string) ([]byte, error) {
+		return []byte(`math := import("math"); export 1; a := 2`), nil
+	})
+
+	// disabled by default
+	assert.NoError(t, c.Compile(parsed))
+	assert.Equal(t, 0, len(c.LintIssues()))
+
+	c = compiler.NewCompiler(file, nil, nil, nil, nil)
+	c.SetModuleLoader(func(moduleName string) ([]byte, error) {
+		return []byte(`math := import("math"); export 1; a := 2`), nil
+	})
+	c.EnableLint(true)
+	assert.NoError(t, c.Compile(parsed))
+
+	var issues []string
+	for _, issue := range c.LintIssues() {
+		issues = append(issues, issue.String())
+	}
+	assert.Equal(t, strings.Join([]string{
+		"main:3:17: 'unused' declared but not used",
+		"mod:1:1: imported and not used: 'math'",
+		"mod:1:35: unreachable code",
+	}, "\n"), strings.Join(issues, "\n"))
+}
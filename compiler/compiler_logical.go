@@ -3,9 +3,14 @@ package compiler
 import (
 	"github.com/d5/tengo/compiler/ast"
 	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
 )
 
 func (c *Compiler) compileLogical(node *ast.BinaryExpr) error {
+	if lhs, ok := c.constValue(node.LHS); ok {
+		return c.compileConstLogical(node, lhs)
+	}
+
 	// left side term
 	if err := c.Compile(node.LHS); err != nil {
 		return err
@@ -28,3 +33,23 @@ func (c *Compiler) compileLogical(node *ast.BinaryExpr) error {
 
 	return nil
 }
+
+// compileConstLogical compiles the logical expression whose left side term is
+// known at compile time: the right side term is either the result or never
+// evaluated.
+func (c *Compiler) compileConstLogical(node *ast.BinaryExpr, lhs objects.Object) error {
+	if lhs.IsFalsy() == (node.Token == token.LOr) {
+		return c.Compile(node.RHS)
+	}
+
+	c.compileConstant(node.LHS, lhs)
+	jumpPos := c.emit(node, OpJump, 0)
+
+	if err := c.Compile(node.RHS); err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+
+	return nil
+}
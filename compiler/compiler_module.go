@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"io/ioutil"
+	"path/filepath"
 	"strings"
 
 	"github.com/d5/tengo/compiler/ast"
@@ -9,51 +10,54 @@ import (
 	"github.com/d5/tengo/objects"
 )
 
-func (c *Compiler) compileModule(expr *ast.ImportExpr) (*objects.CompiledFunction, error) {
-	compiledModule, exists := c.loadCompiledModule(expr.ModuleName)
-	if exists {
-		return compiledModule, nil
-	}
-
+// compileModule compiles the user module, or, reuses the module compiled
+// already, and returns the index of the compiled module in the constants.
+func (c *Compiler) compileModule(expr *ast.ImportExpr) (int, error) {
 	moduleName := expr.ModuleName
-
-	// read module source from loader
-	var moduleSrc []byte
 	if c.moduleLoader == nil {
 		// default loader: read from local file
 		if !strings.HasSuffix(moduleName, ".tengo") {
 			moduleName += ".tengo"
 		}
 
-		if err := c.checkCyclicImports(expr, moduleName); err != nil {
-			return nil, err
+		if c.importDir != "" && !filepath.IsAbs(moduleName) {
+			moduleName = filepath.Join(c.importDir, moduleName)
 		}
+	}
+
+	if modIndex, exists := c.loadCompiledModule(moduleName); exists {
+		return modIndex, nil
+	}
+
+	if err := c.checkCyclicImports(expr, moduleName); err != nil {
+		return 0, err
+	}
 
+	// read module source from loader
+	var moduleSrc []byte
+	if c.moduleLoader == nil {
 		var err error
 		moduleSrc, err = ioutil.ReadFile(moduleName)
 		if err != nil {
-			return nil, c.errorf(expr, "module file read error: %s", err.Error())
+			return 0, c.errorf(expr, "module file read error: %s", err.Error())
 		}
 	} else {
-		if err := c.checkCyclicImports(expr, moduleName); err != nil {
-			return nil, err
-		}
-
 		var err error
 		moduleSrc, err = c.moduleLoader(moduleName)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 	}
 
 	compiledModule, err := c.doCompileModule(moduleName, moduleSrc)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	c.storeCompiledModule(moduleName, compiledModule)
+	modIndex := c.addConstant(compiledModule)
+	c.storeCompiledModule(moduleName, modIndex)
 
-	return compiledModule, nil
+	return modIndex, nil
 }
 
 func (c *Compiler) checkCyclicImports(node ast.Node, moduleName string) error {
@@ -89,10 +93,17 @@ func (c *Compiler) doCompileModule(moduleName string, src []byte) (*objects.Comp
 
 	// compile module
 	moduleCompiler := c.fork(modFile, moduleName, symbolTable)
+	if c.moduleLoader == nil && c.importDir != "" {
+		// nested modules are relative to the module file
+		moduleCompiler.importDir = filepath.Dir(moduleName)
+	}
 	if err := moduleCompiler.Compile(file); err != nil {
 		return nil, err
 	}
 
+	// module variables are local to the module
+	moduleCompiler.checkUnusedSymbols()
+
 	// add OpReturn (== export undefined) if export is missing
 	if !moduleCompiler.lastInstructionIs(OpReturnValue) {
 		moduleCompiler.emit(nil, OpReturn)
@@ -104,20 +115,20 @@ func (c *Compiler) doCompileModule(moduleName string, src []byte) (*objects.Comp
 	return compiledFunc, nil
 }
 
-func (c *Compiler) loadCompiledModule(moduleName string) (mod *objects.CompiledFunction, ok bool) {
+func (c *Compiler) loadCompiledModule(moduleName string) (modIndex int, ok bool) {
 	if c.parent != nil {
 		return c.parent.loadCompiledModule(moduleName)
 	}
 
-	mod, ok = c.compiledModules[moduleName]
+	modIndex, ok = c.compiledModules[moduleName]
 
 	return
 }
 
-func (c *Compiler) storeCompiledModule(moduleName string, module *objects.CompiledFunction) {
+func (c *Compiler) storeCompiledModule(moduleName string, modIndex int) {
 	if c.parent != nil {
-		c.parent.storeCompiledModule(moduleName, module)
+		c.parent.storeCompiledModule(moduleName, modIndex)
 	}
 
-	c.compiledModules[moduleName] = module
+	c.compiledModules[moduleName] = modIndex
 }
@@ -0,0 +1,67 @@
+package compiler_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
+
+func TestCompiler_SetImportDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tengo-import-dir")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	files := map[string]string{
+		"lib/a.tengo": `b := import("b"); export b + 1`,
+		"lib/b.tengo": `export 1`,
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(src), 0644))
+	}
+
+	src := []byte(`a := import("lib/a"); b := import("lib/a")`)
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("main", -1, len(src))
+	parsed, err := parser.ParseFile(file, src, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := compiler.NewCompiler(file, nil, nil, nil, nil)
+	c.SetImportDir(dir)
+	if !assert.NoError(t, c.Compile(parsed)) {
+		return
+	}
+
+	// each module is compiled into the bytecode once
+	var modules []string
+	for _, f := range fileSet.Files {
+		modules = append(modules, f.Name)
+	}
+	assert.Equal(t, 3, len(modules))
+	assert.Equal(t, filepath.Join(dir, "lib", "a.tengo"), modules[1])
+	assert.Equal(t, filepath.Join(dir, "lib", "b.tengo"), modules[2])
+
+	var numModules int
+	for _, cn := range c.Bytecode().Constants {
+		if _, ok := cn.(*objects.CompiledFunction); ok {
+			numModules++
+		}
+	}
+	assert.Equal(t, 2, numModules)
+
+	// without the import directory
+	c = compiler.NewCompiler(file, nil, nil, nil, nil)
+	assert.Error(t, c.Compile(parsed))
+}
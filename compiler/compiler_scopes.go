@@ -1,6 +1,11 @@
 package compiler
 
-import "github.com/d5/tengo/compiler/source"
+import (
+	"strings"
+
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
 
 func (c *Compiler) currentInstructions() []byte {
 	return c.scopes[c.scopeIndex].instructions
@@ -26,13 +31,15 @@ func (c *Compiler) enterScope() {
 	}
 }
 
-func (c *Compiler) leaveScope() (instructions []byte, sourceMap map[int]source.Pos) {
+func (c *Compiler) leaveScope() (instructions []byte, sourceMap map[int]source.Pos, variables []objects.VariableInfo) {
 	instructions = c.currentInstructions()
 	sourceMap = c.currentSourceMap()
+	variables = c.scopes[c.scopeIndex].variables
 
 	c.scopes = c.scopes[:len(c.scopes)-1]
 	c.scopeIndex--
 
+	c.checkUnusedSymbols()
 	c.symbolTable = c.symbolTable.Parent(true)
 
 	if c.trace != nil {
@@ -41,3 +48,42 @@ func (c *Compiler) leaveScope() (instructions []byte, sourceMap map[int]source.P
 
 	return
 }
+
+// enterBlock opens a new symbol table for the block statement that ends at
+// the position.
+func (c *Compiler) enterBlock(end source.Pos) {
+	c.symbolTable = c.symbolTable.Fork(true)
+	c.scopes[c.scopeIndex].blockEnds = append(c.scopes[c.scopeIndex].blockEnds, end)
+}
+
+func (c *Compiler) leaveBlock() {
+	c.checkUnusedSymbols()
+	c.symbolTable = c.symbolTable.Parent(false)
+
+	blockEnds := c.scopes[c.scopeIndex].blockEnds
+	c.scopes[c.scopeIndex].blockEnds = blockEnds[:len(blockEnds)-1]
+}
+
+// addVariable records the debug information of the variable defined at the
+// position. The variable is valid until the end of the current block.
+func (c *Compiler) addVariable(symbol *Symbol, pos source.Pos) {
+	if strings.HasPrefix(symbol.Name, ":") {
+		// internal variables
+		return
+	}
+
+	scope := &c.scopes[c.scopeIndex]
+
+	end := source.NoPos
+	if len(scope.blockEnds) > 0 {
+		end = scope.blockEnds[len(scope.blockEnds)-1]
+	}
+
+	scope.variables = append(scope.variables, objects.VariableInfo{
+		Name:   symbol.Name,
+		Index:  symbol.Index,
+		Global: symbol.Scope == ScopeGlobal,
+		Pos:    pos,
+		End:    end,
+	})
+}
@@ -329,6 +329,23 @@ func TestCompiler_Compile(t *testing.T) {
 				intObject(5),
 				intObject(6))))
 
+	// identical strings share a constant
+	expect(t, `a := {a: "a"}; a.a = "b" + "a"`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpMap, 2),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpAdd),
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetSelGlobal, 0, 1)),
+			objectsArray(
+				stringObject("a"),
+				stringObject("b"))))
+
 	expect(t, `[1, 2, 3][1 + 1]`,
 		bytecode(
 			concat(
@@ -725,7 +742,7 @@ func TestCompiler_Compile(t *testing.T) {
 					compiler.MakeInstruction(compiler.OpAdd),
 					compiler.MakeInstruction(compiler.OpReturnValue)),
 				compiledFunction(1, 1,
-					compiler.MakeInstruction(compiler.OpGetLocal, 0),
+					compiler.MakeInstruction(compiler.OpGetLocalPtr, 0),
 					compiler.MakeInstruction(compiler.OpClosure, 0, 1),
 					compiler.MakeInstruction(compiler.OpPop),
 					compiler.MakeInstruction(compiler.OpReturn)))))
@@ -751,12 +768,12 @@ func(a) {
 					compiler.MakeInstruction(compiler.OpAdd),
 					compiler.MakeInstruction(compiler.OpReturnValue)),
 				compiledFunction(1, 1,
-					compiler.MakeInstruction(compiler.OpGetFree, 0),
-					compiler.MakeInstruction(compiler.OpGetLocal, 0),
+					compiler.MakeInstruction(compiler.OpGetFreePtr, 0),
+					compiler.MakeInstruction(compiler.OpGetLocalPtr, 0),
 					compiler.MakeInstruction(compiler.OpClosure, 0, 2),
 					compiler.MakeInstruction(compiler.OpReturnValue)),
 				compiledFunction(1, 1,
-					compiler.MakeInstruction(compiler.OpGetLocal, 0),
+					compiler.MakeInstruction(compiler.OpGetLocalPtr, 0),
 					compiler.MakeInstruction(compiler.OpClosure, 1, 1),
 					compiler.MakeInstruction(compiler.OpReturnValue)))))
 
@@ -801,14 +818,14 @@ func() {
 				compiledFunction(1, 0,
 					compiler.MakeInstruction(compiler.OpConstant, 2),
 					compiler.MakeInstruction(compiler.OpDefineLocal, 0),
-					compiler.MakeInstruction(compiler.OpGetFree, 0),
-					compiler.MakeInstruction(compiler.OpGetLocal, 0),
+					compiler.MakeInstruction(compiler.OpGetFreePtr, 0),
+					compiler.MakeInstruction(compiler.OpGetLocalPtr, 0),
 					compiler.MakeInstruction(compiler.OpClosure, 4, 2),
 					compiler.MakeInstruction(compiler.OpReturnValue)),
 				compiledFunction(1, 0,
 					compiler.MakeInstruction(compiler.OpConstant, 1),
 					compiler.MakeInstruction(compiler.OpDefineLocal, 0),
-					compiler.MakeInstruction(compiler.OpGetLocal, 0),
+					compiler.MakeInstruction(compiler.OpGetLocalPtr, 0),
 					compiler.MakeInstruction(compiler.OpClosure, 5, 1),
 					compiler.MakeInstruction(compiler.OpReturnValue)))))
 
@@ -875,6 +892,9 @@ func() {
 				intObject(1))))
 
 	expectError(t, `import("user1")`, "no such file or directory") // unknown module name
+
+	expectError(t, `a := {1: 2}`, "test:1:7: invalid map key: 1 (map keys must be identifiers)")
+	expectError(t, `a := {b: 1, "c": 2}`, "test:1:13: invalid map key: \"c\" (map keys must be identifiers)")
 }
 
 func concat(instructions ...[]byte) []byte {
@@ -0,0 +1,18 @@
+package compiler
+
+import "github.com/d5/tengo/compiler/source"
+
+// Diagnostic represents a non-fatal problem found in the source code during
+// the compilation.
+type Diagnostic struct {
+	Pos source.FilePos
+	Msg string
+}
+
+func (d Diagnostic) String() string {
+	if d.Pos.Filename != "" || d.Pos.IsValid() {
+		return d.Pos.String() + ": " + d.Msg
+	}
+
+	return d.Msg
+}
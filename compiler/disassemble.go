@@ -0,0 +1,116 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/d5/tengo/objects"
+)
+
+// maxDisassembleValueLen is the maximum length of a constant value shown
+// next to an instruction in the disassembler output.
+const maxDisassembleValueLen = 40
+
+// Disassemble returns an annotated listing of the bytecode. It prints the
+// instructions of the main function and every compiled function constant,
+// followed by the constants table. Each instruction is shown with its
+// offset, opcode, operands, the source position it was compiled from, and
+// a comment resolving the operands where possible (constant values,
+// builtin function names, and jump targets).
+func Disassemble(b *Bytecode) string {
+	var sb strings.Builder
+
+	sb.WriteString("== main ==\n")
+	disassembleFunction(&sb, b, b.MainFunction)
+
+	for cidx, cn := range b.Constants {
+		fn, ok := cn.(*objects.CompiledFunction)
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "\n== constant %d: compiled function (params: %d, locals: %d) ==\n",
+			cidx, fn.NumParameters, fn.NumLocals)
+		disassembleFunction(&sb, b, fn)
+	}
+
+	if len(b.Constants) > 0 {
+		sb.WriteString("\n== constants ==\n")
+		for cidx, cn := range b.Constants {
+			fmt.Fprintf(&sb, "[% 3d] %s (%s)\n", cidx, disassembleValue(cn), typeName(cn))
+		}
+	}
+
+	return sb.String()
+}
+
+func disassembleFunction(sb *strings.Builder, b *Bytecode, fn *objects.CompiledFunction) {
+	ins := fn.Instructions
+
+	i := 0
+	for i < len(ins) {
+		op := Opcode(ins[i])
+		operands, read := ReadOperands(OpcodeOperands[op], ins[i+1:])
+
+		var ops []string
+		for _, o := range operands {
+			ops = append(ops, fmt.Sprintf("%d", o))
+		}
+
+		pos := "-"
+		if b.FileSet != nil {
+			if p := b.FileSet.Position(fn.SourceMap[i]); p.IsValid() {
+				pos = p.String()
+			}
+		}
+
+		line := fmt.Sprintf("%04d %-7s %-11s %-20s", i, OpcodeNames[op], strings.Join(ops, " "), pos)
+		if comment := disassembleComment(b, op, operands); comment != "" {
+			line += " ; " + comment
+		}
+		sb.WriteString(strings.TrimRight(line, " "))
+		sb.WriteByte('\n')
+
+		i += 1 + read
+	}
+}
+
+func disassembleComment(b *Bytecode, op Opcode, operands []int) string {
+	switch op {
+	case OpConstant, OpClosure:
+		if operands[0] < len(b.Constants) {
+			cn := b.Constants[operands[0]]
+			if _, ok := cn.(*objects.CompiledFunction); ok {
+				return fmt.Sprintf("compiled function (constant %d)", operands[0])
+			}
+
+			return disassembleValue(cn)
+		}
+	case OpGetBuiltin:
+		if operands[0] < len(objects.Builtins) {
+			return objects.Builtins[operands[0]].Name
+		}
+	case OpAddLocalConst:
+		if operands[1] < len(b.Constants) {
+			return disassembleValue(b.Constants[operands[1]])
+		}
+	case OpJumpFalsy, OpAndJump, OpOrJump, OpJump,
+		OpJumpNotEqual, OpJumpEqual, OpJumpNotGreater, OpJumpNotGreaterEqual:
+		return fmt.Sprintf("-> %04d", operands[0])
+	}
+
+	return ""
+}
+
+func disassembleValue(o objects.Object) string {
+	if _, ok := o.(*objects.CompiledFunction); ok {
+		return "<compiled-function>"
+	}
+
+	s := o.String()
+	if len(s) > maxDisassembleValueLen {
+		s = s[:maxDisassembleValueLen-3] + "..."
+	}
+
+	return s
+}
@@ -0,0 +1,38 @@
+package compiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+)
+
+func TestDisassemble(t *testing.T) {
+	b, _, err := traceCompile(`
+a := 1
+f := func(x) {
+	if x > 2 { return len("abc") }
+	return x * a
+}
+f(3)`, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	out := compiler.Disassemble(b)
+	for _, s := range []string{
+		"== main ==",
+		"0000 CONST   0           test:2:6             ; 1",
+		"CONST   3           test:3:6             ; compiled function (constant 3)",
+		"== constant 3: compiled function (params: 1, locals: 1) ==",
+		"BUILTIN 3           test:4:20            ; len",
+		`; "abc"`,
+		"JMPF    17",
+		"; -> 0017",
+		"== constants ==",
+		"[  3] <compiled-function> (CompiledFunction)",
+	} {
+		assert.True(t, strings.Contains(out, s), s)
+	}
+}
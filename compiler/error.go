@@ -18,3 +18,8 @@ func (e *Error) Error() string {
 	filePos := e.fileSet.Position(e.node.Pos())
 	return fmt.Sprintf("%s: %s", filePos, e.error.Error())
 }
+
+// Position returns the position in the source code.
+func (e *Error) Position() source.FilePos {
+	return e.fileSet.Position(e.node.Pos())
+}
@@ -0,0 +1,17 @@
+package lint
+
+import "github.com/d5/tengo/compiler/source"
+
+// Issue represents a problem found by the linter.
+type Issue struct {
+	Pos source.FilePos
+	Msg string
+}
+
+func (i Issue) String() string {
+	if i.Pos.Filename != "" || i.Pos.IsValid() {
+		return i.Pos.String() + ": " + i.Msg
+	}
+
+	return i.Msg
+}
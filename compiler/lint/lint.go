@@ -0,0 +1,516 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+type object struct {
+	pos    source.Pos
+	used   bool
+	param  bool
+	module string // module name if the variable is assigned an import
+}
+
+type scope struct {
+	parent  *scope
+	fn      bool // function scope (otherwise, block scope)
+	objects map[string]*object
+}
+
+type linter struct {
+	file   *source.File
+	scope  *scope
+	issues []issue
+}
+
+type issue struct {
+	pos source.Pos
+	msg string
+}
+
+// Check runs the static analysis on the parsed file and returns the issues
+// found, sorted by their positions. It reports:
+//
+//   - local variables that are declared but never used
+//   - imported modules that are never used
+//   - declarations shadowing the outer ones (or the builtin functions)
+//   - unreachable code after return, export, break, and continue
+//   - conditions that are always true or always false
+//   - equality comparisons between literals of incompatible types
+//
+// Variables in the global scope are not reported as unused because they can
+// be accessed by the host application.
+func Check(file *ast.File) []Issue {
+	l := &linter{file: file.InputFile}
+
+	l.openScope(false)
+	l.checkStmts(file.Stmts)
+	l.closeScope()
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].pos < l.issues[j].pos
+	})
+
+	var issues []Issue
+	for _, i := range l.issues {
+		issues = append(issues, Issue{Pos: l.file.Position(i.pos), Msg: i.msg})
+	}
+
+	return issues
+}
+
+func (l *linter) report(pos source.Pos, format string, args ...interface{}) {
+	l.issues = append(l.issues, issue{pos: pos, msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) openScope(fn bool) {
+	l.scope = &scope{
+		parent:  l.scope,
+		fn:      fn,
+		objects: make(map[string]*object),
+	}
+}
+
+func (l *linter) closeScope() {
+	local := false
+	for s := l.scope; s != nil; s = s.parent {
+		if s.fn {
+			local = true
+			break
+		}
+	}
+
+	for name, o := range l.scope.objects {
+		if o.used || o.param {
+			continue
+		}
+
+		if o.module != "" {
+			l.report(o.pos, "imported and not used: '%s'", o.module)
+		} else if local {
+			l.report(o.pos, "'%s' declared but not used", name)
+		}
+	}
+
+	l.scope = l.scope.parent
+}
+
+func (l *linter) lookup(s *scope, name string) *object {
+	for ; s != nil; s = s.parent {
+		if o, ok := s.objects[name]; ok {
+			return o
+		}
+	}
+
+	return nil
+}
+
+func (l *linter) define(ident *ast.Ident, param bool, module string) {
+	if ident.Name == "_" {
+		return
+	}
+
+	if _, exists := l.scope.objects[ident.Name]; !exists && l.scope.parent != nil {
+		if outer := l.lookup(l.scope.parent, ident.Name); outer != nil {
+			l.report(ident.NamePos, "'%s' shadows declaration at %s", ident.Name, l.file.Position(outer.pos))
+		} else if isBuiltin(ident.Name) {
+			l.report(ident.NamePos, "'%s' shadows builtin function", ident.Name)
+		}
+	}
+
+	l.scope.objects[ident.Name] = &object{
+		pos:    ident.NamePos,
+		param:  param,
+		module: module,
+	}
+}
+
+func (l *linter) checkStmts(stmts []ast.Stmt) {
+	terminated, reported := false, false
+	for _, stmt := range stmts {
+		if _, isEmpty := stmt.(*ast.EmptyStmt); isEmpty {
+			continue
+		}
+
+		if terminated && !reported {
+			l.report(stmt.Pos(), "unreachable code")
+			reported = true
+		}
+
+		l.checkStmt(stmt)
+
+		if isTerminating(stmt) {
+			terminated = true
+		}
+	}
+}
+
+func (l *linter) checkStmt(stmt ast.Stmt) {
+	switch stmt := stmt.(type) {
+	case *ast.ExprStmt:
+		l.checkExpr(stmt.Expr)
+	case *ast.AssignStmt:
+		if stmt.Token == token.Define {
+			var module string
+			if len(stmt.RHS) == 1 {
+				if imp, ok := stmt.RHS[0].(*ast.ImportExpr); ok {
+					module = imp.ModuleName
+				}
+			}
+
+			// the symbol is defined before the right-hand side is compiled
+			for _, lhs := range stmt.LHS {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					l.define(ident, false, module)
+				}
+			}
+		} else {
+			for _, lhs := range stmt.LHS {
+				l.checkAssignTarget(lhs)
+			}
+		}
+
+		for _, rhs := range stmt.RHS {
+			l.checkExpr(rhs)
+		}
+	case *ast.IncDecStmt:
+		l.checkAssignTarget(stmt.Expr)
+	case *ast.ReturnStmt:
+		l.checkExpr(stmt.Result)
+	case *ast.ExportStmt:
+		l.checkExpr(stmt.Result)
+	case *ast.BlockStmt:
+		l.checkStmts(stmt.Stmts)
+	case *ast.IfStmt:
+		l.openScope(false)
+		if stmt.Init != nil {
+			l.checkStmt(stmt.Init)
+		}
+		l.checkCondition(stmt.Cond, true)
+		l.checkExpr(stmt.Cond)
+		l.checkStmts(stmt.Body.Stmts)
+		if stmt.Else != nil {
+			l.checkStmt(stmt.Else)
+		}
+		l.closeScope()
+	case *ast.ForStmt:
+		l.openScope(false)
+		if stmt.Init != nil {
+			l.checkStmt(stmt.Init)
+		}
+		if stmt.Cond != nil {
+			// 'for true {}' is a valid way of writing an infinite loop
+			l.checkCondition(stmt.Cond, false)
+			l.checkExpr(stmt.Cond)
+		}
+		if stmt.Post != nil {
+			l.checkStmt(stmt.Post)
+		}
+		l.checkStmts(stmt.Body.Stmts)
+		l.closeScope()
+	case *ast.ForInStmt:
+		l.openScope(false)
+		l.checkExpr(stmt.Iterable)
+		l.define(stmt.Key, false, "")
+		l.define(stmt.Value, false, "")
+		l.checkStmts(stmt.Body.Stmts)
+		l.closeScope()
+	}
+}
+
+// checkAssignTarget checks the left-hand side of an assignment. Assigning a
+// value to the variable is not considered as a use of the variable, but,
+// assigning to its element or field is.
+func (l *linter) checkAssignTarget(expr ast.Expr) {
+	if _, ok := expr.(*ast.Ident); ok {
+		return
+	}
+
+	l.checkExpr(expr)
+}
+
+func (l *linter) checkExpr(expr ast.Expr) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if o := l.lookup(l.scope, expr.Name); o != nil {
+			o.used = true
+		}
+	case *ast.BinaryExpr:
+		l.checkExpr(expr.LHS)
+		l.checkExpr(expr.RHS)
+
+		if expr.Token == token.Equal || expr.Token == token.NotEqual {
+			lk, rk := literalKind(expr.LHS), literalKind(expr.RHS)
+			if lk != "" && rk != "" && lk != rk {
+				l.report(expr.TokenPos, "suspicious comparison of %s and %s literals: always %t",
+					lk, rk, expr.Token == token.NotEqual)
+			}
+		}
+	case *ast.UnaryExpr:
+		l.checkExpr(expr.Expr)
+	case *ast.ParenExpr:
+		l.checkExpr(expr.Expr)
+	case *ast.CondExpr:
+		l.checkCondition(expr.Cond, true)
+		l.checkExpr(expr.Cond)
+		l.checkExpr(expr.True)
+		l.checkExpr(expr.False)
+	case *ast.CallExpr:
+		l.checkExpr(expr.Func)
+		for _, arg := range expr.Args {
+			l.checkExpr(arg)
+		}
+	case *ast.IndexExpr:
+		l.checkExpr(expr.Expr)
+		l.checkExpr(expr.Index)
+	case *ast.SliceExpr:
+		l.checkExpr(expr.Expr)
+		l.checkExpr(expr.Low)
+		l.checkExpr(expr.High)
+	case *ast.SelectorExpr:
+		l.checkExpr(expr.Expr)
+	case *ast.ArrayLit:
+		for _, elem := range expr.Elements {
+			l.checkExpr(elem)
+		}
+	case *ast.MapLit:
+		for _, elem := range expr.Elements {
+			l.checkExpr(elem.Value)
+		}
+	case *ast.ErrorExpr:
+		l.checkExpr(expr.Expr)
+	case *ast.ImmutableExpr:
+		l.checkExpr(expr.Expr)
+	case *ast.FuncLit:
+		l.openScope(true)
+		for _, param := range expr.Type.Params.List {
+			l.define(param, true, "")
+		}
+		l.checkStmts(expr.Body.Stmts)
+		l.closeScope()
+	}
+}
+
+func (l *linter) checkCondition(cond ast.Expr, reportTrue bool) {
+	if v, ok := constTruth(cond); ok && (!v || reportTrue) {
+		l.report(cond.Pos(), "condition is always %t", v)
+	}
+}
+
+// constTruth evaluates the truthiness of the constant expression. It returns
+// false 'ok' if the expression is not a constant.
+func constTruth(expr ast.Expr) (v bool, ok bool) {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return constTruth(expr.Expr)
+	case *ast.BoolLit:
+		return expr.Value, true
+	case *ast.IntLit:
+		return expr.Value != 0, true
+	case *ast.FloatLit:
+		return true, true
+	case *ast.CharLit:
+		return expr.Value != 0, true
+	case *ast.StringLit:
+		return expr.Value != "", true
+	case *ast.UndefinedLit:
+		return false, true
+	case *ast.UnaryExpr:
+		if expr.Token == token.Not {
+			v, ok := constTruth(expr.Expr)
+			return !v, ok
+		}
+	case *ast.BinaryExpr:
+		switch expr.Token {
+		case token.LAnd, token.LOr:
+			lv, lok := constTruth(expr.LHS)
+			rv, rok := constTruth(expr.RHS)
+
+			// 'x && false' is always false, and, 'x || true' is always true
+			short := expr.Token == token.LOr
+			if (lok && lv == short) || (rok && rv == short) {
+				return short, true
+			}
+			if lok && rok {
+				return !short, true
+			}
+		default:
+			return constCompare(expr)
+		}
+	}
+
+	return false, false
+}
+
+// constCompare evaluates the comparison of two literals of the same type.
+func constCompare(expr *ast.BinaryExpr) (v bool, ok bool) {
+	lhs, rhs := unparen(expr.LHS), unparen(expr.RHS)
+
+	var c int
+	switch lhs := lhs.(type) {
+	case *ast.IntLit:
+		rhs, isInt := rhs.(*ast.IntLit)
+		if !isInt {
+			return false, false
+		}
+		c = compareOrdered(float64(lhs.Value), float64(rhs.Value))
+	case *ast.FloatLit:
+		rhs, isFloat := rhs.(*ast.FloatLit)
+		if !isFloat {
+			return false, false
+		}
+		c = compareOrdered(lhs.Value, rhs.Value)
+	case *ast.CharLit:
+		rhs, isChar := rhs.(*ast.CharLit)
+		if !isChar {
+			return false, false
+		}
+		c = compareOrdered(float64(lhs.Value), float64(rhs.Value))
+	case *ast.StringLit:
+		rhs, isString := rhs.(*ast.StringLit)
+		if !isString || (expr.Token != token.Equal && expr.Token != token.NotEqual) {
+			return false, false
+		}
+		if lhs.Value != rhs.Value {
+			c = 1
+		}
+	case *ast.BoolLit:
+		rhs, isBool := rhs.(*ast.BoolLit)
+		if !isBool || (expr.Token != token.Equal && expr.Token != token.NotEqual) {
+			return false, false
+		}
+		if lhs.Value != rhs.Value {
+			c = 1
+		}
+	default:
+		return false, false
+	}
+
+	switch expr.Token {
+	case token.Equal:
+		return c == 0, true
+	case token.NotEqual:
+		return c != 0, true
+	case token.Less:
+		return c < 0, true
+	case token.LessEq:
+		return c <= 0, true
+	case token.Greater:
+		return c > 0, true
+	case token.GreaterEq:
+		return c >= 0, true
+	}
+
+	return false, false
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// literalKind returns the type name of the literal expression, or, an
+// empty string if the expression is not a literal.
+func literalKind(expr ast.Expr) string {
+	switch unparen(expr).(type) {
+	case *ast.IntLit:
+		return "int"
+	case *ast.FloatLit:
+		return "float"
+	case *ast.CharLit:
+		return "char"
+	case *ast.StringLit:
+		return "string"
+	case *ast.BoolLit:
+		return "bool"
+	case *ast.ArrayLit:
+		return "array"
+	case *ast.MapLit:
+		return "map"
+	case *ast.FuncLit:
+		return "function"
+	}
+
+	return ""
+}
+
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		p, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.Expr
+	}
+}
+
+// isTerminating returns true if the statements following the statement in
+// the same block can never be executed.
+func isTerminating(stmt ast.Stmt) bool {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStmt, *ast.ExportStmt, *ast.BranchStmt:
+		return true
+	case *ast.BlockStmt:
+		return isTerminatingList(stmt.Stmts)
+	case *ast.IfStmt:
+		return stmt.Else != nil && isTerminatingList(stmt.Body.Stmts) && isTerminating(stmt.Else)
+	case *ast.ForStmt:
+		return stmt.Cond == nil && !hasBreak(stmt.Body.Stmts)
+	}
+
+	return false
+}
+
+func isTerminatingList(stmts []ast.Stmt) bool {
+	for i := len(stmts) - 1; i >= 0; i-- {
+		if _, isEmpty := stmts[i].(*ast.EmptyStmt); !isEmpty {
+			return isTerminating(stmts[i])
+		}
+	}
+
+	return false
+}
+
+// hasBreak returns true if there's a break statement for the loop.
+func hasBreak(stmts []ast.Stmt) bool {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.BranchStmt:
+			if stmt.Token == token.Break {
+				return true
+			}
+		case *ast.BlockStmt:
+			if hasBreak(stmt.Stmts) {
+				return true
+			}
+		case *ast.IfStmt:
+			if hasBreak(stmt.Body.Stmts) || (stmt.Else != nil && hasBreak([]ast.Stmt{stmt.Else})) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isBuiltin(name string) bool {
+	for _, fn := range objects.Builtins {
+		if fn.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package lint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler/lint"
+	"github.com/d5/tengo/compiler/parser"
+)
+
+func TestCheck(t *testing.T) {
+	// unused variables and imports
+	expect(t, `a := 1`)
+	expect(t, `func() { a := 1 }`, "1:10: 'a' declared but not used")
+	expect(t, `func() { a := 1; a = 2 }`, "1:10: 'a' declared but not used")
+	expect(t, `func() { a := 1; a++ }`, "1:10: 'a' declared but not used")
+	expect(t, `func() { a := [1]; a[0] = 2 }`)
+	expect(t, `func() { a := 1; return func() { return a } }`)
+	expect(t, `func(a, b) { return 1 }`)
+	expect(t, `func() { for k, v in [1] { return v } }`, "1:14: 'k' declared but not used")
+	expect(t, `func() { for _, v in [1] { return v } }`)
+	expect(t, `func() { for v in [1] { return v } }`)
+	expect(t, `os := import("os")`, "1:1: imported and not used: 'os'")
+	expect(t, `os := import("os"); os.exit(0)`)
+	expect(t, `func() { m := import("math") }`, "1:10: imported and not used: 'math'")
+	expect(t, `f := func() { f() }`)
+
+	// shadowing
+	expect(t, `a := 1; func() { a := 2; return a }`, "1:18: 'a' shadows declaration at 1:1")
+	expect(t, `a := 1; func(a) { return a }`, "1:14: 'a' shadows declaration at 1:1")
+	expect(t, `a := 1; if true { a := 2 }`, "1:12: condition is always true", "1:19: 'a' shadows declaration at 1:1")
+	expect(t, `func() { len := 1; return len }`, "1:10: 'len' shadows builtin function")
+
+	// unreachable code
+	expect(t, `func() { return 1; a := 2; return a }`, "1:20: unreachable code")
+	expect(t, `for { break; x := 1 }`, "1:14: unreachable code")
+	expect(t, `func(x) { if x { return 1 } else { return 2 }; return 3 }`, "1:48: unreachable code")
+	expect(t, `func(x) { if x { return 1 }; return 3 }`)
+	expect(t, `func() { for { }; return 3 }`, "1:19: unreachable code")
+	expect(t, `func(x) { for { if x { break } }; return 3 }`)
+	expect(t, `export 1; a := 2`, "1:11: unreachable code")
+
+	// constant conditions
+	expect(t, `if false {}`, "1:4: condition is always false")
+	expect(t, `if 1 < 2 {}`, "1:4: condition is always true")
+	expect(t, `if !("a" == "b") {}`, "1:5: condition is always true")
+	expect(t, `x := 1; if x && false {}`, "1:12: condition is always false")
+	expect(t, `x := 1; if x || true {}`, "1:12: condition is always true")
+	expect(t, `x := 1; if x && true {}`)
+	expect(t, `a := undefined ? 1 : 2`, "1:6: condition is always false")
+	expect(t, `for true {}`)
+	expect(t, `for 0 {}`, "1:5: condition is always false")
+
+	// incompatible literal comparisons
+	expect(t, `a := 1 == "1"`, "1:8: suspicious comparison of int and string literals: always false")
+	expect(t, `a := 1 != 1.0`, "1:8: suspicious comparison of int and float literals: always true")
+	expect(t, `a := (1) == 'a'`, "1:10: suspicious comparison of int and char literals: always false")
+	expect(t, `a := 1 == undefined`)
+}
+
+func expect(t *testing.T, input string, expected ...string) {
+	file, err := parser.ParseSource("", []byte(input), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var actual []string
+	for _, issue := range lint.Check(file) {
+		actual = append(actual, issue.String())
+	}
+
+	assert.Equal(t, strings.Join(expected, "\n"), strings.Join(actual, "\n"), input)
+}
@@ -0,0 +1,258 @@
+package compiler
+
+import (
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+// constValue evaluates the expression at compile time if it consists of
+// the literals and the host constants only. Unless the optimizer is enabled,
+// only the expressions that refer to the host constants are evaluated.
+func (c *Compiler) constValue(expr ast.Expr) (objects.Object, bool) {
+	var usesConstants bool
+	value, ok := evalConst(expr, func(name string) (objects.Object, bool) {
+		value, ok := c.lookupConstant(name)
+		usesConstants = usesConstants || ok
+
+		return value, ok
+	})
+	if !ok || (!c.optimize && !usesConstants) {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// lookupConstant returns the value of the host constant unless the name is
+// declared as a variable. The host constants take precedence over the builtin
+// functions.
+func (c *Compiler) lookupConstant(name string) (objects.Object, bool) {
+	value, ok := c.hostConstants[name]
+	if !ok {
+		return nil, false
+	}
+
+	if symbol, _, exists := c.symbolTable.Resolve(name); exists && symbol.Scope != ScopeBuiltin {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// evalConst evaluates the expression at compile time if it consists of the
+// literals and the identifiers resolved by lookup only. It returns false if
+// the expression is not constant or if the evaluation fails, so the error can
+// be reported at runtime as before.
+func evalConst(expr ast.Expr, lookup func(name string) (objects.Object, bool)) (value objects.Object, ok bool) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return lookup(expr.Name)
+	case *ast.IntLit:
+		return &objects.Int{Value: expr.Value}, true
+	case *ast.FloatLit:
+		return &objects.Float{Value: expr.Value}, true
+	case *ast.StringLit:
+		return &objects.String{Value: expr.Value}, true
+	case *ast.CharLit:
+		return &objects.Char{Value: expr.Value}, true
+	case *ast.BoolLit:
+		if expr.Value {
+			return objects.TrueValue, true
+		}
+		return objects.FalseValue, true
+	case *ast.UndefinedLit:
+		return objects.UndefinedValue, true
+	case *ast.ParenExpr:
+		return evalConst(expr.Expr, lookup)
+	case *ast.UnaryExpr:
+		operand, ok := evalConst(expr.Expr, lookup)
+		if !ok {
+			return nil, false
+		}
+
+		switch expr.Token {
+		case token.Not:
+			if operand.IsFalsy() {
+				return objects.TrueValue, true
+			}
+			return objects.FalseValue, true
+		case token.Add:
+			return operand, true
+		case token.Sub:
+			switch x := operand.(type) {
+			case *objects.Int:
+				if intOverflows(token.Sub, &objects.Int{}, x) {
+					// the overflow behavior is chosen by the VM
+					return nil, false
+				}
+				return &objects.Int{Value: -x.Value}, true
+			case *objects.Float:
+				return &objects.Float{Value: -x.Value}, true
+			}
+		case token.Xor:
+			if x, isInt := operand.(*objects.Int); isInt {
+				return &objects.Int{Value: ^x.Value}, true
+			}
+		}
+	case *ast.BinaryExpr:
+		lhs, ok := evalConst(expr.LHS, lookup)
+		if !ok {
+			return nil, false
+		}
+		rhs, ok := evalConst(expr.RHS, lookup)
+		if !ok {
+			return nil, false
+		}
+
+		return foldBinaryOp(expr.Token, lhs, rhs)
+	}
+
+	return nil, false
+}
+
+func foldBinaryOp(op token.Token, lhs, rhs objects.Object) (res objects.Object, ok bool) {
+	defer func() {
+		// the operation is left to the runtime if BinaryOp panics
+		if r := recover(); r != nil {
+			res, ok = nil, false
+		}
+	}()
+
+	var err error
+	switch op {
+	case token.LAnd:
+		if lhs.IsFalsy() {
+			return lhs, true
+		}
+		return rhs, true
+	case token.LOr:
+		if !lhs.IsFalsy() {
+			return lhs, true
+		}
+		return rhs, true
+	case token.Equal:
+		return boolValue(lhs.Equals(rhs)), true
+	case token.NotEqual:
+		return boolValue(!lhs.Equals(rhs)), true
+	case token.Less:
+		// the operands are swapped the same way the compiled code does
+		res, err = rhs.BinaryOp(token.Greater, lhs)
+	case token.LessEq:
+		res, err = rhs.BinaryOp(token.GreaterEq, lhs)
+	default:
+		if intOverflows(op, lhs, rhs) {
+			// the overflow behavior is chosen by the VM
+			return nil, false
+		}
+		res, err = lhs.BinaryOp(op, rhs)
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	if !isConstType(res) {
+		return nil, false
+	}
+
+	return res, true
+}
+
+// intOverflows returns true if the int arithmetic operation overflows.
+func intOverflows(op token.Token, lhs, rhs objects.Object) bool {
+	l, ok := lhs.(*objects.Int)
+	if !ok {
+		return false
+	}
+	r, ok := rhs.(*objects.Int)
+	if !ok {
+		return false
+	}
+
+	switch op {
+	case token.Add, token.Sub, token.Mul:
+	case token.Quo:
+		if r.Value == 0 {
+			return false
+		}
+	default:
+		return false
+	}
+
+	_, ok = objects.CheckedIntOp(op, l.Value, r.Value)
+
+	return !ok
+}
+
+func boolValue(b bool) objects.Object {
+	if b {
+		return objects.TrueValue
+	}
+
+	return objects.FalseValue
+}
+
+// isConstType returns true if the value can be evaluated at compile time.
+func isConstType(value objects.Object) bool {
+	switch value.(type) {
+	case *objects.Int, *objects.Float, *objects.String, *objects.Char, *objects.Bool, *objects.Undefined:
+		return true
+	}
+
+	return false
+}
+
+// compileConstant emits the instruction that pushes the constant value
+// evaluated by constValue.
+func (c *Compiler) compileConstant(node ast.Node, value objects.Object) {
+	switch value := value.(type) {
+	case *objects.Bool:
+		if value.IsFalsy() {
+			c.emit(node, OpFalse)
+		} else {
+			c.emit(node, OpTrue)
+		}
+	case *objects.Undefined:
+		c.emit(node, OpNull)
+	default:
+		c.emit(node, OpConstant, c.addConstant(value))
+	}
+}
+
+// compileConstCond compiles the conditional branches when the condition is
+// known at compile time. Instead of testing the condition, it jumps over the
+// branch that is never taken. Both branches are still compiled so that the
+// compile errors are reported the same way as without the optimization.
+func (c *Compiler) compileConstCond(node ast.Node, cond objects.Object, body, els ast.Node) error {
+	if cond.IsFalsy() {
+		jumpPos := c.emit(node, OpJump, 0)
+
+		if err := c.Compile(body); err != nil {
+			return err
+		}
+
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+
+		if els != nil {
+			return c.Compile(els)
+		}
+
+		return nil
+	}
+
+	if err := c.Compile(body); err != nil {
+		return err
+	}
+
+	if els != nil {
+		jumpPos := c.emit(node, OpJump, 0)
+
+		if err := c.Compile(els); err != nil {
+			return err
+		}
+
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+	}
+
+	return nil
+}
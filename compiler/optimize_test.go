@@ -0,0 +1,381 @@
+package compiler_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
+
+func TestCompiler_Optimize(t *testing.T) {
+	expectOptimized(t, `1 + 2 * 3`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(7))))
+
+	expectOptimized(t, `a := "foo" + "bar" + 1`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0)),
+			objectsArray(
+				stringObject("foobar1"))))
+
+	expectOptimized(t, `-(1 + 2); ^4`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpPop),
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(-3),
+				intObject(-5))))
+
+	expectOptimized(t, `1 < 2 && "a" == "b"; !undefined || 3`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpFalse),
+				compiler.MakeInstruction(compiler.OpPop),
+				compiler.MakeInstruction(compiler.OpTrue),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray()))
+
+	// partially constant expressions
+	expectOptimized(t, `a := 1; a + (2 * 3)`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpGetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpAdd),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1),
+				intObject(6))))
+
+	expectOptimized(t, `a := 1; true && a`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpGetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1))))
+
+	expectOptimized(t, `a := 1; 0 && a`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1),
+				intObject(0))))
+
+	// operations that fail at runtime are not folded
+	expectOptimized(t, `1 / 0; 1 + "a"`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpDiv),
+				compiler.MakeInstruction(compiler.OpPop),
+				compiler.MakeInstruction(compiler.OpConstant, 2),
+				compiler.MakeInstruction(compiler.OpConstant, 3),
+				compiler.MakeInstruction(compiler.OpAdd),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1),
+				intObject(0),
+				intObject(1),
+				stringObject("a"))))
+
+	// int overflow is left to the VM
+	expectOptimized(t, `9223372036854775807 + 1`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpAdd),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(9223372036854775807),
+				intObject(1))))
+
+	// constant conditions
+	expectOptimized(t, `if 1 > 2 { 10 } else { 20 }`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(10),
+				intObject(20))))
+
+	expectOptimized(t, `a := true ? 10 : 20`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0)),
+			objectsArray(
+				intObject(10),
+				intObject(20))))
+
+	expectOptimized(t, `for true { break }; 1`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1))))
+
+	// unreachable code
+	expectOptimized(t, `func() { return 1; 2 }`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 2),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1),
+				intObject(2),
+				compiledFunction(0, 0,
+					compiler.MakeInstruction(compiler.OpConstant, 0),
+					compiler.MakeInstruction(compiler.OpReturnValue)))))
+
+	expectOptimized(t, `func(a) { for { if a { return 1 } else { break } }; return 2 }`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 2),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1),
+				intObject(2),
+				compiledFunction(0, 1,
+					compiler.MakeInstruction(compiler.OpGetLocal, 0),
+					compiler.MakeInstruction(compiler.OpJumpFalsy, 9),
+					compiler.MakeInstruction(compiler.OpConstant, 0),
+					compiler.MakeInstruction(compiler.OpReturnValue),
+					compiler.MakeInstruction(compiler.OpConstant, 1),
+					compiler.MakeInstruction(compiler.OpReturnValue)))))
+
+	// jump chains
+	expectOptimized(t, `a := 1; if a { if a { 1 } else { 2 } } else { 3 }`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpGetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpJumpFalsy, 32),
+				compiler.MakeInstruction(compiler.OpGetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpJumpFalsy, 25),
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpPop),
+				compiler.MakeInstruction(compiler.OpJump, 36),
+				compiler.MakeInstruction(compiler.OpConstant, 2),
+				compiler.MakeInstruction(compiler.OpPop),
+				compiler.MakeInstruction(compiler.OpJump, 36),
+				compiler.MakeInstruction(compiler.OpConstant, 3),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1),
+				intObject(1),
+				intObject(2),
+				intObject(3))))
+
+	expectOptimized(t, `a := 1; b := a && a && a`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpGetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpAndJump, 21),
+				compiler.MakeInstruction(compiler.OpGetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpAndJump, 21),
+				compiler.MakeInstruction(compiler.OpGetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 1)),
+			objectsArray(
+				intObject(1))))
+
+	// combined instructions
+	expectOptimized(t, `func(a) { s := 0; for i := 0; i < a; i++ { if i == 3 { continue }; s += i }; return s }`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 4),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(0),
+				intObject(0),
+				intObject(3),
+				intObject(1),
+				compiledFunction(3, 1,
+					compiler.MakeInstruction(compiler.OpConstant, 0),
+					compiler.MakeInstruction(compiler.OpDefineLocal, 1),
+					compiler.MakeInstruction(compiler.OpConstant, 1),
+					compiler.MakeInstruction(compiler.OpDefineLocal, 2),
+					compiler.MakeInstruction(compiler.OpGetLocal, 0),
+					compiler.MakeInstruction(compiler.OpGetLocal, 2),
+					compiler.MakeInstruction(compiler.OpJumpNotGreater, 42),
+					compiler.MakeInstruction(compiler.OpGetLocal, 2),
+					compiler.MakeInstruction(compiler.OpConstant, 2),
+					compiler.MakeInstruction(compiler.OpJumpNotEqual, 28),
+					compiler.MakeInstruction(compiler.OpJump, 35),
+					compiler.MakeInstruction(compiler.OpGetLocal, 1),
+					compiler.MakeInstruction(compiler.OpGetLocal, 2),
+					compiler.MakeInstruction(compiler.OpAdd),
+					compiler.MakeInstruction(compiler.OpSetLocal, 1),
+					compiler.MakeInstruction(compiler.OpAddLocalConst, 2, 3),
+					compiler.MakeInstruction(compiler.OpJump, 10),
+					compiler.MakeInstruction(compiler.OpGetLocal, 1),
+					compiler.MakeInstruction(compiler.OpReturnValue)))))
+
+	// the sequences with a jump target inside are not combined
+	expectOptimized(t, `func(a, b) { if a && b > 1 { return 1 }; return 2 }`,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 3),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1),
+				intObject(1),
+				intObject(2),
+				compiledFunction(2, 2,
+					compiler.MakeInstruction(compiler.OpGetLocal, 0),
+					compiler.MakeInstruction(compiler.OpAndJump, 11),
+					compiler.MakeInstruction(compiler.OpGetLocal, 1),
+					compiler.MakeInstruction(compiler.OpConstant, 0),
+					compiler.MakeInstruction(compiler.OpGreaterThan),
+					compiler.MakeInstruction(compiler.OpJumpFalsy, 18),
+					compiler.MakeInstruction(compiler.OpConstant, 1),
+					compiler.MakeInstruction(compiler.OpReturnValue),
+					compiler.MakeInstruction(compiler.OpConstant, 2),
+					compiler.MakeInstruction(compiler.OpReturnValue)))))
+
+	// compile errors in the branches that are never taken are still reported
+	_, err := compileOptimized(`if false { a }`)
+	assert.Error(t, err)
+}
+
+func TestCompiler_DefineConstant(t *testing.T) {
+	constants := map[string]objects.Object{
+		"env":   stringObject("prod"),
+		"debug": objects.FalseValue,
+	}
+
+	// the expressions using the constants are evaluated even if the optimizer
+	// is disabled
+	expectWithConstants(t, `a := env + "-1"; 1 + 2`, constants,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpConstant, 2),
+				compiler.MakeInstruction(compiler.OpAdd),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				stringObject("prod-1"),
+				intObject(1),
+				intObject(2))))
+
+	expectWithConstants(t, `a := debug && env == "dev" ? 1 : 2`, constants,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpJump, 6),
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpConstant, 1),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0)),
+			objectsArray(
+				intObject(1),
+				intObject(2))))
+
+	// variables shadow the constants
+	expectWithConstants(t, `env := 1; env`, constants,
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpGetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpPop)),
+			objectsArray(
+				intObject(1))))
+
+	_, err := compileWithConstants(`env = "dev"`, constants)
+	assert.Equal(t, "test:1:1: cannot assign to constant 'env'", err.Error())
+
+	c := compiler.NewCompiler(nil, nil, nil, nil, nil)
+	assert.Error(t, c.DefineConstant("a", &objects.Array{}))
+}
+
+func expectWithConstants(t *testing.T, input string, constants map[string]objects.Object, expected *compiler.Bytecode) {
+	actual, err := compileWithConstants(input, constants)
+	if !assert.NoError(t, err, input) {
+		return
+	}
+
+	equalBytecode(t, expected, actual)
+}
+
+func compileWithConstants(input string, constants map[string]objects.Object) (*compiler.Bytecode, error) {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("test", -1, len(input))
+
+	parsed, err := parser.ParseFile(file, []byte(input), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := compiler.NewCompiler(file, nil, nil, nil, nil)
+	for name, value := range constants {
+		if err := c.DefineConstant(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Compile(parsed); err != nil {
+		return nil, err
+	}
+
+	return c.Bytecode(), nil
+}
+
+func expectOptimized(t *testing.T, input string, expected *compiler.Bytecode) {
+	actual, err := compileOptimized(input)
+	if !assert.NoError(t, err, input) {
+		return
+	}
+
+	equalBytecode(t, expected, actual)
+}
+
+func compileOptimized(input string) (*compiler.Bytecode, error) {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("test", -1, len(input))
+
+	parsed, err := parser.ParseFile(file, []byte(input), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	for idx, fn := range objects.Builtins {
+		symbolTable.DefineBuiltin(idx, fn.Name)
+	}
+
+	c := compiler.NewCompiler(file, symbolTable, nil, nil, nil)
+	c.EnableOptimizer(true)
+	if err := c.Compile(parsed); err != nil {
+		return nil, err
+	}
+
+	return c.Bytecode(), nil
+}
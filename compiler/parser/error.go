@@ -15,3 +15,8 @@ func (e Error) Error() string {
 
 	return e.Msg
 }
+
+// Position returns the position in the source code.
+func (e Error) Position() source.FilePos {
+	return e.Pos
+}
@@ -58,6 +58,16 @@ func (p ErrorList) Error() string {
 	return fmt.Sprintf("%s (and %d more errors)", p[0], len(p)-1)
 }
 
+// Unwrap returns the errors in the collection.
+func (p ErrorList) Unwrap() []error {
+	errs := make([]error, len(p))
+	for i, e := range p {
+		errs[i] = e
+	}
+
+	return errs
+}
+
 // Err returns an error.
 func (p ErrorList) Err() error {
 	if len(p) == 0 {
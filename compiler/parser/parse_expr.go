@@ -0,0 +1,23 @@
+package parser
+
+import (
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/source"
+)
+
+// ParseExpr parses source code 'src' of a single expression and builds an
+// AST.
+func ParseExpr(src []byte) (res ast.Expr, err error) {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("", -1, len(src))
+
+	p := NewParser(file, src, nil)
+
+	res, err = p.ParseExpr()
+	if err != nil {
+		p.errors.Sort()
+		err = p.errors.Err()
+	}
+
+	return
+}
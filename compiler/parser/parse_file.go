@@ -11,18 +11,11 @@ import (
 func ParseFile(file *source.File, src []byte, trace io.Writer) (res *ast.File, err error) {
 	p := NewParser(file, src, trace)
 
-	defer func() {
-		if e := recover(); e != nil {
-			if _, ok := e.(bailout); !ok {
-				panic(e)
-			}
-		}
-
+	res, err = p.ParseFile()
+	if err != nil {
 		p.errors.Sort()
 		err = p.errors.Err()
-	}()
-
-	res, err = p.ParseFile()
+	}
 
 	return
 }
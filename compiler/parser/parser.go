@@ -21,8 +21,6 @@ import (
 	"github.com/d5/tengo/compiler/token"
 )
 
-type bailout struct{}
-
 // Parser parses the Tengo source files.
 type Parser struct {
 	file      *source.File
@@ -56,7 +54,9 @@ func NewParser(file *source.File, src []byte, trace io.Writer) *Parser {
 	return p
 }
 
-// ParseFile parses the source and returns an AST file unit.
+// ParseFile parses the source and returns an AST file unit. The parser
+// recovers from the syntax errors at the statement boundaries, and, all the
+// errors found are returned as ErrorList.
 func (p *Parser) ParseFile() (*ast.File, error) {
 	if p.trace {
 		defer un(trace(p, "File"))
@@ -66,7 +66,18 @@ func (p *Parser) ParseFile() (*ast.File, error) {
 		return nil, p.errors.Err()
 	}
 
-	stmts := p.parseStmtList()
+	var stmts []ast.Stmt
+	for {
+		stmts = append(stmts, p.parseStmtList()...)
+		if p.token == token.EOF {
+			break
+		}
+
+		// unmatched '}' in the top-level scope
+		p.errorExpected(p.pos, "statement")
+		p.next()
+	}
+
 	if p.errors.Len() > 0 {
 		return nil, p.errors.Err()
 	}
@@ -77,6 +88,32 @@ func (p *Parser) ParseFile() (*ast.File, error) {
 	}, nil
 }
 
+// ParseExpr parses the source as a single expression. The expression can be
+// followed by a semicolon or a newline only.
+func (p *Parser) ParseExpr() (ast.Expr, error) {
+	if p.trace {
+		defer un(trace(p, "Expr"))
+	}
+
+	if p.errors.Len() > 0 {
+		return nil, p.errors.Err()
+	}
+
+	expr := p.parseExpr()
+	if p.token == token.Semicolon {
+		p.next()
+	}
+	if p.token != token.EOF {
+		p.errorExpected(p.pos, "end of expression")
+	}
+
+	if p.errors.Len() > 0 {
+		return nil, p.errors.Err()
+	}
+
+	return expr, nil
+}
+
 func (p *Parser) parseExpr() ast.Expr {
 	if p.trace {
 		defer un(trace(p, "Expression"))
@@ -175,7 +212,7 @@ L:
 			default:
 				pos := p.pos
 				p.errorExpected(pos, "selector")
-				p.advance(stmtStart)
+				p.advance(stmtSync)
 				return &ast.BadExpr{From: pos, To: p.pos}
 			}
 		case token.LBrack:
@@ -394,7 +431,7 @@ func (p *Parser) parseOperand() ast.Expr {
 
 	pos := p.pos
 	p.errorExpected(pos, "operand")
-	p.advance(stmtStart)
+	p.advance(stmtSync)
 	return &ast.BadExpr{From: pos, To: p.pos}
 }
 
@@ -407,7 +444,7 @@ func (p *Parser) parseImportExpr() ast.Expr {
 
 	if p.token != token.String {
 		p.errorExpected(p.pos, "module name")
-		p.advance(stmtStart)
+		p.advance(stmtSync)
 		return &ast.BadExpr{From: pos, To: p.pos}
 	}
 
@@ -646,7 +683,7 @@ func (p *Parser) parseStmt() (stmt ast.Stmt) {
 	default:
 		pos := p.pos
 		p.errorExpected(pos, "statement")
-		p.advance(stmtStart)
+		p.advance(stmtSync)
 		return &ast.BadStmt{From: pos, To: p.pos}
 	}
 }
@@ -1003,15 +1040,27 @@ func (p *Parser) parseMapElementLit() *ast.MapElementLit {
 	}
 
 	// key: read identifier token but it's not actually an identifier
-	ident := p.parseIdent()
+	var key string
+	var keyPos source.Pos
+	if p.token == token.Ident {
+		ident := p.parseIdent()
+		key, keyPos = ident.Name, ident.NamePos
+	} else {
+		// the map keys are always strings: report the other expressions
+		// (e.g. {1: "a"}) as a whole instead of the unexpected token
+		keyPos = p.pos
+		keyExpr := p.parseExpr()
+		p.error(keyPos, fmt.Sprintf("invalid map key: %s (map keys must be identifiers)", keyExpr.String()))
+		key = "_"
+	}
 
 	colonPos := p.expect(token.Colon)
 
 	valueExpr := p.parseExpr()
 
 	return &ast.MapElementLit{
-		Key:      ident.Name,
-		KeyPos:   ident.NamePos,
+		Key:      key,
+		KeyPos:   keyPos,
 		ColonPos: colonPos,
 		Value:    valueExpr,
 	}
@@ -1067,7 +1116,7 @@ func (p *Parser) expectSemi() {
 		p.next()
 	default:
 		p.errorExpected(p.pos, "';'")
-		p.advance(stmtStart)
+		p.advance(stmtSync)
 	}
 
 }
@@ -1098,11 +1147,6 @@ func (p *Parser) error(pos source.Pos, msg string) {
 		return
 	}
 
-	if n > 10 {
-		// too many errors; terminate early
-		panic(bailout{})
-	}
-
 	p.errors.Add(filePos, msg)
 }
 
@@ -0,0 +1,33 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler/parser"
+)
+
+func TestParseExpr(t *testing.T) {
+	expectExpr(t, `a + b * 2`, `(a + (b * 2))`)
+	expectExpr(t, "x.y > 1 && f(z) ? \"a\" : 'b'\n", `(((x.y > 1) && f(z)) ? "a" : 'b')`)
+	expectExpr(t, `[1, 2][0];`, `[1, 2][0]`)
+
+	expectExprError(t, ``, "1:1: expected operand, found 'EOF'")
+	expectExprError(t, `a := 1`, "1:3: expected end of expression, found ':='")
+	expectExprError(t, `a; b`, "1:4: expected end of expression, found b")
+	expectExprError(t, `a +`, "1:4: expected operand, found 'EOF'")
+}
+
+func expectExpr(t *testing.T, input, expected string) {
+	actual, err := parser.ParseExpr([]byte(input))
+	if assert.NoError(t, err, input) {
+		assert.Equal(t, expected, actual.String(), input)
+	}
+}
+
+func expectExprError(t *testing.T, input, expected string) {
+	_, err := parser.ParseExpr([]byte(input))
+	if assert.Error(t, err, input) {
+		assert.Equal(t, expected, err.Error(), input)
+	}
+}
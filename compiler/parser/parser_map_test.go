@@ -63,4 +63,7 @@ func TestMap(t *testing.T) {
 key1: 1,
 key2: 2,
 }`)
+	expectError(t, `{1: 2}`)
+	expectError(t, `{"a": 1}`)
+	expectError(t, `{key1: 1, [1]: 2}`)
 }
@@ -0,0 +1,56 @@
+package parser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler/parser"
+)
+
+func TestRecovery(t *testing.T) {
+	expectErrors(t, "a := 1 +\nb := 1\nc := 2 ++ 3\nd := 4",
+		"test:2:3: expected ';', found ':='",
+		"test:3:8: expected ';', found '++'")
+
+	expectErrors(t, "a := *\nb := 1\nc := [1 2]\nd := {x: 1 y: 2}",
+		"test:1:6: expected operand, found '*'",
+		"test:3:9: expected ']', found 2",
+		"test:4:12: expected '}', found y")
+
+	expectErrors(t, "f := func() {\n\ta := )\n\tb := 1\n}\ng := f(\nh := 1",
+		"test:2:7: expected operand, found ')'",
+		"test:6:3: expected ')', found ':='")
+
+	expectErrors(t, "if { a := 1 }\nfor x in {}\nb := 1",
+		"test:1:4: missing condition in if statement",
+		"test:2:12: expected '{', found newline",
+		"test:3:7: expected '}', found 'EOF'")
+
+	// unmatched closing brace
+	expectErrors(t, "a := 1 }\nb := 2 +",
+		"test:1:8: expected statement, found '}'",
+		"test:2:9: expected operand, found 'EOF'")
+
+	// all errors are reported
+	var expected []string
+	for i := 1; i <= 20; i++ {
+		expected = append(expected, fmt.Sprintf("test:%d:6: expected operand, found ')'", i))
+	}
+	expectErrors(t, strings.Repeat("a := )\n", 20), expected...)
+}
+
+func expectErrors(t *testing.T, input string, expected ...string) {
+	_, err := parser.ParseSource("test", []byte(input), nil)
+	if !assert.Error(t, err, input) {
+		return
+	}
+
+	var actual []string
+	for _, e := range err.(parser.ErrorList) {
+		actual = append(actual, e.Error())
+	}
+
+	assert.Equal(t, strings.Join(expected, "\n"), strings.Join(actual, "\n"), input)
+}
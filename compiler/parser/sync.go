@@ -2,11 +2,16 @@ package parser
 
 import "github.com/d5/tengo/compiler/token"
 
-var stmtStart = map[token.Token]bool{
-	token.Break:    true,
-	token.Continue: true,
-	token.For:      true,
-	token.If:       true,
-	token.Return:   true,
-	token.Export:   true,
+// stmtSync is the set of tokens where the parser resumes parsing after an
+// error: the start of a statement, or, the end of the current statement or
+// block, so the errors in the following statements are reported too.
+var stmtSync = map[token.Token]bool{
+	token.Break:     true,
+	token.Continue:  true,
+	token.For:       true,
+	token.If:        true,
+	token.Return:    true,
+	token.Export:    true,
+	token.Semicolon: true,
+	token.RBrace:    true,
 }
@@ -0,0 +1,263 @@
+package compiler
+
+import (
+	"github.com/d5/tengo/compiler/source"
+)
+
+type peepholeInst struct {
+	pos      int
+	opcode   Opcode
+	operands []int
+}
+
+// optimizeInstructions removes the instructions that are never executed,
+// e.g. the code after an unconditional jump or return, and, collapses the
+// chains of jumps so that every jump goes directly to its final destination.
+// Then it combines the common sequences of the instructions (see
+// fuseInstructions). It returns the optimized instructions and the source map updated for the
+// new instruction positions.
+func optimizeInstructions(instructions []byte, sourceMap map[int]source.Pos) ([]byte, map[int]source.Pos) {
+	insts := decodeInstructions(instructions)
+
+	for {
+		threadJumps(insts)
+
+		kept := removeDeadCode(insts, len(instructions))
+		if len(kept) == len(insts) {
+			break
+		}
+
+		instructions, sourceMap = encodeInstructions(insts, kept, len(instructions), sourceMap)
+		insts = decodeInstructions(instructions)
+	}
+
+	kept, sourceMap := fuseInstructions(insts, sourceMap)
+
+	return encodeInstructions(insts, kept, len(instructions), sourceMap)
+}
+
+func decodeInstructions(b []byte) (insts []*peepholeInst) {
+	for i := 0; i < len(b); {
+		op := Opcode(b[i])
+		operands, read := ReadOperands(OpcodeOperands[op], b[i+1:])
+		insts = append(insts, &peepholeInst{
+			pos:      i,
+			opcode:   op,
+			operands: operands,
+		})
+
+		i += 1 + read
+	}
+
+	return
+}
+
+func isJump(op Opcode) bool {
+	switch op {
+	case OpJump, OpJumpFalsy, OpAndJump, OpOrJump,
+		OpJumpNotEqual, OpJumpEqual, OpJumpNotGreater, OpJumpNotGreaterEqual:
+		return true
+	}
+
+	return false
+}
+
+// threadJumps replaces the jump targets that are unconditional jumps with
+// their destinations. A conditional jump of the logical operators to the
+// same kind of jump is also collapsed as the value on the stack that is
+// tested again does not change.
+func threadJumps(insts []*peepholeInst) {
+	byPos := make(map[int]*peepholeInst, len(insts))
+	for _, inst := range insts {
+		byPos[inst.pos] = inst
+	}
+
+	for _, inst := range insts {
+		if !isJump(inst.opcode) {
+			continue
+		}
+
+		visited := map[int]bool{inst.pos: true}
+		for {
+			target, ok := byPos[inst.operands[0]]
+			if !ok || visited[target.pos] {
+				break
+			}
+			visited[target.pos] = true
+
+			if target.opcode == OpJump ||
+				(target.opcode == inst.opcode && (inst.opcode == OpAndJump || inst.opcode == OpOrJump)) {
+				inst.operands[0] = target.operands[0]
+				continue
+			}
+
+			break
+		}
+	}
+}
+
+// removeDeadCode returns the set of the instructions to keep: it excludes
+// the instructions that are not reachable from the entry point and the
+// jumps to the next instruction.
+func removeDeadCode(insts []*peepholeInst, end int) map[int]bool {
+	index := make(map[int]int, len(insts))
+	for i, inst := range insts {
+		index[inst.pos] = i
+	}
+
+	reachable := make(map[int]bool, len(insts))
+	var visit func(i int)
+	visit = func(i int) {
+		for i < len(insts) && !reachable[insts[i].pos] {
+			inst := insts[i]
+			reachable[inst.pos] = true
+
+			switch inst.opcode {
+			case OpReturn, OpReturnValue:
+				return
+			case OpJump:
+				if j, ok := index[inst.operands[0]]; ok {
+					i = j
+					continue
+				}
+				return
+			case OpJumpFalsy, OpAndJump, OpOrJump,
+				OpJumpNotEqual, OpJumpEqual, OpJumpNotGreater, OpJumpNotGreaterEqual:
+				if j, ok := index[inst.operands[0]]; ok {
+					visit(j)
+				}
+			}
+
+			i++
+		}
+	}
+	visit(0)
+
+	kept := make(map[int]bool, len(reachable))
+	for i, inst := range insts {
+		if !reachable[inst.pos] {
+			continue
+		}
+
+		if inst.opcode == OpJump && inst.operands[0] == nextReachable(insts, i+1, reachable, end) {
+			continue
+		}
+
+		kept[inst.pos] = true
+	}
+
+	return kept
+}
+
+// compareJumps are the combined instructions of the comparisons followed by
+// OpJumpFalsy.
+var compareJumps = map[Opcode]Opcode{
+	OpEqual:            OpJumpNotEqual,
+	OpNotEqual:         OpJumpEqual,
+	OpGreaterThan:      OpJumpNotGreater,
+	OpGreaterThanEqual: OpJumpNotGreaterEqual,
+}
+
+// fuseInstructions replaces the common sequences of the instructions with
+// the combined instructions that the VM executes at once: a comparison
+// followed by OpJumpFalsy, and, the addition of a constant to a local
+// variable (e.g. i++). The first instruction of a sequence is replaced, and,
+// the rest of it is excluded from the returned set of the instructions to
+// keep. The sequences that other instructions jump into are not combined.
+func fuseInstructions(insts []*peepholeInst, sourceMap map[int]source.Pos) (map[int]bool, map[int]source.Pos) {
+	targets := make(map[int]bool)
+	for _, inst := range insts {
+		if isJump(inst.opcode) {
+			targets[inst.operands[0]] = true
+		}
+	}
+
+	// the instruction following insts[i] that can be combined with it
+	next := func(i, n int) *peepholeInst {
+		if i+n >= len(insts) || targets[insts[i+n].pos] {
+			return nil
+		}
+		return insts[i+n]
+	}
+
+	newSourceMap := make(map[int]source.Pos, len(sourceMap))
+	for pos, p := range sourceMap {
+		newSourceMap[pos] = p
+	}
+
+	kept := make(map[int]bool, len(insts))
+	for i := 0; i < len(insts); i++ {
+		inst := insts[i]
+		kept[inst.pos] = true
+
+		if op, ok := compareJumps[inst.opcode]; ok {
+			if jump := next(i, 1); jump != nil && jump.opcode == OpJumpFalsy {
+				inst.opcode, inst.operands = op, jump.operands
+				i++
+			}
+			continue
+		}
+
+		if inst.opcode == OpGetLocal {
+			cnst, add, set := next(i, 1), next(i, 2), next(i, 3)
+			if cnst != nil && cnst.opcode == OpConstant &&
+				add != nil && add.opcode == OpAdd &&
+				set != nil && set.opcode == OpSetLocal && set.operands[0] == inst.operands[0] {
+				// the error of the addition is reported at its position
+				if p, ok := sourceMap[add.pos]; ok {
+					newSourceMap[inst.pos] = p
+				}
+				inst.opcode, inst.operands = OpAddLocalConst, []int{inst.operands[0], cnst.operands[0]}
+				i += 3
+			}
+		}
+	}
+
+	return kept, newSourceMap
+}
+
+func nextReachable(insts []*peepholeInst, from int, reachable map[int]bool, end int) int {
+	for i := from; i < len(insts); i++ {
+		if reachable[insts[i].pos] {
+			return insts[i].pos
+		}
+	}
+
+	return end
+}
+
+// encodeInstructions encodes the kept instructions (all if kept is nil)
+// updating the jump targets and the source map for the new positions.
+func encodeInstructions(insts []*peepholeInst, kept map[int]bool, end int, sourceMap map[int]source.Pos) ([]byte, map[int]source.Pos) {
+	// new position of every old position: the position of the first kept
+	// instruction at or after it
+	newPos := make(map[int]int, len(insts)+1)
+	pos := 0
+	for _, inst := range insts {
+		newPos[inst.pos] = pos
+		if kept == nil || kept[inst.pos] {
+			pos += len(MakeInstruction(inst.opcode, inst.operands...))
+		}
+	}
+	newPos[end] = pos
+
+	var instructions []byte
+	newSourceMap := make(map[int]source.Pos, len(insts))
+	for _, inst := range insts {
+		if kept != nil && !kept[inst.pos] {
+			continue
+		}
+
+		operands := inst.operands
+		if isJump(inst.opcode) {
+			operands = []int{newPos[operands[0]]}
+		}
+
+		if p, ok := sourceMap[inst.pos]; ok {
+			newSourceMap[len(instructions)] = p
+		}
+		instructions = append(instructions, MakeInstruction(inst.opcode, operands...)...)
+	}
+
+	return instructions, newSourceMap
+}
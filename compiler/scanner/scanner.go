@@ -55,6 +55,14 @@ func NewScanner(file *source.File, src []byte, errorHandler ErrorHandler, mode M
 		s.next() // ignore BOM at file beginning
 	}
 
+	if s.ch == '#' && s.peek() == '!' {
+		// ignore the interpreter line (e.g. "#!/usr/bin/env tengo") so the
+		// source files can be executable scripts
+		for s.ch != '\n' && s.ch != -1 {
+			s.next()
+		}
+	}
+
 	return s
 }
 
@@ -191,6 +191,14 @@ func TestScanner_Scan(t *testing.T) {
 	scanExpect(t, strings.Join(lines, "\n"), scanner.DontInsertSemis, expectedSkipComments...)
 }
 
+func TestScanner_Shebang(t *testing.T) {
+	scanExpect(t, "#!/usr/bin/env tengo\na := 1", scanner.DontInsertSemis,
+		scanResult{Token: token.Ident, Literal: "a", Line: 2, Column: 1},
+		scanResult{Token: token.Define, Literal: "", Line: 2, Column: 3},
+		scanResult{Token: token.Int, Literal: "1", Line: 2, Column: 6})
+	scanExpect(t, "#!/usr/bin/env tengo", scanner.ScanComments)
+}
+
 func TestStripCR(t *testing.T) {
 	for _, tc := range []struct {
 		input  string
@@ -0,0 +1,114 @@
+package source
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+
+	excerptIndent = "    "
+)
+
+// PosError is implemented by the errors that occurred at a position in the
+// source code, e.g. the parser, compiler and runtime errors.
+type PosError interface {
+	error
+	Position() FilePos
+}
+
+// ErrorFormatter renders the errors with the excerpt of the source code:
+// the source line where the error occurred and a caret under the column.
+type ErrorFormatter struct {
+	// Color enables the ANSI terminal colors.
+	Color bool
+	// Source returns the source code of the file by its name. The excerpt is
+	// omitted if Source is nil or it returns an error.
+	Source func(filename string) ([]byte, error)
+}
+
+// Format returns the error message with the source excerpt. The errors that
+// contain multiple errors (e.g. the parser error list) are formatted one by
+// one, and, the errors without the position are returned as they are.
+func (f *ErrorFormatter) Format(err error) string {
+	if errs, ok := err.(interface{ Unwrap() []error }); ok {
+		var formatted []string
+		for _, e := range errs.Unwrap() {
+			formatted = append(formatted, f.Format(e))
+		}
+
+		return strings.Join(formatted, "\n")
+	}
+
+	posErr, ok := err.(PosError)
+	if !ok {
+		return err.Error()
+	}
+
+	pos := posErr.Position()
+
+	var buf bytes.Buffer
+	f.writeHeader(&buf, pos, err.Error())
+
+	if line, ok := f.sourceLine(pos); ok {
+		buf.WriteString("\n" + excerptIndent)
+		buf.WriteString(line)
+
+		if pos.Column > 0 && pos.Column <= len(line)+1 {
+			buf.WriteString("\n" + excerptIndent)
+			for _, c := range line[:pos.Column-1] {
+				// keep the tabs so the caret is aligned with the column
+				if c == '\t' {
+					buf.WriteByte('\t')
+				} else {
+					buf.WriteByte(' ')
+				}
+			}
+			f.writeColored(&buf, colorGreen, "^")
+		}
+	}
+
+	return buf.String()
+}
+
+func (f *ErrorFormatter) writeHeader(buf *bytes.Buffer, pos FilePos, msg string) {
+	prefix := pos.String() + ": "
+	if !strings.HasPrefix(msg, prefix) {
+		f.writeColored(buf, colorBold, msg)
+		return
+	}
+
+	f.writeColored(buf, colorBold, prefix)
+	f.writeColored(buf, colorRed, msg[len(prefix):])
+}
+
+func (f *ErrorFormatter) writeColored(buf *bytes.Buffer, color, s string) {
+	if f.Color {
+		buf.WriteString(color + s + colorReset)
+		return
+	}
+
+	buf.WriteString(s)
+}
+
+func (f *ErrorFormatter) sourceLine(pos FilePos) (string, bool) {
+	if f.Source == nil || !pos.IsValid() {
+		return "", false
+	}
+
+	src, err := f.Source(pos.Filename)
+	if err != nil {
+		return "", false
+	}
+
+	lines := strings.Split(string(src), "\n")
+	if pos.Line > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[pos.Line-1], "\r"), true
+}
@@ -0,0 +1,63 @@
+package source_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/script"
+)
+
+func TestErrorFormatter_Format(t *testing.T) {
+	src := []byte("a := 1\nf := func() {\n\treturn a + \"x\"\n}\nf()")
+	formatter := &source.ErrorFormatter{
+		Source: func(filename string) ([]byte, error) {
+			if filename != "(main)" {
+				return nil, errors.New("not found")
+			}
+			return src, nil
+		},
+	}
+
+	// runtime error
+	_, err := script.New(src).Run()
+	assert.Equal(t, "(main):3:9: invalid operation: int + string\n"+
+		"    \treturn a + \"x\"\n"+
+		"    \t       ^", formatter.Format(err))
+
+	// compile error
+	src = []byte("a := 1\nb := a(c)")
+	_, err = script.New(src).Compile()
+	assert.Equal(t, "(main):2:8: unresolved reference 'c'\n"+
+		"    b := a(c)\n"+
+		"           ^", formatter.Format(err))
+
+	// multiple errors
+	var errList parser.ErrorList
+	errList.Add(source.FilePos{Filename: "(main)", Line: 1, Column: 1}, "error 1")
+	errList.Add(source.FilePos{Filename: "(main)", Line: 2, Column: 2}, "error 2")
+	assert.Equal(t, "(main):1:1: error 1\n"+
+		"    a := 1\n"+
+		"    ^\n"+
+		"(main):2:2: error 2\n"+
+		"    b := a(c)\n"+
+		"     ^", formatter.Format(errList))
+
+	// no source
+	errList = nil
+	errList.Add(source.FilePos{Filename: "mod1", Line: 1, Column: 1}, "error 1")
+	assert.Equal(t, "mod1:1:1: error 1", formatter.Format(errList))
+
+	// no position
+	assert.Equal(t, "stack overflow", formatter.Format(errors.New("stack overflow")))
+
+	// color
+	formatter.Color = true
+	errList = nil
+	errList.Add(source.FilePos{Filename: "(main)", Line: 1, Column: 3}, "error 1")
+	assert.Equal(t, "\x1b[1m(main):1:3: \x1b[0m\x1b[31merror 1\x1b[0m\n"+
+		"    a := 1\n"+
+		"      \x1b[32m^\x1b[0m", formatter.Format(errList))
+}
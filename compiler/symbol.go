@@ -1,9 +1,13 @@
 package compiler
 
+import "github.com/d5/tengo/compiler/source"
+
 // Symbol represents a symbol in the symbol table.
 type Symbol struct {
 	Name          string
 	Scope         SymbolScope
 	Index         int
-	LocalAssigned bool // if the local symbol is assigned at least once
+	LocalAssigned bool       // if the local symbol is assigned at least once
+	pos           source.Pos // position of the declaration in the source code
+	used          bool       // if the symbol is read at least once
 }
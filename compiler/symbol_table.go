@@ -1,5 +1,7 @@
 package compiler
 
+import "sort"
+
 // SymbolTable represents a symbol table.
 type SymbolTable struct {
 	parent        *SymbolTable
@@ -110,6 +112,38 @@ func (t *SymbolTable) Names() []string {
 	return names
 }
 
+// declaration returns the symbol captured by the free symbol, or, the symbol
+// itself if it's not a free symbol.
+func (t *SymbolTable) declaration(symbol *Symbol) *Symbol {
+	if symbol.Scope != ScopeFree {
+		return symbol
+	}
+
+	// free symbols are defined in the function scope
+	fn := t
+	for fn.block {
+		fn = fn.parent
+	}
+
+	return fn.parent.declaration(fn.freeSymbols[symbol.Index])
+}
+
+// unusedSymbols returns the local symbols declared in the scope that are
+// never read.
+func (t *SymbolTable) unusedSymbols() (unused []*Symbol) {
+	for _, symbol := range t.store {
+		if symbol.Scope == ScopeLocal && symbol.pos.IsValid() && !symbol.used {
+			unused = append(unused, symbol)
+		}
+	}
+
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].pos < unused[j].pos
+	})
+
+	return
+}
+
 func (t *SymbolTable) nextIndex() int {
 	if t.block {
 		return t.parent.nextIndex() + t.numDefinition
@@ -0,0 +1,34 @@
+package compiler
+
+import (
+	"github.com/d5/tengo/compiler/ast"
+)
+
+// Transformer rewrites the AST of a source file before it's compiled. It can
+// modify the file in place or return a new one. An error returned by the
+// transformer fails the compilation.
+type Transformer func(file *ast.File) (*ast.File, error)
+
+// AddTransformer adds the transformer that runs before the source files,
+// including the user modules, are compiled (and linted or type checked).
+// The transformers run in the order they are added, e.g. to inject tracing
+// calls, to rename identifiers, or, to reject the code that violates a
+// policy.
+func (c *Compiler) AddTransformer(t Transformer) {
+	c.transformers = append(c.transformers, t)
+}
+
+func (c *Compiler) transform(file *ast.File) (*ast.File, error) {
+	for _, t := range c.transformers {
+		transformed, err := t(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if transformed != nil {
+			file = transformed
+		}
+	}
+
+	return file, nil
+}
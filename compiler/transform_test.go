@@ -0,0 +1,102 @@
+package compiler_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+func TestCompiler_AddTransformer(t *testing.T) {
+	// rename the identifiers
+	rename := func(file *ast.File) (*ast.File, error) {
+		ast.Inspect(file, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Name == "old" {
+				ident.Name = "new"
+			}
+			return true
+		})
+		return nil, nil
+	}
+
+	// prepend a statement
+	define := func(file *ast.File) (*ast.File, error) {
+		return &ast.File{
+			InputFile: file.InputFile,
+			Stmts: append([]ast.Stmt{&ast.AssignStmt{
+				LHS:   []ast.Expr{&ast.Ident{Name: "old"}},
+				RHS:   []ast.Expr{&ast.IntLit{Value: 1}},
+				Token: token.Define,
+			}}, file.Stmts...),
+		}, nil
+	}
+
+	expectTransformed(t, `a := old`, []compiler.Transformer{define, rename},
+		bytecode(
+			concat(
+				compiler.MakeInstruction(compiler.OpConstant, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpGetGlobal, 0),
+				compiler.MakeInstruction(compiler.OpSetGlobal, 1)),
+			objectsArray(
+				intObject(1))))
+
+	// the transformers run in order
+	_, err := compileTransformed(`a := old`, []compiler.Transformer{rename, define})
+	assert.Equal(t, "test:1:6: unresolved reference 'new'", err.Error())
+
+	// reject the code
+	policy := func(file *ast.File) (*ast.File, error) {
+		var err error
+		ast.Inspect(file, func(node ast.Node) bool {
+			if _, ok := node.(*ast.ImportExpr); ok {
+				err = errors.New("imports are not allowed")
+			}
+			return err == nil
+		})
+		return file, err
+	}
+	_, err = compileTransformed(`a := 1; b := import("os")`, []compiler.Transformer{policy})
+	assert.Equal(t, "imports are not allowed", err.Error())
+}
+
+func expectTransformed(t *testing.T, input string, transformers []compiler.Transformer, expected *compiler.Bytecode) {
+	actual, err := compileTransformed(input, transformers)
+	if !assert.NoError(t, err, input) {
+		return
+	}
+
+	equalBytecode(t, expected, actual)
+}
+
+func compileTransformed(input string, transformers []compiler.Transformer) (*compiler.Bytecode, error) {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("test", -1, len(input))
+
+	parsed, err := parser.ParseFile(file, []byte(input), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	for idx, fn := range objects.Builtins {
+		symbolTable.DefineBuiltin(idx, fn.Name)
+	}
+
+	c := compiler.NewCompiler(file, symbolTable, nil, nil, nil)
+	for _, t := range transformers {
+		c.AddTransformer(t)
+	}
+
+	if err := c.Compile(parsed); err != nil {
+		return nil, err
+	}
+
+	return c.Bytecode(), nil
+}
@@ -0,0 +1,412 @@
+package compiler
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+// builtinArity is the minimum and maximum (-1 if unlimited) number of
+// arguments of the builtin functions.
+var builtinArity = map[string][2]int{
+	"print":              {0, -1},
+	"printf":             {1, -1},
+	"sprintf":            {1, -1},
+	"len":                {1, 1},
+	"copy":               {1, 1},
+	"clone":              {1, 1},
+	"equal":              {2, 2},
+	"append":             {2, -1},
+	"stream":             {1, 1},
+	"string":             {1, 2},
+	"int":                {1, 2},
+	"bool":               {1, 1},
+	"float":              {1, 2},
+	"char":               {1, 2},
+	"bytes":              {1, 2},
+	"buffer":             {0, 1},
+	"time":               {1, 2},
+	"is_int":             {1, 1},
+	"is_float":           {1, 1},
+	"is_string":          {1, 1},
+	"is_bool":            {1, 1},
+	"is_char":            {1, 1},
+	"is_bytes":           {1, 1},
+	"is_array":           {1, 1},
+	"is_immutable_array": {1, 1},
+	"is_map":             {1, 1},
+	"is_immutable_map":   {1, 1},
+	"is_time":            {1, 1},
+	"is_error":           {1, 1},
+	"is_undefined":       {1, 1},
+	"is_function":        {1, 1},
+	"is_callable":        {1, 1},
+	"to_json":            {1, 1},
+	"from_json":          {1, 2},
+	"type_name":          {1, 1},
+	"iter":               {1, 1},
+	"map":                {2, 2},
+	"filter":             {2, 2},
+	"reduce":             {2, 3},
+	"each":               {2, 2},
+	"find":               {2, 2},
+	"sort_by":            {2, 2},
+	"push":               {1, -1},
+	"pop":                {1, 1},
+	"insert":             {2, -1},
+	"remove_at":          {2, 2},
+	"reverse":            {1, 1},
+	"concat_in_place":    {1, -1},
+	"keys":               {1, 1},
+	"values":             {1, 1},
+	"merge":              {2, 3},
+	"delete":             {2, 2},
+	"has":                {2, 2},
+	"get":                {2, 3},
+}
+
+// inferredType is the result of the type inference of an expression.
+type inferredType struct {
+	sample  objects.Object // sample value of the type; nil if unknown
+	builtin string         // name of the builtin function
+	vars    []*typedVar    // variables the type was inferred from
+}
+
+type typedVar struct {
+	typ        inferredType
+	reassigned bool
+}
+
+type typeWarning struct {
+	pos  source.Pos
+	msg  string
+	vars []*typedVar
+}
+
+// typeChecker is a best-effort type inference pass over the AST. It infers
+// the types of the literals and the variables that are never reassigned, and,
+// finds the operations that would certainly fail at runtime.
+type typeChecker struct {
+	file        *source.File
+	symbolTable *SymbolTable
+	scopes      []map[string]*typedVar
+	warnings    []typeWarning
+}
+
+func (c *Compiler) checkTypes(file *ast.File) []Diagnostic {
+	tc := &typeChecker{
+		file:        c.file,
+		symbolTable: c.symbolTable,
+	}
+
+	tc.openScope()
+	tc.checkStmts(file.Stmts)
+	tc.closeScope()
+
+	sort.SliceStable(tc.warnings, func(i, j int) bool {
+		return tc.warnings[i].pos < tc.warnings[j].pos
+	})
+
+	var diagnostics []Diagnostic
+L:
+	for _, w := range tc.warnings {
+		for _, v := range w.vars {
+			if v.reassigned {
+				// variable could have a different type
+				continue L
+			}
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Pos: tc.file.Position(w.pos),
+			Msg: w.msg,
+		})
+	}
+
+	return diagnostics
+}
+
+func (tc *typeChecker) warn(pos source.Pos, vars []*typedVar, format string, args ...interface{}) {
+	tc.warnings = append(tc.warnings, typeWarning{
+		pos:  pos,
+		msg:  fmt.Sprintf(format, args...),
+		vars: vars,
+	})
+}
+
+func (tc *typeChecker) openScope() {
+	tc.scopes = append(tc.scopes, make(map[string]*typedVar))
+}
+
+func (tc *typeChecker) closeScope() {
+	tc.scopes = tc.scopes[:len(tc.scopes)-1]
+}
+
+func (tc *typeChecker) define(name string) *typedVar {
+	v := &typedVar{}
+	tc.scopes[len(tc.scopes)-1][name] = v
+
+	return v
+}
+
+func (tc *typeChecker) resolve(name string) (v *typedVar, builtin string) {
+	for i := len(tc.scopes) - 1; i >= 0; i-- {
+		if v, ok := tc.scopes[i][name]; ok {
+			return v, ""
+		}
+	}
+
+	if s, _, ok := tc.symbolTable.Resolve(name); ok && s.Scope == ScopeBuiltin {
+		return nil, objects.Builtins[s.Index].Name
+	}
+
+	return nil, ""
+}
+
+func (tc *typeChecker) checkStmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		tc.checkStmt(stmt)
+	}
+}
+
+func (tc *typeChecker) checkStmt(stmt ast.Stmt) {
+	switch stmt := stmt.(type) {
+	case *ast.ExprStmt:
+		tc.infer(stmt.Expr)
+	case *ast.AssignStmt:
+		if stmt.Token == token.Define {
+			// the symbol is defined before the right-hand side is compiled
+			var vars []*typedVar
+			for _, lhs := range stmt.LHS {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					vars = append(vars, tc.define(ident.Name))
+				}
+			}
+
+			for i, rhs := range stmt.RHS {
+				typ := tc.infer(rhs)
+				if i < len(vars) {
+					vars[i].typ = typ
+				}
+			}
+			return
+		}
+
+		for _, lhs := range stmt.LHS {
+			tc.checkAssignTarget(lhs)
+		}
+		for _, rhs := range stmt.RHS {
+			tc.infer(rhs)
+		}
+	case *ast.IncDecStmt:
+		tc.checkAssignTarget(stmt.Expr)
+	case *ast.ReturnStmt:
+		tc.infer(stmt.Result)
+	case *ast.ExportStmt:
+		tc.infer(stmt.Result)
+	case *ast.BlockStmt:
+		tc.checkStmts(stmt.Stmts)
+	case *ast.IfStmt:
+		tc.openScope()
+		if stmt.Init != nil {
+			tc.checkStmt(stmt.Init)
+		}
+		tc.infer(stmt.Cond)
+		tc.checkStmts(stmt.Body.Stmts)
+		if stmt.Else != nil {
+			tc.checkStmt(stmt.Else)
+		}
+		tc.closeScope()
+	case *ast.ForStmt:
+		tc.openScope()
+		if stmt.Init != nil {
+			tc.checkStmt(stmt.Init)
+		}
+		tc.infer(stmt.Cond)
+		if stmt.Post != nil {
+			tc.checkStmt(stmt.Post)
+		}
+		tc.checkStmts(stmt.Body.Stmts)
+		tc.closeScope()
+	case *ast.ForInStmt:
+		tc.openScope()
+		tc.infer(stmt.Iterable)
+		tc.define(stmt.Key.Name)
+		tc.define(stmt.Value.Name)
+		tc.checkStmts(stmt.Body.Stmts)
+		tc.closeScope()
+	}
+}
+
+func (tc *typeChecker) checkAssignTarget(expr ast.Expr) {
+	if ident, ok := expr.(*ast.Ident); ok {
+		if v, _ := tc.resolve(ident.Name); v != nil {
+			v.reassigned = true
+		}
+		return
+	}
+
+	tc.infer(expr)
+}
+
+func (tc *typeChecker) infer(expr ast.Expr) (typ inferredType) {
+	switch expr := expr.(type) {
+	case *ast.IntLit:
+		typ.sample = &objects.Int{Value: 1}
+	case *ast.FloatLit:
+		typ.sample = &objects.Float{Value: 1}
+	case *ast.CharLit:
+		typ.sample = &objects.Char{Value: 'a'}
+	case *ast.StringLit:
+		typ.sample = &objects.String{Value: "a"}
+	case *ast.BoolLit:
+		typ.sample = objects.TrueValue
+	case *ast.UndefinedLit:
+		typ.sample = objects.UndefinedValue
+	case *ast.Ident:
+		v, builtin := tc.resolve(expr.Name)
+		if v != nil {
+			typ = v.typ
+			typ.vars = append(typ.vars[:len(typ.vars):len(typ.vars)], v)
+		}
+		typ.builtin = builtin
+	case *ast.ParenExpr:
+		return tc.infer(expr.Expr)
+	case *ast.BinaryExpr:
+		lhs, rhs := tc.infer(expr.LHS), tc.infer(expr.RHS)
+
+		switch expr.Token {
+		case token.LAnd, token.LOr:
+			return
+		case token.Equal, token.NotEqual:
+			typ.sample = objects.TrueValue
+			return
+		}
+
+		if lhs.sample == nil || rhs.sample == nil {
+			return
+		}
+
+		typ.vars = append(lhs.vars[:len(lhs.vars):len(lhs.vars)], rhs.vars...)
+		res, err := lhs.sample.BinaryOp(expr.Token, rhs.sample)
+		if err != nil {
+			tc.warn(expr.TokenPos, typ.vars, "invalid operation: %s %s %s",
+				lhs.sample.TypeName(), expr.Token.String(), rhs.sample.TypeName())
+			return inferredType{}
+		}
+		typ.sample = res
+	case *ast.UnaryExpr:
+		operand := tc.infer(expr.Expr)
+		switch expr.Token {
+		case token.Not:
+			typ.sample = objects.TrueValue
+			return
+		case token.Add:
+			return operand
+		}
+
+		if operand.sample == nil {
+			return
+		}
+
+		switch operand.sample.(type) {
+		case *objects.Int:
+			return operand
+		case *objects.Float:
+			if expr.Token == token.Sub {
+				return operand
+			}
+		}
+		tc.warn(expr.TokenPos, operand.vars, "invalid operation: %s%s",
+			expr.Token.String(), operand.sample.TypeName())
+	case *ast.CondExpr:
+		tc.infer(expr.Cond)
+		tc.infer(expr.True)
+		tc.infer(expr.False)
+	case *ast.CallExpr:
+		fn := tc.infer(expr.Func)
+		for _, arg := range expr.Args {
+			tc.infer(arg)
+		}
+		tc.checkCall(expr, fn)
+	case *ast.IndexExpr:
+		tc.infer(expr.Expr)
+		tc.infer(expr.Index)
+	case *ast.SliceExpr:
+		tc.infer(expr.Expr)
+		tc.infer(expr.Low)
+		tc.infer(expr.High)
+	case *ast.SelectorExpr:
+		tc.infer(expr.Expr)
+	case *ast.ArrayLit:
+		for _, elem := range expr.Elements {
+			tc.infer(elem)
+		}
+		typ.sample = &objects.Array{}
+	case *ast.MapLit:
+		for _, elem := range expr.Elements {
+			tc.infer(elem.Value)
+		}
+		typ.sample = &objects.Map{}
+	case *ast.ErrorExpr:
+		tc.infer(expr.Expr)
+		typ.sample = &objects.Error{Value: objects.UndefinedValue}
+	case *ast.ImmutableExpr:
+		typ = tc.infer(expr.Expr)
+		switch typ.sample.(type) {
+		case *objects.Array:
+			typ.sample = &objects.ImmutableArray{}
+		case *objects.Map:
+			typ.sample = &objects.ImmutableMap{}
+		}
+	case *ast.FuncLit:
+		tc.openScope()
+		for _, param := range expr.Type.Params.List {
+			tc.define(param.Name)
+		}
+		tc.checkStmts(expr.Body.Stmts)
+		tc.closeScope()
+
+		typ.sample = &objects.CompiledFunction{NumParameters: len(expr.Type.Params.List)}
+	}
+
+	return
+}
+
+func (tc *typeChecker) checkCall(expr *ast.CallExpr, fn inferredType) {
+	numArgs := len(expr.Args)
+
+	if arity, ok := builtinArity[fn.builtin]; ok {
+		if numArgs < arity[0] || (arity[1] >= 0 && numArgs > arity[1]) {
+			var want string
+			switch {
+			case arity[0] == arity[1]:
+				want = fmt.Sprintf("%d", arity[0])
+			case arity[1] < 0:
+				want = fmt.Sprintf("%d or more", arity[0])
+			default:
+				want = fmt.Sprintf("%d or %d", arity[0], arity[1])
+			}
+
+			tc.warn(expr.Pos(), nil, "wrong number of arguments in call to '%s': want=%s, got=%d",
+				fn.builtin, want, numArgs)
+		}
+		return
+	}
+
+	switch f := fn.sample.(type) {
+	case nil:
+		// unknown type
+	case *objects.CompiledFunction:
+		if f.NumParameters != numArgs {
+			tc.warn(expr.Pos(), fn.vars, "wrong number of arguments: want=%d, got=%d",
+				f.NumParameters, numArgs)
+		}
+	default:
+		tc.warn(expr.Pos(), fn.vars, "not callable: %s", f.TypeName())
+	}
+}
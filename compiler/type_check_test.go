@@ -0,0 +1,88 @@
+package compiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
+
+func TestCompiler_TypeCheck(t *testing.T) {
+	// operators
+	expectWarnings(t, `a := 1 + 2; b := "a" + 1; c := [1] + [2]; d := 't' + 1`)
+	expectWarnings(t, `a := 1 + "a"`, "test:1:8: invalid operation: int + string")
+	expectWarnings(t, `a := (1 * 2.5) - "x"`, "test:1:16: invalid operation: float - string")
+	expectWarnings(t, `a := "a" - "b"`, "test:1:10: invalid operation: string - string")
+	expectWarnings(t, `a := {} + 1`, "test:1:9: invalid operation: map + int")
+	expectWarnings(t, `a := 1.5 % 2.0`, "test:1:10: invalid operation: float % float")
+	expectWarnings(t, `a := -"a"; b := ^1.5; c := -1.5; d := !"a"`,
+		"test:1:6: invalid operation: -string",
+		"test:1:17: invalid operation: ^float")
+	expectWarnings(t, `a := 1 == "a" || 2 && true`)
+
+	// variables
+	expectWarnings(t, `a := 1; b := "x"; c := a + b`, "test:1:26: invalid operation: int + string")
+	expectWarnings(t, `a := 1; a = "x"; c := a + "y"`)
+	expectWarnings(t, `a := 1; a++; c := a + "y"`)
+	expectWarnings(t, `a := 1; f := func() { a = "x" }; c := a + "y"`)
+	expectWarnings(t, `a := 1; f := func(a) { return a + "y" }`)
+	expectWarnings(t, `a := 1; for a in [1] { b := a + "y" }`)
+	expectWarnings(t, `a := immutable([1]); b := a + 1`, "test:1:29: invalid operation: immutable-array + int")
+	expectWarnings(t, `x := 1; f := func() { return x + "y" }`, "test:1:32: invalid operation: int + string")
+
+	// calls
+	expectWarnings(t, `a := 1; a()`, "test:1:9: not callable: int")
+	expectWarnings(t, `"abc"(1)`, "test:1:1: not callable: string")
+	expectWarnings(t, `f := func(a, b) {}; f(1)`, "test:1:21: wrong number of arguments: want=2, got=1")
+	expectWarnings(t, `f := func(a, b) {}; f(1, 2)`)
+	expectWarnings(t, `f := func(a) {}; f = func() {}; f()`)
+	expectWarnings(t, `len(1, 2)`, "test:1:1: wrong number of arguments in call to 'len': want=1, got=2")
+	expectWarnings(t, `append([])`, "test:1:1: wrong number of arguments in call to 'append': want=2 or more, got=1")
+	expectWarnings(t, `string()`, "test:1:1: wrong number of arguments in call to 'string': want=1 or 2, got=0")
+	expectWarnings(t, `print(); len([1]); string(1, "")`)
+	expectWarnings(t, `func() { len := func(a, b) {}; len(1, 2) }`)
+
+	// disabled by default
+	c, err := compileWithTypeCheck(`a := 1 + "a"`, false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(c.Diagnostics()))
+	}
+}
+
+func expectWarnings(t *testing.T, input string, expected ...string) {
+	c, err := compileWithTypeCheck(input, true)
+	if !assert.NoError(t, err, input) {
+		return
+	}
+
+	var actual []string
+	for _, d := range c.Diagnostics() {
+		actual = append(actual, d.String())
+	}
+
+	assert.Equal(t, strings.Join(expected, "\n"), strings.Join(actual, "\n"), input)
+}
+
+func compileWithTypeCheck(input string, enabled bool) (*compiler.Compiler, error) {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("test", -1, len(input))
+
+	parsed, err := parser.ParseFile(file, []byte(input), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	for idx, fn := range objects.Builtins {
+		symbolTable.DefineBuiltin(idx, fn.Name)
+	}
+
+	c := compiler.NewCompiler(file, symbolTable, nil, nil, nil)
+	c.EnableTypeCheck(enabled)
+
+	return c, c.Compile(parsed)
+}
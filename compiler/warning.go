@@ -0,0 +1,18 @@
+package compiler
+
+import "github.com/d5/tengo/compiler/source"
+
+// Warning represents a non-fatal issue found during the compilation that
+// does not prevent the code from running, e.g. an unused variable.
+type Warning struct {
+	Pos source.FilePos
+	Msg string
+}
+
+func (w Warning) String() string {
+	if w.Pos.Filename != "" || w.Pos.IsValid() {
+		return w.Pos.String() + ": " + w.Msg
+	}
+
+	return w.Msg
+}
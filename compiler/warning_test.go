@@ -0,0 +1,107 @@
+package compiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
+
+func TestCompiler_Warnings(t *testing.T) {
+	// unused variables
+	expectCompilerWarnings(t, `a := 1; b := a`)
+	expectCompilerWarnings(t, `func() { a := 1 }`, "test:1:10: 'a' declared but not used")
+	expectCompilerWarnings(t, `func() { a := 1; a = 2 }`, "test:1:10: 'a' declared but not used")
+	expectCompilerWarnings(t, `func() { a := 1; a += 2; a++ }`, "test:1:10: 'a' declared but not used")
+	expectCompilerWarnings(t, `func() { a := 1; return a }`)
+	expectCompilerWarnings(t, `func() { a := [1]; a[0] = 2 }`)
+	expectCompilerWarnings(t, `func() { a := 1; return func() { return a } }`)
+	expectCompilerWarnings(t, `func() { a := 1; return func() { return func() { return a } } }`)
+	expectCompilerWarnings(t, `func() { a := 1; return func() { a = 2 } }`, "test:1:10: 'a' declared but not used")
+	expectCompilerWarnings(t, `func(a, b) { return 1 }`)
+	expectCompilerWarnings(t, `func() { if a := 1; true {} }`, "test:1:13: 'a' declared but not used")
+	expectCompilerWarnings(t, `func() { for a := 0; true; {} }`, "test:1:14: 'a' declared but not used")
+	expectCompilerWarnings(t, `func() { for k, v in [1] {} }`,
+		"test:1:14: 'k' declared but not used",
+		"test:1:17: 'v' declared but not used")
+	expectCompilerWarnings(t, `func() { for _, v in [1] { v = 1 } }`, "test:1:17: 'v' declared but not used")
+	expectCompilerWarnings(t, `func() { for k, v in [1] { print(k, v) } }`)
+
+	// global variables can be used by the host application
+	expectCompilerWarnings(t, `a := 1; if b := 1; true {}; for k, v in [1] {}`)
+
+	// shadowing
+	expectCompilerWarnings(t, `a := 1; func() { a := 2; return a }`, "test:1:18: 'a' shadows declaration at test:1:1")
+	expectCompilerWarnings(t, `func() { a := 1; return func() { a := 2; return a + a } }`,
+		"test:1:34: 'a' shadows declaration at test:1:10",
+		"test:1:10: 'a' declared but not used")
+	expectCompilerWarnings(t, `func(a) { return func() { a := 2; return a } }`, "test:1:27: 'a' shadows declaration in outer scope")
+	expectCompilerWarnings(t, `func() { len := 1; return len }`, "test:1:10: 'len' shadows builtin function")
+	expectCompilerWarnings(t, `len := 1; a := len; a = 2`, "test:1:1: 'len' shadows builtin function")
+
+	// deprecated builtin functions
+	for idx, fn := range objects.Builtins {
+		if fn.Name == "len" {
+			objects.Builtins[idx].Deprecated = "use size() instead"
+			defer func() { objects.Builtins[idx].Deprecated = "" }()
+		}
+	}
+	expectCompilerWarnings(t, `a := len([1]); b := len`,
+		"test:1:6: 'len' is deprecated: use size() instead",
+		"test:1:21: 'len' is deprecated: use size() instead")
+	expectCompilerWarnings(t, `a := print`)
+}
+
+func TestCompiler_WarningsModule(t *testing.T) {
+	src := []byte(`mod := import("mod")`)
+
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("main", -1, len(src))
+	parsed, err := parser.ParseFile(file, src, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := compiler.NewCompiler(file, nil, nil, nil, nil)
+	c.SetModuleLoader(func(moduleName string) ([]byte, error) {
+		return []byte(`a := 1; b := 2; export a`), nil
+	})
+	assert.NoError(t, c.Compile(parsed))
+
+	var warnings []string
+	for _, w := range c.Warnings() {
+		warnings = append(warnings, w.String())
+	}
+	assert.Equal(t, "mod:1:9: 'b' declared but not used", strings.Join(warnings, "\n"))
+}
+
+func expectCompilerWarnings(t *testing.T, input string, expected ...string) {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("test", -1, len(input))
+
+	parsed, err := parser.ParseFile(file, []byte(input), nil)
+	if !assert.NoError(t, err, input) {
+		return
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	for idx, fn := range objects.Builtins {
+		symbolTable.DefineBuiltin(idx, fn.Name)
+	}
+
+	c := compiler.NewCompiler(file, symbolTable, nil, nil, nil)
+	if !assert.NoError(t, c.Compile(parsed), input) {
+		return
+	}
+
+	var actual []string
+	for _, w := range c.Warnings() {
+		actual = append(actual, w.String())
+	}
+
+	assert.Equal(t, strings.Join(expected, "\n"), strings.Join(actual, "\n"), input)
+}
@@ -1,9 +1,6 @@
 package objects
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/d5/tengo/compiler/token"
 )
 
@@ -18,12 +15,7 @@ func (o *Array) TypeName() string {
 }
 
 func (o *Array) String() string {
-	var elements []string
-	for _, e := range o.Value {
-		elements = append(elements, e.String())
-	}
-
-	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+	return stringOf(o, make(map[Object]bool))
 }
 
 // BinaryOp returns another object that is the result of
@@ -35,6 +27,9 @@ func (o *Array) BinaryOp(op token.Token, rhs Object) (Object, error) {
 			if len(rhs.Value) == 0 {
 				return o, nil
 			}
+			if exceedsLimit(len(o.Value), len(rhs.Value), MaxArrayLen) {
+				return nil, ErrArrayLimit
+			}
 			return &Array{Value: append(o.Value, rhs.Value...)}, nil
 		}
 	}
@@ -44,12 +39,7 @@ func (o *Array) BinaryOp(op token.Token, rhs Object) (Object, error) {
 
 // Copy returns a copy of the type.
 func (o *Array) Copy() Object {
-	var c []Object
-	for _, elem := range o.Value {
-		c = append(c, elem.Copy())
-	}
-
-	return &Array{Value: c}
+	return copyOf(o, make(map[Object]Object))
 }
 
 // IsFalsy returns true if the value of the type is falsy.
@@ -60,27 +50,7 @@ func (o *Array) IsFalsy() bool {
 // Equals returns true if the value of the type
 // is equal to the value of another object.
 func (o *Array) Equals(x Object) bool {
-	var xVal []Object
-	switch x := x.(type) {
-	case *Array:
-		xVal = x.Value
-	case *ImmutableArray:
-		xVal = x.Value
-	default:
-		return false
-	}
-
-	if len(o.Value) != len(xVal) {
-		return false
-	}
-
-	for i, e := range o.Value {
-		if !e.Equals(xVal[i]) {
-			return false
-		}
-	}
-
-	return true
+	return DeepEquals(o, x)
 }
 
 // IndexGet returns an element at a given index.
@@ -128,3 +98,9 @@ func (o *Array) Iterate() Iterator {
 		l: len(o.Value),
 	}
 }
+
+// ByteSize returns the approximate size of the value in bytes
+// including the values it references.
+func (o *Array) ByteSize() int64 {
+	return SizeOf(o)
+}
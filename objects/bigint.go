@@ -0,0 +1,115 @@
+package objects
+
+import (
+	"math/big"
+
+	"github.com/d5/tengo/compiler/token"
+)
+
+// BigInt represents an integer value of arbitrary size. It's created when
+// the int arithmetic overflows and the VM promotes the result (see
+// runtime.IntOverflowBigInt). The results of the operations that fit in
+// int64 are Int values.
+type BigInt struct {
+	Value *big.Int
+}
+
+// NewBigInt returns an Int if the value fits in int64, or, a BigInt.
+func NewBigInt(v *big.Int) Object {
+	if v.IsInt64() {
+		return NewInt(v.Int64())
+	}
+
+	return &BigInt{Value: v}
+}
+
+func (o *BigInt) String() string {
+	return o.Value.String()
+}
+
+// TypeName returns the name of the type.
+func (o *BigInt) TypeName() string {
+	return "bigint"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (o *BigInt) BinaryOp(op token.Token, rhs Object) (Object, error) {
+	var r *big.Int
+	switch rhs := rhs.(type) {
+	case *BigInt:
+		r = rhs.Value
+	case *Int:
+		r = big.NewInt(rhs.Value)
+	case *Float:
+		f, _ := new(big.Float).SetInt(o.Value).Float64()
+		return (&Float{Value: f}).BinaryOp(op, rhs)
+	default:
+		return nil, ErrInvalidOperator
+	}
+
+	switch op {
+	case token.Add:
+		return NewBigInt(new(big.Int).Add(o.Value, r)), nil
+	case token.Sub:
+		return NewBigInt(new(big.Int).Sub(o.Value, r)), nil
+	case token.Mul:
+		return NewBigInt(new(big.Int).Mul(o.Value, r)), nil
+	case token.Quo:
+		if r.Sign() == 0 {
+			return divisionByZero(), nil
+		}
+		return NewBigInt(new(big.Int).Quo(o.Value, r)), nil
+	case token.Rem:
+		if r.Sign() == 0 {
+			return divisionByZero(), nil
+		}
+		return NewBigInt(new(big.Int).Rem(o.Value, r)), nil
+	case token.Less:
+		return boolValue(o.Value.Cmp(r) < 0), nil
+	case token.Greater:
+		return boolValue(o.Value.Cmp(r) > 0), nil
+	case token.LessEq:
+		return boolValue(o.Value.Cmp(r) <= 0), nil
+	case token.GreaterEq:
+		return boolValue(o.Value.Cmp(r) >= 0), nil
+	}
+
+	return nil, ErrInvalidOperator
+}
+
+// Copy returns a copy of the type.
+func (o *BigInt) Copy() Object {
+	return &BigInt{Value: new(big.Int).Set(o.Value)}
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (o *BigInt) IsFalsy() bool {
+	return o.Value.Sign() == 0
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (o *BigInt) Equals(x Object) bool {
+	switch x := x.(type) {
+	case *BigInt:
+		return o.Value.Cmp(x.Value) == 0
+	case *Int:
+		return o.Value.IsInt64() && o.Value.Int64() == x.Value
+	}
+
+	return false
+}
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *BigInt) ByteSize() int64 {
+	return int64(len(o.Value.Bits())) * 8
+}
+
+func boolValue(b bool) Object {
+	if b {
+		return TrueValue
+	}
+
+	return FalseValue
+}
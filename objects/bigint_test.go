@@ -0,0 +1,52 @@
+package objects_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+func TestBigInt_BinaryOp(t *testing.T) {
+	big1 := bigInt("10000000000000000000")
+
+	testBinaryOp(t, big1, token.Add, &objects.Int{Value: 1}, bigInt("10000000000000000001"))
+	testBinaryOp(t, big1, token.Sub, big1, &objects.Int{Value: 0})
+	testBinaryOp(t, big1, token.Mul, big1, bigInt("100000000000000000000000000000000000000"))
+	testBinaryOp(t, big1, token.Quo, &objects.Int{Value: 10}, &objects.Int{Value: 1000000000000000000})
+	testBinaryOp(t, big1, token.Rem, &objects.Int{Value: 3}, &objects.Int{Value: 1})
+	testBinaryOp(t, big1, token.Quo, &objects.Int{Value: 0}, divisionByZero)
+	testBinaryOp(t, big1, token.Greater, &objects.Int{Value: 1}, objects.TrueValue)
+	testBinaryOp(t, big1, token.LessEq, big1, objects.TrueValue)
+	testBinaryOp(t, big1, token.Mul, &objects.Float{Value: 0.5}, &objects.Float{Value: 5e18})
+
+	// int and float with bigint
+	testBinaryOp(t, &objects.Int{Value: -1}, token.Add, big1, bigInt("9999999999999999999"))
+	testBinaryOp(t, &objects.Int{Value: 1}, token.Less, big1, objects.TrueValue)
+	testBinaryOp(t, &objects.Float{Value: 1e20}, token.Greater, big1, objects.TrueValue)
+
+	_, err := big1.BinaryOp(token.Add, &objects.String{Value: "a"})
+	assert.Equal(t, objects.ErrInvalidOperator, err)
+}
+
+func TestBigInt(t *testing.T) {
+	big1 := bigInt("10000000000000000000")
+
+	assert.Equal(t, "bigint", big1.TypeName())
+	assert.Equal(t, "10000000000000000000", big1.String())
+	assert.False(t, big1.IsFalsy())
+	assert.True(t, big1.Equals(big1.Copy()))
+	assert.False(t, big1.Equals(&objects.Int{Value: 1}))
+	assert.Equal(t, &objects.Int{Value: 5}, objects.NewBigInt(big.NewInt(5)))
+
+	f, ok := objects.ToFloat64(big1)
+	assert.True(t, ok)
+	assert.Equal(t, 1e19, f)
+}
+
+func bigInt(s string) *objects.BigInt {
+	v, _ := new(big.Int).SetString(s, 10)
+	return &objects.BigInt{Value: v}
+}
@@ -62,3 +62,8 @@ func (o *Bool) GobEncode() (b []byte, err error) {
 
 	return
 }
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *Bool) ByteSize() int64 {
+	return 1
+}
@@ -0,0 +1,201 @@
+package objects
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/d5/tengo/compiler/token"
+)
+
+// Buffer represents a mutable, growable byte buffer. Unlike Bytes, appending
+// to a Buffer modifies it in place, so building a large byte sequence does
+// not copy the previously written data over and over.
+type Buffer struct {
+	Value []byte
+}
+
+func (o *Buffer) String() string {
+	return string(o.Value)
+}
+
+// TypeName returns the name of the type.
+func (o *Buffer) TypeName() string {
+	return "buffer"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (o *Buffer) BinaryOp(op token.Token, rhs Object) (Object, error) {
+	return nil, ErrInvalidOperator
+}
+
+// Copy returns a copy of the type.
+func (o *Buffer) Copy() Object {
+	return &Buffer{Value: append([]byte{}, o.Value...)}
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (o *Buffer) IsFalsy() bool {
+	return len(o.Value) == 0
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (o *Buffer) Equals(x Object) bool {
+	t, ok := x.(*Buffer)
+	if !ok {
+		return false
+	}
+
+	return bytes.Equal(o.Value, t.Value)
+}
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *Buffer) ByteSize() int64 {
+	return int64(cap(o.Value))
+}
+
+// IndexGet returns a buffer method for the given name.
+func (o *Buffer) IndexGet(index Object) (res Object, err error) {
+	strIdx, ok := index.(*String)
+	if !ok {
+		return nil, ErrInvalidIndexType
+	}
+
+	switch strIdx.Value {
+	case "append":
+		return &BuiltinFunction{Name: "append", Value: o.append}, nil
+	case "write_string":
+		return &BuiltinFunction{Name: "write_string", Value: o.writeString}, nil
+	case "truncate":
+		return &BuiltinFunction{Name: "truncate", Value: o.truncate}, nil
+	case "reset":
+		return &BuiltinFunction{Name: "reset", Value: o.reset}, nil
+	case "len":
+		return &BuiltinFunction{Name: "len", Value: o.len}, nil
+	case "bytes":
+		return &BuiltinFunction{Name: "bytes", Value: o.bytes}, nil
+	case "string":
+		return &BuiltinFunction{Name: "string", Value: o.string}, nil
+	}
+
+	return UndefinedValue, nil
+}
+
+// FieldNames returns nil: a buffer has no fields.
+func (o *Buffer) FieldNames() []string {
+	return nil
+}
+
+// MethodNames returns the names of the buffer methods.
+func (o *Buffer) MethodNames() []string {
+	return []string{"append", "write_string", "truncate", "reset", "len", "bytes", "string"}
+}
+
+// append(data bytes/string/char/int...) => buffer
+func (o *Buffer) append(args ...Object) (Object, error) {
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case *Bytes:
+			o.Value = append(o.Value, arg.Value...)
+		case *Buffer:
+			o.Value = append(o.Value, arg.Value...)
+		case *String:
+			o.Value = append(o.Value, arg.Value...)
+		case *Char:
+			o.Value = append(o.Value, string(arg.Value)...)
+		case *Int:
+			o.Value = append(o.Value, byte(arg.Value))
+		default:
+			return nil, ErrInvalidArgumentType{
+				Name:     fmt.Sprintf("args[%d]", i),
+				Expected: "bytes/string/char/int",
+				Found:    arg.TypeName(),
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// write_string(s string) => int
+func (o *Buffer) writeString(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	s, ok := args[0].(*String)
+	if !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	o.Value = append(o.Value, s.Value...)
+
+	return NewInt(int64(len(s.Value))), nil
+}
+
+// truncate(n int) => undefined
+func (o *Buffer) truncate(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	n, ok := args[0].(*Int)
+	if !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "int",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	if n.Value < 0 || n.Value > int64(len(o.Value)) {
+		return nil, ErrIndexOutOfBounds
+	}
+
+	o.Value = o.Value[:n.Value]
+
+	return UndefinedValue, nil
+}
+
+// reset() => undefined
+func (o *Buffer) reset(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	o.Value = o.Value[:0]
+
+	return UndefinedValue, nil
+}
+
+// len() => int
+func (o *Buffer) len(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	return NewInt(int64(len(o.Value))), nil
+}
+
+// bytes() => bytes
+func (o *Buffer) bytes(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	return &Bytes{Value: append([]byte{}, o.Value...)}, nil
+}
+
+// string() => string
+func (o *Buffer) string(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	return &String{Value: string(o.Value)}, nil
+}
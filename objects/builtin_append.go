@@ -8,8 +8,14 @@ func builtinAppend(args ...Object) (Object, error) {
 
 	switch arg := args[0].(type) {
 	case *Array:
+		if exceedsLimit(len(arg.Value), len(args)-1, MaxArrayLen) {
+			return nil, ErrArrayLimit
+		}
 		return &Array{Value: append(arg.Value, args[1:]...)}, nil
 	case *ImmutableArray:
+		if exceedsLimit(len(arg.Value), len(args)-1, MaxArrayLen) {
+			return nil, ErrArrayLimit
+		}
 		return &Array{Value: append(arg.Value, args[1:]...)}, nil
 	default:
 		return nil, ErrInvalidArgumentType{
@@ -0,0 +1,194 @@
+package objects
+
+import "fmt"
+
+// The array builtin functions modify the array in place, so they accept only
+// the mutable arrays.
+
+// push(arr, items...) => arr
+func builtinPush(args ...Object) (Object, error) {
+	if len(args) < 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	arr, err := mutableArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if exceedsLimit(len(arr.Value), len(args)-1, MaxArrayLen) {
+		return nil, ErrArrayLimit
+	}
+	arr.Value = append(arr.Value, args[1:]...)
+
+	return arr, nil
+}
+
+// pop(arr) => item/undefined
+func builtinPop(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	arr, err := mutableArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(arr.Value)
+	if n == 0 {
+		return UndefinedValue, nil
+	}
+
+	item := arr.Value[n-1]
+	arr.Value[n-1] = nil
+	arr.Value = arr.Value[:n-1]
+
+	return item, nil
+}
+
+// insert(arr, index, items...) => arr
+func builtinInsert(args ...Object) (Object, error) {
+	if len(args) < 2 {
+		return nil, ErrWrongNumArguments
+	}
+
+	arr, err := mutableArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := arrayIndex(args[1], len(arr.Value))
+	if err != nil {
+		return nil, err
+	}
+
+	items := args[2:]
+	if exceedsLimit(len(arr.Value), len(items), MaxArrayLen) {
+		return nil, ErrArrayLimit
+	}
+
+	n := len(arr.Value)
+	arr.Value = append(arr.Value, items...)
+	copy(arr.Value[idx+len(items):], arr.Value[idx:n])
+	copy(arr.Value[idx:], items)
+
+	return arr, nil
+}
+
+// remove_at(arr, index) => item
+func builtinRemoveAt(args ...Object) (Object, error) {
+	if len(args) != 2 {
+		return nil, ErrWrongNumArguments
+	}
+
+	arr, err := mutableArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := arrayIndex(args[1], len(arr.Value)-1)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(arr.Value)
+	item := arr.Value[idx]
+	copy(arr.Value[idx:], arr.Value[idx+1:])
+	arr.Value[n-1] = nil
+	arr.Value = arr.Value[:n-1]
+
+	return item, nil
+}
+
+// reverse(arr) => arr
+func builtinReverse(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	arr, err := mutableArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(arr.Value)-1; i < j; i, j = i+1, j-1 {
+		arr.Value[i], arr.Value[j] = arr.Value[j], arr.Value[i]
+	}
+
+	return arr, nil
+}
+
+// concat_in_place(arr, others...) => arr
+func builtinConcatInPlace(args ...Object) (Object, error) {
+	if len(args) < 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	arr, err := mutableArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// the arguments are checked first, so the array is not modified if
+	// any of them is invalid
+	n := len(arr.Value)
+	others := make([][]Object, len(args)-1)
+	for i, arg := range args[1:] {
+		switch arg := arg.(type) {
+		case *Array:
+			others[i] = arg.Value
+		case *ImmutableArray:
+			others[i] = arg.Value
+		default:
+			return nil, ErrInvalidArgumentType{
+				Name:     fmt.Sprintf("args[%d]", i+1),
+				Expected: "array",
+				Found:    arg.TypeName(),
+			}
+		}
+
+		if exceedsLimit(n, len(others[i]), MaxArrayLen) {
+			return nil, ErrArrayLimit
+		}
+		n += len(others[i])
+	}
+
+	for _, other := range others {
+		arr.Value = append(arr.Value, other...)
+	}
+
+	return arr, nil
+}
+
+func mutableArray(o Object) (*Array, error) {
+	arr, ok := o.(*Array)
+	if !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "array",
+			Found:    o.TypeName(),
+		}
+	}
+
+	return arr, nil
+}
+
+// arrayIndex returns the index argument if it's between 0 and max
+// (inclusive).
+func arrayIndex(o Object, max int) (int, error) {
+	idx, ok := o.(*Int)
+	if !ok {
+		return 0, ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "int",
+			Found:    o.TypeName(),
+		}
+	}
+
+	if idx.Value < 0 || idx.Value > int64(max) {
+		return 0, ErrIndexOutOfBounds
+	}
+
+	return int(idx.Value), nil
+}
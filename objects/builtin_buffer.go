@@ -0,0 +1,22 @@
+package objects
+
+// buffer([data bytes/string]) => buffer
+func builtinBuffer(args ...Object) (Object, error) {
+	switch len(args) {
+	case 0:
+		return &Buffer{}, nil
+	case 1:
+		v, ok := ToByteSlice(args[0])
+		if !ok {
+			return nil, ErrInvalidArgumentType{
+				Name:     "first",
+				Expected: "bytes/string",
+				Found:    args[0].TypeName(),
+			}
+		}
+
+		return &Buffer{Value: append([]byte{}, v...)}, nil
+	}
+
+	return nil, ErrWrongNumArguments
+}
@@ -12,7 +12,10 @@ func builtinString(args ...Object) (Object, error) {
 
 	v, ok := ToString(args[0])
 	if ok {
-		return &String{Value: v}, nil
+		if len(v) > MaxStringLen {
+			return nil, ErrStringLimit
+		}
+		return NewString(v), nil
 	}
 
 	if argsLen == 2 {
@@ -34,7 +37,7 @@ func builtinInt(args ...Object) (Object, error) {
 
 	v, ok := ToInt64(args[0])
 	if ok {
-		return &Int{Value: v}, nil
+		return NewInt(v), nil
 	}
 
 	if argsLen == 2 {
@@ -117,11 +120,17 @@ func builtinBytes(args ...Object) (Object, error) {
 
 	// bytes(N) => create a new bytes with given size N
 	if n, ok := args[0].(*Int); ok {
+		if n.Value > int64(MaxBytesLen) {
+			return nil, ErrBytesLimit
+		}
 		return &Bytes{Value: make([]byte, int(n.Value))}, nil
 	}
 
 	v, ok := ToByteSlice(args[0])
 	if ok {
+		if len(v) > MaxBytesLen {
+			return nil, ErrBytesLimit
+		}
 		return &Bytes{Value: v}, nil
 	}
 
@@ -7,3 +7,11 @@ func builtinCopy(args ...Object) (Object, error) {
 
 	return args[0].Copy(), nil
 }
+
+func builtinClone(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	return DeepCopy(args[0]), nil
+}
@@ -0,0 +1,13 @@
+package objects
+
+func builtinEqual(args ...Object) (Object, error) {
+	if len(args) != 2 {
+		return nil, ErrWrongNumArguments
+	}
+
+	if DeepEquals(args[0], args[1]) {
+		return TrueValue, nil
+	}
+
+	return FalseValue, nil
+}
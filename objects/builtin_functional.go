@@ -0,0 +1,358 @@
+package objects
+
+import (
+	"sort"
+
+	"github.com/d5/tengo/compiler/token"
+)
+
+// map(x iterable, fn func(v) => any) => array/map
+func builtinMap(rt Interop, args ...Object) (Object, error) {
+	fn, err := functionalArgs(2, 2, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if isMap(args[0]) {
+		res := make(map[string]Object)
+		err = forEachElement(args[0], func(k, v Object) (bool, error) {
+			r, err := callback(rt, fn, v)
+			if err != nil {
+				return false, err
+			}
+
+			res[k.(*String).Value] = r
+
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &Map{Value: res}, nil
+	}
+
+	res := make([]Object, 0, numElements(args[0]))
+	err = forEachElement(args[0], func(_, v Object) (bool, error) {
+		r, err := callback(rt, fn, v)
+		if err != nil {
+			return false, err
+		}
+
+		res = append(res, r)
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Array{Value: res}, nil
+}
+
+// filter(x iterable, fn func(v) => bool) => array/map
+func builtinFilter(rt Interop, args ...Object) (Object, error) {
+	fn, err := functionalArgs(2, 2, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if isMap(args[0]) {
+		res := make(map[string]Object)
+		err = forEachElement(args[0], func(k, v Object) (bool, error) {
+			r, err := callback(rt, fn, v)
+			if err != nil {
+				return false, err
+			}
+
+			if !r.IsFalsy() {
+				res[k.(*String).Value] = v
+			}
+
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &Map{Value: res}, nil
+	}
+
+	res := make([]Object, 0)
+	err = forEachElement(args[0], func(_, v Object) (bool, error) {
+		r, err := callback(rt, fn, v)
+		if err != nil {
+			return false, err
+		}
+
+		if !r.IsFalsy() {
+			res = append(res, v)
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Array{Value: res}, nil
+}
+
+// reduce(x iterable, fn func(acc, v) => any, initial any) => any
+func builtinReduce(rt Interop, args ...Object) (Object, error) {
+	fn, err := functionalArgs(2, 3, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first element is the initial value if not given
+	var acc Object
+	if len(args) == 3 {
+		acc = args[2]
+	}
+
+	err = forEachElement(args[0], func(_, v Object) (bool, error) {
+		if acc == nil {
+			acc = v
+			return true, nil
+		}
+
+		var err error
+		acc, err = callback(rt, fn, acc, v)
+
+		return err == nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if acc == nil {
+		return UndefinedValue, nil
+	}
+
+	return acc, nil
+}
+
+// each(x iterable, fn func(v)) => undefined
+func builtinEach(rt Interop, args ...Object) (Object, error) {
+	fn, err := functionalArgs(2, 2, args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = forEachElement(args[0], func(_, v Object) (bool, error) {
+		_, err := callback(rt, fn, v)
+
+		return err == nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return UndefinedValue, nil
+}
+
+// find(x iterable, fn func(v) => bool) => any/undefined
+func builtinFind(rt Interop, args ...Object) (Object, error) {
+	fn, err := functionalArgs(2, 2, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var res Object = UndefinedValue
+	err = forEachElement(args[0], func(_, v Object) (bool, error) {
+		r, err := callback(rt, fn, v)
+		if err != nil {
+			return false, err
+		}
+
+		if !r.IsFalsy() {
+			res = v
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// sort_by(x iterable, fn func(v) => any) => array
+func builtinSortBy(rt Interop, args ...Object) (Object, error) {
+	fn, err := functionalArgs(2, 2, args)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sortByElements{}
+	err = forEachElement(args[0], func(_, v Object) (bool, error) {
+		k, err := callback(rt, fn, v)
+		if err != nil {
+			return false, err
+		}
+
+		s.values = append(s.values, v)
+		s.keys = append(s.keys, k)
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Stable(s)
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return &Array{Value: s.values}, nil
+}
+
+// sortByElements sorts the values by their keys using '<' operator (or
+// Compare method of the Comparable keys). The first error stops comparing
+// the elements.
+type sortByElements struct {
+	values []Object
+	keys   []Object
+	err    error
+}
+
+func (s *sortByElements) Len() int {
+	return len(s.values)
+}
+
+func (s *sortByElements) Less(i, j int) bool {
+	if s.err != nil {
+		return false
+	}
+
+	if c, ok := s.keys[i].(Comparable); ok {
+		r, err := c.Compare(s.keys[j])
+		if err != nil {
+			s.err = err
+			return false
+		}
+
+		return r < 0
+	}
+
+	// a < b is evaluated as b > a like the VM does
+	var res Object
+	res, s.err = s.keys[j].BinaryOp(token.Greater, s.keys[i])
+
+	return s.err == nil && !res.IsFalsy()
+}
+
+func (s *sortByElements) Swap(i, j int) {
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// functionalArgs checks the arguments of a higher-order builtin function:
+// an iterable object and a function followed by the optional arguments. It
+// returns the function.
+func functionalArgs(min, max int, args []Object) (Object, error) {
+	if len(args) < min || len(args) > max {
+		return nil, ErrWrongNumArguments
+	}
+
+	if _, ok := args[0].(Iterable); !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "iterable",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	switch args[1].(type) {
+	case *CompiledFunction, *Closure, Callable, InteropCallable:
+		return args[1], nil
+	}
+
+	return nil, ErrInvalidArgumentType{
+		Name:     "second",
+		Expected: "callable",
+		Found:    args[1].TypeName(),
+	}
+}
+
+// callback calls the function passed to a higher-order builtin function.
+// Without the runtime (e.g. called from Go code), only the Callable
+// objects can be called.
+func callback(rt Interop, fn Object, args ...Object) (Object, error) {
+	var res Object
+	var err error
+	if rt != nil {
+		res, err = rt.Call(fn, args...)
+	} else if c, ok := fn.(Callable); ok {
+		res, err = c.Call(args...)
+	} else {
+		return nil, ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "callable without runtime",
+			Found:    fn.TypeName(),
+		}
+	}
+
+	if err == nil && res == nil {
+		res = UndefinedValue
+	}
+
+	return res, err
+}
+
+// forEachElement calls fn with the key and the value of the elements of the
+// iterable object until fn returns false or an error. The key is nil for
+// the arrays.
+func forEachElement(x Object, fn func(key, value Object) (bool, error)) error {
+	var elements []Object
+	switch x := x.(type) {
+	case *Array:
+		elements = x.Value
+	case *ImmutableArray:
+		elements = x.Value
+	}
+
+	if elements != nil {
+		for _, v := range elements {
+			if ok, err := fn(nil, v); !ok || err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	it := x.(Iterable).Iterate()
+	for it.Next() {
+		if ok, err := fn(it.Key(), it.Value()); !ok || err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isMap(x Object) bool {
+	switch x.(type) {
+	case *Map, *ImmutableMap:
+		return true
+	}
+
+	return false
+}
+
+// numElements returns the number of the elements of an array, or, 0.
+func numElements(x Object) int {
+	switch x := x.(type) {
+	case *Array:
+		return len(x.Value)
+	case *ImmutableArray:
+		return len(x.Value)
+	}
+
+	return 0
+}
@@ -0,0 +1,22 @@
+package objects
+
+// iter(x iterable) => iterator
+func builtinIter(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	switch x := args[0].(type) {
+	case Iterator:
+		// e.g. the iterator returned by a method of a user type
+		return NewIter(x), nil
+	case Iterable:
+		return NewIter(x.Iterate()), nil
+	}
+
+	return nil, ErrInvalidArgumentType{
+		Name:     "first",
+		Expected: "iterable",
+		Found:    args[0].TypeName(),
+	}
+}
@@ -1,16 +1,16 @@
 package objects
 
-import (
-	"encoding/json"
-)
-
 // to_json(v object) => bytes
 func builtinToJSON(args ...Object) (Object, error) {
 	if len(args) != 1 {
 		return nil, ErrWrongNumArguments
 	}
 
-	res, err := json.Marshal(objectToInterface(args[0]))
+	if isCyclic(args[0], make(map[Object]bool)) {
+		return &Error{Value: &String{Value: "json: unsupported value: encountered a cycle"}}, nil
+	}
+
+	res, err := encodeJSON(jsonNonFinite(objectToInterface(args[0]), NonFinite))
 	if err != nil {
 		return &Error{Value: &String{Value: err.Error()}}, nil
 	}
@@ -18,25 +18,18 @@ func builtinToJSON(args ...Object) (Object, error) {
 	return &Bytes{Value: res}, nil
 }
 
-// from_json(data string/bytes) => object
+// from_json(data string/bytes [, target object]) => object
 func builtinFromJSON(args ...Object) (Object, error) {
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		return nil, ErrWrongNumArguments
 	}
 
-	var target interface{}
-
+	var data []byte
 	switch o := args[0].(type) {
 	case *Bytes:
-		err := json.Unmarshal(o.Value, &target)
-		if err != nil {
-			return &Error{Value: &String{Value: err.Error()}}, nil
-		}
+		data = o.Value
 	case *String:
-		err := json.Unmarshal([]byte(o.Value), &target)
-		if err != nil {
-			return &Error{Value: &String{Value: err.Error()}}, nil
-		}
+		data = []byte(o.Value)
 	default:
 		return nil, ErrInvalidArgumentType{
 			Name:     "first",
@@ -45,6 +38,15 @@ func builtinFromJSON(args ...Object) (Object, error) {
 		}
 	}
 
+	if len(args) == 2 {
+		return unmarshalJSONInto(data, args[1])
+	}
+
+	target, err := decodeJSON(data)
+	if err != nil {
+		return &Error{Value: &String{Value: err.Error()}}, nil
+	}
+
 	res, err := FromInterface(target)
 	if err != nil {
 		return nil, err
@@ -52,3 +54,30 @@ func builtinFromJSON(args ...Object) (Object, error) {
 
 	return res, nil
 }
+
+// unmarshalJSONInto decodes data into a copy of the target object.
+func unmarshalJSONInto(data []byte, target Object) (Object, error) {
+	if _, ok := target.(JSONUnmarshaler); !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "json-unmarshaler",
+			Found:    target.TypeName(),
+		}
+	}
+
+	res := target.Copy()
+	u, ok := res.(JSONUnmarshaler)
+	if !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "json-unmarshaler",
+			Found:    res.TypeName(),
+		}
+	}
+
+	if err := u.UnmarshalJSON(data); err != nil {
+		return &Error{Value: &String{Value: err.Error()}}, nil
+	}
+
+	return res, nil
+}
@@ -0,0 +1,106 @@
+package objects_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+// The tests run against encoding/json by default, and, against the encoding
+// of the embedded builds with "go test -tags tengo_embedded".
+
+func TestBuiltinJSON_Encode(t *testing.T) {
+	toJSON := builtinFunc(t, "to_json")
+
+	expect := func(o objects.Object, expected string) {
+		res, err := toJSON(o)
+		if assert.NoError(t, err) && assert.NotNil(t, res) {
+			b, ok := res.(*objects.Bytes)
+			if assert.True(t, ok, "unexpected result: %s", res) {
+				assert.Equal(t, expected, string(b.Value))
+			}
+		}
+	}
+
+	expect(objects.TrueValue, `true`)
+	expect(&objects.Int{Value: -42}, `-42`)
+	expect(&objects.Char{Value: 'a'}, `97`)
+	expect(&objects.BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 70)}, `1180591620717411303424`)
+	expect(&objects.Bytes{Value: []byte("foo")}, `"Zm9v"`)
+	expect(&objects.String{Value: "a\"b\\c\n\t<&>\x01é\u2028"}, `"a\"b\\c\n\t\u003c\u0026\u003e\u0001é\u2028"`)
+	expect(&objects.String{Value: "\xff"}, "\"\ufffd\"")
+
+	for _, c := range []struct {
+		f        float64
+		expected string
+	}{
+		{0, `0`},
+		{1.5, `1.5`},
+		{-0.1, `-0.1`},
+		{1e20, `100000000000000000000`},
+		{1e21, `1e+21`},
+		{1e-6, `0.000001`},
+		{1e-7, `1e-7`},
+		{1.5e-10, `1.5e-10`},
+	} {
+		expect(&objects.Float{Value: c.f}, c.expected)
+	}
+
+	expect(&objects.Array{Value: []objects.Object{}}, `[]`)
+	expect(&objects.Map{Value: map[string]objects.Object{
+		"b": &objects.Array{Value: []objects.Object{&objects.Int{Value: 1}, &objects.String{Value: "x"}}},
+		"a": &objects.Map{Value: map[string]objects.Object{}},
+		"c": objects.FalseValue,
+	}}, `{"a":{},"b":[1,"x"],"c":false}`)
+}
+
+func TestBuiltinJSON_Decode(t *testing.T) {
+	fromJSON := builtinFunc(t, "from_json")
+
+	expect := func(input string, expected objects.Object) {
+		res, err := fromJSON(&objects.String{Value: input})
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, res)
+		}
+	}
+
+	expect(`null`, objects.UndefinedValue)
+	expect(` true `, objects.TrueValue)
+	expect(`-1.5e2`, &objects.Float{Value: -150})
+	expect(`0`, &objects.Float{Value: 0})
+	expect(`"a\"\\\/\b\f\n\r\té"`, &objects.String{Value: "a\"\\/\b\f\n\r\té"})
+	expect(`"\ud83d\ude00"`, &objects.String{Value: "\U0001F600"})
+	expect(`"\ud83dx"`, &objects.String{Value: "\ufffdx"})
+	expect(`"\ud83d\u0041"`, &objects.String{Value: "\ufffdA"})
+	expect("[1, [], {\"a\":\n[null]}]", &objects.Array{Value: []objects.Object{
+		&objects.Float{Value: 1},
+		&objects.Array{Value: []objects.Object{}},
+		&objects.Map{Value: map[string]objects.Object{
+			"a": &objects.Array{Value: []objects.Object{objects.UndefinedValue}},
+		}},
+	}})
+
+	for _, input := range []string{
+		``, `{`, `[1,]`, `{"a" 1}`, `{"a":1,}`, `01`, `1.`, `1e`, `-`, `tru`, `nul`,
+		`"a`, `"\x"`, `"\u12"`, "\"\x01\"", `1 2`, `{1:2}`, `1e999`,
+	} {
+		res, err := fromJSON(&objects.String{Value: input})
+		if assert.NoError(t, err) {
+			_, ok := res.(*objects.Error)
+			assert.True(t, ok, "input %q: expected error, got %s", input, res)
+		}
+	}
+}
+
+func builtinFunc(t *testing.T, name string) objects.CallableFunc {
+	for _, fn := range objects.Builtins {
+		if fn.Name == name {
+			return fn.Func
+		}
+	}
+	t.Fatalf("builtin function not found: %s", name)
+
+	return nil
+}
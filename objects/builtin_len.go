@@ -8,21 +8,23 @@ func builtinLen(args ...Object) (Object, error) {
 
 	switch arg := args[0].(type) {
 	case *Array:
-		return &Int{Value: int64(len(arg.Value))}, nil
+		return NewInt(int64(len(arg.Value))), nil
 	case *ImmutableArray:
-		return &Int{Value: int64(len(arg.Value))}, nil
+		return NewInt(int64(len(arg.Value))), nil
 	case *String:
-		return &Int{Value: int64(len(arg.Value))}, nil
+		return NewInt(int64(len(arg.Value))), nil
 	case *Bytes:
-		return &Int{Value: int64(len(arg.Value))}, nil
+		return NewInt(int64(len(arg.Value))), nil
+	case *Buffer:
+		return NewInt(int64(len(arg.Value))), nil
 	case *Map:
-		return &Int{Value: int64(len(arg.Value))}, nil
+		return NewInt(int64(len(arg.Value))), nil
 	case *ImmutableMap:
-		return &Int{Value: int64(len(arg.Value))}, nil
+		return NewInt(int64(len(arg.Value))), nil
 	default:
 		return nil, ErrInvalidArgumentType{
 			Name:     "first",
-			Expected: "array/string/bytes/map",
+			Expected: "array/string/bytes/buffer/map",
 			Found:    arg.TypeName(),
 		}
 	}
@@ -0,0 +1,215 @@
+package objects
+
+import "sort"
+
+// keys(m) => [string]
+func builtinKeys(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	m, err := mapValue(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := sortedKeys(m)
+	arr := make([]Object, len(keys))
+	for i, k := range keys {
+		arr[i] = &String{Value: k}
+	}
+
+	return &Array{Value: arr}, nil
+}
+
+// values(m) => [any]
+func builtinValues(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	m, err := mapValue(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := sortedKeys(m)
+	arr := make([]Object, len(keys))
+	for i, k := range keys {
+		arr[i] = m[k]
+	}
+
+	return &Array{Value: arr}, nil
+}
+
+// merge(a, b, deep) => map
+func builtinMerge(args ...Object) (Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, ErrWrongNumArguments
+	}
+
+	a, err := mapValue(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := mapValue(args[1], "second")
+	if err != nil {
+		return nil, err
+	}
+
+	deep := len(args) == 3 && !args[2].IsFalsy()
+
+	return &Map{Value: mergeMaps(a, b, deep)}, nil
+}
+
+// delete(m, key) => undefined
+func builtinDelete(args ...Object) (Object, error) {
+	if len(args) != 2 {
+		return nil, ErrWrongNumArguments
+	}
+
+	m, ok := args[0].(*Map)
+	if !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "map",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	key, err := mapKey(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	delete(m.Value, key)
+
+	return UndefinedValue, nil
+}
+
+// has(m, key) => bool
+func builtinHas(args ...Object) (Object, error) {
+	if len(args) != 2 {
+		return nil, ErrWrongNumArguments
+	}
+
+	m, err := mapValue(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := mapKey(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := m[key]; ok {
+		return TrueValue, nil
+	}
+
+	return FalseValue, nil
+}
+
+// get(m, key, default) => any
+func builtinGet(args ...Object) (Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, ErrWrongNumArguments
+	}
+
+	m, err := mapValue(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := mapKey(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := m[key]; ok {
+		return v, nil
+	}
+
+	if len(args) == 3 {
+		return args[2], nil
+	}
+
+	return UndefinedValue, nil
+}
+
+// mergeMaps returns a new map of the elements of a and b. The values of b
+// replace the values of a, unless both are maps and deep is true, in which
+// case the maps are merged recursively.
+func mergeMaps(a, b map[string]Object, deep bool) map[string]Object {
+	res := make(map[string]Object, len(a)+len(b))
+	for k, v := range a {
+		res[k] = v
+	}
+
+	for k, v := range b {
+		if deep {
+			if bm, ok := mapElements(v); ok {
+				if am, ok := mapElements(res[k]); ok {
+					res[k] = &Map{Value: mergeMaps(am, bm, deep)}
+					continue
+				}
+			}
+		}
+
+		res[k] = v
+	}
+
+	return res
+}
+
+func mapValue(o Object, name string) (map[string]Object, error) {
+	m, ok := mapElements(o)
+	if !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     name,
+			Expected: "map",
+			Found:    o.TypeName(),
+		}
+	}
+
+	return m, nil
+}
+
+// mapElements returns the elements of a map or an immutable map.
+func mapElements(o Object) (map[string]Object, bool) {
+	switch o := o.(type) {
+	case *Map:
+		return o.Value, true
+	case *ImmutableMap:
+		return o.Value, true
+	}
+
+	return nil, false
+}
+
+func mapKey(o Object) (string, error) {
+	s, ok := o.(*String)
+	if !ok {
+		return "", ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "string",
+			Found:    o.TypeName(),
+		}
+	}
+
+	return s.Value, nil
+}
+
+// sortedKeys returns the keys of the map in the ascending order, so the
+// results of keys and values do not depend on the iteration order of Go
+// maps.
+func sortedKeys(m map[string]Object) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,60 @@
+package objects
+
+import (
+	"sync"
+
+	"github.com/d5/tengo/compiler/token"
+)
+
+// BuiltinModule represents a builtin module of which the members are created
+// when the module is imported for the first time. The VM imports the
+// immutable map returned by Module.
+type BuiltinModule struct {
+	Name    string
+	Members func() map[string]Object
+	once    sync.Once
+	module  *ImmutableMap
+}
+
+// Module returns the immutable map of the members of the module. Members is
+// called only once even if Module is called by multiple goroutines, and, the
+// map is shared by all of them.
+func (o *BuiltinModule) Module() *ImmutableMap {
+	o.once.Do(func() {
+		o.module = &ImmutableMap{Value: o.Members()}
+		Freeze(o.module)
+	})
+
+	return o.module
+}
+
+// TypeName returns the name of the type.
+func (o *BuiltinModule) TypeName() string {
+	return "builtin-module:" + o.Name
+}
+
+func (o *BuiltinModule) String() string {
+	return "<builtin-module>"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (o *BuiltinModule) BinaryOp(op token.Token, rhs Object) (Object, error) {
+	return nil, ErrInvalidOperator
+}
+
+// Copy returns the same module as the module is immutable.
+func (o *BuiltinModule) Copy() Object {
+	return o
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (o *BuiltinModule) IsFalsy() bool {
+	return false
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (o *BuiltinModule) Equals(x Object) bool {
+	return o == x
+}
@@ -0,0 +1,36 @@
+package objects_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestBuiltinModule(t *testing.T) {
+	var calls int
+	mod := &objects.BuiltinModule{
+		Name: "mod",
+		Members: func() map[string]objects.Object {
+			calls++
+			return map[string]objects.Object{"a": &objects.Int{Value: 1}}
+		},
+	}
+	assert.Equal(t, "builtin-module:mod", mod.TypeName())
+	assert.Equal(t, 0, calls)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, &objects.ImmutableMap{Value: map[string]objects.Object{"a": &objects.Int{Value: 1}}}, mod.Module())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, calls)
+	assert.True(t, mod.Module() == mod.Module())
+	assert.True(t, mod.Copy() == objects.Object(mod))
+}
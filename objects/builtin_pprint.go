@@ -0,0 +1,186 @@
+package objects
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pprintIndent is the default number of the spaces of each indentation level
+// of pprint and pformat.
+const pprintIndent = 2
+
+// pprint(v object [, indent int])
+func builtinPPrint(args ...Object) (Object, error) {
+	s, err := pformat(args)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println(s)
+
+	return nil, nil
+}
+
+// pformat(v object [, indent int]) => string
+func builtinPFormat(args ...Object) (Object, error) {
+	s, err := pformat(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &String{Value: s}, nil
+}
+
+func pformat(args []Object) (string, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return "", ErrWrongNumArguments
+	}
+
+	p := &prettyPrinter{
+		indent:    strings.Repeat(" ", pprintIndent),
+		ancestors: make(map[Object]bool),
+	}
+	if len(args) == 2 {
+		i, ok := args[1].(*Int)
+		if !ok {
+			return "", ErrInvalidArgumentType{
+				Name:     "second",
+				Expected: "int",
+				Found:    args[1].TypeName(),
+			}
+		}
+		if i.Value < 0 || i.Value > 16 {
+			return "", fmt.Errorf("invalid indent: %d", i.Value)
+		}
+		p.indent = strings.Repeat(" ", int(i.Value))
+	}
+
+	if err := p.write(args[0], 0); err != nil {
+		return "", err
+	}
+
+	return p.sb.String(), nil
+}
+
+// prettyPrinter renders the values over multiple lines with their types. The
+// elements of the arrays and the maps are written on their own lines, unless
+// the indentation is empty, and the keys of the maps are sorted.
+type prettyPrinter struct {
+	sb        strings.Builder
+	indent    string
+	ancestors map[Object]bool
+}
+
+func (p *prettyPrinter) write(o Object, depth int) error {
+	switch o := o.(type) {
+	case *Array:
+		return p.writeArray(o, o.Value, depth)
+	case *ImmutableArray:
+		return p.writeArray(o, o.Value, depth)
+	case *Map:
+		return p.writeMap(o, o.Value, depth)
+	case *ImmutableMap:
+		return p.writeMap(o, o.Value, depth)
+	case *Error:
+		p.sb.WriteString("error(")
+		if o.Value != nil {
+			if err := p.write(o.Value, depth); err != nil {
+				return err
+			}
+		}
+		p.sb.WriteString(")")
+	case *String:
+		p.sb.WriteString("string(" + strconv.Quote(o.Value) + ")")
+	case *Char:
+		p.sb.WriteString("char(" + strconv.QuoteRune(o.Value) + ")")
+	case *Bytes:
+		p.sb.WriteString("bytes(" + strconv.Quote(string(o.Value)) + ")")
+	default:
+		s := o.String()
+		if strings.HasPrefix(s, "<") {
+			p.sb.WriteString(s)
+		} else {
+			p.sb.WriteString(o.TypeName() + "(" + s + ")")
+		}
+	}
+
+	if p.sb.Len() > MaxStringLen {
+		return ErrStringLimit
+	}
+
+	return nil
+}
+
+func (p *prettyPrinter) writeArray(o Object, value []Object, depth int) error {
+	if p.ancestors[o] {
+		p.sb.WriteString("<cycle " + o.TypeName() + ">")
+		return nil
+	}
+	p.ancestors[o] = true
+	defer delete(p.ancestors, o)
+
+	p.sb.WriteString(fmt.Sprintf("%s(%d) [", o.TypeName(), len(value)))
+	for i, e := range value {
+		p.writeSeparator(i, depth+1)
+		if err := p.write(e, depth+1); err != nil {
+			return err
+		}
+	}
+	p.writeEnd(len(value), depth)
+	p.sb.WriteString("]")
+
+	return nil
+}
+
+func (p *prettyPrinter) writeMap(o Object, value map[string]Object, depth int) error {
+	if p.ancestors[o] {
+		p.sb.WriteString("<cycle " + o.TypeName() + ">")
+		return nil
+	}
+	p.ancestors[o] = true
+	defer delete(p.ancestors, o)
+
+	keys := make([]string, 0, len(value))
+	for k := range value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	p.sb.WriteString(fmt.Sprintf("%s(%d) {", o.TypeName(), len(value)))
+	for i, k := range keys {
+		p.writeSeparator(i, depth+1)
+		p.sb.WriteString(strconv.Quote(k) + ": ")
+		if err := p.write(value[k], depth+1); err != nil {
+			return err
+		}
+	}
+	p.writeEnd(len(value), depth)
+	p.sb.WriteString("}")
+
+	return nil
+}
+
+// writeSeparator writes what comes before the i-th element of an array or a
+// map.
+func (p *prettyPrinter) writeSeparator(i int, depth int) {
+	if p.indent == "" {
+		if i > 0 {
+			p.sb.WriteString(", ")
+		}
+		return
+	}
+
+	if i > 0 {
+		p.sb.WriteString(",")
+	}
+	p.sb.WriteString("\n" + strings.Repeat(p.indent, depth))
+}
+
+// writeEnd writes what comes after the last element of an array or a map.
+func (p *prettyPrinter) writeEnd(n int, depth int) {
+	if p.indent != "" && n > 0 {
+		p.sb.WriteString(",\n" + strings.Repeat(p.indent, depth))
+	}
+}
@@ -0,0 +1,77 @@
+package objects_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestBuiltinPFormat(t *testing.T) {
+	pformat := builtinFunc(t, "pformat")
+
+	expect := func(expected string, args ...objects.Object) {
+		res, err := pformat(args...)
+		if assert.NoError(t, err) {
+			assert.Equal(t, &objects.String{Value: expected}, res)
+		}
+	}
+
+	expect(`int(30)`, &objects.Int{Value: 30})
+	expect(`string("a\"b\n")`, &objects.String{Value: "a\"b\n"})
+	expect(`char('x')`, &objects.Char{Value: 'x'})
+	expect(`bytes("foo")`, &objects.Bytes{Value: []byte("foo")})
+	expect(`bool(true)`, objects.TrueValue)
+	expect(`<undefined>`, objects.UndefinedValue)
+	expect(`array(0) []`, &objects.Array{})
+	expect(`map(0) {}`, &objects.Map{Value: map[string]objects.Object{}})
+
+	v := &objects.Map{Value: map[string]objects.Object{
+		"name": &objects.String{Value: "kim"},
+		"age":  &objects.Int{Value: 30},
+		"tags": &objects.ImmutableArray{Value: []objects.Object{
+			&objects.String{Value: "a"},
+			&objects.Float{Value: 1.5},
+		}},
+		"err": &objects.Error{Value: &objects.String{Value: "oops"}},
+	}}
+	expect(`map(4) {
+  "age": int(30),
+  "err": error(string("oops")),
+  "name": string("kim"),
+  "tags": immutable-array(2) [
+    string("a"),
+    float(1.5),
+  ],
+}`, v)
+	expect(`map(4) {
+    "age": int(30),
+    "err": error(string("oops")),
+    "name": string("kim"),
+    "tags": immutable-array(2) [
+        string("a"),
+        float(1.5),
+    ],
+}`, v, &objects.Int{Value: 4})
+	expect(`map(4) {"age": int(30), "err": error(string("oops")), "name": string("kim"), `+
+		`"tags": immutable-array(2) [string("a"), float(1.5)]}`, v, &objects.Int{Value: 0})
+
+	// cycles
+	a := &objects.Array{}
+	a.Value = []objects.Object{a, &objects.Map{Value: map[string]objects.Object{"a": a}}}
+	expect(`array(2) [<cycle array>, map(1) {"a": <cycle array>}]`, a, &objects.Int{Value: 0})
+
+	// the same value more than once is not a cycle
+	m := &objects.Map{Value: map[string]objects.Object{}}
+	expect(`array(2) [map(0) {}, map(0) {}]`, &objects.Array{Value: []objects.Object{m, m}}, &objects.Int{Value: 0})
+
+	for _, args := range [][]objects.Object{
+		{},
+		{objects.TrueValue, &objects.Int{Value: 2}, &objects.Int{Value: 2}},
+		{objects.TrueValue, &objects.String{Value: "  "}},
+		{objects.TrueValue, &objects.Int{Value: -1}},
+	} {
+		_, err := pformat(args...)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,19 @@
+package objects
+
+// stream(x iterable) => stream
+func builtinStream(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	iterable, ok := args[0].(Iterable)
+	if !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "iterable",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	return NewStream(iterable), nil
+}
@@ -175,7 +175,7 @@ func builtinIsCallable(args ...Object) (Object, error) {
 	}
 
 	switch args[0].(type) {
-	case *CompiledFunction, *Closure, Callable: // BuiltinFunction is Callable
+	case *CompiledFunction, *Closure, Callable, InteropCallable: // BuiltinFunction is Callable
 		return TrueValue, nil
 	}
 
@@ -4,6 +4,16 @@ package objects
 type NamedBuiltinFunc struct {
 	Name string
 	Func CallableFunc
+
+	// Interop is the function that calls back the script functions using
+	// the runtime. The VM calls it instead of Func if it's set, and, Func
+	// calls it without the runtime.
+	Interop InteropFunc
+
+	// Deprecated is the deprecation notice of the function (e.g. what to use
+	// instead). The compiler reports a warning when a deprecated function is
+	// used.
+	Deprecated string
 }
 
 // Builtins contains all default builtin functions.
@@ -28,10 +38,22 @@ var Builtins = []NamedBuiltinFunc{
 		Name: "copy",
 		Func: builtinCopy,
 	},
+	{
+		Name: "clone",
+		Func: builtinClone,
+	},
+	{
+		Name: "equal",
+		Func: builtinEqual,
+	},
 	{
 		Name: "append",
 		Func: builtinAppend,
 	},
+	{
+		Name: "stream",
+		Func: builtinStream,
+	},
 	{
 		Name: "string",
 		Func: builtinString,
@@ -56,6 +78,10 @@ var Builtins = []NamedBuiltinFunc{
 		Name: "bytes",
 		Func: builtinBytes,
 	},
+	{
+		Name: "buffer",
+		Func: builtinBuffer,
+	},
 	{
 		Name: "time",
 		Func: builtinTime,
@@ -132,4 +158,98 @@ var Builtins = []NamedBuiltinFunc{
 		Name: "type_name",
 		Func: builtinTypeName,
 	},
+	{
+		Name: "iter",
+		Func: builtinIter,
+	},
+	{
+		Name:    "map",
+		Interop: builtinMap,
+	},
+	{
+		Name:    "filter",
+		Interop: builtinFilter,
+	},
+	{
+		Name:    "reduce",
+		Interop: builtinReduce,
+	},
+	{
+		Name:    "each",
+		Interop: builtinEach,
+	},
+	{
+		Name:    "find",
+		Interop: builtinFind,
+	},
+	{
+		Name:    "sort_by",
+		Interop: builtinSortBy,
+	},
+	{
+		Name: "push",
+		Func: builtinPush,
+	},
+	{
+		Name: "pop",
+		Func: builtinPop,
+	},
+	{
+		Name: "insert",
+		Func: builtinInsert,
+	},
+	{
+		Name: "remove_at",
+		Func: builtinRemoveAt,
+	},
+	{
+		Name: "reverse",
+		Func: builtinReverse,
+	},
+	{
+		Name: "concat_in_place",
+		Func: builtinConcatInPlace,
+	},
+	{
+		Name: "keys",
+		Func: builtinKeys,
+	},
+	{
+		Name: "values",
+		Func: builtinValues,
+	},
+	{
+		Name: "merge",
+		Func: builtinMerge,
+	},
+	{
+		Name: "delete",
+		Func: builtinDelete,
+	},
+	{
+		Name: "has",
+		Func: builtinHas,
+	},
+	{
+		Name: "get",
+		Func: builtinGet,
+	},
+	{
+		Name: "pprint",
+		Func: builtinPPrint,
+	},
+	{
+		Name: "pformat",
+		Func: builtinPFormat,
+	},
+}
+
+func init() {
+	for i := range Builtins {
+		if fn := Builtins[i].Interop; fn != nil && Builtins[i].Func == nil {
+			Builtins[i].Func = func(args ...Object) (Object, error) {
+				return fn(nil, args...)
+			}
+		}
+	}
 }
@@ -27,7 +27,13 @@ func (o *Bytes) BinaryOp(op token.Token, rhs Object) (Object, error) {
 	case token.Add:
 		switch rhs := rhs.(type) {
 		case *Bytes:
-			return &Bytes{Value: append(o.Value, rhs.Value...)}, nil
+			if exceedsLimit(len(o.Value), len(rhs.Value), MaxBytesLen) {
+				return nil, ErrBytesLimit
+			}
+			// the bytes are mutable: the result must not share the
+			// memory of the operands
+			res := make([]byte, 0, len(o.Value)+len(rhs.Value))
+			return &Bytes{Value: append(append(res, o.Value...), rhs.Value...)}, nil
 		}
 	}
 
@@ -70,7 +76,37 @@ func (o *Bytes) IndexGet(index Object) (res Object, err error) {
 		return
 	}
 
-	res = &Int{Value: int64(o.Value[idxVal])}
+	res = NewInt(int64(o.Value[idxVal]))
 
 	return
 }
+
+// IndexSet sets an element at a given index. The value must be an int or a
+// char in the range of a byte.
+func (o *Bytes) IndexSet(index, value Object) (err error) {
+	intIdx, ok := ToInt(index)
+	if !ok {
+		err = ErrInvalidIndexType
+		return
+	}
+
+	if intIdx < 0 || intIdx >= len(o.Value) {
+		err = ErrIndexOutOfBounds
+		return
+	}
+
+	b, ok := ToRune(value)
+	if !ok || b < 0 || b > 255 {
+		err = ErrInvalidIndexValueType
+		return
+	}
+
+	o.Value[intIdx] = byte(b)
+
+	return nil
+}
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *Bytes) ByteSize() int64 {
+	return int64(len(o.Value))
+}
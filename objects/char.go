@@ -117,3 +117,8 @@ func (o *Char) Equals(x Object) bool {
 
 	return o.Value == t.Value
 }
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *Char) ByteSize() int64 {
+	return 4
+}
@@ -0,0 +1,10 @@
+package objects
+
+// Comparable represents an object that can be ordered against another object.
+type Comparable interface {
+	// Compare should return a negative number if the value of the type is less
+	// than the value of another object, zero if they are equal, and a positive
+	// number if it is greater. If the two values cannot be ordered, Compare
+	// should return ErrInvalidOperator, which the VM will treat as a run-time error.
+	Compare(another Object) (int, error)
+}
@@ -11,6 +11,26 @@ type CompiledFunction struct {
 	NumLocals     int // number of local variables (including function parameters)
 	NumParameters int
 	SourceMap     map[int]source.Pos
+	Variables     []VariableInfo // debug information of the variables
+	Pos           source.Pos     // position of the function literal (debug information)
+}
+
+// VariableInfo is the debug information of a variable defined in the
+// compiled function. The variables of the main function are the global
+// variables, and, the variables of the other functions are the local
+// variables. The local variables of the different blocks can share the same
+// index, so the variable is valid only between Pos and End.
+type VariableInfo struct {
+	Name   string
+	Index  int
+	Global bool
+	Pos    source.Pos // position where the variable is defined
+	End    source.Pos // end of the block (NoPos if it's the end of the function)
+}
+
+// InScope returns true if the variable is valid at the position.
+func (v VariableInfo) InScope(pos source.Pos) bool {
+	return pos >= v.Pos && (v.End == source.NoPos || pos < v.End)
 }
 
 // TypeName returns the name of the type.
@@ -2,6 +2,7 @@ package objects
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
 	"time"
 )
@@ -31,8 +32,10 @@ func ToInt(o Object) (v int, ok bool) {
 		v = int(o.Value)
 		ok = true
 	case *Float:
-		v = int(o.Value)
-		ok = true
+		if !isNonFinite(o.Value) {
+			v = int(o.Value)
+			ok = true
+		}
 	case *Char:
 		v = int(o.Value)
 		ok = true
@@ -60,8 +63,10 @@ func ToInt64(o Object) (v int64, ok bool) {
 		v = o.Value
 		ok = true
 	case *Float:
-		v = int64(o.Value)
-		ok = true
+		if !isNonFinite(o.Value) {
+			v = int64(o.Value)
+			ok = true
+		}
 	case *Char:
 		v = int64(o.Value)
 		ok = true
@@ -91,6 +96,9 @@ func ToFloat64(o Object) (v float64, ok bool) {
 	case *Float:
 		v = o.Value
 		ok = true
+	case *BigInt:
+		v, _ = new(big.Float).SetInt(o.Value).Float64()
+		ok = true
 	case *String:
 		c, err := strconv.ParseFloat(o.Value, 64)
 		if err == nil {
@@ -135,6 +143,9 @@ func ToByteSlice(o Object) (v []byte, ok bool) {
 	case *String:
 		v = []byte(o.Value)
 		ok = true
+	case *Buffer:
+		v = append([]byte{}, o.Value...)
+		ok = true
 	}
 
 	//ok = false
@@ -158,13 +169,24 @@ func ToTime(o Object) (v time.Time, ok bool) {
 
 // objectToInterface attempts to convert an object o to an interface{} value
 func objectToInterface(o Object) (res interface{}) {
+	return toInterface(o, make(map[Object]bool))
+}
+
+// toInterface converts the object like objectToInterface. An array or a map
+// that contains itself is converted to "[...]" or "{...}" where it appears
+// again.
+func toInterface(o Object, visiting map[Object]bool) (res interface{}) {
 	switch o := o.(type) {
+	case Formatter:
+		res = o
 	case *Int:
 		res = o.Value
 	case *String:
 		res = o.Value
 	case *Float:
 		res = o.Value
+	case *BigInt:
+		res = o.Value
 	case *Bool:
 		res = o == TrueValue
 	case *Char:
@@ -172,14 +194,26 @@ func objectToInterface(o Object) (res interface{}) {
 	case *Bytes:
 		res = o.Value
 	case *Array:
+		if visiting[o] {
+			return "[...]"
+		}
+		visiting[o] = true
+		defer delete(visiting, o)
+
 		res = make([]interface{}, len(o.Value))
 		for i, val := range o.Value {
-			res.([]interface{})[i] = objectToInterface(val)
+			res.([]interface{})[i] = toInterface(val, visiting)
 		}
 	case *Map:
+		if visiting[o] {
+			return "{...}"
+		}
+		visiting[o] = true
+		defer delete(visiting, o)
+
 		res = make(map[string]interface{})
 		for key, v := range o.Value {
-			res.(map[string]interface{})[key] = objectToInterface(v)
+			res.(map[string]interface{})[key] = toInterface(v, visiting)
 		}
 	case Object:
 		return o
@@ -194,11 +228,11 @@ func FromInterface(v interface{}) (Object, error) {
 	case nil:
 		return UndefinedValue, nil
 	case string:
-		return &String{Value: v}, nil
+		return NewString(v), nil
 	case int64:
-		return &Int{Value: v}, nil
+		return NewInt(v), nil
 	case int:
-		return &Int{Value: int64(v)}, nil
+		return NewInt(int64(v)), nil
 	case bool:
 		if v {
 			return TrueValue, nil
@@ -210,6 +244,8 @@ func FromInterface(v interface{}) (Object, error) {
 		return &Char{Value: rune(v)}, nil
 	case float64:
 		return &Float{Value: v}, nil
+	case *big.Int:
+		return NewBigInt(v), nil
 	case []byte:
 		return &Bytes{Value: v}, nil
 	case error:
@@ -241,6 +277,12 @@ func FromInterface(v interface{}) (Object, error) {
 		return &Array{Value: arr}, nil
 	case time.Time:
 		return &Time{Value: v}, nil
+	case chan Object:
+		return NewChanIterable(v), nil
+	case <-chan Object:
+		return NewChanIterable(v), nil
+	case PullFunc:
+		return NewPullIterable(v), nil
 	case Object:
 		return v, nil
 	}
@@ -0,0 +1,157 @@
+package objects
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The arrays and maps can contain themselves (e.g. a[0] = a). The functions
+// below keep track of the arrays and maps on the current path, so that such
+// values do not cause infinite recursion.
+
+// stringOf returns the string representation of the object. An array or a
+// map that contains itself is rendered as "[...]" or "{...}" where it
+// appears again.
+func stringOf(o Object, visiting map[Object]bool) string {
+	switch o := o.(type) {
+	case *Array:
+		return arrayString(o, o.Value, visiting)
+	case *ImmutableArray:
+		return arrayString(o, o.Value, visiting)
+	case *Map:
+		return mapString(o, o.Value, visiting)
+	case *ImmutableMap:
+		return mapString(o, o.Value, visiting)
+	case *Error:
+		if o.Value != nil {
+			return fmt.Sprintf("error: %s", stringOf(o.Value, visiting))
+		}
+	}
+
+	return o.String()
+}
+
+func arrayString(o Object, value []Object, visiting map[Object]bool) string {
+	if visiting[o] {
+		return "[...]"
+	}
+	visiting[o] = true
+	defer delete(visiting, o)
+
+	var elements []string
+	for _, e := range value {
+		elements = append(elements, stringOf(e, visiting))
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
+
+func mapString(o Object, value map[string]Object, visiting map[Object]bool) string {
+	if visiting[o] {
+		return "{...}"
+	}
+	visiting[o] = true
+	defer delete(visiting, o)
+
+	var pairs []string
+	for k, v := range value {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", k, stringOf(v, visiting)))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
+}
+
+// copyOf returns a copy of the object like its Copy method. An array or a
+// map that contains itself is copied into a value that contains the copy.
+// Unlike DeepCopy, the values shared by the different elements are copied
+// separately.
+func copyOf(o Object, copying map[Object]Object) Object {
+	switch o := o.(type) {
+	case *Array:
+		return copyArray(o, o.Value, copying)
+	case *ImmutableArray:
+		return copyArray(o, o.Value, copying)
+	case *Map:
+		return copyMap(o, o.Value, copying)
+	case *ImmutableMap:
+		return copyMap(o, o.Value, copying)
+	case *Error:
+		if o.Value == nil {
+			return &Error{Stack: o.Stack}
+		}
+
+		return &Error{Value: copyOf(o.Value, copying), Stack: o.Stack}
+	}
+
+	return o.Copy()
+}
+
+func copyArray(o Object, value []Object, copying map[Object]Object) Object {
+	if c, ok := copying[o]; ok {
+		return c
+	}
+
+	c := &Array{Value: make([]Object, len(value))}
+	copying[o] = c
+	defer delete(copying, o)
+
+	for i, elem := range value {
+		c.Value[i] = copyOf(elem, copying)
+	}
+
+	return c
+}
+
+func copyMap(o Object, value map[string]Object, copying map[Object]Object) Object {
+	if c, ok := copying[o]; ok {
+		return c
+	}
+
+	c := &Map{Value: make(map[string]Object, len(value))}
+	copying[o] = c
+	defer delete(copying, o)
+
+	for k, v := range value {
+		c.Value[k] = copyOf(v, copying)
+	}
+
+	return c
+}
+
+// isCyclic returns true if the object is an array, a map or an error that
+// contains itself.
+func isCyclic(o Object, visiting map[Object]bool) bool {
+	var elems []Object
+	switch o := o.(type) {
+	case *Array:
+		elems = o.Value
+	case *ImmutableArray:
+		elems = o.Value
+	case *Map:
+		for _, v := range o.Value {
+			elems = append(elems, v)
+		}
+	case *ImmutableMap:
+		for _, v := range o.Value {
+			elems = append(elems, v)
+		}
+	case *Error:
+		elems = []Object{o.Value}
+	default:
+		return false
+	}
+
+	if visiting[o] {
+		return true
+	}
+	visiting[o] = true
+	defer delete(visiting, o)
+
+	for _, e := range elems {
+		if isCyclic(e, visiting) {
+			return true
+		}
+	}
+
+	return false
+}
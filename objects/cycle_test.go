@@ -0,0 +1,49 @@
+package objects_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestCyclicString(t *testing.T) {
+	arr := &objects.Array{Value: []objects.Object{&objects.Int{Value: 1}}}
+	arr.Value = append(arr.Value, arr)
+	assert.Equal(t, "[1, [...]]", arr.String())
+
+	m := &objects.Map{Value: map[string]objects.Object{}}
+	m.Value["self"] = m
+	assert.Equal(t, "{self: {...}}", m.String())
+
+	// the shared values that are not cyclic are rendered each time
+	inner := &objects.Array{Value: []objects.Object{&objects.Int{Value: 2}}}
+	assert.Equal(t, "[[2], [2]]", (&objects.ImmutableArray{Value: []objects.Object{inner, inner}}).String())
+
+	e := &objects.Error{}
+	e.Value = &objects.Array{Value: []objects.Object{e}}
+	assert.Equal(t, "error: [error: [...]]", e.String())
+}
+
+func TestCyclicCopy(t *testing.T) {
+	arr := &objects.Array{Value: []objects.Object{&objects.Int{Value: 1}}}
+	arr.Value = append(arr.Value, arr)
+
+	c := arr.Copy().(*objects.Array)
+	assert.True(t, c != arr)
+	assert.True(t, c.Value[1] == c)
+
+	m := &objects.Map{Value: map[string]objects.Object{"arr": arr}}
+	m.Value["self"] = m
+	cm := m.Copy().(*objects.Map)
+	assert.True(t, cm.Value["self"] == cm)
+	assert.True(t, cm.Value["arr"] != arr)
+	ca := cm.Value["arr"].(*objects.Array)
+	assert.True(t, ca.Value[1] == ca)
+
+	// the shared values that are not cyclic are copied separately
+	inner := &objects.Array{Value: []objects.Object{&objects.Int{Value: 2}}}
+	ci := (&objects.ImmutableMap{Value: map[string]objects.Object{"a": inner, "b": inner}}).Copy().(*objects.Map)
+	assert.True(t, ci.Value["a"] != ci.Value["b"])
+	assert.Equal(t, inner, ci.Value["a"])
+}
@@ -0,0 +1,71 @@
+package objects
+
+// DeepCopy returns a deep copy of the object. Unlike Copy method, DeepCopy
+// keeps track of the arrays and maps it has already copied, so that
+// self-referencing values can be copied and the values shared within the
+// original object are also shared within the copy. Like Copy method,
+// immutable arrays and maps are copied into mutable ones.
+func DeepCopy(o Object) Object {
+	return deepCopy(o, make(map[Object]Object))
+}
+
+func deepCopy(o Object, copied map[Object]Object) Object {
+	switch o := o.(type) {
+	case *Array:
+		if c, ok := copied[o]; ok {
+			return c
+		}
+
+		c := &Array{Value: make([]Object, len(o.Value))}
+		copied[o] = c
+		for i, elem := range o.Value {
+			c.Value[i] = deepCopy(elem, copied)
+		}
+
+		return c
+	case *ImmutableArray:
+		if c, ok := copied[o]; ok {
+			return c
+		}
+
+		c := &Array{Value: make([]Object, len(o.Value))}
+		copied[o] = c
+		for i, elem := range o.Value {
+			c.Value[i] = deepCopy(elem, copied)
+		}
+
+		return c
+	case *Map:
+		if c, ok := copied[o]; ok {
+			return c
+		}
+
+		c := &Map{Value: make(map[string]Object, len(o.Value))}
+		copied[o] = c
+		for k, v := range o.Value {
+			c.Value[k] = deepCopy(v, copied)
+		}
+
+		return c
+	case *ImmutableMap:
+		if c, ok := copied[o]; ok {
+			return c
+		}
+
+		c := &Map{Value: make(map[string]Object, len(o.Value))}
+		copied[o] = c
+		for k, v := range o.Value {
+			c.Value[k] = deepCopy(v, copied)
+		}
+
+		return c
+	case *Error:
+		if o.Value == nil {
+			return &Error{Stack: o.Stack}
+		}
+
+		return &Error{Value: deepCopy(o.Value, copied), Stack: o.Stack}
+	}
+
+	return o.Copy()
+}
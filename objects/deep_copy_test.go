@@ -0,0 +1,25 @@
+package objects_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestDeepCopy(t *testing.T) {
+	arr := &objects.Array{Value: []objects.Object{&objects.Int{Value: 1}}}
+	m := &objects.Map{Value: map[string]objects.Object{"arr": arr}}
+	m.Value["self"] = m
+	m.Value["arr2"] = arr
+
+	c := objects.DeepCopy(m).(*objects.Map)
+	assert.True(t, c != m)
+	assert.True(t, c.Value["self"] == c)
+	assert.True(t, c.Value["arr"] != arr)
+	assert.True(t, c.Value["arr"] == c.Value["arr2"])
+	assert.Equal(t, arr, c.Value["arr"])
+
+	im := &objects.ImmutableArray{Value: []objects.Object{&objects.String{Value: "foo"}}}
+	assert.Equal(t, &objects.Array{Value: []objects.Object{&objects.String{Value: "foo"}}}, objects.DeepCopy(im))
+}
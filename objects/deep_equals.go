@@ -0,0 +1,112 @@
+package objects
+
+// DeepEquals returns true if the two objects are deeply equal. Arrays and
+// maps (mutable or immutable) are compared element by element, and, all
+// other values are compared using their Equals method. DeepEquals keeps
+// track of the pairs of arrays and maps it is comparing, so that
+// self-referencing values do not cause infinite recursion.
+func DeepEquals(a, b Object) bool {
+	return deepEquals(a, b, nil)
+}
+
+func deepEquals(a, b Object, visiting map[[2]Object]bool) bool {
+	switch a := a.(type) {
+	case *Array:
+		return deepEqualsArray(a, a.Value, b, visiting)
+	case *ImmutableArray:
+		return deepEqualsArray(a, a.Value, b, visiting)
+	case *Map:
+		return deepEqualsMap(a, a.Value, b, visiting)
+	case *ImmutableMap:
+		return deepEqualsMap(a, a.Value, b, visiting)
+	}
+
+	return a.Equals(b)
+}
+
+func deepEqualsArray(a Object, aVal []Object, b Object, visiting map[[2]Object]bool) bool {
+	var bVal []Object
+	switch b := b.(type) {
+	case *Array:
+		bVal = b.Value
+	case *ImmutableArray:
+		bVal = b.Value
+	default:
+		return false
+	}
+
+	if len(aVal) != len(bVal) {
+		return false
+	}
+
+	// a pair that is already being compared is assumed to be equal:
+	// any difference will be reported by the outer comparison.
+	pair := [2]Object{a, b}
+	if visiting[pair] {
+		return true
+	}
+	if visiting != nil {
+		visiting[pair] = true
+	}
+
+	for i, e := range aVal {
+		if visiting == nil && isContainer(e) {
+			// the pairs are tracked from the first nested array or map,
+			// so comparing the flat values does not allocate.
+			visiting = map[[2]Object]bool{pair: true}
+		}
+		if !deepEquals(e, bVal[i], visiting) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func deepEqualsMap(a Object, aVal map[string]Object, b Object, visiting map[[2]Object]bool) bool {
+	var bVal map[string]Object
+	switch b := b.(type) {
+	case *Map:
+		bVal = b.Value
+	case *ImmutableMap:
+		bVal = b.Value
+	default:
+		return false
+	}
+
+	if len(aVal) != len(bVal) {
+		return false
+	}
+
+	pair := [2]Object{a, b}
+	if visiting[pair] {
+		return true
+	}
+	if visiting != nil {
+		visiting[pair] = true
+	}
+
+	for k, v := range aVal {
+		tv, ok := bVal[k]
+		if !ok {
+			return false
+		}
+		if visiting == nil && isContainer(v) {
+			visiting = map[[2]Object]bool{pair: true}
+		}
+		if !deepEquals(v, tv, visiting) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isContainer(o Object) bool {
+	switch o.(type) {
+	case *Array, *ImmutableArray, *Map, *ImmutableMap:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package objects_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestDeepEquals(t *testing.T) {
+	a := &objects.Map{Value: map[string]objects.Object{"x": &objects.Int{Value: 1}}}
+	a.Value["self"] = a
+	b := &objects.Map{Value: map[string]objects.Object{"x": &objects.Int{Value: 1}}}
+	b.Value["self"] = b
+	assert.True(t, objects.DeepEquals(a, b))
+
+	b.Value["x"] = &objects.Int{Value: 2}
+	assert.False(t, objects.DeepEquals(a, b))
+
+	arr := &objects.Array{Value: []objects.Object{&objects.String{Value: "foo"}}}
+	arr.Value = append(arr.Value, arr)
+	imm := &objects.ImmutableArray{Value: []objects.Object{&objects.String{Value: "foo"}, arr}}
+	assert.True(t, objects.DeepEquals(arr, imm))
+	assert.True(t, objects.DeepEquals(imm, arr))
+
+	assert.False(t, objects.DeepEquals(
+		&objects.Map{Value: map[string]objects.Object{"a": objects.UndefinedValue}},
+		&objects.Map{Value: map[string]objects.Object{"b": objects.UndefinedValue}}))
+}
+
+func TestDeepEquals_Allocs(t *testing.T) {
+	// the flat arrays and maps are compared without allocations
+	arr := &objects.Array{Value: []objects.Object{&objects.Int{Value: 1}, &objects.String{Value: "a"}}}
+	arr2 := &objects.Array{Value: []objects.Object{&objects.Int{Value: 1}, &objects.String{Value: "a"}}}
+	m := &objects.Map{Value: map[string]objects.Object{"a": &objects.Int{Value: 1}}}
+	m2 := &objects.ImmutableMap{Value: map[string]objects.Object{"a": &objects.Int{Value: 1}}}
+	allocs := testing.AllocsPerRun(100, func() {
+		if !objects.DeepEquals(arr, arr2) || !objects.DeepEquals(m, m2) {
+			t.Fatal("not equal")
+		}
+	})
+	assert.Equal(t, 0.0, allocs)
+}
@@ -1,14 +1,17 @@
 package objects
 
 import (
-	"fmt"
-
+	"github.com/d5/tengo/compiler/source"
 	"github.com/d5/tengo/compiler/token"
 )
 
 // Error represents a string value.
 type Error struct {
 	Value Object
+
+	// Stack is the source positions of the error expression that created the
+	// error and the function calls that led to it, innermost first.
+	Stack []source.FilePos
 }
 
 // TypeName returns the name of the type.
@@ -18,7 +21,7 @@ func (o *Error) TypeName() string {
 
 func (o *Error) String() string {
 	if o.Value != nil {
-		return fmt.Sprintf("error: %s", o.Value.String())
+		return stringOf(o, make(map[Object]bool))
 	}
 
 	return "error"
@@ -37,7 +40,7 @@ func (o *Error) IsFalsy() bool {
 
 // Copy returns a copy of the type.
 func (o *Error) Copy() Object {
-	return &Error{Value: o.Value.Copy()}
+	return copyOf(o, make(map[Object]Object))
 }
 
 // Equals returns true if the value of the type
@@ -45,3 +48,9 @@ func (o *Error) Copy() Object {
 func (o *Error) Equals(x Object) bool {
 	return o == x // pointer equality
 }
+
+// ByteSize returns the approximate size of the value in bytes
+// including the values it references.
+func (o *Error) ByteSize() int64 {
+	return SizeOf(o)
+}
@@ -17,6 +17,21 @@ var ErrInvalidIndexValueType = errors.New("invalid index value type")
 // ErrInvalidOperator represents an error for invalid operator usage.
 var ErrInvalidOperator = errors.New("invalid operator")
 
+// ErrDivisionByZero is the message of the error value that the division and
+// the modulo by zero evaluate to.
+var ErrDivisionByZero = errors.New("division by zero")
+
+// ErrStringLimit represents an error where a string value would exceed
+// MaxStringLen.
+var ErrStringLimit = errors.New("exceeding string size limit")
+
+// ErrBytesLimit represents an error where a bytes value would exceed
+// MaxBytesLen.
+var ErrBytesLimit = errors.New("exceeding bytes size limit")
+
+// ErrArrayLimit represents an error where an array would exceed MaxArrayLen.
+var ErrArrayLimit = errors.New("exceeding array size limit")
+
 // ErrWrongNumArguments represents a wrong number of arguments error.
 var ErrWrongNumArguments = errors.New("wrong number of arguments")
 
@@ -30,3 +45,10 @@ type ErrInvalidArgumentType struct {
 func (e ErrInvalidArgumentType) Error() string {
 	return fmt.Sprintf("invalid type for argument '%s': expected %s, found %s", e.Name, e.Expected, e.Found)
 }
+
+// divisionByZero returns the error value of the division by zero. It's
+// returned as the result of the operator instead of a Go error, so the
+// scripts can check it using is_error.
+func divisionByZero() Object {
+	return &Error{Value: &String{Value: ErrDivisionByZero.Error()}}
+}
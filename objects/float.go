@@ -2,6 +2,7 @@ package objects
 
 import (
 	"math"
+	"math/big"
 	"strconv"
 
 	"github.com/d5/tengo/compiler/token"
@@ -46,6 +47,9 @@ func (o *Float) BinaryOp(op token.Token, rhs Object) (Object, error) {
 			}
 			return &Float{Value: r}, nil
 		case token.Quo:
+			if rhs.Value == 0 {
+				return divisionByZero(), nil
+			}
 			r := o.Value / rhs.Value
 			if r == o.Value {
 				return o, nil
@@ -93,6 +97,9 @@ func (o *Float) BinaryOp(op token.Token, rhs Object) (Object, error) {
 			}
 			return &Float{Value: r}, nil
 		case token.Quo:
+			if rhs.Value == 0 {
+				return divisionByZero(), nil
+			}
 			r := o.Value / float64(rhs.Value)
 			if r == o.Value {
 				return o, nil
@@ -119,6 +126,9 @@ func (o *Float) BinaryOp(op token.Token, rhs Object) (Object, error) {
 			}
 			return FalseValue, nil
 		}
+	case *BigInt:
+		f, _ := new(big.Float).SetInt(rhs.Value).Float64()
+		return o.BinaryOp(op, &Float{Value: f})
 	}
 
 	return nil, ErrInvalidOperator
@@ -144,3 +154,8 @@ func (o *Float) Equals(x Object) bool {
 
 	return o.Value == t.Value
 }
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *Float) ByteSize() int64 {
+	return 8
+}
@@ -37,6 +37,7 @@ func TestFloat_BinaryOp(t *testing.T) {
 			}
 		}
 	}
+	testBinaryOp(t, &objects.Float{Value: 1}, token.Quo, &objects.Float{Value: 0}, divisionByZero)
 
 	// float < float
 	for l := float64(-2); l <= 2.1; l += 0.4 {
@@ -95,6 +96,7 @@ func TestFloat_BinaryOp(t *testing.T) {
 			}
 		}
 	}
+	testBinaryOp(t, &objects.Float{Value: 1}, token.Quo, &objects.Int{Value: 0}, divisionByZero)
 
 	// float < int
 	for l := float64(-2); l <= 2.1; l += 0.4 {
@@ -0,0 +1,14 @@
+package objects
+
+import (
+	"fmt"
+)
+
+// Formatter represents an object that controls how its value is rendered by
+// printf and sprintf builtin functions. Format method is called with the
+// formatting state (width, precision, flags) and the verb (e.g. 'v', 's',
+// 'q'), and, it should write the formatted value to the state. Objects that
+// do not implement Formatter interface are rendered using String method.
+type Formatter interface {
+	Format(f fmt.State, verb rune)
+}
@@ -0,0 +1,28 @@
+package objects
+
+// Freeze prepares the object to be shared by the VMs running on multiple
+// goroutines, and, returns the same object. The values that are computed
+// lazily when the object is read (e.g. the runes of a string) are computed
+// in advance, so reading the object never modifies it. The elements of the
+// immutable arrays and maps are prepared recursively. Freeze does not make
+// the mutable arrays, maps and bytes read-only: they must not be shared.
+func Freeze(o Object) Object {
+	switch o := o.(type) {
+	case *String:
+		if o.runeStr == nil {
+			o.runeStr = []rune(o.Value)
+		}
+	case *ImmutableArray:
+		for _, elem := range o.Value {
+			Freeze(elem)
+		}
+	case *ImmutableMap:
+		for _, elem := range o.Value {
+			Freeze(elem)
+		}
+	case *Error:
+		Freeze(o.Value)
+	}
+
+	return o
+}
@@ -0,0 +1,35 @@
+package objects_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestFreeze(t *testing.T) {
+	s := &objects.String{Value: "héllo"}
+	arr := &objects.ImmutableArray{Value: []objects.Object{s}}
+	m := &objects.ImmutableMap{Value: map[string]objects.Object{"a": arr}}
+	assert.Equal(t, m, objects.Freeze(m))
+
+	// read by multiple goroutines (run with -race)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := s.IndexGet(&objects.Int{Value: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, &objects.Char{Value: 'é'}, c)
+			assert.True(t, s.Iterate().Next())
+		}()
+	}
+	wg.Wait()
+
+	// other values are returned as they are
+	i := &objects.Int{Value: 1}
+	assert.Equal(t, i, objects.Freeze(i))
+	assert.Equal(t, objects.UndefinedValue, objects.Freeze(objects.UndefinedValue))
+}
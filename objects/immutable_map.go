@@ -1,9 +1,6 @@
 package objects
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/d5/tengo/compiler/token"
 )
 
@@ -18,12 +15,7 @@ func (o *ImmutableMap) TypeName() string {
 }
 
 func (o *ImmutableMap) String() string {
-	var pairs []string
-	for k, v := range o.Value {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", k, v.String()))
-	}
-
-	return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
+	return stringOf(o, make(map[Object]bool))
 }
 
 // BinaryOp returns another object that is the result of
@@ -34,12 +26,7 @@ func (o *ImmutableMap) BinaryOp(op token.Token, rhs Object) (Object, error) {
 
 // Copy returns a copy of the type.
 func (o *ImmutableMap) Copy() Object {
-	c := make(map[string]Object)
-	for k, v := range o.Value {
-		c[k] = v.Copy()
-	}
-
-	return &Map{Value: c}
+	return copyOf(o, make(map[Object]Object))
 }
 
 // IsFalsy returns true if the value of the type is falsy.
@@ -66,28 +53,7 @@ func (o *ImmutableMap) IndexGet(index Object) (res Object, err error) {
 // Equals returns true if the value of the type
 // is equal to the value of another object.
 func (o *ImmutableMap) Equals(x Object) bool {
-	var xVal map[string]Object
-	switch x := x.(type) {
-	case *Map:
-		xVal = x.Value
-	case *ImmutableMap:
-		xVal = x.Value
-	default:
-		return false
-	}
-
-	if len(o.Value) != len(xVal) {
-		return false
-	}
-
-	for k, v := range o.Value {
-		tv := xVal[k]
-		if !v.Equals(tv) {
-			return false
-		}
-	}
-
-	return true
+	return DeepEquals(o, x)
 }
 
 // Iterate creates an immutable map iterator.
@@ -103,3 +69,9 @@ func (o *ImmutableMap) Iterate() Iterator {
 		l: len(keys),
 	}
 }
+
+// ByteSize returns the approximate size of the value in bytes
+// including the values it references.
+func (o *ImmutableMap) ByteSize() int64 {
+	return SizeOf(o)
+}
@@ -1,6 +1,8 @@
 package objects
 
 import (
+	"math"
+	"math/big"
 	"strconv"
 
 	"github.com/d5/tengo/compiler/token"
@@ -31,67 +33,73 @@ func (o *Int) BinaryOp(op token.Token, rhs Object) (Object, error) {
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.Sub:
 			r := o.Value - rhs.Value
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.Mul:
 			r := o.Value * rhs.Value
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.Quo:
+			if rhs.Value == 0 {
+				return divisionByZero(), nil
+			}
 			r := o.Value / rhs.Value
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.Rem:
+			if rhs.Value == 0 {
+				return divisionByZero(), nil
+			}
 			r := o.Value % rhs.Value
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.And:
 			r := o.Value & rhs.Value
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.Or:
 			r := o.Value | rhs.Value
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.Xor:
 			r := o.Value ^ rhs.Value
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.AndNot:
 			r := o.Value &^ rhs.Value
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.Shl:
 			r := o.Value << uint64(rhs.Value)
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.Shr:
 			r := o.Value >> uint64(rhs.Value)
 			if r == o.Value {
 				return o, nil
 			}
-			return &Int{Value: r}, nil
+			return NewInt(r), nil
 		case token.Less:
 			if o.Value < rhs.Value {
 				return TrueValue, nil
@@ -122,6 +130,9 @@ func (o *Int) BinaryOp(op token.Token, rhs Object) (Object, error) {
 		case token.Mul:
 			return &Float{float64(o.Value) * rhs.Value}, nil
 		case token.Quo:
+			if rhs.Value == 0 {
+				return divisionByZero(), nil
+			}
 			return &Float{float64(o.Value) / rhs.Value}, nil
 		case token.Less:
 			if float64(o.Value) < rhs.Value {
@@ -171,6 +182,8 @@ func (o *Int) BinaryOp(op token.Token, rhs Object) (Object, error) {
 			}
 			return FalseValue, nil
 		}
+	case *BigInt:
+		return (&BigInt{Value: big.NewInt(o.Value)}).BinaryOp(op, rhs)
 	}
 
 	return nil, ErrInvalidOperator
@@ -196,3 +209,29 @@ func (o *Int) Equals(x Object) bool {
 
 	return o.Value == t.Value
 }
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *Int) ByteSize() int64 {
+	return 8
+}
+
+// CheckedIntOp returns the result of the int operator (+, -, * or /) that
+// wraps around on overflow, and, false if the operation overflows. b must
+// not be 0 for the division.
+func CheckedIntOp(op token.Token, a, b int64) (r int64, ok bool) {
+	switch op {
+	case token.Add:
+		r = a + b
+		return r, (a^r)&(b^r) >= 0
+	case token.Sub:
+		r = a - b
+		return r, (a^b)&(a^r) >= 0
+	case token.Mul:
+		r = a * b
+		return r, a == 0 || (r/a == b && !(a == -1 && b == math.MinInt64))
+	case token.Quo:
+		return a / b, !(a == math.MinInt64 && b == -1)
+	}
+
+	return 0, false
+}
@@ -1,8 +1,10 @@
 package objects_test
 
 import (
+	"math"
 	"testing"
 
+	"github.com/d5/tengo/assert"
 	"github.com/d5/tengo/compiler/token"
 	"github.com/d5/tengo/objects"
 )
@@ -37,6 +39,8 @@ func TestInt_BinaryOp(t *testing.T) {
 			}
 		}
 	}
+	testBinaryOp(t, &objects.Int{Value: 1}, token.Quo, &objects.Int{Value: 0}, divisionByZero)
+	testBinaryOp(t, &objects.Int{Value: 1}, token.Rem, &objects.Int{Value: 0}, divisionByZero)
 
 	// int % int
 	for l := int64(-4); l <= 4; l++ {
@@ -168,6 +172,7 @@ func TestInt_BinaryOp(t *testing.T) {
 			}
 		}
 	}
+	testBinaryOp(t, &objects.Int{Value: 1}, token.Quo, &objects.Float{Value: 0}, divisionByZero)
 
 	// int < float
 	for l := int64(-2); l <= 2; l++ {
@@ -197,3 +202,50 @@ func TestInt_BinaryOp(t *testing.T) {
 		}
 	}
 }
+
+func TestNewInt(t *testing.T) {
+	for _, v := range []int64{-129, -128, -1, 0, 1, 255, 256, 1 << 40} {
+		assert.Equal(t, v, objects.NewInt(v).Value)
+	}
+
+	// small integers are cached
+	assert.True(t, objects.NewInt(-128) == objects.NewInt(-128))
+	assert.True(t, objects.NewInt(255) == objects.NewInt(255))
+	assert.False(t, objects.NewInt(256) == objects.NewInt(256))
+
+	// the arithmetic results in the range don't allocate
+	one, two := objects.NewInt(1), objects.NewInt(2)
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = one.BinaryOp(token.Add, two)
+	})
+	assert.Equal(t, float64(0), allocs)
+
+	assert.Equal(t, objects.EmptyString, objects.NewString(""))
+	assert.Equal(t, "a", objects.NewString("a").(*objects.String).Value)
+}
+
+func TestCheckedIntOp(t *testing.T) {
+	const max, min = math.MaxInt64, math.MinInt64
+
+	expect := func(op token.Token, a, b, expected int64, expectedOK bool) {
+		r, ok := objects.CheckedIntOp(op, a, b)
+		assert.Equal(t, expected, r)
+		assert.Equal(t, expectedOK, ok)
+	}
+
+	expect(token.Add, 1, 2, 3, true)
+	expect(token.Add, max, 1, min, false)
+	expect(token.Add, min, -1, max, false)
+	expect(token.Add, max, min, -1, true)
+	expect(token.Sub, 1, 2, -1, true)
+	expect(token.Sub, min, 1, max, false)
+	expect(token.Sub, 0, min, min, false)
+	expect(token.Sub, -1, min, max, true)
+	expect(token.Mul, 6, 7, 42, true)
+	expect(token.Mul, max, 2, -2, false)
+	expect(token.Mul, -1, min, min, false)
+	expect(token.Mul, min, -1, min, false)
+	expect(token.Mul, 0, min, 0, true)
+	expect(token.Quo, 7, 2, 3, true)
+	expect(token.Quo, min, -1, min, false)
+}
@@ -0,0 +1,46 @@
+package objects
+
+import "github.com/d5/tengo/compiler/source"
+
+// Interop represents the runtime that is executing the script. It allows Go
+// functions called by the runtime to call back script functions.
+type Interop interface {
+	// Call calls the callable object fn (a compiled function, a closure, or
+	// any other callable object) with the given arguments and returns its
+	// result. A run-time error in fn is returned as an error, and, it also
+	// stops the execution of the script.
+	Call(fn Object, args ...Object) (ret Object, err error)
+}
+
+// InteropCallable represents an object that can be called like a function,
+// and, that needs to call back script functions using the runtime.
+// If an object implements both InteropCallable and Callable, the VM uses
+// InteropCall method.
+type InteropCallable interface {
+	// InteropCall should take the runtime and an arbitrary number of
+	// arguments, and, returns a return value and/or an error, which the VM
+	// will consider as a run-time error.
+	InteropCall(rt Interop, args ...Object) (ret Object, err error)
+}
+
+// InteropFunc is a function signature for the callable functions that
+// receive the runtime.
+type InteropFunc func(rt Interop, args ...Object) (ret Object, err error)
+
+// InteropPositioner represents the runtime that can convert the source
+// positions (e.g. the position of a compiled function) to the file positions
+// of the source code it's executing.
+type InteropPositioner interface {
+	// FilePos should return the file position of the source position, or,
+	// an invalid position if it's unknown.
+	FilePos(pos source.Pos) source.FilePos
+}
+
+// InteropAborter represents the runtime that can be aborted (e.g. by the
+// timeout of the host application), so the Go functions that wait can return
+// early.
+type InteropAborter interface {
+	// Aborted should return a channel that's closed when the execution is
+	// aborted.
+	Aborted() <-chan struct{}
+}
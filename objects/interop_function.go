@@ -0,0 +1,48 @@
+package objects
+
+import (
+	"github.com/d5/tengo/compiler/token"
+)
+
+// InteropFunction represents a builtin function that can call back script
+// functions using the runtime.
+type InteropFunction struct {
+	Name  string
+	Value InteropFunc
+}
+
+// TypeName returns the name of the type.
+func (o *InteropFunction) TypeName() string {
+	return "builtin-function:" + o.Name
+}
+
+func (o *InteropFunction) String() string {
+	return "<builtin-function>"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (o *InteropFunction) BinaryOp(op token.Token, rhs Object) (Object, error) {
+	return nil, ErrInvalidOperator
+}
+
+// Copy returns a copy of the type.
+func (o *InteropFunction) Copy() Object {
+	return &InteropFunction{Name: o.Name, Value: o.Value}
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (o *InteropFunction) IsFalsy() bool {
+	return false
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (o *InteropFunction) Equals(x Object) bool {
+	return false
+}
+
+// InteropCall executes a builtin function.
+func (o *InteropFunction) InteropCall(rt Interop, args ...Object) (Object, error) {
+	return o.Value(rt, args...)
+}
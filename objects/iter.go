@@ -0,0 +1,156 @@
+package objects
+
+import "github.com/d5/tengo/compiler/token"
+
+// Iter represents an iterator as a value of the scripts: next() advances
+// it, and, key() and value() return the current element. It's iterable, so
+// a for-in statement continues from its current position.
+type Iter struct {
+	it    Iterator
+	valid bool // there is the current element
+}
+
+// NewIter creates an iter of the iterator.
+func NewIter(it Iterator) *Iter {
+	if iter, ok := it.(*Iter); ok {
+		return iter
+	}
+
+	return &Iter{it: it}
+}
+
+// TypeName returns the name of the type.
+func (o *Iter) TypeName() string {
+	return "iterator"
+}
+
+func (o *Iter) String() string {
+	return "<iterator>"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (o *Iter) BinaryOp(op token.Token, rhs Object) (Object, error) {
+	return nil, ErrInvalidOperator
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (o *Iter) IsFalsy() bool {
+	return false
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (o *Iter) Equals(x Object) bool {
+	return o == x
+}
+
+// Copy returns a copy of the type. The copy of the iterator of a stream
+// shares the elements pulled from the source.
+func (o *Iter) Copy() Object {
+	it, ok := o.it.Copy().(Iterator)
+	if !ok {
+		return o
+	}
+
+	return &Iter{it: it, valid: o.valid}
+}
+
+// Iterate returns the iterator itself.
+func (o *Iter) Iterate() Iterator {
+	return o
+}
+
+// Next returns true if there are more elements to iterate.
+func (o *Iter) Next() bool {
+	if o.it == nil {
+		return false
+	}
+
+	o.valid = o.it.Next()
+	if !o.valid {
+		o.it = nil // exhausted
+	}
+
+	return o.valid
+}
+
+// Key returns the key or index value of the current element, or, undefined
+// if there is no current element.
+func (o *Iter) Key() Object {
+	if !o.valid {
+		return UndefinedValue
+	}
+
+	return o.it.Key()
+}
+
+// Value returns the value of the current element, or, undefined if there
+// is no current element.
+func (o *Iter) Value() Object {
+	if !o.valid {
+		return UndefinedValue
+	}
+
+	return o.it.Value()
+}
+
+// IndexGet returns an iterator method for the given name.
+func (o *Iter) IndexGet(index Object) (Object, error) {
+	strIdx, ok := index.(*String)
+	if !ok {
+		return nil, ErrInvalidIndexType
+	}
+
+	switch strIdx.Value {
+	case "next":
+		return &BuiltinFunction{Name: "next", Value: o.next}, nil
+	case "key":
+		return &BuiltinFunction{Name: "key", Value: o.key}, nil
+	case "value":
+		return &BuiltinFunction{Name: "value", Value: o.value}, nil
+	}
+
+	return UndefinedValue, nil
+}
+
+// FieldNames returns nil: an iterator has no fields.
+func (o *Iter) FieldNames() []string {
+	return nil
+}
+
+// MethodNames returns the names of the iterator methods.
+func (o *Iter) MethodNames() []string {
+	return []string{"next", "key", "value"}
+}
+
+// next() => bool
+func (o *Iter) next(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	if o.Next() {
+		return TrueValue, nil
+	}
+
+	return FalseValue, nil
+}
+
+// key() => any
+func (o *Iter) key(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	return o.Key(), nil
+}
+
+// value() => any
+func (o *Iter) value(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	return o.Value(), nil
+}
@@ -0,0 +1,15 @@
+package objects
+
+// JSONMarshaler represents an object that can encode itself into JSON.
+// to_json builtin uses MarshalJSON method of the object instead of
+// encoding the underlying Go value.
+type JSONMarshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+// JSONUnmarshaler represents an object that can decode a JSON
+// representation of itself. When passed as the second argument of from_json
+// builtin, a copy of the object is populated using UnmarshalJSON method.
+type JSONUnmarshaler interface {
+	UnmarshalJSON(data []byte) error
+}
@@ -0,0 +1,505 @@
+//go:build tinygo || tengo_embedded
+// +build tinygo tengo_embedded
+
+package objects
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxJSONDepth is the maximum nesting depth of the arrays and the objects
+// that decodeJSON accepts.
+const maxJSONDepth = 10000
+
+// encodeJSON returns the JSON encoding of the result of objectToInterface.
+// It does the same encoding as json.Marshal for the values that
+// objectToInterface returns without using reflection: the bytes are encoded
+// as base64 strings, the chars as numbers, and, the map keys are sorted.
+// The objects that do not implement JSONMarshaler are encoded as the
+// strings returned by their String method.
+func encodeJSON(v interface{}) ([]byte, error) {
+	return appendJSON(nil, v)
+}
+
+func appendJSON(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, "null"...), nil
+	case bool:
+		return strconv.AppendBool(b, v), nil
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+	case rune:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case float64:
+		return appendJSONFloat(b, v)
+	case string:
+		return appendJSONString(b, v), nil
+	case []byte:
+		if v == nil {
+			return append(b, "null"...), nil
+		}
+		b = append(b, '"')
+		b = append(b, base64.StdEncoding.EncodeToString(v)...)
+		return append(b, '"'), nil
+	case *big.Int:
+		if v == nil {
+			return append(b, "null"...), nil
+		}
+		return v.Append(b, 10), nil
+	case []interface{}:
+		if v == nil {
+			return append(b, "null"...), nil
+		}
+		b = append(b, '[')
+		for i, e := range v {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			var err error
+			if b, err = appendJSON(b, e); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, ']'), nil
+	case map[string]interface{}:
+		if v == nil {
+			return append(b, "null"...), nil
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b = append(b, '{')
+		for i, k := range keys {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONString(b, k)
+			b = append(b, ':')
+			var err error
+			if b, err = appendJSON(b, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, '}'), nil
+	case JSONMarshaler:
+		res, err := v.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return append(b, res...), nil
+	case Object:
+		return appendJSONString(b, v.String()), nil
+	}
+
+	return nil, fmt.Errorf("json: unsupported type: %T", v)
+}
+
+// appendJSONFloat formats f like json.Marshal: the exponent format is used
+// only for the very small and the very large values.
+func appendJSONFloat(b []byte, f float64) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, 64))
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+
+	return b, nil
+}
+
+// appendJSONString quotes s like json.Marshal including the escaping of the
+// HTML characters. The invalid UTF-8 bytes are replaced with U+FFFD.
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b = append(b, '\\', c)
+			case c == '\n':
+				b = append(b, '\\', 'n')
+			case c == '\r':
+				b = append(b, '\\', 'r')
+			case c == '\t':
+				b = append(b, '\\', 't')
+			case c < 0x20 || c == '<' || c == '>' || c == '&':
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				b = append(b, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b = append(b, "\ufffd"...)
+		case r == '\u2028' || r == '\u2029':
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xf])
+		default:
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+
+	return append(b, '"')
+}
+
+// decodeJSON decodes data into nil, bool, float64, string, []interface{} and
+// map[string]interface{} values.
+func decodeJSON(data []byte) (interface{}, error) {
+	d := &jsonDecoder{data: data}
+
+	d.skipSpace()
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+
+	d.skipSpace()
+	if d.pos < len(d.data) {
+		return nil, d.syntaxError("after top-level value")
+	}
+
+	return v, nil
+}
+
+type jsonDecoder struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func (d *jsonDecoder) value() (interface{}, error) {
+	switch c := d.peek(); {
+	case c == '{':
+		return d.object()
+	case c == '[':
+		return d.array()
+	case c == '"':
+		return d.str()
+	case c == '-' || isJSONDigit(c):
+		return d.number()
+	case c == 't':
+		return d.literal("true", true)
+	case c == 'f':
+		return d.literal("false", false)
+	case c == 'n':
+		return d.literal("null", nil)
+	}
+
+	return nil, d.syntaxError("looking for beginning of value")
+}
+
+func (d *jsonDecoder) object() (interface{}, error) {
+	if d.depth++; d.depth > maxJSONDepth {
+		return nil, errors.New("exceeded max depth")
+	}
+	defer func() { d.depth-- }()
+
+	d.pos++ // '{'
+	d.skipSpace()
+
+	res := make(map[string]interface{})
+	if d.peek() == '}' {
+		d.pos++
+		return res, nil
+	}
+
+	for {
+		if d.peek() != '"' {
+			return nil, d.syntaxError("looking for beginning of object key string")
+		}
+		key, err := d.str()
+		if err != nil {
+			return nil, err
+		}
+
+		d.skipSpace()
+		if d.peek() != ':' {
+			return nil, d.syntaxError("after object key")
+		}
+		d.pos++
+		d.skipSpace()
+
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		res[key] = v
+
+		d.skipSpace()
+		switch d.peek() {
+		case ',':
+			d.pos++
+			d.skipSpace()
+		case '}':
+			d.pos++
+			return res, nil
+		default:
+			return nil, d.syntaxError("after object key:value pair")
+		}
+	}
+}
+
+func (d *jsonDecoder) array() (interface{}, error) {
+	if d.depth++; d.depth > maxJSONDepth {
+		return nil, errors.New("exceeded max depth")
+	}
+	defer func() { d.depth-- }()
+
+	d.pos++ // '['
+	d.skipSpace()
+
+	res := []interface{}{}
+	if d.peek() == ']' {
+		d.pos++
+		return res, nil
+	}
+
+	for {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+
+		d.skipSpace()
+		switch d.peek() {
+		case ',':
+			d.pos++
+			d.skipSpace()
+		case ']':
+			d.pos++
+			return res, nil
+		default:
+			return nil, d.syntaxError("after array element")
+		}
+	}
+}
+
+func (d *jsonDecoder) str() (string, error) {
+	d.pos++ // '"'
+
+	var res []byte
+	var buf [utf8.UTFMax]byte
+	for {
+		if d.pos >= len(d.data) {
+			return "", d.syntaxError("")
+		}
+
+		c := d.data[d.pos]
+		switch {
+		case c == '"':
+			d.pos++
+			return string(res), nil
+		case c == '\\':
+			d.pos++
+			switch d.peek() {
+			case '"', '\\', '/':
+				res = append(res, d.data[d.pos])
+			case 'b':
+				res = append(res, '\b')
+			case 'f':
+				res = append(res, '\f')
+			case 'n':
+				res = append(res, '\n')
+			case 'r':
+				res = append(res, '\r')
+			case 't':
+				res = append(res, '\t')
+			case 'u':
+				d.pos++
+				r, err := d.hex4()
+				if err != nil {
+					return "", err
+				}
+				if utf16.IsSurrogate(r) {
+					r = d.surrogatePair(r)
+				}
+				n := utf8.EncodeRune(buf[:], r)
+				res = append(res, buf[:n]...)
+				continue
+			default:
+				return "", d.syntaxError("in string escape code")
+			}
+			d.pos++
+		case c < 0x20:
+			return "", d.syntaxError("in string literal")
+		case c < utf8.RuneSelf:
+			res = append(res, c)
+			d.pos++
+		default:
+			r, size := utf8.DecodeRune(d.data[d.pos:])
+			if r == utf8.RuneError && size == 1 {
+				n := utf8.EncodeRune(buf[:], unicode.ReplacementChar)
+				res = append(res, buf[:n]...)
+			} else {
+				res = append(res, d.data[d.pos:d.pos+size]...)
+			}
+			d.pos += size
+		}
+	}
+}
+
+// surrogatePair returns the rune of the surrogate pair if r1 is followed by
+// the escape of its second half. Otherwise, it returns U+FFFD, and, the
+// following escape is decoded separately.
+func (d *jsonDecoder) surrogatePair(r1 rune) rune {
+	if d.pos+6 > len(d.data) || d.data[d.pos] != '\\' || d.data[d.pos+1] != 'u' {
+		return unicode.ReplacementChar
+	}
+
+	start := d.pos
+	d.pos += 2
+	if r2, err := d.hex4(); err == nil {
+		if r := utf16.DecodeRune(r1, r2); r != unicode.ReplacementChar {
+			return r
+		}
+	}
+	d.pos = start
+
+	return unicode.ReplacementChar
+}
+
+func (d *jsonDecoder) hex4() (rune, error) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		c := d.peek()
+		switch {
+		case isJSONDigit(c):
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, d.syntaxError("in \\u hexadecimal character escape")
+		}
+		r = r*16 + rune(c)
+		d.pos++
+	}
+
+	return r, nil
+}
+
+func (d *jsonDecoder) number() (interface{}, error) {
+	start := d.pos
+
+	if d.peek() == '-' {
+		d.pos++
+	}
+
+	switch c := d.peek(); {
+	case c == '0':
+		d.pos++
+	case c >= '1' && c <= '9':
+		d.digits()
+	default:
+		return nil, d.syntaxError("in numeric literal")
+	}
+
+	if d.peek() == '.' {
+		d.pos++
+		if !isJSONDigit(d.peek()) {
+			return nil, d.syntaxError("after decimal point in numeric literal")
+		}
+		d.digits()
+	}
+
+	if c := d.peek(); c == 'e' || c == 'E' {
+		d.pos++
+		if c := d.peek(); c == '+' || c == '-' {
+			d.pos++
+		}
+		if !isJSONDigit(d.peek()) {
+			return nil, d.syntaxError("in exponent of numeric literal")
+		}
+		d.digits()
+	}
+
+	s := string(d.data[start:d.pos])
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("json: cannot unmarshal number %s into Go value of type float64", s)
+	}
+
+	return f, nil
+}
+
+func (d *jsonDecoder) literal(name string, v interface{}) (interface{}, error) {
+	for i := 0; i < len(name); i++ {
+		if d.peek() != name[i] {
+			return nil, d.syntaxError(fmt.Sprintf("in literal %s (expecting %s)", name, strconv.QuoteRune(rune(name[i]))))
+		}
+		d.pos++
+	}
+
+	return v, nil
+}
+
+func (d *jsonDecoder) digits() {
+	for isJSONDigit(d.peek()) {
+		d.pos++
+	}
+}
+
+func (d *jsonDecoder) skipSpace() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the current byte, or, 0 at the end of the data.
+func (d *jsonDecoder) peek() byte {
+	if d.pos >= len(d.data) {
+		return 0
+	}
+
+	return d.data[d.pos]
+}
+
+func (d *jsonDecoder) syntaxError(context string) error {
+	if d.pos >= len(d.data) {
+		return errors.New("unexpected end of JSON input")
+	}
+
+	return fmt.Errorf("invalid character %s %s", strconv.QuoteRune(rune(d.data[d.pos])), context)
+}
+
+func isJSONDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
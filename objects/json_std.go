@@ -0,0 +1,24 @@
+//go:build !tinygo && !tengo_embedded
+// +build !tinygo,!tengo_embedded
+
+package objects
+
+import (
+	"encoding/json"
+)
+
+// encodeJSON returns the JSON encoding of the result of objectToInterface.
+func encodeJSON(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// decodeJSON decodes data into nil, bool, float64, string, []interface{} and
+// map[string]interface{} values.
+func decodeJSON(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
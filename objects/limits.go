@@ -0,0 +1,23 @@
+package objects
+
+// MaxStringLen is the maximum length in bytes of the strings that the string
+// concatenation, the string conversion and the text module functions build.
+// The operations fail with ErrStringLimit if it's exceeded. The concatenation
+// and the repetition check the length before they allocate the result.
+var MaxStringLen = 2147483647
+
+// MaxBytesLen is the maximum length of the bytes values that the bytes
+// concatenation and the bytes builtin function build. The operations fail
+// with ErrBytesLimit if it's exceeded.
+var MaxBytesLen = 2147483647
+
+// MaxArrayLen is the maximum number of the elements of the arrays that the
+// array concatenation and the append builtin function build. The operations
+// fail with ErrArrayLimit if it's exceeded.
+var MaxArrayLen = 2147483647
+
+// exceedsLimit returns true if the sum of the lengths a and b is greater than
+// max. It doesn't compute the sum, so it can't overflow.
+func exceedsLimit(a, b, max int) bool {
+	return a > max || b > max-a
+}
@@ -0,0 +1,66 @@
+package objects
+
+import (
+	"math"
+)
+
+// NonFinitePolicy is the handling of the NaN and ±Inf float values by the
+// operations that cannot represent them.
+type NonFinitePolicy int
+
+const (
+	// NonFiniteError makes the operation fail: to_json returns an error
+	// value, and, the map assignment is a run-time error.
+	NonFiniteError NonFinitePolicy = iota
+
+	// NonFiniteNull encodes the values as null in JSON. The map assignment
+	// fails like NonFiniteError.
+	NonFiniteNull
+
+	// NonFiniteString uses "NaN", "+Inf" and "-Inf" strings as the JSON
+	// values and the map keys.
+	NonFiniteString
+)
+
+// NonFinite is the policy for the NaN and ±Inf float values in the JSON
+// encoding (to_json) and the map keys (m[f] = v). The comparisons follow
+// IEEE 754 regardless of the policy: NaN is not equal to any value including
+// itself, and, all the ordering comparisons with NaN are false. The
+// conversions of the values to int fail, and, they are rendered as "NaN",
+// "+Inf" and "-Inf" by the String method and the format builtins.
+var NonFinite = NonFiniteError
+
+// isNonFinite returns true if f is NaN or ±Inf.
+func isNonFinite(f float64) bool {
+	return math.IsNaN(f) || math.IsInf(f, 0)
+}
+
+// jsonNonFinite replaces the NaN and ±Inf values in the result of
+// objectToInterface with null or the strings according to the policy. It
+// returns v unchanged for NonFiniteError, so json.Marshal reports them.
+func jsonNonFinite(v interface{}, policy NonFinitePolicy) interface{} {
+	if policy == NonFiniteError {
+		return v
+	}
+
+	switch v := v.(type) {
+	case float64:
+		if !isNonFinite(v) {
+			return v
+		}
+		if policy == NonFiniteNull {
+			return nil
+		}
+		return (&Float{Value: v}).String()
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNonFinite(e, policy)
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNonFinite(e, policy)
+		}
+	}
+
+	return v
+}
@@ -1,5 +1,11 @@
 package objects
 
+// range of the integer values that NewInt returns the cached Int for.
+const (
+	minCachedInt = -128
+	maxCachedInt = 255
+)
+
 var (
 	// TrueValue represents a true value.
 	TrueValue Object = &Bool{value: true}
@@ -9,4 +15,43 @@ var (
 
 	// UndefinedValue represents an undefined value.
 	UndefinedValue Object = &Undefined{}
+
+	// EmptyString represents an empty string.
+	EmptyString Object = &String{Value: "", runeStr: []rune{}}
+
+	// EmptyImmutableArray represents an empty immutable array.
+	EmptyImmutableArray Object = &ImmutableArray{Value: []Object{}}
+
+	// EmptyImmutableMap represents an empty immutable map.
+	EmptyImmutableMap Object = &ImmutableMap{Value: map[string]Object{}}
+
+	intCache = makeIntCache()
 )
+
+func makeIntCache() []Int {
+	cache := make([]Int, maxCachedInt-minCachedInt+1)
+	for i := range cache {
+		cache[i].Value = int64(i + minCachedInt)
+	}
+
+	return cache
+}
+
+// NewInt returns an Int of the value. The small integers are shared, so the
+// Value of the returned Int must not be changed.
+func NewInt(v int64) *Int {
+	if v >= minCachedInt && v <= maxCachedInt {
+		return &intCache[v-minCachedInt]
+	}
+
+	return &Int{Value: v}
+}
+
+// NewString returns a String of the value. The empty string is shared.
+func NewString(s string) Object {
+	if s == "" {
+		return EmptyString
+	}
+
+	return &String{Value: s}
+}
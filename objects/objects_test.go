@@ -16,6 +16,8 @@ func testBinaryOp(t *testing.T, lhs objects.Object, op token.Token, rhs objects.
 	return assert.NoError(t, err) && assert.Equal(t, expected, actual)
 }
 
+var divisionByZero = &objects.Error{Value: &objects.String{Value: "division by zero"}}
+
 func boolValue(b bool) objects.Object {
 	if b {
 		return objects.TrueValue
@@ -0,0 +1,137 @@
+package objects
+
+import "github.com/d5/tengo/compiler/token"
+
+// PullFunc returns the next value of a sequence produced by the host
+// application. It returns false if there are no more values.
+type PullFunc func() (Object, bool)
+
+// PullIterable is an iterable object of the values pulled from the host
+// application one by one while it's iterated (e.g. "for msg in events {}"),
+// so the values are produced lazily, and, a producer sending the values to
+// an unbuffered channel waits for the script to consume them.
+//
+// The values are pulled from the same source by all the iterators, so the
+// second for-in statement continues where the first one stopped.
+type PullIterable struct {
+	pull PullFunc
+}
+
+// NewPullIterable creates an iterable object of the values returned by the
+// function.
+func NewPullIterable(pull PullFunc) *PullIterable {
+	return &PullIterable{pull: pull}
+}
+
+// NewChanIterable creates an iterable object of the values received from
+// the channel until it's closed. The receive blocks the script, and, it's
+// not interrupted by VM.Abort: use NewPullIterable with a select statement
+// to stop waiting, e.g. when a context is canceled.
+func NewChanIterable(ch <-chan Object) *PullIterable {
+	return NewPullIterable(func() (Object, bool) {
+		v, ok := <-ch
+		if ok && v == nil {
+			v = UndefinedValue
+		}
+
+		return v, ok
+	})
+}
+
+// TypeName returns the name of the type.
+func (o *PullIterable) TypeName() string {
+	return "pull-iterable"
+}
+
+func (o *PullIterable) String() string {
+	return "<pull-iterable>"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (o *PullIterable) BinaryOp(op token.Token, rhs Object) (Object, error) {
+	return nil, ErrInvalidOperator
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (o *PullIterable) IsFalsy() bool {
+	return false
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (o *PullIterable) Equals(x Object) bool {
+	return o == x
+}
+
+// Copy returns a copy of the type. The copy pulls the values from the same
+// source.
+func (o *PullIterable) Copy() Object {
+	return &PullIterable{pull: o.pull}
+}
+
+// Iterate creates a pull iterator.
+func (o *PullIterable) Iterate() Iterator {
+	return &PullIterator{pull: o.pull}
+}
+
+// PullIterator is an iterator for a pull iterable.
+type PullIterator struct {
+	pull PullFunc
+	i    int
+	v    Object
+}
+
+// TypeName returns the name of the type.
+func (i *PullIterator) TypeName() string {
+	return "pull-iterator"
+}
+
+func (i *PullIterator) String() string {
+	return "<pull-iterator>"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (i *PullIterator) BinaryOp(op token.Token, rhs Object) (Object, error) {
+	return nil, ErrInvalidOperator
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (i *PullIterator) IsFalsy() bool {
+	return true
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (i *PullIterator) Equals(Object) bool {
+	return false
+}
+
+// Copy returns a copy of the type.
+func (i *PullIterator) Copy() Object {
+	return &PullIterator{pull: i.pull, i: i.i, v: i.v}
+}
+
+// Next returns true if there are more elements to iterate.
+func (i *PullIterator) Next() bool {
+	v, ok := i.pull()
+	if !ok {
+		return false
+	}
+
+	i.i++
+	i.v = v
+
+	return true
+}
+
+// Key returns the index of the current element.
+func (i *PullIterator) Key() Object {
+	return NewInt(int64(i.i - 1))
+}
+
+// Value returns the value of the current element.
+func (i *PullIterator) Value() Object {
+	return i.v
+}
@@ -0,0 +1,12 @@
+package objects
+
+// Reflectable represents an object that has the fields and the methods that
+// can be read with the selectors (e.g. "t.year" and "t.add(d)"). The names
+// are listed by the reflection functions (e.g. reflect module).
+type Reflectable interface {
+	// FieldNames should return the names of the fields.
+	FieldNames() []string
+
+	// MethodNames should return the names of the methods.
+	MethodNames() []string
+}
@@ -0,0 +1,101 @@
+package objects
+
+// interfaceSize is the approximate number of bytes used to store an Object
+// value (an interface) in an array element or a map entry.
+const interfaceSize = 16
+
+// Sizer represents an object that can report the approximate number of bytes
+// its value occupies in memory. The VM uses the sizes to count the values a
+// script creates against the limit set by runtime.VM.SetMaxMemory, so a
+// user type that holds a large value should implement it.
+type Sizer interface {
+	// ByteSize should return the approximate size of the value in bytes,
+	// including the values it references.
+	ByteSize() int64
+}
+
+// SizeOf returns the approximate size of the object's value in bytes. Arrays
+// and maps are measured including their elements, where the values referenced
+// more than once (including self-references) are counted only once. Objects
+// that do not implement Sizer interface are counted as zero bytes.
+func SizeOf(o Object) int64 {
+	return sizeOf(o, make(map[Object]bool))
+}
+
+// ShallowSizeOf returns the approximate size of the object's value in bytes
+// without the values that arrays and maps reference: only their elements (and
+// the keys of the maps) are counted. Other objects are measured by SizeOf.
+func ShallowSizeOf(o Object) int64 {
+	switch o := o.(type) {
+	case *Array:
+		return int64(len(o.Value)) * interfaceSize
+	case *ImmutableArray:
+		return int64(len(o.Value)) * interfaceSize
+	case *Map:
+		return sizeOfKeys(o.Value)
+	case *ImmutableMap:
+		return sizeOfKeys(o.Value)
+	}
+
+	return SizeOf(o)
+}
+
+func sizeOfKeys(elems map[string]Object) int64 {
+	var size int64
+	for k := range elems {
+		size += int64(len(k)) + interfaceSize
+	}
+
+	return size
+}
+
+func sizeOf(o Object, counted map[Object]bool) int64 {
+	switch o := o.(type) {
+	case *Array:
+		return sizeOfArray(o, o.Value, counted)
+	case *ImmutableArray:
+		return sizeOfArray(o, o.Value, counted)
+	case *Map:
+		return sizeOfMap(o, o.Value, counted)
+	case *ImmutableMap:
+		return sizeOfMap(o, o.Value, counted)
+	case *Error:
+		if o.Value == nil {
+			return 0
+		}
+
+		return sizeOf(o.Value, counted)
+	case Sizer:
+		return o.ByteSize()
+	}
+
+	return 0
+}
+
+func sizeOfArray(o Object, elems []Object, counted map[Object]bool) int64 {
+	if counted[o] {
+		return 0
+	}
+	counted[o] = true
+
+	size := int64(len(elems)) * interfaceSize
+	for _, elem := range elems {
+		size += sizeOf(elem, counted)
+	}
+
+	return size
+}
+
+func sizeOfMap(o Object, elems map[string]Object, counted map[Object]bool) int64 {
+	if counted[o] {
+		return 0
+	}
+	counted[o] = true
+
+	var size int64
+	for k, v := range elems {
+		size += int64(len(k)) + interfaceSize + sizeOf(v, counted)
+	}
+
+	return size
+}
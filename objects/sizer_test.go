@@ -0,0 +1,45 @@
+package objects_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestSizeOf(t *testing.T) {
+	assert.Equal(t, int64(8), objects.SizeOf(&objects.Int{Value: 5}))
+	assert.Equal(t, int64(3), objects.SizeOf(&objects.String{Value: "foo"}))
+	assert.Equal(t, int64(4), objects.SizeOf(&objects.Bytes{Value: []byte("abcd")}))
+	assert.Equal(t, int64(0), objects.SizeOf(objects.UndefinedValue))
+	assert.Equal(t, int64(0), objects.SizeOf(&objects.BuiltinFunction{}))
+
+	// 2 elements (16 bytes each) + int (8) + string (3)
+	arr := &objects.Array{Value: []objects.Object{&objects.Int{Value: 1}, &objects.String{Value: "foo"}}}
+	assert.Equal(t, int64(43), arr.ByteSize())
+
+	// key "a" (1) + element (16) + array (43)
+	m := &objects.Map{Value: map[string]objects.Object{"a": arr}}
+	assert.Equal(t, int64(60), m.ByteSize())
+
+	// shared and self-referencing values are counted once
+	m.Value["b"] = arr
+	m.Value["c"] = m
+	assert.Equal(t, int64(60+17+17), m.ByteSize())
+}
+
+func TestShallowSizeOf(t *testing.T) {
+	assert.Equal(t, int64(3), objects.ShallowSizeOf(&objects.String{Value: "foo"}))
+
+	arr := &objects.Array{Value: []objects.Object{&objects.Int{Value: 1}, &objects.String{Value: "foo"}}}
+	assert.Equal(t, int64(32), objects.ShallowSizeOf(arr))
+	assert.Equal(t, int64(32), objects.ShallowSizeOf(&objects.ImmutableArray{Value: arr.Value}))
+
+	// keys "a" and "bc" (3) + 2 elements (16 bytes each)
+	m := map[string]objects.Object{"a": arr, "bc": arr}
+	assert.Equal(t, int64(35), objects.ShallowSizeOf(&objects.Map{Value: m}))
+	assert.Equal(t, int64(35), objects.ShallowSizeOf(&objects.ImmutableMap{Value: m}))
+
+	// errors are measured including their values
+	assert.Equal(t, int64(43), objects.ShallowSizeOf(&objects.Error{Value: arr}))
+}
@@ -0,0 +1,282 @@
+package objects
+
+import (
+	"github.com/d5/tengo/compiler/token"
+)
+
+// streamPull returns the next element of a stream. It returns false if there
+// are no more elements.
+type streamPull func() (Object, bool, error)
+
+// Stream represents a lazy sequence of values pulled from an iterable object.
+// Intermediate operations (map, filter, take, skip) return new streams
+// without evaluating any elements; the elements are evaluated one by one
+// only when the stream is iterated or a terminal operation (reduce, to_array)
+// is called.
+type Stream struct {
+	open func() streamPull
+}
+
+// NewStream creates a stream of the values of the iterable object.
+func NewStream(iterable Iterable) *Stream {
+	return &Stream{
+		open: func() streamPull {
+			it := iterable.Iterate()
+			return func() (Object, bool, error) {
+				if !it.Next() {
+					return nil, false, nil
+				}
+
+				return it.Value(), true, nil
+			}
+		},
+	}
+}
+
+// TypeName returns the name of the type.
+func (o *Stream) TypeName() string {
+	return "stream"
+}
+
+func (o *Stream) String() string {
+	return "<stream>"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (o *Stream) BinaryOp(op token.Token, rhs Object) (Object, error) {
+	return nil, ErrInvalidOperator
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (o *Stream) IsFalsy() bool {
+	return false
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (o *Stream) Equals(x Object) bool {
+	return o == x
+}
+
+// Copy returns a copy of the type.
+func (o *Stream) Copy() Object {
+	return &Stream{open: o.open}
+}
+
+// Iterate creates a stream iterator.
+func (o *Stream) Iterate() Iterator {
+	return &StreamIterator{pull: o.open()}
+}
+
+// IndexGet returns a stream method for the given name.
+func (o *Stream) IndexGet(index Object) (Object, error) {
+	strIdx, ok := index.(*String)
+	if !ok {
+		return nil, ErrInvalidIndexType
+	}
+
+	switch strIdx.Value {
+	case "map":
+		return &InteropFunction{Name: "map", Value: o.mapFn}, nil
+	case "filter":
+		return &InteropFunction{Name: "filter", Value: o.filter}, nil
+	case "take":
+		return &BuiltinFunction{Name: "take", Value: o.take}, nil
+	case "skip":
+		return &BuiltinFunction{Name: "skip", Value: o.skip}, nil
+	case "reduce":
+		return &InteropFunction{Name: "reduce", Value: o.reduce}, nil
+	case "to_array":
+		return &BuiltinFunction{Name: "to_array", Value: o.toArray}, nil
+	}
+
+	return UndefinedValue, nil
+}
+
+// FieldNames returns nil: a stream has no fields.
+func (o *Stream) FieldNames() []string {
+	return nil
+}
+
+// MethodNames returns the names of the stream methods.
+func (o *Stream) MethodNames() []string {
+	return []string{"map", "filter", "take", "skip", "reduce", "to_array"}
+}
+
+// map(fn func(v) => any) => stream
+func (o *Stream) mapFn(rt Interop, args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	fn := args[0]
+
+	return &Stream{
+		open: func() streamPull {
+			pull := o.open()
+			return func() (Object, bool, error) {
+				v, ok, err := pull()
+				if !ok || err != nil {
+					return nil, false, err
+				}
+
+				res, err := rt.Call(fn, v)
+				if err != nil {
+					return nil, false, err
+				}
+
+				return res, true, nil
+			}
+		},
+	}, nil
+}
+
+// filter(fn func(v) => bool) => stream
+func (o *Stream) filter(rt Interop, args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	fn := args[0]
+
+	return &Stream{
+		open: func() streamPull {
+			pull := o.open()
+			return func() (Object, bool, error) {
+				for {
+					v, ok, err := pull()
+					if !ok || err != nil {
+						return nil, false, err
+					}
+
+					res, err := rt.Call(fn, v)
+					if err != nil {
+						return nil, false, err
+					}
+
+					if !res.IsFalsy() {
+						return v, true, nil
+					}
+				}
+			}
+		},
+	}, nil
+}
+
+// take(n int) => stream
+func (o *Stream) take(args ...Object) (Object, error) {
+	n, err := streamCount(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stream{
+		open: func() streamPull {
+			pull := o.open()
+			var taken int64
+			return func() (Object, bool, error) {
+				if taken >= n {
+					return nil, false, nil
+				}
+				taken++
+
+				return pull()
+			}
+		},
+	}, nil
+}
+
+// skip(n int) => stream
+func (o *Stream) skip(args ...Object) (Object, error) {
+	n, err := streamCount(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stream{
+		open: func() streamPull {
+			pull := o.open()
+			skipped := false
+			return func() (Object, bool, error) {
+				if !skipped {
+					skipped = true
+					for i := int64(0); i < n; i++ {
+						if _, ok, err := pull(); !ok || err != nil {
+							return nil, false, err
+						}
+					}
+				}
+
+				return pull()
+			}
+		},
+	}, nil
+}
+
+// reduce(fn func(acc, v) => any, initial any) => any
+func (o *Stream) reduce(rt Interop, args ...Object) (Object, error) {
+	if len(args) != 2 {
+		return nil, ErrWrongNumArguments
+	}
+
+	fn, acc := args[0], args[1]
+
+	pull := o.open()
+	for {
+		v, ok, err := pull()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return acc, nil
+		}
+
+		acc, err = rt.Call(fn, acc, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// to_array() => array
+func (o *Stream) toArray(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	var arr []Object
+	pull := o.open()
+	for {
+		v, ok, err := pull()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return &Array{Value: arr}, nil
+		}
+
+		arr = append(arr, v)
+	}
+}
+
+func streamCount(args ...Object) (int64, error) {
+	if len(args) != 1 {
+		return 0, ErrWrongNumArguments
+	}
+
+	n, ok := args[0].(*Int)
+	if !ok {
+		return 0, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "int",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	if n.Value < 0 {
+		return 0, ErrIndexOutOfBounds
+	}
+
+	return n.Value, nil
+}
@@ -0,0 +1,66 @@
+package objects
+
+import "github.com/d5/tengo/compiler/token"
+
+// StreamIterator is an iterator for a stream.
+type StreamIterator struct {
+	pull streamPull
+	i    int
+	v    Object
+}
+
+// TypeName returns the name of the type.
+func (i *StreamIterator) TypeName() string {
+	return "stream-iterator"
+}
+
+func (i *StreamIterator) String() string {
+	return "<stream-iterator>"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (i *StreamIterator) BinaryOp(op token.Token, rhs Object) (Object, error) {
+	return nil, ErrInvalidOperator
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (i *StreamIterator) IsFalsy() bool {
+	return true
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (i *StreamIterator) Equals(Object) bool {
+	return false
+}
+
+// Copy returns a copy of the type.
+func (i *StreamIterator) Copy() Object {
+	return &StreamIterator{pull: i.pull, i: i.i, v: i.v}
+}
+
+// Next returns true if there are more elements to iterate. An error
+// returned from a function called by the stream (which also stops the
+// execution of the script) ends the iteration.
+func (i *StreamIterator) Next() bool {
+	v, ok, err := i.pull()
+	if !ok || err != nil {
+		return false
+	}
+
+	i.i++
+	i.v = v
+
+	return true
+}
+
+// Key returns the index of the current element.
+func (i *StreamIterator) Key() Object {
+	return NewInt(int64(i.i - 1))
+}
+
+// Value returns the value of the current element.
+func (i *StreamIterator) Value() Object {
+	return i.v
+}
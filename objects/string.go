@@ -28,9 +28,16 @@ func (o *String) BinaryOp(op token.Token, rhs Object) (Object, error) {
 	case token.Add:
 		switch rhs := rhs.(type) {
 		case *String:
-			return &String{Value: o.Value + rhs.Value}, nil
+			if exceedsLimit(len(o.Value), len(rhs.Value), MaxStringLen) {
+				return nil, ErrStringLimit
+			}
+			return NewString(o.Value + rhs.Value), nil
 		default:
-			return &String{Value: o.Value + rhs.String()}, nil
+			rhsStr := rhs.String()
+			if exceedsLimit(len(o.Value), len(rhsStr), MaxStringLen) {
+				return nil, ErrStringLimit
+			}
+			return NewString(o.Value + rhsStr), nil
 		}
 	}
 
@@ -93,3 +100,8 @@ func (o *String) Iterate() Iterator {
 		l: len(o.runeStr),
 	}
 }
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *String) ByteSize() int64 {
+	return int64(len(o.Value))
+}
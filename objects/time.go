@@ -40,7 +40,7 @@ func (o *Time) BinaryOp(op token.Token, rhs Object) (Object, error) {
 	case *Time:
 		switch op {
 		case token.Sub: // time - time => int (duration)
-			return &Int{Value: int64(o.Value.Sub(rhs.Value))}, nil
+			return NewInt(int64(o.Value.Sub(rhs.Value))), nil
 		case token.Less: // time < time => bool
 			if o.Value.Before(rhs.Value) {
 				return TrueValue, nil
@@ -87,3 +87,287 @@ func (o *Time) Equals(x Object) bool {
 
 	return o.Value.Equal(t.Value)
 }
+
+// Compare returns the ordering of the value of the type
+// relative to the value of another time.
+func (o *Time) Compare(x Object) (int, error) {
+	t, ok := x.(*Time)
+	if !ok {
+		return 0, ErrInvalidOperator
+	}
+
+	switch {
+	case o.Value.Before(t.Value):
+		return -1, nil
+	case o.Value.After(t.Value):
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// MarshalJSON returns the JSON encoding of the time in RFC 3339 format.
+func (o *Time) MarshalJSON() ([]byte, error) {
+	return o.Value.MarshalJSON()
+}
+
+// UnmarshalJSON sets the time from a JSON string in RFC 3339 format.
+func (o *Time) UnmarshalJSON(data []byte) error {
+	return o.Value.UnmarshalJSON(data)
+}
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *Time) ByteSize() int64 {
+	return 24
+}
+
+// IndexGet returns a time component (e.g. t.year, t.unix) or a time method
+// (e.g. t.add(d), t.format(layout)) for the given name.
+func (o *Time) IndexGet(index Object) (Object, error) {
+	strIdx, ok := index.(*String)
+	if !ok {
+		return nil, ErrInvalidIndexType
+	}
+
+	t := o.Value
+
+	switch strIdx.Value {
+	case "year":
+		return NewInt(int64(t.Year())), nil
+	case "month":
+		return NewInt(int64(t.Month())), nil
+	case "day":
+		return NewInt(int64(t.Day())), nil
+	case "weekday":
+		return NewInt(int64(t.Weekday())), nil
+	case "year_day":
+		return NewInt(int64(t.YearDay())), nil
+	case "hour":
+		return NewInt(int64(t.Hour())), nil
+	case "minute":
+		return NewInt(int64(t.Minute())), nil
+	case "second":
+		return NewInt(int64(t.Second())), nil
+	case "nanosecond":
+		return NewInt(int64(t.Nanosecond())), nil
+	case "unix":
+		return NewInt(t.Unix()), nil
+	case "unix_nano":
+		return NewInt(t.UnixNano()), nil
+	case "location":
+		return &String{Value: t.Location().String()}, nil
+	case "is_zero":
+		if t.IsZero() {
+			return TrueValue, nil
+		}
+		return FalseValue, nil
+	case "add":
+		return &BuiltinFunction{Name: "add", Value: o.add}, nil
+	case "add_date":
+		return &BuiltinFunction{Name: "add_date", Value: o.addDate}, nil
+	case "sub":
+		return &BuiltinFunction{Name: "sub", Value: o.sub}, nil
+	case "before":
+		return &BuiltinFunction{Name: "before", Value: o.before}, nil
+	case "after":
+		return &BuiltinFunction{Name: "after", Value: o.after}, nil
+	case "equal":
+		return &BuiltinFunction{Name: "equal", Value: o.equal}, nil
+	case "truncate":
+		return &BuiltinFunction{Name: "truncate", Value: o.truncate}, nil
+	case "round":
+		return &BuiltinFunction{Name: "round", Value: o.round}, nil
+	case "format":
+		return &BuiltinFunction{Name: "format", Value: o.format}, nil
+	case "to_local":
+		return &BuiltinFunction{Name: "to_local", Value: o.toLocal}, nil
+	case "to_utc":
+		return &BuiltinFunction{Name: "to_utc", Value: o.toUTC}, nil
+	}
+
+	return UndefinedValue, nil
+}
+
+// FieldNames returns the names of the time fields.
+func (o *Time) FieldNames() []string {
+	return []string{"year", "month", "day", "weekday", "year_day", "hour", "minute", "second", "nanosecond", "unix", "unix_nano", "location", "is_zero"}
+}
+
+// MethodNames returns the names of the time methods.
+func (o *Time) MethodNames() []string {
+	return []string{"add", "add_date", "sub", "before", "after", "equal", "truncate", "round", "format", "to_local", "to_utc"}
+}
+
+// add(d int) => time
+func (o *Time) add(args ...Object) (Object, error) {
+	d, err := timeDurationArg(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Time{Value: o.Value.Add(d)}, nil
+}
+
+// add_date(years int, months int, days int) => time
+func (o *Time) addDate(args ...Object) (Object, error) {
+	if len(args) != 3 {
+		return nil, ErrWrongNumArguments
+	}
+
+	var vals [3]int
+	for i, name := range []string{"first", "second", "third"} {
+		v, ok := ToInt(args[i])
+		if !ok {
+			return nil, ErrInvalidArgumentType{
+				Name:     name,
+				Expected: "int(compatible)",
+				Found:    args[i].TypeName(),
+			}
+		}
+		vals[i] = v
+	}
+
+	return &Time{Value: o.Value.AddDate(vals[0], vals[1], vals[2])}, nil
+}
+
+// sub(u time) => int
+func (o *Time) sub(args ...Object) (Object, error) {
+	u, err := timeTimeArg(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewInt(int64(o.Value.Sub(u))), nil
+}
+
+// before(u time) => bool
+func (o *Time) before(args ...Object) (Object, error) {
+	u, err := timeTimeArg(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Value.Before(u) {
+		return TrueValue, nil
+	}
+
+	return FalseValue, nil
+}
+
+// after(u time) => bool
+func (o *Time) after(args ...Object) (Object, error) {
+	u, err := timeTimeArg(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Value.After(u) {
+		return TrueValue, nil
+	}
+
+	return FalseValue, nil
+}
+
+// equal(u time) => bool
+func (o *Time) equal(args ...Object) (Object, error) {
+	u, err := timeTimeArg(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Value.Equal(u) {
+		return TrueValue, nil
+	}
+
+	return FalseValue, nil
+}
+
+// truncate(d int) => time
+func (o *Time) truncate(args ...Object) (Object, error) {
+	d, err := timeDurationArg(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Time{Value: o.Value.Truncate(d)}, nil
+}
+
+// round(d int) => time
+func (o *Time) round(args ...Object) (Object, error) {
+	d, err := timeDurationArg(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Time{Value: o.Value.Round(d)}, nil
+}
+
+// format(layout string) => string
+func (o *Time) format(args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return nil, ErrWrongNumArguments
+	}
+
+	layout, ok := ToString(args[0])
+	if !ok {
+		return nil, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	return &String{Value: o.Value.Format(layout)}, nil
+}
+
+// to_local() => time
+func (o *Time) toLocal(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	return &Time{Value: o.Value.Local()}, nil
+}
+
+// to_utc() => time
+func (o *Time) toUTC(args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return nil, ErrWrongNumArguments
+	}
+
+	return &Time{Value: o.Value.UTC()}, nil
+}
+
+func timeDurationArg(args ...Object) (time.Duration, error) {
+	if len(args) != 1 {
+		return 0, ErrWrongNumArguments
+	}
+
+	d, ok := ToInt64(args[0])
+	if !ok {
+		return 0, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "int(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	return time.Duration(d), nil
+}
+
+func timeTimeArg(args ...Object) (time.Time, error) {
+	if len(args) != 1 {
+		return time.Time{}, ErrWrongNumArguments
+	}
+
+	u, ok := ToTime(args[0])
+	if !ok {
+		return time.Time{}, ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "time(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	return u, nil
+}
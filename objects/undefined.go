@@ -40,3 +40,8 @@ func (o *Undefined) Equals(x Object) bool {
 func (o *Undefined) IndexGet(index Object) (Object, error) {
 	return UndefinedValue, nil
 }
+
+// ByteSize returns the approximate size of the value in bytes.
+func (o *Undefined) ByteSize() int64 {
+	return 0
+}
@@ -0,0 +1,394 @@
+// Package plugins loads the scripts in a directory as the plugins of the
+// host application, and, reloads them when the files change.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+// File extensions of the plugins.
+const (
+	SourceFileExt   = ".tengo"
+	BytecodeFileExt = ".out"
+)
+
+// ErrPluginNotFound is returned when calling a plugin that is not loaded.
+var ErrPluginNotFound = errors.New("plugin not found")
+
+// ErrEntryNotFound is returned when calling an entry point that is not
+// defined by the plugin.
+var ErrEntryNotFound = errors.New("entry point not found")
+
+// Registry loads the source files (".tengo") and the compiled bytecode files
+// (".out") in a directory as the plugins named after the files. Each plugin
+// runs in its own VM: it's run once when it's loaded, and, the functions
+// assigned to its top-level variables are the entry points that can be
+// called using Call. The entry points of the bytecode files are found using
+// their debug information, so the stripped bytecode files have no entry
+// points. Registry is safe for concurrent use.
+type Registry struct {
+	dir      string
+	loadLock sync.Mutex
+	lock     sync.RWMutex
+	plugins  map[string]*Plugin
+	failed   map[string]fileStamp // files that failed to load
+	modules  map[string]*objects.Object
+	maxInsts int64
+}
+
+// fileStamp identifies the version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewRegistry creates a Registry of the plugins in the directory. Use Load
+// or Watch to load them.
+func NewRegistry(dir string) *Registry {
+	return &Registry{
+		dir:     dir,
+		plugins: make(map[string]*Plugin),
+		failed:  make(map[string]fileStamp),
+	}
+}
+
+// SetModules sets the builtin modules that the plugins can import (nil for
+// the standard library modules). It applies to the plugins loaded after the
+// call.
+func (r *Registry) SetModules(modules map[string]*objects.Object) {
+	r.loadLock.Lock()
+	defer r.loadLock.Unlock()
+
+	r.modules = modules
+}
+
+// SetMaxInstructions sets the maximum number of the instructions of the
+// plugin runs and the calls of the entry points. See
+// runtime.VM.SetMaxInstructions for details. It applies to the plugins loaded
+// after the call.
+func (r *Registry) SetMaxInstructions(n int64) {
+	r.loadLock.Lock()
+	defer r.loadLock.Unlock()
+
+	r.maxInsts = n
+}
+
+// Load loads the plugins that are added or changed since the last load, and,
+// unloads the plugins whose files are removed. A changed plugin is replaced
+// by a new version only if it's loaded successfully, so the callers keep
+// using the previous version if the new one is broken. The files that fail
+// to load are reported by the returned *LoadError, once per change of the
+// file. If a name has both a source file and a bytecode file, the source file
+// is used.
+func (r *Registry) Load() error {
+	r.loadLock.Lock()
+	defer r.loadLock.Unlock()
+
+	files, err := r.scan()
+	if err != nil {
+		return err
+	}
+
+	loadErr := &LoadError{}
+	for name, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			loadErr.add(path, err)
+			continue
+		}
+		stamp := fileStamp{modTime: info.ModTime(), size: info.Size()}
+
+		old := r.Get(name)
+		if old != nil && old.path == path && old.stamp == stamp {
+			continue
+		}
+		if failed, ok := r.failed[path]; ok && failed == stamp {
+			continue
+		}
+
+		p, err := r.load(name, path)
+		if err != nil {
+			r.failed[path] = stamp
+			loadErr.add(path, err)
+			continue
+		}
+		delete(r.failed, path)
+
+		p.stamp = stamp
+		p.version = 1
+		if old != nil {
+			p.version = old.version + 1
+		}
+
+		r.lock.Lock()
+		r.plugins[name] = p
+		r.lock.Unlock()
+	}
+
+	r.lock.Lock()
+	for name := range r.plugins {
+		if _, ok := files[name]; !ok {
+			delete(r.plugins, name)
+		}
+	}
+	r.lock.Unlock()
+
+	if len(loadErr.Errors) > 0 {
+		return loadErr
+	}
+
+	return nil
+}
+
+// Watch loads the plugins, and, reloads them at the interval until the
+// context is done. The errors of Load are passed to onError (if it's not
+// nil).
+func (r *Registry) Watch(ctx context.Context, interval time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Load(); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Get returns the current version of the plugin, or, nil if it's not loaded.
+func (r *Registry) Get(name string) *Plugin {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.plugins[name]
+}
+
+// Names returns the names of the loaded plugins in the sorted order.
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var names []string
+	for name := range r.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Call calls the entry point of the current version of the plugin.
+func (r *Registry) Call(name, entry string, args ...objects.Object) (objects.Object, error) {
+	p := r.Get(name)
+	if p == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPluginNotFound, name)
+	}
+
+	return p.Call(entry, args...)
+}
+
+// scan returns the paths of the plugin files by the plugin name.
+func (r *Registry) scan() (map[string]string, error) {
+	infos, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+
+		ext := filepath.Ext(info.Name())
+		if ext != SourceFileExt && ext != BytecodeFileExt {
+			continue
+		}
+
+		name := strings.TrimSuffix(info.Name(), ext)
+		if _, ok := files[name]; ok && ext == BytecodeFileExt {
+			continue
+		}
+		files[name] = filepath.Join(r.dir, info.Name())
+	}
+
+	return files, nil
+}
+
+// load compiles or decodes the plugin file, and, runs it.
+func (r *Registry) load(name, path string) (*Plugin, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var bytecode *compiler.Bytecode
+	if filepath.Ext(path) == SourceFileExt {
+		bytecode, err = r.compile(filepath.Base(path), data)
+	} else {
+		bytecode = &compiler.Bytecode{}
+		err = bytecode.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	machine := runtime.NewVM(bytecode, nil, r.modules)
+	machine.SetMaxInstructions(r.maxInsts)
+	if err := machine.Run(); err != nil {
+		return nil, err
+	}
+
+	p := &Plugin{
+		name:    name,
+		path:    path,
+		machine: machine,
+		entries: make(map[string]int),
+	}
+
+	globals := machine.Globals()
+	for _, v := range bytecode.MainFunction.Variables {
+		if !v.Global || v.End != source.NoPos {
+			continue
+		}
+
+		switch globals[v.Index].(type) {
+		case *objects.CompiledFunction, *objects.Closure:
+			p.entries[v.Name] = v.Index
+		}
+	}
+
+	return p, nil
+}
+
+func (r *Registry) compile(filename string, src []byte) (*compiler.Bytecode, error) {
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile(filename, -1, len(src))
+
+	file, err := parser.NewParser(srcFile, src, nil).ParseFile()
+	if err != nil {
+		return nil, err
+	}
+
+	var moduleNames map[string]bool
+	if r.modules != nil {
+		moduleNames = make(map[string]bool)
+		for name := range r.modules {
+			moduleNames[name] = true
+		}
+	}
+
+	c := compiler.NewCompiler(srcFile, nil, nil, moduleNames, nil)
+	c.EnableOptimizer(true)
+	c.SetImportDir(r.dir)
+	if err := c.Compile(file); err != nil {
+		return nil, err
+	}
+
+	return c.Bytecode(), nil
+}
+
+// Plugin is a version of a loaded plugin. The reloaded plugin is a new
+// Plugin, so the callers holding the previous version can keep using it.
+// Plugin is safe for concurrent use: the calls of the entry points are
+// serialized.
+type Plugin struct {
+	name    string
+	path    string
+	version int
+	stamp   fileStamp
+	lock    sync.Mutex
+	machine *runtime.VM
+	entries map[string]int // global variable index by the name
+}
+
+// Name returns the name of the plugin.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Path returns the path of the plugin file.
+func (p *Plugin) Path() string {
+	return p.path
+}
+
+// Version returns the version of the plugin. It starts from 1, and, it's
+// incremented each time the plugin is reloaded.
+func (p *Plugin) Version() int {
+	return p.version
+}
+
+// Entries returns the names of the entry points in the sorted order.
+func (p *Plugin) Entries() []string {
+	var names []string
+	for name := range p.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Call calls the entry point with the arguments, and, returns its result.
+// The function runs in the VM of the plugin, so the changes of the global
+// variables made by the calls are kept until the plugin is reloaded.
+func (p *Plugin) Call(entry string, args ...objects.Object) (objects.Object, error) {
+	idx, ok := p.entries[entry]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s.%s", ErrEntryNotFound, p.name, entry)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.machine.RunFunction(p.machine.Globals()[idx], args...)
+}
+
+// LoadError is the error of the plugin files that failed to load.
+type LoadError struct {
+	Errors map[string]error // errors by the file path
+}
+
+func (e *LoadError) add(path string, err error) {
+	if e.Errors == nil {
+		e.Errors = make(map[string]error)
+	}
+
+	e.Errors[path] = err
+}
+
+func (e *LoadError) Error() string {
+	var paths []string
+	for path := range e.Errors {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var msgs []string
+	for _, path := range paths {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", path, e.Errors[path]))
+	}
+
+	return strings.Join(msgs, "\n")
+}
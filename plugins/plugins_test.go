@@ -0,0 +1,188 @@
+package plugins_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/plugins"
+)
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tengo_plugins_test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	writeFile(t, dir, "greet.tengo", `
+text := import("text")
+prefix := import("lib/prefix")
+count := 0
+hello := func(name) { count++; return prefix + name }
+calls := func() { return count }
+fail := func() { return 1 + "a" }
+notEntry := 1`, 0)
+	writeFile(t, dir, "lib/prefix.tengo", `export "hello, "`, 0)
+	writeFile(t, dir, "calc.out", string(encodeBytecode(t, `double := func(x) { return x * 2 }`)), 0)
+	writeFile(t, dir, "README.md", `not a plugin`, 0)
+
+	r := plugins.NewRegistry(dir)
+	if !assert.NoError(t, r.Load()) {
+		return
+	}
+	assert.Equal(t, "calc greet", strings.Join(r.Names(), " "))
+
+	greet := r.Get("greet")
+	assert.Equal(t, 1, greet.Version())
+	assert.Equal(t, "calls fail hello", strings.Join(greet.Entries(), " "))
+	assert.Equal(t, "calc", r.Get("calc").Name())
+	assert.Equal(t, "double", strings.Join(r.Get("calc").Entries(), " "))
+
+	res, err := r.Call("greet", "hello", &objects.String{Value: "foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.String{Value: "hello, foo"}, res)
+	_, err = r.Call("greet", "fail")
+	assert.Error(t, err)
+	res, err = r.Call("greet", "calls")
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Int{Value: 1}, res)
+	res, err = r.Call("calc", "double", &objects.Int{Value: 21})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Int{Value: 42}, res)
+
+	_, err = r.Call("foo", "hello")
+	assert.True(t, err != nil && strings.Contains(err.Error(), plugins.ErrPluginNotFound.Error()), "%v", err)
+	_, err = r.Call("greet", "notEntry")
+	assert.True(t, err != nil && strings.Contains(err.Error(), plugins.ErrEntryNotFound.Error()), "%v", err)
+
+	// unchanged
+	assert.NoError(t, r.Load())
+	assert.True(t, greet == r.Get("greet"), "plugin reloaded")
+
+	// changed: the new version is swapped in, and, the old one still works
+	writeFile(t, dir, "greet.tengo", `hello := func(name) { return "hi, " + name }`, time.Second)
+	assert.NoError(t, r.Load())
+	assert.Equal(t, 2, r.Get("greet").Version())
+	res, err = r.Call("greet", "hello", &objects.String{Value: "foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.String{Value: "hi, foo"}, res)
+	res, err = greet.Call("hello", &objects.String{Value: "bar"})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.String{Value: "hello, bar"}, res)
+
+	// broken: the previous version is kept, and, the error is reported once
+	writeFile(t, dir, "greet.tengo", `hello := func(name) {`, 2*time.Second)
+	err = r.Load()
+	if assert.Error(t, err) {
+		loadErr, ok := err.(*plugins.LoadError)
+		if assert.True(t, ok) {
+			assert.Equal(t, 1, len(loadErr.Errors))
+		}
+	}
+	assert.NoError(t, r.Load())
+	assert.Equal(t, 2, r.Get("greet").Version())
+
+	// fixed
+	writeFile(t, dir, "greet.tengo", `hello := func(name) { return "hey, " + name }`, 3*time.Second)
+	assert.NoError(t, r.Load())
+	assert.Equal(t, 3, r.Get("greet").Version())
+
+	// removed
+	assert.NoError(t, os.Remove(filepath.Join(dir, "calc.out")))
+	assert.NoError(t, r.Load())
+	assert.Equal(t, "greet", strings.Join(r.Names(), " "))
+	assert.Nil(t, r.Get("calc"))
+}
+
+func TestRegistry_Limits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tengo_plugins_test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	writeFile(t, dir, "loop.tengo", `loop := func() { for {} }; f := func() { return import("os") }`, 0)
+
+	r := plugins.NewRegistry(dir)
+	r.SetMaxInstructions(1000)
+	r.SetModules(map[string]*objects.Object{})
+	assert.Error(t, r.Load()) // os module is not allowed
+
+	writeFile(t, dir, "loop.tengo", `loop := func() { for {} }`, time.Second)
+	if !assert.NoError(t, r.Load()) {
+		return
+	}
+	_, err = r.Call("loop", "loop")
+	assert.Error(t, err)
+}
+
+func TestRegistry_Watch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tengo_plugins_test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	r := plugins.NewRegistry(dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	errs := make(chan error, 10)
+	go func() {
+		r.Watch(ctx, 10*time.Millisecond, func(err error) { errs <- err })
+		close(done)
+	}()
+
+	writeFile(t, dir, "b.tengo", `f := func() {`, 0) // written before a
+	writeFile(t, dir, "a.tengo", `f := func() { return 1 }`, 0)
+	for i := 0; i < 500 && r.Get("a") == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	res, err := r.Call("a", "f")
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Int{Value: 1}, res)
+
+	cancel()
+	<-done
+	assert.Equal(t, 1, len(errs))
+}
+
+func writeFile(t *testing.T, dir, name, content string, age time.Duration) {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	// the file system may not have the precision to tell the changes apart
+	modTime := time.Now().Add(age)
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func encodeBytecode(t *testing.T, input string) []byte {
+	src := []byte(input)
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("calc.tengo", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	assert.NoError(t, err)
+
+	c := compiler.NewCompiler(srcFile, nil, nil, nil, nil)
+	assert.NoError(t, c.Compile(file))
+
+	var buf bytes.Buffer
+	err = c.Bytecode().Encode(&buf)
+	if err == compiler.ErrBytecodeUnsupported {
+		t.Skip(err)
+	}
+	assert.NoError(t, err)
+
+	return buf.Bytes()
+}
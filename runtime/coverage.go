@@ -0,0 +1,140 @@
+package runtime
+
+import (
+	"sort"
+
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
+
+// Coverage records the instructions executed by the VMs, and, reports the
+// source lines that are covered. The same Coverage can be set to multiple
+// VMs (e.g. running the tests of a script library) to merge their coverage.
+// Coverage is not safe for concurrent use.
+type Coverage struct {
+	funcs []*coveredFunc
+	index map[*objects.CompiledFunction]*coveredFunc
+}
+
+type coveredFunc struct {
+	fn      *objects.CompiledFunction
+	fileSet *source.FileSet
+	counts  []int // execution counts by the instruction position
+}
+
+// FileCoverage represents the coverage of a source file.
+type FileCoverage struct {
+	Filename string
+	Lines    []LineCoverage // sorted by the line number
+}
+
+// LineCoverage represents the number of times the instructions compiled from
+// a source line were executed.
+type LineCoverage struct {
+	Line  int
+	Count int
+}
+
+// NewCoverage creates a Coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		index: make(map[*objects.CompiledFunction]*coveredFunc),
+	}
+}
+
+// Covered returns the number of the lines that were executed at least once.
+func (f FileCoverage) Covered() int {
+	var n int
+	for _, l := range f.Lines {
+		if l.Count > 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Percent returns the percentage of the lines that were executed.
+func (f FileCoverage) Percent() float64 {
+	if len(f.Lines) == 0 {
+		return 0
+	}
+
+	return float64(f.Covered()) * 100 / float64(len(f.Lines))
+}
+
+// Report returns the coverage of the source files sorted by the filename.
+// The lines without any compiled instructions are not included, and, the
+// bytecode without debug information is not reported.
+func (c *Coverage) Report() []FileCoverage {
+	lines := make(map[string]map[int]int)
+	for _, f := range c.funcs {
+		for ip, pos := range f.fn.SourceMap {
+			filePos := f.fileSet.Position(pos)
+			if !filePos.IsValid() {
+				continue
+			}
+
+			fileLines := lines[filePos.Filename]
+			if fileLines == nil {
+				fileLines = make(map[int]int)
+				lines[filePos.Filename] = fileLines
+			}
+
+			if count := f.counts[ip]; count > fileLines[filePos.Line] {
+				fileLines[filePos.Line] = count
+			} else if _, ok := fileLines[filePos.Line]; !ok {
+				fileLines[filePos.Line] = 0
+			}
+		}
+	}
+
+	var report []FileCoverage
+	for filename, fileLines := range lines {
+		f := FileCoverage{Filename: filename}
+		for line, count := range fileLines {
+			f.Lines = append(f.Lines, LineCoverage{Line: line, Count: count})
+		}
+		sort.Slice(f.Lines, func(i, j int) bool {
+			return f.Lines[i].Line < f.Lines[j].Line
+		})
+
+		report = append(report, f)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Filename < report[j].Filename
+	})
+
+	return report
+}
+
+// add registers the compiled functions of the bytecode so the lines that are
+// never executed are reported too.
+func (c *Coverage) add(mainFn *objects.CompiledFunction, constants []objects.Object, fileSet *source.FileSet) {
+	c.function(mainFn, fileSet)
+	for _, cn := range constants {
+		if fn, ok := cn.(*objects.CompiledFunction); ok {
+			c.function(fn, fileSet)
+		}
+	}
+}
+
+func (c *Coverage) function(fn *objects.CompiledFunction, fileSet *source.FileSet) *coveredFunc {
+	f := c.index[fn]
+	if f == nil {
+		f = &coveredFunc{
+			fn:      fn,
+			fileSet: fileSet,
+			counts:  make([]int, len(fn.Instructions)),
+		}
+		c.index[fn] = f
+		c.funcs = append(c.funcs, f)
+	}
+
+	return f
+}
+
+// hit records the execution of the instruction at ip.
+func (c *Coverage) hit(fn *objects.CompiledFunction, fileSet *source.FileSet, ip int) {
+	c.function(fn, fileSet).counts[ip]++
+}
@@ -0,0 +1,259 @@
+package runtime
+
+import (
+	"sort"
+
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
+
+// DebugAction tells the VM how to continue after it's stopped by the
+// Debugger.
+type DebugAction int
+
+// List of the debug actions.
+const (
+	// DebugContinue runs until the next breakpoint.
+	DebugContinue DebugAction = iota
+	// DebugStepIn stops at the next source line, including the lines of the
+	// called functions.
+	DebugStepIn
+	// DebugStepOver stops at the next source line of the current function,
+	// or, of the calling function after it returns.
+	DebugStepOver
+	// DebugStepOut stops at the next source line of the calling function.
+	DebugStepOut
+)
+
+// Debugger stops the VM at the breakpoints, or, after a step, and, calls the
+// handler with the state of the VM. The handler returns how the VM continues
+// the execution. The VM stops only at the first instruction of a source line.
+// Debugger is not safe for concurrent use.
+type Debugger struct {
+	handler     func(state *DebugState) DebugAction
+	breakpoints map[breakpoint]bool
+	action      DebugAction
+	last        location // location of the last instruction
+	stopped     location // location where the VM stopped last time
+}
+
+type breakpoint struct {
+	filename string
+	line     int
+}
+
+type location struct {
+	breakpoint
+	depth int // number of the function call frames
+}
+
+// DebugVariable is a variable of the program being debugged.
+type DebugVariable struct {
+	Name  string
+	Value objects.Object
+}
+
+// NewDebugger creates a Debugger that calls handler whenever the VM stops.
+func NewDebugger(handler func(state *DebugState) DebugAction) *Debugger {
+	return &Debugger{
+		handler:     handler,
+		breakpoints: make(map[breakpoint]bool),
+	}
+}
+
+// SetBreakpoint sets a breakpoint at the line of the source file.
+func (d *Debugger) SetBreakpoint(filename string, line int) {
+	d.breakpoints[breakpoint{filename, line}] = true
+}
+
+// ClearBreakpoint removes the breakpoint at the line of the source file. It
+// returns false if there's no such breakpoint.
+func (d *Debugger) ClearBreakpoint(filename string, line int) bool {
+	bp := breakpoint{filename, line}
+	if !d.breakpoints[bp] {
+		return false
+	}
+
+	delete(d.breakpoints, bp)
+
+	return true
+}
+
+// Breakpoints returns the positions of the breakpoints sorted by the file
+// name and the line.
+func (d *Debugger) Breakpoints() []source.FilePos {
+	var positions []source.FilePos
+	for bp := range d.breakpoints {
+		positions = append(positions, source.FilePos{Filename: bp.filename, Line: bp.line})
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Filename != positions[j].Filename {
+			return positions[i].Filename < positions[j].Filename
+		}
+		return positions[i].Line < positions[j].Line
+	})
+
+	return positions
+}
+
+// Pause makes the VM stop at the next source line.
+func (d *Debugger) Pause() {
+	d.action = DebugStepIn
+}
+
+// hit is called before the VM executes the instruction at ip.
+func (d *Debugger) hit(v *VM) {
+	pos, ok := v.curFrame.fn.SourceMap[v.ip]
+	if !ok {
+		return
+	}
+
+	filePos := v.fileSet.Position(pos)
+	if !filePos.IsValid() {
+		return
+	}
+
+	cur := location{breakpoint{filePos.Filename, filePos.Line}, v.framesIndex}
+	if cur == d.last {
+		return
+	}
+	d.last = cur
+
+	stop := d.breakpoints[cur.breakpoint]
+	switch d.action {
+	case DebugStepIn:
+		stop = stop || cur != d.stopped
+	case DebugStepOver:
+		stop = stop || cur.depth < d.stopped.depth ||
+			(cur.depth == d.stopped.depth && cur != d.stopped)
+	case DebugStepOut:
+		stop = stop || cur.depth < d.stopped.depth
+	}
+	if !stop {
+		return
+	}
+
+	d.stopped = cur
+	d.action = d.handler(&DebugState{vm: v, pos: filePos})
+}
+
+// DebugState is the state of the VM stopped by the Debugger. It's valid only
+// until the handler returns.
+type DebugState struct {
+	vm  *VM
+	pos source.FilePos
+}
+
+// Position returns the source position where the VM stopped.
+func (s *DebugState) Position() source.FilePos {
+	return s.pos
+}
+
+// Stack returns the source positions of the current instruction and the
+// function calls in the active call frames, innermost first.
+func (s *DebugState) Stack() []source.FilePos {
+	return s.vm.callStack()
+}
+
+// Locals returns the variables that are valid in the function call frame
+// (0 is the innermost frame) sorted by the name. The variables of the main
+// function are the global variables.
+func (s *DebugState) Locals(frame int) []DebugVariable {
+	frameIndex := s.vm.framesIndex - 1 - frame
+	if frame < 0 || frameIndex < 0 {
+		return nil
+	}
+
+	// the inner blocks can define the variables with the same name
+	vars := make(map[string]objects.VariableInfo)
+	for _, info := range s.frameVariables(frameIndex) {
+		if prev, ok := vars[info.Name]; !ok || info.Pos > prev.Pos {
+			vars[info.Name] = info
+		}
+	}
+
+	var locals []DebugVariable
+	for name, info := range vars {
+		locals = append(locals, DebugVariable{
+			Name:  name,
+			Value: s.value(frameIndex, info),
+		})
+	}
+
+	sort.Slice(locals, func(i, j int) bool {
+		return locals[i].Name < locals[j].Name
+	})
+
+	return locals
+}
+
+// Globals returns the global variables that are valid in the main function
+// sorted by the name.
+func (s *DebugState) Globals() []DebugVariable {
+	return s.Locals(s.vm.framesIndex - 1)
+}
+
+// Lookup returns the value of the variable that is valid in the innermost
+// function call frame, or, of the global variable.
+func (s *DebugState) Lookup(name string) (objects.Object, bool) {
+	for _, frameIndex := range []int{s.vm.framesIndex - 1, 0} {
+		var found *objects.VariableInfo
+		for _, info := range s.frameVariables(frameIndex) {
+			if info.Name == name && (found == nil || info.Pos > found.Pos) {
+				info := info
+				found = &info
+			}
+		}
+
+		if found != nil {
+			return s.value(frameIndex, *found), true
+		}
+	}
+
+	return nil, false
+}
+
+// Abort aborts the execution of the VM.
+func (s *DebugState) Abort() {
+	s.vm.Abort()
+}
+
+// frameVariables returns the variables that are valid at the current
+// instruction of the function call frame.
+func (s *DebugState) frameVariables(frameIndex int) []objects.VariableInfo {
+	frame := &s.vm.frames[frameIndex]
+
+	pos := instructionPos(frame.fn, frame.ip)
+	if frameIndex == s.vm.framesIndex-1 {
+		pos = frame.fn.SourceMap[s.vm.ip]
+	}
+
+	var vars []objects.VariableInfo
+	for _, info := range frame.fn.Variables {
+		if info.InScope(pos) {
+			vars = append(vars, info)
+		}
+	}
+
+	return vars
+}
+
+func (s *DebugState) value(frameIndex int, info objects.VariableInfo) objects.Object {
+	var val objects.Object
+	if info.Global {
+		val = s.vm.globals[info.Index]
+	} else {
+		val = s.vm.stack[s.vm.frames[frameIndex].basePointer+info.Index]
+	}
+
+	if ptr, ok := val.(*objects.ObjectPtr); ok {
+		val = *ptr.Value
+	}
+
+	if val == nil {
+		return objects.UndefinedValue
+	}
+
+	return val
+}
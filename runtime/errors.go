@@ -2,7 +2,102 @@ package runtime
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/source"
 )
 
-// ErrStackOverflow is a stack overflow error.
+// ErrStackOverflow is a stack overflow error. It's the cause of the run-time
+// error that describes the innermost function calls, e.g.:
+//
+//	test:3:9: stack overflow at call depth 1024: func@test:3 at test:3:9 (1022 calls), (main) at test:6:1
 var ErrStackOverflow = errors.New("stack overflow")
+
+// ErrInstructionLimit is returned when the VM executes more instructions
+// than the limit set by VM.SetMaxInstructions.
+var ErrInstructionLimit = errors.New("instruction limit exceeded")
+
+// ErrMemoryLimit is returned when the values created by the script exceed the
+// limit set by VM.SetMaxMemory.
+var ErrMemoryLimit = errors.New("memory limit exceeded")
+
+// ErrAborted is returned by Run when the execution is aborted by Abort. It's
+// also returned to the Go functions calling back script functions after the
+// execution is aborted.
+var ErrAborted = errors.New("execution aborted")
+
+// ErrVerification is the cause of the errors from running malformed
+// bytecode: the bytecode rejected by compiler.Bytecode.Validate before
+// running it, an unknown opcode, or, the values left on the stack after the
+// execution.
+var ErrVerification = errors.New("bytecode verification failed")
+
+// ErrNotIndexable is the cause of the errors from indexing a value that
+// does not support the index operator.
+var ErrNotIndexable = errors.New("not indexable")
+
+// ErrNotCallable is the cause of the errors from calling a value that is not
+// a function.
+var ErrNotCallable = errors.New("not callable")
+
+// ErrNotIterable is the cause of the errors from iterating over a value that
+// is not iterable.
+var ErrNotIterable = errors.New("not iterable")
+
+// Error represents a runtime error with the position in the source code
+// where it occurred. Run returns an *Error for all the run-time errors of the
+// script except ErrInstructionLimit and ErrMemoryLimit.
+//
+// Err is the cause of the error (e.g. objects.ErrWrongNumArguments or
+// ErrNotIndexable) if known, so the errors can be compared using errors.Is
+// instead of parsing the message.
+type Error struct {
+	Pos     source.FilePos
+	Opcode  compiler.Opcode
+	Message string
+	Frames  []source.FilePos // positions of the active calls, innermost first
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if !e.Pos.IsValid() && e.Pos.Filename == "" {
+		// no debug information (e.g. stripped bytecode)
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// Position returns the position in the source code.
+func (e *Error) Position() source.FilePos {
+	return e.Pos
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(pos source.FilePos, format string, args ...interface{}) error {
+	return &Error{
+		Pos:     pos,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func wrapError(pos source.FilePos, err error) error {
+	return &Error{
+		Pos:     pos,
+		Message: err.Error(),
+		Err:     err,
+	}
+}
+
+func wrapErrorf(pos source.FilePos, err error, format string, args ...interface{}) error {
+	return &Error{
+		Pos:     pos,
+		Message: fmt.Sprintf(format, args...),
+		Err:     err,
+	}
+}
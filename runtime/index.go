@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"unicode/utf8"
+
+	"github.com/d5/tengo/objects"
+)
+
+// SetStrictIndex sets whether the index operator on the arrays, strings and
+// bytes stops the execution with a run-time error if the index is out of
+// range. By default, the out of range index evaluates to undefined.
+func (v *VM) SetStrictIndex(strict bool) {
+	v.strictIndex = strict
+}
+
+// outOfRange returns the length of the array, string or bytes if the int
+// index is out of its range. It's called only when the index operator
+// evaluated to undefined in the strict mode.
+func outOfRange(left objects.Indexable, index objects.Object) (length int, ok bool) {
+	idx, isInt := index.(*objects.Int)
+	if !isInt {
+		return 0, false
+	}
+
+	switch left := left.(type) {
+	case *objects.Array:
+		length = len(left.Value)
+	case *objects.ImmutableArray:
+		length = len(left.Value)
+	case *objects.String:
+		length = utf8.RuneCountInString(left.Value)
+	case *objects.Bytes:
+		length = len(left.Value)
+	default:
+		return 0, false
+	}
+
+	return length, idx.Value < 0 || idx.Value >= int64(length)
+}
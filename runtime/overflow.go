@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+// IntOverflow is the behavior of the int arithmetic (+, -, *, / and the
+// unary -) when the result does not fit in int64.
+type IntOverflow int
+
+// List of the int overflow behaviors.
+const (
+	// IntOverflowWrap wraps the result around (e.g. the maximum int + 1 is
+	// the minimum int). It's the default behavior.
+	IntOverflowWrap IntOverflow = iota
+
+	// IntOverflowSaturate clamps the result to the maximum or the minimum
+	// int.
+	IntOverflowSaturate
+
+	// IntOverflowError stops the execution with a run-time error.
+	IntOverflowError
+
+	// IntOverflowBigInt promotes the result to objects.BigInt.
+	IntOverflowBigInt
+)
+
+// SetIntOverflow sets the behavior of the int arithmetic on overflow.
+func (v *VM) SetIntOverflow(mode IntOverflow) {
+	v.intOverflow = mode
+}
+
+// intOp returns the result of the int operator applying the overflow
+// behavior of the VM. It's called only when the behavior is not
+// IntOverflowWrap. b must not be 0 for the division.
+func (v *VM) intOp(op token.Token, a, b int64) (objects.Object, error) {
+	r, ok := objects.CheckedIntOp(op, a, b)
+	if ok {
+		return objects.NewInt(r), nil
+	}
+
+	switch v.intOverflow {
+	case IntOverflowSaturate:
+		// the sign of the exact result
+		negative := (a < 0) != (b < 0)
+		switch op {
+		case token.Add:
+			negative = b < 0
+		case token.Sub:
+			negative = b > 0
+		}
+		if negative {
+			return objects.NewInt(math.MinInt64), nil
+		}
+		return objects.NewInt(math.MaxInt64), nil
+	case IntOverflowError:
+		return nil, fmt.Errorf("integer overflow: %d %s %d", a, op.String(), b)
+	case IntOverflowBigInt:
+		return (&objects.BigInt{Value: big.NewInt(a)}).BinaryOp(op, &objects.Int{Value: b})
+	}
+
+	return objects.NewInt(r), nil
+}
+
+// negInt returns the negation of the int applying the overflow behavior of
+// the VM. It's called only when the behavior is not IntOverflowWrap.
+func (v *VM) negInt(x int64) (objects.Object, error) {
+	if x != math.MinInt64 {
+		return objects.NewInt(-x), nil
+	}
+
+	switch v.intOverflow {
+	case IntOverflowSaturate:
+		return objects.NewInt(math.MaxInt64), nil
+	case IntOverflowError:
+		return nil, fmt.Errorf("integer overflow: -(%d)", x)
+	case IntOverflowBigInt:
+		return objects.NewBigInt(new(big.Int).Neg(big.NewInt(x))), nil
+	}
+
+	return objects.NewInt(x), nil
+}
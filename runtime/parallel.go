@@ -0,0 +1,180 @@
+package runtime
+
+import (
+	goruntime "runtime"
+	"sync"
+
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/objects"
+)
+
+// ParallelMapFunction is an optional builtin function that calls a script
+// function with each element of an array on multiple goroutines:
+//
+//	parallel_map(arr, fn [, workers]) => array
+//
+// It is not defined by default. Add it as a global variable (e.g. using
+// script.Add) to make it available to the scripts.
+var ParallelMapFunction = &objects.InteropFunction{
+	Name:  "parallel_map",
+	Value: parallelMap,
+}
+
+// ParallelMap calls fn with each element of elems, and, returns the results
+// in the same order. The elements are split into the chunks of the same size,
+// and, each chunk is processed by a new VM on its own goroutine. The number of
+// the goroutines is workers, or, the number of the CPUs if workers is 0 or
+// less.
+//
+// fn must be a compiled function or a closure of the bytecode the VM is
+// running. Each VM has its own copy of the global variables, so changes to
+// the global variables made by fn are not visible to the others or to v.
+// The elements, the captured variables of fn, and, the values of the global
+// variables are shared by the goroutines, and, fn must not modify them.
+//
+// If fn fails with a run-time error, the other VMs are aborted, and,
+// ParallelMap returns the error.
+func (v *VM) ParallelMap(fn objects.Object, elems []objects.Object, workers int) ([]objects.Object, error) {
+	if workers <= 0 {
+		workers = goruntime.NumCPU()
+	}
+	if workers > len(elems) {
+		workers = len(elems)
+	}
+
+	results := make([]objects.Object, len(elems))
+	if workers == 0 {
+		return results, nil
+	}
+
+	machines := make([]*VM, workers)
+	for i := range machines {
+		machines[i] = v.fork()
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	chunkSize := (len(elems) + workers - 1) / workers
+	for i, machine := range machines {
+		start := i * chunkSize
+		end := start + chunkSize
+		if end > len(elems) {
+			end = len(elems)
+		}
+
+		wg.Add(1)
+		go func(machine *VM, start, end int) {
+			defer wg.Done()
+
+			for idx := start; idx < end; idx++ {
+				res, err := machine.Call(fn, elems[idx])
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						for _, m := range machines {
+							m.Abort()
+						}
+					})
+					return
+				}
+
+				results[idx] = res
+			}
+		}(machine, start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return results, nil
+}
+
+// fork creates a VM that runs the same bytecode with a copy of the global
+// variables and the same limits.
+func (v *VM) fork() *VM {
+	globals := make([]objects.Object, len(v.globals))
+	copy(globals, v.globals)
+
+	bytecode := &compiler.Bytecode{
+		FileSet:      v.fileSet,
+		MainFunction: v.frames[0].fn,
+		Constants:    v.constants,
+	}
+
+	machine := NewVM(bytecode, globals, v.builtinModules)
+	machine.maxInsts = v.maxInsts
+	machine.maxMemory = v.maxMemory
+	machine.intOverflow = v.intOverflow
+	machine.strictIndex = v.strictIndex
+
+	return machine
+}
+
+func parallelMap(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var elems []objects.Object
+	switch arg := args[0].(type) {
+	case *objects.Array:
+		elems = arg.Value
+	case *objects.ImmutableArray:
+		elems = arg.Value
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "array",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	switch args[1].(type) {
+	case *objects.CompiledFunction, *objects.Closure:
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "compiled-function",
+			Found:    args[1].TypeName(),
+		}
+	}
+
+	workers := 0
+	if len(args) == 3 {
+		n, ok := objects.ToInt(args[2])
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "third",
+				Expected: "int(compatible)",
+				Found:    args[2].TypeName(),
+			}
+		}
+		workers = n
+	}
+
+	var results []objects.Object
+	if machine, ok := rt.(*VM); ok {
+		var err error
+		results, err = machine.ParallelMap(args[1], elems, workers)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// the runtime cannot create the VMs: call fn sequentially
+		results = make([]objects.Object, len(elems))
+		for idx, elem := range elems {
+			res, err := rt.Call(args[1], elem)
+			if err != nil {
+				return nil, err
+			}
+			results[idx] = res
+		}
+	}
+
+	return &objects.Array{Value: results}, nil
+}
@@ -0,0 +1,210 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
+
+// Profile records the number of times each instruction is executed by the
+// VMs and the time spent on it until the next instruction (including the
+// time spent in the Go functions it calls), and, reports them by function and
+// by source line. Profile is not safe for concurrent use.
+type Profile struct {
+	funcs    []*profiledFunc
+	index    map[*objects.CompiledFunction]*profiledFunc
+	last     *profiledFunc
+	lastIP   int
+	lastTime time.Time
+}
+
+type profiledFunc struct {
+	fn        *objects.CompiledFunction
+	fileSet   *source.FileSet
+	name      string
+	counts    []int
+	durations []time.Duration
+}
+
+// ProfileEntry represents the number of the executed instructions and the
+// time spent on them for a function or a source line.
+type ProfileEntry struct {
+	Name     string // function name or source position ("file:line")
+	Count    int
+	Duration time.Duration
+}
+
+// NewProfile creates a Profile.
+func NewProfile() *Profile {
+	return &Profile{
+		index: make(map[*objects.CompiledFunction]*profiledFunc),
+	}
+}
+
+// Functions returns the profile of the compiled functions sorted by the time
+// spent (the most expensive first). The functions are named after the
+// source position of their first statement (e.g. "func@myapp.tengo:12"), and,
+// the main function is named "(main)".
+func (p *Profile) Functions() []ProfileEntry {
+	var entries []ProfileEntry
+	for _, f := range p.funcs {
+		entry := ProfileEntry{Name: f.name}
+		for ip, count := range f.counts {
+			entry.Count += count
+			entry.Duration += f.durations[ip]
+		}
+
+		if entry.Count > 0 {
+			entries = append(entries, entry)
+		}
+	}
+
+	sortProfileEntries(entries)
+
+	return entries
+}
+
+// Lines returns the profile of the source lines sorted by the time spent
+// (the most expensive first). The instructions without the source positions
+// (e.g. stripped bytecode) are not reported.
+func (p *Profile) Lines() []ProfileEntry {
+	index := make(map[string]int)
+	var entries []ProfileEntry
+	for _, f := range p.funcs {
+		for ip, count := range f.counts {
+			if count == 0 {
+				continue
+			}
+
+			filePos := f.fileSet.Position(instructionPos(f.fn, ip))
+			if !filePos.IsValid() {
+				continue
+			}
+
+			name := fmt.Sprintf("%s:%d", filePos.Filename, filePos.Line)
+			idx, ok := index[name]
+			if !ok {
+				idx = len(entries)
+				index[name] = idx
+				entries = append(entries, ProfileEntry{Name: name})
+			}
+
+			entries[idx].Count += count
+			entries[idx].Duration += f.durations[ip]
+		}
+	}
+
+	sortProfileEntries(entries)
+
+	return entries
+}
+
+// WriteText writes the flat text profile of the functions and the source
+// lines.
+func (p *Profile) WriteText(w io.Writer) error {
+	sections := []struct {
+		title   string
+		entries []ProfileEntry
+	}{
+		{"function", p.Functions()},
+		{"line", p.Lines()},
+	}
+
+	for i, section := range sections {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+
+		var total time.Duration
+		for _, e := range section.entries {
+			total += e.Duration
+		}
+
+		if _, err := fmt.Fprintf(w, "%12s %7s %12s  %s\n", "flat", "flat%", "count", section.title); err != nil {
+			return err
+		}
+
+		for _, e := range section.entries {
+			var percent float64
+			if total > 0 {
+				percent = float64(e.Duration) * 100 / float64(total)
+			}
+
+			if _, err := fmt.Fprintf(w, "%12s %6.2f%% %12d  %s\n", e.Duration, percent, e.Count, e.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// hit records the execution of the instruction at ip, and, the time spent on
+// the previous instruction.
+func (p *Profile) hit(fn *objects.CompiledFunction, fileSet *source.FileSet, ip int) {
+	now := time.Now()
+	p.stop(now)
+
+	f := p.function(fn, fileSet)
+	f.counts[ip]++
+	p.last, p.lastIP, p.lastTime = f, ip, now
+}
+
+func (p *Profile) function(fn *objects.CompiledFunction, fileSet *source.FileSet) *profiledFunc {
+	f := p.index[fn]
+	if f == nil {
+		f = &profiledFunc{
+			fn:        fn,
+			fileSet:   fileSet,
+			name:      funcName(fn, fileSet, len(p.funcs)),
+			counts:    make([]int, len(fn.Instructions)),
+			durations: make([]time.Duration, len(fn.Instructions)),
+		}
+		p.index[fn] = f
+		p.funcs = append(p.funcs, f)
+	}
+
+	return f
+}
+
+// stop records the time spent on the last instruction.
+func (p *Profile) stop(now time.Time) {
+	if p.last != nil {
+		p.last.durations[p.lastIP] += now.Sub(p.lastTime)
+		p.last = nil
+	}
+}
+
+// funcName returns the source position of the function, or, its index if
+// the function does not have the debug information.
+func funcName(fn *objects.CompiledFunction, fileSet *source.FileSet, idx int) string {
+	minPos := source.NoPos
+	for _, pos := range fn.SourceMap {
+		if pos.IsValid() && (!minPos.IsValid() || pos < minPos) {
+			minPos = pos
+		}
+	}
+
+	filePos := fileSet.Position(minPos)
+	if !filePos.IsValid() {
+		return fmt.Sprintf("func#%d", idx)
+	}
+
+	return fmt.Sprintf("func@%s:%d", filePos.Filename, filePos.Line)
+}
+
+func sortProfileEntries(entries []ProfileEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Duration != entries[j].Duration {
+			return entries[i].Duration > entries[j].Duration
+		}
+
+		return entries[i].Name < entries[j].Name
+	})
+}
@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+)
+
+// Tracer receives the execution events of the VM. The VM calls the methods
+// synchronously from Run (and RunFunction), so they slow down the script,
+// and, they must not modify or retain the arguments. The VMs created by the
+// parallel builtin functions are not traced.
+type Tracer interface {
+	// OnInstruction is called before the VM executes the instruction.
+	OnInstruction(frame TraceFrame)
+
+	// OnCall is called before the VM calls a function. The frame is the
+	// location of the call. The tail calls reuse the call frame of the
+	// caller, so they are not followed by OnReturn.
+	OnCall(frame TraceFrame, callee objects.Object, args []objects.Object)
+
+	// OnReturn is called after a function returns the value. The frame is
+	// the location of the call, the same as the matching OnCall.
+	OnReturn(frame TraceFrame, value objects.Object)
+
+	// OnError is called with the error that Run or RunFunction returns.
+	OnError(err error)
+}
+
+// TraceFrame is the location of the VM passed to the Tracer.
+type TraceFrame struct {
+	Fn     *objects.CompiledFunction // function being executed
+	IP     int                       // offset of the instruction in Fn
+	Opcode compiler.Opcode
+	Pos    source.FilePos // position of the instruction in the source
+	Depth  int            // number of the function call frames
+}
+
+// SetTracer sets the Tracer that receives the execution events of the VM.
+// Set nil to stop tracing.
+func (v *VM) SetTracer(tracer Tracer) {
+	v.tracer = tracer
+}
+
+// traceFrame returns the location of the instruction at ip in the current
+// function.
+func (v *VM) traceFrame(ip int) TraceFrame {
+	frame := TraceFrame{
+		Fn:    v.curFrame.fn,
+		IP:    ip,
+		Depth: v.framesIndex,
+	}
+
+	if ip >= 0 && ip < len(v.curInsts) {
+		frame.Opcode = v.curInsts[ip]
+		frame.Pos = v.fileSet.Position(instructionPos(v.curFrame.fn, ip))
+	}
+
+	return frame
+}
+
+// callSite returns the location of the instruction that called the function
+// of the frame above the current one. ip of the current frame points at one
+// of the operands of the call instruction.
+func (v *VM) callSite() TraceFrame {
+	return v.traceFrame(instructionStart(v.curInsts, v.ip))
+}
+
+// instructionStart returns the offset of the instruction at ip (which can be
+// pointing at one of the operands of the instruction), or, -1 if ip is not
+// in the instructions.
+func instructionStart(insts []byte, ip int) int {
+	start := -1
+	for i := 0; i <= ip && i < len(insts); {
+		start = i
+		op := insts[i]
+		if int(op) >= len(compiler.OpcodeOperands) {
+			break
+		}
+		for _, w := range compiler.OpcodeOperands[op] {
+			i += w
+		}
+		i++
+	}
+
+	return start
+}
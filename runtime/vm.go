@@ -2,7 +2,11 @@ package runtime
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/d5/tengo/compiler"
 	"github.com/d5/tengo/compiler/source"
@@ -23,18 +27,15 @@ const (
 )
 
 var (
-	truePtr      = &objects.TrueValue
-	falsePtr     = &objects.FalseValue
-	undefinedPtr = &objects.UndefinedValue
 	builtinFuncs []objects.Object
 )
 
 // VM is a virtual machine that executes the bytecode compiled by Compiler.
 type VM struct {
 	constants      []objects.Object
-	stack          []*objects.Object
+	stack          []objects.Object
 	sp             int
-	globals        []*objects.Object
+	globals        []objects.Object
 	fileSet        *source.FileSet
 	frames         []Frame
 	framesIndex    int
@@ -43,19 +44,44 @@ type VM struct {
 	curIPLimit     int
 	ip             int
 	aborting       int64
+	abortLock      sync.Mutex
+	abortCh        chan struct{} // closed when the execution is aborted
+	abortClosed    bool
+	running        int64
+	err            error
 	builtinModules map[string]*objects.Object
+	validated      bool
+	coverage       *Coverage
+	profile        *Profile
+	debugger       *Debugger
+	tracer         Tracer
+	maxInsts       int64
+	maxMemory      uint64
+	numInsts       int64
+	allocated      uint64 // approximate bytes of the values created by the VM
+	intOverflow    IntOverflow
+	strictIndex    bool
+	keys           map[string]*objects.String // string constants to intern the map keys
 }
 
-// NewVM creates a VM.
-func NewVM(bytecode *compiler.Bytecode, globals []*objects.Object, builtinModules map[string]*objects.Object) *VM {
+// NewVM creates a VM. The bytecode and the builtin modules are not modified
+// by the VM, so they can be shared by multiple VMs running concurrently as
+// long as the builtin modules are prepared by objects.Freeze.
+func NewVM(bytecode *compiler.Bytecode, globals []objects.Object, builtinModules map[string]*objects.Object) *VM {
 	if globals == nil {
-		globals = make([]*objects.Object, GlobalsSize)
+		globals = make([]objects.Object, GlobalsSize)
 	}
 
 	if builtinModules == nil {
 		builtinModules = stdlib.Modules
 	}
 
+	fileSet := bytecode.FileSet
+	if fileSet == nil {
+		// bytecode without debug information
+		fileSet = source.NewFileSet()
+	}
+
 	frames := make([]Frame, MaxFrames)
 	frames[0].fn = bytecode.MainFunction
 	frames[0].freeVars = nil
@@ -64,10 +90,10 @@ func NewVM(bytecode *compiler.Bytecode, globals []*objects.Object, builtinModule
 
 	return &VM{
 		constants:      bytecode.Constants,
-		stack:          make([]*objects.Object, StackSize),
+		stack:          make([]objects.Object, StackSize),
 		sp:             0,
 		globals:        globals,
-		fileSet:        bytecode.FileSet,
+		fileSet:        fileSet,
 		frames:         frames,
 		framesIndex:    1,
 		curFrame:       &(frames[0]),
@@ -78,13 +104,75 @@ func NewVM(bytecode *compiler.Bytecode, globals []*objects.Object, builtinModule
 	}
 }
 
-// Abort aborts the execution.
+// Abort aborts the execution. Run returns ErrAborted if the execution is
+// aborted before it completes. It can be called from any goroutine.
 func (v *VM) Abort() {
 	atomic.StoreInt64(&v.aborting, 1)
+
+	v.abortLock.Lock()
+	if v.abortCh != nil && !v.abortClosed {
+		close(v.abortCh)
+		v.abortClosed = true
+	}
+	v.abortLock.Unlock()
+}
+
+// Aborted returns a channel that's closed when the execution is aborted, so
+// the Go functions called by the script can stop waiting (e.g. for a timer).
+// It can be called from any goroutine.
+func (v *VM) Aborted() <-chan struct{} {
+	v.abortLock.Lock()
+	defer v.abortLock.Unlock()
+
+	if v.abortCh == nil {
+		v.abortCh = make(chan struct{})
+		if atomic.LoadInt64(&v.aborting) != 0 {
+			close(v.abortCh)
+			v.abortClosed = true
+		}
+	}
+
+	return v.abortCh
+}
+
+// resetAbort clears the abort of the previous run.
+func (v *VM) resetAbort() {
+	atomic.StoreInt64(&v.aborting, 0)
+
+	v.abortLock.Lock()
+	if v.abortClosed {
+		v.abortCh = nil
+		v.abortClosed = false
+	}
+	v.abortLock.Unlock()
+}
+
+// IsRunning returns true while Run is executing the bytecode. It can be
+// called from any goroutine.
+func (v *VM) IsRunning() bool {
+	return atomic.LoadInt64(&v.running) != 0
 }
 
 // Run starts the execution.
-func (v *VM) Run() error {
+func (v *VM) Run() (err error) {
+	atomic.StoreInt64(&v.running, 1)
+	defer atomic.StoreInt64(&v.running, 0)
+
+	if v.tracer != nil {
+		defer func() {
+			if err != nil {
+				v.tracer.OnError(err)
+			}
+		}()
+	}
+
+	if !v.validated {
+		if err := v.validate(); err != nil {
+			return err
+		}
+		v.validated = true
+	}
+
 	// reset VM states
 	v.sp = 0
 	v.curFrame = &(v.frames[0])
@@ -92,12 +180,86 @@ func (v *VM) Run() error {
 	v.curIPLimit = len(v.curInsts) - 1
 	v.framesIndex = 1
 	v.ip = -1
-	atomic.StoreInt64(&v.aborting, 0)
+	v.err = nil
+	v.numInsts = 0
+	v.allocated = 0
+	v.resetAbort()
+
+	err = v.run(0)
+	if v.profile != nil {
+		v.profile.stop(time.Now())
+	}
+	if err != nil {
+		return v.runtimeError(err)
+	}
 
+	// error in a function called back by Go code
+	if v.err != nil {
+		return v.err
+	}
+
+	// aborted before the end of the main function
+	if atomic.LoadInt64(&v.aborting) != 0 && (v.framesIndex > 1 || v.ip < v.curIPLimit) {
+		return ErrAborted
+	}
+
+	// check if stack still has some objects left
+	if v.sp > 0 && atomic.LoadInt64(&v.aborting) == 0 {
+		return &Error{
+			Message: fmt.Sprintf("non empty stack after execution: %d", v.sp),
+			Err:     ErrVerification,
+		}
+	}
+
+	return nil
+}
+
+// validate checks the bytecode before running it for the first time so the
+// malformed (e.g. decoded from a corrupted file) instructions do not crash
+// the VM.
+func (v *VM) validate() error {
+	bytecode := &compiler.Bytecode{
+		MainFunction: v.frames[0].fn,
+		Constants:    v.constants,
+	}
+
+	if err := bytecode.ValidateGlobals(len(v.globals)); err != nil {
+		return &Error{Message: err.Error(), Err: ErrVerification}
+	}
+
+	return nil
+}
+
+// run executes the instructions until the end of the main function, or,
+// until the function call frame at exitFrameIndex returns.
+func (v *VM) run(exitFrameIndex int) error {
 mainloop:
 	for v.ip < v.curIPLimit && (atomic.LoadInt64(&v.aborting) == 0) {
 		v.ip++
 
+		if v.maxInsts > 0 {
+			v.numInsts++
+			if v.numInsts > v.maxInsts {
+				return ErrInstructionLimit
+			}
+		}
+
+		if v.coverage != nil {
+			v.coverage.hit(v.curFrame.fn, v.fileSet, v.ip)
+		}
+
+		if v.profile != nil {
+			v.profile.hit(v.curFrame.fn, v.fileSet, v.ip)
+		}
+
+		if v.debugger != nil {
+			v.debugger.hit(v)
+		}
+
+		if v.tracer != nil {
+			v.tracer.OnInstruction(v.traceFrame(v.ip))
+		}
+
 		switch v.curInsts[v.ip] {
 		case compiler.OpConstant:
 			cidx := int(v.curInsts[v.ip+2]) | int(v.curInsts[v.ip+1])<<8
@@ -107,7 +269,7 @@ mainloop:
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &v.constants[cidx]
+			v.stack[v.sp] = v.constants[cidx]
 			v.sp++
 
 		case compiler.OpNull:
@@ -115,7 +277,7 @@ mainloop:
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = undefinedPtr
+			v.stack[v.sp] = objects.UndefinedValue
 			v.sp++
 
 		case compiler.OpAdd:
@@ -123,22 +285,46 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Add, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s + %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			// the int and float operands are computed without calling
+			// BinaryOp
+			var res objects.Object
+			var err error
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					if v.intOverflow == IntOverflowWrap {
+						res = objects.NewInt(l.Value + r.Value)
+					} else if res, err = v.intOp(token.Add, l.Value, r.Value); err != nil {
+						filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+						return wrapError(filePos, err)
+					}
 				}
+			} else if l, ok := left.(*objects.Float); ok {
+				if r, ok := right.(*objects.Float); ok {
+					res = &objects.Float{Value: l.Value + r.Value}
+				}
+			}
+
+			if res == nil {
+				res, err = left.BinaryOp(token.Add, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s + %s",
+							left.TypeName(), right.TypeName())
+					}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
+				}
+				if err := v.allocate(res, left, right); err != nil {
+					return err
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpSub:
@@ -146,22 +332,41 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Sub, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s - %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			var err error
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					if v.intOverflow == IntOverflowWrap {
+						res = objects.NewInt(l.Value - r.Value)
+					} else if res, err = v.intOp(token.Sub, l.Value, r.Value); err != nil {
+						filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+						return wrapError(filePos, err)
+					}
+				}
+			} else if l, ok := left.(*objects.Float); ok {
+				if r, ok := right.(*objects.Float); ok {
+					res = &objects.Float{Value: l.Value - r.Value}
 				}
+			}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+			if res == nil {
+				res, err = left.BinaryOp(token.Sub, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s - %s",
+							left.TypeName(), right.TypeName())
+					}
+
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpMul:
@@ -169,22 +374,41 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Mul, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s * %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			var err error
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					if v.intOverflow == IntOverflowWrap {
+						res = objects.NewInt(l.Value * r.Value)
+					} else if res, err = v.intOp(token.Mul, l.Value, r.Value); err != nil {
+						filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+						return wrapError(filePos, err)
+					}
+				}
+			} else if l, ok := left.(*objects.Float); ok {
+				if r, ok := right.(*objects.Float); ok {
+					res = &objects.Float{Value: l.Value * r.Value}
 				}
+			}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+			if res == nil {
+				res, err = left.BinaryOp(token.Mul, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s * %s",
+							left.TypeName(), right.TypeName())
+					}
+
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpDiv:
@@ -192,22 +416,41 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Quo, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s / %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			var err error
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok && r.Value != 0 {
+					if v.intOverflow == IntOverflowWrap {
+						res = objects.NewInt(l.Value / r.Value)
+					} else if res, err = v.intOp(token.Quo, l.Value, r.Value); err != nil {
+						filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+						return wrapError(filePos, err)
+					}
 				}
+			} else if l, ok := left.(*objects.Float); ok {
+				if r, ok := right.(*objects.Float); ok && r.Value != 0 {
+					res = &objects.Float{Value: l.Value / r.Value}
+				}
+			}
+
+			if res == nil {
+				res, err = left.BinaryOp(token.Quo, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s / %s",
+							left.TypeName(), right.TypeName())
+					}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpRem:
@@ -215,22 +458,32 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Rem, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s %% %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok && r.Value != 0 {
+					res = objects.NewInt(l.Value % r.Value)
 				}
+			}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+			if res == nil {
+				var err error
+				res, err = left.BinaryOp(token.Rem, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s %% %s",
+							left.TypeName(), right.TypeName())
+					}
+
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpBAnd:
@@ -238,22 +491,32 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.And, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s & %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					res = objects.NewInt(l.Value & r.Value)
 				}
+			}
+
+			if res == nil {
+				var err error
+				res, err = left.BinaryOp(token.And, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s & %s",
+							left.TypeName(), right.TypeName())
+					}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpBOr:
@@ -261,22 +524,32 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Or, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s | %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					res = objects.NewInt(l.Value | r.Value)
 				}
+			}
+
+			if res == nil {
+				var err error
+				res, err = left.BinaryOp(token.Or, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s | %s",
+							left.TypeName(), right.TypeName())
+					}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpBXor:
@@ -284,22 +557,32 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Xor, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s ^ %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					res = objects.NewInt(l.Value ^ r.Value)
 				}
+			}
+
+			if res == nil {
+				var err error
+				res, err = left.BinaryOp(token.Xor, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s ^ %s",
+							left.TypeName(), right.TypeName())
+					}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpBAndNot:
@@ -307,22 +590,32 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.AndNot, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s &^ %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					res = objects.NewInt(l.Value &^ r.Value)
 				}
+			}
+
+			if res == nil {
+				var err error
+				res, err = left.BinaryOp(token.AndNot, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s &^ %s",
+							left.TypeName(), right.TypeName())
+					}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpBShiftLeft:
@@ -330,22 +623,32 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Shl, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s << %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					res = objects.NewInt(l.Value << uint64(r.Value))
 				}
+			}
+
+			if res == nil {
+				var err error
+				res, err = left.BinaryOp(token.Shl, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s << %s",
+							left.TypeName(), right.TypeName())
+					}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpBShiftRight:
@@ -353,22 +656,32 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Shr, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s >> %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					res = objects.NewInt(l.Value >> uint64(r.Value))
 				}
+			}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+			if res == nil {
+				var err error
+				res, err = left.BinaryOp(token.Shr, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s >> %s",
+							left.TypeName(), right.TypeName())
+					}
+
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpEqual:
@@ -380,10 +693,10 @@ mainloop:
 				return ErrStackOverflow
 			}
 
-			if (*left).Equals(*right) {
-				v.stack[v.sp] = truePtr
+			if left.Equals(right) {
+				v.stack[v.sp] = objects.TrueValue
 			} else {
-				v.stack[v.sp] = falsePtr
+				v.stack[v.sp] = objects.FalseValue
 			}
 			v.sp++
 
@@ -396,10 +709,10 @@ mainloop:
 				return ErrStackOverflow
 			}
 
-			if (*left).Equals(*right) {
-				v.stack[v.sp] = falsePtr
+			if left.Equals(right) {
+				v.stack[v.sp] = objects.FalseValue
 			} else {
-				v.stack[v.sp] = truePtr
+				v.stack[v.sp] = objects.TrueValue
 			}
 			v.sp++
 
@@ -408,22 +721,44 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.Greater, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s > %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					if l.Value > r.Value {
+						res = objects.TrueValue
+					} else {
+						res = objects.FalseValue
+					}
+				}
+			} else if l, ok := left.(*objects.Float); ok {
+				if r, ok := right.(*objects.Float); ok {
+					if l.Value > r.Value {
+						res = objects.TrueValue
+					} else {
+						res = objects.FalseValue
+					}
 				}
+			}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+			if res == nil {
+				var err error
+				res, err = compare(token.Greater, left, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s > %s",
+							left.TypeName(), right.TypeName())
+					}
+
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpGreaterThanEqual:
@@ -431,22 +766,44 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			res, err := (*left).BinaryOp(token.GreaterEq, *right)
-			if err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				if err == objects.ErrInvalidOperator {
-					return fmt.Errorf("%s: invalid operation: %s >= %s",
-						filePos, (*left).TypeName(), (*right).TypeName())
+			var res objects.Object
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					if l.Value >= r.Value {
+						res = objects.TrueValue
+					} else {
+						res = objects.FalseValue
+					}
+				}
+			} else if l, ok := left.(*objects.Float); ok {
+				if r, ok := right.(*objects.Float); ok {
+					if l.Value >= r.Value {
+						res = objects.TrueValue
+					} else {
+						res = objects.FalseValue
+					}
 				}
+			}
+
+			if res == nil {
+				var err error
+				res, err = compare(token.GreaterEq, left, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s >= %s",
+							left.TypeName(), right.TypeName())
+					}
 
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
+				}
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &res
+			v.stack[v.sp] = res
 			v.sp++
 
 		case compiler.OpPop:
@@ -457,7 +814,7 @@ mainloop:
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = truePtr
+			v.stack[v.sp] = objects.TrueValue
 			v.sp++
 
 		case compiler.OpFalse:
@@ -465,7 +822,7 @@ mainloop:
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = falsePtr
+			v.stack[v.sp] = objects.FalseValue
 			v.sp++
 
 		case compiler.OpLNot:
@@ -476,10 +833,10 @@ mainloop:
 				return ErrStackOverflow
 			}
 
-			if (*operand).IsFalsy() {
-				v.stack[v.sp] = truePtr
+			if operand.IsFalsy() {
+				v.stack[v.sp] = objects.TrueValue
 			} else {
-				v.stack[v.sp] = falsePtr
+				v.stack[v.sp] = objects.FalseValue
 			}
 			v.sp++
 
@@ -487,34 +844,41 @@ mainloop:
 			operand := v.stack[v.sp-1]
 			v.sp--
 
-			switch x := (*operand).(type) {
+			switch x := operand.(type) {
 			case *objects.Int:
 				if v.sp >= StackSize {
 					return ErrStackOverflow
 				}
 
-				var res objects.Object = &objects.Int{Value: ^x.Value}
+				var res objects.Object = objects.NewInt(^x.Value)
 
-				v.stack[v.sp] = &res
+				v.stack[v.sp] = res
 				v.sp++
 			default:
 				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				return fmt.Errorf("%s: invalid operation: ^%s", filePos, (*operand).TypeName())
+				return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: ^%s", operand.TypeName())
 			}
 
 		case compiler.OpMinus:
 			operand := v.stack[v.sp-1]
 			v.sp--
 
-			switch x := (*operand).(type) {
+			switch x := operand.(type) {
 			case *objects.Int:
 				if v.sp >= StackSize {
 					return ErrStackOverflow
 				}
 
-				var res objects.Object = &objects.Int{Value: -x.Value}
+				var res objects.Object = objects.NewInt(-x.Value)
+				if v.intOverflow != IntOverflowWrap {
+					var err error
+					if res, err = v.negInt(x.Value); err != nil {
+						filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+						return wrapError(filePos, err)
+					}
+				}
 
-				v.stack[v.sp] = &res
+				v.stack[v.sp] = res
 				v.sp++
 			case *objects.Float:
 				if v.sp >= StackSize {
@@ -523,11 +887,11 @@ mainloop:
 
 				var res objects.Object = &objects.Float{Value: -x.Value}
 
-				v.stack[v.sp] = &res
+				v.stack[v.sp] = res
 				v.sp++
 			default:
 				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				return fmt.Errorf("%s: invalid operation: -%s", filePos, (*operand).TypeName())
+				return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: -%s", operand.TypeName())
 			}
 
 		case compiler.OpJumpFalsy:
@@ -537,7 +901,77 @@ mainloop:
 			condition := v.stack[v.sp-1]
 			v.sp--
 
-			if (*condition).IsFalsy() {
+			if condition.IsFalsy() {
+				v.ip = pos - 1
+			}
+
+		case compiler.OpJumpNotEqual:
+			pos := int(v.curInsts[v.ip+2]) | int(v.curInsts[v.ip+1])<<8
+			v.ip += 2
+
+			right := v.stack[v.sp-1]
+			left := v.stack[v.sp-2]
+			v.sp -= 2
+
+			if !left.Equals(right) {
+				v.ip = pos - 1
+			}
+
+		case compiler.OpJumpEqual:
+			pos := int(v.curInsts[v.ip+2]) | int(v.curInsts[v.ip+1])<<8
+			v.ip += 2
+
+			right := v.stack[v.sp-1]
+			left := v.stack[v.sp-2]
+			v.sp -= 2
+
+			if left.Equals(right) {
+				v.ip = pos - 1
+			}
+
+		case compiler.OpJumpNotGreater, compiler.OpJumpNotGreaterEqual:
+			op := v.curInsts[v.ip]
+			pos := int(v.curInsts[v.ip+2]) | int(v.curInsts[v.ip+1])<<8
+			v.ip += 2
+
+			right := v.stack[v.sp-1]
+			left := v.stack[v.sp-2]
+			v.sp -= 2
+
+			var res bool
+			var fast bool
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					res = l.Value > r.Value || (op == compiler.OpJumpNotGreaterEqual && l.Value == r.Value)
+					fast = true
+				}
+			} else if l, ok := left.(*objects.Float); ok {
+				if r, ok := right.(*objects.Float); ok {
+					res = l.Value > r.Value || (op == compiler.OpJumpNotGreaterEqual && l.Value == r.Value)
+					fast = true
+				}
+			}
+
+			if !fast {
+				tok, sym := token.Greater, ">"
+				if op == compiler.OpJumpNotGreaterEqual {
+					tok, sym = token.GreaterEq, ">="
+				}
+
+				obj, err := compare(tok, left, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-2])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s %s %s",
+							left.TypeName(), sym, right.TypeName())
+					}
+
+					return wrapError(filePos, err)
+				}
+				res = !obj.IsFalsy()
+			}
+
+			if !res {
 				v.ip = pos - 1
 			}
 
@@ -545,7 +979,7 @@ mainloop:
 			pos := int(v.curInsts[v.ip+2]) | int(v.curInsts[v.ip+1])<<8
 			v.ip += 2
 
-			condition := *v.stack[v.sp-1]
+			condition := v.stack[v.sp-1]
 			if condition.IsFalsy() {
 				v.ip = pos - 1
 			} else {
@@ -556,7 +990,7 @@ mainloop:
 			pos := int(v.curInsts[v.ip+2]) | int(v.curInsts[v.ip+1])<<8
 			v.ip += 2
 
-			condition := *v.stack[v.sp-1]
+			condition := v.stack[v.sp-1]
 			if !condition.IsFalsy() {
 				v.ip = pos - 1
 			} else {
@@ -585,9 +1019,9 @@ mainloop:
 			val := v.stack[v.sp-numSelectors-1]
 			v.sp -= numSelectors + 1
 
-			if err := indexAssign(v.globals[globalIndex], val, selectors); err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-3])
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+			if err := v.indexAssign(v.globals[globalIndex], val, selectors); err != nil {
+				err.Pos = v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-3])
+				return err
 			}
 
 		case compiler.OpGetGlobal:
@@ -607,65 +1041,85 @@ mainloop:
 			numElements := int(v.curInsts[v.ip+2]) | int(v.curInsts[v.ip+1])<<8
 			v.ip += 2
 
-			var elements []objects.Object
-			for i := v.sp - numElements; i < v.sp; i++ {
-				elements = append(elements, *v.stack[i])
-			}
+			elements := make([]objects.Object, numElements)
+			copy(elements, v.stack[v.sp-numElements:v.sp])
 			v.sp -= numElements
 
 			var arr objects.Object = &objects.Array{Value: elements}
+			if err := v.allocate(arr); err != nil {
+				return err
+			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &arr
+			v.stack[v.sp] = arr
 			v.sp++
 
 		case compiler.OpMap:
 			numElements := int(v.curInsts[v.ip+2]) | int(v.curInsts[v.ip+1])<<8
 			v.ip += 2
 
-			kv := make(map[string]objects.Object)
+			// the keys are the string constants, so the maps created by
+			// the same literal share the key strings
+			kv := make(map[string]objects.Object, numElements/2)
 			for i := v.sp - numElements; i < v.sp; i += 2 {
-				key := *v.stack[i]
-				value := *v.stack[i+1]
-				kv[key.(*objects.String).Value] = value
+				key, ok := v.stack[i].(*objects.String)
+				if !ok {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-2])
+					return wrapErrorf(filePos, objects.ErrInvalidIndexType, "invalid map key type: %s", v.stack[i].TypeName())
+				}
+				kv[key.Value] = v.stack[i+1]
 			}
 			v.sp -= numElements
 
 			var m objects.Object = &objects.Map{Value: kv}
+			if err := v.allocate(m); err != nil {
+				return err
+			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &m
+			v.stack[v.sp] = m
 			v.sp++
 
 		case compiler.OpError:
 			value := v.stack[v.sp-1]
 
 			var err objects.Object = &objects.Error{
-				Value: *value,
+				Value: value,
+				Stack: v.callStack(),
 			}
 
-			v.stack[v.sp-1] = &err
+			v.stack[v.sp-1] = err
 
 		case compiler.OpImmutable:
 			value := v.stack[v.sp-1]
 
-			switch value := (*value).(type) {
+			switch value := value.(type) {
 			case *objects.Array:
+				if len(value.Value) == 0 {
+					v.stack[v.sp-1] = objects.EmptyImmutableArray
+					break
+				}
+
 				var immutableArray objects.Object = &objects.ImmutableArray{
 					Value: value.Value,
 				}
-				v.stack[v.sp-1] = &immutableArray
+				v.stack[v.sp-1] = immutableArray
 			case *objects.Map:
+				if len(value.Value) == 0 {
+					v.stack[v.sp-1] = objects.EmptyImmutableMap
+					break
+				}
+
 				var immutableMap objects.Object = &objects.ImmutableMap{
 					Value: value.Value,
 				}
-				v.stack[v.sp-1] = &immutableMap
+				v.stack[v.sp-1] = immutableMap
 			}
 
 		case compiler.OpIndex:
@@ -673,46 +1127,63 @@ mainloop:
 			left := v.stack[v.sp-2]
 			v.sp -= 2
 
-			switch left := (*left).(type) {
+			switch left := left.(type) {
 			case objects.Indexable:
-				val, err := left.IndexGet(*index)
+				val, err := left.IndexGet(index)
 				if err != nil {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
 
 					if err == objects.ErrInvalidIndexType {
-						return fmt.Errorf("%s: invalid index type: %s", filePos, (*index).TypeName())
+						return wrapErrorf(filePos, objects.ErrInvalidIndexType, "invalid index type: %s", index.TypeName())
 					}
 
-					return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
 				}
 				if val == nil {
 					val = objects.UndefinedValue
 				}
 
+				if v.strictIndex && val == objects.UndefinedValue {
+					if length, ok := outOfRange(left, index); ok {
+						filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
+						return wrapErrorf(filePos, objects.ErrIndexOutOfBounds, "index out of bounds: %s (length %d)", index, length)
+					}
+				}
+
 				if v.sp >= StackSize {
 					return ErrStackOverflow
 				}
 
-				v.stack[v.sp] = &val
+				v.stack[v.sp] = val
 				v.sp++
 
-			case *objects.Error: // err.value
-				key, ok := (*index).(*objects.String)
-				if !ok || key.Value != "value" {
+			case *objects.Error: // err.value, err.stack
+				key, ok := index.(*objects.String)
+				if !ok || (key.Value != "value" && key.Value != "stack") {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid index on error", filePos)
+					return wrapErrorf(filePos, objects.ErrInvalidIndexType, "invalid index on error")
 				}
 
 				if v.sp >= StackSize {
 					return ErrStackOverflow
 				}
 
-				v.stack[v.sp] = &left.Value
+				if key.Value == "stack" {
+					stack := make([]objects.Object, len(left.Stack))
+					for i, pos := range left.Stack {
+						stack[i] = &objects.String{Value: pos.String()}
+					}
+
+					var val objects.Object = &objects.ImmutableArray{Value: stack}
+					v.stack[v.sp] = val
+				} else {
+					v.stack[v.sp] = left.Value
+				}
 				v.sp++
 
 			default:
 				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				return fmt.Errorf("%s: not indexable: %s", filePos, left.TypeName())
+				return wrapErrorf(filePos, ErrNotIndexable, "not indexable: %s", left.TypeName())
 			}
 
 		case compiler.OpSliceIndex:
@@ -722,31 +1193,31 @@ mainloop:
 			v.sp -= 3
 
 			var lowIdx int64
-			if *low != objects.UndefinedValue {
-				if low, ok := (*low).(*objects.Int); ok {
+			if low != objects.UndefinedValue {
+				if low, ok := low.(*objects.Int); ok {
 					lowIdx = low.Value
 				} else {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid slice index type: %s", filePos, low.TypeName())
+					return wrapErrorf(filePos, objects.ErrInvalidIndexType, "invalid slice index type: %s", low.TypeName())
 				}
 			}
 
-			switch left := (*left).(type) {
+			switch left := left.(type) {
 			case *objects.Array:
 				numElements := int64(len(left.Value))
 				var highIdx int64
-				if *high == objects.UndefinedValue {
+				if high == objects.UndefinedValue {
 					highIdx = numElements
-				} else if high, ok := (*high).(*objects.Int); ok {
+				} else if high, ok := high.(*objects.Int); ok {
 					highIdx = high.Value
 				} else {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid slice index type: %s", filePos, high.TypeName())
+					return wrapErrorf(filePos, objects.ErrInvalidIndexType, "invalid slice index type: %s", high.TypeName())
 				}
 
 				if lowIdx > highIdx {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid slice index: %d > %d", filePos, lowIdx, highIdx)
+					return wrapErrorf(filePos, objects.ErrIndexOutOfBounds, "invalid slice index: %d > %d", lowIdx, highIdx)
 				}
 
 				if lowIdx < 0 {
@@ -766,24 +1237,24 @@ mainloop:
 				}
 
 				var val objects.Object = &objects.Array{Value: left.Value[lowIdx:highIdx]}
-				v.stack[v.sp] = &val
+				v.stack[v.sp] = val
 				v.sp++
 
 			case *objects.ImmutableArray:
 				numElements := int64(len(left.Value))
 				var highIdx int64
-				if *high == objects.UndefinedValue {
+				if high == objects.UndefinedValue {
 					highIdx = numElements
-				} else if high, ok := (*high).(*objects.Int); ok {
+				} else if high, ok := high.(*objects.Int); ok {
 					highIdx = high.Value
 				} else {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid slice index type: %s", filePos, high.TypeName())
+					return wrapErrorf(filePos, objects.ErrInvalidIndexType, "invalid slice index type: %s", high.TypeName())
 				}
 
 				if lowIdx > highIdx {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid slice index: %d > %d", filePos, lowIdx, highIdx)
+					return wrapErrorf(filePos, objects.ErrIndexOutOfBounds, "invalid slice index: %d > %d", lowIdx, highIdx)
 				}
 
 				if lowIdx < 0 {
@@ -804,24 +1275,24 @@ mainloop:
 
 				var val objects.Object = &objects.Array{Value: left.Value[lowIdx:highIdx]}
 
-				v.stack[v.sp] = &val
+				v.stack[v.sp] = val
 				v.sp++
 
 			case *objects.String:
 				numElements := int64(len(left.Value))
 				var highIdx int64
-				if *high == objects.UndefinedValue {
+				if high == objects.UndefinedValue {
 					highIdx = numElements
-				} else if high, ok := (*high).(*objects.Int); ok {
+				} else if high, ok := high.(*objects.Int); ok {
 					highIdx = high.Value
 				} else {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid slice index type: %s", filePos, high.TypeName())
+					return wrapErrorf(filePos, objects.ErrInvalidIndexType, "invalid slice index type: %s", high.TypeName())
 				}
 
 				if lowIdx > highIdx {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid slice index: %d > %d", filePos, lowIdx, highIdx)
+					return wrapErrorf(filePos, objects.ErrIndexOutOfBounds, "invalid slice index: %d > %d", lowIdx, highIdx)
 				}
 
 				if lowIdx < 0 {
@@ -840,26 +1311,26 @@ mainloop:
 					return ErrStackOverflow
 				}
 
-				var val objects.Object = &objects.String{Value: left.Value[lowIdx:highIdx]}
+				val := objects.NewString(left.Value[lowIdx:highIdx])
 
-				v.stack[v.sp] = &val
+				v.stack[v.sp] = val
 				v.sp++
 
 			case *objects.Bytes:
 				numElements := int64(len(left.Value))
 				var highIdx int64
-				if *high == objects.UndefinedValue {
+				if high == objects.UndefinedValue {
 					highIdx = numElements
-				} else if high, ok := (*high).(*objects.Int); ok {
+				} else if high, ok := high.(*objects.Int); ok {
 					highIdx = high.Value
 				} else {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid slice index type: %s", filePos, high.TypeName())
+					return wrapErrorf(filePos, objects.ErrInvalidIndexType, "invalid slice index type: %s", high.TypeName())
 				}
 
 				if lowIdx > highIdx {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-					return fmt.Errorf("%s: invalid slice index: %d > %d", filePos, lowIdx, highIdx)
+					return wrapErrorf(filePos, objects.ErrIndexOutOfBounds, "invalid slice index: %d > %d", lowIdx, highIdx)
 				}
 
 				if lowIdx < 0 {
@@ -880,7 +1351,7 @@ mainloop:
 
 				var val objects.Object = &objects.Bytes{Value: left.Value[lowIdx:highIdx]}
 
-				v.stack[v.sp] = &val
+				v.stack[v.sp] = val
 				v.sp++
 			}
 
@@ -888,28 +1359,29 @@ mainloop:
 			numArgs := int(v.curInsts[v.ip+1])
 			v.ip++
 
-			value := *v.stack[v.sp-1-numArgs]
+			value := v.stack[v.sp-1-numArgs]
 
 			switch callee := value.(type) {
 			case *objects.Closure:
 				if numArgs != callee.Fn.NumParameters {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-1])
-					return fmt.Errorf("%s: wrong number of arguments: want=%d, got=%d",
-						filePos, callee.Fn.NumParameters, numArgs)
-				}
-
-				// test if it's tail-call
-				if callee.Fn == v.curFrame.fn { // recursion
-					nextOp := v.curInsts[v.ip+1]
-					if nextOp == compiler.OpReturnValue ||
-						(nextOp == compiler.OpPop && compiler.OpReturn == v.curInsts[v.ip+2]) {
-						for p := 0; p < numArgs; p++ {
-							v.stack[v.curFrame.basePointer+p] = v.stack[v.sp-numArgs+p]
-						}
-						v.sp -= numArgs + 1
-						v.ip = -1 // reset IP to beginning of the frame
-						continue mainloop
+					return wrapErrorf(filePos, objects.ErrWrongNumArguments, "wrong number of arguments: want=%d, got=%d",
+						callee.Fn.NumParameters, numArgs)
+				}
+
+				if v.tracer != nil {
+					v.tracer.OnCall(v.traceFrame(v.ip-1), callee, v.stack[v.sp-numArgs:v.sp])
+				}
+
+				if v.isTailCall(callee.Fn) {
+					if err := v.tailCall(callee.Fn, callee.Free, numArgs); err != nil {
+						return err
 					}
+					continue mainloop
+				}
+
+				if v.framesIndex >= MaxFrames || v.sp-numArgs+callee.Fn.NumLocals >= StackSize {
+					return ErrStackOverflow
 				}
 
 				// update call frame
@@ -927,22 +1399,23 @@ mainloop:
 			case *objects.CompiledFunction:
 				if numArgs != callee.NumParameters {
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-1])
-					return fmt.Errorf("%s: wrong number of arguments: want=%d, got=%d",
-						filePos, callee.NumParameters, numArgs)
-				}
-
-				// test if it's tail-call
-				if callee == v.curFrame.fn { // recursion
-					nextOp := v.curInsts[v.ip+1]
-					if nextOp == compiler.OpReturnValue ||
-						(nextOp == compiler.OpPop && compiler.OpReturn == v.curInsts[v.ip+2]) {
-						for p := 0; p < numArgs; p++ {
-							v.stack[v.curFrame.basePointer+p] = v.stack[v.sp-numArgs+p]
-						}
-						v.sp -= numArgs + 1
-						v.ip = -1 // reset IP to beginning of the frame
-						continue mainloop
+					return wrapErrorf(filePos, objects.ErrWrongNumArguments, "wrong number of arguments: want=%d, got=%d",
+						callee.NumParameters, numArgs)
+				}
+
+				if v.tracer != nil {
+					v.tracer.OnCall(v.traceFrame(v.ip-1), callee, v.stack[v.sp-numArgs:v.sp])
+				}
+
+				if v.isTailCall(callee) {
+					if err := v.tailCall(callee, nil, numArgs); err != nil {
+						return err
 					}
+					continue mainloop
+				}
+
+				if v.framesIndex >= MaxFrames || v.sp-numArgs+callee.NumLocals >= StackSize {
+					return ErrStackOverflow
 				}
 
 				// update call frame
@@ -957,30 +1430,44 @@ mainloop:
 				v.framesIndex++
 				v.sp = v.sp - numArgs + callee.NumLocals
 
-			case objects.Callable:
-				var args []objects.Object
-				for _, arg := range v.stack[v.sp-numArgs : v.sp] {
-					args = append(args, *arg)
+			case objects.InteropCallable, objects.Callable:
+				args := make([]objects.Object, numArgs)
+				copy(args, v.stack[v.sp-numArgs:v.sp])
+
+				if v.tracer != nil {
+					v.tracer.OnCall(v.traceFrame(v.ip-1), callee, args)
 				}
 
-				ret, err := callee.Call(args...)
+				var ret objects.Object
+				var err error
+				if interopCallee, ok := callee.(objects.InteropCallable); ok {
+					ret, err = interopCallee.InteropCall(v, args...)
+				} else {
+					ret, err = callee.(objects.Callable).Call(args...)
+				}
 				v.sp -= numArgs + 1
 
 				// runtime error
 				if err != nil {
+					// the error occurred in (or stopped) a function called back by the callee:
+					// it's already reported with its own position.
+					if v.err != nil || atomic.LoadInt64(&v.aborting) != 0 {
+						return v.err
+					}
+
 					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-1])
 
 					if err == objects.ErrWrongNumArguments {
-						return fmt.Errorf("%s: wrong number of arguments in call to '%s'",
-							filePos, value.TypeName())
+						return wrapErrorf(filePos, objects.ErrWrongNumArguments, "wrong number of arguments in call to '%s'",
+							value.TypeName())
 					}
 
 					if err, ok := err.(objects.ErrInvalidArgumentType); ok {
-						return fmt.Errorf("%s: invalid type for argument '%s' in call to '%s': expected %s, found %s",
-							filePos, err.Name, value.TypeName(), err.Expected, err.Found)
+						return wrapErrorf(filePos, err, "invalid type for argument '%s' in call to '%s': expected %s, found %s",
+							err.Name, value.TypeName(), err.Expected, err.Found)
 					}
 
-					return fmt.Errorf("%s: %s", filePos, err.Error())
+					return wrapError(filePos, err)
 				}
 
 				// nil return -> undefined
@@ -988,16 +1475,24 @@ mainloop:
 					ret = objects.UndefinedValue
 				}
 
+				if err := v.allocate(ret, args...); err != nil {
+					return err
+				}
+
+				if v.tracer != nil {
+					v.tracer.OnReturn(v.traceFrame(v.ip-1), ret)
+				}
+
 				if v.sp >= StackSize {
 					return ErrStackOverflow
 				}
 
-				v.stack[v.sp] = &ret
+				v.stack[v.sp] = ret
 				v.sp++
 
 			default:
 				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-1])
-				return fmt.Errorf("%s: not callable: %s", filePos, callee.TypeName())
+				return wrapErrorf(filePos, ErrNotCallable, "not callable: %s", callee.TypeName())
 			}
 
 		case compiler.OpReturnValue:
@@ -1022,6 +1517,14 @@ mainloop:
 			v.stack[v.sp-1] = retVal
 			//v.sp++
 
+			if v.tracer != nil {
+				v.tracer.OnReturn(v.callSite(), retVal)
+			}
+
+			if v.framesIndex == exitFrameIndex {
+				return nil
+			}
+
 		case compiler.OpReturn:
 			v.framesIndex--
 			lastFrame := v.frames[v.framesIndex]
@@ -1038,34 +1541,97 @@ mainloop:
 			//	return ErrStackOverflow
 			//}
 
-			v.stack[v.sp-1] = undefinedPtr
+			v.stack[v.sp-1] = objects.UndefinedValue
 			//v.sp++
 
+			if v.tracer != nil {
+				v.tracer.OnReturn(v.callSite(), objects.UndefinedValue)
+			}
+
+			if v.framesIndex == exitFrameIndex {
+				return nil
+			}
+
 		case compiler.OpDefineLocal:
 			localIndex := int(v.curInsts[v.ip+1])
 			v.ip++
 
 			sp := v.curFrame.basePointer + localIndex
 
-			// local variables can be mutated by other actions
-			// so always store the copy of popped value
-			val := *v.stack[v.sp-1]
+			// the closures that captured the previous variable at the same
+			// index keep its free variable
+			v.stack[sp] = v.stack[v.sp-1]
 			v.sp--
 
-			v.stack[sp] = &val
-
 		case compiler.OpSetLocal:
 			localIndex := int(v.curInsts[v.ip+1])
 			v.ip++
 
 			sp := v.curFrame.basePointer + localIndex
 
-			// update pointee of v.stack[sp] instead of replacing the pointer itself.
-			// this is needed because there can be free variables referencing the same local variables.
 			val := v.stack[v.sp-1]
 			v.sp--
 
-			*v.stack[sp] = *val // also use a copy of popped value
+			// update the free variable if the local variable is captured by
+			// the closures
+			if obj, ok := v.stack[sp].(*objects.ObjectPtr); ok {
+				*obj.Value = val
+			} else {
+				v.stack[sp] = val
+			}
+
+		case compiler.OpAddLocalConst:
+			localIndex := int(v.curInsts[v.ip+1])
+			cidx := int(v.curInsts[v.ip+3]) | int(v.curInsts[v.ip+2])<<8
+			v.ip += 3
+
+			sp := v.curFrame.basePointer + localIndex
+
+			left := v.stack[sp]
+			freeVar, captured := left.(*objects.ObjectPtr)
+			if captured {
+				left = *freeVar.Value
+			}
+			right := v.constants[cidx]
+
+			var res objects.Object
+			var err error
+			if l, ok := left.(*objects.Int); ok {
+				if r, ok := right.(*objects.Int); ok {
+					if v.intOverflow == IntOverflowWrap {
+						res = objects.NewInt(l.Value + r.Value)
+					} else if res, err = v.intOp(token.Add, l.Value, r.Value); err != nil {
+						filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-3])
+						return wrapError(filePos, err)
+					}
+				}
+			} else if l, ok := left.(*objects.Float); ok {
+				if r, ok := right.(*objects.Float); ok {
+					res = &objects.Float{Value: l.Value + r.Value}
+				}
+			}
+
+			if res == nil {
+				res, err = left.BinaryOp(token.Add, right)
+				if err != nil {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-3])
+					if err == objects.ErrInvalidOperator {
+						return wrapErrorf(filePos, objects.ErrInvalidOperator, "invalid operation: %s + %s",
+							left.TypeName(), right.TypeName())
+					}
+
+					return wrapError(filePos, err)
+				}
+				if err := v.allocate(res, left, right); err != nil {
+					return err
+				}
+			}
+
+			if captured {
+				*freeVar.Value = res
+			} else {
+				v.stack[sp] = res
+			}
 
 		case compiler.OpSetSelLocal:
 			localIndex := int(v.curInsts[v.ip+1])
@@ -1077,11 +1643,14 @@ mainloop:
 			val := v.stack[v.sp-numSelectors-1]
 			v.sp -= numSelectors + 1
 
-			sp := v.curFrame.basePointer + localIndex
+			dst := v.stack[v.curFrame.basePointer+localIndex]
+			if obj, ok := dst.(*objects.ObjectPtr); ok {
+				dst = *obj.Value
+			}
 
-			if err := indexAssign(v.stack[sp], val, selectors); err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-2])
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+			if err := v.indexAssign(dst, val, selectors); err != nil {
+				err.Pos = v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-2])
+				return err
 			}
 
 		case compiler.OpGetLocal:
@@ -1089,6 +1658,9 @@ mainloop:
 			v.ip++
 
 			val := v.stack[v.curFrame.basePointer+localIndex]
+			if obj, ok := val.(*objects.ObjectPtr); ok {
+				val = *obj.Value
+			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
@@ -1097,6 +1669,28 @@ mainloop:
 			v.stack[v.sp] = val
 			v.sp++
 
+		case compiler.OpGetLocalPtr:
+			localIndex := int(v.curInsts[v.ip+1])
+			v.ip++
+
+			sp := v.curFrame.basePointer + localIndex
+
+			// replace the local variable with the free variable that is
+			// shared by the function and the closures
+			freeVar, ok := v.stack[sp].(*objects.ObjectPtr)
+			if !ok {
+				val := v.stack[sp]
+				freeVar = &objects.ObjectPtr{Value: &val}
+				v.stack[sp] = freeVar
+			}
+
+			if v.sp >= StackSize {
+				return ErrStackOverflow
+			}
+
+			v.stack[v.sp] = freeVar
+			v.sp++
+
 		case compiler.OpGetBuiltin:
 			builtinIndex := int(v.curInsts[v.ip+1])
 			v.ip++
@@ -1105,26 +1699,30 @@ mainloop:
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &builtinFuncs[builtinIndex]
+			v.stack[v.sp] = builtinFuncs[builtinIndex]
 			v.sp++
 
 		case compiler.OpGetBuiltinModule:
 			val := v.stack[v.sp-1]
 			v.sp--
 
-			moduleName := (*val).(*objects.String).Value
+			moduleName := val.(*objects.String).Value
 
 			module, ok := v.builtinModules[moduleName]
 			if !ok {
 				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-3])
-				return fmt.Errorf("%s: module '%s' not found", filePos, moduleName)
+				return newError(filePos, "module '%s' not found", moduleName)
 			}
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = module
+			if lazy, ok := (*module).(*objects.BuiltinModule); ok {
+				v.stack[v.sp] = lazy.Module()
+			} else {
+				v.stack[v.sp] = *module
+			}
 			v.sp++
 
 		case compiler.OpClosure:
@@ -1135,12 +1733,17 @@ mainloop:
 			fn, ok := v.constants[constIndex].(*objects.CompiledFunction)
 			if !ok {
 				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-3])
-				return fmt.Errorf("%s: not function: %s", filePos, fn.TypeName())
+				return newError(filePos, "not function: %s", fn.TypeName())
 			}
 
-			free := make([]*objects.Object, numFree)
+			free := make([]*objects.ObjectPtr, numFree)
 			for i := 0; i < numFree; i++ {
-				free[i] = v.stack[v.sp-numFree+i]
+				freeVar, ok := v.stack[v.sp-numFree+i].(*objects.ObjectPtr)
+				if !ok {
+					filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-3])
+					return newError(filePos, "not free variable: %s", v.stack[v.sp-numFree+i].TypeName())
+				}
+				free[i] = freeVar
 			}
 			v.sp -= numFree
 
@@ -1153,14 +1756,14 @@ mainloop:
 				Free: free,
 			}
 
-			v.stack[v.sp] = &cl
+			v.stack[v.sp] = cl
 			v.sp++
 
 		case compiler.OpGetFree:
 			freeIndex := int(v.curInsts[v.ip+1])
 			v.ip++
 
-			val := v.curFrame.freeVars[freeIndex]
+			val := *v.curFrame.freeVars[freeIndex].Value
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
@@ -1169,6 +1772,17 @@ mainloop:
 			v.stack[v.sp] = val
 			v.sp++
 
+		case compiler.OpGetFreePtr:
+			freeIndex := int(v.curInsts[v.ip+1])
+			v.ip++
+
+			if v.sp >= StackSize {
+				return ErrStackOverflow
+			}
+
+			v.stack[v.sp] = v.curFrame.freeVars[freeIndex]
+			v.sp++
+
 		case compiler.OpSetSelFree:
 			freeIndex := int(v.curInsts[v.ip+1])
 			numSelectors := int(v.curInsts[v.ip+2])
@@ -1179,9 +1793,9 @@ mainloop:
 			val := v.stack[v.sp-numSelectors-1]
 			v.sp -= numSelectors + 1
 
-			if err := indexAssign(v.curFrame.freeVars[freeIndex], val, selectors); err != nil {
-				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-2])
-				return fmt.Errorf("%s: %s", filePos, err.Error())
+			if err := v.indexAssign(*v.curFrame.freeVars[freeIndex].Value, val, selectors); err != nil {
+				err.Pos = v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip-2])
+				return err
 			}
 
 		case compiler.OpSetFree:
@@ -1191,7 +1805,7 @@ mainloop:
 			val := v.stack[v.sp-1]
 			v.sp--
 
-			*v.curFrame.freeVars[freeIndex] = *val
+			*v.curFrame.freeVars[freeIndex].Value = val
 
 		case compiler.OpIteratorInit:
 			var iterator objects.Object
@@ -1199,10 +1813,10 @@ mainloop:
 			dst := v.stack[v.sp-1]
 			v.sp--
 
-			iterable, ok := (*dst).(objects.Iterable)
+			iterable, ok := dst.(objects.Iterable)
 			if !ok {
 				filePos := v.fileSet.Position(v.curFrame.fn.SourceMap[v.ip])
-				return fmt.Errorf("%s: not iterable: %s", filePos, (*dst).TypeName())
+				return wrapErrorf(filePos, ErrNotIterable, "not iterable: %s", dst.TypeName())
 			}
 
 			iterator = iterable.Iterate()
@@ -1211,23 +1825,23 @@ mainloop:
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &iterator
+			v.stack[v.sp] = iterator
 			v.sp++
 
 		case compiler.OpIteratorNext:
 			iterator := v.stack[v.sp-1]
 			v.sp--
 
-			hasMore := (*iterator).(objects.Iterator).Next()
+			hasMore := iterator.(objects.Iterator).Next()
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
 			if hasMore {
-				v.stack[v.sp] = truePtr
+				v.stack[v.sp] = objects.TrueValue
 			} else {
-				v.stack[v.sp] = falsePtr
+				v.stack[v.sp] = objects.FalseValue
 			}
 			v.sp++
 
@@ -1235,91 +1849,574 @@ mainloop:
 			iterator := v.stack[v.sp-1]
 			v.sp--
 
-			val := (*iterator).(objects.Iterator).Key()
+			val := iterator.(objects.Iterator).Key()
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &val
+			v.stack[v.sp] = val
 			v.sp++
 
 		case compiler.OpIteratorValue:
 			iterator := v.stack[v.sp-1]
 			v.sp--
 
-			val := (*iterator).(objects.Iterator).Value()
+			val := iterator.(objects.Iterator).Value()
 
 			if v.sp >= StackSize {
 				return ErrStackOverflow
 			}
 
-			v.stack[v.sp] = &val
+			v.stack[v.sp] = val
 			v.sp++
 
 		default:
-			panic(fmt.Errorf("unknown opcode: %d", v.curInsts[v.ip]))
+			filePos := v.fileSet.Position(instructionPos(v.curFrame.fn, v.ip))
+			return wrapErrorf(filePos, ErrVerification, "unknown opcode: %d", v.curInsts[v.ip])
 		}
 	}
 
-	// check if stack still has some objects left
-	if v.sp > 0 && atomic.LoadInt64(&v.aborting) == 0 {
-		panic(fmt.Errorf("non empty stack after execution: %d", v.sp))
+	return nil
+}
+
+// Call calls the callable object fn with the given arguments and returns its
+// result. Compiled functions and closures are executed on top of the current
+// call frames, so Go functions invoked by the VM (e.g. InteropCallable
+// objects) can call back script functions. A run-time error in fn stops the
+// execution of the VM: Call returns the error, and Run returns the same error.
+// Call is not safe for concurrent use.
+func (v *VM) Call(fn objects.Object, args ...objects.Object) (objects.Object, error) {
+	var callee *objects.CompiledFunction
+	var freeVars []*objects.ObjectPtr
+
+	switch fn := fn.(type) {
+	case *objects.Closure:
+		callee = fn.Fn
+		freeVars = fn.Free
+	case *objects.CompiledFunction:
+		callee = fn
+	case objects.InteropCallable:
+		return fn.InteropCall(v, args...)
+	case objects.Callable:
+		return fn.Call(args...)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotCallable, fn.TypeName())
+	}
+
+	if atomic.LoadInt64(&v.aborting) != 0 {
+		return nil, ErrAborted
+	}
+
+	numArgs := len(args)
+	if numArgs != callee.NumParameters {
+		return nil, fmt.Errorf("%w: want=%d, got=%d",
+			objects.ErrWrongNumArguments, callee.NumParameters, numArgs)
+	}
+
+	if v.sp+1+callee.NumLocals >= StackSize || v.framesIndex >= MaxFrames {
+		v.err = v.runtimeError(ErrStackOverflow)
+		v.Abort()
+
+		return nil, v.err
+	}
+
+	if v.tracer != nil {
+		v.tracer.OnCall(v.callSite(), fn, args)
+	}
+
+	// push the function and the arguments
+	v.stack[v.sp] = fn
+	v.sp++
+	for _, arg := range args {
+		v.stack[v.sp] = arg
+		v.sp++
+	}
+
+	// update call frame
+	exitFrameIndex := v.framesIndex
+	v.curFrame.ip = v.ip // store current ip before call
+	v.curFrame = &(v.frames[v.framesIndex])
+	v.curFrame.fn = callee
+	v.curFrame.freeVars = freeVars
+	v.curFrame.basePointer = v.sp - numArgs
+	v.curInsts = callee.Instructions
+	v.ip = -1
+	v.curIPLimit = len(v.curInsts) - 1
+	v.framesIndex++
+	v.sp = v.sp - numArgs + callee.NumLocals
+
+	if err := v.run(exitFrameIndex); err != nil {
+		v.err = v.runtimeError(err)
+		v.Abort()
+
+		return nil, v.err
+	}
+
+	if v.framesIndex != exitFrameIndex { // aborted before the function returns
+		return nil, ErrAborted
+	}
+
+	// the return value replaced the function on the stack
+	ret := v.stack[v.sp-1]
+	v.sp--
+
+	return ret, nil
+}
+
+// RunFunction calls fn like Call, but, as a run of its own, so the functions
+// of a script can be called from Go repeatedly after Run completes: the
+// abort and the error of the previous run are cleared, the instruction limit
+// applies to each call, and, the VM is restored to the state before the call
+// if fn fails. RunFunction is the same as Call if it's called while the VM is
+// running (e.g. from a Go function called by the script). RunFunction is not
+// safe for concurrent use.
+func (v *VM) RunFunction(fn objects.Object, args ...objects.Object) (ret objects.Object, err error) {
+	if !atomic.CompareAndSwapInt64(&v.running, 0, 1) {
+		return v.Call(fn, args...)
+	}
+	defer atomic.StoreInt64(&v.running, 0)
+
+	if v.tracer != nil {
+		defer func() {
+			if err != nil {
+				v.tracer.OnError(err)
+			}
+		}()
+	}
+
+	if !v.validated {
+		if err := v.validate(); err != nil {
+			return nil, err
+		}
+		v.validated = true
+	}
+
+	v.resetAbort()
+	v.err = nil
+	v.numInsts = 0
+	v.allocated = 0
+
+	sp, framesIndex := v.sp, v.framesIndex
+	ret, err = v.Call(fn, args...)
+	if v.profile != nil {
+		v.profile.stop(time.Now())
+	}
+	if err != nil {
+		v.sp = sp
+		v.framesIndex = framesIndex
+		v.curFrame = &v.frames[framesIndex-1]
+		v.curInsts = v.curFrame.fn.Instructions
+		v.curIPLimit = len(v.curInsts) - 1
+		v.ip = v.curFrame.ip
+	}
+
+	return ret, err
+}
+
+// SetCoverage sets the Coverage that records the instructions executed by the
+// VM. Set nil to stop recording.
+func (v *VM) SetCoverage(coverage *Coverage) {
+	if coverage != nil {
+		coverage.add(v.frames[0].fn, v.constants, v.fileSet)
+	}
+
+	v.coverage = coverage
+}
+
+// SetProfile sets the Profile that records the instructions executed by the
+// VM and the time spent on them. Set nil to stop recording.
+func (v *VM) SetProfile(profile *Profile) {
+	if profile != nil {
+		profile.function(v.frames[0].fn, v.fileSet).name = "(main)"
+	}
+
+	v.profile = profile
+}
+
+// SetDebugger sets the Debugger that stops the VM at the breakpoints and
+// after the steps. Set nil to stop debugging.
+func (v *VM) SetDebugger(debugger *Debugger) {
+	v.debugger = debugger
+}
+
+// SetMaxInstructions sets the maximum number of the instructions that Run
+// executes, including the instructions of the functions called back by Go
+// code. Run returns ErrInstructionLimit when the limit is exceeded. Set 0 to
+// remove the limit.
+func (v *VM) SetMaxInstructions(n int64) {
+	v.maxInsts = n
+}
+
+// SetMaxMemory sets the maximum number of the bytes that Run allocates for
+// the values created by the script: the array and map literals, the results
+// of + operator on strings, bytes and arrays, and, the values returned by the
+// builtin and Go functions, measured by objects.SizeOf. The size counts all
+// the values created during the run, not only those still in use, and, each
+// VM counts its own values, so the VMs running concurrently have separate
+// limits. Run returns ErrMemoryLimit when the limit is exceeded. Set 0 to
+// remove the limit.
+func (v *VM) SetMaxMemory(bytes uint64) {
+	v.maxMemory = bytes
+}
+
+// allocate counts the size of the value created from the operands against
+// the memory limit. A new array or map shares the elements of its operands
+// (e.g. the result of + operator or append), so only the elements it adds to
+// the largest operand of the same type are counted, and, the operands
+// returned as they are (e.g. by push) are not counted.
+func (v *VM) allocate(res objects.Object, operands ...objects.Object) error {
+	if v.maxMemory == 0 {
+		return nil
+	}
+
+	kind := containerKind(res)
+	size := objects.ShallowSizeOf(res)
+	var shared int64
+	for _, o := range operands {
+		if sameObject(o, res) {
+			return nil
+		}
+		if kind != 0 && containerKind(o) == kind {
+			if s := objects.ShallowSizeOf(o); s > shared {
+				shared = s
+			}
+		}
+	}
+	size -= shared
+
+	if size > 0 {
+		v.allocated += uint64(size)
+		if v.allocated > v.maxMemory {
+			return ErrMemoryLimit
+		}
 	}
 
 	return nil
 }
 
+// sameObject returns true if the objects are the same pointer. The other
+// types are not compared, as they may not be comparable (e.g. a struct with
+// a slice field).
+func sameObject(a, b objects.Object) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+
+	return va.Kind() == reflect.Ptr && vb.Kind() == reflect.Ptr &&
+		va.Type() == vb.Type() && va.Pointer() == vb.Pointer()
+}
+
+// containerKind returns 1 for the arrays, 2 for the maps, and, 0 for the
+// other objects.
+func containerKind(o objects.Object) int {
+	switch o.(type) {
+	case *objects.Array, *objects.ImmutableArray:
+		return 1
+	case *objects.Map, *objects.ImmutableMap:
+		return 2
+	}
+
+	return 0
+}
+
 // Globals returns the global variables.
-func (v *VM) Globals() []*objects.Object {
+func (v *VM) Globals() []objects.Object {
 	return v.globals
 }
 
+// FilePos returns the file position of the source position (e.g. the
+// position of a compiled function) in the bytecode being executed.
+func (v *VM) FilePos(pos source.Pos) source.FilePos {
+	return v.fileSet.Position(pos)
+}
+
 // FrameInfo returns the current function call frame information.
 func (v *VM) FrameInfo() (frameIndex, ip int) {
 	return v.framesIndex - 1, v.ip
 }
 
-func indexAssign(dst, src *objects.Object, selectors []*objects.Object) error {
+// callStack returns the source positions of the current instruction and the
+// function calls in the active call frames, innermost first.
+func (v *VM) callStack() []source.FilePos {
+	stack := []source.FilePos{v.fileSet.Position(instructionPos(v.curFrame.fn, v.ip))}
+	for i := v.framesIndex - 2; i >= 0; i-- {
+		frame := &v.frames[i]
+		stack = append(stack, v.fileSet.Position(instructionPos(frame.fn, frame.ip)))
+	}
+
+	return stack
+}
+
+// runtimeError converts the error returned from the main loop into an *Error
+// with the opcode of the current instruction and the call frames. The limit
+// errors are returned as they are.
+func (v *VM) runtimeError(err error) error {
+	if err == ErrInstructionLimit || err == ErrMemoryLimit {
+		return err
+	}
+
+	e, ok := err.(*Error)
+	if !ok {
+		e = &Error{
+			Pos:     v.fileSet.Position(instructionPos(v.curFrame.fn, v.ip)),
+			Message: err.Error(),
+			Err:     err,
+		}
+		if err == ErrStackOverflow {
+			e.Message = v.stackOverflowMessage()
+		}
+	}
+	if e.Frames != nil {
+		// already converted in a function called back by Go code
+		return e
+	}
+
+	e.Opcode = instructionOpcode(v.curInsts, v.ip)
+	e.Frames = v.callStack()
+
+	return e
+}
+
+// maxOverflowCalls is the number of the innermost calls described in the
+// stack overflow errors.
+const maxOverflowCalls = 5
+
+// stackOverflowMessage describes the innermost function calls of the call
+// stack with the function names and the positions. The consecutive calls of
+// the same function (e.g. a runaway recursion) are described once with the
+// number of the calls.
+func (v *VM) stackOverflowMessage() string {
+	var calls []string
+	var lastFn *objects.CompiledFunction
+	count := 0
+	flush := func() {
+		if count > 1 {
+			calls[len(calls)-1] += fmt.Sprintf(" (%d calls)", count)
+		}
+	}
+
+	for i := v.framesIndex - 1; i >= 0; i-- {
+		frame := &v.frames[i]
+		ip := frame.ip
+		if i == v.framesIndex-1 {
+			ip = v.ip
+		}
+
+		if frame.fn == lastFn {
+			count++
+			continue
+		}
+		flush()
+		if len(calls) == maxOverflowCalls {
+			calls = append(calls, "...")
+			count = 0
+			break
+		}
+
+		name := "(main)"
+		if i > 0 {
+			name = funcName(frame.fn, v.fileSet, i)
+		}
+		calls = append(calls, fmt.Sprintf("%s at %s", name, v.fileSet.Position(instructionPos(frame.fn, ip))))
+		lastFn, count = frame.fn, 1
+	}
+	flush()
+
+	return fmt.Sprintf("stack overflow at call depth %d: %s", v.framesIndex, strings.Join(calls, ", "))
+}
+
+// instructionOpcode returns the opcode of the instruction at ip (which can be
+// pointing at one of the operands of the instruction).
+func instructionOpcode(insts []byte, ip int) compiler.Opcode {
+	var op compiler.Opcode
+	for i := 0; i <= ip && i < len(insts); {
+		op = insts[i]
+		if int(op) >= len(compiler.OpcodeOperands) {
+			break
+		}
+		for _, w := range compiler.OpcodeOperands[op] {
+			i += w
+		}
+		i++
+	}
+
+	return op
+}
+
+// instructionPos returns the source position of the instruction at ip
+// (which can be pointing at one of the operands of the instruction).
+func instructionPos(fn *objects.CompiledFunction, ip int) source.Pos {
+	for ; ip >= 0; ip-- {
+		if pos, ok := fn.SourceMap[ip]; ok {
+			return pos
+		}
+	}
+
+	return source.NoPos
+}
+
+// isTailCall returns true if the result of the call of the function at ip
+// is returned right away by the current function, so the call can reuse
+// the current frame. The result of a recursive call that is discarded
+// before returning from the function is also treated as a tail call.
+func (v *VM) isTailCall(fn *objects.CompiledFunction) bool {
+	if v.framesIndex <= 1 { // main function
+		return false
+	}
+
+	nextOp := v.curInsts[v.ip+1]
+	if nextOp == compiler.OpReturnValue {
+		return true
+	}
+
+	return fn == v.curFrame.fn && nextOp == compiler.OpPop && v.curInsts[v.ip+2] == compiler.OpReturn
+}
+
+// tailCall replaces the current frame with the frame of the function, so
+// the tail calls (including the mutual recursion) do not grow the call
+// stack. The arguments are on top of the stack.
+func (v *VM) tailCall(fn *objects.CompiledFunction, freeVars []*objects.ObjectPtr, numArgs int) error {
+	basePointer := v.curFrame.basePointer
+	if basePointer+fn.NumLocals >= StackSize {
+		return ErrStackOverflow
+	}
+
+	copy(v.stack[basePointer:], v.stack[v.sp-numArgs:v.sp])
+	for i := basePointer + numArgs; i < basePointer+fn.NumLocals; i++ {
+		v.stack[i] = nil
+	}
+
+	v.curFrame.fn = fn
+	v.curFrame.freeVars = freeVars
+	v.curInsts = fn.Instructions
+	v.curIPLimit = len(v.curInsts) - 1
+	v.ip = -1 // reset IP to beginning of the frame
+	v.sp = basePointer + fn.NumLocals
+
+	return nil
+}
+
+// indexAssign assigns src to the element of dst. The position of the
+// returned error is set by the caller.
+func (v *VM) indexAssign(dst, src objects.Object, selectors []objects.Object) *Error {
 	numSel := len(selectors)
 
 	for sidx := numSel - 1; sidx > 0; sidx-- {
-		indexable, ok := (*dst).(objects.Indexable)
+		indexable, ok := dst.(objects.Indexable)
 		if !ok {
-			return fmt.Errorf("not indexable: %s", (*dst).TypeName())
+			return &Error{
+				Message: fmt.Sprintf("not indexable: %s", dst.TypeName()),
+				Err:     ErrNotIndexable,
+			}
 		}
 
-		next, err := indexable.IndexGet(*selectors[sidx])
+		next, err := indexable.IndexGet(selectors[sidx])
 		if err != nil {
 			if err == objects.ErrInvalidIndexType {
-				return fmt.Errorf("invalid index type: %s", (*selectors[sidx]).TypeName())
+				return &Error{
+					Message: fmt.Sprintf("invalid index type: %s", selectors[sidx].TypeName()),
+					Err:     err,
+				}
 			}
 
-			return err
+			return &Error{Message: err.Error(), Err: err}
 		}
 
-		dst = &next
+		dst = next
 	}
 
-	indexAssignable, ok := (*dst).(objects.IndexAssignable)
+	indexAssignable, ok := dst.(objects.IndexAssignable)
 	if !ok {
-		return fmt.Errorf("not index-assignable: %s", (*dst).TypeName())
+		return &Error{
+			Message: fmt.Sprintf("not index-assignable: %s", dst.TypeName()),
+			Err:     ErrNotIndexable,
+		}
 	}
 
-	if err := indexAssignable.IndexSet(*selectors[0], *src); err != nil {
+	index := selectors[0]
+	if key, ok := index.(*objects.String); ok {
+		if _, ok := dst.(*objects.Map); ok {
+			index = v.internKey(key)
+		}
+	}
+
+	if err := indexAssignable.IndexSet(index, src); err != nil {
+		if err == objects.ErrInvalidIndexType {
+			return &Error{
+				Message: fmt.Sprintf("invalid index type: %s", selectors[0].TypeName()),
+				Err:     err,
+			}
+		}
 		if err == objects.ErrInvalidIndexValueType {
-			return fmt.Errorf("invaid index value type: %s", (*src).TypeName())
+			return &Error{
+				Message: fmt.Sprintf("invaid index value type: %s", src.TypeName()),
+				Err:     err,
+			}
 		}
 
-		return err
+		return &Error{Message: err.Error(), Err: err}
 	}
 
 	return nil
 }
 
+// internKey returns the string constant equal to the map key if any. The
+// keys created at runtime (e.g. by concatenation) then share the memory of
+// the constants, and, the lookups with the constants compare the same
+// strings.
+func (v *VM) internKey(key *objects.String) *objects.String {
+	if v.keys == nil {
+		v.keys = make(map[string]*objects.String)
+		for _, c := range v.constants {
+			if s, ok := c.(*objects.String); ok {
+				v.keys[s.Value] = s
+			}
+		}
+	}
+
+	if s, ok := v.keys[key.Value]; ok {
+		return s
+	}
+
+	return key
+}
+
+// compare evaluates a relational operator (> or >=). If either operand
+// implements objects.Comparable, its Compare method decides the result;
+// otherwise the operator is delegated to the left operand's BinaryOp.
+func compare(op token.Token, left, right objects.Object) (objects.Object, error) {
+	var c int
+	var err error
+	if cmp, ok := left.(objects.Comparable); ok {
+		c, err = cmp.Compare(right)
+	} else if cmp, ok := right.(objects.Comparable); ok {
+		c, err = cmp.Compare(left)
+		c = -c
+	} else {
+		return left.BinaryOp(op, right)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if (op == token.Greater && c > 0) || (op == token.GreaterEq && c >= 0) {
+		return objects.TrueValue, nil
+	}
+
+	return objects.FalseValue, nil
+}
+
 func init() {
 	builtinFuncs = make([]objects.Object, len(objects.Builtins))
 	for i, b := range objects.Builtins {
+		if b.Interop != nil {
+			builtinFuncs[i] = &objects.InteropFunction{
+				Name:  b.Name,
+				Value: b.Interop,
+			}
+			continue
+		}
+
 		builtinFuncs[i] = &objects.BuiltinFunction{
 			Name:  b.Name,
 			Value: b.Func,
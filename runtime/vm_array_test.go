@@ -52,4 +52,11 @@ func TestArray(t *testing.T) {
 	expectError(t, fmt.Sprintf("%s[%d:]", arrStr, arrLen+1), "invalid slice index")
 	expectError(t, fmt.Sprintf("%s[%d:%d]", arrStr, 0, -1), "invalid slice index")
 	expectError(t, fmt.Sprintf("%s[%d:%d]", arrStr, 2, 1), "invalid slice index")
+
+	// self-referencing arrays
+	expect(t, `a := [1, 2]; a[1] = a; out = string(a)`, "[1, [...]]")
+	expect(t, `a := [1, 2]; a[1] = a; out = sprintf("%v", a)`, "[1 [...]]")
+	expect(t, `a := [1, 2]; a[1] = a; b := copy(a); out = [b[1] == b, b == a]`, ARR{true, true})
+	expect(t, `a := [1, 2]; a[1] = {x: a}; out = is_error(to_json(a))`, true)
+	expect(t, `a := [1, 2]; b := [a, a]; out = string(to_json(b))`, "[[1,2],[1,2]]")
 }
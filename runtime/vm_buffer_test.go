@@ -0,0 +1,24 @@
+package runtime_test
+
+import (
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	expect(t, `out = buffer().string()`, "")
+	expect(t, `out = buffer("foo").bytes()`, []byte("foo"))
+	expect(t, `b := buffer(bytes("foo")); b.append("bar", bytes("baz"), '!', 63); out = b.string()`, "foobarbaz!?")
+	expect(t, `out = buffer().append("a").append("b").string()`, "ab")
+	expect(t, `b := buffer(); out = [b.write_string("hello"), b.len(), len(b)]`, ARR{5, 5, 5})
+	expect(t, `b := buffer("hello"); b.truncate(2); out = string(b)`, "he")
+	expect(t, `b := buffer("hello"); b.reset(); out = [b.len(), is_error(b), bool(b)]`, ARR{0, false, false})
+	expect(t, `b := buffer("foo"); v := bytes(b); b.append("bar"); out = v`, []byte("foo"))
+	expect(t, `b := buffer("foo"); c := copy(b); c.append("bar"); out = b.string()`, "foo")
+	expect(t, `out = buffer("foo") == buffer("foo")`, true)
+	expect(t, `b := buffer(); for i := 0; i < 3; i++ { b.append(i + 48) }; out = b.string()`, "012")
+
+	expectError(t, `buffer(1)`, "invalid type for argument 'first'")
+	expectError(t, `buffer().append([])`, "invalid type for argument 'args[0]'")
+	expectError(t, `buffer("foo").truncate(4)`, "index out of bounds")
+	expectError(t, `buffer().write_string(1)`, "invalid type for argument 'first'")
+}
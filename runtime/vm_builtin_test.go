@@ -24,6 +24,25 @@ func TestBuiltinFunction(t *testing.T) {
 	expect(t, `out = copy(1)`, 1)
 	expectError(t, `copy(1, 2)`, "wrong number of arguments")
 
+	expect(t, `out = clone(1)`, 1)
+	expect(t, `out = clone([1, [2, 3], {a: 4}])`, ARR{1, ARR{2, 3}, MAP{"a": 4}})
+	expect(t, `out = clone(immutable({a: [1]}))`, MAP{"a": ARR{1}})
+	expect(t, `a := [1, 2]; b := clone(a); b[0] = 3; out = a[0]`, 1)
+	expect(t, `a := {x: [1]}; b := clone(a); b.x[0] = 3; out = a.x[0]`, 1)
+	expect(t, `out = func() { a := {x: 1}; a.self = a; b := clone(a); b.x = 2; return [a.x, b.self.x, a.self.x] }()`, ARR{1, 2, 1})
+	expect(t, `s := [1]; a := [s, s]; b := clone(a); b[0][0] = 2; out = b[1][0]`, 2)
+	expectError(t, `clone(1, 2)`, "wrong number of arguments")
+
+	expect(t, `out = equal(1, 1)`, true)
+	expect(t, `out = equal(1, "1")`, false)
+	expect(t, `out = equal([1, {a: [2]}], [1, {a: [2]}])`, true)
+	expect(t, `out = equal([1, {a: [2]}], [1, {a: [3]}])`, false)
+	expect(t, `out = equal({a: 1}, immutable({a: 1}))`, true)
+	expect(t, `out = func() { a := {x: 1}; a.self = a; b := {x: 1}; b.self = b; return equal(a, b) }()`, true)
+	expect(t, `out = func() { a := {x: 1}; a.self = a; b := {x: 2}; b.self = b; return equal(a, b) }()`, false)
+	expect(t, `out = func() { a := {x: 1}; a.self = a; b := clone(a); return a == b }()`, true)
+	expectError(t, `equal(1)`, "wrong number of arguments")
+
 	expect(t, `out = append([1, 2, 3], 4)`, ARR{1, 2, 3, 4})
 	expect(t, `out = append([1, 2, 3], 4, 5, 6)`, ARR{1, 2, 3, 4, 5, 6})
 	expect(t, `out = append([1, 2, 3], "foo", false)`, ARR{1, 2, 3, "foo", false})
@@ -151,6 +170,13 @@ func TestBuiltinFunction(t *testing.T) {
 	expect(t, `out = from_json("5")`, 5.0)
 	expect(t, `out = from_json("[\"bar\",1,1.8,56,true]")`, ARR{"bar", 1.0, 1.8, 56.0, true})
 
+	// json marshaler/unmarshaler
+	expect(t, `times := import("times"); out = to_json(times.to_utc(times.unix(1546441445, 0)))`, []byte(`"2019-01-02T15:04:05Z"`))
+	expect(t, `times := import("times"); out = to_json({t: times.to_utc(times.unix(1546441445, 500000000))})`, []byte(`{"t":"2019-01-02T15:04:05.5Z"}`))
+	expect(t, `times := import("times"); out = times.time_unix(from_json("\"2019-01-02T15:04:05Z\"", times.now()))`, 1546441445)
+	expect(t, `times := import("times"); out = is_error(from_json("\"foo\"", times.now()))`, true)
+	expectError(t, `from_json("5", 1)`, "invalid type for argument 'second'")
+
 	// sprintf
 	expect(t, `out = sprintf("")`, "")
 	expect(t, `out = sprintf("foo")`, "foo")
@@ -193,3 +219,104 @@ func TestBuiltinFunction(t *testing.T) {
 	expect(t, `a := func(x) { return func() { return x } }; out = is_callable(a(5))`, true)                   // closure
 	expectWithSymbols(t, `out = is_callable(x)`, true, SYM{"x": &StringArray{Value: []string{"foo", "bar"}}}) // user object
 }
+
+func TestBuiltinFunctional(t *testing.T) {
+	expect(t, `out = map([1, 2, 3], func(x) { return x * 2 })`, ARR{2, 4, 6})
+	expect(t, `out = map(immutable([1, 2]), string)`, ARR{"1", "2"})
+	expect(t, `out = map({a: 1, b: 2}, func(x) { return x + 1 })`, MAP{"a": 2, "b": 3})
+	expect(t, `out = map("ab", func(c) { return c + 1 })`, ARR{'b', 'c'})
+	expect(t, `out = map([], func(x) { return x })`, ARR{})
+
+	expect(t, `out = filter([1, 2, 3, 4], func(x) { return x % 2 == 0 })`, ARR{2, 4})
+	expect(t, `out = filter({a: 1, b: 2}, func(x) { return x > 1 })`, MAP{"b": 2})
+	expect(t, `out = filter([1, 2], func(x) { return false })`, ARR{})
+
+	expect(t, `out = reduce([1, 2, 3], func(acc, x) { return acc + x }, 10)`, 16)
+	expect(t, `out = reduce([1, 2, 3], func(acc, x) { return acc * x })`, 6)
+	expect(t, `out = reduce([], func(acc, x) { return acc + x })`, objects.UndefinedValue)
+	expect(t, `out = reduce([], func(acc, x) { return acc + x }, 0)`, 0)
+
+	expect(t, `out = 0; each([1, 2, 3], func(x) { out += x })`, 6)
+	expect(t, `out = each([1], func(x) { return x })`, objects.UndefinedValue)
+
+	expect(t, `out = find([1, 2, 3, 4], func(x) { return x > 2 })`, 3)
+	expect(t, `out = find([1, 2], func(x) { return x > 2 })`, objects.UndefinedValue)
+	expect(t, `n := 0; find([1, 2, 3, 4], func(x) { n++; return x == 2 }); out = n`, 2)
+
+	expect(t, `out = sort_by([3, 1, 2], func(x) { return x })`, ARR{1, 2, 3})
+	expect(t, `out = sort_by(["bb", "a", "ccc", "dd"], func(s) { return -len(s) })`, ARR{"ccc", "bb", "dd", "a"})
+	expect(t, `out = sort_by([{n: 2.5}, {n: 1}], func(x) { return x.n })`, ARR{MAP{"n": 1}, MAP{"n": 2.5}})
+	expect(t, `out = sort_by([time(2), time(1)], func(x) { return x })[0] == time(1)`, true)
+	expectError(t, `sort_by(["b", "a"], func(x) { return x })`, "invalid operator")
+
+	// lazy sources, closures and builtin functions
+	expect(t, `out = map(stream([1, 2, 3]).filter(func(x) { return x > 1 }), func(x) { return x * 10 })`, ARR{20, 30})
+	expect(t, `k := 3; out = map([1, 2], func(x) { return x * k })`, ARR{3, 6})
+	expect(t, `out = map([[1], [2, 3]], len)`, ARR{1, 2})
+	expect(t, `out = map([[1, 2], [3]], func(a) { return reduce(a, func(acc, x) { return acc + x }) })`, ARR{3, 3})
+
+	// global variables can shadow the builtin functions
+	expect(t, `map := func(x) { return x + 1 }; out = map(1)`, 2)
+
+	expectError(t, `map(1, func(x) { return x })`, "invalid type for argument 'first' in call to 'builtin-function:map': expected iterable, found int")
+	expectError(t, `filter([1], 1)`, "invalid type for argument 'second' in call to 'builtin-function:filter': expected callable, found int")
+	expectError(t, `map([1])`, "wrong number of arguments")
+	expectError(t, `each([1], func(x, y) { return x })`, "wrong number of arguments")
+	expectError(t, `map([1], func(x) { return x + {} })`, "invalid operation: int + map")
+}
+
+func TestBuiltinArray(t *testing.T) {
+	expect(t, `out = [1]; push(out, 2, 3)`, ARR{1, 2, 3})
+	expect(t, `a := [1]; out = push(a) == a`, true)
+	expect(t, `a := [1, 2]; out = [pop(a), a]`, ARR{2, ARR{1}})
+	expect(t, `out = pop([])`, objects.UndefinedValue)
+
+	expect(t, `out = [1, 4]; insert(out, 1, 2, 3)`, ARR{1, 2, 3, 4})
+	expect(t, `out = [1]; insert(out, 0, 0)`, ARR{0, 1})
+	expect(t, `out = [1]; insert(out, 1, 2)`, ARR{1, 2})
+	expectError(t, `insert([1], 2, 3)`, "index out of bounds")
+	expectError(t, `insert([1], -1, 3)`, "index out of bounds")
+
+	expect(t, `a := [1, 2, 3]; out = [remove_at(a, 1), a]`, ARR{2, ARR{1, 3}})
+	expect(t, `a := [1, 2, 3]; out = [remove_at(a, 2), a]`, ARR{3, ARR{1, 2}})
+	expectError(t, `remove_at([1], 1)`, "index out of bounds")
+	expectError(t, `remove_at([], 0)`, "index out of bounds")
+	expectError(t, `remove_at([1], "0")`, "invalid type for argument 'second' in call to 'builtin-function:remove_at': expected int, found string")
+
+	expect(t, `out = reverse([1, 2, 3, 4])`, ARR{4, 3, 2, 1})
+	expect(t, `out = reverse([])`, ARR{})
+
+	expect(t, `out = [1]; concat_in_place(out, [2, 3], immutable([4]))`, ARR{1, 2, 3, 4})
+	expect(t, `out = [1, 2]; concat_in_place(out, out)`, ARR{1, 2, 1, 2})
+	expectError(t, `concat_in_place([1], [2], 3)`, "invalid type for argument 'args[2]' in call to 'builtin-function:concat_in_place': expected array, found int")
+
+	// the array is modified in place
+	expect(t, `a := [1, 2]; b := a; push(b, 3); reverse(b); out = a`, ARR{3, 2, 1})
+	expect(t, `m := {a: [1]}; push(m.a, 2); out = m`, MAP{"a": ARR{1, 2}})
+
+	expectError(t, `push(immutable([1]), 2)`, "invalid type for argument 'first' in call to 'builtin-function:push': expected array, found immutable-array")
+	expectError(t, `pop("abc")`, "invalid type for argument 'first' in call to 'builtin-function:pop': expected array, found string")
+	expectError(t, `pop()`, "wrong number of arguments")
+}
+
+func TestBuiltinMap(t *testing.T) {
+	expect(t, `out = keys({b: 1, a: 2, c: 3})`, ARR{"a", "b", "c"})
+	expect(t, `out = values({b: 1, a: 2, c: 3})`, ARR{2, 1, 3})
+	expect(t, `out = keys(immutable({a: 1}))`, ARR{"a"})
+	expect(t, `out = values({})`, ARR{})
+	expectError(t, `keys([1])`, "invalid type for argument 'first' in call to 'builtin-function:keys': expected map, found array")
+
+	expect(t, `out = merge({a: 1, b: {x: 1}}, {b: {y: 2}, c: 3})`, MAP{"a": 1, "b": MAP{"y": 2}, "c": 3})
+	expect(t, `out = merge({a: 1, b: {x: 1, y: 1}}, {b: {y: 2}, c: 3}, true)`, MAP{"a": 1, "b": MAP{"x": 1, "y": 2}, "c": 3})
+	expect(t, `out = merge({a: {b: {c: 1, d: 1}}}, immutable({a: {b: {d: 2}}}), true)`, MAP{"a": MAP{"b": MAP{"c": 1, "d": 2}}})
+	expect(t, `out = merge({a: {b: 1}}, {a: 2}, true)`, MAP{"a": 2})
+	expect(t, `a := {x: {y: 1}}; merge(a, {x: {z: 2}}, true); out = a`, MAP{"x": MAP{"y": 1}})
+	expectError(t, `merge({}, 1)`, "invalid type for argument 'second' in call to 'builtin-function:merge': expected map, found int")
+
+	expect(t, `out = {a: 1, b: 2}; delete(out, "a"); delete(out, "x")`, MAP{"b": 2})
+	expectError(t, `delete(immutable({a: 1}), "a")`, "invalid type for argument 'first' in call to 'builtin-function:delete': expected map, found immutable-map")
+	expectError(t, `delete({a: 1}, 1)`, "invalid type for argument 'second' in call to 'builtin-function:delete': expected string, found int")
+
+	expect(t, `out = [has({a: 1}, "a"), has({a: undefined}, "a"), has({a: 1}, "b")]`, ARR{true, true, false})
+	expect(t, `out = [get({a: 1}, "a", 2), get({a: 1}, "b", 2), get({a: 1}, "b")]`, ARR{1, 2, objects.UndefinedValue})
+}
@@ -15,4 +15,16 @@ func TestBytes(t *testing.T) {
 	expect(t, `out = bytes("abcde")[1]`, 98)
 	expect(t, `out = bytes("abcde")[4]`, 101)
 	expect(t, `out = bytes("abcde")[10]`, objects.UndefinedValue)
+
+	// bytes[] = int/char
+	expect(t, `out = bytes(3); out[0] = 97; out[1] = 'b'; out[2] += 99`, []byte("abc"))
+	expectError(t, `b := bytes("abc"); b[3] = 'x'`, "index out of bounds")
+	expectError(t, `b := bytes("abc"); b[-1] = 'x'`, "index out of bounds")
+	expectError(t, `b := bytes("abc"); b[0] = 256`, "invaid index value type: int")
+	expectError(t, `b := bytes("abc"); b[0] = "x"`, "invaid index value type: string")
+	expectError(t, `b := bytes("abc"); b[[]] = 'x'`, "invalid index type: array")
+
+	// the slices share the elements, but, the concatenation does not
+	expect(t, `b := bytes("abc"); s := b[1:]; s[0] = 'x'; out = b`, []byte("axc"))
+	expect(t, `b := bytes("abc"); c := b[:1] + bytes("x"); c[0] = 'y'; out = b`, []byte("abc"))
 }
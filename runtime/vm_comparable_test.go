@@ -0,0 +1,56 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/objects"
+)
+
+type Version struct {
+	objectImpl
+	Major, Minor int
+}
+
+func (o *Version) TypeName() string {
+	return "version"
+}
+
+func (o *Version) Compare(x objects.Object) (int, error) {
+	v, ok := x.(*Version)
+	if !ok {
+		return 0, objects.ErrInvalidOperator
+	}
+
+	if o.Major != v.Major {
+		return o.Major - v.Major, nil
+	}
+
+	return o.Minor - v.Minor, nil
+}
+
+func TestComparable(t *testing.T) {
+	vers := func() SYM {
+		return SYM{
+			"v1":  &Version{Major: 1, Minor: 2},
+			"v2":  &Version{Major: 1, Minor: 10},
+			"v3":  &Version{Major: 2, Minor: 0},
+			"v1b": &Version{Major: 1, Minor: 2},
+			"i":   &objects.Int{Value: 1},
+		}
+	}
+
+	expectWithSymbols(t, `out = v2 > v1`, true, vers())
+	expectWithSymbols(t, `out = v1 > v2`, false, vers())
+	expectWithSymbols(t, `out = v1 < v3`, true, vers())
+	expectWithSymbols(t, `out = v3 < v2`, false, vers())
+	expectWithSymbols(t, `out = v1 >= v1b`, true, vers())
+	expectWithSymbols(t, `out = v1 <= v1b`, true, vers())
+	expectWithSymbols(t, `out = v1 > v1b`, false, vers())
+	expectWithSymbols(t, `out = v1 < v1b`, false, vers())
+
+	expectErrorWithSymbols(t, `v1 > i`, vers(), "invalid operation: version > int")
+	expectErrorWithSymbols(t, `i > v1`, vers(), "invalid operation: int > version")
+
+	// time values
+	expect(t, `times := import("times"); out = times.now() > times.add(times.now(), -1000000000)`, true)
+}
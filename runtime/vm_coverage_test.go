@@ -0,0 +1,80 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/runtime"
+)
+
+func TestVMCoverage(t *testing.T) {
+	coverage := runtime.NewCoverage()
+	if !runWithCoverage(t, `
+f := func(x) {
+	if x > 0 {
+		return "positive"
+	}
+	return "other"
+}
+for i := 0; i < 3; i++ {
+	f(i)
+}`, coverage) {
+		return
+	}
+
+	report := coverage.Report()
+	if !assert.Equal(t, 1, len(report)) {
+		return
+	}
+	assert.Equal(t, "test", report[0].Filename)
+	var lines, counts []int
+	for _, l := range report[0].Lines {
+		lines = append(lines, l.Line)
+		counts = append(counts, l.Count)
+	}
+	assert.Equal(t, []int{2, 3, 4, 6, 8, 9}, lines)
+	assert.Equal(t, []int{1, 3, 2, 1, 4, 3}, counts)
+	assert.Equal(t, 6, report[0].Covered())
+	assert.Equal(t, 100.0, report[0].Percent())
+
+	// the lines of the functions that are never called
+	coverage = runtime.NewCoverage()
+	if !runWithCoverage(t, `
+a := 1
+f := func() {
+	return a
+}`, coverage) {
+		return
+	}
+
+	report = coverage.Report()
+	if !assert.Equal(t, 1, len(report)) {
+		return
+	}
+	assert.Equal(t, 3, len(report[0].Lines))
+	assert.Equal(t, 0, report[0].Lines[2].Count)
+	assert.Equal(t, 2, report[0].Covered())
+}
+
+func runWithCoverage(t *testing.T, input string, coverage *runtime.Coverage) bool {
+	src := []byte(input)
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if !assert.NoError(t, err) {
+		return false
+	}
+
+	c := compiler.NewCompiler(srcFile, nil, nil, nil, nil)
+	if !assert.NoError(t, c.Compile(file)) {
+		return false
+	}
+
+	v := runtime.NewVM(c.Bytecode(), nil, nil)
+	v.SetCoverage(coverage)
+
+	return assert.NoError(t, v.Run())
+}
@@ -0,0 +1,97 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+const debuggerTestInput = `
+a := 1
+f := func(x) {
+	y := x * 2
+	return y
+}
+b := f(a)
+c := b + 1`
+
+func TestVMDebugger(t *testing.T) {
+	var stops int
+	var names string
+	var y, a objects.Object
+	var stack []source.FilePos
+	debugger := runtime.NewDebugger(func(state *runtime.DebugState) runtime.DebugAction {
+		stops++
+		assert.Equal(t, "test", state.Position().Filename)
+		assert.Equal(t, 5, state.Position().Line)
+
+		for _, v := range state.Locals(0) {
+			names += v.Name
+		}
+		y, _ = state.Lookup("y")
+		a, _ = state.Lookup("a")
+		stack = state.Stack()
+
+		return runtime.DebugContinue
+	})
+	debugger.SetBreakpoint("test", 5)
+	assert.Equal(t, 1, len(debugger.Breakpoints()))
+
+	if !runWithDebugger(t, debuggerTestInput, debugger) {
+		return
+	}
+
+	assert.Equal(t, 1, stops)
+	assert.Equal(t, "xy", names)
+	assert.Equal(t, &objects.Int{Value: 2}, y)
+	assert.Equal(t, &objects.Int{Value: 1}, a)
+	if assert.Equal(t, 2, len(stack)) {
+		assert.Equal(t, 5, stack[0].Line)
+		assert.Equal(t, 7, stack[1].Line)
+	}
+
+	assert.True(t, debugger.ClearBreakpoint("test", 5))
+	assert.False(t, debugger.ClearBreakpoint("test", 5))
+
+	testDebuggerSteps(t, runtime.DebugStepIn, []int{2, 3, 7, 4, 5, 7, 8})
+	testDebuggerSteps(t, runtime.DebugStepOver, []int{2, 3, 7, 8})
+	testDebuggerSteps(t, runtime.DebugStepOut, []int{2})
+}
+
+func testDebuggerSteps(t *testing.T, action runtime.DebugAction, expected []int) {
+	var lines []int
+	debugger := runtime.NewDebugger(func(state *runtime.DebugState) runtime.DebugAction {
+		lines = append(lines, state.Position().Line)
+		return action
+	})
+	debugger.Pause()
+
+	if runWithDebugger(t, debuggerTestInput, debugger) {
+		assert.Equal(t, expected, lines)
+	}
+}
+
+func runWithDebugger(t *testing.T, input string, debugger *runtime.Debugger) bool {
+	src := []byte(input)
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if !assert.NoError(t, err) {
+		return false
+	}
+
+	c := compiler.NewCompiler(srcFile, nil, nil, nil, nil)
+	if !assert.NoError(t, c.Compile(file)) {
+		return false
+	}
+
+	v := runtime.NewVM(c.Bytecode(), nil, nil)
+	v.SetDebugger(debugger)
+
+	return assert.NoError(t, v.Run())
+}
@@ -1,6 +1,17 @@
 package runtime_test
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
 
 func TestVMErrorInfo(t *testing.T) {
 	expectError(t, `a := 5
@@ -46,3 +57,227 @@ export func() {
 }`,
 	}, "mod2:4:9: invalid operation: int + string")
 }
+
+func TestVMErrorStructured(t *testing.T) {
+	runError := func(input string) *runtime.Error {
+		v, _, err := optimizedVM(input)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		err = v.Run()
+		if !assert.Error(t, err) {
+			return nil
+		}
+		e, ok := err.(*runtime.Error)
+		assert.True(t, ok, "unexpected error type: %T", err)
+
+		return e
+	}
+
+	e := runError(`f := func(a, b) { return a + b }
+f(1)`)
+	if e != nil {
+		assert.True(t, errors.Is(e, objects.ErrWrongNumArguments))
+		assert.Equal(t, "CALL", compiler.OpcodeNames[e.Opcode])
+		assert.Equal(t, "test:2:1", e.Pos.String())
+		assert.Equal(t, "wrong number of arguments: want=2, got=1", e.Message)
+		assert.Equal(t, 1, len(e.Frames))
+	}
+
+	e = runError(`a := 5; b := a[1]`)
+	if e != nil {
+		assert.True(t, errors.Is(e, runtime.ErrNotIndexable))
+		assert.Equal(t, "INDEX", compiler.OpcodeNames[e.Opcode])
+		assert.Equal(t, "not indexable: int", e.Message)
+	}
+
+	e = runError(`a := 5; a.b.c = 1`)
+	if e != nil {
+		assert.True(t, errors.Is(e, runtime.ErrNotIndexable))
+		assert.Equal(t, "not indexable: int", e.Message)
+	}
+
+	e = runError(`for x in 5 {}`)
+	if e != nil {
+		assert.True(t, errors.Is(e, runtime.ErrNotIterable))
+	}
+
+	e = runError(`len(1, 2)`)
+	if e != nil {
+		assert.True(t, errors.Is(e, objects.ErrWrongNumArguments))
+		assert.Equal(t, "CALL", compiler.OpcodeNames[e.Opcode])
+	}
+
+	e = runError(`a := 1; a()`)
+	if e != nil {
+		assert.True(t, errors.Is(e, runtime.ErrNotCallable))
+	}
+
+	e = runError(`g := func() { return 1 + {} }
+f := func() { x := g(); return x }
+f()`)
+	if e != nil {
+		assert.True(t, errors.Is(e, objects.ErrInvalidOperator))
+		assert.Equal(t, "invalid operation: int + map", e.Message)
+		if assert.Equal(t, 3, len(e.Frames)) {
+			assert.Equal(t, "test:1:22", e.Frames[0].String())
+			assert.Equal(t, "test:2:20", e.Frames[1].String())
+			assert.Equal(t, "test:3:1", e.Frames[2].String())
+		}
+	}
+
+	e = runError(`f := func(x) { return f(x) + 1 }; f(1)`)
+	if e != nil {
+		assert.True(t, errors.Is(e, runtime.ErrStackOverflow))
+		assert.True(t, len(e.Frames) > 1)
+	}
+}
+
+func TestVMStackOverflow(t *testing.T) {
+	expectError(t, `
+f := func(x) {
+	return f(x) + 1
+}
+g := func() { x := f(1); return x }
+g()`, "test:3:9: stack overflow at call depth 1024: func@test:3 at test:3:9 (1022 calls), func@test:5 at test:5:20, (main) at test:6:1")
+
+	// the functions called back by Go code
+	expectError(t, `
+f := func(x) {
+	return stream([x]).map(func(y) {
+		return f(y)
+	}).to_array()
+}
+f(1)`, "stack overflow at call depth")
+
+	expectError(t, `
+f := undefined
+g := func(x) { return f(x) + 1 }
+h := func(x) { return g(x) + 1 }
+i := func(x) { return h(x) + 1 }
+j := func(x) { return i(x) + 1 }
+k := func(x) { return j(x) + 1 }
+f = func(x) { return k(x) + 1 }
+f(1)`, "test:6:23: stack overflow at call depth 1024: func@test:6 at test:6:23, func@test:7 at test:7:23, func@test:8 at test:8:22, func@test:3 at test:3:23, func@test:4 at test:4:23, ...")
+}
+
+func TestVMErrorInfo_StrippedBytecode(t *testing.T) {
+	src := []byte(`
+f := func() {
+	b := 5
+	return b + "foo"
+}
+f()`)
+
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := compiler.NewCompiler(srcFile, nil, nil, nil, nil)
+	if !assert.NoError(t, c.Compile(file)) {
+		return
+	}
+
+	var full, stripped bytes.Buffer
+	bytecode := c.Bytecode()
+	err = bytecode.Encode(&full)
+	if err == compiler.ErrBytecodeUnsupported {
+		t.Skip(err)
+	}
+	assert.NoError(t, err)
+	bytecode.StripDebugInfo()
+	assert.NoError(t, bytecode.Encode(&stripped))
+	assert.True(t, stripped.Len() < full.Len())
+
+	decoded := &compiler.Bytecode{}
+	if !assert.NoError(t, decoded.Decode(bytes.NewReader(stripped.Bytes()))) {
+		return
+	}
+
+	err = runtime.NewVM(decoded, nil, nil).Run()
+	assert.Error(t, err)
+	assert.Equal(t, "invalid operation: int + string", err.Error())
+
+	// bytecode without file set
+	decoded.FileSet = nil
+	err = runtime.NewVM(decoded, nil, nil).Run()
+	assert.Error(t, err)
+	assert.Equal(t, "invalid operation: int + string", err.Error())
+}
+
+func TestVMMapKeyType(t *testing.T) {
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, 20)
+	insts := concatInsts(
+		compiler.MakeInstruction(compiler.OpConstant, 0),
+		compiler.MakeInstruction(compiler.OpConstant, 1),
+		compiler.MakeInstruction(compiler.OpMap, 2),
+		compiler.MakeInstruction(compiler.OpPop))
+	bytecode := &compiler.Bytecode{
+		FileSet: fileSet,
+		MainFunction: &objects.CompiledFunction{
+			Instructions: insts,
+			SourceMap:    map[int]source.Pos{6: srcFile.FileSetPos(5)},
+		},
+		Constants: []objects.Object{&objects.Int{Value: 1}, &objects.Int{Value: 2}},
+	}
+
+	err := runtime.NewVM(bytecode, nil, nil).Run()
+	if assert.Error(t, err) {
+		assert.Equal(t, "test:1:6: invalid map key type: int", err.Error())
+		assert.True(t, errors.Is(err, objects.ErrInvalidIndexType))
+	}
+}
+
+func concatInsts(insts ...[]byte) []byte {
+	var concat []byte
+	for _, i := range insts {
+		concat = append(concat, i...)
+	}
+
+	return concat
+}
+
+func TestVMInvalidBytecode(t *testing.T) {
+	bytecode := &compiler.Bytecode{
+		FileSet: source.NewFileSet(),
+		MainFunction: &objects.CompiledFunction{
+			Instructions: compiler.MakeInstruction(compiler.OpConstant, 1),
+		},
+		Constants: []objects.Object{&objects.Int{Value: 1}},
+	}
+
+	err := runtime.NewVM(bytecode, nil, nil).Run()
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid bytecode: main function: 0000: constant index out of range: 1", err.Error())
+		assert.True(t, errors.Is(err, runtime.ErrVerification))
+	}
+
+	// global variable index out of range of the globals
+	bytecode.MainFunction.Instructions = compiler.MakeInstruction(compiler.OpGetGlobal, 2)
+	err = runtime.NewVM(bytecode, make([]objects.Object, 2), nil).Run()
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid bytecode: main function: 0000: global variable index out of range: 2", err.Error())
+		assert.True(t, errors.Is(err, runtime.ErrVerification))
+	}
+
+	// value left on the stack
+	bytecode.MainFunction.Instructions = compiler.MakeInstruction(compiler.OpConstant, 0)
+	v := runtime.NewVM(bytecode, nil, nil)
+	err = v.Run()
+	if assert.Error(t, err) {
+		assert.Equal(t, "non empty stack after execution: 1", err.Error())
+		assert.True(t, errors.Is(err, runtime.ErrVerification))
+	}
+
+	// instructions modified after the validation
+	bytecode.MainFunction.Instructions[0] = 255
+	err = v.Run()
+	if assert.Error(t, err) {
+		assert.Equal(t, "unknown opcode: 255", err.Error())
+		assert.True(t, errors.Is(err, runtime.ErrVerification))
+	}
+}
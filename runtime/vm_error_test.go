@@ -15,6 +15,19 @@ func TestError(t *testing.T) {
 	expect(t, `out = error("some error").value`, "some error")
 	expect(t, `out = error("some error")["value"]`, "some error")
 
+	// stack
+	expect(t, `out = error("foo").stack`, IARR{"test:1:7"})
+	expect(t, `f := func() {
+	return error("foo")
+}
+g := func() {
+	x := f()
+	return x
+}
+out = g().stack`, IARR{"test:2:9", "test:5:7", "test:8:7"})
+	expect(t, `out = stream([1]).map(func(x) { return error(x) }).to_array()[0].stack`, IARR{"test:1:40", "test:1:7"})
+	expect(t, `out = error("foo")["stack"][0]`, "test:1:7")
+
 	expectError(t, `error("error").err`, "invalid index on error")
 	expectError(t, `error("error").value_`, "invalid index on error")
 	expectError(t, `error([1,2,3])[1]`, "invalid index on error")
@@ -12,4 +12,11 @@ func TestFloat(t *testing.T) {
 	expect(t, `out = 2.3 + 4`, 6.3)
 	expect(t, `out = +5.0`, 5.0)
 	expect(t, `out = -5.0 + +5.0`, 0.0)
+
+	// operands not known at compile time
+	expect(t, `a := 7.5; b := 2.5; out = [a + b, a - b, a * b, a / b]`, ARR{10.0, 5.0, 18.75, 3.0})
+	expect(t, `a := 7.5; b := 2.5; out = [a > b, a >= b, a < b, a <= b, a >= 7.5]`, ARR{true, true, false, false, true})
+	expect(t, `a := 7.5; b := 2; out = [a + b, a / b, b < a]`, ARR{9.5, 3.75, true})
+	expect(t, `a := 7.5; out = [a / 0.0, a / 0]`, ARR{errorObject("division by zero"), errorObject("division by zero")})
+	expectError(t, `a := 1.5; b := 2.5; a % b`, "invalid operation: float % float")
 }
@@ -0,0 +1,45 @@
+package runtime_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type Money struct {
+	objectImpl
+	Cents int64
+}
+
+func (o *Money) TypeName() string {
+	return "money"
+}
+
+func (o *Money) String() string {
+	return fmt.Sprintf("Money(%d)", o.Cents)
+}
+
+func (o *Money) Format(f fmt.State, verb rune) {
+	s := fmt.Sprintf("$%d.%02d", o.Cents/100, o.Cents%100)
+	switch verb {
+	case 'q':
+		s = `"` + s + `"`
+	case 'd':
+		s = fmt.Sprintf("%d", o.Cents)
+	}
+
+	if w, ok := f.Width(); ok && w > len(s) {
+		s = strings.Repeat(" ", w-len(s)) + s
+	}
+
+	_, _ = f.Write([]byte(s))
+}
+
+func TestFormatter(t *testing.T) {
+	m := func() SYM { return SYM{"m": &Money{Cents: 1234}} }
+
+	expectWithSymbols(t, `out = sprintf("%v %s %q %d", m, m, m, m)`, `$12.34 $12.34 "$12.34" 1234`, m())
+	expectWithSymbols(t, `out = sprintf("[%8v]", m)`, `[  $12.34]`, m())
+	expectWithSymbols(t, `out = sprintf("%v", [m, {a: m}])`, `[$12.34 map[a:$12.34]]`, m())
+	expectWithSymbols(t, `out = string(m)`, `Money(1234)`, m())
+}
@@ -253,4 +253,37 @@ func() {
 		}()
 	}()
 }()`, 15)
+
+	// closures share the captured local variables with the function
+	expect(t, `
+func() {
+	a := 1
+	f := func() { a += 10; return a }
+	g := func() { return a }
+	a = 2
+	out = [f(), g(), a]
+}()`, ARR{12, 12, 12})
+	expect(t, `
+func() {
+	fns := []
+	for i := 0; i < 3; i++ {
+		x := i
+		fns = append(fns, func() { x *= 10; return x })
+	}
+	out = [fns[0](), fns[1](), fns[2](), fns[2]()]
+}()`, ARR{0, 10, 20, 200})
+	expect(t, `
+func() {
+	m := {a: 1}
+	f := func() { m.a = 5 }
+	f()
+	out = m.a
+}()`, 5)
+	expect(t, `
+func(a) {
+	f := func() { return func() { a++ } }
+	f()()
+	f()()
+	out = a
+}(1)`, 3)
 }
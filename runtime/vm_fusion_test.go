@@ -0,0 +1,114 @@
+package runtime_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+func TestVMCombinedInstructions(t *testing.T) {
+	// compare and jump
+	expectOptimized(t, `
+out = func(n) {
+	s := 0
+	for i := 0; i < n; i++ {
+		if i == 3 { continue }
+		if i != 5 { s += i }
+		if i >= 7 { break }
+	}
+	return s
+}(10)`, "20")
+	expectOptimized(t, `
+out = func(a, b) {
+	s := ""
+	for x := a; x > b; x -= 0.5 { s += "x" }
+	return s
+}(3.0, 1.0)`, `"xxxx"`)
+	expectOptimized(t, `
+out = func(a, b) {
+	if a < b { return "lt" } else if a > b { return "gt" }
+	return "eq"
+}('a', 'b')`, `"lt"`)
+	expectOptimizedError(t, `func(a) { if a > 1 { return 1 } }("x")`,
+		"invalid operation: string > int")
+
+	// add the constant to a local variable
+	expectOptimized(t, `
+out = func() {
+	i := 1.5
+	i += 2.0
+	s := "a"
+	s += "b"
+	return [i, s]
+}()`, `[3.5, "ab"]`)
+	expectOptimized(t, `
+out = func() {
+	i := 0
+	f := func() { return i }
+	i++
+	i += 10
+	return f()
+}()`, `11`)
+	expectOptimizedError(t, `func() { a := {}; a += 1 }()`,
+		"invalid operation: map + int")
+}
+
+func expectOptimized(t *testing.T, input string, expected string) {
+	v, symbolTable, err := optimizedVM(input)
+	if !assert.NoError(t, err) || !assert.NoError(t, v.Run()) {
+		return
+	}
+
+	symbol, _, _ := symbolTable.Resolve("out")
+	assert.Equal(t, expected, v.Globals()[symbol.Index].String())
+}
+
+func expectOptimizedError(t *testing.T, input string, expected string) {
+	v, _, err := optimizedVM(input)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = v.Run()
+	assert.Error(t, err)
+	assert.True(t, err != nil && strings.Contains(err.Error(), expected), err)
+}
+
+func optimizedVM(input string) (*runtime.VM, *compiler.SymbolTable, error) {
+	bytecode, symbolTable, err := optimizedBytecode(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return runtime.NewVM(bytecode, nil, nil), symbolTable, nil
+}
+
+func optimizedBytecode(input string) (*compiler.Bytecode, *compiler.SymbolTable, error) {
+	src := []byte(input)
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	symbolTable.Define("out")
+	for idx, fn := range objects.Builtins {
+		symbolTable.DefineBuiltin(idx, fn.Name)
+	}
+
+	c := compiler.NewCompiler(srcFile, symbolTable, nil, nil, nil)
+	c.EnableOptimizer(true)
+	if err := c.Compile(file); err != nil {
+		return nil, nil, err
+	}
+
+	return c.Bytecode(), symbolTable, nil
+}
@@ -27,4 +27,17 @@ func TestInteger(t *testing.T) {
 
 	expect(t, `out = 9 + '0'`, '9')
 	expect(t, `out = '9' - 5`, '4')
+
+	// operands not known at compile time
+	expect(t, `a := 7; b := 2; out = [a + b, a - b, a * b, a / b, a % b]`, ARR{9, 5, 14, 3, 1})
+	expect(t, `a := 12; b := 10; out = [a & b, a | b, a ^ b, a &^ b, a << b, a >> 2]`, ARR{8, 14, 6, 4, 12288, 3})
+	expect(t, `a := 7; b := 2; out = [a > b, a >= b, a < b, a <= b, a > 7, a >= 7]`, ARR{true, true, false, false, false, true})
+	expect(t, `a := 7; b := 2.0; out = [a + b, b * a, a > b]`, ARR{9.0, 14.0, true})
+	expect(t, `a := 9; b := '0'; out = a + b`, '9')
+	expect(t, `a := -1; out = 1 << 62 << 1 + a`, 1<<63-1) // overflow
+	expect(t, `a := 7; b := 0; out = [a / b, a % b]`, ARR{errorObject("division by zero"), errorObject("division by zero")})
+	expect(t, `a := 7; a /= 0; out = is_error(a) ? a.value : a`, "division by zero")
+	expect(t, `out = 1 / 0.0`, errorObject("division by zero"))
+	expectError(t, `a := 1; b := "x"; a - b`, "invalid operation: int - string")
+	expectError(t, `a := 1; b := 0.5; a % b`, "invalid operation: int % float")
 }
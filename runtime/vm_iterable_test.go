@@ -42,3 +42,74 @@ func TestIterable(t *testing.T) {
 	expectWithSymbols(t, `for i, s in arr { out += s }`, "onetwothree", SYM{"arr": strArr()})
 	expectWithSymbols(t, `for i, s in arr { out += s + i }`, "one0two1three2", SYM{"arr": strArr()})
 }
+
+func TestIter(t *testing.T) {
+	strArr := func() *StringArray { return &StringArray{Value: []string{"one", "two", "three"}} }
+
+	expect(t, `it := iter([1, 2]); out = [it.key(), it.next(), it.key(), it.value(), it.next(), it.value(), it.next(), it.value(), it.next()]`,
+		ARR{objects.UndefinedValue, true, 0, 1, true, 2, false, objects.UndefinedValue, false})
+	expect(t, `it := iter({a: 1}); it.next(); out = [it.key(), it.value()]`, ARR{"a", 1})
+	expect(t, `it := iter("ab"); out = ""; for it.next() { out += it.value() }`, "ab")
+	expect(t, `out = [type_name(iter([])), string(iter([]))]`, ARR{"iterator", "<iterator>"})
+
+	// for-in continues from the current position
+	expect(t, `it := iter([1, 2, 3, 4]); it.next(); it.next(); out = 0; for k, v in it { out += k * v }`, 2*3+3*4)
+	expect(t, `it := iter([1, 2]); for v in it {}; out = [it.next(), it.value()]`, ARR{false, objects.UndefinedValue})
+
+	// lazy streams
+	expect(t, `it := iter(stream([1, 2, 3]).map(func(x) { return x * 10 })); it.next(); it.next(); out = it.value()`, 20)
+
+	// custom traversal: zip
+	expect(t, `
+zip := func(a, b) {
+	ia := iter(a)
+	ib := iter(b)
+	res := []
+	for ia.next() && ib.next() {
+		res = append(res, [ia.value(), ib.value()])
+	}
+	return res
+}
+out = zip([1, 2, 3], "ab")`, ARR{ARR{1, 'a'}, ARR{2, 'b'}})
+
+	// copies are independent
+	expect(t, `a := iter([1, 2, 3]); a.next(); b := copy(a); b.next(); out = [a.value(), b.value(), a == a, a == b]`,
+		ARR{1, 2, true, false})
+
+	// user types
+	expectWithSymbols(t, `it := iter(arr); it.next(); it.next(); out = it.value() + it.key()`, "two1", SYM{"arr": strArr()})
+	expectWithSymbols(t, `it := iter(arr); out = iter(it) == it`, true, SYM{"arr": strArr()})
+
+	expectError(t, `iter(1)`, "invalid type for argument 'first' in call to 'builtin-function:iter': expected iterable, found int")
+	expectError(t, `iter([]).next(1)`, "wrong number of arguments")
+}
+
+func TestPullIterable(t *testing.T) {
+	counter := func(n int) *objects.PullIterable {
+		i := 0
+		return objects.NewPullIterable(func() (objects.Object, bool) {
+			if i == n {
+				return nil, false
+			}
+			i++
+			return &objects.Int{Value: int64(i)}, true
+		})
+	}
+
+	expectWithSymbols(t, `for i, v in src { out += i * v }`, 0*1+1*2+2*3, SYM{"src": counter(3)})
+	expectWithSymbols(t, `for v in src { if v == 2 { break } }; for v in src { out += v }`, 3, SYM{"src": counter(3)})
+	expectWithSymbols(t, `out = stream(src).filter(func(x) { return x % 2 == 0 }).to_array()`, ARR{2, 4}, SYM{"src": counter(5)})
+	expectWithSymbols(t, `it := iter(src); it.next(); out = [it.value(), type_name(src)]`, ARR{1, "pull-iterable"}, SYM{"src": counter(5)})
+
+	// values are received as they are consumed
+	ch := make(chan objects.Object)
+	go func() {
+		defer close(ch)
+		for i := 0; i < 100; i++ {
+			ch <- &objects.Int{Value: int64(i)}
+		}
+		ch <- nil
+	}()
+	expectWithSymbols(t, `out = []; for v in src { out = append(out, v) }; out = [len(out), out[99], out[100]]`,
+		ARR{101, 99, objects.UndefinedValue}, SYM{"src": objects.NewChanIterable(ch)})
+}
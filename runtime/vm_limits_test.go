@@ -0,0 +1,269 @@
+package runtime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+func TestVMMaxInstructions(t *testing.T) {
+	v := limitsTestVM(t, `
+f := func(x) { return x + 1 }
+a := 0
+for i := 0; i < 10; i++ { a = f(a) }`)
+	if v == nil {
+		return
+	}
+
+	v.SetMaxInstructions(100000)
+	assert.NoError(t, v.Run())
+
+	v.SetMaxInstructions(50)
+	assert.Equal(t, runtime.ErrInstructionLimit, v.Run())
+
+	// the count is reset for each run
+	v.SetMaxInstructions(100000)
+	assert.NoError(t, v.Run())
+
+	// the functions called back by Go code
+	v = limitsTestVM(t, `
+stream([1, 2]).map(func(x) { for {} }).to_array()`)
+	if v == nil {
+		return
+	}
+	v.SetMaxInstructions(1000)
+	assert.Equal(t, runtime.ErrInstructionLimit, v.Run())
+
+	// the optimizer removes the return after the endless loop
+	v, _, err := optimizedVM(`f := func() { for {} }; f()`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	v.SetMaxInstructions(1000)
+	assert.Equal(t, runtime.ErrInstructionLimit, v.Run())
+}
+
+func TestVMMaxMemory(t *testing.T) {
+	v := limitsTestVM(t, `
+a := []
+for {
+	a = append(a, "x")
+}`)
+	if v == nil {
+		return
+	}
+
+	v.SetMaxMemory(1)
+	v.SetMaxInstructions(100000000)
+	assert.Equal(t, runtime.ErrMemoryLimit, v.Run())
+
+	// the values created by the script are counted, with the elements
+	// shared by the operands counted once
+	for _, c := range []struct {
+		input string
+		size  uint64
+	}{
+		{`a := [1, 2, 3]`, 48},
+		{`a := {ab: 1, c: 2}`, 35},
+		{`a := "x"; b := a + "yz"`, 3},
+		{`a := bytes("x") + bytes("yz")`, 1 + 2 + 3},
+		{`a := [1, 2] + [3]`, 32 + 16 + 16},
+		{`a := append([1, 2], 3)`, 32 + 16},
+		{`a := [1, 2]; push(a, 3)`, 32},
+		{`a := "x"; for i := 0; i < 3; i++ { a += "y" }`, 2 + 3 + 4},
+		{`a := 1; b := a + 2; c := a + 1.5`, 0},
+	} {
+		v = limitsTestVM(t, c.input)
+		if v == nil {
+			return
+		}
+		v.SetMaxMemory(c.size)
+		assert.NoError(t, v.Run(), c.input)
+		if c.size > 0 {
+			v.SetMaxMemory(c.size - 1)
+			assert.Equal(t, runtime.ErrMemoryLimit, v.Run(), c.input)
+		}
+	}
+
+	// the count is reset for each run
+	v = limitsTestVM(t, `a := [1, 2, 3]`)
+	if v == nil {
+		return
+	}
+	v.SetMaxMemory(48)
+	assert.NoError(t, v.Run())
+	assert.NoError(t, v.Run())
+}
+
+// tagList is an object that is not comparable with == operator.
+type tagList struct {
+	objectImpl
+	tags []string
+}
+
+func (o tagList) BinaryOp(op token.Token, rhs objects.Object) (objects.Object, error) {
+	return tagList{tags: append(o.tags, rhs.(tagList).tags...)}, nil
+}
+
+func TestVMMaxMemoryUncomparable(t *testing.T) {
+	src := []byte(`b := a + a; c := f(a)`)
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	globals := make([]objects.Object, runtime.GlobalsSize)
+	globals[symbolTable.Define("a").Index] = tagList{tags: []string{"x"}}
+	globals[symbolTable.Define("f").Index] = &objects.UserFunction{
+		Value: func(args ...objects.Object) (objects.Object, error) {
+			return args[0], nil
+		},
+	}
+
+	c := compiler.NewCompiler(srcFile, symbolTable, nil, nil, nil)
+	if !assert.NoError(t, c.Compile(file)) {
+		return
+	}
+
+	v := runtime.NewVM(c.Bytecode(), globals, nil)
+	v.SetMaxMemory(1 << 20)
+	assert.NoError(t, v.Run())
+}
+
+func TestVMMaxMemoryConcurrent(t *testing.T) {
+	// each VM counts its own values, so the VM that allocates little is not
+	// stopped by the other one
+	heavy := limitsTestVM(t, `
+a := []
+for i := 0; i < 100000; i++ {
+	a = append(a, "x" + string(i))
+}`)
+	light := limitsTestVM(t, `
+n := 0
+for i := 0; i < 100000; i++ {
+	a := [i]
+	n += len(a)
+}`)
+	if heavy == nil || light == nil {
+		return
+	}
+	heavy.SetMaxMemory(64 << 10)
+	light.SetMaxMemory(4 << 20)
+
+	heavyErr := make(chan error, 1)
+	lightErr := make(chan error, 1)
+	go func() { heavyErr <- heavy.Run() }()
+	go func() { lightErr <- light.Run() }()
+	assert.Equal(t, runtime.ErrMemoryLimit, <-heavyErr)
+	assert.NoError(t, <-lightErr)
+}
+
+func TestVMAbort(t *testing.T) {
+	v := limitsTestVM(t, `for {}`)
+	if v == nil {
+		return
+	}
+	assert.False(t, v.IsRunning())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- v.Run()
+	}()
+	for !v.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	v.Abort()
+	assert.Equal(t, runtime.ErrAborted, <-done)
+	assert.False(t, v.IsRunning())
+
+	// aborted in a function called back by Go code
+	v = limitsTestVM(t, `
+stream([1, 2]).map(func(x) { for {} }).to_array()`)
+	if v == nil {
+		return
+	}
+	go func() {
+		done <- v.Run()
+	}()
+	for !v.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	v.Abort()
+	assert.Equal(t, runtime.ErrAborted, <-done)
+
+	// completed
+	v = limitsTestVM(t, `a := 1`)
+	if v == nil {
+		return
+	}
+	assert.NoError(t, v.Run())
+	v.Abort()
+	assert.NoError(t, v.Run())
+}
+
+func TestVMAborted(t *testing.T) {
+	v := limitsTestVM(t, `for {}`)
+	if v == nil {
+		return
+	}
+
+	aborted := v.Aborted()
+	done := make(chan error, 1)
+	go func() {
+		done <- v.Run()
+	}()
+	for !v.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-aborted:
+		assert.Fail(t, "closed before abort")
+	default:
+	}
+
+	v.Abort()
+	<-aborted
+	assert.Equal(t, runtime.ErrAborted, <-done)
+	<-v.Aborted() // still aborted
+
+	// the next run is not aborted
+	v = limitsTestVM(t, `a := 1`)
+	if v == nil {
+		return
+	}
+	v.Abort()
+	<-v.Aborted()
+	assert.NoError(t, v.Run())
+	select {
+	case <-v.Aborted():
+		assert.Fail(t, "closed after run")
+	default:
+	}
+}
+
+func limitsTestVM(t *testing.T, input string) *runtime.VM {
+	src := []byte(input)
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	c := compiler.NewCompiler(srcFile, nil, nil, nil, nil)
+	if !assert.NoError(t, c.Compile(file)) {
+		return nil
+	}
+
+	return runtime.NewVM(c.Bytecode(), nil, nil)
+}
@@ -37,4 +37,10 @@ out = m["foo"](2) + m["foo"](3)
 	expect(t, `m1 := {k1: 1, k2: "foo"}; m2 := m1; m2.k1 = 3; out = m1.k1`, 3)
 	expect(t, `func() { m1 := {k1: 1, k2: "foo"}; m2 := m1; m1.k1 = 5; out = m2.k1 }()`, 5)
 	expect(t, `func() { m1 := {k1: 1, k2: "foo"}; m2 := m1; m2.k1 = 3; out = m1.k1 }()`, 3)
+
+	// keys created at runtime (interned if equal to a constant)
+	expect(t, `m := {}; m["fo" + "o"] = 1; m["ba" + "z"] = 2; out = [m.foo, m["baz"], m.bar]`,
+		ARR{1, 2, objects.UndefinedValue})
+	expect(t, `m := {foo: 1}; k := "f"; k += "oo"; m[k] = 2; out = m`, MAP{"foo": 2})
+	expect(t, `m := {a: {}}; m.a["fo" + "o"] = 3; m.a.foo += 1; out = m.a.foo`, 4)
 }
@@ -0,0 +1,67 @@
+//go:build !tengo_embedded && !tinygo
+// +build !tengo_embedded,!tinygo
+
+package runtime_test
+
+import "testing"
+
+// TestStdLibHost tests the standard modules that are left out of the
+// embedded builds.
+func TestStdLibHost(t *testing.T) {
+	// os.File
+	expect(t, `
+os := import("os")
+
+write_file := func(filename, data) {
+	file := os.create(filename)
+	if !file { return file }
+
+	if res := file.write(bytes(data)); is_error(res) {
+		return res
+	}
+
+	return file.close()
+}
+
+read_file := func(filename) {
+	file := os.open(filename)
+	if !file { return file }
+
+	data := bytes(100)
+	cnt := file.read(data)
+	if  is_error(cnt) {
+		return cnt
+	}
+
+	file.close()
+	return data[:cnt]
+}
+
+if write_file("./temp", "foobar") {
+	out = string(read_file("./temp"))
+}
+
+os.remove("./temp")
+`, "foobar")
+
+	// exec.command
+	expect(t, `
+os := import("os")
+cmd := os.exec("echo", "foo", "bar")
+if !is_error(cmd) { 
+	out = cmd.output()
+}
+`, []byte("foo bar\n"))
+
+	// graphql
+	expect(t, `
+graphql := import("graphql")
+out = graphql.build("query", ["user(id: $id)", ["name", {friends: ["name"]}]], {id: "ID!"})
+`, "query($id: ID!) { user(id: $id) { name friends { name } } }")
+
+	// x509
+	expect(t, `
+x509 := import("x509")
+out = [is_error(x509.parse("")), is_error(x509.verify(bytes("")))]
+`, ARR{true, true})
+}
@@ -3,7 +3,12 @@ package runtime_test
 import (
 	"testing"
 
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
 	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
 )
 
 func TestStdLib(t *testing.T) {
@@ -13,51 +18,146 @@ func TestStdLib(t *testing.T) {
 	expect(t, `math := import("math"); out = math.abs(1.0)`, 1.0)
 	expect(t, `math := import("math"); out = math.abs(-1.0)`, 1.0)
 
-	// os.File
+	// container: heap with a comparator closure
 	expect(t, `
-os := import("os")
-
-write_file := func(filename, data) {
-	file := os.create(filename)
-	if !file { return file }
+container := import("container")
+h := container.heap(func(a, b) { return a.p > b.p })
+h.push({n: "a", p: 1}, {n: "b", p: 3}, {n: "c", p: 2})
+out = [h.pop().n, h.pop().n, h.pop().n, h.pop()]
+`, ARR{"b", "c", "a", objects.UndefinedValue})
+	expectError(t, `container := import("container"); h := container.heap(func(a) { return true }); h.push(1, 2)`, "wrong number of arguments")
+
+	// ip
+	expect(t, `ip := import("ip"); out = []; for a in ip.range("10.0.0.0/31") { out = append(out, a) }`, ARR{"10.0.0.0", "10.0.0.1"})
+
+	// reflect
+	expect(t, `reflect := import("reflect"); out = reflect.arity(func(a, b) {})`, 2)
+	expect(t, `reflect := import("reflect"); out = reflect.arity(len)`, objects.UndefinedValue)
+	expect(t, `reflect := import("reflect"); out = reflect.source_pos(func() {})`, "test:1:56")
+	expect(t, `reflect := import("reflect")
+x := 1
+f := func() { return x }
+out = reflect.source_pos(f)`, "test:3:6")
+	expect(t, `reflect := import("reflect"); out = reflect.source_pos(string)`, objects.UndefinedValue)
+	expect(t, `reflect := import("reflect"); out = reflect.methods(iter([1]))`, ARR{"next", "key", "value"})
+	expect(t, `reflect := import("reflect"); out = reflect.fields({a: 1, f: func() {}})`, ARR{"a"})
+
+	// retry
+	expect(t, `
+retry := import("retry")
+n := 0
+out = retry.run(func() { n++; return n < 3 ? error("busy") : n }, {delay: 0})
+`, 3)
+	expect(t, `
+retry := import("retry")
+out = retry.run(func() { return 1 }, {delay: 0, retry_on: func(res) { return res < 2 }})
+`, &objects.Error{Value: &objects.String{Value: "3 attempts failed: 1; 1; 1"}})
+	expectError(t, `retry := import("retry"); retry.run(func() { return 1 + "a" })`, "invalid operation")
 
-	if res := file.write(bytes(data)); is_error(res) {
-		return res
-	}
+	// ratelimit
+	expect(t, `
+ratelimit := import("ratelimit")
+limiter := ratelimit.token_bucket(1000, 2)
+out = [limiter.try_acquire(), limiter.try_acquire(), limiter.wait(), limiter.try_acquire(2)]
+`, ARR{true, true, objects.UndefinedValue, false})
 
-	return file.close()
-}
+	// cache
+	expect(t, `
+cache := import("cache")
+calls := 0
+fib := undefined
+fib = cache.memoize(func(n) { calls++; return n < 2 ? n : fib(n-1) + fib(n-2) })
+out = [fib(50), calls]
+`, ARR{12586269025, 51})
+	expect(t, `
+cache := import("cache")
+c := cache.lru(2)
+c.set([1, "a"], 1)
+c.set({a: 1}, 2)
+c.get([1, "a"])
+c.set("b", 3)
+out = [c.get([1, "a"]), c.get({a: 1}, -1), c.len()]
+`, ARR{1, -1, 2})
+
+	// diff
+	expect(t, `
+diff := import("diff")
+a := {name: "web", ports: [80, 443], env: {debug: true}}
+b := {name: "web", ports: [8080], env: {}}
+ops := diff.diff(a, b)
+out = [len(ops), diff.patch(a, ops) == b, a.ports]
+`, ARR{3, true, ARR{80, 443}})
+	expect(t, `
+diff := import("diff")
+out = diff.patch({a: 1}, [{op: "test", path: "/a", value: 2}])
+`, &objects.Error{Value: &objects.String{Value: "operation 0: test failed: /a"}})
 
-read_file := func(filename) {
-	file := os.open(filename)
-	if !file { return file }
+	// textdiff
+	expect(t, `
+textdiff := import("textdiff")
+out = textdiff.unified("a\nb\n", "a\nc\n", {context: 0})
+`, "--- a\n+++ b\n@@ -2 +2 @@\n-b\n+c\n")
+	expect(t, `
+textdiff := import("textdiff")
+m := textdiff.merge("a\nb\nc\nd\n", "a\nB\nc\nd\n", "a\nb\nc\nD\n")
+out = [m.text, m.conflicts]
+`, ARR{"a\nB\nc\nD\n", 0})
 
-	data := bytes(100)
-	cnt := file.read(data)
-	if  is_error(cnt) {
-		return cnt
+	// markdown
+	expect(t, `
+markdown := import("markdown")
+out = markdown.to_html("# Hi\n\n- **a**\n- [b](/b)")
+`, "<h1>Hi</h1>\n<ul>\n<li><strong>a</strong></li>\n<li><a href=\"/b\">b</a></li>\n</ul>\n")
+	expect(t, `
+markdown := import("markdown")
+doc := markdown.parse("# A\n\ntext\n\n## B")
+out = []
+for n in doc.children {
+	if n.type == "heading" {
+		out = append(out, [n.level, n.children[0].text])
 	}
-
-	file.close()
-	return data[:cnt]
 }
+`, ARR{ARR{1, "A"}, ARR{2, "B"}})
 
-if write_file("./temp", "foobar") {
-	out = string(read_file("./temp"))
-}
+	// proto
+	expect(t, `
+proto := import("proto")
+r := proto.load(bytes(""))
+out = [r.messages(), is_error(proto.load(bytes("\x0a\x05")))]
+`, ARR{ARR{}, true})
 
-os.remove("./temp")
-`, "foobar")
+	// msgpack
+	expect(t, `
+msgpack := import("msgpack")
+out = msgpack.decode(msgpack.encode({a: [1, "x", true], b: bytes("y")}))
+`, MAP{"a": ARR{1, "x", true}, "b": []byte("y")})
+	expect(t, `out = is_error(import("msgpack").encode(func(){}))`, true)
 
-	// exec.command
+	// cbor
 	expect(t, `
-os := import("os")
-cmd := os.exec("echo", "foo", "bar")
-if !is_error(cmd) { 
-	out = cmd.output()
-}
-`, []byte("foo bar\n"))
+cbor := import("cbor")
+out = cbor.decode(cbor.encode({a: [1, "x", true], b: 1.5}))
+`, MAP{"a": ARR{1, "x", true}, "b": 1.5})
+	expect(t, `out = import("cbor").encode([1, -1])`, []byte{0x82, 0x01, 0x20})
 
+	// jwt
+	expect(t, `
+jwt := import("jwt")
+times := import("times")
+token := jwt.sign({sub: "a", exp: times.add(times.now(), times.hour)}, "secret")
+claims := jwt.verify(token, "secret")
+out = [claims.sub, is_error(jwt.verify(token, "other"))]
+`, ARR{"a", true})
+
+	// table
+	expect(t, `
+table := import("table")
+sales := table.from_csv("region,amount\neast,10\nwest,5\neast,2\n", {infer: true})
+big := sales.filter(func(row) { return row.amount > 3 })
+totals := sales.group_by("region").aggregate({total: ["sum", "amount"]})
+out = [big.len(), sales[0].region, totals.to_csv()]
+for row in totals { out = append(out, row.total) }
+`, ARR{2, "east", "region,total\neast,12\nwest,5\n", 12, 5})
 }
 
 func TestUserModules(t *testing.T) {
@@ -246,3 +346,37 @@ export func() {
 	`,
 	})
 }
+
+func TestBuiltinModule(t *testing.T) {
+	var calls int
+	var mod objects.Object = &objects.BuiltinModule{
+		Name: "mod",
+		Members: func() map[string]objects.Object {
+			calls++
+			return map[string]objects.Object{"a": &objects.Int{Value: 1}}
+		},
+	}
+
+	src := []byte(`out = import("mod").a + import("mod").a`)
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	out := symbolTable.Define("out")
+	c := compiler.NewCompiler(srcFile, symbolTable, nil, map[string]bool{"mod": true}, nil)
+	if !assert.NoError(t, c.Compile(file)) {
+		return
+	}
+
+	// the members are created on the first import
+	v := runtime.NewVM(c.Bytecode(), nil, map[string]*objects.Object{"mod": &mod})
+	assert.Equal(t, 0, calls)
+	assert.NoError(t, v.Run())
+	assert.NoError(t, v.Run())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, int64(2), v.Globals()[out.Index].(*objects.Int).Value)
+}
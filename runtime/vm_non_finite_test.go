@@ -0,0 +1,46 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/objects"
+)
+
+func TestNonFiniteFloat(t *testing.T) {
+	defer func(policy objects.NonFinitePolicy) { objects.NonFinite = policy }(objects.NonFinite)
+
+	// comparisons
+	expect(t, `n := float("NaN"); out = [n == n, n != n, n < 1.0, n > 1.0, n <= n, n >= 1.0, [n] == [n]]`,
+		ARR{false, true, false, false, false, false, false})
+	expect(t, `i := float("Inf"); out = [i == i, i > 1e308, -i < -1e308, i > 1, -i < 1]`,
+		ARR{true, true, true, true, true})
+	expect(t, `out = 0; n := float("NaN"); if n < 1.0 { out = 1 } else if n >= 1.0 { out = 2 }`, 0)
+
+	// conversions and formatting
+	expect(t, `out = [int(float("NaN")), int(float("Inf"), -1), int(float("-Inf"), 0), int(1.5)]`,
+		ARR{objects.UndefinedValue, -1, 0, 1})
+	expect(t, `n := float("NaN"); i := float("Inf"); out = [string(n), string(i), string(-i), sprintf("%v %.2f %v", n, i, -i)]`,
+		ARR{"NaN", "+Inf", "-Inf", "NaN +Inf -Inf"})
+	expectError(t, `a := [1, 2]; a[float("NaN")] = 3`, "invalid index type")
+
+	// json encoding and map keys
+	const src = `
+n := float("NaN"); i := float("Inf")
+m := {}
+m[1.5] = 1
+res := [is_error(to_json([n])) ? "error" : string(to_json([n, i, -i, 1.5]))]
+m[n] = 2
+m[i] = 3
+res = append(res, m)`
+
+	objects.NonFinite = objects.NonFiniteError
+	expectError(t, src, "invalid index type: float")
+
+	objects.NonFinite = objects.NonFiniteNull
+	expectError(t, src, "invalid index type: float")
+	expect(t, `n := float("NaN"); i := float("Inf"); out = string(to_json({a: [n, i, -i, 1.5]}))`,
+		`{"a":[null,null,null,1.5]}`)
+
+	objects.NonFinite = objects.NonFiniteString
+	expect(t, src+"\nout = res", ARR{`["NaN","+Inf","-Inf",1.5]`, MAP{"1.5": 1, "NaN": 2, "+Inf": 3}})
+}
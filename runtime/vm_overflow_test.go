@@ -0,0 +1,77 @@
+package runtime_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/runtime"
+)
+
+func TestIntOverflow(t *testing.T) {
+	const src = `
+max := 9223372036854775807
+min := -max - 1
+out = [max + 1, min - 1, max * 2, min * -1, min / -1, -min, max - 1, 6 * 7]`
+
+	expectOverflow(t, runtime.IntOverflowWrap, src,
+		`[-9223372036854775808, 9223372036854775807, -2, -9223372036854775808, -9223372036854775808, -9223372036854775808, 9223372036854775806, 42]`)
+	expectOverflow(t, runtime.IntOverflowSaturate, src,
+		`[9223372036854775807, -9223372036854775808, 9223372036854775807, 9223372036854775807, 9223372036854775807, 9223372036854775807, 9223372036854775806, 42]`)
+	expectOverflow(t, runtime.IntOverflowBigInt, src,
+		`[9223372036854775808, -9223372036854775809, 18446744073709551614, 9223372036854775808, 9223372036854775808, 9223372036854775808, 9223372036854775806, 42]`)
+
+	// the local variable incremented by a constant
+	expectOverflow(t, runtime.IntOverflowSaturate, `
+out = func() {
+	a := 9223372036854775800
+	for i := 0; i < 10; i++ { a += 1 }
+	return a
+}()`, `9223372036854775807`)
+
+	// big integers
+	expectOverflow(t, runtime.IntOverflowBigInt, `
+f := 1
+for i := 1; i <= 25; i++ { f *= i }
+out = [f, type_name(f), f / 1000000000000, f > 1, 1 < f, f == f * 1, f - f + 1, type_name(f - f), float(f) > 1.0]`,
+		`[15511210043330985984000000, "bigint", 15511210043330, true, true, true, 1, "int", true]`)
+
+	// run-time error
+	expectOverflowError(t, runtime.IntOverflowError, `a := 9223372036854775807; b := a + 1`,
+		"test:1:32: integer overflow: 9223372036854775807 + 1")
+	expectOverflowError(t, runtime.IntOverflowError, `a := -9223372036854775807 - 1; b := -a`,
+		"integer overflow: -(-9223372036854775808)")
+	expectOverflowError(t, runtime.IntOverflowError, `func() { a := 9223372036854775807; a += 1 }()`,
+		"integer overflow: 9223372036854775807 + 1")
+	expectOverflow(t, runtime.IntOverflowError, `a := 9223372036854775806; out = a + 1`, `9223372036854775807`)
+}
+
+func expectOverflow(t *testing.T, mode runtime.IntOverflow, input, expected string) {
+	bytecode, symbolTable, err := optimizedBytecode(input)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	v := runtime.NewVM(bytecode, nil, nil)
+	v.SetIntOverflow(mode)
+	if !assert.NoError(t, v.Run()) {
+		return
+	}
+
+	symbol, _, _ := symbolTable.Resolve("out")
+	assert.Equal(t, expected, v.Globals()[symbol.Index].String())
+}
+
+func expectOverflowError(t *testing.T, mode runtime.IntOverflow, input, expected string) {
+	bytecode, _, err := optimizedBytecode(input)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	v := runtime.NewVM(bytecode, nil, nil)
+	v.SetIntOverflow(mode)
+	err = v.Run()
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), expected), "expected error string: %s, got: %s", expected, err.Error())
+	}
+}
@@ -0,0 +1,91 @@
+package runtime_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+func TestParallelMap(t *testing.T) {
+	v, symbolTable, err := optimizedVM(`
+fib := func(x) { return x <= 1 ? x : fib(x-1) + fib(x-2) }
+out = func(x) { return fib(x) }`)
+	if !assert.NoError(t, err) || !assert.NoError(t, v.Run()) {
+		return
+	}
+	symbol, _, _ := symbolTable.Resolve("out")
+	fn := v.Globals()[symbol.Index]
+
+	var elems []objects.Object
+	for i := 0; i < 20; i++ {
+		elems = append(elems, &objects.Int{Value: int64(i)})
+	}
+
+	for _, workers := range []int{0, 1, 3, 100} {
+		res, err := v.ParallelMap(fn, elems, workers)
+		if !assert.NoError(t, err) || !assert.Equal(t, len(elems), len(res)) {
+			continue
+		}
+		assert.Equal(t, int64(0), res[0].(*objects.Int).Value)
+		assert.Equal(t, int64(55), res[10].(*objects.Int).Value)
+		assert.Equal(t, int64(4181), res[19].(*objects.Int).Value)
+	}
+
+	res, err := v.ParallelMap(fn, nil, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(res))
+
+	// the run-time error
+	_, err = v.ParallelMap(fn, []objects.Object{&objects.Int{Value: 1}, &objects.String{Value: "a"}}, 2)
+	assert.Error(t, err)
+	assert.True(t, err != nil && strings.Contains(err.Error(), "invalid operation"), err)
+}
+
+func TestParallelMapFunction(t *testing.T) {
+	symbols := func() SYM {
+		return SYM{"parallel_map": runtime.ParallelMapFunction}
+	}
+
+	expectWithSymbols(t, `out = parallel_map([1, 2, 3, 4, 5], func(x) { return x * x })`, ARR{1, 4, 9, 16, 25}, symbols())
+	expectWithSymbols(t, `out = parallel_map(immutable([1, 2, 3]), func(x) { return x * 2 }, 2)`, ARR{2, 4, 6}, symbols())
+	expectWithSymbols(t, `out = parallel_map([], func(x) { return x })`, ARR{}, symbols())
+
+	// closures and globals
+	expectWithSymbols(t, `n := 10; f := func(y) { return func(x) { return x + y + n } }(5); out = parallel_map([1, 2], f, 2)`, ARR{16, 17}, symbols())
+	expectWithSymbols(t, `n := 1; parallel_map([1, 2, 3], func(x) { n = x }); out = n`, 1, symbols())
+
+	expectErrorWithSymbols(t, `parallel_map([1])`, symbols(), "wrong number of arguments")
+	expectErrorWithSymbols(t, `parallel_map(1, func(x) { return x })`, symbols(), "invalid type for argument 'first'")
+	expectErrorWithSymbols(t, `parallel_map([1], len)`, symbols(), "invalid type for argument 'second'")
+	expectErrorWithSymbols(t, `parallel_map([1], func(x) { return x }, "a")`, symbols(), "invalid type for argument 'third'")
+	expectErrorWithSymbols(t, `parallel_map([1, 2, 3], func(x) { return x + {} }, 3)`, symbols(), "invalid operation: int + map")
+}
+
+func TestSharedBytecode(t *testing.T) {
+	bytecode, symbolTable, err := optimizedBytecode(`
+times := import("times")
+s := "héllo"
+out = [s[1], times.format_kitchen[0], func() { a := [1, 2]; a[0] = 3; return a }()]`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	symbol, _, _ := symbolTable.Resolve("out")
+
+	// multiple VMs running the same bytecode (run with -race)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			machine := runtime.NewVM(bytecode, nil, nil)
+			if assert.NoError(t, machine.Run()) {
+				assert.Equal(t, `[é, 3, [3, 2]]`, machine.Globals()[symbol.Index].String())
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,63 @@
+package runtime_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/runtime"
+)
+
+func TestVMProfile(t *testing.T) {
+	src := []byte(`
+fib := func(n) {
+	if n < 2 { return n }
+	return fib(n - 1) + fib(n - 2)
+}
+a := fib(10)`)
+
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := compiler.NewCompiler(srcFile, nil, nil, nil, nil)
+	if !assert.NoError(t, c.Compile(file)) {
+		return
+	}
+
+	profile := runtime.NewProfile()
+	v := runtime.NewVM(c.Bytecode(), nil, nil)
+	v.SetProfile(profile)
+	if !assert.NoError(t, v.Run()) {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, e := range profile.Functions() {
+		counts[e.Name] = e.Count
+	}
+	assert.Equal(t, 2, len(counts))
+	assert.Equal(t, 6, counts["(main)"])
+	assert.True(t, counts["func@test:3"] > 177) // fib is called 177 times
+
+	counts = make(map[string]int)
+	for _, e := range profile.Lines() {
+		counts[e.Name] = e.Count
+	}
+	assert.Equal(t, 4, len(counts))
+	assert.True(t, counts["test:3"] > 177*3)
+	assert.Equal(t, 2, counts["test:2"]) // CONST, SETG
+
+	var buf bytes.Buffer
+	assert.NoError(t, profile.WriteText(&buf))
+	assert.True(t, strings.Contains(buf.String(), "  function\n"))
+	assert.True(t, strings.Contains(buf.String(), "  func@test:3\n"))
+	assert.True(t, strings.Contains(buf.String(), "  test:4\n"))
+}
@@ -2,6 +2,8 @@ package runtime_test
 
 import (
 	"testing"
+
+	"github.com/d5/tengo/objects"
 )
 
 func TestReturn(t *testing.T) {
@@ -21,4 +23,9 @@ func TestReturn(t *testing.T) {
 	}()`, 10)
 
 	expect(t, `f1 := func() { return 2 * 5; }; out = f1()`, 10)
+
+	// the paths that do not return
+	expect(t, `f := func(x) { if x { return 1 } }; out = [f(true), f(false)]`, ARR{1, objects.UndefinedValue})
+	expect(t, `f := func(x) { if x { y := 1 } else { return 2 } }; out = [f(true), f(false)]`, ARR{objects.UndefinedValue, 2})
+	expect(t, `f := func(x) { for x > 0 { if x == 5 { break }; x--; return x } }; out = [f(5), f(3)]`, ARR{objects.UndefinedValue, 2})
 }
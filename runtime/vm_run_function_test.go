@@ -0,0 +1,56 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+func TestVMRunFunction(t *testing.T) {
+	v := limitsTestVM(t, `
+count := 0
+f := func(x) { count++; if x < 0 { return x + "a" }; return x * 2 }
+loop := func() { for {} }`)
+	if v == nil {
+		return
+	}
+	assert.NoError(t, v.Run())
+	f, loop := v.Globals()[1], v.Globals()[2]
+
+	res, err := v.RunFunction(f, &objects.Int{Value: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Int{Value: 6}, res)
+
+	// the VM is restored after the error
+	_, err = v.RunFunction(f, &objects.Int{Value: -1})
+	if assert.Error(t, err) {
+		e, ok := err.(*runtime.Error)
+		if assert.True(t, ok) {
+			assert.Equal(t, 3, e.Pos.Line)
+		}
+	}
+	res, err = v.RunFunction(f, &objects.Int{Value: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Int{Value: 8}, res)
+	assert.Equal(t, &objects.Int{Value: 3}, v.Globals()[0])
+
+	// the instruction limit applies to each call
+	v.SetMaxInstructions(1000)
+	_, err = v.RunFunction(loop)
+	assert.Equal(t, runtime.ErrInstructionLimit, err)
+	res, err = v.RunFunction(f, &objects.Int{Value: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Int{Value: 2}, res)
+
+	// the abort of the previous run is cleared
+	v.Abort()
+	res, err = v.RunFunction(f, &objects.Int{Value: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Int{Value: 4}, res)
+
+	_, err = v.RunFunction(objects.UndefinedValue)
+	assert.Error(t, err)
+	assert.NoError(t, v.Run())
+}
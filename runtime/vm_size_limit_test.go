@@ -0,0 +1,38 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/objects"
+)
+
+func TestVMSizeLimit(t *testing.T) {
+	defer func(s, b, a int) {
+		objects.MaxStringLen, objects.MaxBytesLen, objects.MaxArrayLen = s, b, a
+	}(objects.MaxStringLen, objects.MaxBytesLen, objects.MaxArrayLen)
+	objects.MaxStringLen, objects.MaxBytesLen, objects.MaxArrayLen = 8, 8, 4
+
+	// string
+	expect(t, `out = "abcd" + "efgh"`, "abcdefgh")
+	expect(t, `out = "abcd" + 1234`, "abcd1234")
+	expectError(t, `s := "abcd" + "efghi"`, "exceeding string size limit")
+	expectError(t, `s := "abcd" + 12345`, "exceeding string size limit")
+	expectError(t, `s := "a"; for i := 0; i < 10; i++ { s += s }`, "exceeding string size limit")
+	expectError(t, `s := string(bytes("abcdefghi"))`, "exceeding bytes size limit")
+	expectError(t, `s := string([1, 2, 3])`, "exceeding string size limit")
+
+	// bytes
+	expect(t, `out = len(bytes("abcd") + bytes("efgh"))`, 8)
+	expect(t, `out = len(bytes(8))`, 8)
+	expectError(t, `b := bytes("abcd") + bytes("efghi")`, "exceeding bytes size limit")
+	expectError(t, `b := bytes(9)`, "exceeding bytes size limit")
+	expectError(t, `b := bytes(1 << 62)`, "exceeding bytes size limit")
+
+	// array
+	expect(t, `out = [1, 2] + [3, 4]`, ARR{1, 2, 3, 4})
+	expect(t, `out = append([1, 2], 3, 4)`, ARR{1, 2, 3, 4})
+	expectError(t, `a := [1, 2] + [3, 4, 5]`, "exceeding array size limit")
+	expectError(t, `a := immutable([1, 2]) + immutable([3, 4, 5])`, "exceeding array size limit")
+	expectError(t, `a := append([1, 2, 3], 4, 5)`, "exceeding array size limit")
+	expectError(t, `a := []; for i := 0; i < 10; i++ { a = append(a, i) }`, "exceeding array size limit")
+}
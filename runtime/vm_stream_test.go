@@ -0,0 +1,42 @@
+package runtime_test
+
+import (
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	expect(t, `out = stream([1, 2, 3]).to_array()`, ARR{1, 2, 3})
+	expect(t, `out = stream([1, 2, 3]).map(func(x) { return x * 2 }).to_array()`, ARR{2, 4, 6})
+	expect(t, `out = stream([1, 2, 3, 4]).filter(func(x) { return x % 2 == 0 }).to_array()`, ARR{2, 4})
+	expect(t, `out = stream([1, 2, 3, 4]).skip(1).take(2).to_array()`, ARR{2, 3})
+	expect(t, `out = stream([1, 2, 3]).skip(5).to_array()`, ARR{})
+	expect(t, `out = stream([1, 2, 3, 4]).reduce(func(acc, x) { return acc + x }, 10)`, 20)
+	expect(t, `out = stream([]).reduce(func(acc, x) { return acc + x }, 10)`, 10)
+	expect(t, `out = stream("abc").map(func(c) { return string(c) + "!" }).to_array()`, ARR{"a!", "b!", "c!"})
+	expect(t, `out = stream(immutable([1, 2])).map(func(x) { return x + 1 }).to_array()`, ARR{2, 3})
+
+	// closures and lazy evaluation
+	expect(t, `n := 0; f := func(x) { n++; return x }; s := stream([1, 2, 3, 4]).map(f).take(2); out = [n, s.to_array(), n]`, ARR{0, ARR{1, 2}, 2})
+	expect(t, `factor := 3; out = stream([1, 2]).map(func(x) { return x * factor }).to_array()`, ARR{3, 6})
+	expect(t, `s := stream([1, 2]).map(func(x) { return x * 10 }); out = [s.to_array(), s.to_array()]`, ARR{ARR{10, 20}, ARR{10, 20}})
+
+	// for-in
+	expect(t, `for i, x in stream([1, 2, 3]).map(func(x) { return x * x }) { out += i + x }`, 17)
+
+	// builtin functions as callbacks
+	expect(t, `out = stream([1, 2]).map(string).to_array()`, ARR{"1", "2"})
+
+	// nested streams
+	expect(t, `out = stream([[1, 2], [3]]).map(func(a) { return stream(a).reduce(func(s, x) { return s + x }, 0) }).to_array()`, ARR{3, 3})
+
+	// recursion inside callbacks
+	expect(t, `fib := func(x) { return x <= 1 ? x : fib(x-1) + fib(x-2) }; out = stream([5, 10]).map(fib).to_array()`, ARR{5, 55})
+
+	expectError(t, `stream(1)`, "invalid type for argument 'first'")
+	expectError(t, `stream([1]).take(-1)`, "index out of bounds")
+	expectError(t, `stream([1]).map(func(x, y) { return x }).to_array()`, "wrong number of arguments")
+	expectError(t, `stream([1, 0]).map(func(x) {
+	return x + {}
+}).to_array()`, "test:2:9: invalid operation: int + map")
+	expectError(t, `for x in stream([1]).map(func(x) { return x.y.z }) {}`, "not indexable")
+}
@@ -0,0 +1,62 @@
+package runtime_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/runtime"
+)
+
+func TestStrictIndex(t *testing.T) {
+	const src = `
+arr := [1, 2, 3]
+out = [arr[2], arr[99], "héllo"[4], "abc"[3], bytes("ab")[-1], immutable([1])[1], {a: 1}.b, [undefined][0]]`
+
+	expectStrictIndex(t, false, src, `[3, <undefined>, o, <undefined>, <undefined>, <undefined>, <undefined>, <undefined>]`)
+
+	// maps and in-range undefined elements are not affected
+	expectStrictIndex(t, true, `out = [{a: 1}.b, [undefined][0], "héllo"[4], bytes("ab")[1]]`,
+		`[<undefined>, <undefined>, o, 98]`)
+
+	expectStrictIndexError(t, `arr := [1, 2, 3]; b := arr[99]`,
+		"test:1:24: index out of bounds: 99 (length 3)")
+	expectStrictIndexError(t, `b := bytes("ab")[-1]`,
+		"test:1:6: index out of bounds: -1 (length 2)")
+	expectStrictIndexError(t, `b := "héllo"[5]`,
+		"index out of bounds: 5 (length 5)")
+	expectStrictIndexError(t, `b := immutable([1])[1]`,
+		"index out of bounds: 1 (length 1)")
+	expectStrictIndexError(t, `f := func(a) { return a[1] }; f([])`,
+		"test:1:23: index out of bounds: 1 (length 0)")
+}
+
+func expectStrictIndex(t *testing.T, strict bool, input, expected string) {
+	bytecode, symbolTable, err := optimizedBytecode(input)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	v := runtime.NewVM(bytecode, nil, nil)
+	v.SetStrictIndex(strict)
+	if !assert.NoError(t, v.Run()) {
+		return
+	}
+
+	symbol, _, _ := symbolTable.Resolve("out")
+	assert.Equal(t, expected, v.Globals()[symbol.Index].String())
+}
+
+func expectStrictIndexError(t *testing.T, input, expected string) {
+	bytecode, _, err := optimizedBytecode(input)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	v := runtime.NewVM(bytecode, nil, nil)
+	v.SetStrictIndex(true)
+	err = v.Run()
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), expected), "expected error string: %s, got: %s", expected, err.Error())
+	}
+}
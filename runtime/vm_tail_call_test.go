@@ -109,3 +109,64 @@ func() {
 	out = f2(5, 0)
 }()`, 25)
 }
+
+// tail calls to other functions
+func TestTailCallOtherFunctions(t *testing.T) {
+	// mutual recursion
+	expect(t, `
+is_even := undefined
+is_odd := func(n) {
+	if n == 0 {
+		return false
+	}
+	return is_even(n-1)
+}
+is_even = func(n) {
+	if n == 0 {
+		return true
+	}
+	return is_odd(n-1)
+}
+out = [is_even(10000), is_odd(10001), is_even(7)]`, ARR{true, true, false})
+
+	// closures with different free variables and locals
+	expect(t, `
+make_counter := func(step) {
+	count := 0
+	f := undefined
+	f = func(n, next) {
+		count += step
+		if n == 0 {
+			return count
+		}
+		return next(n-1, f)
+	}
+	return f
+}
+a := make_counter(1)
+b := make_counter(10)
+out = [a(5000, b), b(0, a)]`, ARR{2501, 25010})
+
+	// continuation-passing style
+	expect(t, `
+sum := func(n, k) {
+	if n == 0 {
+		return k(0)
+	}
+	return sum(n-1, func(s) { return k(s + n) })
+}
+out = sum(500, func(s) { return s })`, 125250)
+
+	// compiled function without free variables
+	expect(t, `
+g := func(x, y, z) { a := x + y; b := a + z; return b }
+f := func(n) { return g(n, 1, 2) }
+out = f(3)`, 6)
+
+	// non-tail calls keep the frames
+	expectError(t, `
+g := undefined
+f := func(n) { return g(n) + 1 }
+g = func(n) { return f(n) }
+f(1)`, "stack overflow")
+}
@@ -212,16 +212,12 @@ func traceCompileRun(file *ast.File, symbols map[string]objects.Object, userModu
 		}
 	}()
 
-	globals := make([]*objects.Object, runtime.GlobalsSize)
+	globals := make([]objects.Object, runtime.GlobalsSize)
 
 	symTable := compiler.NewSymbolTable()
 	for name, value := range symbols {
 		sym := symTable.Define(name)
-
-		// should not store pointer to 'value' variable
-		// which is re-used in each iteration.
-		valueCopy := value
-		globals[sym.Index] = &valueCopy
+		globals[sym.Index] = value
 	}
 	for idx, fn := range objects.Builtins {
 		symTable.DefineBuiltin(idx, fn.Name)
@@ -258,7 +254,7 @@ func traceCompileRun(file *ast.File, symbols map[string]objects.Object, userModu
 				return
 			}
 
-			res[name] = *globals[sym.Index]
+			res[name] = globals[sym.Index]
 		}
 		trace = append(trace, fmt.Sprintf("\n[Globals]\n\n%s", strings.Join(formatGlobals(globals), "\n")))
 
@@ -272,13 +268,13 @@ func traceCompileRun(file *ast.File, symbols map[string]objects.Object, userModu
 	return
 }
 
-func formatGlobals(globals []*objects.Object) (formatted []string) {
+func formatGlobals(globals []objects.Object) (formatted []string) {
 	for idx, global := range globals {
 		if global == nil {
 			return
 		}
 
-		switch global := (*global).(type) {
+		switch global := global.(type) {
 		case *objects.Closure:
 			formatted = append(formatted, fmt.Sprintf("[% 3d] (Closure|%p)", idx, global))
 			for _, l := range compiler.FormatInstructions(global.Fn.Instructions, 0) {
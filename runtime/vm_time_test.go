@@ -0,0 +1,29 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/objects"
+)
+
+func TestTimeSelectors(t *testing.T) {
+	date := `times := import("times"); t := times.date(2019, 1, 2, 15, 4, 5, 6); `
+	expect(t, date+`out = [t.year, t.month, t.day, t.hour, t.minute, t.second, t.nanosecond]`, ARR{2019, 1, 2, 15, 4, 5, 6})
+	expect(t, date+`out = [t.weekday, t.year_day, t.is_zero]`, ARR{3, 2, false})
+	expect(t, date+`out = t.add(times.hour).hour`, 16)
+	expect(t, date+`out = t.add_date(0, 1, 1).format("2006-01-02")`, "2019-02-03")
+	expect(t, date+`out = t.add(times.second).sub(t)`, 1000000000)
+	expect(t, date+`u := t.add(1); out = [t.before(u), t.after(u), t.equal(u), t.equal(t)]`, ARR{true, false, false, true})
+	expect(t, date+`out = t.truncate(times.hour).minute`, 0)
+	expect(t, date+`out = t.round(times.hour).hour`, 15)
+	expect(t, date+`out = t.format("15:04:05")`, "15:04:05")
+
+	expect(t, `times := import("times"); t := times.unix(1546441445, 7); out = [t.unix, t.unix_nano]`, ARR{1546441445, 1546441445000000007})
+	expect(t, `times := import("times"); t := times.unix(1546441445, 0).to_utc(); out = [t.hour, t.location]`, ARR{15, "UTC"})
+	expect(t, `times := import("times"); out = times.unix(0, 0).to_utc().to_local().unix`, 0)
+	expect(t, `times := import("times"); out = times.now().foo`, objects.UndefinedValue)
+
+	expectError(t, `times := import("times"); times.now().add("x")`, "invalid type for argument 'first'")
+	expectError(t, `times := import("times"); times.now().sub(1, 2)`, "wrong number of arguments")
+	expectError(t, `times := import("times"); times.now()[1]`, "invalid index type")
+}
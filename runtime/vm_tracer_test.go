@@ -0,0 +1,121 @@
+package runtime_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+type recordingTracer struct {
+	insts  int
+	lines  []int // lines in the order of the first execution
+	seen   map[int]bool
+	events []string
+	err    error
+}
+
+func (r *recordingTracer) OnInstruction(frame runtime.TraceFrame) {
+	r.insts++
+	if !r.seen[frame.Pos.Line] {
+		r.seen[frame.Pos.Line] = true
+		r.lines = append(r.lines, frame.Pos.Line)
+	}
+}
+
+func (r *recordingTracer) OnCall(frame runtime.TraceFrame, callee objects.Object, args []objects.Object) {
+	r.events = append(r.events, fmt.Sprintf("call %s %s:%d depth=%d %s args=%v",
+		compiler.OpcodeNames[frame.Opcode], frame.Pos.Filename, frame.Pos.Line, frame.Depth, callee.TypeName(), args))
+}
+
+func (r *recordingTracer) OnReturn(frame runtime.TraceFrame, value objects.Object) {
+	r.events = append(r.events, fmt.Sprintf("return %s:%d depth=%d %s", frame.Pos.Filename, frame.Pos.Line, frame.Depth, value))
+}
+
+func (r *recordingTracer) OnError(err error) {
+	r.err = err
+}
+
+func TestVMTracer(t *testing.T) {
+	tracer := traceScript(t, `
+f := func(x) {
+	return x * 2
+}
+g := func() {}
+a := f(1) + len([1, 2])
+g()`)
+	assert.NoError(t, tracer.err)
+	assert.True(t, tracer.insts > 10, "insts: %d", tracer.insts)
+	assert.Equal(t, []int{2, 5, 6, 3, 7}, tracer.lines)
+	assert.Equal(t, `call CALL test:6 depth=1 compiled-function args=[1]
+return test:6 depth=1 2
+call CALL test:6 depth=1 builtin-function:len args=[[1, 2]]
+return test:6 depth=1 2
+call CALL test:7 depth=1 compiled-function args=[]
+return test:7 depth=1 <undefined>`, strings.Join(tracer.events, "\n"))
+
+	// nested calls and the calls from Go functions
+	tracer = traceScript(t, `
+f := func(x) { return x + 1 }
+h := func(acc, x) { return acc + f(x) }
+out := stream([1]).reduce(h, 10)`)
+	assert.NoError(t, tracer.err)
+	assert.Equal(t, `call CALL test:4 depth=1 builtin-function:stream args=[[1]]
+return test:4 depth=1 <stream>
+call CALL test:4 depth=1 builtin-function:reduce args=[<compiled-function> 10]
+call CALL test:4 depth=1 compiled-function args=[10 1]
+call CALL test:3 depth=2 compiled-function args=[1]
+return test:3 depth=2 2
+return test:4 depth=1 12
+return test:4 depth=1 12`, strings.Join(tracer.events, "\n"))
+
+	// tail calls
+	tracer = traceScript(t, `
+f := func(n) { if n == 0 { return 0 }; return f(n - 1) }
+f(2)`)
+	assert.NoError(t, tracer.err)
+	assert.Equal(t, `call CALL test:3 depth=1 compiled-function args=[2]
+call CALL test:2 depth=2 compiled-function args=[1]
+call CALL test:2 depth=2 compiled-function args=[0]
+return test:3 depth=1 0`, strings.Join(tracer.events, "\n"))
+
+	// error
+	tracer = traceScript(t, `
+f := func() { return 1 + "a" }
+f()`)
+	if assert.Error(t, tracer.err) {
+		e, ok := tracer.err.(*runtime.Error)
+		if assert.True(t, ok) {
+			assert.Equal(t, 2, e.Pos.Line)
+		}
+	}
+	assert.Equal(t, "call CALL test:3 depth=1 compiled-function args=[]", strings.Join(tracer.events, "\n"))
+}
+
+func traceScript(t *testing.T, input string) *recordingTracer {
+	src := []byte(input)
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("test", -1, len(src))
+	file, err := parser.ParseFile(srcFile, src, nil)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	c := compiler.NewCompiler(srcFile, nil, nil, nil, nil)
+	if !assert.NoError(t, c.Compile(file)) {
+		return nil
+	}
+
+	tracer := &recordingTracer{seen: make(map[int]bool)}
+	v := runtime.NewVM(c.Bytecode(), nil, nil)
+	v.SetTracer(tracer)
+	_ = v.Run()
+
+	return tracer
+}
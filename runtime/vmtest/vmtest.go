@@ -0,0 +1,164 @@
+// Package vmtest provides the standard micro-benchmarks of the VM, and, the
+// helpers to run the VM under the CPU profiler of pprof and the execution
+// tracer.
+package vmtest
+
+import (
+	"fmt"
+	"io"
+	"runtime/pprof"
+	"runtime/trace"
+	"testing"
+
+	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+)
+
+// Benchmark is a micro-benchmark script that assigns its result to the
+// global variable "out".
+type Benchmark struct {
+	Name     string
+	Source   string
+	Expected objects.Object
+}
+
+// List of the standard benchmarks.
+var (
+	// Fib calls the functions recursively.
+	Fib = Benchmark{
+		Name: "fib",
+		Source: `
+fib := func(x) {
+	if x == 0 {
+		return 0
+	} else if x == 1 {
+		return 1
+	}
+	return fib(x-1) + fib(x-2)
+}
+out = fib(25)`,
+		Expected: &objects.Int{Value: 75025},
+	}
+
+	// StringBuilding concatenates the strings in a loop.
+	StringBuilding = Benchmark{
+		Name: "string-building",
+		Source: `
+s := ""
+for i := 0; i < 10000; i++ {
+	s += string(i % 10)
+}
+out = len(s)`,
+		Expected: &objects.Int{Value: 10000},
+	}
+
+	// MapChurn updates and iterates a map with many keys.
+	MapChurn = Benchmark{
+		Name: "map-churn",
+		Source: `
+m := {}
+for i := 0; i < 10000; i++ {
+	k := "k" + (i % 500)
+	m[k] = (m[k] || 0) + i
+}
+out = 0
+for k, v in m {
+	out += v
+}`,
+		Expected: &objects.Int{Value: 49995000},
+	}
+)
+
+// Benchmarks are the standard benchmarks.
+var Benchmarks = []Benchmark{Fib, StringBuilding, MapChurn}
+
+// NewVM compiles the source code, and, creates a VM that runs it. The
+// returned function returns the value of the global variable "out".
+func NewVM(src string) (*runtime.VM, func() objects.Object, error) {
+	fileSet := source.NewFileSet()
+	srcFile := fileSet.AddFile("vmtest", -1, len(src))
+	file, err := parser.ParseFile(srcFile, []byte(src), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	symbolTable := compiler.NewSymbolTable()
+	for idx, fn := range objects.Builtins {
+		symbolTable.DefineBuiltin(idx, fn.Name)
+	}
+	out := symbolTable.Define("out")
+
+	c := compiler.NewCompiler(srcFile, symbolTable, nil, nil, nil)
+	c.EnableOptimizer(true)
+	if err := c.Compile(file); err != nil {
+		return nil, nil, err
+	}
+
+	globals := make([]objects.Object, runtime.GlobalsSize)
+	result := func() objects.Object {
+		if globals[out.Index] == nil {
+			return objects.UndefinedValue
+		}
+		return globals[out.Index]
+	}
+
+	return runtime.NewVM(c.Bytecode(), globals, nil), result, nil
+}
+
+// Run runs the benchmark b.N times, and, fails if the result is not the
+// expected value. The compilation is not included in the measurement.
+func Run(b *testing.B, bench Benchmark) {
+	b.Helper()
+
+	machine, result, err := NewVM(bench.Source)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := machine.Run(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+
+	if err := check(bench, result()); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// Profile runs the VM with the CPU profiler of pprof, and, writes the profile
+// to w. The profile can be read by "go tool pprof".
+func Profile(w io.Writer, machine *runtime.VM) error {
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return err
+	}
+	defer pprof.StopCPUProfile()
+
+	return machine.Run()
+}
+
+// Trace runs the VM with the execution tracer, and, writes the trace to w.
+// The trace can be read by "go tool trace".
+func Trace(w io.Writer, machine *runtime.VM) error {
+	if err := trace.Start(w); err != nil {
+		return err
+	}
+	defer trace.Stop()
+
+	return machine.Run()
+}
+
+func check(bench Benchmark, res objects.Object) error {
+	if !bench.Expected.Equals(res) {
+		return fmt.Errorf("%s: wrong result: %s (expected: %s)",
+			bench.Name, res.String(), bench.Expected.String())
+	}
+
+	return nil
+}
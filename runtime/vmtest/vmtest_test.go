@@ -0,0 +1,54 @@
+package vmtest_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/runtime/vmtest"
+)
+
+func TestBenchmarks(t *testing.T) {
+	for _, bench := range vmtest.Benchmarks {
+		machine, result, err := vmtest.NewVM(bench.Source)
+		if !assert.NoError(t, err) || !assert.NoError(t, machine.Run()) {
+			continue
+		}
+		assert.Equal(t, bench.Expected, result())
+	}
+}
+
+func TestProfile(t *testing.T) {
+	machine, result, err := vmtest.NewVM(vmtest.Fib.Source)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, vmtest.Profile(&buf, machine))
+	assert.Equal(t, vmtest.Fib.Expected, result())
+	assert.True(t, buf.Len() > 0)
+
+	buf.Reset()
+	assert.NoError(t, vmtest.Trace(&buf, machine))
+	assert.True(t, buf.Len() > 0)
+
+	// the run-time error
+	machine, _, err = vmtest.NewVM(`out = 1 + "a" - 1`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Error(t, vmtest.Profile(&buf, machine))
+}
+
+func BenchmarkFib(b *testing.B) {
+	vmtest.Run(b, vmtest.Fib)
+}
+
+func BenchmarkStringBuilding(b *testing.B) {
+	vmtest.Run(b, vmtest.StringBuilding)
+}
+
+func BenchmarkMapChurn(b *testing.B) {
+	vmtest.Run(b, vmtest.MapChurn)
+}
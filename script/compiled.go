@@ -3,6 +3,8 @@ package script
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/d5/tengo/compiler"
 	"github.com/d5/tengo/objects"
@@ -11,18 +13,30 @@ import (
 
 // Compiled is a compiled instance of the user script.
 // Use Script.Compile() to create Compiled object.
+// The global variables can be read and replaced between the runs using Get
+// and Set, and, the functions of the script can be called using Call. It's
+// safe to call them from other goroutines while the script is running: they
+// wait until the run completes. They must not be called from the Go
+// functions called by the script.
 type Compiled struct {
 	symbolTable *compiler.SymbolTable
 	machine     *runtime.VM
+	lock        sync.RWMutex
 }
 
 // Run executes the compiled script in the virtual machine.
 func (c *Compiled) Run() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	return c.machine.Run()
 }
 
 // RunContext is like Run but includes a context.
 func (c *Compiled) RunContext(ctx context.Context) (err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	ch := make(chan error, 1)
 
 	go func() {
@@ -40,10 +54,70 @@ func (c *Compiled) RunContext(ctx context.Context) (err error) {
 	return
 }
 
+// SetTracer sets the Tracer that receives the execution events of the
+// virtual machine. Set nil to stop tracing.
+func (c *Compiled) SetTracer(tracer runtime.Tracer) {
+	c.machine.SetTracer(tracer)
+}
+
+// Call calls the function stored in the global variable identified by the
+// name with the arguments, and, returns its result. The function is called
+// in the virtual machine of the compiled script, so it can be called
+// repeatedly after Run to reuse the functions defined by the script (e.g. as
+// the handlers of the events). An error will be returned if the name was not
+// defined during compilation, or, the function fails.
+func (c *Compiled) Call(name string, args ...interface{}) (*Variable, error) {
+	symbol, _, ok := c.symbolTable.Resolve(name)
+	if !ok || symbol.Scope != compiler.ScopeGlobal {
+		return nil, fmt.Errorf("'%s' is not defined", name)
+	}
+
+	objArgs := make([]objects.Object, len(args))
+	for i, arg := range args {
+		obj, err := objects.FromInterface(arg)
+		if err != nil {
+			return nil, err
+		}
+		objArgs[i] = obj
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	fn := c.machine.Globals()[symbol.Index]
+	if fn == nil {
+		fn = objects.UndefinedValue
+	}
+
+	res, err := c.machine.RunFunction(fn, objArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Variable{
+		name:  name,
+		value: res,
+	}, nil
+}
+
+// RunFunction calls the function object (e.g. a callback that the script
+// passed to a Go function) with the arguments in the virtual machine of the
+// compiled script, and, returns its result. Like Call, it waits until the
+// run completes.
+func (c *Compiled) RunFunction(fn objects.Object, args ...objects.Object) (objects.Object, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.machine.RunFunction(fn, args...)
+}
+
 // IsDefined returns true if the variable name is defined (has value) before or after the execution.
 func (c *Compiled) IsDefined(name string) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	symbol, _, ok := c.symbolTable.Resolve(name)
-	if !ok {
+	if !ok || symbol.Scope != compiler.ScopeGlobal {
 		return false
 	}
 
@@ -52,18 +126,22 @@ func (c *Compiled) IsDefined(name string) bool {
 		return false
 	}
 
-	return *v != objects.UndefinedValue
+	return v != objects.UndefinedValue
 }
 
 // Get returns a variable identified by the name.
+// The value is undefined if the name was not defined during compilation.
 func (c *Compiled) Get(name string) *Variable {
-	value := &objects.UndefinedValue
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	value := objects.UndefinedValue
 
 	symbol, _, ok := c.symbolTable.Resolve(name)
 	if ok && symbol.Scope == compiler.ScopeGlobal {
 		value = c.machine.Globals()[symbol.Index]
 		if value == nil {
-			value = &objects.UndefinedValue
+			value = objects.UndefinedValue
 		}
 	}
 
@@ -73,15 +151,23 @@ func (c *Compiled) Get(name string) *Variable {
 	}
 }
 
-// GetAll returns all the variables that are defined by the compiled script.
+// GetAll returns all the global variables sorted by the name: the variables
+// added by Script.Add and the ones defined by the script. The variables that
+// are not assigned yet have undefined values.
 func (c *Compiled) GetAll() []*Variable {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	names := c.symbolTable.Names()
+	sort.Strings(names)
+
 	var vars []*Variable
-	for _, name := range c.symbolTable.Names() {
+	for _, name := range names {
 		symbol, _, ok := c.symbolTable.Resolve(name)
 		if ok && symbol.Scope == compiler.ScopeGlobal {
 			value := c.machine.Globals()[symbol.Index]
 			if value == nil {
-				value = &objects.UndefinedValue
+				value = objects.UndefinedValue
 			}
 
 			vars = append(vars, &Variable{
@@ -94,8 +180,19 @@ func (c *Compiled) GetAll() []*Variable {
 	return vars
 }
 
+// GetAllMap is like GetAll, but, it returns the variables by the name.
+func (c *Compiled) GetAllMap() map[string]*Variable {
+	vars := make(map[string]*Variable)
+	for _, v := range c.GetAll() {
+		vars[v.name] = v
+	}
+
+	return vars
+}
+
 // Set replaces the value of a global variable identified by the name.
 // An error will be returned if the name was not defined during compilation.
+// The new value is seen by the next run of the script.
 func (c *Compiled) Set(name string, value interface{}) error {
 	obj, err := objects.FromInterface(value)
 	if err != nil {
@@ -107,7 +204,9 @@ func (c *Compiled) Set(name string, value interface{}) error {
 		return fmt.Errorf("'%s' is not defined", name)
 	}
 
-	c.machine.Globals()[symbol.Index] = &obj
+	c.lock.Lock()
+	c.machine.Globals()[symbol.Index] = obj
+	c.lock.Unlock()
 
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
 	"github.com/d5/tengo/script"
 )
 
@@ -37,6 +38,26 @@ func TestCompiled_GetAll(t *testing.T) {
 	c = compile(t, `a := b; b = 5`, M{"b": "foo"})
 	compiledRun(t, c)
 	compiledGetAll(t, c, M{"a": "foo", "b": int64(5)})
+
+	// the variables defined by the script
+	c = compile(t, `
+x := [1, 2]
+f := func(a) { y := a; return y }
+z := f(x[0])
+if z > 0 { w := 1 }`, nil)
+	compiledGetAll(t, c, M{"f": nil, "x": nil, "z": nil})
+	compiledRun(t, c)
+	vars := c.GetAll()
+	if assert.Equal(t, 3, len(vars)) {
+		assert.Equal(t, "f", vars[0].Name())
+		assert.Equal(t, "x", vars[1].Name())
+		assert.Equal(t, "z", vars[2].Name())
+	}
+	m := c.GetAllMap()
+	assert.Equal(t, 3, len(m))
+	assert.Equal(t, "[1, 2]", m["x"].String())
+	assert.Equal(t, int64(1), m["z"].Value())
+	assert.Equal(t, "compiled-function", m["f"].ValueType())
 }
 
 func TestCompiled_IsDefined(t *testing.T) {
@@ -75,6 +96,85 @@ a := func() {
 	assert.NoError(t, err)
 	compiledRun(t, c)
 	compiledGet(t, c, "a", int64(15))
+
+	// replace the values while the script is running
+	c = compile(t, `sum := 0; for i := 0; i < 10000; i++ { sum += b }`, M{"b": 1})
+	done := make(chan error)
+	go func() {
+		done <- c.RunContext(context.Background())
+	}()
+	for i := 2; i <= 10; i++ {
+		assert.NoError(t, c.Set("b", i))
+	}
+	assert.NoError(t, <-done)
+	compiledGet(t, c, "b", int64(10))
+	compiledRun(t, c)
+	compiledGet(t, c, "sum", int64(100000))
+	compiledGet(t, c, "c", nil)
+}
+
+func TestCompiled_Call(t *testing.T) {
+	c := compile(t, `
+count := 0
+handler := func(event) {
+	count++
+	return { name: event.name, size: len(event.data), count: count }
+}
+fail := func() { return 1 + "a" }
+`, nil)
+	compiledRun(t, c)
+
+	res, err := c.Call("handler", map[string]interface{}{"name": "foo", "data": []interface{}{1, 2}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "handler", res.Name())
+		assert.Equal(t, "foo", res.Map()["name"])
+		assert.Equal(t, int64(2), res.Map()["size"])
+		assert.Equal(t, int64(1), res.Map()["count"])
+	}
+
+	_, err = c.Call("fail")
+	assert.Error(t, err)
+
+	res, err = c.Call("handler", map[string]interface{}{"name": "bar", "data": "abc"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "bar", res.Map()["name"])
+		assert.Equal(t, int64(3), res.Map()["size"])
+		assert.Equal(t, int64(2), res.Map()["count"])
+	}
+	compiledGet(t, c, "count", int64(2))
+
+	_, err = c.Call("count")
+	assert.Error(t, err) // not callable
+	_, err = c.Call("foo")
+	assert.Error(t, err) // 'foo' is not defined
+	_, err = c.Call("handler", struct{}{})
+	assert.Error(t, err) // unsupported argument type
+}
+
+func TestCompiled_RunFunction(t *testing.T) {
+	c := compile(t, `
+count := 0
+callback := undefined
+register := func(fn) { callback = fn }
+register(func(n) { count += n; return count })
+`, nil)
+	compiledRun(t, c)
+
+	callback := c.Get("callback").Object()
+	res, err := c.RunFunction(callback, &objects.Int{Value: 2})
+	if assert.NoError(t, err) {
+		assert.Equal(t, objects.Object(&objects.Int{Value: 2}), res)
+	}
+	res, err = c.RunFunction(callback, &objects.Int{Value: 3})
+	if assert.NoError(t, err) {
+		assert.Equal(t, objects.Object(&objects.Int{Value: 5}), res)
+	}
+	compiledGet(t, c, "count", int64(5))
+
+	_, err = c.RunFunction(callback, &objects.String{Value: "a"})
+	assert.Error(t, err)
+	_, err = c.RunFunction(c.Get("count").Object())
+	assert.Error(t, err) // not callable
 }
 
 func TestCompiled_RunContext(t *testing.T) {
@@ -100,6 +200,21 @@ func TestCompiled_RunContext(t *testing.T) {
 	defer cancel()
 	err = c.RunContext(ctx)
 	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// timeout while a Go function is waiting
+	c = compile(t, `
+ratelimit := import("ratelimit")
+limiter := ratelimit.leaky_bucket(0.001, 2)
+limiter.wait()
+limiter.wait()
+a = 1`, M{"a": 0})
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel2()
+	start := time.Now()
+	err = c.RunContext(ctx2)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < time.Second)
+	compiledGet(t, c, "a", int64(0))
 }
 
 func compile(t *testing.T, input string, vars M) *script.Compiled {
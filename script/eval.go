@@ -0,0 +1,56 @@
+package script
+
+import (
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+)
+
+// evalResult is the name of the global variable that holds the value of the
+// evaluated expression. It's not a valid identifier so it cannot conflict
+// with the variables.
+const evalResult = "(result)"
+
+// Eval compiles and runs a single expression (e.g. `age >= 18 && country ==
+// "KR"`) with the variables, and, returns its value converted to a Go value
+// the same way as Variable.Value does. The expression can use the builtin
+// functions and import the standard library modules.
+func Eval(expr string, vars map[string]interface{}) (interface{}, error) {
+	s := New([]byte(expr))
+	s.isExpr = true
+
+	for name, value := range vars {
+		if err := s.Add(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	compiled, err := s.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	return compiled.Get(evalResult).Value(), nil
+}
+
+// parseExpr parses the expression, and, returns the file that assigns its
+// value to the result variable.
+func parseExpr(p *parser.Parser, srcFile *source.File) (*ast.File, error) {
+	expr, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.File{
+		InputFile: srcFile,
+		Stmts: []ast.Stmt{
+			&ast.AssignStmt{
+				LHS:      []ast.Expr{&ast.Ident{Name: evalResult, NamePos: expr.Pos()}},
+				RHS:      []ast.Expr{expr},
+				Token:    token.Define,
+				TokenPos: expr.Pos(),
+			},
+		},
+	}, nil
+}
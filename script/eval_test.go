@@ -0,0 +1,40 @@
+package script_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/script"
+)
+
+func TestEval(t *testing.T) {
+	expectEval(t, `1 + 2 * 3`, nil, int64(7))
+	expectEval(t, `age >= 18 && country == "KR"`, map[string]interface{}{
+		"age":     20,
+		"country": "KR",
+	}, true)
+	expectEval(t, `len(items) > 2 ? items[0] : "none"`, map[string]interface{}{
+		"items": []interface{}{"a", "b"},
+	}, "none")
+	expectEval(t, `import("text").to_upper(s)`, map[string]interface{}{"s": "foo"}, "FOO")
+	expectEval(t, `undefined`, nil, nil)
+
+	_, err := script.Eval(`a := 1`, nil)
+	assert.Equal(t, "parse error: (main):1:3: expected end of expression, found ':='", err.Error())
+
+	_, err = script.Eval(`a + 1`, nil)
+	assert.Equal(t, "(main):1:1: unresolved reference 'a'", err.Error())
+
+	_, err = script.Eval(`a + 1`, map[string]interface{}{"a": "x"})
+	assert.NoError(t, err)
+
+	_, err = script.Eval(`a + b`, map[string]interface{}{"a": 1, "b": "x"})
+	assert.Equal(t, "(main):1:1: invalid operation: int + string", err.Error())
+}
+
+func expectEval(t *testing.T, expr string, vars map[string]interface{}, expected interface{}) {
+	actual, err := script.Eval(expr, vars)
+	if assert.NoError(t, err, expr) {
+		assert.Equal(t, expected, actual, expr)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/d5/tengo/compiler"
+	"github.com/d5/tengo/compiler/ast"
 	"github.com/d5/tengo/compiler/parser"
 	"github.com/d5/tengo/compiler/source"
 	"github.com/d5/tengo/objects"
@@ -18,7 +19,12 @@ type Script struct {
 	removedBuiltins   map[string]bool
 	removedStdModules map[string]bool
 	userModuleLoader  compiler.ModuleLoader
+	optimize          bool
+	constants         map[string]objects.Object
+	transformers      []compiler.Transformer
 	input             []byte
+	isExpr            bool // input is an expression (see Eval)
+	args              []string
 }
 
 // New creates a Script instance with an input script.
@@ -38,7 +44,7 @@ func (s *Script) Add(name string, value interface{}) error {
 
 	s.variables[name] = &Variable{
 		name:  name,
-		value: &obj,
+		value: obj,
 	}
 
 	return nil
@@ -79,6 +85,42 @@ func (s *Script) SetUserModuleLoader(loader compiler.ModuleLoader) {
 	s.userModuleLoader = loader
 }
 
+// SetArgs sets the arguments returned by os.args() in the script. By default,
+// os.args() returns the command-line arguments of the host program.
+func (s *Script) SetArgs(args []string) {
+	s.args = args
+}
+
+// EnableOptimizer enables or disables the compile-time optimization of the
+// script. See Compiler.EnableOptimizer for details.
+func (s *Script) EnableOptimizer(enabled bool) {
+	s.optimize = enabled
+}
+
+// AddTransformer adds the transformer that rewrites the AST of the script
+// before it's compiled. See Compiler.AddTransformer for details.
+func (s *Script) AddTransformer(t compiler.Transformer) {
+	s.transformers = append(s.transformers, t)
+}
+
+// DefineConstant defines a compile-time constant for the script. Unlike the
+// variables added by Add, the constants are substituted when the script is
+// compiled. See Compiler.DefineConstant for details.
+func (s *Script) DefineConstant(name string, value interface{}) error {
+	obj, err := objects.FromInterface(value)
+	if err != nil {
+		return err
+	}
+
+	if s.constants == nil {
+		s.constants = make(map[string]objects.Object)
+	}
+
+	s.constants[name] = obj
+
+	return nil
+}
+
 // Compile compiles the script with all the defined variables, and, returns Compiled object.
 func (s *Script) Compile() (*Compiled, error) {
 	symbolTable, stdModules, globals, err := s.prepCompile()
@@ -90,12 +132,28 @@ func (s *Script) Compile() (*Compiled, error) {
 	srcFile := fileSet.AddFile("(main)", -1, len(s.input))
 
 	p := parser.NewParser(srcFile, s.input, nil)
-	file, err := p.ParseFile()
+	var file *ast.File
+	if s.isExpr {
+		file, err = parseExpr(p, srcFile)
+	} else {
+		file, err = p.ParseFile()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("parse error: %s", err.Error())
 	}
 
 	c := compiler.NewCompiler(srcFile, symbolTable, nil, stdModules, nil)
+	c.EnableOptimizer(s.optimize)
+
+	for name, value := range s.constants {
+		if err := c.DefineConstant(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range s.transformers {
+		c.AddTransformer(t)
+	}
 
 	if s.userModuleLoader != nil {
 		c.SetModuleLoader(s.userModuleLoader)
@@ -105,9 +163,14 @@ func (s *Script) Compile() (*Compiled, error) {
 		return nil, err
 	}
 
+	var modules map[string]*objects.Object
+	if s.args != nil {
+		modules = stdlib.ModulesWithArgs(s.args)
+	}
+
 	return &Compiled{
 		symbolTable: symbolTable,
-		machine:     runtime.NewVM(c.Bytecode(), globals, nil),
+		machine:     runtime.NewVM(c.Bytecode(), globals, modules),
 	}, nil
 }
 
@@ -136,7 +199,7 @@ func (s *Script) RunContext(ctx context.Context) (compiled *Compiled, err error)
 	return
 }
 
-func (s *Script) prepCompile() (symbolTable *compiler.SymbolTable, stdModules map[string]bool, globals []*objects.Object, err error) {
+func (s *Script) prepCompile() (symbolTable *compiler.SymbolTable, stdModules map[string]bool, globals []objects.Object, err error) {
 	var names []string
 	for name := range s.variables {
 		names = append(names, name)
@@ -156,7 +219,7 @@ func (s *Script) prepCompile() (symbolTable *compiler.SymbolTable, stdModules ma
 		}
 	}
 
-	globals = make([]*objects.Object, runtime.GlobalsSize, runtime.GlobalsSize)
+	globals = make([]objects.Object, runtime.GlobalsSize, runtime.GlobalsSize)
 
 	for idx, name := range names {
 		symbol := symbolTable.Define(name)
@@ -4,7 +4,10 @@ import (
 	"testing"
 
 	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/objects"
 	"github.com/d5/tengo/script"
+	"github.com/d5/tengo/stdlib"
 )
 
 func TestScript_Add(t *testing.T) {
@@ -18,6 +21,24 @@ func TestScript_Add(t *testing.T) {
 	assert.Equal(t, "foo", c.Get("b").Value())
 }
 
+func TestScript_AddChannel(t *testing.T) {
+	events := make(chan objects.Object)
+	go func() {
+		defer close(events)
+		for _, name := range []string{"start", "stop"} {
+			events <- &objects.String{Value: name}
+		}
+	}()
+
+	s := script.New([]byte(`for i, e in events { log += string(i) + ":" + e + " " }`))
+	assert.NoError(t, s.Add("events", events))
+	assert.NoError(t, s.Add("log", ""))
+	c, err := s.Run()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0:start 1:stop ", c.Get("log").String())
+	}
+}
+
 func TestScript_Remove(t *testing.T) {
 	s := script.New([]byte(`a := b`))
 	err := s.Add("b", 5)
@@ -37,6 +58,68 @@ func TestScript_Run(t *testing.T) {
 	compiledGet(t, c, "a", int64(5))
 }
 
+func TestScript_EnableOptimizer(t *testing.T) {
+	s := script.New([]byte(`a := 2 * (3 + 4) - b; c := "x" + 1 == "x1" ? 1.5 * 2 : 0; d := undefined || "z"`))
+	err := s.Add("b", 5)
+	assert.NoError(t, err)
+	s.EnableOptimizer(true)
+	c, err := s.Run()
+	assert.NoError(t, err)
+	compiledGet(t, c, "a", int64(9))
+	compiledGet(t, c, "c", 3.0)
+	compiledGet(t, c, "d", "z")
+
+	s = script.New([]byte(`a := 1 + "a"`))
+	s.EnableOptimizer(true)
+	_, err = s.Run()
+	assert.Error(t, err)
+}
+
+func TestScript_DefineConstant(t *testing.T) {
+	s := script.New([]byte(`a := env == "prod" ? timeout * 2 : 0; b := env`))
+	assert.NoError(t, s.DefineConstant("env", "prod"))
+	assert.NoError(t, s.DefineConstant("timeout", 30))
+	c, err := s.Run()
+	assert.NoError(t, err)
+	compiledGet(t, c, "a", int64(60))
+	compiledGet(t, c, "b", "prod")
+
+	s = script.New([]byte(`a := 1`))
+	assert.NoError(t, s.DefineConstant("m", map[string]interface{}{}))
+	_, err = s.Run()
+	assert.Error(t, err)
+}
+
+func TestScript_SetArgs(t *testing.T) {
+	if _, ok := stdlib.Modules["os"]; !ok {
+		t.Skip("os module is left out of the build")
+	}
+
+	s := script.New([]byte(`os := import("os"); args := os.args(); a := len(args); b := args[1]`))
+	s.SetArgs([]string{"script.tengo", "foo"})
+	c, err := s.Run()
+	assert.NoError(t, err)
+	compiledGet(t, c, "a", int64(2))
+	compiledGet(t, c, "b", "foo")
+}
+
+func TestScript_AddTransformer(t *testing.T) {
+	s := script.New([]byte(`a := b * 2`))
+	assert.NoError(t, s.Add("c", 5))
+	s.AddTransformer(func(file *ast.File) (*ast.File, error) {
+		ast.Inspect(file, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Name == "b" {
+				ident.Name = "c"
+			}
+			return true
+		})
+		return file, nil
+	})
+	c, err := s.Run()
+	assert.NoError(t, err)
+	compiledGet(t, c, "a", int64(10))
+}
+
 func TestScript_DisableBuiltinFunction(t *testing.T) {
 	s := script.New([]byte(`a := len([1, 2, 3])`))
 	c, err := s.Run()
@@ -0,0 +1,445 @@
+//go:build !tengo_no_cache
+// +build !tengo_no_cache
+
+package stdlib
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("cache", cacheModule)
+}
+
+func cacheModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"lru":     &objects.UserFunction{Name: "lru", Value: cacheNewLRU},      // lru(capacity, ttl) => cache
+		"ttl":     &objects.UserFunction{Name: "ttl", Value: cacheNewTTL},      // ttl(ttl) => cache
+		"memoize": &objects.UserFunction{Name: "memoize", Value: cacheMemoize}, // memoize(fn, options) => fn
+	}
+}
+
+func cacheNewLRU(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	capacity, err := cacheSize(args[0], "first", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var ttl int64
+	if len(args) == 2 {
+		if ttl, err = cacheSize(args[1], "second", 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return makeCache(newCacheLRU(int(capacity), time.Duration(ttl))), nil
+}
+
+func cacheNewTTL(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	ttl, err := cacheSize(args[0], "first", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeCache(newCacheLRU(0, time.Duration(ttl))), nil
+}
+
+// cacheMemoize returns a function that calls fn, and, caches the results by
+// the arguments. The errors are not cached, so the failed calls are called
+// again.
+func cacheMemoize(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	fn := args[0]
+	switch fn.(type) {
+	case *objects.CompiledFunction, *objects.Closure, objects.Callable, objects.InteropCallable:
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "callable",
+			Found:    fn.TypeName(),
+		}
+	}
+
+	var capacity, ttl int64
+	if len(args) == 2 {
+		opts, ok := args[1].(*objects.Map)
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "second",
+				Expected: "map",
+				Found:    args[1].TypeName(),
+			}
+		}
+
+		for k, v := range opts.Value {
+			var err error
+			switch k {
+			case "capacity":
+				capacity, err = cacheSize(v, k, 1)
+			case "ttl":
+				ttl, err = cacheSize(v, k, 0)
+			default:
+				err = fmt.Errorf("unknown memoize option '%s'", k)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	c := newCacheLRU(int(capacity), time.Duration(ttl))
+
+	return &objects.InteropFunction{
+		Name: "memoized",
+		Value: func(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+			key, err := cacheKey(&objects.Array{Value: args})
+			if err != nil {
+				return nil, err
+			}
+
+			if res, ok := c.get(key, time.Now()); ok {
+				return res, nil
+			}
+
+			res, err := rt.Call(fn, args...)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, isErr := res.(*objects.Error); !isErr {
+				c.set(key, res, time.Now())
+			}
+
+			return res, nil
+		},
+	}, nil
+}
+
+func makeCache(c *cacheLRU) *objects.ImmutableMap {
+	return &objects.ImmutableMap{
+		Value: map[string]objects.Object{
+			"get":    &objects.UserFunction{Name: "get", Value: c.getFunc},       // get(key, default) => any
+			"set":    &objects.UserFunction{Name: "set", Value: c.setFunc},       // set(key, value) => undefined
+			"has":    &objects.UserFunction{Name: "has", Value: c.hasFunc},       // has(key) => bool
+			"delete": &objects.UserFunction{Name: "delete", Value: c.deleteFunc}, // delete(key) => bool
+			"len":    &objects.UserFunction{Name: "len", Value: c.lenFunc},       // len() => int
+			"clear":  &objects.UserFunction{Name: "clear", Value: c.clearFunc},   // clear() => undefined
+		},
+	}
+}
+
+// cacheLRU is a cache that removes the least recently used entry when the
+// capacity is exceeded, and, the entries older than the TTL. A zero
+// capacity or TTL is unlimited. It's safe for concurrent use.
+type cacheLRU struct {
+	lock     sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List // the most recently used entry is at the front
+}
+
+type cacheEntry struct {
+	key     string
+	value   objects.Object
+	expires time.Time
+}
+
+func newCacheLRU(capacity int, ttl time.Duration) *cacheLRU {
+	return &cacheLRU{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *cacheLRU) get(key string, now time.Time) (objects.Object, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if c.ttl > 0 && !now.Before(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+func (c *cacheLRU) set(key string, value objects.Object, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	entry := &cacheEntry{key: key, value: value}
+	if c.ttl > 0 {
+		entry.expires = now.Add(c.ttl)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// len returns the number of the entries after removing the expired ones.
+func (c *cacheLRU) len(now time.Time) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.ttl > 0 {
+		for elem := c.order.Front(); elem != nil; {
+			next := elem.Next()
+			if !now.Before(elem.Value.(*cacheEntry).expires) {
+				c.remove(elem)
+			}
+			elem = next
+		}
+	}
+
+	return c.order.Len()
+}
+
+func (c *cacheLRU) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+	c.order.Remove(elem)
+}
+
+func (c *cacheLRU) getFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	key, err := cacheKey(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if res, ok := c.get(key, time.Now()); ok {
+		return res, nil
+	}
+
+	if len(args) == 2 {
+		return args[1], nil
+	}
+
+	return objects.UndefinedValue, nil
+}
+
+func (c *cacheLRU) setFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	key, err := cacheKey(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	c.set(key, args[1], time.Now())
+
+	return objects.UndefinedValue, nil
+}
+
+func (c *cacheLRU) hasFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	key, err := cacheKey(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// has does not make the entry recently used
+	c.lock.Lock()
+	elem, ok := c.entries[key]
+	ok = ok && (c.ttl == 0 || time.Now().Before(elem.Value.(*cacheEntry).expires))
+	c.lock.Unlock()
+
+	if ok {
+		return objects.TrueValue, nil
+	}
+
+	return objects.FalseValue, nil
+}
+
+func (c *cacheLRU) deleteFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	key, err := cacheKey(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	elem, ok := c.entries[key]
+	if ok {
+		c.remove(elem)
+	}
+	c.lock.Unlock()
+
+	if ok {
+		return objects.TrueValue, nil
+	}
+
+	return objects.FalseValue, nil
+}
+
+func (c *cacheLRU) lenFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return &objects.Int{Value: int64(c.len(time.Now()))}, nil
+}
+
+func (c *cacheLRU) clearFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	c.lock.Lock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.lock.Unlock()
+
+	return objects.UndefinedValue, nil
+}
+
+// cacheKey returns the string that identifies the value as a cache key. The
+// values of the different types are different keys (e.g. 1 and 1.0), and,
+// the arrays and the maps are the same keys if their elements are the same.
+func cacheKey(o objects.Object) (string, error) {
+	var sb strings.Builder
+	if err := cacheWriteKey(&sb, o); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+func cacheWriteKey(sb *strings.Builder, o objects.Object) error {
+	switch o := o.(type) {
+	case *objects.Undefined:
+		sb.WriteString("u")
+	case *objects.Bool:
+		sb.WriteString("b" + o.String())
+	case *objects.Int:
+		sb.WriteString("i" + o.String())
+	case *objects.Float:
+		sb.WriteString("f" + strconv.FormatFloat(o.Value, 'g', -1, 64))
+	case *objects.Char:
+		sb.WriteString("c" + strconv.QuoteRune(o.Value))
+	case *objects.String:
+		sb.WriteString("s" + strconv.Quote(o.Value))
+	case *objects.Bytes:
+		sb.WriteString("y" + strconv.Quote(string(o.Value)))
+	case *objects.Time:
+		sb.WriteString("t" + o.Value.Format(time.RFC3339Nano))
+	case *objects.Error:
+		sb.WriteString("e(")
+		if err := cacheWriteKey(sb, o.Value); err != nil {
+			return err
+		}
+		sb.WriteString(")")
+	case *objects.Array:
+		return cacheWriteArrayKey(sb, o.Value)
+	case *objects.ImmutableArray:
+		return cacheWriteArrayKey(sb, o.Value)
+	case *objects.Map:
+		return cacheWriteMapKey(sb, o.Value)
+	case *objects.ImmutableMap:
+		return cacheWriteMapKey(sb, o.Value)
+	default:
+		return fmt.Errorf("invalid cache key type: %s", o.TypeName())
+	}
+
+	return nil
+}
+
+func cacheWriteArrayKey(sb *strings.Builder, elems []objects.Object) error {
+	sb.WriteString("[")
+	for i, e := range elems {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		if err := cacheWriteKey(sb, e); err != nil {
+			return err
+		}
+	}
+	sb.WriteString("]")
+
+	return nil
+}
+
+func cacheWriteMapKey(sb *strings.Builder, elems map[string]objects.Object) error {
+	keys := make([]string, 0, len(elems))
+	for k := range elems {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sb.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(strconv.Quote(k) + ":")
+		if err := cacheWriteKey(sb, elems[k]); err != nil {
+			return err
+		}
+	}
+	sb.WriteString("}")
+
+	return nil
+}
+
+// cacheSize returns the int argument if it's at least min.
+func cacheSize(o objects.Object, name string, min int64) (int64, error) {
+	n, ok := o.(*objects.Int)
+	if !ok {
+		return 0, objects.ErrInvalidArgumentType{
+			Name:     name,
+			Expected: "int",
+			Found:    o.TypeName(),
+		}
+	}
+
+	if n.Value < min {
+		return 0, fmt.Errorf("invalid %s: %d", name, n.Value)
+	}
+
+	return n.Value, nil
+}
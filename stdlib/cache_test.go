@@ -0,0 +1,135 @@
+package stdlib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestCacheLRU(t *testing.T) {
+	module(t, "cache").call("lru").expectError()
+	module(t, "cache").call("lru", 0).expectError()
+	module(t, "cache").call("lru", "2").expectError()
+	module(t, "cache").call("lru", 2, -1).expectError()
+
+	c := module(t, "cache").call("lru", 2)
+	assert.NoError(t, c.e)
+	c.call("get", "a").expect(objects.UndefinedValue)
+	c.call("get", "a", 0).expect(0)
+	c.call("set", "a", 1).expect(objects.UndefinedValue)
+	c.call("set", 1, "int").expect(objects.UndefinedValue)
+	c.call("get", "a").expect(1)
+	c.call("len").expect(2)
+
+	// "a" is used recently, so 1 is removed
+	c.call("set", 1.0, "float").expect(objects.UndefinedValue)
+	c.call("has", 1).expect(false)
+	c.call("has", "a").expect(true)
+	c.call("get", 1.0).expect("float")
+	c.call("len").expect(2)
+
+	c.call("delete", "a").expect(true)
+	c.call("delete", "a").expect(false)
+	c.call("len").expect(1)
+	c.call("clear").expect(objects.UndefinedValue)
+	c.call("len").expect(0)
+
+	// composite keys
+	c.call("set", ARR{1, MAP{"a": 1, "b": "c"}}, "x").expect(objects.UndefinedValue)
+	c.call("get", IARR{1, IMAP{"b": "c", "a": 1}}).expect("x")
+	c.call("get", ARR{1, MAP{"a": 1, "b": 'c'}}).expect(objects.UndefinedValue)
+	c.call("set", ARR{c.o}, 1).expectError()
+	c.call("get", c.o).expectError()
+	c.call("get").expectError()
+	c.call("set", "a").expectError()
+	c.call("len", 1).expectError()
+}
+
+func TestCacheTTL(t *testing.T) {
+	module(t, "cache").call("ttl").expectError()
+	module(t, "cache").call("ttl", 0).expectError()
+
+	ttl := 20 * time.Millisecond
+	c := module(t, "cache").call("ttl", int64(ttl))
+	assert.NoError(t, c.e)
+	c.call("set", "a", 1).expect(objects.UndefinedValue)
+	c.call("get", "a").expect(1)
+	c.call("has", "a").expect(true)
+	c.call("len").expect(1)
+
+	time.Sleep(ttl)
+	c.call("has", "a").expect(false)
+	c.call("get", "a", 2).expect(2)
+	c.call("len").expect(0)
+
+	// set resets the expiration
+	c = module(t, "cache").call("lru", 10, int64(ttl))
+	c.call("set", "a", 1).expect(objects.UndefinedValue)
+	c.call("set", "b", 2).expect(objects.UndefinedValue)
+	time.Sleep(ttl / 2)
+	c.call("set", "a", 3).expect(objects.UndefinedValue)
+	time.Sleep(ttl / 2)
+	c.call("len").expect(1)
+	c.call("get", "a").expect(3)
+}
+
+func TestCacheMemoize(t *testing.T) {
+	calls := 0
+	double := &objects.UserFunction{Value: func(args ...objects.Object) (objects.Object, error) {
+		calls++
+		if len(args) != 1 {
+			return &objects.Error{Value: &objects.String{Value: "one argument"}}, nil
+		}
+		i, ok := args[0].(*objects.Int)
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "int", Found: args[0].TypeName()}
+		}
+		return &objects.Int{Value: i.Value * 2}, nil
+	}}
+
+	module(t, "cache").call("memoize").expectError()
+	module(t, "cache").call("memoize", 1).expectError()
+	module(t, "cache").call("memoize", double, 1).expectError()
+	module(t, "cache").call("memoize", double, MAP{"size": 1}).expectError()
+	module(t, "cache").call("memoize", double, MAP{"capacity": 0}).expectError()
+
+	m := module(t, "cache").call("memoize", double, MAP{"capacity": 2})
+	assert.NoError(t, m.e)
+	fn := m.o.(*objects.InteropFunction)
+	call := func(args ...objects.Object) (objects.Object, error) {
+		return fn.Value(interop{}, args...)
+	}
+
+	res, err := call(&objects.Int{Value: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Int{Value: 4}, res)
+	res, err = call(&objects.Int{Value: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Int{Value: 4}, res)
+	assert.Equal(t, 1, calls)
+
+	// the error results are not cached
+	for i := 0; i < 2; i++ {
+		res, err = call()
+		assert.NoError(t, err)
+		assert.Equal(t, &objects.Error{Value: &objects.String{Value: "one argument"}}, res)
+	}
+	assert.Equal(t, 3, calls)
+
+	// the runtime errors are returned
+	_, err = call(&objects.String{Value: "2"})
+	assert.Error(t, err)
+
+	// the least recently used result is removed
+	_, _ = call(&objects.Int{Value: 3})
+	_, _ = call(&objects.Int{Value: 4})
+	calls = 0
+	_, _ = call(&objects.Int{Value: 2})
+	assert.Equal(t, 1, calls)
+
+	// the arguments that can't be the keys
+	_, err = call(double)
+	assert.Error(t, err)
+}
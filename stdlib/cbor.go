@@ -0,0 +1,572 @@
+//go:build !tengo_no_cbor
+// +build !tengo_no_cbor
+
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("cbor", cborModule)
+}
+
+func cborModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"encode": &objects.UserFunction{Name: "encode", Value: cborEncode}, // encode(v) => bytes/error
+		"decode": &objects.UserFunction{Name: "decode", Value: cborDecode}, // decode(data) => object/error
+	}
+}
+
+// the major types
+const (
+	cborUint = iota
+	cborNegative
+	cborBytes
+	cborString
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// the tags
+const (
+	cborTagTime      = 0 // RFC 3339 string
+	cborTagEpoch     = 1 // seconds since the epoch
+	cborTagBigUint   = 2
+	cborTagBigNegint = 3
+)
+
+// cborMaxDepth is the maximum nesting depth of the arrays, the maps and the
+// tags to decode.
+const cborMaxDepth = 10000
+
+// cborBreak is the end of the indefinite length items.
+const cborBreak = 0xff
+
+var errCBOREOF = errors.New("unexpected end of data")
+
+func cborEncode(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	e := &cborEncoder{visiting: make(map[objects.Object]bool)}
+	if err := e.encode(args[0]); err != nil {
+		return wrapError(err), nil
+	}
+	if len(e.b) > objects.MaxBytesLen {
+		return nil, objects.ErrBytesLimit
+	}
+
+	return &objects.Bytes{Value: e.b}, nil
+}
+
+func cborDecode(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var data []byte
+	switch o := args[0].(type) {
+	case *objects.Bytes:
+		data = o.Value
+	case *objects.String:
+		data = []byte(o.Value)
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "bytes/string",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	d := &cborDecoder{b: data}
+	res, err := d.decode(0)
+	if err == nil && d.pos < len(d.b) {
+		err = fmt.Errorf("extra data at offset %d", d.pos)
+	}
+	if err != nil {
+		if isLimitError(err) {
+			return nil, err
+		}
+		return wrapError(err), nil
+	}
+
+	return res, nil
+}
+
+type cborEncoder struct {
+	b        []byte
+	visiting map[objects.Object]bool
+}
+
+func (e *cborEncoder) encode(o objects.Object) error {
+	switch o := o.(type) {
+	case *objects.Undefined:
+		e.b = append(e.b, 0xf6) // null
+	case *objects.Bool:
+		if o == objects.TrueValue {
+			e.b = append(e.b, 0xf5)
+		} else {
+			e.b = append(e.b, 0xf4)
+		}
+	case *objects.Int:
+		e.encodeInt(o.Value)
+	case *objects.Char:
+		e.encodeInt(int64(o.Value))
+	case *objects.BigInt:
+		e.encodeBigInt(o.Value)
+	case *objects.Float:
+		e.b = append(e.b, cborSimple<<5|27)
+		e.b = cborAppendUint(e.b, math.Float64bits(o.Value), 8)
+	case *objects.String:
+		e.encodeHead(cborString, uint64(len(o.Value)))
+		e.b = append(e.b, o.Value...)
+	case *objects.Bytes:
+		e.encodeHead(cborBytes, uint64(len(o.Value)))
+		e.b = append(e.b, o.Value...)
+	case *objects.Array:
+		return e.encodeArray(o, o.Value)
+	case *objects.ImmutableArray:
+		return e.encodeArray(o, o.Value)
+	case *objects.Map:
+		return e.encodeMap(o, o.Value)
+	case *objects.ImmutableMap:
+		return e.encodeMap(o, o.Value)
+	case *objects.Time:
+		s := o.Value.Format(time.RFC3339Nano)
+		e.encodeHead(cborTag, cborTagTime)
+		e.encodeHead(cborString, uint64(len(s)))
+		e.b = append(e.b, s...)
+	default:
+		return fmt.Errorf("unsupported type: %s", o.TypeName())
+	}
+
+	return nil
+}
+
+// encodeHead appends the initial byte of the major type and the argument n
+// in the smallest format.
+func (e *cborEncoder) encodeHead(major byte, n uint64) {
+	switch {
+	case n < 24:
+		e.b = append(e.b, major<<5|byte(n))
+	case n <= math.MaxUint8:
+		e.b = append(e.b, major<<5|24, byte(n))
+	case n <= math.MaxUint16:
+		e.b = cborAppendUint(append(e.b, major<<5|25), n, 2)
+	case n <= math.MaxUint32:
+		e.b = cborAppendUint(append(e.b, major<<5|26), n, 4)
+	default:
+		e.b = cborAppendUint(append(e.b, major<<5|27), n, 8)
+	}
+}
+
+func (e *cborEncoder) encodeInt(v int64) {
+	if v < 0 {
+		e.encodeHead(cborNegative, uint64(-1-v))
+		return
+	}
+	e.encodeHead(cborUint, uint64(v))
+}
+
+// encodeBigInt appends the integer, or, the bignum if it's out of the range
+// of the integers.
+func (e *cborEncoder) encodeBigInt(v *big.Int) {
+	if v.Sign() >= 0 {
+		if v.IsUint64() {
+			e.encodeHead(cborUint, v.Uint64())
+			return
+		}
+		b := v.Bytes()
+		e.encodeHead(cborTag, cborTagBigUint)
+		e.encodeHead(cborBytes, uint64(len(b)))
+		e.b = append(e.b, b...)
+		return
+	}
+
+	// -1 - v
+	n := new(big.Int).Not(v)
+	if n.IsUint64() {
+		e.encodeHead(cborNegative, n.Uint64())
+		return
+	}
+	b := n.Bytes()
+	e.encodeHead(cborTag, cborTagBigNegint)
+	e.encodeHead(cborBytes, uint64(len(b)))
+	e.b = append(e.b, b...)
+}
+
+func (e *cborEncoder) encodeArray(o objects.Object, values []objects.Object) error {
+	if e.visiting[o] {
+		return errors.New("unsupported value: encountered a cycle")
+	}
+	e.visiting[o] = true
+	defer delete(e.visiting, o)
+
+	e.encodeHead(cborArray, uint64(len(values)))
+	for _, v := range values {
+		if err := e.encode(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *cborEncoder) encodeMap(o objects.Object, values map[string]objects.Object) error {
+	if e.visiting[o] {
+		return errors.New("unsupported value: encountered a cycle")
+	}
+	e.visiting[o] = true
+	defer delete(e.visiting, o)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	e.encodeHead(cborMap, uint64(len(keys)))
+	for _, k := range keys {
+		e.encodeHead(cborString, uint64(len(k)))
+		e.b = append(e.b, k...)
+		if err := e.encode(values[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cborAppendUint appends the n bytes of v in big endian.
+func cborAppendUint(b []byte, v uint64, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(v>>(uint(i)*8)))
+	}
+	return b
+}
+
+type cborDecoder struct {
+	b   []byte
+	pos int
+}
+
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if uint64(len(d.b)-d.pos) < n {
+		return nil, errCBOREOF
+	}
+	b := d.b[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// head reads the initial byte and the argument of an item. indefinite is
+// true for the indefinite length strings, arrays and maps.
+func (d *cborDecoder) head() (major byte, info byte, arg uint64, indefinite bool, err error) {
+	b, err := d.read(1)
+	if err != nil {
+		return
+	}
+	major, info = b[0]>>5, b[0]&0x1f
+
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		if b, err = d.read(1 << (info - 24)); err != nil {
+			return
+		}
+		for _, c := range b {
+			arg = arg<<8 | uint64(c)
+		}
+	case info == 31 && major >= cborBytes && major <= cborMap:
+		indefinite = true
+	case info == 31 && major == cborSimple:
+		err = fmt.Errorf("unexpected break at offset %d", d.pos-1)
+	default:
+		err = fmt.Errorf("invalid initial byte 0x%02x at offset %d", b[0], d.pos-1)
+	}
+
+	return
+}
+
+// atBreak returns true and skips the break if it's the next byte.
+func (d *cborDecoder) atBreak() (bool, error) {
+	if d.pos >= len(d.b) {
+		return false, errCBOREOF
+	}
+	if d.b[d.pos] == cborBreak {
+		d.pos++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (d *cborDecoder) decode(depth int) (objects.Object, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.New("exceeding nesting depth limit")
+	}
+
+	offset := d.pos
+	major, info, arg, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if arg > math.MaxInt64 {
+			return objects.NewBigInt(new(big.Int).SetUint64(arg)), nil
+		}
+		return objects.NewInt(int64(arg)), nil
+	case cborNegative:
+		if arg > math.MaxInt64 {
+			return objects.NewBigInt(new(big.Int).Not(new(big.Int).SetUint64(arg))), nil
+		}
+		return objects.NewInt(-1 - int64(arg)), nil
+	case cborBytes:
+		b, err := d.decodeString(major, arg, indefinite, objects.MaxBytesLen)
+		if err != nil {
+			if err == objects.ErrStringLimit {
+				err = objects.ErrBytesLimit
+			}
+			return nil, err
+		}
+		return &objects.Bytes{Value: b}, nil
+	case cborString:
+		b, err := d.decodeString(major, arg, indefinite, objects.MaxStringLen)
+		if err != nil {
+			return nil, err
+		}
+		return &objects.String{Value: string(b)}, nil
+	case cborArray:
+		return d.decodeArray(arg, indefinite, depth)
+	case cborMap:
+		return d.decodeMap(arg, indefinite, depth)
+	case cborTag:
+		return d.decodeTag(arg, depth)
+	}
+
+	// the simple values and the floats
+	switch info {
+	case 20:
+		return objects.FalseValue, nil
+	case 21:
+		return objects.TrueValue, nil
+	case 22, 23: // null, undefined
+		return objects.UndefinedValue, nil
+	case 25:
+		return &objects.Float{Value: cborFloat16(uint16(arg))}, nil
+	case 26:
+		return &objects.Float{Value: float64(math.Float32frombits(uint32(arg)))}, nil
+	case 27:
+		return &objects.Float{Value: math.Float64frombits(arg)}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported simple value %d at offset %d", arg, offset)
+}
+
+// decodeString reads the bytes of the byte or text string. The chunks of
+// the indefinite length string are concatenated.
+func (d *cborDecoder) decodeString(major byte, n uint64, indefinite bool, max int) ([]byte, error) {
+	if !indefinite {
+		if n > uint64(max) {
+			return nil, objects.ErrStringLimit
+		}
+		b, err := d.read(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+	}
+
+	res := []byte{}
+	for {
+		end, err := d.atBreak()
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			return res, nil
+		}
+
+		offset := d.pos
+		chunkMajor, _, n, chunkIndefinite, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkIndefinite {
+			return nil, fmt.Errorf("invalid string chunk at offset %d", offset)
+		}
+		if n > uint64(max-len(res)) {
+			return nil, objects.ErrStringLimit
+		}
+		b, err := d.read(n)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, b...)
+	}
+}
+
+func (d *cborDecoder) decodeArray(n uint64, indefinite bool, depth int) (objects.Object, error) {
+	if indefinite {
+		values := []objects.Object{}
+		for {
+			end, err := d.atBreak()
+			if err != nil {
+				return nil, err
+			}
+			if end {
+				return &objects.Array{Value: values}, nil
+			}
+			if len(values) >= objects.MaxArrayLen {
+				return nil, objects.ErrArrayLimit
+			}
+
+			v, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+	}
+
+	// every element is at least 1 byte
+	if n > uint64(len(d.b)-d.pos) {
+		return nil, errCBOREOF
+	}
+	if n > uint64(objects.MaxArrayLen) {
+		return nil, objects.ErrArrayLimit
+	}
+
+	values := make([]objects.Object, n)
+	for i := range values {
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	return &objects.Array{Value: values}, nil
+}
+
+func (d *cborDecoder) decodeMap(n uint64, indefinite bool, depth int) (objects.Object, error) {
+	// every key and value is at least 1 byte
+	if !indefinite && n > uint64(len(d.b)-d.pos)/2 {
+		return nil, errCBOREOF
+	}
+
+	values := make(map[string]objects.Object)
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite {
+			end, err := d.atBreak()
+			if err != nil {
+				return nil, err
+			}
+			if end {
+				break
+			}
+		}
+
+		offset := d.pos
+		k, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := decodedKey(k)
+		if !ok {
+			return nil, fmt.Errorf("unsupported map key type %s at offset %d", k.TypeName(), offset)
+		}
+
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+
+	return &objects.Map{Value: values}, nil
+}
+
+// decodeTag decodes the tagged item. The items of the unknown tags are
+// decoded as if they were not tagged.
+func (d *cborDecoder) decodeTag(tag uint64, depth int) (objects.Object, error) {
+	offset := d.pos
+	v, err := d.decode(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case cborTagTime:
+		s, ok := v.(*objects.String)
+		if !ok {
+			return nil, fmt.Errorf("invalid time at offset %d: expected string, found %s", offset, v.TypeName())
+		}
+		t, err := time.Parse(time.RFC3339Nano, s.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time at offset %d: %s", offset, err.Error())
+		}
+		return &objects.Time{Value: t}, nil
+	case cborTagEpoch:
+		switch v := v.(type) {
+		case *objects.Int:
+			return &objects.Time{Value: time.Unix(v.Value, 0).UTC()}, nil
+		case *objects.Float:
+			if math.IsNaN(v.Value) || math.IsInf(v.Value, 0) {
+				return nil, fmt.Errorf("invalid time at offset %d: %s", offset, v.String())
+			}
+			sec, frac := math.Modf(v.Value)
+			return &objects.Time{Value: time.Unix(int64(sec), int64(frac*1e9)).UTC()}, nil
+		}
+		return nil, fmt.Errorf("invalid time at offset %d: expected int or float, found %s", offset, v.TypeName())
+	case cborTagBigUint, cborTagBigNegint:
+		b, ok := v.(*objects.Bytes)
+		if !ok {
+			return nil, fmt.Errorf("invalid bignum at offset %d: expected bytes, found %s", offset, v.TypeName())
+		}
+		n := new(big.Int).SetBytes(b.Value)
+		if tag == cborTagBigNegint {
+			n.Not(n)
+		}
+		return objects.NewBigInt(n), nil
+	}
+
+	return v, nil
+}
+
+// cborFloat16 returns the value of the IEEE 754 half precision float.
+func cborFloat16(h uint16) float64 {
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+
+	var v float64
+	switch exp {
+	case 0:
+		v = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			v = math.Inf(1)
+		} else {
+			v = math.NaN()
+		}
+	default:
+		v = math.Ldexp(mant+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,138 @@
+package stdlib_test
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestCBOREncode(t *testing.T) {
+	module(t, "cbor").call("encode").expectError()
+
+	encode := func(v interface{}, expected []byte) {
+		module(t, "cbor").call("encode", v).expect(expected, v)
+	}
+
+	// the examples of RFC 8949
+	encode(0, []byte{0x00})
+	encode(23, []byte{0x17})
+	encode(24, []byte{0x18, 0x18})
+	encode(1000, []byte{0x19, 0x03, 0xe8})
+	encode(1000000, []byte{0x1a, 0x00, 0x0f, 0x42, 0x40})
+	encode(1000000000000, []byte{0x1b, 0x00, 0x00, 0x00, 0xe8, 0xd4, 0xa5, 0x10, 0x00})
+	encode(-1, []byte{0x20})
+	encode(-100, []byte{0x38, 0x63})
+	encode(-1000, []byte{0x39, 0x03, 0xe7})
+	encode(math.MinInt64, []byte{0x3b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	encode('a', []byte{0x18, 0x61})
+	encode(1.1, []byte{0xfb, 0x3f, 0xf1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a})
+	encode(false, []byte{0xf4})
+	encode(true, []byte{0xf5})
+	encode(objects.UndefinedValue, []byte{0xf6})
+	encode("", []byte{0x60})
+	encode("IETF", []byte{0x64, 'I', 'E', 'T', 'F'})
+	encode([]byte{1, 2, 3, 4}, []byte{0x44, 0x01, 0x02, 0x03, 0x04})
+	encode(ARR{}, []byte{0x80})
+	encode(ARR{1, ARR{2, 3}, IARR{4, 5}}, []byte{0x83, 0x01, 0x82, 0x02, 0x03, 0x82, 0x04, 0x05})
+	encode(make([]int, 25), append([]byte{0x98, 0x19}, make([]byte, 25)...))
+	encode(MAP{}, []byte{0xa0})
+	encode(IMAP{"b": ARR{2, 3}, "a": 1}, []byte{0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0x82, 0x02, 0x03})
+	encode(time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC),
+		append([]byte{0xc0, 0x74}, "2013-03-21T20:04:00Z"...))
+
+	// the bignums
+	max := new(big.Int).SetUint64(math.MaxUint64)
+	encode(&objects.BigInt{Value: max},
+		[]byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	encode(&objects.BigInt{Value: new(big.Int).Add(max, big.NewInt(1))},
+		[]byte{0xc2, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	encode(&objects.BigInt{Value: new(big.Int).Not(max)},
+		[]byte{0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	encode(&objects.BigInt{Value: new(big.Int).Sub(new(big.Int).Not(max), big.NewInt(1))},
+		[]byte{0xc3, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+
+	encodeError := func(v interface{}, msg string) {
+		module(t, "cbor").call("encode", v).
+			expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+
+	m := &objects.Map{Value: map[string]objects.Object{}}
+	m.Value["a"] = object(ARR{m})
+	encodeError(MAP{"m": m}, "unsupported value: encountered a cycle")
+	encodeError(ARR{&objects.Error{}}, "unsupported type: error")
+}
+
+func TestCBORDecode(t *testing.T) {
+	module(t, "cbor").call("decode").expectError()
+	module(t, "cbor").call("decode", ARR{}).expectError()
+
+	decode := func(data []byte, expected interface{}) {
+		module(t, "cbor").call("decode", data).expect(expected, data)
+	}
+
+	// the examples of RFC 8949
+	decode([]byte{0x18, 0x64}, 100)
+	decode([]byte{0x29}, -10)
+	decode([]byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		&objects.BigInt{Value: new(big.Int).SetUint64(math.MaxUint64)})
+	decode([]byte{0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		&objects.BigInt{Value: new(big.Int).Not(new(big.Int).SetUint64(math.MaxUint64))})
+	decode([]byte{0xc2, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		&objects.BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 64)})
+	decode([]byte{0xc3, 0x41, 0x00}, -1)
+	decode([]byte{0xf9, 0x3c, 0x00}, 1.0)
+	decode([]byte{0xf9, 0xc4, 0x00}, -4.0)
+	decode([]byte{0xf9, 0x7b, 0xff}, 65504.0)
+	decode([]byte{0xf9, 0x00, 0x01}, 5.960464477539063e-08)
+	decode([]byte{0xf9, 0x7c, 0x00}, math.Inf(1))
+	decode([]byte{0xfa, 0x47, 0xc3, 0x50, 0x00}, 100000.0)
+	decode([]byte{0xf7}, objects.UndefinedValue)
+	decode(append([]byte{0xc0, 0x74}, "2013-03-21T20:04:00Z"...), time.Unix(1363896240, 0))
+	decode([]byte{0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0}, time.Unix(1363896240, 0))
+	decode([]byte{0xc1, 0xfb, 0x41, 0xd4, 0x52, 0xd9, 0xec, 0x20, 0x00, 0x00}, time.Unix(1363896240, 5e8))
+	decode([]byte{0xd8, 0x20, 0x61, 'a'}, "a")
+	decode([]byte{0x5f, 0x42, 0x01, 0x02, 0x43, 0x03, 0x04, 0x05, 0xff}, []byte{1, 2, 3, 4, 5})
+	decode(append(append([]byte{0x7f, 0x65}, "strea"...), append([]byte{0x64}, "ming\xff"...)...), "streaming")
+	decode([]byte{0x9f, 0xff}, ARR{})
+	decode([]byte{0x9f, 0x01, 0x82, 0x02, 0x03, 0x9f, 0x04, 0x05, 0xff, 0xff}, ARR{1, ARR{2, 3}, ARR{4, 5}})
+	decode([]byte{0xbf, 0x61, 'a', 0x01, 0x61, 'b', 0x9f, 0x02, 0x03, 0xff, 0xff}, MAP{"a": 1, "b": ARR{2, 3}})
+	decode([]byte{0xa2, 0x01, 0x02, 0x41, 'x', 0xf5}, MAP{"1": 2, "x": true})
+	module(t, "cbor").call("decode", "\x61a").expect("a")
+
+	// the round trip
+	v := MAP{
+		"a": ARR{1, -1000, 1.25, "x", []byte("y"), false, objects.UndefinedValue},
+		"b": MAP{"c": MAP{"d": strings.Repeat("z", 70000)}},
+		"t": time.Date(2020, 1, 2, 3, 4, 5, 6, time.FixedZone("", 3600)),
+	}
+	data := module(t, "cbor").call("encode", v)
+	assert.NoError(t, data.e)
+	decode(data.o.(*objects.Bytes).Value, v)
+
+	decodeError := func(data []byte, msg string) {
+		module(t, "cbor").call("decode", data).
+			expect(&objects.Error{Value: &objects.String{Value: msg}}, data)
+	}
+
+	decodeError([]byte{}, "unexpected end of data")
+	decodeError([]byte{0x19, 0x01}, "unexpected end of data")
+	decodeError([]byte{0x9f, 0x01}, "unexpected end of data")
+	decodeError([]byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, "unexpected end of data")
+	decodeError([]byte{0xff}, "unexpected break at offset 0")
+	decodeError([]byte{0x1c}, "invalid initial byte 0x1c at offset 0")
+	decodeError([]byte{0x01, 0x01}, "extra data at offset 1")
+	decodeError([]byte{0xf0}, "unsupported simple value 16 at offset 0")
+	decodeError([]byte{0xa1, 0xf6, 0x01}, "unsupported map key type undefined at offset 1")
+	decodeError([]byte{0x5f, 0x61, 'a', 0xff}, "invalid string chunk at offset 1")
+	decodeError([]byte{0xc0, 0x01}, "invalid time at offset 1: expected string, found int")
+	decodeError([]byte{0xc1, 0x61, 'a'}, "invalid time at offset 1: expected int or float, found string")
+	decodeError([]byte{0xc2, 0x01}, "invalid bignum at offset 1: expected bytes, found int")
+
+	deep := append([]byte(strings.Repeat("\x81", 10001)), 0x00)
+	decodeError(deep, "exceeding nesting depth limit")
+}
@@ -0,0 +1,347 @@
+//go:build !tengo_no_container
+// +build !tengo_no_container
+
+package stdlib
+
+import (
+	"container/heap"
+
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("container", containerModule)
+}
+
+func containerModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"heap":  &objects.UserFunction{Name: "heap", Value: containerNewHeap},   // heap([less func(a, b) => bool]) => heap
+		"queue": &objects.UserFunction{Name: "queue", Value: containerNewQueue}, // queue() => queue
+		"deque": &objects.UserFunction{Name: "deque", Value: containerNewDeque}, // deque() => deque
+	}
+}
+
+func containerNewHeap(args ...objects.Object) (objects.Object, error) {
+	if len(args) > 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	h := &containerHeap{}
+	if len(args) == 1 {
+		switch args[0].(type) {
+		case *objects.CompiledFunction, *objects.Closure, objects.Callable, objects.InteropCallable:
+			h.less = args[0]
+		default:
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "first",
+				Expected: "callable",
+				Found:    args[0].TypeName(),
+			}
+		}
+	}
+
+	return makeContainerHeap(h), nil
+}
+
+func containerNewQueue(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return makeContainerQueue(&containerDeque{}), nil
+}
+
+func containerNewDeque(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return makeContainerDeque(&containerDeque{}), nil
+}
+
+func makeContainerHeap(h *containerHeap) *objects.ImmutableMap {
+	return &objects.ImmutableMap{
+		Value: map[string]objects.Object{
+			// push(values...) => undefined
+			"push": &objects.InteropFunction{
+				Name: "push",
+				Value: func(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+					h.rt = rt
+					for _, arg := range args {
+						heap.Push(h, arg)
+						if h.err != nil {
+							return nil, h.takeErr()
+						}
+					}
+
+					return objects.UndefinedValue, nil
+				},
+			},
+			// pop() => any/undefined
+			"pop": &objects.InteropFunction{
+				Name: "pop",
+				Value: func(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+					if len(args) != 0 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					if len(h.elems) == 0 {
+						return objects.UndefinedValue, nil
+					}
+
+					h.rt = rt
+					res := heap.Pop(h).(objects.Object)
+					if h.err != nil {
+						return nil, h.takeErr()
+					}
+
+					return res, nil
+				},
+			},
+			// peek() => any/undefined
+			"peek": &objects.UserFunction{
+				Name: "peek",
+				Value: func(args ...objects.Object) (objects.Object, error) {
+					if len(args) != 0 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					if len(h.elems) == 0 {
+						return objects.UndefinedValue, nil
+					}
+
+					return h.elems[0], nil
+				},
+			},
+			// len() => int
+			"len": &objects.UserFunction{
+				Name: "len",
+				Value: func(args ...objects.Object) (objects.Object, error) {
+					if len(args) != 0 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					return &objects.Int{Value: int64(len(h.elems))}, nil
+				},
+			},
+		},
+	}
+}
+
+func makeContainerQueue(d *containerDeque) *objects.ImmutableMap {
+	return &objects.ImmutableMap{
+		Value: map[string]objects.Object{
+			"push": &objects.UserFunction{Name: "push", Value: d.pushBackFunc}, // push(values...) => undefined
+			"pop":  &objects.UserFunction{Name: "pop", Value: d.popFrontFunc},  // pop() => any/undefined
+			"peek": &objects.UserFunction{Name: "peek", Value: d.frontFunc},    // peek() => any/undefined
+			"len":  &objects.UserFunction{Name: "len", Value: d.lenFunc},       // len() => int
+		},
+	}
+}
+
+func makeContainerDeque(d *containerDeque) *objects.ImmutableMap {
+	return &objects.ImmutableMap{
+		Value: map[string]objects.Object{
+			"push_back":  &objects.UserFunction{Name: "push_back", Value: d.pushBackFunc},   // push_back(values...) => undefined
+			"push_front": &objects.UserFunction{Name: "push_front", Value: d.pushFrontFunc}, // push_front(values...) => undefined
+			"pop_back":   &objects.UserFunction{Name: "pop_back", Value: d.popBackFunc},     // pop_back() => any/undefined
+			"pop_front":  &objects.UserFunction{Name: "pop_front", Value: d.popFrontFunc},   // pop_front() => any/undefined
+			"back":       &objects.UserFunction{Name: "back", Value: d.backFunc},            // back() => any/undefined
+			"front":      &objects.UserFunction{Name: "front", Value: d.frontFunc},          // front() => any/undefined
+			"len":        &objects.UserFunction{Name: "len", Value: d.lenFunc},              // len() => int
+		},
+	}
+}
+
+// containerHeap implements heap.Interface. If less function is not given,
+// the elements are ordered by '<' operator (a min-heap).
+type containerHeap struct {
+	elems []objects.Object
+	less  objects.Object
+	rt    objects.Interop
+	err   error
+}
+
+func (h *containerHeap) Len() int {
+	return len(h.elems)
+}
+
+func (h *containerHeap) Less(i, j int) bool {
+	if h.err != nil {
+		return false
+	}
+
+	var res objects.Object
+	if h.less == nil {
+		if c, ok := h.elems[i].(objects.Comparable); ok {
+			r, err := c.Compare(h.elems[j])
+			if err != nil {
+				h.err = err
+				return false
+			}
+
+			return r < 0
+		}
+
+		res, h.err = h.elems[i].BinaryOp(token.Less, h.elems[j])
+	} else {
+		res, h.err = h.rt.Call(h.less, h.elems[i], h.elems[j])
+	}
+
+	return h.err == nil && !res.IsFalsy()
+}
+
+func (h *containerHeap) Swap(i, j int) {
+	h.elems[i], h.elems[j] = h.elems[j], h.elems[i]
+}
+
+func (h *containerHeap) Push(x interface{}) {
+	h.elems = append(h.elems, x.(objects.Object))
+}
+
+func (h *containerHeap) Pop() interface{} {
+	n := len(h.elems)
+	x := h.elems[n-1]
+	h.elems[n-1] = nil
+	h.elems = h.elems[:n-1]
+
+	return x
+}
+
+// takeErr returns and clears the error from the last failed comparison.
+func (h *containerHeap) takeErr() error {
+	err := h.err
+	h.err = nil
+
+	return err
+}
+
+// containerDeque is a double-ended queue backed by a ring buffer.
+type containerDeque struct {
+	buf   []objects.Object
+	head  int
+	count int
+}
+
+func (d *containerDeque) grow() {
+	if d.count < len(d.buf) {
+		return
+	}
+
+	n := len(d.buf) * 2
+	if n == 0 {
+		n = 8
+	}
+
+	buf := make([]objects.Object, n)
+	for i := 0; i < d.count; i++ {
+		buf[i] = d.buf[(d.head+i)%len(d.buf)]
+	}
+
+	d.buf = buf
+	d.head = 0
+}
+
+func (d *containerDeque) pushBack(o objects.Object) {
+	d.grow()
+	d.buf[(d.head+d.count)%len(d.buf)] = o
+	d.count++
+}
+
+func (d *containerDeque) pushFront(o objects.Object) {
+	d.grow()
+	d.head = (d.head - 1 + len(d.buf)) % len(d.buf)
+	d.buf[d.head] = o
+	d.count++
+}
+
+func (d *containerDeque) popFront() objects.Object {
+	if d.count == 0 {
+		return objects.UndefinedValue
+	}
+
+	o := d.buf[d.head]
+	d.buf[d.head] = nil
+	d.head = (d.head + 1) % len(d.buf)
+	d.count--
+
+	return o
+}
+
+func (d *containerDeque) popBack() objects.Object {
+	if d.count == 0 {
+		return objects.UndefinedValue
+	}
+
+	i := (d.head + d.count - 1) % len(d.buf)
+	o := d.buf[i]
+	d.buf[i] = nil
+	d.count--
+
+	return o
+}
+
+func (d *containerDeque) pushBackFunc(args ...objects.Object) (objects.Object, error) {
+	for _, arg := range args {
+		d.pushBack(arg)
+	}
+
+	return objects.UndefinedValue, nil
+}
+
+func (d *containerDeque) pushFrontFunc(args ...objects.Object) (objects.Object, error) {
+	for _, arg := range args {
+		d.pushFront(arg)
+	}
+
+	return objects.UndefinedValue, nil
+}
+
+func (d *containerDeque) popFrontFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return d.popFront(), nil
+}
+
+func (d *containerDeque) popBackFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return d.popBack(), nil
+}
+
+func (d *containerDeque) frontFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	if d.count == 0 {
+		return objects.UndefinedValue, nil
+	}
+
+	return d.buf[d.head], nil
+}
+
+func (d *containerDeque) backFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	if d.count == 0 {
+		return objects.UndefinedValue, nil
+	}
+
+	return d.buf[(d.head+d.count-1)%len(d.buf)], nil
+}
+
+func (d *containerDeque) lenFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return &objects.Int{Value: int64(d.count)}, nil
+}
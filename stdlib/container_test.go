@@ -0,0 +1,74 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+func TestContainerHeap(t *testing.T) {
+	h := module(t, "container").call("heap")
+	h.call("pop").expect(objects.UndefinedValue)
+	h.call("push", 5, 1, 4).expect(objects.UndefinedValue)
+	h.call("push", 2).expect(objects.UndefinedValue)
+	h.call("len").expect(4)
+	h.call("peek").expect(1)
+	h.call("pop").expect(1)
+	h.call("pop").expect(2)
+	h.call("pop").expect(4)
+	h.call("pop").expect(5)
+	h.call("len").expect(0)
+
+	h = module(t, "container").call("heap", &objects.UserFunction{
+		Value: func(args ...objects.Object) (objects.Object, error) {
+			return args[0].BinaryOp(token.Greater, args[1])
+		},
+	})
+	h.call("push", 2, 3, 1).expect(objects.UndefinedValue)
+	h.call("pop").expect(3)
+	h.call("pop").expect(2)
+	h.call("pop").expect(1)
+
+	h = module(t, "container").call("heap")
+	h.call("push", 1, "a").expectError()
+
+	module(t, "container").call("heap", 1).expectError()
+}
+
+func TestContainerQueue(t *testing.T) {
+	q := module(t, "container").call("queue")
+	q.call("pop").expect(objects.UndefinedValue)
+	q.call("peek").expect(objects.UndefinedValue)
+	for i := 0; i < 20; i++ {
+		q.call("push", i).expect(objects.UndefinedValue)
+	}
+	q.call("len").expect(20)
+	for i := 0; i < 10; i++ {
+		q.call("pop").expect(i)
+	}
+	q.call("push", 20, 21).expect(objects.UndefinedValue)
+	q.call("peek").expect(10)
+	for i := 10; i < 22; i++ {
+		q.call("pop").expect(i)
+	}
+	q.call("len").expect(0)
+}
+
+func TestContainerDeque(t *testing.T) {
+	d := module(t, "container").call("deque")
+	d.call("pop_back").expect(objects.UndefinedValue)
+	d.call("pop_front").expect(objects.UndefinedValue)
+	d.call("push_back", 1, 2).expect(objects.UndefinedValue)
+	d.call("push_front", 0, -1).expect(objects.UndefinedValue)
+	d.call("front").expect(-1)
+	d.call("back").expect(2)
+	d.call("len").expect(4)
+	for i := 0; i < 10; i++ {
+		d.call("push_front", 100+i).expect(objects.UndefinedValue)
+	}
+	d.call("pop_back").expect(2)
+	d.call("pop_back").expect(1)
+	d.call("pop_front").expect(109)
+	d.call("len").expect(11)
+}
@@ -0,0 +1,482 @@
+//go:build !tengo_no_cron
+// +build !tengo_no_cron
+
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("cron", cronModule)
+}
+
+func cronModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"parse":    &objects.UserFunction{Name: "parse", Value: cronParse},         // parse(expr) => {expr:,next:}/error
+		"next":     &objects.UserFunction{Name: "next", Value: cronNext},           // next(expr, after) => time/error
+		"is_valid": &objects.UserFunction{Name: "is_valid", Value: cronIsValid},    // is_valid(expr) => bool
+		"schedule": &objects.UserFunction{Name: "schedule", Value: cronNoSchedule}, // schedule(expr, fn(time)) => undefined/error
+	}
+}
+
+// errCronNoScheduler is returned by cron.schedule if the host application
+// did not enable the scheduler.
+var errCronNoScheduler = errors.New("cron scheduler is not enabled by the host application")
+
+// cronDescriptors are the shorthands of the cron expressions.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// cronSchedule is a parsed cron expression. The fields are the bit sets of
+// the matching values.
+type cronSchedule struct {
+	expr    string
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+// cronParseExpr parses a cron expression of 5 fields (minute, hour, day of
+// month, month and day of week) or a descriptor (e.g. "@daily").
+func cronParseExpr(expr string) (*cronSchedule, error) {
+	s := strings.TrimSpace(expr)
+	if d, ok := cronDescriptors[strings.ToLower(s)]; ok {
+		s = d
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression '%s': expected 5 fields, found %d", expr, len(fields))
+	}
+
+	sched := &cronSchedule{expr: expr}
+
+	var err error
+	if sched.minute, _, err = cronParseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression '%s': minute: %s", expr, err.Error())
+	}
+	if sched.hour, _, err = cronParseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression '%s': hour: %s", expr, err.Error())
+	}
+	if sched.dom, sched.domStar, err = cronParseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression '%s': day of month: %s", expr, err.Error())
+	}
+	if sched.month, _, err = cronParseField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("invalid cron expression '%s': month: %s", expr, err.Error())
+	}
+	if sched.dow, sched.dowStar, err = cronParseField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, fmt.Errorf("invalid cron expression '%s': day of week: %s", expr, err.Error())
+	}
+
+	// 7 is Sunday too
+	if sched.dow&(1<<7) != 0 {
+		sched.dow |= 1
+	}
+
+	return sched, nil
+}
+
+// cronParseField parses a comma-separated list of the values, the ranges
+// ("1-5") and the steps ("*/15", "1-30/2"). It returns true if the field
+// starts with "*" (or is "?"), which matters for the days.
+func cronParseField(field string, min, max int, names map[string]int) (uint64, bool, error) {
+	if field == "?" {
+		field = "*"
+	}
+	star := strings.HasPrefix(field, "*")
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, false, fmt.Errorf("invalid step '%s'", part[i+1:])
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			i := strings.Index(rng, "-")
+			var err error
+			if i >= 0 {
+				if lo, err = cronParseValue(rng[:i], min, max, names); err != nil {
+					return 0, false, err
+				}
+				if hi, err = cronParseValue(rng[i+1:], min, max, names); err != nil {
+					return 0, false, err
+				}
+				if lo > hi {
+					return 0, false, fmt.Errorf("invalid range '%s'", rng)
+				}
+			} else {
+				if lo, err = cronParseValue(rng, min, max, names); err != nil {
+					return 0, false, err
+				}
+				// "5/10" is from 5 to the maximum
+				if step == 1 {
+					hi = lo
+				}
+			}
+		}
+
+		bits |= cronBits(lo, hi, step)
+	}
+
+	return bits, star, nil
+}
+
+func cronParseValue(s string, min, max int, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s'", s)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", n, min, max)
+	}
+
+	return n, nil
+}
+
+func cronBits(lo, hi, step int) uint64 {
+	var bits uint64
+	for i := lo; i <= hi; i += step {
+		bits |= 1 << uint(i)
+	}
+
+	return bits
+}
+
+// next returns the first time after t that matches the schedule in the
+// location of t. It returns false if there's no such time in 5 years (e.g.
+// February 30).
+func (s *cronSchedule) next(t time.Time) (time.Time, bool) {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.Year() + 5
+
+	for t.Year() <= limit {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// matchDay returns true if the day matches the day of month or the day of
+// week. If one of them is "*", only the other one is used.
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dow
+	case s.dowStar:
+		return dom
+	}
+
+	return dom || dow
+}
+
+func (s *cronSchedule) nextObject(after objects.Object, name string) (objects.Object, error) {
+	t, ok := objects.ToTime(after)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     name,
+			Expected: "time(compatible)",
+			Found:    after.TypeName(),
+		}
+	}
+
+	next, ok := s.next(t)
+	if !ok {
+		return wrapError(fmt.Errorf("cron expression '%s' has no next time", s.expr)), nil
+	}
+
+	return &objects.Time{Value: next}, nil
+}
+
+func cronParse(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	expr, err := stringArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	sched, err := cronParseExpr(expr)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	return &objects.ImmutableMap{Value: map[string]objects.Object{
+		"expr": &objects.String{Value: expr},
+		// next(after) => time/error
+		"next": &objects.UserFunction{
+			Name: "next",
+			Value: func(args ...objects.Object) (objects.Object, error) {
+				if len(args) != 1 {
+					return nil, objects.ErrWrongNumArguments
+				}
+
+				return sched.nextObject(args[0], "first")
+			},
+		},
+	}}, nil
+}
+
+func cronNext(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	expr, err := stringArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	sched, err := cronParseExpr(expr)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	return sched.nextObject(args[1], "second")
+}
+
+func cronIsValid(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	expr, err := stringArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := cronParseExpr(expr); err != nil {
+		return objects.FalseValue, nil
+	}
+
+	return objects.TrueValue, nil
+}
+
+func cronNoSchedule(args ...objects.Object) (objects.Object, error) {
+	return nil, errCronNoScheduler
+}
+
+// CronScheduler calls the callbacks that the scripts registered with
+// cron.schedule on their schedules. cron.schedule fails unless the host
+// application enables the scheduler with Register, and, runs it after the
+// script completes.
+//
+//	s := &stdlib.CronScheduler{}
+//	stdlib.Register("cron", s.Module)
+//	// compile and run the script
+//	err := s.Run(ctx, compiled)
+//
+// The callbacks are called one at a time in the virtual machine of the
+// script with the scheduled time.
+type CronScheduler struct {
+	// Location is the time zone of the schedules. The default is the local
+	// time zone.
+	Location *time.Location
+
+	lock  sync.Mutex
+	jobs  []*cronJob
+	added chan struct{}
+}
+
+// cronJob is a callback scheduled by the script, and, the next time to
+// call it.
+type cronJob struct {
+	sched *cronSchedule
+	fn    objects.Object
+	next  time.Time
+}
+
+// Module returns the members of cron module where cron.schedule adds the
+// callbacks to the scheduler.
+func (s *CronScheduler) Module() map[string]objects.Object {
+	members := cronModule()
+	members["schedule"] = &objects.UserFunction{Name: "schedule", Value: s.schedule}
+
+	return members
+}
+
+// Run calls the callbacks on their schedules until the context is done. It
+// returns the error of the context, or, the error of a callback. The runs
+// that are missed while a callback is running are skipped.
+func (s *CronScheduler) Run(ctx context.Context, runner FunctionRunner) error {
+	for {
+		s.lock.Lock()
+		added := s.addedChan()
+		var next time.Time
+		for _, job := range s.jobs {
+			if next.IsZero() || job.next.Before(next) {
+				next = job.next
+			}
+		}
+		s.lock.Unlock()
+
+		// wait for the next run, or, a new callback that may run earlier
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			timeout = timer.C
+		}
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-added:
+		case <-timeout:
+			err = s.RunDue(time.Now(), runner)
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// RunDue calls the callbacks that are scheduled at or before now, and,
+// schedules their next runs after now.
+func (s *CronScheduler) RunDue(now time.Time, runner FunctionRunner) error {
+	s.lock.Lock()
+	var due []*cronJob
+	jobs := s.jobs[:0]
+	for _, job := range s.jobs {
+		if !job.next.After(now) {
+			due = append(due, job)
+
+			// the jobs without the next runs are removed
+			next, ok := job.sched.next(now.In(job.next.Location()))
+			if !ok {
+				continue
+			}
+			job = &cronJob{sched: job.sched, fn: job.fn, next: next}
+		}
+		jobs = append(jobs, job)
+	}
+	s.jobs = jobs
+	s.lock.Unlock()
+
+	for _, job := range due {
+
+		if _, err := runner.RunFunction(job.fn, &objects.Time{Value: job.next}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *CronScheduler) schedule(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	expr, err := stringArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	switch args[1].(type) {
+	case *objects.CompiledFunction, *objects.Closure, objects.Callable, objects.InteropCallable:
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "callable",
+			Found:    args[1].TypeName(),
+		}
+	}
+
+	sched, err := cronParseExpr(expr)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	next, ok := sched.next(time.Now().In(loc))
+	if !ok {
+		return wrapError(fmt.Errorf("cron expression '%s' has no next time", expr)), nil
+	}
+
+	s.lock.Lock()
+	s.jobs = append(s.jobs, &cronJob{sched: sched, fn: args[1], next: next})
+	select {
+	case s.addedChan() <- struct{}{}:
+	default:
+	}
+	s.lock.Unlock()
+
+	return objects.UndefinedValue, nil
+}
+
+// addedChan returns the channel that is notified when a callback is added
+// while Run is waiting. It must be called with the lock.
+func (s *CronScheduler) addedChan() chan struct{} {
+	if s.added == nil {
+		s.added = make(chan struct{}, 1)
+	}
+
+	return s.added
+}
@@ -0,0 +1,109 @@
+package stdlib_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/stdlib"
+)
+
+func TestCron(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, 1, 31, 10, 20, 30, 0, time.UTC)
+	date := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2024, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	invalid := func(msg string) *objects.Error {
+		return &objects.Error{Value: &objects.String{Value: msg}}
+	}
+
+	mod := module(t, "cron")
+	mod.call("next", "* * * * *", now).expect(date(1, 31, 10, 21))
+	mod.call("next", "*/15 * * * *", now).expect(date(1, 31, 10, 30))
+	mod.call("next", "0 9-17 * * *", now).expect(date(1, 31, 11, 0))
+	mod.call("next", "30 9 * * mon-fri", now).expect(date(2, 1, 9, 30))
+	mod.call("next", "0 0 * * 0", now).expect(date(2, 4, 0, 0))
+	mod.call("next", "0 0 * * 7", now).expect(date(2, 4, 0, 0))
+	mod.call("next", "0 0 29 2 *", now).expect(date(2, 29, 0, 0))
+	mod.call("next", "0 0 31 * *", now).expect(date(3, 31, 0, 0))
+	mod.call("next", "0 12 1,15 * *", now).expect(date(2, 1, 12, 0))
+	mod.call("next", "0 0 1 jan-mar *", now).expect(date(2, 1, 0, 0))
+	mod.call("next", "5/20 10 * * *", now).expect(date(1, 31, 10, 25))
+	mod.call("next", "@daily", now).expect(date(2, 1, 0, 0))
+	mod.call("next", "@hourly", now).expect(date(1, 31, 11, 0))
+	mod.call("next", "@monthly", now).expect(date(2, 1, 0, 0))
+
+	// the day of month or the day of week (Friday)
+	mod.call("next", "0 0 15 * fri", now).expect(date(2, 2, 0, 0))
+	mod.call("next", "0 0 */2 * fri", now).expect(date(2, 2, 0, 0))
+
+	// unix time in seconds
+	mod.call("next", "0 * * * *", now.Unix()).expect(time.Unix(date(1, 31, 11, 0).Unix(), 0))
+
+	mod.call("next", "0 0 30 2 *", now).expect(invalid("cron expression '0 0 30 2 *' has no next time"))
+	mod.call("next", "0 0 * *", now).expect(invalid("invalid cron expression '0 0 * *': expected 5 fields, found 4"))
+	mod.call("next", "60 * * * *", now).expect(invalid("invalid cron expression '60 * * * *': minute: value 60 out of range [0, 59]"))
+	mod.call("next", "* 5-1 * * *", now).expect(invalid("invalid cron expression '* 5-1 * * *': hour: invalid range '5-1'"))
+	mod.call("next", "*/0 * * * *", now).expect(invalid("invalid cron expression '*/0 * * * *': minute: invalid step '0'"))
+	mod.call("next", "* * * foo *", now).expect(invalid("invalid cron expression '* * * foo *': month: invalid value 'foo'"))
+	mod.call("next", 1, now).expectError()
+	mod.call("next", "* * * * *", "now").expectError()
+	mod.call("next", "* * * * *").expectError()
+
+	mod.call("is_valid", "0 9 * * mon-fri").expect(true)
+	mod.call("is_valid", "@weekly").expect(true)
+	mod.call("is_valid", "0 9 * * funday").expect(false)
+	mod.call("is_valid", "").expect(false)
+
+	sched := mod.call("parse", "0 */6 * * *")
+	if assert.NoError(t, sched.e) {
+		m := callres{t: t, o: sched.o}
+		assert.Equal(t, objects.Object(&objects.String{Value: "0 */6 * * *"}), sched.o.(*objects.ImmutableMap).Value["expr"])
+		m.call("next", now).expect(date(1, 31, 12, 0))
+		m.call("next", date(1, 31, 23, 59)).expect(date(2, 1, 0, 0))
+	}
+	mod.call("parse", "* * *").expect(invalid("invalid cron expression '* * *': expected 5 fields, found 3"))
+
+	// the scheduler is not enabled
+	mod.call("schedule", "* * * * *", &objects.UserFunction{}).expectError()
+}
+
+func TestCronScheduler(t *testing.T) {
+	var calls []time.Time
+	callback := &objects.UserFunction{Value: func(args ...objects.Object) (objects.Object, error) {
+		calls = append(calls, args[0].(*objects.Time).Value)
+		return objects.UndefinedValue, nil
+	}}
+
+	s := &stdlib.CronScheduler{Location: time.UTC}
+	mod := callres{t: t, o: &objects.ImmutableMap{Value: s.Module()}}
+	mod.call("schedule", "* * * * *", callback).expect(objects.UndefinedValue)
+	mod.call("schedule", "0 0 30 2 *", callback).expect(&objects.Error{Value: &objects.String{Value: "cron expression '0 0 30 2 *' has no next time"}})
+	mod.call("schedule", "* * * * *", 1).expectError()
+	mod.call("schedule", "* * *", callback).expect(&objects.Error{Value: &objects.String{Value: "invalid cron expression '* * *': expected 5 fields, found 3"}})
+	mod.call("next", "@hourly", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)).
+		expect(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC))
+
+	// not due yet
+	assert.NoError(t, s.RunDue(time.Now(), runner{}))
+	assert.Equal(t, 0, len(calls))
+
+	// the missed runs are skipped
+	now := time.Now().Add(3 * time.Minute)
+	assert.NoError(t, s.RunDue(now, runner{}))
+	assert.Equal(t, 1, len(calls))
+	assert.NoError(t, s.RunDue(now, runner{}))
+	assert.Equal(t, 1, len(calls))
+	assert.NoError(t, s.RunDue(now.Add(time.Minute), runner{}))
+	assert.Equal(t, 2, len(calls))
+	assert.Equal(t, 0, calls[1].Second())
+	assert.True(t, calls[1].After(now))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Run(ctx, runner{}))
+}
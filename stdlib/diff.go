@@ -0,0 +1,430 @@
+//go:build !tengo_no_diff
+// +build !tengo_no_diff
+
+package stdlib
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("diff", diffModule)
+}
+
+func diffModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"diff":  &objects.UserFunction{Name: "diff", Value: diffDiff},   // diff(a, b) => [op]
+		"patch": &objects.UserFunction{Name: "patch", Value: diffPatch}, // patch(v, ops) => any/error
+	}
+}
+
+func diffDiff(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	d := &differ{visiting: make(map[[2]objects.Object]bool)}
+	d.diff("", args[0], args[1])
+
+	return &objects.Array{Value: d.ops}, nil
+}
+
+func diffPatch(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var ops []objects.Object
+	switch o := args[1].(type) {
+	case *objects.Array:
+		ops = o.Value
+	case *objects.ImmutableArray:
+		ops = o.Value
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "array",
+			Found:    args[1].TypeName(),
+		}
+	}
+
+	// the operations are applied to a copy, so the value is not modified if
+	// an operation fails
+	root := args[0].Copy()
+	for i, op := range ops {
+		var err error
+		if root, err = diffApply(root, op); err != nil {
+			return wrapError(fmt.Errorf("operation %d: %s", i, err.Error())), nil
+		}
+	}
+
+	return root, nil
+}
+
+// differ collects the operations that change a value to another.
+type differ struct {
+	ops      []objects.Object
+	visiting map[[2]objects.Object]bool
+}
+
+func (d *differ) diff(path string, a, b objects.Object) {
+	if am, ok := toStringMap(a); ok {
+		if bm, ok := toStringMap(b); ok {
+			d.diffContainers(path, a, b, func() { d.diffMaps(path, am, bm) })
+			return
+		}
+	}
+
+	if aa, ok := toArray(a); ok {
+		if ba, ok := toArray(b); ok {
+			d.diffContainers(path, a, b, func() { d.diffArrays(path, aa, ba) })
+			return
+		}
+	}
+
+	if !objects.DeepEquals(a, b) {
+		d.add("replace", path, b)
+	}
+}
+
+// diffContainers compares the elements of the containers unless they are
+// already being compared: any difference is found by the outer comparison.
+func (d *differ) diffContainers(path string, a, b objects.Object, fn func()) {
+	pair := [2]objects.Object{a, b}
+	if d.visiting[pair] {
+		return
+	}
+	d.visiting[pair] = true
+	defer delete(d.visiting, pair)
+
+	fn()
+}
+
+func (d *differ) diffMaps(path string, a, b map[string]objects.Object) {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		av, inA := a[k]
+		bv, inB := b[k]
+		elemPath := path + "/" + diffEscape(k)
+		switch {
+		case !inB:
+			d.add("remove", elemPath, nil)
+		case !inA:
+			d.add("add", elemPath, bv)
+		default:
+			d.diff(elemPath, av, bv)
+		}
+	}
+}
+
+// diffArrays compares the elements at the same indexes, and, adds or
+// removes the elements at the end.
+func (d *differ) diffArrays(path string, a, b []objects.Object) {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+
+	for i := 0; i < n; i++ {
+		d.diff(path+"/"+strconv.Itoa(i), a[i], b[i])
+	}
+
+	for i := n; i < len(b); i++ {
+		d.add("add", path+"/"+strconv.Itoa(i), b[i])
+	}
+
+	// from the last element, so the indexes of the others don't change
+	for i := len(a) - 1; i >= n; i-- {
+		d.add("remove", path+"/"+strconv.Itoa(i), nil)
+	}
+}
+
+func (d *differ) add(op, path string, value objects.Object) {
+	m := map[string]objects.Object{
+		"op":   &objects.String{Value: op},
+		"path": &objects.String{Value: path},
+	}
+	if value != nil {
+		m["value"] = value.Copy()
+	}
+
+	d.ops = append(d.ops, &objects.Map{Value: m})
+}
+
+// diffApply applies the operation to the root, and, returns the new root.
+func diffApply(root objects.Object, o objects.Object) (objects.Object, error) {
+	op, ok := toStringMap(o)
+	if !ok {
+		return nil, fmt.Errorf("not a map: %s", o.TypeName())
+	}
+
+	name, err := diffOpString(op, "op")
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := diffOpPath(op, "path")
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "add", "replace", "test":
+		value, ok := op["value"]
+		if !ok {
+			return nil, fmt.Errorf("missing 'value'")
+		}
+
+		switch name {
+		case "add":
+			return diffAdd(root, path, value.Copy())
+		case "replace":
+			return diffReplace(root, path, value.Copy())
+		default:
+			v, err := diffGet(root, path)
+			if err != nil {
+				return nil, err
+			}
+			if !objects.DeepEquals(v, value) {
+				return nil, fmt.Errorf("test failed: %s", diffPointer(path))
+			}
+			return root, nil
+		}
+	case "remove":
+		return diffRemove(root, path)
+	case "move", "copy":
+		from, err := diffOpPath(op, "from")
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := diffGet(root, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "copy" {
+			return diffAdd(root, path, v.Copy())
+		}
+
+		if len(path) > len(from) && diffPointer(path[:len(from)]) == diffPointer(from) {
+			return nil, fmt.Errorf("cannot move into itself: %s", diffPointer(from))
+		}
+		if root, err = diffRemove(root, from); err != nil {
+			return nil, err
+		}
+		return diffAdd(root, path, v)
+	}
+
+	return nil, fmt.Errorf("unknown op '%s'", name)
+}
+
+// diffGet returns the value at the path.
+func diffGet(root objects.Object, path []string) (objects.Object, error) {
+	v := root
+	for i, token := range path {
+		switch c := v.(type) {
+		case *objects.Map:
+			elem, ok := c.Value[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %s", diffPointer(path[:i+1]))
+			}
+			v = elem
+		case *objects.Array:
+			idx, ok := diffIndex(token, len(c.Value)-1)
+			if !ok {
+				return nil, fmt.Errorf("path not found: %s", diffPointer(path[:i+1]))
+			}
+			v = c.Value[idx]
+		default:
+			return nil, fmt.Errorf("path not found: %s", diffPointer(path[:i+1]))
+		}
+	}
+
+	return v, nil
+}
+
+// diffAdd sets the key of a map, or, inserts the element to an array.
+func diffAdd(root objects.Object, path []string, value objects.Object) (objects.Object, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := diffGet(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+	switch c := parent.(type) {
+	case *objects.Map:
+		c.Value[token] = value
+	case *objects.Array:
+		idx, ok := len(c.Value), token == "-"
+		if !ok {
+			idx, ok = diffIndex(token, len(c.Value))
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid index: %s", diffPointer(path))
+		}
+
+		c.Value = append(c.Value, nil)
+		copy(c.Value[idx+1:], c.Value[idx:])
+		c.Value[idx] = value
+	default:
+		return nil, fmt.Errorf("path not found: %s", diffPointer(path))
+	}
+
+	return root, nil
+}
+
+// diffReplace sets the existing key of a map or element of an array.
+func diffReplace(root objects.Object, path []string, value objects.Object) (objects.Object, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := diffGet(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+	switch c := parent.(type) {
+	case *objects.Map:
+		if _, ok := c.Value[token]; !ok {
+			return nil, fmt.Errorf("path not found: %s", diffPointer(path))
+		}
+		c.Value[token] = value
+	case *objects.Array:
+		idx, ok := diffIndex(token, len(c.Value)-1)
+		if !ok {
+			return nil, fmt.Errorf("path not found: %s", diffPointer(path))
+		}
+		c.Value[idx] = value
+	default:
+		return nil, fmt.Errorf("path not found: %s", diffPointer(path))
+	}
+
+	return root, nil
+}
+
+func diffRemove(root objects.Object, path []string) (objects.Object, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the root")
+	}
+
+	parent, err := diffGet(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+	switch c := parent.(type) {
+	case *objects.Map:
+		if _, ok := c.Value[token]; !ok {
+			return nil, fmt.Errorf("path not found: %s", diffPointer(path))
+		}
+		delete(c.Value, token)
+	case *objects.Array:
+		idx, ok := diffIndex(token, len(c.Value)-1)
+		if !ok {
+			return nil, fmt.Errorf("path not found: %s", diffPointer(path))
+		}
+		c.Value = append(c.Value[:idx], c.Value[idx+1:]...)
+	default:
+		return nil, fmt.Errorf("path not found: %s", diffPointer(path))
+	}
+
+	return root, nil
+}
+
+// diffIndex returns the array index of the token if it's not greater than
+// max. The leading zeros are not allowed.
+func diffIndex(token string, max int) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx > max {
+		return 0, false
+	}
+
+	return idx, true
+}
+
+func diffOpString(op map[string]objects.Object, key string) (string, error) {
+	v, ok := op[key]
+	if !ok {
+		return "", fmt.Errorf("missing '%s'", key)
+	}
+
+	s, ok := v.(*objects.String)
+	if !ok {
+		return "", fmt.Errorf("invalid '%s': %s", key, v.TypeName())
+	}
+
+	return s.Value, nil
+}
+
+// diffOpPath returns the tokens of the JSON pointer of the operation.
+func diffOpPath(op map[string]objects.Object, key string) ([]string, error) {
+	s, err := diffOpString(op, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if s == "" {
+		return nil, nil
+	}
+
+	if s[0] != '/' {
+		return nil, fmt.Errorf("invalid '%s': %s", key, s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = diffUnescaper.Replace(token)
+	}
+
+	return tokens, nil
+}
+
+func diffPointer(path []string) string {
+	var sb strings.Builder
+	for _, token := range path {
+		sb.WriteString("/" + diffEscape(token))
+	}
+
+	return sb.String()
+}
+
+var (
+	diffEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	diffUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+func diffEscape(token string) string {
+	return diffEscaper.Replace(token)
+}
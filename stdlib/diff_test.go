@@ -0,0 +1,122 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestDiff(t *testing.T) {
+	module(t, "diff").call("diff", 1).expectError()
+
+	module(t, "diff").call("diff", 1, 1).expect(ARR{})
+	module(t, "diff").call("diff", MAP{"a": ARR{1, 2}}, IMAP{"a": IARR{1, 2}}).expect(ARR{})
+	module(t, "diff").call("diff", 1, 1.0).
+		expect(ARR{MAP{"op": "replace", "path": "", "value": 1.0}})
+	module(t, "diff").call("diff", MAP{"a": 1}, ARR{1}).
+		expect(ARR{MAP{"op": "replace", "path": "", "value": ARR{1}}})
+
+	module(t, "diff").call("diff",
+		MAP{"a": 1, "b": MAP{"c": "x", "d/e": 2}, "f": true},
+		MAP{"a": 2, "b": MAP{"c": "x", "d/e": 3, "g~": 4}, "h": ARR{}}).
+		expect(ARR{
+			MAP{"op": "replace", "path": "/a", "value": 2},
+			MAP{"op": "replace", "path": "/b/d~1e", "value": 3},
+			MAP{"op": "add", "path": "/b/g~0", "value": 4},
+			MAP{"op": "remove", "path": "/f"},
+			MAP{"op": "add", "path": "/h", "value": ARR{}},
+		})
+
+	module(t, "diff").call("diff", ARR{1, 2, 3, 4}, ARR{1, 5}).
+		expect(ARR{
+			MAP{"op": "replace", "path": "/1", "value": 5},
+			MAP{"op": "remove", "path": "/3"},
+			MAP{"op": "remove", "path": "/2"},
+		})
+	module(t, "diff").call("diff", ARR{1}, ARR{1, ARR{2}, 3}).
+		expect(ARR{
+			MAP{"op": "add", "path": "/1", "value": ARR{2}},
+			MAP{"op": "add", "path": "/2", "value": 3},
+		})
+
+	// cyclic values
+	a := &objects.Map{Value: map[string]objects.Object{"n": &objects.Int{Value: 1}}}
+	a.Value["self"] = a
+	b := &objects.Map{Value: map[string]objects.Object{"n": &objects.Int{Value: 2}}}
+	b.Value["self"] = b
+	module(t, "diff").call("diff", a, b).
+		expect(ARR{MAP{"op": "replace", "path": "/n", "value": 2}})
+}
+
+func TestDiffPatch(t *testing.T) {
+	module(t, "diff").call("patch", 1).expectError()
+	module(t, "diff").call("patch", 1, MAP{}).expectError()
+
+	doc := MAP{"a": 1, "b": ARR{1, 2}, "c": MAP{"d": "e"}}
+	module(t, "diff").call("patch", doc, ARR{}).expect(doc)
+	module(t, "diff").call("patch", doc, ARR{
+		MAP{"op": "add", "path": "/b/1", "value": 5},
+		MAP{"op": "add", "path": "/b/-", "value": 6},
+		MAP{"op": "add", "path": "/c/f~1g", "value": ARR{}},
+		MAP{"op": "replace", "path": "/a", "value": "x"},
+		MAP{"op": "remove", "path": "/b/0"},
+		MAP{"op": "test", "path": "/b", "value": IARR{5, 2, 6}},
+	}).expect(MAP{"a": "x", "b": ARR{5, 2, 6}, "c": MAP{"d": "e", "f/g": ARR{}}})
+
+	module(t, "diff").call("patch", doc, ARR{
+		MAP{"op": "move", "from": "/c/d", "path": "/b/0"},
+		MAP{"op": "copy", "from": "/b", "path": "/c/b"},
+		MAP{"op": "remove", "path": "/a"},
+	}).expect(MAP{"b": ARR{"e", 1, 2}, "c": MAP{"b": ARR{"e", 1, 2}}})
+
+	// the root
+	module(t, "diff").call("patch", doc, ARR{MAP{"op": "replace", "path": "", "value": 1}}).expect(1)
+	module(t, "diff").call("patch", 1, ARR{MAP{"op": "add", "path": "", "value": ARR{}}}).expect(ARR{})
+
+	// the immutable values are patched as the mutable copies
+	module(t, "diff").call("patch", IMAP{"a": IARR{1}}, IARR{IMAP{"op": "add", "path": "/a/0", "value": 0}}).
+		expect(MAP{"a": ARR{0, 1}})
+
+	patchError := func(ops ARR, msg string) {
+		module(t, "diff").call("patch", doc, ops).
+			expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	patchError(ARR{1}, "operation 0: not a map: int")
+	patchError(ARR{MAP{"path": "/a"}}, "operation 0: missing 'op'")
+	patchError(ARR{MAP{"op": "add", "path": "a", "value": 1}}, "operation 0: invalid 'path': a")
+	patchError(ARR{MAP{"op": "add", "path": "/a"}}, "operation 0: missing 'value'")
+	patchError(ARR{MAP{"op": "merge", "path": "/a"}}, "operation 0: unknown op 'merge'")
+	patchError(ARR{
+		MAP{"op": "remove", "path": "/a"},
+		MAP{"op": "remove", "path": "/a"},
+	}, "operation 1: path not found: /a")
+	patchError(ARR{MAP{"op": "replace", "path": "/x", "value": 1}}, "operation 0: path not found: /x")
+	patchError(ARR{MAP{"op": "add", "path": "/x/y", "value": 1}}, "operation 0: path not found: /x")
+	patchError(ARR{MAP{"op": "add", "path": "/b/3", "value": 1}}, "operation 0: invalid index: /b/3")
+	patchError(ARR{MAP{"op": "remove", "path": "/b/01"}}, "operation 0: path not found: /b/01")
+	patchError(ARR{MAP{"op": "remove", "path": "/b/-1"}}, "operation 0: path not found: /b/-1")
+	patchError(ARR{MAP{"op": "remove", "path": ""}}, "operation 0: cannot remove the root")
+	patchError(ARR{MAP{"op": "test", "path": "/a", "value": 1.0}}, "operation 0: test failed: /a")
+	patchError(ARR{MAP{"op": "move", "from": "/c", "path": "/c/x"}}, "operation 0: cannot move into itself: /c")
+	patchError(ARR{MAP{"op": "copy", "path": "/x"}}, "operation 0: missing 'from'")
+
+	// the value is not modified
+	v := object(MAP{"a": ARR{1}})
+	module(t, "diff").call("patch", v, ARR{MAP{"op": "add", "path": "/a/-", "value": 2}}).
+		expect(MAP{"a": ARR{1, 2}})
+	assert.Equal(t, object(MAP{"a": ARR{1}}), v)
+}
+
+func TestDiffRoundTrip(t *testing.T) {
+	for _, c := range []struct{ a, b interface{} }{
+		{MAP{}, MAP{"a": 1}},
+		{ARR{1, 2, 3}, ARR{3}},
+		{MAP{"a": ARR{1, MAP{"b": 2}}, "c": "d"}, MAP{"a": ARR{MAP{"b": 3}, 1, 2}, "e": 1.5}},
+		{ARR{MAP{"~/": 1}}, ARR{MAP{"~/": 2, "/~": 3}}},
+	} {
+		ops := module(t, "diff").call("diff", c.a, c.b)
+		assert.NoError(t, ops.e)
+		module(t, "diff").call("patch", c.a, ops.o).expect(c.b)
+	}
+}
@@ -9,3 +9,10 @@ func wrapError(err error) objects.Object {
 
 	return &objects.Error{Value: &objects.String{Value: err.Error()}}
 }
+
+// isLimitError returns true if err is one of the size limit errors of the
+// objects package. They are returned as the run-time errors, not the error
+// values.
+func isLimitError(err error) bool {
+	return err == objects.ErrStringLimit || err == objects.ErrBytesLimit || err == objects.ErrArrayLimit
+}
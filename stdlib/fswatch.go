@@ -0,0 +1,247 @@
+//go:build !tengo_no_fswatch && !tengo_embedded && !tinygo
+// +build !tengo_no_fswatch,!tengo_embedded,!tinygo
+
+package stdlib
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+// FSWatcher watches the files and the directories for the changes, and,
+// calls the callbacks that the scripts registered with fswatch.watch. It's
+// not registered by default: the host application enables fswatch module
+// with Register, and, runs the watcher after the script completes.
+//
+//	w := &stdlib.FSWatcher{}
+//	stdlib.Register("fswatch", w.Module)
+//	// compile and run the script
+//	err := w.Run(ctx, compiled)
+//
+// The callbacks are called one at a time in the virtual machine of the
+// script, so they can use the global variables of the script.
+type FSWatcher struct {
+	// Interval is the polling interval of Run. The default is 500ms.
+	Interval time.Duration
+
+	lock    sync.Mutex
+	watches []*fswatchWatch
+}
+
+// fswatchWatch is a path registered by the script with the callback and the
+// state of the files seen last time.
+type fswatchWatch struct {
+	path      string
+	recursive bool
+	fn        objects.Object
+	files     map[string]fswatchFile
+}
+
+type fswatchFile struct {
+	modTime time.Time
+	size    int64
+	dir     bool
+}
+
+// Module returns the members of fswatch module.
+func (w *FSWatcher) Module() map[string]objects.Object {
+	return map[string]objects.Object{
+		"watch": &objects.UserFunction{Name: "watch", Value: w.watch}, // watch(path, fn(event), recursive) => undefined/error
+	}
+}
+
+// Run polls the watched paths until the context is done, and, calls the
+// callbacks with the changes. It returns the error of the context, or, the
+// error of a callback.
+func (w *FSWatcher) Run(ctx context.Context, runner FunctionRunner) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := w.Poll(runner); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Poll checks the watched paths once, and, calls the callbacks with the
+// changes since the previous check. It's not safe for concurrent use, but,
+// the scripts can register the paths while it's running.
+func (w *FSWatcher) Poll(runner FunctionRunner) error {
+	w.lock.Lock()
+	watches := append([]*fswatchWatch(nil), w.watches...)
+	w.lock.Unlock()
+
+	for _, watch := range watches {
+		files := fswatchScan(watch.path, watch.recursive)
+		events := fswatchDiff(watch.files, files)
+		watch.files = files
+
+		for _, event := range events {
+			if _, err := runner.RunFunction(watch.fn, event); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *FSWatcher) watch(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	s, ok := args[0].(*objects.String)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	switch args[1].(type) {
+	case *objects.CompiledFunction, *objects.Closure, objects.Callable, objects.InteropCallable:
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "callable",
+			Found:    args[1].TypeName(),
+		}
+	}
+
+	recursive := len(args) == 3 && !args[2].IsFalsy()
+
+	path, err := filepath.Abs(s.Value)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	// the files are scanned when the path is registered, so only the changes
+	// after that are reported
+	watch := &fswatchWatch{
+		path:      path,
+		recursive: recursive,
+		fn:        args[1],
+		files:     fswatchScan(path, recursive),
+	}
+
+	w.lock.Lock()
+	w.watches = append(w.watches, watch)
+	w.lock.Unlock()
+
+	return objects.UndefinedValue, nil
+}
+
+// fswatchScan returns the state of the path and the entries of the
+// directory (and its subdirectories if recursive). It's empty if the path
+// does not exist.
+func fswatchScan(path string, recursive bool) map[string]fswatchFile {
+	files := make(map[string]fswatchFile)
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return files
+	}
+	files[path] = fswatchFileOf(fi)
+
+	if !fi.IsDir() {
+		return files
+	}
+
+	if !recursive {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return files
+		}
+
+		for _, entry := range entries {
+			if fi, err := entry.Info(); err == nil {
+				files[filepath.Join(path, entry.Name())] = fswatchFileOf(fi)
+			}
+		}
+
+		return files
+	}
+
+	// the entries that can't be read (e.g. removed while scanning) are
+	// skipped
+	_ = filepath.WalkDir(path, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if fi, err := entry.Info(); err == nil {
+			files[p] = fswatchFileOf(fi)
+		}
+
+		return nil
+	})
+
+	return files
+}
+
+func fswatchFileOf(fi os.FileInfo) fswatchFile {
+	return fswatchFile{modTime: fi.ModTime(), size: fi.Size(), dir: fi.IsDir()}
+}
+
+// fswatchDiff returns the events of the changes from the previous state to
+// the current state sorted by the path. The directories are not written: the
+// changes of their entries are reported instead.
+func fswatchDiff(prev, cur map[string]fswatchFile) []objects.Object {
+	paths := make([]string, 0, len(prev)+len(cur))
+	for p := range prev {
+		paths = append(paths, p)
+	}
+	for p := range cur {
+		if _, ok := prev[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var events []objects.Object
+	for _, p := range paths {
+		o, inPrev := prev[p]
+		n, inCur := cur[p]
+
+		var op string
+		switch {
+		case !inCur:
+			op = "remove"
+			n = o
+		case !inPrev, o.dir != n.dir:
+			op = "create"
+		case !n.dir && (!o.modTime.Equal(n.modTime) || o.size != n.size):
+			op = "write"
+		default:
+			continue
+		}
+
+		events = append(events, &objects.ImmutableMap{Value: map[string]objects.Object{
+			"path": &objects.String{Value: p},
+			"op":   &objects.String{Value: op},
+			"dir":  fromBool(n.dir),
+		}})
+	}
+
+	return events
+}
@@ -0,0 +1,106 @@
+//go:build !tengo_no_fswatch && !tengo_embedded && !tinygo
+// +build !tengo_no_fswatch,!tengo_embedded,!tinygo
+
+package stdlib_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/script"
+	"github.com/d5/tengo/stdlib"
+)
+
+func TestFSWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tengo-fswatch")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	a := filepath.Join(dir, "a.txt")
+	assert.NoError(t, ioutil.WriteFile(a, []byte("a"), 0644))
+
+	var events []string
+	callback := &objects.UserFunction{Value: func(args ...objects.Object) (objects.Object, error) {
+		m := args[0].(*objects.ImmutableMap).Value
+		path, _ := objects.ToString(m["path"])
+		op, _ := objects.ToString(m["op"])
+		events = append(events, op+" "+filepath.Base(path))
+		return objects.UndefinedValue, nil
+	}}
+
+	w := &stdlib.FSWatcher{}
+	watch := callres{t: t, o: &objects.ImmutableMap{Value: w.Module()}}
+	watch.call("watch", dir, callback).expect(objects.UndefinedValue)
+	watch.call("watch", 1, callback).expectError()
+	watch.call("watch", dir, 1).expectError()
+	watch.call("watch", dir).expectError()
+
+	// no changes
+	assert.NoError(t, w.Poll(runner{}))
+	assert.Equal(t, 0, len(events))
+
+	b := filepath.Join(dir, "b.txt")
+	assert.NoError(t, ioutil.WriteFile(b, []byte("b"), 0644))
+	assert.NoError(t, ioutil.WriteFile(a, []byte("aa"), 0644))
+	assert.NoError(t, w.Poll(runner{}))
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "write a.txt", events[0])
+	assert.Equal(t, "create b.txt", events[1])
+
+	events = nil
+	assert.NoError(t, os.Remove(a))
+	assert.NoError(t, w.Poll(runner{}))
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "remove a.txt", events[0])
+
+	// the files of the subdirectories are watched only if recursive
+	events = nil
+	sub := filepath.Join(dir, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0755))
+	assert.NoError(t, w.Poll(runner{}))
+	watch.call("watch", dir, callback, true).expect(objects.UndefinedValue)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(sub, "c.txt"), []byte("c"), 0644))
+	assert.NoError(t, w.Poll(runner{}))
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "create sub", events[0])
+	assert.Equal(t, "create c.txt", events[1])
+}
+
+func TestFSWatcher_Script(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tengo-fswatch")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	w := &stdlib.FSWatcher{Interval: 10 * time.Millisecond}
+	stdlib.Register("fswatch", w.Module)
+	defer delete(stdlib.Modules, "fswatch")
+
+	s := script.New([]byte(`
+fswatch := import("fswatch")
+changes := []
+fswatch.watch(dir, func(event) {
+	changes = append(changes, event.op)
+	if len(changes) > 1 { return 1 + "a" }
+})
+`))
+	assert.NoError(t, s.Add("dir", dir))
+	c, err := s.Run()
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, w.Run(ctx, c))
+	assert.Equal(t, "[\"create\"]", c.Get("changes").String())
+
+	// the error of the callback stops the watcher
+	assert.NoError(t, os.Remove(filepath.Join(dir, "a.txt")))
+	assert.Error(t, w.Run(context.Background(), c))
+	assert.Equal(t, "[\"create\", \"remove\"]", c.Get("changes").String())
+}
@@ -0,0 +1,369 @@
+//go:build !tengo_no_graphql && !tengo_embedded && !tinygo
+// +build !tengo_no_graphql,!tengo_embedded,!tinygo
+
+package stdlib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("graphql", graphqlModule)
+}
+
+func graphqlModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"build":  &objects.UserFunction{Name: "build", Value: graphqlBuild},   // build(operation, fields, variables) => string/error
+		"client": &objects.UserFunction{Name: "client", Value: graphqlClient}, // client(url, options) => client
+	}
+}
+
+// graphqlOperations are the operation types of the documents that build
+// makes.
+var graphqlOperations = map[string]bool{"query": true, "mutation": true, "subscription": true}
+
+func graphqlBuild(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	operation, ok := objects.ToString(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	fields, ok := toArray(args[1])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "array",
+			Found:    args[1].TypeName(),
+		}
+	}
+
+	var variables map[string]objects.Object
+	if len(args) == 3 {
+		if variables, ok = toStringMap(args[2]); !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "third",
+				Expected: "map",
+				Found:    args[2].TypeName(),
+			}
+		}
+	}
+
+	operation = strings.TrimSpace(operation)
+	if !graphqlOperations[strings.SplitN(operation, " ", 2)[0]] {
+		return wrapError(fmt.Errorf("invalid operation '%s'", operation)), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(operation)
+
+	if len(variables) > 0 {
+		// the names are sorted without "$" prefix
+		names := make([]string, 0, len(variables))
+		for name := range variables {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return strings.TrimPrefix(names[i], "$") < strings.TrimPrefix(names[j], "$")
+		})
+
+		sb.WriteByte('(')
+		for i, name := range names {
+			typ, ok := variables[name].(*objects.String)
+			if !ok {
+				return wrapError(fmt.Errorf("invalid type of variable '%s': %s", name, variables[name])), nil
+			}
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("$" + strings.TrimPrefix(name, "$") + ": " + typ.Value)
+		}
+		sb.WriteByte(')')
+	}
+
+	if err := graphqlWriteFields(&sb, fields); err != nil {
+		return wrapError(err), nil
+	}
+	if sb.Len() > objects.MaxStringLen {
+		return nil, objects.ErrStringLimit
+	}
+
+	return &objects.String{Value: sb.String()}, nil
+}
+
+// graphqlWriteFields writes the selection set of the fields. A field is a
+// string (e.g. "name" or "user(id: $id)"), and, an array after a string is
+// the selection set of the field. A map maps the fields to their selection
+// sets, and, its fields are written in the order of the keys.
+func graphqlWriteFields(sb *strings.Builder, fields []objects.Object) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	sb.WriteString(" {")
+	for i, f := range fields {
+		switch f := f.(type) {
+		case *objects.String:
+			sb.WriteString(" " + f.Value)
+		case *objects.Array, *objects.ImmutableArray:
+			if i == 0 {
+				return fmt.Errorf("selection set without field: %s", f)
+			}
+			if _, ok := fields[i-1].(*objects.String); !ok {
+				return fmt.Errorf("selection set without field: %s", f)
+			}
+			sub, _ := toArray(f)
+			if err := graphqlWriteFields(sb, sub); err != nil {
+				return err
+			}
+		case *objects.Map, *objects.ImmutableMap:
+			m, _ := toStringMap(f)
+			names := make([]string, 0, len(m))
+			for name := range m {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				sub, ok := toArray(m[name])
+				if !ok {
+					return fmt.Errorf("invalid selection set of '%s': %s", name, m[name])
+				}
+				sb.WriteString(" " + name)
+				if err := graphqlWriteFields(sb, sub); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("invalid field: %s", f)
+		}
+	}
+	sb.WriteString(" }")
+
+	return nil
+}
+
+// graphqlClientOptions are the options of graphql.client.
+type graphqlClientOptions struct {
+	headers map[string]string
+	timeout time.Duration
+}
+
+func graphqlClient(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	url, ok := objects.ToString(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	opts := &graphqlClientOptions{timeout: 30 * time.Second}
+	if len(args) == 2 {
+		if err := opts.parse(args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient := &http.Client{Transport: graphqlTransport{}}
+
+	return &objects.ImmutableMap{
+		Value: map[string]objects.Object{
+			// execute(query, variables) => response/error
+			"execute": &objects.InteropFunction{
+				Name: "execute",
+				Value: func(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+					if len(args) != 1 && len(args) != 2 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					query, ok := args[0].(*objects.String)
+					if !ok {
+						return nil, objects.ErrInvalidArgumentType{
+							Name:     "first",
+							Expected: "string",
+							Found:    args[0].TypeName(),
+						}
+					}
+
+					req := map[string]objects.Object{"query": query}
+					if len(args) == 2 && args[1] != objects.UndefinedValue {
+						if _, ok := toStringMap(args[1]); !ok {
+							return nil, objects.ErrInvalidArgumentType{
+								Name:     "second",
+								Expected: "map",
+								Found:    args[1].TypeName(),
+							}
+						}
+						req["variables"] = args[1]
+					}
+
+					body, err := toJSON(&objects.Map{Value: req})
+					if err != nil {
+						return nil, err
+					}
+					if _, isErr := body.(*objects.Error); isErr {
+						return body, nil
+					}
+
+					var aborted <-chan struct{}
+					if aborter, ok := rt.(objects.InteropAborter); ok {
+						aborted = aborter.Aborted()
+					}
+
+					return graphqlPost(httpClient, url, opts, body.(*objects.Bytes).Value, aborted)
+				},
+			},
+		},
+	}, nil
+}
+
+// parse reads the options from the map.
+func (opts *graphqlClientOptions) parse(o objects.Object) error {
+	m, ok := toStringMap(o)
+	if !ok {
+		return objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "map",
+			Found:    o.TypeName(),
+		}
+	}
+
+	for k, v := range m {
+		switch k {
+		case "headers":
+			headers, ok := toStringMap(v)
+			if !ok {
+				return fmt.Errorf("invalid graphql option '%s': %s", k, v)
+			}
+			opts.headers = make(map[string]string, len(headers))
+			for name, value := range headers {
+				s, ok := value.(*objects.String)
+				if !ok {
+					return fmt.Errorf("invalid graphql option '%s': %s", k, v)
+				}
+				opts.headers[name] = s.Value
+			}
+		case "timeout":
+			i, ok := v.(*objects.Int)
+			if !ok || i.Value <= 0 {
+				return fmt.Errorf("invalid graphql option '%s': %s", k, v)
+			}
+			opts.timeout = time.Duration(i.Value)
+		default:
+			return fmt.Errorf("unknown graphql option '%s'", k)
+		}
+	}
+
+	return nil
+}
+
+// graphqlPost sends the request and returns the decoded response. The
+// responses with the other status codes than 2xx are accepted if they have
+// data or errors, as GraphQL servers may report the errors of the request
+// with 4xx status codes.
+func graphqlPost(client *http.Client, url string, opts *graphqlClientOptions, body []byte, aborted <-chan struct{}) (objects.Object, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	// the request is canceled if the execution is aborted
+	go func() {
+		select {
+		case <-aborted:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return wrapError(err), nil
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	for name, value := range opts.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return wrapError(err), nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(objects.MaxBytesLen)+1))
+	if err != nil {
+		return wrapError(err), nil
+	}
+	if len(data) > objects.MaxBytesLen {
+		return wrapError(objects.ErrBytesLimit), nil
+	}
+
+	res, err := fromJSON(&objects.Bytes{Value: data})
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := res.(*objects.Map); ok && (m.Value["data"] != nil || m.Value["errors"] != nil) {
+		return m, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return wrapError(fmt.Errorf("unexpected status: %s", resp.Status)), nil
+	}
+	return wrapError(fmt.Errorf("invalid response: %s", graphqlSnippet(data))), nil
+}
+
+// graphqlTransport sends the requests with http.DefaultTransport after
+// checking their addresses with CheckNetwork, so the redirects are checked
+// too.
+type graphqlTransport struct{}
+
+func (graphqlTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	address := req.URL.Host
+	if req.URL.Port() == "" {
+		port := "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+		address = net.JoinHostPort(req.URL.Hostname(), port)
+	}
+	if err := checkNetwork(address); err != nil {
+		return nil, err
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// graphqlSnippet returns the beginning of the response body for the error
+// messages.
+func graphqlSnippet(data []byte) string {
+	const max = 100
+	if len(data) > max {
+		return string(data[:max]) + "..."
+	}
+	return string(data)
+}
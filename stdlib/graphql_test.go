@@ -0,0 +1,158 @@
+//go:build !tengo_no_graphql && !tengo_embedded && !tinygo
+// +build !tengo_no_graphql,!tengo_embedded,!tinygo
+
+package stdlib_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/stdlib"
+)
+
+func TestGraphQLBuild(t *testing.T) {
+	module(t, "graphql").call("build", "query").expectError()
+	module(t, "graphql").call("build", "query", "a").expectError()
+	module(t, "graphql").call("build", "query", ARR{}, ARR{}).expectError()
+
+	module(t, "graphql").call("build", "query", ARR{"a"}).expect("query { a }")
+	module(t, "graphql").call("build", " mutation ", ARR{}).expect("mutation")
+	module(t, "graphql").call("build", "query GetUser",
+		ARR{"viewer", "user(id: $id)", ARR{"name", "posts(first: $n)", IARR{"title"}, IMAP{"b": ARR{"c"}, "a": ARR{}}}},
+		MAP{"id": "ID!", "$n": "Int = 10"}).
+		expect("query GetUser($id: ID!, $n: Int = 10) { viewer user(id: $id) { name posts(first: $n) { title } a b { c } } }")
+
+	buildError := func(operation string, fields ARR, variables MAP, msg string) {
+		module(t, "graphql").call("build", operation, fields, variables).
+			expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	buildError("fetch", ARR{"a"}, MAP{}, "invalid operation 'fetch'")
+	buildError("query", ARR{1}, MAP{}, "invalid field: 1")
+	buildError("query", ARR{MAP{"a": "b"}}, MAP{}, "invalid selection set of 'a': \"b\"")
+	buildError("query", ARR{ARR{"a"}}, MAP{}, "selection set without field: [\"a\"]")
+	buildError("query", ARR{"a", ARR{}, ARR{"b"}}, MAP{}, "selection set without field: [\"b\"]")
+	buildError("query", ARR{"a"}, MAP{"id": 1}, "invalid type of variable 'id': 1")
+}
+
+func TestGraphQLClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" ||
+			json.NewDecoder(r.Body).Decode(&req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch req.Query {
+		case "{ invalid }":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors": [{"message": "invalid query"}]}`))
+		case "{ down }":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`<html>bad gateway</html>`))
+		case "{ text }":
+			_, _ = w.Write([]byte(strings.Repeat("a", 200)))
+		case "{ slow }":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"query":     req.Query,
+					"variables": req.Variables,
+					"token":     r.Header.Get("Authorization"),
+				},
+			})
+		}
+	}))
+	defer server.Close()
+
+	module(t, "graphql").call("client").expectError()
+	module(t, "graphql").call("client", server.URL, 1).expectError()
+	module(t, "graphql").call("client", server.URL, MAP{"retries": 1}).expectError()
+	module(t, "graphql").call("client", server.URL, MAP{"timeout": 0}).expectError()
+	module(t, "graphql").call("client", server.URL, MAP{"headers": MAP{"a": 1}}).expectError()
+
+	c := module(t, "graphql").call("client", server.URL, MAP{"headers": MAP{"Authorization": "Bearer x"}})
+	assert.NoError(t, c.e)
+
+	c.call("execute").expectError()
+	c.call("execute", 1).expectError()
+	c.call("execute", "{ a }", 1).expectError()
+
+	c.call("execute", "{ a }").
+		expect(MAP{"data": MAP{"query": "{ a }", "variables": objects.UndefinedValue, "token": "Bearer x"}})
+	c.call("execute", "query($id: ID!) { a(id: $id) }", MAP{"id": "1", "n": 2, "tags": ARR{"x"}}).
+		expect(MAP{"data": MAP{
+			"query":     "query($id: ID!) { a(id: $id) }",
+			"variables": MAP{"id": "1", "n": 2.0, "tags": ARR{"x"}},
+			"token":     "Bearer x",
+		}})
+	c.call("execute", "{ invalid }").
+		expect(MAP{"errors": ARR{MAP{"message": "invalid query"}}})
+
+	executeError := func(query, msg string) {
+		c.call("execute", query).expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	executeError("{ down }", "unexpected status: 502 Bad Gateway")
+	executeError("{ text }", "invalid response: "+strings.Repeat("a", 100)+"...")
+
+	// the response is larger than the bytes limit
+	defer func(n int) { objects.MaxBytesLen = n }(objects.MaxBytesLen)
+	objects.MaxBytesLen = 100
+	executeError("{ text }", objects.ErrBytesLimit.Error())
+	objects.MaxBytesLen = 200
+	executeError("{ text }", "invalid response: "+strings.Repeat("a", 100)+"...")
+
+	// the address is checked by the host application
+	var addresses []string
+	stdlib.CheckNetwork = func(address string) error {
+		addresses = append(addresses, address)
+		return errors.New("not allowed")
+	}
+	res := c.call("execute", "{ a }")
+	stdlib.CheckNetwork = nil
+	assert.NoError(t, res.e)
+	assert.Equal(t, 1, len(addresses))
+	assert.Equal(t, server.Listener.Addr().String(), addresses[0])
+	assert.True(t, strings.HasSuffix(res.o.(*objects.Error).Value.(*objects.String).Value, "not allowed"))
+
+	// the cycles of the variables
+	m := &objects.Map{Value: map[string]objects.Object{}}
+	m.Value["m"] = m
+	res = c.call("execute", "{ a }", m)
+	assert.NoError(t, res.e)
+	_, isErr := res.o.(*objects.Error)
+	assert.True(t, isErr)
+
+	// the timeout
+	c = module(t, "graphql").call("client", server.URL, MAP{"timeout": int64(10 * time.Millisecond)})
+	res = c.call("execute", "{ slow }")
+	assert.NoError(t, res.e)
+	_, isErr = res.o.(*objects.Error)
+	assert.True(t, isErr)
+
+	// the execution is aborted
+	c = module(t, "graphql").call("client", server.URL)
+	rt := abortInterop{aborted: make(chan struct{})}
+	close(rt.aborted)
+	execute := c.o.(*objects.ImmutableMap).Value["execute"].(*objects.InteropFunction)
+	start := time.Now()
+	ret, err := execute.Value(rt, &objects.String{Value: "{ slow }"})
+	assert.NoError(t, err)
+	_, isErr = ret.(*objects.Error)
+	assert.True(t, isErr)
+	assert.True(t, time.Since(start) < time.Second)
+}
@@ -0,0 +1,380 @@
+//go:build !tengo_no_ip
+// +build !tengo_no_ip
+
+package stdlib
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("ip", ipModule)
+}
+
+func ipModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"parse":      &objects.UserFunction{Name: "parse", Value: ipParse},          // parse(s) => {address:,version:,...}/error
+		"normalize":  &objects.UserFunction{Name: "normalize", Value: ipNormalize},  // normalize(s) => string/error
+		"is_valid":   &objects.UserFunction{Name: "is_valid", Value: ipIsValid},     // is_valid(s) => bool
+		"compare":    &objects.UserFunction{Name: "compare", Value: ipCompare},      // compare(a, b) => int/error
+		"parse_cidr": &objects.UserFunction{Name: "parse_cidr", Value: ipParseCIDR}, // parse_cidr(s) => {network:,address:,prefix:,...}/error
+		"contains":   &objects.UserFunction{Name: "contains", Value: ipContains},    // contains(cidr, ip) => bool/error
+		"range":      &objects.UserFunction{Name: "range", Value: ipRangeFunc},      // range(cidr)/range(first, last) => ip-range/error
+	}
+}
+
+// ipParse returns the information of an IP address.
+func ipParse(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	addr, err := ipArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+	if !addr.IsValid() {
+		return ipInvalid(args[0]), nil
+	}
+
+	return &objects.ImmutableMap{Value: map[string]objects.Object{
+		"address":     &objects.String{Value: addr.String()},
+		"version":     &objects.Int{Value: ipVersion(addr)},
+		"loopback":    fromBool(addr.IsLoopback()),
+		"private":     fromBool(addr.IsPrivate()),
+		"multicast":   fromBool(addr.IsMulticast()),
+		"link_local":  fromBool(addr.IsLinkLocalUnicast()),
+		"unspecified": fromBool(addr.IsUnspecified()),
+	}}, nil
+}
+
+func ipNormalize(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	addr, err := ipArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+	if !addr.IsValid() {
+		return ipInvalid(args[0]), nil
+	}
+
+	return &objects.String{Value: addr.String()}, nil
+}
+
+func ipIsValid(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	addr, err := ipArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	return fromBool(addr.IsValid()), nil
+}
+
+func ipCompare(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	a, err := ipArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+	if !a.IsValid() {
+		return ipInvalid(args[0]), nil
+	}
+
+	b, err := ipArg(args[1], "second")
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsValid() {
+		return ipInvalid(args[1]), nil
+	}
+
+	return &objects.Int{Value: int64(a.Compare(b))}, nil
+}
+
+// ipParseCIDR returns the information of a CIDR notation.
+func ipParseCIDR(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	prefix, err := ipPrefixArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+	if !prefix.IsValid() {
+		return ipInvalidCIDR(args[0]), nil
+	}
+
+	network := prefix.Masked()
+
+	return &objects.ImmutableMap{Value: map[string]objects.Object{
+		"network": &objects.String{Value: network.String()},
+		"address": &objects.String{Value: prefix.Addr().String()},
+		"prefix":  &objects.Int{Value: int64(prefix.Bits())},
+		"version": &objects.Int{Value: ipVersion(prefix.Addr())},
+		"first":   &objects.String{Value: network.Addr().String()},
+		"last":    &objects.String{Value: ipLast(network).String()},
+	}}, nil
+}
+
+func ipContains(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	prefix, err := ipPrefixArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+	if !prefix.IsValid() {
+		return ipInvalidCIDR(args[0]), nil
+	}
+
+	addr, err := ipArg(args[1], "second")
+	if err != nil {
+		return nil, err
+	}
+	if !addr.IsValid() {
+		return ipInvalid(args[1]), nil
+	}
+
+	return fromBool(prefix.Contains(addr)), nil
+}
+
+func ipRangeFunc(args ...objects.Object) (objects.Object, error) {
+	switch len(args) {
+	case 1:
+		prefix, err := ipPrefixArg(args[0], "first")
+		if err != nil {
+			return nil, err
+		}
+		if !prefix.IsValid() {
+			return ipInvalidCIDR(args[0]), nil
+		}
+
+		network := prefix.Masked()
+
+		return &ipRange{first: network.Addr(), last: ipLast(network)}, nil
+	case 2:
+		first, err := ipArg(args[0], "first")
+		if err != nil {
+			return nil, err
+		}
+		if !first.IsValid() {
+			return ipInvalid(args[0]), nil
+		}
+
+		last, err := ipArg(args[1], "second")
+		if err != nil {
+			return nil, err
+		}
+		if !last.IsValid() {
+			return ipInvalid(args[1]), nil
+		}
+
+		if first.BitLen() != last.BitLen() || first.Compare(last) > 0 {
+			return wrapError(fmt.Errorf("invalid IP range: %s-%s", first, last)), nil
+		}
+
+		return &ipRange{first: first, last: last}, nil
+	}
+
+	return nil, objects.ErrWrongNumArguments
+}
+
+// ipArg parses the string argument. The IPv4-mapped IPv6 addresses (e.g.
+// "::ffff:10.0.0.1") are converted to the IPv4 addresses. The address is
+// invalid if the string is not an IP address.
+func ipArg(o objects.Object, name string) (netip.Addr, error) {
+	s, ok := o.(*objects.String)
+	if !ok {
+		return netip.Addr{}, objects.ErrInvalidArgumentType{
+			Name:     name,
+			Expected: "string",
+			Found:    o.TypeName(),
+		}
+	}
+
+	addr, err := netip.ParseAddr(s.Value)
+	if err != nil {
+		return netip.Addr{}, nil
+	}
+
+	return addr.Unmap(), nil
+}
+
+// ipPrefixArg parses the string argument in CIDR notation. The prefix is
+// invalid if the string is not a CIDR notation.
+func ipPrefixArg(o objects.Object, name string) (netip.Prefix, error) {
+	s, ok := o.(*objects.String)
+	if !ok {
+		return netip.Prefix{}, objects.ErrInvalidArgumentType{
+			Name:     name,
+			Expected: "string",
+			Found:    o.TypeName(),
+		}
+	}
+
+	prefix, err := netip.ParsePrefix(s.Value)
+	if err != nil {
+		return netip.Prefix{}, nil
+	}
+
+	return prefix, nil
+}
+
+func ipInvalid(o objects.Object) objects.Object {
+	return wrapError(fmt.Errorf("invalid IP address: %s", o.(*objects.String).Value))
+}
+
+func ipInvalidCIDR(o objects.Object) objects.Object {
+	return wrapError(fmt.Errorf("invalid CIDR address: %s", o.(*objects.String).Value))
+}
+
+func ipVersion(addr netip.Addr) int64 {
+	if addr.Is4() {
+		return 4
+	}
+
+	return 6
+}
+
+// ipLast returns the last address of the network: the host bits are set.
+func ipLast(network netip.Prefix) netip.Addr {
+	b := network.Addr().AsSlice()
+	for i := network.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << uint(7-i%8)
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+
+	return addr
+}
+
+// ipRange is an iterable object of the IP addresses between the first and
+// the last (inclusive) addresses. The addresses are produced while it's
+// iterated, so a large range does not use memory.
+type ipRange struct {
+	first netip.Addr
+	last  netip.Addr
+}
+
+// TypeName returns the name of the type.
+func (o *ipRange) TypeName() string {
+	return "ip-range"
+}
+
+func (o *ipRange) String() string {
+	return fmt.Sprintf("<ip-range %s-%s>", o.first, o.last)
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (o *ipRange) BinaryOp(op token.Token, rhs objects.Object) (objects.Object, error) {
+	return nil, objects.ErrInvalidOperator
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (o *ipRange) IsFalsy() bool {
+	return false
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (o *ipRange) Equals(x objects.Object) bool {
+	t, ok := x.(*ipRange)
+
+	return ok && t.first == o.first && t.last == o.last
+}
+
+// Copy returns a copy of the type.
+func (o *ipRange) Copy() objects.Object {
+	return &ipRange{first: o.first, last: o.last}
+}
+
+// Iterate creates an iterator of the addresses.
+func (o *ipRange) Iterate() objects.Iterator {
+	return &ipRangeIterator{next: o.first, last: o.last, idx: -1}
+}
+
+type ipRangeIterator struct {
+	next netip.Addr
+	last netip.Addr
+	cur  netip.Addr
+	idx  int64
+	done bool
+}
+
+// TypeName returns the name of the type.
+func (i *ipRangeIterator) TypeName() string {
+	return "ip-range-iterator"
+}
+
+func (i *ipRangeIterator) String() string {
+	return "<ip-range-iterator>"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (i *ipRangeIterator) BinaryOp(op token.Token, rhs objects.Object) (objects.Object, error) {
+	return nil, objects.ErrInvalidOperator
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (i *ipRangeIterator) IsFalsy() bool {
+	return true
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (i *ipRangeIterator) Equals(objects.Object) bool {
+	return false
+}
+
+// Copy returns a copy of the type.
+func (i *ipRangeIterator) Copy() objects.Object {
+	c := *i
+
+	return &c
+}
+
+// Next returns true if there are more addresses to iterate.
+func (i *ipRangeIterator) Next() bool {
+	if i.done {
+		return false
+	}
+
+	i.cur = i.next
+	i.idx++
+	if i.cur == i.last {
+		i.done = true
+	} else {
+		i.next = i.cur.Next()
+	}
+
+	return true
+}
+
+// Key returns the index of the current address.
+func (i *ipRangeIterator) Key() objects.Object {
+	return &objects.Int{Value: i.idx}
+}
+
+// Value returns the current address.
+func (i *ipRangeIterator) Value() objects.Object {
+	return &objects.String{Value: i.cur.String()}
+}
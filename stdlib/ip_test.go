@@ -0,0 +1,76 @@
+package stdlib_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestIP(t *testing.T) {
+	invalid := func(msg string) *objects.Error {
+		return &objects.Error{Value: &objects.String{Value: msg}}
+	}
+
+	module(t, "ip").call("parse", "10.0.0.1").expect(IMAP{
+		"address": "10.0.0.1", "version": 4, "loopback": false, "private": true,
+		"multicast": false, "link_local": false, "unspecified": false,
+	})
+	module(t, "ip").call("parse", "::1").expect(IMAP{
+		"address": "::1", "version": 6, "loopback": true, "private": false,
+		"multicast": false, "link_local": false, "unspecified": false,
+	})
+	module(t, "ip").call("parse", "10.0.0.256").expect(invalid("invalid IP address: 10.0.0.256"))
+	module(t, "ip").call("parse", 1).expectError()
+
+	module(t, "ip").call("normalize", "2001:0DB8:0000::0001").expect("2001:db8::1")
+	module(t, "ip").call("normalize", "::ffff:10.0.0.1").expect("10.0.0.1")
+	module(t, "ip").call("normalize", "x").expect(invalid("invalid IP address: x"))
+	module(t, "ip").call("is_valid", "fe80::1").expect(true)
+	module(t, "ip").call("is_valid", "10.0.0").expect(false)
+
+	module(t, "ip").call("compare", "10.0.0.2", "10.0.0.10").expect(-1)
+	module(t, "ip").call("compare", "::1", "::1").expect(0)
+	module(t, "ip").call("compare", "::1", "10.0.0.1").expect(1)
+
+	module(t, "ip").call("parse_cidr", "10.1.2.3/8").expect(IMAP{
+		"network": "10.0.0.0/8", "address": "10.1.2.3", "prefix": 8, "version": 4,
+		"first": "10.0.0.0", "last": "10.255.255.255",
+	})
+	module(t, "ip").call("parse_cidr", "2001:db8::/126").expect(IMAP{
+		"network": "2001:db8::/126", "address": "2001:db8::", "prefix": 126, "version": 6,
+		"first": "2001:db8::", "last": "2001:db8::3",
+	})
+	module(t, "ip").call("parse_cidr", "10.0.0.0/33").expect(invalid("invalid CIDR address: 10.0.0.0/33"))
+
+	module(t, "ip").call("contains", "10.0.0.0/8", "10.20.30.40").expect(true)
+	module(t, "ip").call("contains", "10.0.0.0/8", "11.0.0.1").expect(false)
+	module(t, "ip").call("contains", "10.0.0.0/8", "::ffff:10.0.0.1").expect(true)
+	module(t, "ip").call("contains", "10.0.0.0/8", "::1").expect(false)
+	module(t, "ip").call("contains", "10.0.0.0", "10.0.0.1").expect(invalid("invalid CIDR address: 10.0.0.0"))
+
+	expectRange := func(res callres, expected ...string) {
+		if !assert.NoError(t, res.e) {
+			return
+		}
+
+		var addrs []string
+		for i := 0; i < 2; i++ { // the range can be iterated again
+			addrs = nil
+			it := res.o.(objects.Iterable).Iterate()
+			for it.Next() {
+				assert.Equal(t, objects.Object(&objects.Int{Value: int64(len(addrs))}), it.Key())
+				addrs = append(addrs, it.Value().(*objects.String).Value)
+			}
+			assert.Equal(t, strings.Join(expected, ","), strings.Join(addrs, ","))
+		}
+	}
+	expectRange(module(t, "ip").call("range", "10.0.0.5/30"), "10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.7")
+	expectRange(module(t, "ip").call("range", "10.0.0.255", "10.0.1.1"), "10.0.0.255", "10.0.1.0", "10.0.1.1")
+	expectRange(module(t, "ip").call("range", "::fffe", "::1:0"), "::fffe", "::ffff", "::1:0")
+	expectRange(module(t, "ip").call("range", "255.255.255.255/32"), "255.255.255.255")
+	module(t, "ip").call("range", "10.0.0.2", "10.0.0.1").expect(invalid("invalid IP range: 10.0.0.2-10.0.0.1"))
+	module(t, "ip").call("range", "10.0.0.1", "::1").expect(invalid("invalid IP range: 10.0.0.1-::1"))
+	module(t, "ip").call("range").expectError()
+}
@@ -0,0 +1,18 @@
+package stdlib
+
+import "github.com/d5/tengo/objects"
+
+// toJSON and fromJSON are to_json and from_json builtin functions, so the
+// modules encode and decode JSON like the scripts do.
+var toJSON, fromJSON objects.CallableFunc
+
+func init() {
+	for _, b := range objects.Builtins {
+		switch b.Name {
+		case "to_json":
+			toJSON = b.Func
+		case "from_json":
+			fromJSON = b.Func
+		}
+	}
+}
@@ -0,0 +1,459 @@
+//go:build !tengo_no_jwt
+// +build !tengo_no_jwt
+
+package stdlib
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("jwt", jwtModule)
+}
+
+func jwtModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"sign":   &objects.UserFunction{Name: "sign", Value: jwtSign},     // sign(claims, key, options) => string/error
+		"verify": &objects.UserFunction{Name: "verify", Value: jwtVerify}, // verify(token, key, options) => claims/error
+		"decode": &objects.UserFunction{Name: "decode", Value: jwtDecode}, // decode(token) => {header, claims}/error
+	}
+}
+
+// jwtTimeClaims are the claims of the NumericDate values: the times are
+// converted to the seconds since the epoch.
+var jwtTimeClaims = []string{"exp", "nbf", "iat"}
+
+// jwtKey is the key of HS256 (the secret) or RS256 (the RSA key).
+type jwtKey struct {
+	secret  []byte
+	private *rsa.PrivateKey
+	public  *rsa.PublicKey
+}
+
+func (k *jwtKey) alg() string {
+	if k.secret != nil {
+		return "HS256"
+	}
+	return "RS256"
+}
+
+// jwtParseKey returns the key of the string or the bytes. The PEM encoded
+// RSA keys and certificates are the keys of RS256, and, the other values are
+// the secrets of HS256.
+func jwtParseKey(o objects.Object, argName string) (*jwtKey, error) {
+	var b []byte
+	switch o := o.(type) {
+	case *objects.String:
+		b = []byte(o.Value)
+	case *objects.Bytes:
+		b = o.Value
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     argName,
+			Expected: "string/bytes",
+			Found:    o.TypeName(),
+		}
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(b), []byte("-----BEGIN ")) {
+		if len(b) == 0 {
+			return nil, errors.New("invalid key: empty secret")
+		}
+		return &jwtKey{secret: b}, nil
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("invalid key: invalid PEM data")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("invalid key: unsupported PEM type '%s'", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %s", err.Error())
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return &jwtKey{private: key, public: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &jwtKey{public: key}, nil
+	}
+
+	return nil, errors.New("invalid key: not an RSA key")
+}
+
+func jwtSign(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	claims, ok := toStringMap(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "map",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	key, err := jwtParseKey(args[1], "second")
+	if err != nil {
+		if _, ok := err.(objects.ErrInvalidArgumentType); ok {
+			return nil, err
+		}
+		return wrapError(err), nil
+	}
+	if key.secret == nil && key.private == nil {
+		return wrapError(errors.New("invalid key: RS256 requires a private key")), nil
+	}
+
+	header := map[string]objects.Object{}
+	if len(args) == 3 {
+		options, ok := toStringMap(args[2])
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "third",
+				Expected: "map",
+				Found:    args[2].TypeName(),
+			}
+		}
+		for k, v := range options {
+			switch k {
+			case "header":
+				h, ok := toStringMap(v)
+				if !ok {
+					return nil, fmt.Errorf("invalid jwt option '%s': %s", k, v)
+				}
+				for name, value := range h {
+					header[name] = value
+				}
+			default:
+				return nil, fmt.Errorf("unknown jwt option '%s'", k)
+			}
+		}
+	}
+	header["alg"] = &objects.String{Value: key.alg()}
+	if _, ok := header["typ"]; !ok {
+		header["typ"] = &objects.String{Value: "JWT"}
+	}
+
+	payload := make(map[string]objects.Object, len(claims))
+	for k, v := range claims {
+		payload[k] = v
+	}
+	for _, name := range jwtTimeClaims {
+		if t, ok := payload[name].(*objects.Time); ok {
+			payload[name] = &objects.Int{Value: t.Value.Unix()}
+		}
+	}
+
+	var parts []string
+	for _, m := range []map[string]objects.Object{header, payload} {
+		data, err := toJSON(&objects.Map{Value: m})
+		if err != nil {
+			return nil, err
+		}
+		if _, isErr := data.(*objects.Error); isErr {
+			return data, nil
+		}
+		parts = append(parts, base64.RawURLEncoding.EncodeToString(data.(*objects.Bytes).Value))
+	}
+
+	signingInput := strings.Join(parts, ".")
+	sig, err := key.sign([]byte(signingInput))
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	token := signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+	if len(token) > objects.MaxStringLen {
+		return nil, objects.ErrStringLimit
+	}
+
+	return &objects.String{Value: token}, nil
+}
+
+func (k *jwtKey) sign(input []byte) ([]byte, error) {
+	if k.secret != nil {
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	}
+
+	digest := sha256.Sum256(input)
+	return rsa.SignPKCS1v15(rand.Reader, k.private, crypto.SHA256, digest[:])
+}
+
+func (k *jwtKey) verify(input, sig []byte) bool {
+	if k.secret != nil {
+		expected, _ := k.sign(input)
+		return hmac.Equal(expected, sig)
+	}
+
+	digest := sha256.Sum256(input)
+	return rsa.VerifyPKCS1v15(k.public, crypto.SHA256, digest[:], sig) == nil
+}
+
+// jwtVerifyOptions are the options of jwt.verify.
+type jwtVerifyOptions struct {
+	leeway   time.Duration
+	issuer   *string
+	audience *string
+}
+
+func jwtVerify(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	token, ok := args[0].(*objects.String)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	key, err := jwtParseKey(args[1], "second")
+	if err != nil {
+		if _, ok := err.(objects.ErrInvalidArgumentType); ok {
+			return nil, err
+		}
+		return wrapError(err), nil
+	}
+
+	opts := &jwtVerifyOptions{}
+	if len(args) == 3 {
+		if err := opts.parse(args[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	parts := strings.Split(token.Value, ".")
+	if len(parts) != 3 {
+		return wrapError(errors.New("invalid token: expected 3 parts")), nil
+	}
+	header, claims, err := jwtParse(parts)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	// the algorithm is the one of the key, so the tokens can't choose a
+	// weaker one (e.g. "none" or HS256 with the public key as the secret)
+	alg, _ := header["alg"].(*objects.String)
+	if alg == nil || alg.Value != key.alg() {
+		return wrapError(fmt.Errorf("unexpected algorithm: %s", jwtClaimString(header["alg"]))), nil
+	}
+
+	sig, err := base64.RawURLEncoding.Strict().DecodeString(parts[2])
+	if err != nil {
+		return wrapError(errors.New("invalid token: invalid signature encoding")), nil
+	}
+	if !key.verify([]byte(parts[0]+"."+parts[1]), sig) {
+		return wrapError(errors.New("invalid signature")), nil
+	}
+
+	if err := opts.validate(claims, time.Now()); err != nil {
+		return wrapError(err), nil
+	}
+
+	return &objects.Map{Value: claims}, nil
+}
+
+// parse reads the options from the map.
+func (opts *jwtVerifyOptions) parse(o objects.Object) error {
+	m, ok := toStringMap(o)
+	if !ok {
+		return objects.ErrInvalidArgumentType{
+			Name:     "third",
+			Expected: "map",
+			Found:    o.TypeName(),
+		}
+	}
+
+	for k, v := range m {
+		switch k {
+		case "leeway":
+			i, ok := v.(*objects.Int)
+			if !ok || i.Value < 0 {
+				return fmt.Errorf("invalid jwt option '%s': %s", k, v)
+			}
+			opts.leeway = time.Duration(i.Value)
+		case "issuer", "audience":
+			s, ok := v.(*objects.String)
+			if !ok {
+				return fmt.Errorf("invalid jwt option '%s': %s", k, v)
+			}
+			if k == "issuer" {
+				opts.issuer = &s.Value
+			} else {
+				opts.audience = &s.Value
+			}
+		default:
+			return fmt.Errorf("unknown jwt option '%s'", k)
+		}
+	}
+
+	return nil
+}
+
+// validate checks the registered claims: the expiration time, the not
+// before time, the issuer and the audience.
+func (opts *jwtVerifyOptions) validate(claims map[string]objects.Object, now time.Time) error {
+	if exp, ok := claims["exp"]; ok {
+		t, ok := jwtNumericDate(exp)
+		if !ok {
+			return fmt.Errorf("invalid claim 'exp': %s", exp)
+		}
+		if !now.Before(t.Add(opts.leeway)) {
+			return errors.New("token is expired")
+		}
+	}
+
+	if nbf, ok := claims["nbf"]; ok {
+		t, ok := jwtNumericDate(nbf)
+		if !ok {
+			return fmt.Errorf("invalid claim 'nbf': %s", nbf)
+		}
+		if now.Add(opts.leeway).Before(t) {
+			return errors.New("token is not valid yet")
+		}
+	}
+
+	if opts.issuer != nil {
+		iss, _ := claims["iss"].(*objects.String)
+		if iss == nil || iss.Value != *opts.issuer {
+			return fmt.Errorf("unexpected issuer: %s", jwtClaimString(claims["iss"]))
+		}
+	}
+
+	if opts.audience != nil && !jwtHasAudience(claims["aud"], *opts.audience) {
+		return fmt.Errorf("unexpected audience: %s", jwtClaimString(claims["aud"]))
+	}
+
+	return nil
+}
+
+func jwtDecode(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	token, ok := args[0].(*objects.String)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	parts := strings.Split(token.Value, ".")
+	if len(parts) != 3 {
+		return wrapError(errors.New("invalid token: expected 3 parts")), nil
+	}
+	header, claims, err := jwtParse(parts)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	return &objects.Map{Value: map[string]objects.Object{
+		"header": &objects.Map{Value: header},
+		"claims": &objects.Map{Value: claims},
+	}}, nil
+}
+
+// jwtParse decodes the header and the claims of the token.
+func jwtParse(parts []string) (header, claims map[string]objects.Object, err error) {
+	for i, name := range []string{"header", "claims"} {
+		data, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid token: invalid %s encoding", name)
+		}
+
+		res, err := fromJSON(&objects.Bytes{Value: data})
+		if err != nil {
+			return nil, nil, err
+		}
+		m, ok := res.(*objects.Map)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid token: invalid %s", name)
+		}
+
+		if i == 0 {
+			header = m.Value
+		} else {
+			claims = m.Value
+		}
+	}
+
+	return header, claims, nil
+}
+
+// jwtNumericDate returns the time of the seconds since the epoch.
+func jwtNumericDate(o objects.Object) (time.Time, bool) {
+	switch o := o.(type) {
+	case *objects.Int:
+		return time.Unix(o.Value, 0), true
+	case *objects.Float:
+		sec := int64(o.Value)
+		return time.Unix(sec, int64((o.Value-float64(sec))*1e9)), true
+	}
+	return time.Time{}, false
+}
+
+// jwtHasAudience returns true if the audience claim (a string or an array of
+// strings) contains the audience.
+func jwtHasAudience(aud objects.Object, audience string) bool {
+	switch aud := aud.(type) {
+	case *objects.String:
+		return aud.Value == audience
+	case *objects.Array:
+		for _, a := range aud.Value {
+			if s, ok := a.(*objects.String); ok && s.Value == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jwtClaimString(o objects.Object) string {
+	if o == nil {
+		return "none"
+	}
+	return o.String()
+}
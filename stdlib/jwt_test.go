@@ -0,0 +1,124 @@
+package stdlib_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestJWTHS256(t *testing.T) {
+	// the example of jwt.io
+	const token = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9." +
+		"eyJzdWIiOiIxMjM0NTY3ODkwIiwibmFtZSI6IkpvaG4gRG9lIiwiaWF0IjoxNTE2MjM5MDIyfQ." +
+		"SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c"
+	claims := MAP{"sub": "1234567890", "name": "John Doe", "iat": 1516239022.0}
+
+	module(t, "jwt").call("verify", token).expectError()
+	module(t, "jwt").call("verify", 1, "a").expectError()
+	module(t, "jwt").call("verify", token, 1).expectError()
+	module(t, "jwt").call("verify", token, "a", MAP{"leeway": -1}).expectError()
+	module(t, "jwt").call("verify", token, "a", MAP{"issuer": 1}).expectError()
+	module(t, "jwt").call("verify", token, "a", MAP{"algs": ARR{}}).expectError()
+
+	module(t, "jwt").call("verify", token, "your-256-bit-secret").expect(claims)
+	module(t, "jwt").call("verify", token, []byte("your-256-bit-secret")).expect(claims)
+	module(t, "jwt").call("decode", token).
+		expect(MAP{"header": MAP{"alg": "HS256", "typ": "JWT"}, "claims": claims})
+
+	verifyError := func(token string, key interface{}, opts MAP, msg string) {
+		module(t, "jwt").call("verify", token, key, opts).
+			expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	verifyError(token, "secret", MAP{}, "invalid signature")
+	verifyError(token, "", MAP{}, "invalid key: empty secret")
+	verifyError(strings.Replace(token, ".Sfl", ".Tfl", 1), "your-256-bit-secret", MAP{}, "invalid signature")
+	verifyError(token[:len(token)-1]+"d", "your-256-bit-secret", MAP{}, "invalid token: invalid signature encoding")
+	verifyError("a.b", "secret", MAP{}, "invalid token: expected 3 parts")
+	verifyError("!.b.c", "secret", MAP{}, "invalid token: invalid header encoding")
+	verifyError("e30.!.c", "secret", MAP{}, "invalid token: invalid claims encoding")
+	verifyError("W10.e30.c", "secret", MAP{}, "invalid token: invalid header")
+	verifyError("e30.e30.!", "secret", MAP{}, "unexpected algorithm: none")
+	verifyError("eyJhbGciOiJub25lIn0.e30.", "secret", MAP{}, "unexpected algorithm: \"none\"")
+
+	// the round trip
+	now := time.Now()
+	signed := module(t, "jwt").call("sign", MAP{"sub": "a", "exp": now.Add(time.Hour), "n": 1}, "secret",
+		MAP{"header": MAP{"kid": "k1"}})
+	assert.NoError(t, signed.e)
+	parts := strings.Split(signed.o.(*objects.String).Value, ".")
+	assert.Equal(t, 3, len(parts))
+	module(t, "jwt").call("verify", signed.o, "secret").
+		expect(MAP{"sub": "a", "exp": float64(now.Add(time.Hour).Unix()), "n": 1.0})
+	decoded := module(t, "jwt").call("decode", signed.o)
+	assert.NoError(t, decoded.e)
+	assert.Equal(t, object(MAP{"alg": "HS256", "typ": "JWT", "kid": "k1"}),
+		decoded.o.(*objects.Map).Value["header"])
+
+	module(t, "jwt").call("sign", MAP{}).expectError()
+	module(t, "jwt").call("sign", 1, "secret").expectError()
+	module(t, "jwt").call("sign", MAP{}, "secret", MAP{"alg": "RS256"}).expectError()
+	module(t, "jwt").call("sign", MAP{}, "").
+		expect(&objects.Error{Value: &objects.String{Value: "invalid key: empty secret"}})
+
+	// the registered claims
+	sign := func(claims MAP) string {
+		res := module(t, "jwt").call("sign", claims, "secret")
+		assert.NoError(t, res.e)
+		return res.o.(*objects.String).Value
+	}
+	expired := sign(MAP{"exp": now.Add(-time.Minute)})
+	verifyError(expired, "secret", MAP{}, "token is expired")
+	module(t, "jwt").call("verify", expired, "secret", MAP{"leeway": int64(time.Hour)}).
+		expect(MAP{"exp": float64(now.Add(-time.Minute).Unix())})
+	verifyError(sign(MAP{"nbf": now.Add(time.Hour).Unix()}), "secret", MAP{}, "token is not valid yet")
+	verifyError(sign(MAP{"exp": "tomorrow"}), "secret", MAP{}, "invalid claim 'exp': \"tomorrow\"")
+
+	withAud := sign(MAP{"iss": "me", "aud": ARR{"a", "b"}})
+	module(t, "jwt").call("verify", withAud, "secret", MAP{"issuer": "me", "audience": "b"}).
+		expect(MAP{"iss": "me", "aud": ARR{"a", "b"}})
+	verifyError(withAud, "secret", MAP{"issuer": "you"}, "unexpected issuer: \"me\"")
+	verifyError(withAud, "secret", MAP{"audience": "c"}, "unexpected audience: [\"a\", \"b\"]")
+	verifyError(sign(MAP{}), "secret", MAP{"audience": "c"}, "unexpected audience: none")
+}
+
+func TestJWTRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	privatePEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	pkcs8PEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	publicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}))
+
+	for _, signingKey := range []interface{}{privatePEM, pkcs8PEM} {
+		signed := module(t, "jwt").call("sign", MAP{"sub": "a"}, signingKey)
+		assert.NoError(t, signed.e)
+		module(t, "jwt").call("decode", signed.o).
+			expect(MAP{"header": MAP{"alg": "RS256", "typ": "JWT"}, "claims": MAP{"sub": "a"}})
+
+		module(t, "jwt").call("verify", signed.o, publicPEM).expect(MAP{"sub": "a"})
+		module(t, "jwt").call("verify", signed.o, privatePEM).expect(MAP{"sub": "a"})
+
+		// the public key can't be used as the secret of HS256
+		module(t, "jwt").call("verify", signed.o, "secret").
+			expect(&objects.Error{Value: &objects.String{Value: "unexpected algorithm: \"RS256\""}})
+	}
+
+	keyError := func(key, msg string) {
+		module(t, "jwt").call("sign", MAP{}, key).
+			expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	keyError(publicPEM, "invalid key: RS256 requires a private key")
+	keyError("-----BEGIN PUBLIC KEY-----", "invalid key: invalid PEM data")
+	keyError("-----BEGIN X-----\nAAAA\n-----END X-----\n", "invalid key: unsupported PEM type 'X'")
+}
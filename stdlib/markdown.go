@@ -0,0 +1,1084 @@
+//go:build !tengo_no_markdown
+// +build !tengo_no_markdown
+
+package stdlib
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("markdown", markdownModule)
+}
+
+func markdownModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"to_html": &objects.UserFunction{Name: "to_html", Value: markdownToHTML}, // to_html(src) => string
+		"parse":   &objects.UserFunction{Name: "parse", Value: markdownParse},    // parse(src) => node
+	}
+}
+
+func markdownToHTML(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	src, err := stringArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	w := &markdownWriter{}
+	w.block(markdownParseDocument(src), false)
+	if w.sb.Len() > objects.MaxStringLen {
+		return nil, objects.ErrStringLimit
+	}
+
+	return &objects.String{Value: w.sb.String()}, nil
+}
+
+func markdownParse(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	src, err := stringArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	return markdownParseDocument(src).object(), nil
+}
+
+// markdownNode is a node of the document. The fields that are not used by
+// the type of the node are zero.
+type markdownNode struct {
+	typ      string
+	children []*markdownNode
+	text     string // text, code, code_block
+	level    int    // heading
+	lang     string // code_block
+	ordered  bool   // list
+	start    int    // list
+	tight    bool   // list
+	url      string // link, image
+	title    string // link, image
+
+	// afterBlank is true if the block is after a blank line in its container.
+	afterBlank bool
+}
+
+// object returns the node as a map of the script.
+func (n *markdownNode) object() objects.Object {
+	m := map[string]objects.Object{
+		"type": &objects.String{Value: n.typ},
+	}
+
+	switch n.typ {
+	case "heading":
+		m["level"] = &objects.Int{Value: int64(n.level)}
+	case "code_block":
+		m["lang"] = &objects.String{Value: n.lang}
+		m["text"] = &objects.String{Value: n.text}
+	case "list":
+		m["ordered"] = objects.FalseValue
+		if n.ordered {
+			m["ordered"] = objects.TrueValue
+			m["start"] = &objects.Int{Value: int64(n.start)}
+		}
+		m["tight"] = objects.FalseValue
+		if n.tight {
+			m["tight"] = objects.TrueValue
+		}
+	case "text", "code":
+		m["text"] = &objects.String{Value: n.text}
+	case "link", "image":
+		m["url"] = &objects.String{Value: n.url}
+		m["title"] = &objects.String{Value: n.title}
+	}
+
+	switch n.typ {
+	case "document", "heading", "paragraph", "blockquote", "list", "item",
+		"emphasis", "strong", "link", "image":
+		children := make([]objects.Object, len(n.children))
+		for i, c := range n.children {
+			children[i] = c.object()
+		}
+		m["children"] = &objects.Array{Value: children}
+	}
+
+	return &objects.Map{Value: m}
+}
+
+func markdownParseDocument(src string) *markdownNode {
+	src = strings.Replace(src, "\r\n", "\n", -1)
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = markdownExpandTabs(line)
+	}
+
+	return &markdownNode{typ: "document", children: markdownParseBlocks(lines)}
+}
+
+// markdownParseBlocks parses the lines of a container (the document, a
+// block quote or a list item).
+func markdownParseBlocks(lines []string) []*markdownNode {
+	var nodes []*markdownNode
+	blank := false
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if markdownIsBlank(line) {
+			blank = true
+			i++
+			continue
+		}
+
+		var n *markdownNode
+		if markdownIndent(line) >= 4 {
+			n, i = markdownParseIndentedCode(lines, i)
+		} else if _, _, _, ok := markdownFence(line); ok {
+			n, i = markdownParseFencedCode(lines, i)
+		} else if level, text, ok := markdownATXHeading(line); ok {
+			n = &markdownNode{typ: "heading", level: level, children: markdownParseInlines(text)}
+			i++
+		} else if markdownIsThematicBreak(line) {
+			n = &markdownNode{typ: "thematic_break"}
+			i++
+		} else if _, ok := markdownQuoteLine(line); ok {
+			n, i = markdownParseBlockquote(lines, i)
+		} else if _, ok := markdownListMarker(line); ok {
+			n, i = markdownParseList(lines, i)
+		} else {
+			n, i = markdownParseParagraph(lines, i)
+		}
+
+		n.afterBlank = blank && len(nodes) > 0
+		nodes = append(nodes, n)
+		blank = false
+	}
+
+	return nodes
+}
+
+func markdownParseIndentedCode(lines []string, i int) (*markdownNode, int) {
+	var code []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if markdownIsBlank(line) {
+			code = append(code, markdownTrimIndent(line, 4))
+			continue
+		}
+		if markdownIndent(line) < 4 {
+			break
+		}
+		code = append(code, line[4:])
+	}
+
+	// the blank lines at the end are not in the code
+	for len(code) > 0 && markdownIsBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+
+	return &markdownNode{typ: "code_block", text: strings.Join(code, "\n") + "\n"}, i
+}
+
+func markdownParseFencedCode(lines []string, i int) (*markdownNode, int) {
+	indent, fence, info, _ := markdownFence(lines[i])
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		line := lines[i]
+		if markdownIndent(line) < 4 {
+			rest := strings.TrimLeft(line, " ")
+			if strings.HasPrefix(rest, fence) &&
+				strings.TrimSpace(strings.TrimLeft(rest, fence[:1])) == "" {
+				i++
+				break
+			}
+		}
+		code = append(code, markdownTrimIndent(line, indent))
+	}
+
+	n := &markdownNode{typ: "code_block"}
+	if len(code) > 0 {
+		n.text = strings.Join(code, "\n") + "\n"
+	}
+	if info != "" {
+		n.lang = markdownUnescape(strings.Fields(info)[0])
+	}
+
+	return n, i
+}
+
+func markdownParseBlockquote(lines []string, i int) (*markdownNode, int) {
+	var inner []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if rest, ok := markdownQuoteLine(line); ok {
+			inner = append(inner, rest)
+			continue
+		}
+
+		// a lazy continuation line of the paragraph in the quote
+		if markdownIsBlank(line) || markdownIsBlank(inner[len(inner)-1]) ||
+			markdownIsBlockStart(line) {
+			break
+		}
+		inner = append(inner, line)
+	}
+
+	return &markdownNode{typ: "blockquote", children: markdownParseBlocks(inner)}, i
+}
+
+func markdownParseList(lines []string, i int) (*markdownNode, int) {
+	first, _ := markdownListMarker(lines[i])
+	list := &markdownNode{
+		typ:     "list",
+		ordered: first.ordered,
+		start:   first.start,
+		tight:   true,
+	}
+
+	trailing := 0
+	for i < len(lines) {
+		m, ok := markdownListMarker(lines[i])
+		if !ok || m.bullet != first.bullet || markdownIsThematicBreak(lines[i]) {
+			break
+		}
+
+		item := []string{""}
+		if !m.empty {
+			item[0] = lines[i][m.content:]
+		}
+
+		j := i + 1
+	itemLines:
+		for ; j < len(lines); j++ {
+			line := lines[j]
+			switch {
+			case markdownIsBlank(line):
+				item = append(item, "")
+			case markdownIndent(line) >= m.content:
+				item = append(item, line[m.content:])
+			case markdownIsListItem(line, m.bullet):
+				break itemLines
+			case !markdownIsBlank(item[len(item)-1]) && !markdownIsBlockStart(line):
+				// a lazy continuation line of the paragraph in the item
+				item = append(item, line)
+			default:
+				break itemLines
+			}
+		}
+
+		// the list is loose if there are blank lines between the items
+		if trailing > 0 && len(list.children) > 0 {
+			list.tight = false
+		}
+
+		// the blank lines at the end of the item are between the items
+		trailing = 0
+		for len(item) > 1 && markdownIsBlank(item[len(item)-1]) {
+			item = item[:len(item)-1]
+			trailing++
+		}
+
+		// or, between the blocks of an item
+		n := &markdownNode{typ: "item", children: markdownParseBlocks(item)}
+		for _, c := range n.children {
+			if c.afterBlank {
+				list.tight = false
+			}
+		}
+		list.children = append(list.children, n)
+
+		i = j
+	}
+
+	// the blank lines after the last item are not in the list
+	return list, i - trailing
+}
+
+func markdownParseParagraph(lines []string, i int) (*markdownNode, int) {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if markdownIsBlank(line) {
+			break
+		}
+
+		if len(text) > 0 {
+			if level := markdownSetextLevel(line); level > 0 {
+				return &markdownNode{
+					typ:      "heading",
+					level:    level,
+					children: markdownParseInlines(strings.TrimSpace(strings.Join(text, "\n"))),
+				}, i + 1
+			}
+
+			if markdownIsBlockStart(line) {
+				break
+			}
+		}
+
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+
+	return &markdownNode{
+		typ:      "paragraph",
+		children: markdownParseInlines(strings.TrimRight(strings.Join(text, "\n"), " ")),
+	}, i
+}
+
+// markdownIsBlockStart returns true if the line starts a block that
+// interrupts a paragraph.
+func markdownIsBlockStart(line string) bool {
+	if markdownIndent(line) >= 4 {
+		return false
+	}
+
+	if _, _, _, ok := markdownFence(line); ok {
+		return true
+	}
+
+	if _, _, ok := markdownATXHeading(line); ok {
+		return true
+	}
+
+	if _, ok := markdownQuoteLine(line); ok {
+		return true
+	}
+
+	if markdownIsThematicBreak(line) {
+		return true
+	}
+
+	// an ordered list interrupts a paragraph only if it starts with 1
+	m, ok := markdownListMarker(line)
+
+	return ok && !m.empty && (!m.ordered || m.start == 1)
+}
+
+// markdownFence returns the code fence of the line: 3 or more backticks or
+// tildes, and, the info string after it.
+func markdownFence(line string) (indent int, fence, info string, ok bool) {
+	indent = markdownIndent(line)
+	if indent >= 4 {
+		return
+	}
+
+	rest := line[indent:]
+	if !strings.HasPrefix(rest, "```") && !strings.HasPrefix(rest, "~~~") {
+		return
+	}
+
+	n := len(rest) - len(strings.TrimLeft(rest, rest[:1]))
+	fence, info = rest[:n], strings.TrimSpace(rest[n:])
+	if fence[0] == '`' && strings.Contains(info, "`") {
+		return
+	}
+
+	return indent, fence, info, true
+}
+
+func markdownATXHeading(line string) (int, string, bool) {
+	indent := markdownIndent(line)
+	if indent >= 4 {
+		return 0, "", false
+	}
+
+	rest := line[indent:]
+	level := len(rest) - len(strings.TrimLeft(rest, "#"))
+	if level < 1 || level > 6 || (len(rest) > level && rest[level] != ' ') {
+		return 0, "", false
+	}
+
+	// the closing sequence of # is not in the text
+	text := strings.TrimSpace(rest[level:])
+	if trimmed := strings.TrimRight(text, "#"); trimmed == "" {
+		text = ""
+	} else if trimmed != text && strings.HasSuffix(trimmed, " ") {
+		text = strings.TrimSpace(trimmed)
+	}
+
+	return level, text, true
+}
+
+func markdownIsThematicBreak(line string) bool {
+	if markdownIndent(line) >= 4 {
+		return false
+	}
+
+	var marker rune
+	count := 0
+	for _, c := range line {
+		switch {
+		case c == ' ':
+		case (c == '-' || c == '*' || c == '_') && (marker == 0 || c == marker):
+			marker = c
+			count++
+		default:
+			return false
+		}
+	}
+
+	return count >= 3
+}
+
+// markdownSetextLevel returns the level of the heading underlined by the
+// line, or, 0 if it's not an underline.
+func markdownSetextLevel(line string) int {
+	if markdownIndent(line) >= 4 {
+		return 0
+	}
+
+	s := strings.TrimSpace(line)
+	switch {
+	case s == "":
+		return 0
+	case strings.Trim(s, "=") == "":
+		return 1
+	case strings.Trim(s, "-") == "":
+		return 2
+	}
+
+	return 0
+}
+
+// markdownQuoteLine returns the line without the block quote marker.
+func markdownQuoteLine(line string) (string, bool) {
+	indent := markdownIndent(line)
+	if indent >= 4 || len(line) == indent || line[indent] != '>' {
+		return "", false
+	}
+
+	rest := line[indent+1:]
+	if strings.HasPrefix(rest, " ") {
+		rest = rest[1:]
+	}
+
+	return rest, true
+}
+
+// markdownMarker is the marker of a list item.
+type markdownMarker struct {
+	ordered bool
+	start   int
+	bullet  byte // the bullet character, or, the delimiter of the number
+	content int  // the indentation of the content
+	empty   bool // the item is empty on the line of the marker
+}
+
+func markdownListMarker(line string) (markdownMarker, bool) {
+	var m markdownMarker
+	indent := markdownIndent(line)
+	if indent >= 4 || indent == len(line) {
+		return m, false
+	}
+
+	pos := indent
+	switch c := line[pos]; {
+	case c == '-' || c == '*' || c == '+':
+		m.bullet = c
+		pos++
+	case c >= '0' && c <= '9':
+		for pos < len(line) && pos-indent < 9 && line[pos] >= '0' && line[pos] <= '9' {
+			pos++
+		}
+		if pos == len(line) || (line[pos] != '.' && line[pos] != ')') {
+			return m, false
+		}
+		m.ordered = true
+		m.start, _ = strconv.Atoi(line[indent:pos])
+		m.bullet = line[pos]
+		pos++
+	default:
+		return m, false
+	}
+
+	spaces := markdownIndent(line[pos:])
+	switch {
+	case pos+spaces == len(line):
+		m.empty = true
+		m.content = pos + 1
+	case spaces == 0:
+		return m, false
+	case spaces > 4:
+		// the content is an indented code block
+		m.content = pos + 1
+	default:
+		m.content = pos + spaces
+	}
+
+	return m, true
+}
+
+// markdownIsListItem returns true if the line starts an item of the list.
+func markdownIsListItem(line string, bullet byte) bool {
+	m, ok := markdownListMarker(line)
+
+	return ok && m.bullet == bullet
+}
+
+func markdownIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func markdownTrimIndent(line string, n int) string {
+	if indent := markdownIndent(line); indent < n {
+		n = indent
+	}
+
+	return line[n:]
+}
+
+func markdownIsBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// markdownExpandTabs replaces the tabs of the indentation with the spaces
+// to the next tab stop of 4.
+func markdownExpandTabs(line string) string {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if !strings.Contains(line[:indent], "\t") {
+		return line
+	}
+
+	var sb strings.Builder
+	for _, c := range line[:indent] {
+		if c == '\t' {
+			sb.WriteString(strings.Repeat(" ", 4-sb.Len()%4))
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+
+	return sb.String() + line[indent:]
+}
+
+// markdownParseInlines parses the text of a paragraph or a heading.
+func markdownParseInlines(s string) []*markdownNode {
+	var nodes []*markdownNode
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, &markdownNode{typ: "text", text: text.String()})
+			text.Reset()
+		}
+	}
+	add := func(n *markdownNode) {
+		flush()
+		nodes = append(nodes, n)
+	}
+
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				add(&markdownNode{typ: "line_break"})
+				i += 2
+				continue
+			}
+			if i+1 < len(s) && markdownIsPunct(s[i+1]) {
+				text.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+		case '`':
+			n, size := markdownCodeSpan(s[i:])
+			if n != nil {
+				add(n)
+			} else {
+				text.WriteString(s[i : i+size])
+			}
+			i += size
+			continue
+		case '!', '[':
+			start := i
+			if c == '!' {
+				if i+1 >= len(s) || s[i+1] != '[' {
+					break
+				}
+				start++
+			}
+			if n, size := markdownLink(s[start:]); n != nil {
+				if c == '!' {
+					n.typ = "image"
+				}
+				add(n)
+				i = start + size
+				continue
+			}
+		case '<':
+			if n, size := markdownAutolink(s[i:]); n != nil {
+				add(n)
+				i += size
+				continue
+			}
+		case '&':
+			if end := strings.IndexByte(s[i:], ';'); end > 1 && end < 32 {
+				if entity := s[i : i+end+1]; html.UnescapeString(entity) != entity {
+					text.WriteString(html.UnescapeString(entity))
+					i += end + 1
+					continue
+				}
+			}
+		case '*', '_':
+			prefix, n, size := markdownEmphasis(s, i)
+			text.WriteString(s[i : i+prefix])
+			if n != nil {
+				add(n)
+			}
+			i += size
+			continue
+		case '\n':
+			// a line ending after 2 or more spaces is a hard line break
+			t := text.String()
+			trimmed := strings.TrimRight(t, " ")
+			text.Reset()
+			text.WriteString(trimmed)
+			if len(t)-len(trimmed) >= 2 {
+				add(&markdownNode{typ: "line_break"})
+			} else {
+				add(&markdownNode{typ: "soft_break"})
+			}
+			for i++; i < len(s) && s[i] == ' '; i++ {
+			}
+			continue
+		}
+
+		text.WriteByte(s[i])
+		i++
+	}
+	flush()
+
+	return nodes
+}
+
+// markdownCodeSpan returns the code span at the start of s and its size. If
+// it's not a code span, it returns the size of the backticks.
+func markdownCodeSpan(s string) (*markdownNode, int) {
+	n := markdownRun(s, 0)
+	for i := n; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+
+		m := markdownRun(s, i)
+		if m != n {
+			i += m
+			continue
+		}
+
+		code := strings.Replace(s[n:i], "\n", " ", -1)
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' &&
+			strings.TrimSpace(code) != "" {
+			code = code[1 : len(code)-1]
+		}
+
+		return &markdownNode{typ: "code", text: code}, i + m
+	}
+
+	return nil, n
+}
+
+// markdownLink returns the inline link at the start of s and its size.
+func markdownLink(s string) (*markdownNode, int) {
+	// the matching bracket
+	end, depth := -1, 0
+	for i := 0; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			_, size := markdownCodeSpan(s[i:])
+			i += size - 1
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return nil, 0
+	}
+
+	i := markdownSkipSpaces(s, end+2)
+
+	// the destination
+	var url string
+	if i < len(s) && s[i] == '<' {
+		close := strings.IndexAny(s[i+1:], ">\n")
+		if close < 0 || s[i+1+close] != '>' {
+			return nil, 0
+		}
+		url = s[i+1 : i+1+close]
+		i += close + 2
+	} else {
+		start, parens := i, 0
+		for ; i < len(s) && s[i] > ' '; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			} else if s[i] == '(' {
+				parens++
+			} else if s[i] == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			}
+		}
+		url = s[start:i]
+	}
+
+	// the optional title
+	var title string
+	if j := markdownSkipSpaces(s, i); j > i && j < len(s) && strings.IndexByte("\"'(", s[j]) >= 0 {
+		closing := s[j]
+		if closing == '(' {
+			closing = ')'
+		}
+
+		k := j + 1
+		for ; k < len(s) && s[k] != closing; k++ {
+			if s[k] == '\\' {
+				k++
+			}
+		}
+		if k >= len(s) {
+			return nil, 0
+		}
+		title = s[j+1 : k]
+		i = k + 1
+	}
+
+	i = markdownSkipSpaces(s, i)
+	if i >= len(s) || s[i] != ')' {
+		return nil, 0
+	}
+
+	return &markdownNode{
+		typ:      "link",
+		url:      markdownUnescape(url),
+		title:    markdownUnescape(title),
+		children: markdownParseInlines(s[1:end]),
+	}, i + 1
+}
+
+// markdownAutolink returns the URL or the email address in the angle
+// brackets at the start of s and its size.
+func markdownAutolink(s string) (*markdownNode, int) {
+	end := strings.IndexAny(s[1:], "<> \t\n")
+	if end < 0 || s[1+end] != '>' {
+		return nil, 0
+	}
+
+	addr := s[1 : 1+end]
+	url := addr
+	colon, at := strings.IndexByte(addr, ':'), strings.IndexByte(addr, '@')
+	switch {
+	case colon >= 2 && colon <= 32 && markdownIsScheme(addr[:colon]):
+		// an absolute URI
+	case at > 0 && at < len(addr)-1 && !strings.ContainsAny(addr, "\\:"):
+		url = "mailto:" + addr
+	default:
+		return nil, 0
+	}
+
+	return &markdownNode{
+		typ:      "link",
+		url:      url,
+		children: []*markdownNode{{typ: "text", text: addr}},
+	}, end + 2
+}
+
+func markdownIsScheme(s string) bool {
+	for i, c := range s {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isLetter && (i == 0 || !(c >= '0' && c <= '9' || c == '+' || c == '.' || c == '-')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// markdownEmphasis parses the emphasis or the strong emphasis that starts
+// with the run of * or _ at i. It returns the size of the run that is not
+// used as the opening delimiter, the node, and, the size of the emphasis
+// including the unused run. The node is nil if the run is not an opening
+// delimiter.
+func markdownEmphasis(s string, i int) (int, *markdownNode, int) {
+	c := s[i]
+	run := markdownRun(s, i)
+	if !markdownCanOpen(s, i, run) {
+		return run, nil, run
+	}
+
+	for need := minInt(run, 3); need > 0; need-- {
+		start := i + run
+		closer := markdownFindCloser(s, start, c, need)
+		if closer < 0 {
+			continue
+		}
+
+		children := markdownParseInlines(s[start:closer])
+		var n *markdownNode
+		switch need {
+		case 1:
+			n = &markdownNode{typ: "emphasis", children: children}
+		case 2:
+			n = &markdownNode{typ: "strong", children: children}
+		default:
+			n = &markdownNode{typ: "emphasis", children: []*markdownNode{
+				{typ: "strong", children: children},
+			}}
+		}
+
+		return run - need, n, closer + need - i
+	}
+
+	return run, nil, run
+}
+
+// markdownFindCloser returns the position of the closing delimiter run of
+// at least n characters, or, -1 if it's not found. The runs that only open
+// are the nested emphasis, so the runs that close them are skipped.
+func markdownFindCloser(s string, start int, c byte, n int) int {
+	nested := 0
+	for i := start; i < len(s); {
+		switch s[i] {
+		case '\\':
+			i += 2
+			continue
+		case '`':
+			_, size := markdownCodeSpan(s[i:])
+			i += size
+			continue
+		case c:
+			run := markdownRun(s, i)
+			switch {
+			case i > start && markdownCanClose(s, i, run):
+				if nested > 0 {
+					nested--
+				} else if run >= n {
+					return i
+				}
+			case markdownCanOpen(s, i, run):
+				nested++
+			}
+			i += run
+			continue
+		}
+
+		i++
+	}
+
+	return -1
+}
+
+// markdownCanOpen returns true if the delimiter run is followed by a
+// non-space character. The run of _ can't be in a word.
+func markdownCanOpen(s string, i, run int) bool {
+	if i+run >= len(s) || markdownIsSpace(s[i+run]) {
+		return false
+	}
+
+	return s[i] != '_' || i == 0 || !markdownIsAlnum(s[i-1])
+}
+
+// markdownCanClose returns true if the delimiter run is after a non-space
+// character. The run of _ can't be in a word.
+func markdownCanClose(s string, i, run int) bool {
+	if i == 0 || markdownIsSpace(s[i-1]) {
+		return false
+	}
+
+	return s[i] != '_' || i+run >= len(s) || !markdownIsAlnum(s[i+run])
+}
+
+// markdownRun returns the number of the same characters at i.
+func markdownRun(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+
+	return n
+}
+
+func markdownSkipSpaces(s string, i int) int {
+	for i < len(s) && markdownIsSpace(s[i]) {
+		i++
+	}
+
+	return i
+}
+
+// markdownUnescape returns the text without the backslash escapes and the
+// entity references.
+func markdownUnescape(s string) string {
+	if strings.IndexByte(s, '\\') >= 0 {
+		var sb strings.Builder
+		for i := 0; i < len(s); i++ {
+			if s[i] == '\\' && i+1 < len(s) && markdownIsPunct(s[i+1]) {
+				i++
+			}
+			sb.WriteByte(s[i])
+		}
+		s = sb.String()
+	}
+
+	return html.UnescapeString(s)
+}
+
+func markdownIsSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func markdownIsAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+func markdownIsPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// markdownWriter renders the nodes to HTML.
+type markdownWriter struct {
+	sb strings.Builder
+}
+
+// cr starts a new line unless the output is at the start of a line.
+func (w *markdownWriter) cr() {
+	if s := w.sb.String(); s != "" && s[len(s)-1] != '\n' {
+		w.sb.WriteByte('\n')
+	}
+}
+
+// block renders a block. The paragraphs of the items of the tight lists are
+// rendered without the tags.
+func (w *markdownWriter) block(n *markdownNode, tight bool) {
+	switch n.typ {
+	case "document":
+		for _, c := range n.children {
+			w.block(c, false)
+		}
+	case "paragraph":
+		if tight {
+			w.inlines(n.children)
+			return
+		}
+		w.cr()
+		w.sb.WriteString("<p>")
+		w.inlines(n.children)
+		w.sb.WriteString("</p>\n")
+	case "heading":
+		tag := "h" + strconv.Itoa(n.level)
+		w.cr()
+		w.sb.WriteString("<" + tag + ">")
+		w.inlines(n.children)
+		w.sb.WriteString("</" + tag + ">\n")
+	case "code_block":
+		w.cr()
+		w.sb.WriteString("<pre><code")
+		if n.lang != "" {
+			w.sb.WriteString(` class="language-` + html.EscapeString(n.lang) + `"`)
+		}
+		w.sb.WriteString(">" + html.EscapeString(n.text) + "</code></pre>\n")
+	case "thematic_break":
+		w.cr()
+		w.sb.WriteString("<hr />\n")
+	case "blockquote":
+		w.cr()
+		w.sb.WriteString("<blockquote>\n")
+		for _, c := range n.children {
+			w.block(c, false)
+		}
+		w.cr()
+		w.sb.WriteString("</blockquote>\n")
+	case "list":
+		tag := "ul"
+		w.cr()
+		if n.ordered {
+			tag = "ol"
+			if n.start != 1 {
+				w.sb.WriteString(`<ol start="` + strconv.Itoa(n.start) + `">` + "\n")
+			} else {
+				w.sb.WriteString("<ol>\n")
+			}
+		} else {
+			w.sb.WriteString("<ul>\n")
+		}
+		for _, item := range n.children {
+			w.sb.WriteString("<li>")
+			for _, c := range item.children {
+				w.block(c, n.tight)
+			}
+			w.sb.WriteString("</li>\n")
+		}
+		w.sb.WriteString("</" + tag + ">\n")
+	}
+}
+
+func (w *markdownWriter) inlines(nodes []*markdownNode) {
+	for _, n := range nodes {
+		switch n.typ {
+		case "text":
+			w.sb.WriteString(html.EscapeString(n.text))
+		case "code":
+			w.sb.WriteString("<code>" + html.EscapeString(n.text) + "</code>")
+		case "emphasis":
+			w.sb.WriteString("<em>")
+			w.inlines(n.children)
+			w.sb.WriteString("</em>")
+		case "strong":
+			w.sb.WriteString("<strong>")
+			w.inlines(n.children)
+			w.sb.WriteString("</strong>")
+		case "link":
+			w.sb.WriteString(`<a href="` + html.EscapeString(n.url) + `"`)
+			if n.title != "" {
+				w.sb.WriteString(` title="` + html.EscapeString(n.title) + `"`)
+			}
+			w.sb.WriteString(">")
+			w.inlines(n.children)
+			w.sb.WriteString("</a>")
+		case "image":
+			w.sb.WriteString(`<img src="` + html.EscapeString(n.url) +
+				`" alt="` + html.EscapeString(markdownPlainText(n.children)) + `"`)
+			if n.title != "" {
+				w.sb.WriteString(` title="` + html.EscapeString(n.title) + `"`)
+			}
+			w.sb.WriteString(" />")
+		case "line_break":
+			w.sb.WriteString("<br />\n")
+		case "soft_break":
+			w.sb.WriteString("\n")
+		}
+	}
+}
+
+// markdownPlainText returns the text of the inline nodes without the
+// formatting.
+func markdownPlainText(nodes []*markdownNode) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		switch n.typ {
+		case "text", "code":
+			sb.WriteString(n.text)
+		case "soft_break", "line_break":
+			sb.WriteString(" ")
+		default:
+			sb.WriteString(markdownPlainText(n.children))
+		}
+	}
+
+	return sb.String()
+}
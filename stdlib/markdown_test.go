@@ -0,0 +1,127 @@
+package stdlib_test
+
+import (
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	module(t, "markdown").call("to_html").expectError()
+	module(t, "markdown").call("to_html", 1).expectError()
+
+	toHTML := func(src, expected string) {
+		module(t, "markdown").call("to_html", src).expect(expected, src)
+	}
+
+	toHTML("", "")
+	toHTML("hello *world*", "<p>hello <em>world</em></p>\n")
+
+	// headings
+	toHTML("# a\n## b ##\n###### c\n####### d", "<h1>a</h1>\n<h2>b</h2>\n<h6>c</h6>\n<p>####### d</p>\n")
+	toHTML("#a", "<p>#a</p>\n")
+	toHTML("a\nb\n===\nc\n---", "<h1>a\nb</h1>\n<h2>c</h2>\n")
+
+	// paragraphs and breaks
+	toHTML("a\nb\n\nc", "<p>a\nb</p>\n<p>c</p>\n")
+	toHTML("a  \nb\\\nc", "<p>a<br />\nb<br />\nc</p>\n")
+	toHTML("a\n\n***\n- - -\n___", "<p>a</p>\n<hr />\n<hr />\n<hr />\n")
+
+	// code blocks
+	toHTML("```go\nfmt := 1\n\n  <b>\n```", "<pre><code class=\"language-go\">fmt := 1\n\n  &lt;b&gt;\n</code></pre>\n")
+	toHTML("~~~~\n~~~\n~~~~", "<pre><code>~~~\n</code></pre>\n")
+	toHTML("```\nnot closed", "<pre><code>not closed\n</code></pre>\n")
+	toHTML("    a\n\n    b\n\nc", "<pre><code>a\n\nb\n</code></pre>\n<p>c</p>\n")
+	toHTML("\tcode", "<pre><code>code\n</code></pre>\n")
+
+	// block quotes
+	toHTML("> a\nb\n> # c\n\n> d", "<blockquote>\n<p>a\nb</p>\n<h1>c</h1>\n</blockquote>\n<blockquote>\n<p>d</p>\n</blockquote>\n")
+	toHTML("> > a", "<blockquote>\n<blockquote>\n<p>a</p>\n</blockquote>\n</blockquote>\n")
+
+	// lists
+	toHTML("- a\n- b\n  - c\n\n  d\n- e",
+		"<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n<ul>\n<li>c</li>\n</ul>\n<p>d</p>\n</li>\n<li>\n<p>e</p>\n</li>\n</ul>\n")
+	toHTML("* a\n* b\n  c\n\nd", "<ul>\n<li>a</li>\n<li>b\nc</li>\n</ul>\n<p>d</p>\n")
+	toHTML("3. a\n4. b\n1) c", "<ol start=\"3\">\n<li>a</li>\n<li>b</li>\n</ol>\n<ol>\n<li>c</li>\n</ol>\n")
+	toHTML("- a\n\n- b", "<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ul>\n")
+	toHTML("- ```\n  code\n  ```\n-\n+ x", "<ul>\n<li>\n<pre><code>code\n</code></pre>\n</li>\n<li></li>\n</ul>\n<ul>\n<li>x</li>\n</ul>\n")
+	toHTML("a\n2. b\n- c", "<p>a\n2. b</p>\n<ul>\n<li>c</li>\n</ul>\n")
+
+	// emphasis
+	toHTML("*a* _b_ **c** __d__ ***e***", "<p><em>a</em> <em>b</em> <strong>c</strong> <strong>d</strong> <em><strong>e</strong></em></p>\n")
+	toHTML("**a *b* c**", "<p><strong>a <em>b</em> c</strong></p>\n")
+	toHTML("*a **b** c*", "<p><em>a <strong>b</strong> c</em></p>\n")
+	toHTML("snake_case_name * a * **b", "<p>snake_case_name * a * **b</p>\n")
+	toHTML("**a*", "<p>*<em>a</em></p>\n")
+	toHTML("*a `*` b*", "<p><em>a <code>*</code> b</em></p>\n")
+
+	// code spans
+	toHTML("`a` `` b`c `` ` ` `x", "<p><code>a</code> <code>b`c</code> <code> </code> `x</p>\n")
+
+	// links and images
+	toHTML(`[a *b*](http://x.com/?a=1&b=2 "T")`, "<p><a href=\"http://x.com/?a=1&amp;b=2\" title=\"T\">a <em>b</em></a></p>\n")
+	toHTML("[a](</my url>) [b](c(d)) [e]", "<p><a href=\"/my url\">a</a> <a href=\"c(d)\">b</a> [e]</p>\n")
+	toHTML(`![alt *x*](img.png 'y') ![](z)`, "<p><img src=\"img.png\" alt=\"alt x\" title=\"y\" /> <img src=\"z\" alt=\"\" /></p>\n")
+	toHTML("<https://x.com> <a@b.com> <b>", "<p><a href=\"https://x.com\">https://x.com</a> <a href=\"mailto:a@b.com\">a@b.com</a> &lt;b&gt;</p>\n")
+
+	// escapes and entities
+	toHTML(`\*a\* \\ \q &copy; &amp; &nope; <script>"'`, "<p>*a* \\ \\q © &amp; &amp;nope; &lt;script&gt;&#34;&#39;</p>\n")
+	toHTML("a\r\nb", "<p>a\nb</p>\n")
+}
+
+func TestMarkdownParse(t *testing.T) {
+	module(t, "markdown").call("parse").expectError()
+	module(t, "markdown").call("parse", []byte("a")).expectError()
+
+	module(t, "markdown").call("parse", "").expect(MAP{"type": "document", "children": ARR{}})
+
+	module(t, "markdown").call("parse", "# Title\n\nSee [docs](/d \"D\"), `x`.\n\n```sh\nls\n```\n\n1. *a*\n2. **b**\n\n---").
+		expect(MAP{"type": "document", "children": ARR{
+			MAP{"type": "heading", "level": 1, "children": ARR{
+				MAP{"type": "text", "text": "Title"},
+			}},
+			MAP{"type": "paragraph", "children": ARR{
+				MAP{"type": "text", "text": "See "},
+				MAP{"type": "link", "url": "/d", "title": "D", "children": ARR{
+					MAP{"type": "text", "text": "docs"},
+				}},
+				MAP{"type": "text", "text": ", "},
+				MAP{"type": "code", "text": "x"},
+				MAP{"type": "text", "text": "."},
+			}},
+			MAP{"type": "code_block", "lang": "sh", "text": "ls\n"},
+			MAP{"type": "list", "ordered": true, "start": 1, "tight": true, "children": ARR{
+				MAP{"type": "item", "children": ARR{
+					MAP{"type": "paragraph", "children": ARR{
+						MAP{"type": "emphasis", "children": ARR{MAP{"type": "text", "text": "a"}}},
+					}},
+				}},
+				MAP{"type": "item", "children": ARR{
+					MAP{"type": "paragraph", "children": ARR{
+						MAP{"type": "strong", "children": ARR{MAP{"type": "text", "text": "b"}}},
+					}},
+				}},
+			}},
+			MAP{"type": "thematic_break"},
+		}})
+
+	module(t, "markdown").call("parse", "> a\nb  \nc\n- ![i](j)").
+		expect(MAP{"type": "document", "children": ARR{
+			MAP{"type": "blockquote", "children": ARR{
+				MAP{"type": "paragraph", "children": ARR{
+					MAP{"type": "text", "text": "a"},
+					MAP{"type": "soft_break"},
+					MAP{"type": "text", "text": "b"},
+					MAP{"type": "line_break"},
+					MAP{"type": "text", "text": "c"},
+				}},
+			}},
+			MAP{"type": "list", "ordered": false, "tight": true, "children": ARR{
+				MAP{"type": "item", "children": ARR{
+					MAP{"type": "paragraph", "children": ARR{
+						MAP{"type": "image", "url": "j", "title": "", "children": ARR{
+							MAP{"type": "text", "text": "i"},
+						}},
+					}},
+				}},
+			}},
+		}})
+}
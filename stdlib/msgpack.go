@@ -0,0 +1,496 @@
+//go:build !tengo_no_msgpack
+// +build !tengo_no_msgpack
+
+package stdlib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("msgpack", msgpackModule)
+}
+
+func msgpackModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"encode": &objects.UserFunction{Name: "encode", Value: msgpackEncode}, // encode(v) => bytes/error
+		"decode": &objects.UserFunction{Name: "decode", Value: msgpackDecode}, // decode(data) => object/error
+	}
+}
+
+// msgpackMaxDepth is the maximum nesting depth of the arrays and the maps
+// to decode.
+const msgpackMaxDepth = 10000
+
+// msgpackTimestamp is the extension type of the timestamps.
+const msgpackTimestamp = -1
+
+var errMsgpackEOF = errors.New("unexpected end of data")
+
+func msgpackEncode(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	e := &msgpackEncoder{visiting: make(map[objects.Object]bool)}
+	if err := e.encode(args[0]); err != nil {
+		return wrapError(err), nil
+	}
+	if len(e.b) > objects.MaxBytesLen {
+		return nil, objects.ErrBytesLimit
+	}
+
+	return &objects.Bytes{Value: e.b}, nil
+}
+
+func msgpackDecode(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var data []byte
+	switch o := args[0].(type) {
+	case *objects.Bytes:
+		data = o.Value
+	case *objects.String:
+		data = []byte(o.Value)
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "bytes/string",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	d := &msgpackDecoder{b: data}
+	res, err := d.decode(0)
+	if err == nil && d.pos < len(d.b) {
+		err = fmt.Errorf("extra data at offset %d", d.pos)
+	}
+	if err != nil {
+		if isLimitError(err) {
+			return nil, err
+		}
+		return wrapError(err), nil
+	}
+
+	return res, nil
+}
+
+type msgpackEncoder struct {
+	b        []byte
+	visiting map[objects.Object]bool
+}
+
+func (e *msgpackEncoder) encode(o objects.Object) error {
+	switch o := o.(type) {
+	case *objects.Undefined:
+		e.b = append(e.b, 0xc0)
+	case *objects.Bool:
+		if o == objects.TrueValue {
+			e.b = append(e.b, 0xc3)
+		} else {
+			e.b = append(e.b, 0xc2)
+		}
+	case *objects.Int:
+		e.encodeInt(o.Value)
+	case *objects.Char:
+		e.encodeInt(int64(o.Value))
+	case *objects.BigInt:
+		if !o.Value.IsUint64() {
+			return fmt.Errorf("integer out of range: %s", o.Value.String())
+		}
+		e.encodeUint(o.Value.Uint64())
+	case *objects.Float:
+		e.b = append(e.b, 0xcb)
+		e.b = msgpackAppendUint(e.b, math.Float64bits(o.Value), 8)
+	case *objects.String:
+		if err := e.encodeHeader(len(o.Value), 0xa0, 32, 0xd9, 0xda); err != nil {
+			return err
+		}
+		e.b = append(e.b, o.Value...)
+	case *objects.Bytes:
+		if err := e.encodeHeader(len(o.Value), 0, 0, 0xc4, 0xc5); err != nil {
+			return err
+		}
+		e.b = append(e.b, o.Value...)
+	case *objects.Array:
+		return e.encodeArray(o, o.Value)
+	case *objects.ImmutableArray:
+		return e.encodeArray(o, o.Value)
+	case *objects.Map:
+		return e.encodeMap(o, o.Value)
+	case *objects.ImmutableMap:
+		return e.encodeMap(o, o.Value)
+	case *objects.Time:
+		e.encodeTime(o.Value)
+	default:
+		return fmt.Errorf("unsupported type: %s", o.TypeName())
+	}
+
+	return nil
+}
+
+func (e *msgpackEncoder) encodeInt(v int64) {
+	switch {
+	case v >= 0:
+		e.encodeUint(uint64(v))
+	case v >= -32:
+		e.b = append(e.b, byte(v))
+	case v >= math.MinInt8:
+		e.b = append(e.b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		e.b = msgpackAppendUint(append(e.b, 0xd1), uint64(v), 2)
+	case v >= math.MinInt32:
+		e.b = msgpackAppendUint(append(e.b, 0xd2), uint64(v), 4)
+	default:
+		e.b = msgpackAppendUint(append(e.b, 0xd3), uint64(v), 8)
+	}
+}
+
+func (e *msgpackEncoder) encodeUint(v uint64) {
+	switch {
+	case v < 0x80:
+		e.b = append(e.b, byte(v))
+	case v <= math.MaxUint8:
+		e.b = append(e.b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		e.b = msgpackAppendUint(append(e.b, 0xcd), v, 2)
+	case v <= math.MaxUint32:
+		e.b = msgpackAppendUint(append(e.b, 0xce), v, 4)
+	default:
+		e.b = msgpackAppendUint(append(e.b, 0xcf), v, 8)
+	}
+}
+
+// encodeHeader appends the header of a value of the length n. The lengths
+// less than fixMax use the fix format of the code fix. code8 is the code of
+// the 8 bit format (or 0 if there's none), and, code16 and code16+1 are the
+// codes of the 16 and 32 bit formats.
+func (e *msgpackEncoder) encodeHeader(n int, fix byte, fixMax int, code8, code16 byte) error {
+	switch {
+	case n < fixMax:
+		e.b = append(e.b, fix|byte(n))
+	case n <= math.MaxUint8 && code8 != 0:
+		e.b = append(e.b, code8, byte(n))
+	case n <= math.MaxUint16:
+		e.b = msgpackAppendUint(append(e.b, code16), uint64(n), 2)
+	case uint64(n) <= math.MaxUint32:
+		e.b = msgpackAppendUint(append(e.b, code16+1), uint64(n), 4)
+	default:
+		return fmt.Errorf("value too large: %d", n)
+	}
+
+	return nil
+}
+
+func (e *msgpackEncoder) encodeArray(o objects.Object, values []objects.Object) error {
+	if e.visiting[o] {
+		return errors.New("unsupported value: encountered a cycle")
+	}
+	e.visiting[o] = true
+	defer delete(e.visiting, o)
+
+	if err := e.encodeHeader(len(values), 0x90, 16, 0, 0xdc); err != nil {
+		return err
+	}
+	for _, v := range values {
+		if err := e.encode(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *msgpackEncoder) encodeMap(o objects.Object, values map[string]objects.Object) error {
+	if e.visiting[o] {
+		return errors.New("unsupported value: encountered a cycle")
+	}
+	e.visiting[o] = true
+	defer delete(e.visiting, o)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if err := e.encodeHeader(len(keys), 0x80, 16, 0, 0xde); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := e.encode(&objects.String{Value: k}); err != nil {
+			return err
+		}
+		if err := e.encode(values[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeTime appends the timestamp in the smallest of the 32, 64 and 96 bit
+// formats.
+func (e *msgpackEncoder) encodeTime(t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec <= math.MaxUint32 && nsec == 0:
+		e.b = append(e.b, 0xd6, 0xff)
+		e.b = msgpackAppendUint(e.b, uint64(sec), 4)
+	case sec >= 0 && sec < 1<<34:
+		e.b = append(e.b, 0xd7, 0xff)
+		e.b = msgpackAppendUint(e.b, nsec<<34|uint64(sec), 8)
+	default:
+		e.b = append(e.b, 0xc7, 12, 0xff)
+		e.b = msgpackAppendUint(e.b, nsec, 4)
+		e.b = msgpackAppendUint(e.b, uint64(sec), 8)
+	}
+}
+
+// msgpackAppendUint appends the n bytes of v in big endian.
+func msgpackAppendUint(b []byte, v uint64, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(v>>(uint(i)*8)))
+	}
+	return b
+}
+
+type msgpackDecoder struct {
+	b   []byte
+	pos int
+}
+
+func (d *msgpackDecoder) read(n uint64) ([]byte, error) {
+	if uint64(len(d.b)-d.pos) < n {
+		return nil, errMsgpackEOF
+	}
+	b := d.b[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// uint reads the n bytes unsigned integer in big endian.
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.read(uint64(n))
+	if err != nil {
+		return 0, err
+	}
+
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func (d *msgpackDecoder) decode(depth int) (objects.Object, error) {
+	if depth > msgpackMaxDepth {
+		return nil, errors.New("exceeding nesting depth limit")
+	}
+
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return objects.NewInt(int64(c)), nil
+	case c >= 0xe0:
+		return objects.NewInt(int64(int8(c))), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(uint64(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.decodeArray(uint64(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return d.decodeString(uint64(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return objects.UndefinedValue, nil
+	case 0xc2:
+		return objects.FalseValue, nil
+	case 0xc3:
+		return objects.TrueValue, nil
+	case 0xc4, 0xc5, 0xc6: // bin 8, 16, 32
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(objects.MaxBytesLen) {
+			return nil, objects.ErrBytesLimit
+		}
+		v, err := d.read(n)
+		if err != nil {
+			return nil, err
+		}
+		return &objects.Bytes{Value: append([]byte{}, v...)}, nil
+	case 0xc7, 0xc8, 0xc9: // ext 8, 16, 32
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(n)
+	case 0xca:
+		v, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return &objects.Float{Value: float64(math.Float32frombits(uint32(v)))}, nil
+	case 0xcb:
+		v, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return &objects.Float{Value: math.Float64frombits(v)}, nil
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8, 16, 32, 64
+		v, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if v > math.MaxInt64 {
+			return objects.NewBigInt(new(big.Int).SetUint64(v)), nil
+		}
+		return objects.NewInt(int64(v)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8, 16, 32, 64
+		n := 1 << (c - 0xd0)
+		v, err := d.uint(n)
+		if err != nil {
+			return nil, err
+		}
+		// sign extension
+		shift := uint(64 - n*8)
+		return objects.NewInt(int64(v<<shift) >> shift), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1, 2, 4, 8, 16
+		return d.decodeExt(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb: // str 8, 16, 32
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(n)
+	case 0xdc, 0xdd: // array 16, 32
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n, depth)
+	case 0xde, 0xdf: // map 16, 32
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n, depth)
+	}
+
+	return nil, fmt.Errorf("invalid code 0x%02x at offset %d", c, d.pos-1)
+}
+
+func (d *msgpackDecoder) decodeString(n uint64) (objects.Object, error) {
+	if n > uint64(objects.MaxStringLen) {
+		return nil, objects.ErrStringLimit
+	}
+	v, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	return &objects.String{Value: string(v)}, nil
+}
+
+func (d *msgpackDecoder) decodeArray(n uint64, depth int) (objects.Object, error) {
+	// every element is at least 1 byte
+	if n > uint64(len(d.b)-d.pos) {
+		return nil, errMsgpackEOF
+	}
+	if n > uint64(objects.MaxArrayLen) {
+		return nil, objects.ErrArrayLimit
+	}
+
+	values := make([]objects.Object, n)
+	for i := range values {
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	return &objects.Array{Value: values}, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n uint64, depth int) (objects.Object, error) {
+	// every key and value is at least 1 byte
+	if n > uint64(len(d.b)-d.pos)/2 {
+		return nil, errMsgpackEOF
+	}
+
+	values := make(map[string]objects.Object, n)
+	for i := uint64(0); i < n; i++ {
+		offset := d.pos
+		k, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := decodedKey(k)
+		if !ok {
+			return nil, fmt.Errorf("unsupported map key type %s at offset %d", k.TypeName(), offset)
+		}
+
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+
+	return &objects.Map{Value: values}, nil
+}
+
+// decodeExt decodes the extension type of the data of n bytes. The
+// timestamps are the only supported extension type.
+func (d *msgpackDecoder) decodeExt(n uint64) (objects.Object, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	typ := int8(b[0])
+
+	data, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	if typ != msgpackTimestamp {
+		return nil, fmt.Errorf("unsupported extension type %d", typ)
+	}
+
+	var sec, nsec int64
+	switch len(data) {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(data))
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		sec, nsec = int64(v&(1<<34-1)), int64(v>>34)
+	case 12:
+		nsec = int64(binary.BigEndian.Uint32(data))
+		sec = int64(binary.BigEndian.Uint64(data[4:]))
+	default:
+		return nil, fmt.Errorf("invalid timestamp length %d", len(data))
+	}
+	if nsec > 999999999 {
+		return nil, fmt.Errorf("invalid timestamp nanoseconds %d", nsec)
+	}
+
+	return &objects.Time{Value: time.Unix(sec, nsec).UTC()}, nil
+}
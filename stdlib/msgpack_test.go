@@ -0,0 +1,120 @@
+package stdlib_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestMsgpackEncode(t *testing.T) {
+	module(t, "msgpack").call("encode").expectError()
+
+	encode := func(v interface{}, expected []byte) {
+		module(t, "msgpack").call("encode", v).expect(expected, v)
+	}
+
+	encode(objects.UndefinedValue, []byte{0xc0})
+	encode(true, []byte{0xc3})
+	encode(false, []byte{0xc2})
+	encode(0, []byte{0x00})
+	encode(127, []byte{0x7f})
+	encode(200, []byte{0xcc, 0xc8})
+	encode(1000, []byte{0xcd, 0x03, 0xe8})
+	encode(70000, []byte{0xce, 0x00, 0x01, 0x11, 0x70})
+	encode(1<<32, []byte{0xcf, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00})
+	encode(-1, []byte{0xff})
+	encode(-32, []byte{0xe0})
+	encode(-33, []byte{0xd0, 0xdf})
+	encode(-129, []byte{0xd1, 0xff, 0x7f})
+	encode(-40000, []byte{0xd2, 0xff, 0xff, 0x63, 0xc0})
+	encode(-1<<40, []byte{0xd3, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00})
+	encode('x', []byte{0x78})
+	encode(&objects.BigInt{Value: new(big.Int).SetUint64(1 << 63)},
+		[]byte{0xcf, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	encode(1.5, []byte{0xcb, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	encode("", []byte{0xa0})
+	encode("a", []byte{0xa1, 'a'})
+	encode(strings.Repeat("a", 32), append([]byte{0xd9, 0x20}, strings.Repeat("a", 32)...))
+	encode(strings.Repeat("a", 256), append([]byte{0xda, 0x01, 0x00}, strings.Repeat("a", 256)...))
+	encode([]byte{1}, []byte{0xc4, 0x01, 0x01})
+	encode(ARR{}, []byte{0x90})
+	encode(ARR{1, "a", ARR{}}, []byte{0x93, 0x01, 0xa1, 'a', 0x90})
+	encode(IARR{true}, []byte{0x91, 0xc3})
+	encode(make([]int, 16), append([]byte{0xdc, 0x00, 0x10}, make([]byte, 16)...))
+	encode(MAP{"b": 1, "a": MAP{}}, []byte{0x82, 0xa1, 'a', 0x80, 0xa1, 'b', 0x01})
+	encode(IMAP{"a": objects.UndefinedValue}, []byte{0x81, 0xa1, 'a', 0xc0})
+
+	// the timestamps
+	encode(time.Unix(1, 0), []byte{0xd6, 0xff, 0x00, 0x00, 0x00, 0x01})
+	encode(time.Unix(1, 5), []byte{0xd7, 0xff, 0x00, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00, 0x01})
+	encode(time.Unix(-1, 0), []byte{0xc7, 0x0c, 0xff, 0x00, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	encodeError := func(v interface{}, msg string) {
+		module(t, "msgpack").call("encode", v).
+			expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+
+	a := &objects.Array{}
+	a.Value = []objects.Object{a}
+	encodeError(a, "unsupported value: encountered a cycle")
+	encodeError(MAP{"a": &objects.UserFunction{Name: "f"}}, "unsupported type: user-function:f")
+	encodeError(&objects.BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 64)}, "integer out of range: 18446744073709551616")
+}
+
+func TestMsgpackDecode(t *testing.T) {
+	module(t, "msgpack").call("decode").expectError()
+	module(t, "msgpack").call("decode", 1).expectError()
+
+	decode := func(data []byte, expected interface{}) {
+		module(t, "msgpack").call("decode", data).expect(expected, data)
+	}
+
+	decode([]byte{0xc0}, objects.UndefinedValue)
+	decode([]byte{0xc3}, true)
+	decode([]byte{0xe0}, -32)
+	decode([]byte{0xcc, 0xff}, 255)
+	decode([]byte{0xd0, 0x80}, -128)
+	decode([]byte{0xd1, 0x80, 0x00}, -32768)
+	decode([]byte{0xd3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, -1)
+	decode([]byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		&objects.BigInt{Value: new(big.Int).SetUint64(1<<64 - 1)})
+	decode([]byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, 1.5)
+	decode([]byte{0xdb, 0x00, 0x00, 0x00, 0x01, 'a'}, "a")
+	decode([]byte{0xc6, 0x00, 0x00, 0x00, 0x01, 0x00}, []byte{0})
+	decode([]byte{0xdd, 0x00, 0x00, 0x00, 0x01, 0x90}, ARR{ARR{}})
+	decode([]byte{0x82, 0x01, 0xa1, 'a', 0xc4, 0x01, 'b', 0x80}, MAP{"1": "a", "b": MAP{}})
+	decode([]byte{0xd7, 0xff, 0x00, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00, 0x01}, time.Unix(1, 5))
+	module(t, "msgpack").call("decode", "\xa1a").expect("a")
+
+	// the round trip
+	v := MAP{
+		"a": ARR{1, -1000, 1.25, "x", []byte("y"), true, objects.UndefinedValue},
+		"b": MAP{"c": MAP{"d": strings.Repeat("z", 70000)}},
+		"t": time.Unix(1<<35, 999999999),
+	}
+	data := module(t, "msgpack").call("encode", v)
+	assert.NoError(t, data.e)
+	decode(data.o.(*objects.Bytes).Value, v)
+
+	decodeError := func(data []byte, msg string) {
+		module(t, "msgpack").call("decode", data).
+			expect(&objects.Error{Value: &objects.String{Value: msg}}, data)
+	}
+
+	decodeError([]byte{}, "unexpected end of data")
+	decodeError([]byte{0xa2, 'a'}, "unexpected end of data")
+	decodeError([]byte{0xdc, 0xff, 0xff, 0x00}, "unexpected end of data")
+	decodeError([]byte{0xc1}, "invalid code 0xc1 at offset 0")
+	decodeError([]byte{0x01, 0x02}, "extra data at offset 1")
+	decodeError([]byte{0x81, 0xc0, 0xc0}, "unsupported map key type undefined at offset 1")
+	decodeError([]byte{0xd4, 0x05, 0x00}, "unsupported extension type 5")
+	decodeError([]byte{0xd4, 0xff, 0x00}, "invalid timestamp length 1")
+
+	deep := append([]byte(strings.Repeat("\x91", 10001)), 0x00)
+	decodeError(deep, "exceeding nesting depth limit")
+}
@@ -1,3 +1,6 @@
+//go:build !tengo_no_os && !tengo_embedded && !tinygo
+// +build !tengo_no_os,!tengo_embedded,!tinygo
+
 package stdlib
 
 import (
@@ -1,3 +1,6 @@
+//go:build !tengo_no_os && !tengo_embedded && !tinygo
+// +build !tengo_no_os,!tengo_embedded,!tinygo
+
 package stdlib_test
 
 import (
@@ -7,6 +10,7 @@ import (
 
 	"github.com/d5/tengo/assert"
 	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/stdlib"
 )
 
 func TestReadFile(t *testing.T) {
@@ -86,3 +90,15 @@ func TestFileStatDir(t *testing.T) {
 		},
 	})
 }
+
+func TestModulesWithArgs(t *testing.T) {
+	mod := stdlib.ModulesWithArgs([]string{"script.tengo", "foo"})["os"]
+	callres{t: t, o: (*mod).(*objects.BuiltinModule).Module()}.call("args").expect(ARR{"script.tengo", "foo"})
+
+	// os module of the standard modules is not changed
+	var osArgs ARR
+	for _, arg := range os.Args {
+		osArgs = append(osArgs, arg)
+	}
+	module(t, "os").call("args").expect(osArgs)
+}
@@ -0,0 +1,299 @@
+//go:build tengo_passwd && !tengo_no_passwd
+// +build tengo_passwd,!tengo_no_passwd
+
+package stdlib
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/d5/tengo/objects"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswdModule returns the members of passwd module. It's not registered by
+// default, even if the binary is built with the build tag "tengo_passwd":
+// the host application enables passwd module with Register.
+//
+//	stdlib.Register("passwd", stdlib.PasswdModule)
+func PasswdModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"hash":   &objects.UserFunction{Name: "hash", Value: passwdHash},     // hash(password, options) => string/error
+		"verify": &objects.UserFunction{Name: "verify", Value: passwdVerify}, // verify(password, hash) => bool/error
+		"salt":   &objects.UserFunction{Name: "salt", Value: passwdSalt},     // salt(size) => bytes/error
+	}
+}
+
+const (
+	// passwdSaltLen is the size of the argon2 salts and the default size of
+	// salt().
+	passwdSaltLen = 16
+
+	// passwdKeyLen is the size of the argon2 hashes.
+	passwdKeyLen = 32
+
+	// passwdMaxMemory is the maximum memory of argon2 in KiB (1 GiB), so the
+	// options or the hashes can't make the host allocate more.
+	passwdMaxMemory = 1 << 20
+
+	// passwdMaxTime is the maximum number of the passes of argon2.
+	passwdMaxTime = 100
+
+	// passwdMaxBcryptLen is the maximum size of the passwords of bcrypt.
+	passwdMaxBcryptLen = 72
+)
+
+// passwdOptions are the options of passwd.hash. The defaults of argon2 are the
+// recommended parameters of golang.org/x/crypto/argon2.
+type passwdOptions struct {
+	algorithm string
+	cost      int
+	time      uint32
+	memory    uint32
+	threads   uint8
+}
+
+// parse reads the options from the map.
+func (opts *passwdOptions) parse(o objects.Object) error {
+	var m map[string]objects.Object
+	switch o := o.(type) {
+	case *objects.Map:
+		m = o.Value
+	case *objects.ImmutableMap:
+		m = o.Value
+	default:
+		return objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "map",
+			Found:    o.TypeName(),
+		}
+	}
+
+	for k, v := range m {
+		if k == "algorithm" {
+			s, ok := v.(*objects.String)
+			if !ok || (s.Value != "argon2id" && s.Value != "bcrypt") {
+				return fmt.Errorf("invalid passwd option '%s': %s", k, v)
+			}
+			opts.algorithm = s.Value
+			continue
+		}
+
+		i, ok := v.(*objects.Int)
+		if !ok {
+			return fmt.Errorf("invalid passwd option '%s': %s", k, v)
+		}
+		switch k {
+		case "cost":
+			if i.Value < int64(bcrypt.MinCost) || i.Value > int64(bcrypt.MaxCost) {
+				return fmt.Errorf("invalid passwd option '%s': %s", k, v)
+			}
+			opts.cost = int(i.Value)
+		case "time":
+			if i.Value < 1 || i.Value > passwdMaxTime {
+				return fmt.Errorf("invalid passwd option '%s': %s", k, v)
+			}
+			opts.time = uint32(i.Value)
+		case "memory":
+			if i.Value < 8 || i.Value > passwdMaxMemory {
+				return fmt.Errorf("invalid passwd option '%s': %s", k, v)
+			}
+			opts.memory = uint32(i.Value)
+		case "threads":
+			if i.Value < 1 || i.Value > 255 {
+				return fmt.Errorf("invalid passwd option '%s': %s", k, v)
+			}
+			opts.threads = uint8(i.Value)
+		default:
+			return fmt.Errorf("unknown passwd option '%s'", k)
+		}
+	}
+
+	return nil
+}
+
+func passwdHash(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	password, err := passwdBytes(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &passwdOptions{
+		algorithm: "argon2id",
+		cost:      bcrypt.DefaultCost,
+		time:      1,
+		memory:    64 * 1024,
+		threads:   4,
+	}
+	if len(args) == 2 {
+		if err := opts.parse(args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.algorithm == "bcrypt" {
+		if len(password) > passwdMaxBcryptLen {
+			return wrapError(fmt.Errorf("password is longer than %d bytes", passwdMaxBcryptLen)), nil
+		}
+		hash, err := bcrypt.GenerateFromPassword(password, opts.cost)
+		if err != nil {
+			return wrapError(err), nil
+		}
+		return &objects.String{Value: string(hash)}, nil
+	}
+
+	salt := make([]byte, passwdSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return wrapError(err), nil
+	}
+	key := argon2.IDKey(password, salt, opts.time, opts.memory, opts.threads, passwdKeyLen)
+
+	return &objects.String{
+		Value: fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+			opts.memory, opts.time, opts.threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key)),
+	}, nil
+}
+
+func passwdVerify(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	password, err := passwdBytes(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := args[1].(*objects.String)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "string",
+			Found:    args[1].TypeName(),
+		}
+	}
+	hash := s.Value
+
+	var match bool
+	switch {
+	case strings.HasPrefix(hash, "$argon2"):
+		if match, err = passwdVerifyArgon2(password, hash); err != nil {
+			return wrapError(err), nil
+		}
+	case strings.HasPrefix(hash, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), password)
+		if err != nil && err != bcrypt.ErrMismatchedHashAndPassword {
+			return wrapError(fmt.Errorf("invalid hash: %s", strings.TrimPrefix(err.Error(), "crypto/bcrypt: "))), nil
+		}
+		match = err == nil
+	default:
+		return wrapError(errors.New("invalid hash: unsupported algorithm")), nil
+	}
+
+	if match {
+		return objects.TrueValue, nil
+	}
+	return objects.FalseValue, nil
+}
+
+// passwdVerifyArgon2 compares the password with the argon2 hash in the PHC
+// string format, e.g. "$argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>".
+func passwdVerifyArgon2(password []byte, hash string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" {
+		return false, errors.New("invalid hash: invalid format")
+	}
+
+	var kdf func(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte
+	switch parts[1] {
+	case "argon2id":
+		kdf = argon2.IDKey
+	case "argon2i":
+		kdf = argon2.Key
+	default:
+		return false, errors.New("invalid hash: unsupported algorithm")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, fmt.Errorf("invalid hash: unsupported version '%s'", parts[2])
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil ||
+		memory > passwdMaxMemory || time < 1 || time > passwdMaxTime || threads < 1 {
+		return false, fmt.Errorf("invalid hash: invalid parameters '%s'", parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.Strict().DecodeString(parts[4])
+	if err != nil {
+		return false, errors.New("invalid hash: invalid salt encoding")
+	}
+	key, err := base64.RawStdEncoding.Strict().DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, errors.New("invalid hash: invalid hash encoding")
+	}
+
+	actual := kdf(password, salt, time, memory, threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+func passwdSalt(args ...objects.Object) (objects.Object, error) {
+	if len(args) > 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	size := passwdSaltLen
+	if len(args) == 1 {
+		i, ok := args[0].(*objects.Int)
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "first",
+				Expected: "int",
+				Found:    args[0].TypeName(),
+			}
+		}
+		if i.Value < 1 {
+			return wrapError(fmt.Errorf("invalid size: %d", i.Value)), nil
+		}
+		if i.Value > int64(objects.MaxBytesLen) {
+			return nil, objects.ErrBytesLimit
+		}
+		size = int(i.Value)
+	}
+
+	salt := make([]byte, size)
+	if _, err := rand.Read(salt); err != nil {
+		return wrapError(err), nil
+	}
+
+	return &objects.Bytes{Value: salt}, nil
+}
+
+func passwdBytes(o objects.Object, argName string) ([]byte, error) {
+	switch o := o.(type) {
+	case *objects.String:
+		return []byte(o.Value), nil
+	case *objects.Bytes:
+		return o.Value, nil
+	}
+
+	return nil, objects.ErrInvalidArgumentType{
+		Name:     argName,
+		Expected: "string/bytes",
+		Found:    o.TypeName(),
+	}
+}
@@ -0,0 +1,112 @@
+//go:build tengo_passwd && !tengo_no_passwd
+// +build tengo_passwd,!tengo_no_passwd
+
+package stdlib_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/stdlib"
+)
+
+// passwdDefault is true if passwd module is registered before the tests
+// register it.
+var passwdDefault = func() bool {
+	_, ok := stdlib.Modules["passwd"]
+	return ok
+}()
+
+func init() {
+	stdlib.Register("passwd", stdlib.PasswdModule)
+}
+
+func TestPasswdModule(t *testing.T) {
+	// the host application enables passwd module
+	assert.False(t, passwdDefault)
+}
+
+func TestPasswdHash(t *testing.T) {
+	module(t, "passwd").call("hash").expectError()
+	module(t, "passwd").call("hash", 1).expectError()
+	module(t, "passwd").call("hash", "a", 1).expectError()
+	module(t, "passwd").call("hash", "a", MAP{"salt": "x"}).expectError()
+	module(t, "passwd").call("hash", "a", MAP{"algorithm": "md5"}).expectError()
+	module(t, "passwd").call("hash", "a", MAP{"cost": 3}).expectError()
+	module(t, "passwd").call("hash", "a", MAP{"time": 0}).expectError()
+	module(t, "passwd").call("hash", "a", MAP{"memory": 1 << 30}).expectError()
+	module(t, "passwd").call("hash", "a", MAP{"threads": 256}).expectError()
+
+	hash := func(password interface{}, opts MAP) string {
+		res := module(t, "passwd").call("hash", password, opts)
+		assert.NoError(t, res.e)
+		return res.o.(*objects.String).Value
+	}
+
+	argon := hash("secret", MAP{"memory": 64, "threads": 1})
+	assert.True(t, strings.HasPrefix(argon, "$argon2id$v=19$m=64,t=1,p=1$"), argon)
+	assert.Equal(t, 6, len(strings.Split(argon, "$")))
+	assert.True(t, argon != hash("secret", MAP{"memory": 64, "threads": 1}))
+	module(t, "passwd").call("verify", "secret", argon).expect(true)
+	module(t, "passwd").call("verify", []byte("secret"), argon).expect(true)
+	module(t, "passwd").call("verify", "Secret", argon).expect(false)
+
+	bcrypt := hash([]byte("secret"), MAP{"algorithm": "bcrypt", "cost": 4})
+	assert.True(t, strings.HasPrefix(bcrypt, "$2a$04$"), bcrypt)
+	module(t, "passwd").call("verify", "secret", bcrypt).expect(true)
+	module(t, "passwd").call("verify", "Secret", bcrypt).expect(false)
+	module(t, "passwd").call("hash", strings.Repeat("a", 73), MAP{"algorithm": "bcrypt", "cost": 4}).
+		expect(&objects.Error{Value: &objects.String{Value: "password is longer than 72 bytes"}})
+
+	// the defaults
+	assert.True(t, strings.HasPrefix(hash("", MAP{}), "$argon2id$v=19$m=65536,t=1,p=4$"))
+}
+
+func TestPasswdVerify(t *testing.T) {
+	module(t, "passwd").call("verify", "a").expectError()
+	module(t, "passwd").call("verify", 1, "a").expectError()
+	module(t, "passwd").call("verify", "a", ARR{}).expectError()
+
+	// the hashes of the other implementations
+	module(t, "passwd").call("verify", "password",
+		"$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc").expect(true)
+	module(t, "passwd").call("verify", "password",
+		"$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$wWKIMhR9lyDFvRz9YTZweHKfbftvj+qf+YFY4NeBbtA").expect(true)
+	module(t, "passwd").call("verify", "U*U",
+		"$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW").expect(true)
+
+	verifyError := func(hash, msg string) {
+		module(t, "passwd").call("verify", "password", hash).
+			expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	verifyError("password", "invalid hash: unsupported algorithm")
+	verifyError("$argon2d$v=19$m=64,t=1,p=1$c29tZXNhbHQ$AAAA", "invalid hash: unsupported algorithm")
+	verifyError("$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ", "invalid hash: invalid format")
+	verifyError("$argon2id$v=16$m=64,t=1,p=1$c29tZXNhbHQ$AAAA", "invalid hash: unsupported version 'v=16'")
+	verifyError("$argon2id$v=19$m=4194304,t=1,p=1$c29tZXNhbHQ$AAAA", "invalid hash: invalid parameters 'm=4194304,t=1,p=1'")
+	verifyError("$argon2id$v=19$m=64,t=0,p=1$c29tZXNhbHQ$AAAA", "invalid hash: invalid parameters 'm=64,t=0,p=1'")
+	verifyError("$argon2id$v=19$m=64,t=1,p=1$!$AAAA", "invalid hash: invalid salt encoding")
+	verifyError("$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ$", "invalid hash: invalid hash encoding")
+	verifyError("$2a$05$short", "invalid hash: hashedSecret too short to be a bcrypted password")
+}
+
+func TestPasswdSalt(t *testing.T) {
+	module(t, "passwd").call("salt", 1, 2).expectError()
+	module(t, "passwd").call("salt", "a").expectError()
+	module(t, "passwd").call("salt", 0).
+		expect(&objects.Error{Value: &objects.String{Value: "invalid size: 0"}})
+
+	res := module(t, "passwd").call("salt")
+	assert.NoError(t, res.e)
+	assert.Equal(t, 16, len(res.o.(*objects.Bytes).Value))
+
+	res = module(t, "passwd").call("salt", 32)
+	assert.NoError(t, res.e)
+	assert.Equal(t, 32, len(res.o.(*objects.Bytes).Value))
+	other := module(t, "passwd").call("salt", 32)
+	assert.NoError(t, other.e)
+	assert.False(t, bytes.Equal(res.o.(*objects.Bytes).Value, other.o.(*objects.Bytes).Value))
+}
@@ -0,0 +1,1017 @@
+//go:build !tengo_no_proto
+// +build !tengo_no_proto
+
+package stdlib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("proto", protoModule)
+}
+
+func protoModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"load": &objects.UserFunction{Name: "load", Value: protoLoad}, // load(descriptor_set) => registry/error
+	}
+}
+
+func protoLoad(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	b, ok := args[0].(*objects.Bytes)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "bytes",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	r, err := newProtoRegistry(b.Value)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	return makeProtoRegistry(r), nil
+}
+
+func makeProtoRegistry(r *protoRegistry) *objects.ImmutableMap {
+	return &objects.ImmutableMap{
+		Value: map[string]objects.Object{
+			// encode(name, value) => bytes/error
+			"encode": &objects.UserFunction{
+				Name: "encode",
+				Value: func(args ...objects.Object) (objects.Object, error) {
+					if len(args) != 2 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					msg, err := r.messageArg(args[0])
+					if err != nil {
+						return nil, err
+					}
+
+					fields, ok := toStringMap(args[1])
+					if !ok {
+						return nil, objects.ErrInvalidArgumentType{
+							Name:     "second",
+							Expected: "map",
+							Found:    args[1].TypeName(),
+						}
+					}
+
+					b, err := msg.encode(nil, fields)
+					if err != nil {
+						return wrapError(err), nil
+					}
+					if len(b) > objects.MaxBytesLen {
+						return nil, objects.ErrBytesLimit
+					}
+
+					return &objects.Bytes{Value: b}, nil
+				},
+			},
+			// decode(name, data) => map/error
+			"decode": &objects.UserFunction{
+				Name: "decode",
+				Value: func(args ...objects.Object) (objects.Object, error) {
+					if len(args) != 2 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					msg, err := r.messageArg(args[0])
+					if err != nil {
+						return nil, err
+					}
+
+					b, ok := args[1].(*objects.Bytes)
+					if !ok {
+						return nil, objects.ErrInvalidArgumentType{
+							Name:     "second",
+							Expected: "bytes",
+							Found:    args[1].TypeName(),
+						}
+					}
+
+					res, err := msg.decode(b.Value, 0)
+					if err != nil {
+						return wrapError(err), nil
+					}
+
+					return res, nil
+				},
+			},
+			// messages() => [string]
+			"messages": &objects.UserFunction{
+				Name: "messages",
+				Value: func(args ...objects.Object) (objects.Object, error) {
+					if len(args) != 0 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					var names []string
+					for name, msg := range r.messages {
+						if !msg.mapEntry {
+							names = append(names, name)
+						}
+					}
+					sort.Strings(names)
+
+					res := make([]objects.Object, len(names))
+					for i, name := range names {
+						res[i] = &objects.String{Value: name}
+					}
+
+					return &objects.Array{Value: res}, nil
+				},
+			},
+		},
+	}
+}
+
+// the types of the fields in the descriptors
+const (
+	protoDouble   = 1
+	protoFloat    = 2
+	protoInt64    = 3
+	protoUint64   = 4
+	protoInt32    = 5
+	protoFixed64  = 6
+	protoFixed32  = 7
+	protoBool     = 8
+	protoString   = 9
+	protoGroup    = 10
+	protoMessage  = 11
+	protoBytes    = 12
+	protoUint32   = 13
+	protoEnum     = 14
+	protoSfixed32 = 15
+	protoSfixed64 = 16
+	protoSint32   = 17
+	protoSint64   = 18
+)
+
+// the wire types
+const (
+	protoVarint = 0
+	protoI64    = 1
+	protoLen    = 2
+	protoI32    = 5
+)
+
+// protoMaxDepth is the maximum depth of the nested messages to decode.
+const protoMaxDepth = 100
+
+var errProtoTruncated = errors.New("truncated message")
+
+// protoRegistry is the messages and the enums of a descriptor set by their
+// full names.
+type protoRegistry struct {
+	messages map[string]*protoMessageType
+	enums    map[string]*protoEnumType
+}
+
+type protoMessageType struct {
+	name     string
+	fields   []*protoField // in the order of the numbers
+	byNumber map[int32]*protoField
+	byName   map[string]*protoField
+	mapEntry bool
+}
+
+type protoField struct {
+	name     string
+	jsonName string
+	number   int32
+	typ      int32
+	typeName string
+	repeated bool
+	packed   bool
+	message  *protoMessageType // message fields
+	enum     *protoEnumType    // enum fields
+}
+
+type protoEnumType struct {
+	names   map[int32]string
+	numbers map[string]int32
+}
+
+// newProtoRegistry reads a FileDescriptorSet.
+func newProtoRegistry(b []byte) (*protoRegistry, error) {
+	r := &protoRegistry{
+		messages: make(map[string]*protoMessageType),
+		enums:    make(map[string]*protoEnumType),
+	}
+
+	var files [][]byte
+	err := protoEachField(b, func(num int32, wire int, v uint64, data []byte) error {
+		if num == 1 && wire == protoLen { // file
+			files = append(files, data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %s", err.Error())
+	}
+
+	var fields []*protoField
+	for _, file := range files {
+		fileFields, err := r.addFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid descriptor set: %s", err.Error())
+		}
+		fields = append(fields, fileFields...)
+	}
+
+	// the types of the fields can be in the other files
+	for _, f := range fields {
+		switch f.typ {
+		case protoMessage:
+			if f.message = r.messages[f.typeName]; f.message == nil {
+				return nil, fmt.Errorf("unknown message type: %s", f.typeName)
+			}
+		case protoEnum:
+			if f.enum = r.enums[f.typeName]; f.enum == nil {
+				return nil, fmt.Errorf("unknown enum type: %s", f.typeName)
+			}
+		}
+
+		if f.isMap() && (f.message.byNumber[1] == nil || f.message.byNumber[2] == nil) {
+			return nil, fmt.Errorf("invalid map entry type: %s", f.typeName)
+		}
+	}
+
+	return r, nil
+}
+
+// addFile reads a FileDescriptorProto, and, returns its fields.
+func (r *protoRegistry) addFile(b []byte) ([]*protoField, error) {
+	var pkg, syntax string
+	var messages, enums [][]byte
+	err := protoEachField(b, func(num int32, wire int, v uint64, data []byte) error {
+		if wire != protoLen {
+			return nil
+		}
+
+		switch num {
+		case 2: // package
+			pkg = string(data)
+		case 4: // message_type
+			messages = append(messages, data)
+		case 5: // enum_type
+			enums = append(enums, data)
+		case 12: // syntax
+			syntax = string(data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the repeated scalars are packed by default since proto3
+	packed := syntax != "" && syntax != "proto2"
+
+	for _, e := range enums {
+		if err := r.addEnum(pkg, e); err != nil {
+			return nil, err
+		}
+	}
+
+	var fields []*protoField
+	for _, m := range messages {
+		messageFields, err := r.addMessage(pkg, m, packed)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, messageFields...)
+	}
+
+	return fields, nil
+}
+
+// addMessage reads a DescriptorProto and its nested types, and, returns
+// their fields.
+func (r *protoRegistry) addMessage(scope string, b []byte, packed bool) ([]*protoField, error) {
+	msg := &protoMessageType{
+		byNumber: make(map[int32]*protoField),
+		byName:   make(map[string]*protoField),
+	}
+
+	var name string
+	var fields, nested, enums [][]byte
+	err := protoEachField(b, func(num int32, wire int, v uint64, data []byte) error {
+		if wire != protoLen {
+			return nil
+		}
+
+		switch num {
+		case 1: // name
+			name = string(data)
+		case 2: // field
+			fields = append(fields, data)
+		case 3: // nested_type
+			nested = append(nested, data)
+		case 4: // enum_type
+			enums = append(enums, data)
+		case 7: // options
+			return protoEachField(data, func(num int32, wire int, v uint64, data []byte) error {
+				if num == 7 && wire == protoVarint { // map_entry
+					msg.mapEntry = v != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg.name = protoFullName(scope, name)
+	r.messages[msg.name] = msg
+
+	for _, e := range enums {
+		if err := r.addEnum(msg.name, e); err != nil {
+			return nil, err
+		}
+	}
+
+	var res []*protoField
+	for _, fb := range fields {
+		f, err := newProtoField(fb, packed)
+		if err != nil {
+			return nil, err
+		}
+		if f.typ == protoGroup {
+			return nil, fmt.Errorf("unsupported group field: %s.%s", msg.name, f.name)
+		}
+
+		msg.fields = append(msg.fields, f)
+		msg.byNumber[f.number] = f
+		msg.byName[f.name] = f
+		res = append(res, f)
+	}
+	sort.Slice(msg.fields, func(i, j int) bool {
+		return msg.fields[i].number < msg.fields[j].number
+	})
+
+	for _, m := range nested {
+		nestedFields, err := r.addMessage(msg.name, m, packed)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, nestedFields...)
+	}
+
+	return res, nil
+}
+
+// newProtoField reads a FieldDescriptorProto.
+func newProtoField(b []byte, packed bool) (*protoField, error) {
+	f := &protoField{}
+	explicitPacked := -1
+	err := protoEachField(b, func(num int32, wire int, v uint64, data []byte) error {
+		switch num {
+		case 1: // name
+			f.name = string(data)
+		case 3: // number
+			f.number = int32(v)
+		case 4: // label
+			f.repeated = v == 3
+		case 5: // type
+			f.typ = int32(v)
+		case 6: // type_name
+			f.typeName = strings.TrimPrefix(string(data), ".")
+		case 8: // options
+			if wire == protoLen {
+				return protoEachField(data, func(num int32, wire int, v uint64, data []byte) error {
+					if num == 2 && wire == protoVarint { // packed
+						explicitPacked = int(v)
+					}
+					return nil
+				})
+			}
+		case 10: // json_name
+			f.jsonName = string(data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if f.name == "" || f.number <= 0 || f.typ < protoDouble || f.typ > protoSint64 {
+		return nil, fmt.Errorf("invalid field: %s", f.name)
+	}
+
+	if explicitPacked >= 0 {
+		packed = explicitPacked != 0
+	}
+	f.packed = f.repeated && packed && protoIsPackable(f.typ)
+
+	return f, nil
+}
+
+// addEnum reads an EnumDescriptorProto.
+func (r *protoRegistry) addEnum(scope string, b []byte) error {
+	e := &protoEnumType{
+		names:   make(map[int32]string),
+		numbers: make(map[string]int32),
+	}
+
+	var name string
+	err := protoEachField(b, func(num int32, wire int, v uint64, data []byte) error {
+		switch {
+		case num == 1 && wire == protoLen: // name
+			name = string(data)
+		case num == 2 && wire == protoLen: // value
+			var valueName string
+			var number int32
+			err := protoEachField(data, func(num int32, wire int, v uint64, data []byte) error {
+				switch num {
+				case 1:
+					valueName = string(data)
+				case 2:
+					number = int32(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			// the first name of the aliases is used
+			if _, ok := e.names[number]; !ok {
+				e.names[number] = valueName
+			}
+			e.numbers[valueName] = number
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.enums[protoFullName(scope, name)] = e
+
+	return nil
+}
+
+func (r *protoRegistry) messageArg(o objects.Object) (*protoMessageType, error) {
+	name, ok := o.(*objects.String)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string",
+			Found:    o.TypeName(),
+		}
+	}
+
+	msg, ok := r.messages[strings.TrimPrefix(name.Value, ".")]
+	if !ok {
+		return nil, fmt.Errorf("unknown message type: %s", name.Value)
+	}
+
+	return msg, nil
+}
+
+// encode appends the message of the field values to b.
+func (msg *protoMessageType) encode(b []byte, values map[string]objects.Object) ([]byte, error) {
+	set := make(map[*protoField]objects.Object, len(values))
+	for k, v := range values {
+		f := msg.field(k)
+		if f == nil {
+			return nil, fmt.Errorf("unknown field '%s' in %s", k, msg.name)
+		}
+		if v != objects.UndefinedValue {
+			set[f] = v
+		}
+	}
+
+	var err error
+	for _, f := range msg.fields {
+		v, ok := set[f]
+		if !ok {
+			continue
+		}
+
+		if b, err = f.encode(b, v); err != nil {
+			return nil, fmt.Errorf("%s.%s: %s", msg.name, f.name, err.Error())
+		}
+	}
+
+	return b, nil
+}
+
+// field returns the field of the name or the JSON name.
+func (msg *protoMessageType) field(name string) *protoField {
+	if f, ok := msg.byName[name]; ok {
+		return f
+	}
+
+	for _, f := range msg.fields {
+		if f.jsonName == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// encode appends the field of the value to b.
+func (f *protoField) encode(b []byte, v objects.Object) ([]byte, error) {
+	if f.isMap() {
+		entries, ok := toStringMap(v)
+		if !ok {
+			return nil, fmt.Errorf("expected map, found %s", v.TypeName())
+		}
+
+		keys := make([]string, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		keyField, valueField := f.message.byNumber[1], f.message.byNumber[2]
+		for _, k := range keys {
+			key, err := keyField.parseKey(k)
+			if err != nil {
+				return nil, err
+			}
+
+			var entry []byte
+			if entry, err = keyField.encode(entry, key); err != nil {
+				return nil, err
+			}
+			if entry, err = valueField.encode(entry, entries[k]); err != nil {
+				return nil, err
+			}
+
+			b = protoAppendTag(b, f.number, protoLen)
+			b = protoAppendVarint(b, uint64(len(entry)))
+			b = append(b, entry...)
+		}
+
+		return b, nil
+	}
+
+	if !f.repeated {
+		return f.encodeValue(b, v, true)
+	}
+
+	elems, ok := toArray(v)
+	if !ok {
+		return nil, fmt.Errorf("expected array, found %s", v.TypeName())
+	}
+
+	if f.packed {
+		if len(elems) == 0 {
+			return b, nil
+		}
+
+		var packed []byte
+		for _, e := range elems {
+			var err error
+			if packed, err = f.encodeValue(packed, e, false); err != nil {
+				return nil, err
+			}
+		}
+
+		b = protoAppendTag(b, f.number, protoLen)
+		b = protoAppendVarint(b, uint64(len(packed)))
+
+		return append(b, packed...), nil
+	}
+
+	for _, e := range elems {
+		var err error
+		if b, err = f.encodeValue(b, e, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// encodeValue appends a value of the field type to b, with the tag if tag
+// is true.
+func (f *protoField) encodeValue(b []byte, v objects.Object, tag bool) ([]byte, error) {
+	if tag {
+		b = protoAppendTag(b, f.number, protoWireType(f.typ))
+	}
+
+	switch f.typ {
+	case protoDouble, protoFloat:
+		var x float64
+		switch v := v.(type) {
+		case *objects.Float:
+			x = v.Value
+		case *objects.Int:
+			x = float64(v.Value)
+		default:
+			return nil, fmt.Errorf("expected float, found %s", v.TypeName())
+		}
+
+		if f.typ == protoFloat {
+			return protoAppendFixed32(b, math.Float32bits(float32(x))), nil
+		}
+		return protoAppendFixed64(b, math.Float64bits(x)), nil
+	case protoBool:
+		x, ok := v.(*objects.Bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool, found %s", v.TypeName())
+		}
+		if x.IsFalsy() {
+			return protoAppendVarint(b, 0), nil
+		}
+		return protoAppendVarint(b, 1), nil
+	case protoString, protoBytes:
+		var x []byte
+		switch v := v.(type) {
+		case *objects.String:
+			x = []byte(v.Value)
+		case *objects.Bytes:
+			x = v.Value
+		default:
+			return nil, fmt.Errorf("expected string or bytes, found %s", v.TypeName())
+		}
+		b = protoAppendVarint(b, uint64(len(x)))
+		return append(b, x...), nil
+	case protoMessage:
+		fields, ok := toStringMap(v)
+		if !ok {
+			return nil, fmt.Errorf("expected map, found %s", v.TypeName())
+		}
+
+		// the length is not known until the message is encoded
+		m, err := f.message.encode(nil, fields)
+		if err != nil {
+			return nil, err
+		}
+		b = protoAppendVarint(b, uint64(len(m)))
+		return append(b, m...), nil
+	case protoEnum:
+		var x int32
+		switch v := v.(type) {
+		case *objects.String:
+			n, ok := f.enum.numbers[v.Value]
+			if !ok {
+				return nil, fmt.Errorf("unknown enum value: %s", v.Value)
+			}
+			x = n
+		case *objects.Int:
+			x = int32(v.Value)
+		default:
+			return nil, fmt.Errorf("expected string or int, found %s", v.TypeName())
+		}
+		return protoAppendVarint(b, uint64(int64(x))), nil
+	}
+
+	// the integers
+	i, ok := v.(*objects.Int)
+	if !ok {
+		return nil, fmt.Errorf("expected int, found %s", v.TypeName())
+	}
+
+	x := i.Value
+	switch f.typ {
+	case protoInt32:
+		return protoAppendVarint(b, uint64(int64(int32(x)))), nil
+	case protoUint32:
+		return protoAppendVarint(b, uint64(uint32(x))), nil
+	case protoSint32:
+		return protoAppendVarint(b, uint64(uint32((int32(x)<<1)^(int32(x)>>31)))), nil
+	case protoSint64:
+		return protoAppendVarint(b, uint64((x<<1)^(x>>63))), nil
+	case protoFixed32, protoSfixed32:
+		return protoAppendFixed32(b, uint32(x)), nil
+	case protoFixed64, protoSfixed64:
+		return protoAppendFixed64(b, uint64(x)), nil
+	}
+
+	// int64, uint64
+	return protoAppendVarint(b, uint64(x)), nil
+}
+
+// parseKey returns the value of a map key. The keys of the maps of the
+// script are strings.
+func (f *protoField) parseKey(k string) (objects.Object, error) {
+	switch f.typ {
+	case protoString:
+		return &objects.String{Value: k}, nil
+	case protoBool:
+		switch k {
+		case "true":
+			return objects.TrueValue, nil
+		case "false":
+			return objects.FalseValue, nil
+		}
+	default:
+		if i, err := strconv.ParseInt(k, 10, 64); err == nil {
+			return &objects.Int{Value: i}, nil
+		}
+		if u, err := strconv.ParseUint(k, 10, 64); err == nil {
+			return &objects.Int{Value: int64(u)}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid map key: %s", k)
+}
+
+func (f *protoField) isMap() bool {
+	return f.repeated && f.typ == protoMessage && f.message.mapEntry
+}
+
+// decode returns the field values of the message.
+func (msg *protoMessageType) decode(b []byte, depth int) (*objects.Map, error) {
+	if depth > protoMaxDepth {
+		return nil, errors.New("exceeding nesting depth limit")
+	}
+
+	res := &objects.Map{Value: make(map[string]objects.Object)}
+	err := protoEachField(b, func(num int32, wire int, v uint64, data []byte) error {
+		f, ok := msg.byNumber[num]
+		if !ok {
+			// the unknown fields are skipped
+			return nil
+		}
+
+		if err := f.decode(res.Value, wire, v, data, depth); err != nil {
+			return fmt.Errorf("%s.%s: %s", msg.name, f.name, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// decode sets the field value in the values.
+func (f *protoField) decode(values map[string]objects.Object, wire int, v uint64, data []byte, depth int) error {
+	if f.isMap() {
+		entry, err := f.message.decode(data, depth+1)
+		if err != nil {
+			return err
+		}
+
+		key, ok := entry.Value[f.message.byNumber[1].name]
+		if !ok {
+			key = f.message.byNumber[1].zero()
+		}
+		value, ok := entry.Value[f.message.byNumber[2].name]
+		if !ok {
+			value = f.message.byNumber[2].zero()
+		}
+
+		entries, ok := values[f.name].(*objects.Map)
+		if !ok {
+			entries = &objects.Map{Value: make(map[string]objects.Object)}
+			values[f.name] = entries
+		}
+		if s, ok := key.(*objects.String); ok {
+			entries.Value[s.Value] = value
+		} else {
+			entries.Value[key.String()] = value
+		}
+
+		return nil
+	}
+
+	if !f.repeated {
+		x, err := f.decodeValue(wire, v, data, depth)
+		if err != nil {
+			return err
+		}
+		values[f.name] = x
+
+		return nil
+	}
+
+	elems, ok := values[f.name].(*objects.Array)
+	if !ok {
+		elems = &objects.Array{}
+		values[f.name] = elems
+	}
+
+	// the packed scalars are accepted even if the field is not packed
+	if wire == protoLen && protoIsPackable(f.typ) {
+		for len(data) > 0 {
+			var n int
+			switch protoWireType(f.typ) {
+			case protoVarint:
+				if v, n = binary.Uvarint(data); n <= 0 {
+					return errProtoTruncated
+				}
+			case protoI32:
+				if len(data) < 4 {
+					return errProtoTruncated
+				}
+				v, n = uint64(binary.LittleEndian.Uint32(data)), 4
+			case protoI64:
+				if len(data) < 8 {
+					return errProtoTruncated
+				}
+				v, n = binary.LittleEndian.Uint64(data), 8
+			}
+
+			x, err := f.decodeValue(protoWireType(f.typ), v, nil, depth)
+			if err != nil {
+				return err
+			}
+			elems.Value = append(elems.Value, x)
+			data = data[n:]
+		}
+
+		return nil
+	}
+
+	x, err := f.decodeValue(wire, v, data, depth)
+	if err != nil {
+		return err
+	}
+	elems.Value = append(elems.Value, x)
+
+	return nil
+}
+
+// decodeValue returns a value of the field type.
+func (f *protoField) decodeValue(wire int, v uint64, data []byte, depth int) (objects.Object, error) {
+	if expected := protoWireType(f.typ); wire != expected {
+		return nil, fmt.Errorf("invalid wire type %d", wire)
+	}
+
+	switch f.typ {
+	case protoDouble:
+		return &objects.Float{Value: math.Float64frombits(v)}, nil
+	case protoFloat:
+		return &objects.Float{Value: float64(math.Float32frombits(uint32(v)))}, nil
+	case protoBool:
+		if v != 0 {
+			return objects.TrueValue, nil
+		}
+		return objects.FalseValue, nil
+	case protoString:
+		return &objects.String{Value: string(data)}, nil
+	case protoBytes:
+		return &objects.Bytes{Value: append([]byte{}, data...)}, nil
+	case protoMessage:
+		return f.message.decode(data, depth+1)
+	case protoEnum:
+		if name, ok := f.enum.names[int32(v)]; ok {
+			return &objects.String{Value: name}, nil
+		}
+		return &objects.Int{Value: int64(int32(v))}, nil
+	case protoInt32, protoSfixed32:
+		return &objects.Int{Value: int64(int32(v))}, nil
+	case protoUint32, protoFixed32:
+		return &objects.Int{Value: int64(uint32(v))}, nil
+	case protoSint32:
+		return &objects.Int{Value: int64(int32(uint32(v)>>1) ^ -int32(v&1))}, nil
+	case protoSint64:
+		return &objects.Int{Value: int64(v>>1) ^ -int64(v&1)}, nil
+	}
+
+	// int64, uint64, fixed64, sfixed64
+	return &objects.Int{Value: int64(v)}, nil
+}
+
+// zero returns the default value of the field type.
+func (f *protoField) zero() objects.Object {
+	switch f.typ {
+	case protoDouble, protoFloat:
+		return &objects.Float{Value: 0}
+	case protoBool:
+		return objects.FalseValue
+	case protoString:
+		return &objects.String{Value: ""}
+	case protoBytes:
+		return &objects.Bytes{Value: []byte{}}
+	case protoMessage:
+		return &objects.Map{Value: make(map[string]objects.Object)}
+	case protoEnum:
+		if name, ok := f.enum.names[0]; ok {
+			return &objects.String{Value: name}
+		}
+	}
+
+	return &objects.Int{Value: 0}
+}
+
+// protoEachField calls fn with each field of the message in b: the value of
+// the varint and the fixed fields, or, the data of the length-delimited
+// fields.
+func protoEachField(b []byte, fn func(num int32, wire int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errProtoTruncated
+		}
+		b = b[n:]
+
+		num, wire := int32(tag>>3), int(tag&7)
+		if num <= 0 {
+			return fmt.Errorf("invalid field number %d", tag>>3)
+		}
+
+		var v uint64
+		var data []byte
+		switch wire {
+		case protoVarint:
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return errProtoTruncated
+			}
+		case protoI64:
+			if len(b) < 8 {
+				return errProtoTruncated
+			}
+			v, n = binary.LittleEndian.Uint64(b), 8
+		case protoI32:
+			if len(b) < 4 {
+				return errProtoTruncated
+			}
+			v, n = uint64(binary.LittleEndian.Uint32(b)), 4
+		case protoLen:
+			size, m := binary.Uvarint(b)
+			if m <= 0 || size > uint64(len(b)-m) {
+				return errProtoTruncated
+			}
+			data, n = b[m:m+int(size)], m+int(size)
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+		b = b[n:]
+
+		if err := fn(num, wire, v, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func protoWireType(typ int32) int {
+	switch typ {
+	case protoDouble, protoFixed64, protoSfixed64:
+		return protoI64
+	case protoFloat, protoFixed32, protoSfixed32:
+		return protoI32
+	case protoString, protoBytes, protoMessage:
+		return protoLen
+	}
+
+	return protoVarint
+}
+
+func protoIsPackable(typ int32) bool {
+	return protoWireType(typ) != protoLen
+}
+
+func protoAppendTag(b []byte, num int32, wire int) []byte {
+	return protoAppendVarint(b, uint64(num)<<3|uint64(wire))
+}
+
+func protoAppendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+
+	return append(b, byte(v))
+}
+
+func protoAppendFixed32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+
+	return append(b, buf[:]...)
+}
+
+func protoAppendFixed64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+
+	return append(b, buf[:]...)
+}
+
+func protoFullName(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+
+	return scope + "." + name
+}
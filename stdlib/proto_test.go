@@ -0,0 +1,202 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+// pb builds the protobuf messages of the descriptors for testing.
+type pb []byte
+
+func (b pb) varint(num int, v uint64) pb {
+	b = pb(pbVarint([]byte(b), uint64(num)<<3))
+	return pb(pbVarint([]byte(b), v))
+}
+
+func (b pb) bytes(num int, data []byte) pb {
+	b = pb(pbVarint([]byte(b), uint64(num)<<3|2))
+	b = pb(pbVarint([]byte(b), uint64(len(data))))
+	return append(b, data...)
+}
+
+func (b pb) str(num int, s string) pb {
+	return b.bytes(num, []byte(s))
+}
+
+func pbVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// pbField returns a FieldDescriptorProto.
+func pbField(name string, number, label, typ int, typeName string) pb {
+	f := pb{}.str(1, name).varint(3, uint64(number)).varint(4, uint64(label)).varint(5, uint64(typ))
+	if typeName != "" {
+		f = f.str(6, typeName)
+	}
+	return f
+}
+
+// testDescriptorSet returns the descriptor set of:
+//
+//	syntax = "proto3";
+//	package test;
+//
+//	enum Kind { UNKNOWN = 0; ADMIN = 1; }
+//
+//	message Person {
+//	  string name = 1;
+//	  int32 id = 2;
+//	  repeated string emails = 3;
+//	  Kind kind = 4;
+//	  map<string, int64> scores = 5;
+//	  repeated int32 nums = 6;
+//	  Address address = 7;
+//	  sint64 delta = 8;
+//	  double ratio = 9;
+//	  bool active = 10;
+//	  bytes data = 11;
+//	  fixed32 f32 = 12;
+//	  repeated sint32 unpacked = 13 [packed = false];
+//	  map<int32, Address> places = 14;
+//	  float f = 15;
+//	  uint64 big = 16;
+//	}
+//
+//	message Address { string city = 1; }
+func testDescriptorSet() []byte {
+	const optional, repeated = 1, 3
+	person := pb{}.str(1, "Person").
+		bytes(2, pbField("name", 1, optional, 9, "")).
+		bytes(2, pbField("id", 2, optional, 5, "")).
+		bytes(2, pbField("emails", 3, repeated, 9, "")).
+		bytes(2, pbField("kind", 4, optional, 14, ".test.Kind")).
+		bytes(2, pbField("scores", 5, repeated, 11, ".test.Person.ScoresEntry").str(10, "scores")).
+		bytes(2, pbField("nums", 6, repeated, 5, "")).
+		bytes(2, pbField("address", 7, optional, 11, ".test.Address")).
+		bytes(2, pbField("delta", 8, optional, 18, "")).
+		bytes(2, pbField("ratio", 9, optional, 1, "")).
+		bytes(2, pbField("active", 10, optional, 8, "")).
+		bytes(2, pbField("data", 11, optional, 12, "")).
+		bytes(2, pbField("f32", 12, optional, 7, "")).
+		bytes(2, pbField("unpacked", 13, repeated, 17, "").bytes(8, pb{}.varint(2, 0))).
+		bytes(2, pbField("places", 14, repeated, 11, ".test.Person.PlacesEntry")).
+		bytes(2, pbField("f", 15, optional, 2, "")).
+		bytes(2, pbField("big", 16, optional, 4, "").str(10, "bigNumber")).
+		bytes(3, pb{}.str(1, "ScoresEntry").
+			bytes(2, pbField("key", 1, optional, 9, "")).
+			bytes(2, pbField("value", 2, optional, 3, "")).
+			bytes(7, pb{}.varint(7, 1))).
+		bytes(3, pb{}.str(1, "PlacesEntry").
+			bytes(2, pbField("key", 1, optional, 5, "")).
+			bytes(2, pbField("value", 2, optional, 11, ".test.Address")).
+			bytes(7, pb{}.varint(7, 1)))
+	address := pb{}.str(1, "Address").bytes(2, pbField("city", 1, optional, 9, ""))
+	kind := pb{}.str(1, "Kind").
+		bytes(2, pb{}.str(1, "UNKNOWN").varint(2, 0)).
+		bytes(2, pb{}.str(1, "ADMIN").varint(2, 1))
+
+	file := pb{}.str(1, "test.proto").str(2, "test").
+		bytes(4, person).bytes(4, address).bytes(5, kind).str(12, "proto3")
+
+	return pb{}.bytes(1, file)
+}
+
+func TestProtoLoad(t *testing.T) {
+	module(t, "proto").call("load").expectError()
+	module(t, "proto").call("load", "a").expectError()
+
+	module(t, "proto").call("load", []byte{0x0a, 0x05}).
+		expect(&objects.Error{Value: &objects.String{Value: "invalid descriptor set: truncated message"}})
+
+	unknown := pb{}.bytes(1, pb{}.str(2, "a").bytes(4, pb{}.str(1, "M").
+		bytes(2, pbField("b", 1, 1, 11, ".a.B"))))
+	module(t, "proto").call("load", []byte(unknown)).
+		expect(&objects.Error{Value: &objects.String{Value: "unknown message type: a.B"}})
+
+	r := module(t, "proto").call("load", []byte{})
+	assert.NoError(t, r.e)
+	r.call("messages").expect(ARR{})
+
+	r = module(t, "proto").call("load", testDescriptorSet())
+	assert.NoError(t, r.e)
+	r.call("messages").expect(ARR{"test.Address", "test.Person"})
+}
+
+func TestProtoEncodeDecode(t *testing.T) {
+	r := module(t, "proto").call("load", testDescriptorSet())
+	assert.NoError(t, r.e)
+
+	r.call("encode", "test.Person").expectError()
+	r.call("encode", "test.Nope", MAP{}).expectError()
+	r.call("encode", "test.Person", 1).expectError()
+	r.call("decode", "test.Person", "a").expectError()
+
+	// the wire format
+	r.call("encode", "test.Person", MAP{}).expect([]byte{})
+	r.call("encode", "test.Person", MAP{"id": 150, "name": "a"}).
+		expect([]byte{0x0a, 0x01, 'a', 0x10, 0x96, 0x01})
+	r.call("encode", ".test.Person", IMAP{"nums": ARR{1, 2, 300}, "unpacked": ARR{-1, 1}}).
+		expect([]byte{0x32, 0x04, 0x01, 0x02, 0xac, 0x02, 0x68, 0x01, 0x68, 0x02})
+	r.call("encode", "test.Person", MAP{"kind": "ADMIN", "delta": -2, "name": objects.UndefinedValue}).
+		expect([]byte{0x20, 0x01, 0x40, 0x03})
+	r.call("decode", "test.Person", []byte{0x0a, 0x01, 'a', 0x10, 0x96, 0x01}).
+		expect(MAP{"name": "a", "id": 150})
+
+	// the round trip
+	person := MAP{
+		"name":     "Alice",
+		"id":       -7,
+		"emails":   ARR{"a@x.com", "b@x.com"},
+		"kind":     "ADMIN",
+		"scores":   MAP{"go": 10, "rust": -3},
+		"nums":     ARR{1, -1, 1 << 40},
+		"address":  MAP{"city": "Seoul"},
+		"delta":    -1 << 40,
+		"ratio":    0.25,
+		"active":   true,
+		"data":     []byte{0, 1, 2},
+		"f32":      4000000000,
+		"unpacked": ARR{-5, 5},
+		"places":   MAP{"-1": MAP{"city": "A"}, "2": MAP{}},
+		"f":        1.5,
+		"big":      -1,
+	}
+	data := r.call("encode", "test.Person", person)
+	assert.NoError(t, data.e)
+	expected := object(person).(*objects.Map)
+	expected.Value["nums"] = object(ARR{1, -1, 0}) // int32
+	r.call("decode", "test.Person", data.o).expect(expected)
+
+	// the JSON names, and, the unknown enum values
+	data = r.call("encode", "test.Person", MAP{"bigNumber": 5, "kind": 3})
+	r.call("decode", "test.Person", data.o).expect(MAP{"big": 5, "kind": 3})
+
+	// the unpacked encoding of the packed fields, and, the unknown fields
+	r.call("decode", "test.Person", []byte{0x30, 0x01, 0x30, 0x02, 0xf8, 0x07, 0x01}).
+		expect(MAP{"nums": ARR{1, 2}})
+
+	encodeError := func(v MAP, msg string) {
+		r.call("encode", "test.Person", v).expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	encodeError(MAP{"nope": 1}, "unknown field 'nope' in test.Person")
+	encodeError(MAP{"id": "1"}, "test.Person.id: expected int, found string")
+	encodeError(MAP{"emails": "a"}, "test.Person.emails: expected array, found string")
+	encodeError(MAP{"kind": "USER"}, "test.Person.kind: unknown enum value: USER")
+	encodeError(MAP{"places": MAP{"a": MAP{}}}, "test.Person.places: invalid map key: a")
+	encodeError(MAP{"address": MAP{"zip": 1}}, "test.Person.address: unknown field 'zip' in test.Address")
+
+	decodeError := func(b []byte, msg string) {
+		r.call("decode", "test.Person", b).expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	decodeError([]byte{0x0a, 0x05, 'a'}, "truncated message")
+	decodeError([]byte{0x10}, "truncated message")
+	decodeError([]byte{0x08, 0x01}, "test.Person.name: invalid wire type 0")
+	decodeError([]byte{0x3a, 0x02, 0x0a, 0x05}, "test.Person.address: truncated message")
+	decodeError([]byte{0x0b}, "unsupported wire type 3")
+}
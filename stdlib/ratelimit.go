@@ -0,0 +1,335 @@
+//go:build !tengo_no_ratelimit
+// +build !tengo_no_ratelimit
+
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("ratelimit", ratelimitModule)
+}
+
+func ratelimitModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"token_bucket": &objects.UserFunction{Name: "token_bucket", Value: ratelimitNewTokenBucket}, // token_bucket(rate, burst) => limiter
+		"leaky_bucket": &objects.UserFunction{Name: "leaky_bucket", Value: ratelimitNewLeakyBucket}, // leaky_bucket(rate, capacity) => limiter
+	}
+}
+
+// ratelimitLimiter is a rate limiter that hands out the permits.
+type ratelimitLimiter interface {
+	// limit returns the maximum number of the permits taken at once.
+	limit() int64
+
+	// take takes n permits and returns true if they are available now.
+	take(n int64, now time.Time) bool
+
+	// reserve takes n permits, and, returns how long to wait until they are
+	// available. It returns false if the limiter can't take more waits.
+	reserve(n int64, now time.Time) (time.Duration, bool)
+}
+
+func ratelimitNewTokenBucket(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	rate, err := ratelimitRate(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	burst, err := ratelimitSize(args[1], "burst")
+	if err != nil {
+		return nil, err
+	}
+
+	// the bucket is full at first
+	b := &ratelimitTokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+
+	limiter := makeRatelimitLimiter(b)
+	// tokens() => float
+	limiter.Value["tokens"] = &objects.UserFunction{
+		Name: "tokens",
+		Value: func(args ...objects.Object) (objects.Object, error) {
+			if len(args) != 0 {
+				return nil, objects.ErrWrongNumArguments
+			}
+
+			return &objects.Float{Value: b.available(time.Now())}, nil
+		},
+	}
+
+	return limiter, nil
+}
+
+func ratelimitNewLeakyBucket(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	rate, err := ratelimitRate(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := ratelimitSize(args[1], "capacity")
+	if err != nil {
+		return nil, err
+	}
+
+	// the interval is at least 1ns, and, at most about 292 years
+	interval := time.Duration(math.Min(float64(time.Second)/rate, math.MaxInt64/2))
+	if interval < 1 {
+		interval = 1
+	}
+
+	return makeRatelimitLimiter(&ratelimitLeakyBucket{
+		interval: interval,
+		capacity: capacity,
+	}), nil
+}
+
+func makeRatelimitLimiter(l ratelimitLimiter) *objects.ImmutableMap {
+	return &objects.ImmutableMap{
+		Value: map[string]objects.Object{
+			// try_acquire(n) => bool
+			"try_acquire": &objects.UserFunction{
+				Name: "try_acquire",
+				Value: func(args ...objects.Object) (objects.Object, error) {
+					n, err := ratelimitPermits(l, args)
+					if err != nil {
+						return nil, err
+					}
+
+					if l.take(n, time.Now()) {
+						return objects.TrueValue, nil
+					}
+
+					return objects.FalseValue, nil
+				},
+			},
+			// wait(n) => undefined/error
+			"wait": &objects.InteropFunction{
+				Name: "wait",
+				Value: func(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+					n, err := ratelimitPermits(l, args)
+					if err != nil {
+						return nil, err
+					}
+
+					d, ok := l.reserve(n, time.Now())
+					if !ok {
+						return wrapError(errRatelimitFull), nil
+					}
+
+					// the execution is stopped anyway if it's aborted while
+					// waiting
+					ratelimitWait(rt, d)
+
+					return objects.UndefinedValue, nil
+				},
+			},
+		},
+	}
+}
+
+// errRatelimitFull is returned by wait if the leaky bucket is full.
+var errRatelimitFull = errors.New("rate limiter is full")
+
+// ratelimitWait waits for the duration, or, until the execution is aborted.
+func ratelimitWait(rt objects.Interop, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	var aborted <-chan struct{}
+	if aborter, ok := rt.(objects.InteropAborter); ok {
+		aborted = aborter.Aborted()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-aborted:
+	}
+}
+
+// ratelimitPermits returns the optional number of the permits (default 1).
+func ratelimitPermits(l ratelimitLimiter, args []objects.Object) (int64, error) {
+	switch len(args) {
+	case 0:
+		return 1, nil
+	case 1:
+		n, err := ratelimitSize(args[0], "first")
+		if err != nil {
+			return 0, err
+		}
+		if n > l.limit() {
+			return 0, fmt.Errorf("%d permits exceed the limit %d", n, l.limit())
+		}
+
+		return n, nil
+	}
+
+	return 0, objects.ErrWrongNumArguments
+}
+
+// ratelimitRate returns the rate in the permits per second.
+func ratelimitRate(o objects.Object) (float64, error) {
+	var rate float64
+	switch o := o.(type) {
+	case *objects.Int:
+		rate = float64(o.Value)
+	case *objects.Float:
+		rate = o.Value
+	default:
+		return 0, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "float(compatible)",
+			Found:    o.TypeName(),
+		}
+	}
+
+	if !(rate > 0) || math.IsInf(rate, 0) {
+		return 0, fmt.Errorf("invalid rate: %s", o)
+	}
+
+	return rate, nil
+}
+
+func ratelimitSize(o objects.Object, name string) (int64, error) {
+	n, ok := o.(*objects.Int)
+	if !ok {
+		return 0, objects.ErrInvalidArgumentType{
+			Name:     name,
+			Expected: "int",
+			Found:    o.TypeName(),
+		}
+	}
+
+	if n.Value < 1 {
+		return 0, fmt.Errorf("invalid %s: %d", name, n.Value)
+	}
+
+	return n.Value, nil
+}
+
+// ratelimitTokenBucket is filled with the tokens at the rate up to the
+// burst. The permits are the tokens taken from the bucket, so a burst of
+// the calls is allowed after an idle time.
+type ratelimitTokenBucket struct {
+	lock   sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64 // negative if the tokens are reserved by the waits
+	last   time.Time
+}
+
+func (b *ratelimitTokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+func (b *ratelimitTokenBucket) available(now time.Time) float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.refill(now)
+
+	return math.Max(0, b.tokens)
+}
+
+func (b *ratelimitTokenBucket) limit() int64 {
+	return int64(b.burst)
+}
+
+func (b *ratelimitTokenBucket) take(n int64, now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.refill(now)
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+
+	return true
+}
+
+// reserve takes the tokens even if they are not in the bucket yet, so the
+// waits are served in order.
+func (b *ratelimitTokenBucket) reserve(n int64, now time.Time) (time.Duration, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.refill(now)
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0, true
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second)), true
+}
+
+// ratelimitLeakyBucket lets the permits out one at a time at the rate, so
+// the calls are evenly spaced. Up to the capacity of the permits can wait
+// in the bucket.
+type ratelimitLeakyBucket struct {
+	lock     sync.Mutex
+	interval time.Duration // between the permits
+	capacity int64
+	next     time.Time // when the next permit is out
+}
+
+func (b *ratelimitLeakyBucket) limit() int64 {
+	return b.capacity
+}
+
+func (b *ratelimitLeakyBucket) take(n int64, now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.next.After(now) {
+		return false
+	}
+	b.next = now.Add(time.Duration(n) * b.interval)
+
+	return true
+}
+
+func (b *ratelimitLeakyBucket) reserve(n int64, now time.Time) (time.Duration, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	start := b.next
+	if start.Before(now) {
+		start = now
+	}
+
+	// the number of the permits that are waiting before this one
+	wait := start.Sub(now)
+	if queued := int64((wait - 1) / b.interval); queued+n > b.capacity {
+		return 0, false
+	}
+	b.next = start.Add(time.Duration(n) * b.interval)
+
+	return wait, true
+}
@@ -0,0 +1,83 @@
+package stdlib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestRatelimitTokenBucket(t *testing.T) {
+	module(t, "ratelimit").call("token_bucket").expectError()
+	module(t, "ratelimit").call("token_bucket", "1", 1).expectError()
+	module(t, "ratelimit").call("token_bucket", 0, 1).expectError()
+	module(t, "ratelimit").call("token_bucket", 1, 0).expectError()
+	module(t, "ratelimit").call("token_bucket", 1, 1.5).expectError()
+
+	// 1 token per second, so no tokens are added while testing
+	b := module(t, "ratelimit").call("token_bucket", 1, 3)
+	assert.NoError(t, b.e)
+	b.call("try_acquire").expect(true)
+	b.call("try_acquire", 2).expect(true)
+	b.call("try_acquire").expect(false)
+	b.call("try_acquire", 4).expectError()
+	b.call("try_acquire", 0).expectError()
+	b.call("try_acquire", 1, 2).expectError()
+	assert.True(t, b.call("tokens").o.(*objects.Float).Value < 0.5)
+
+	// 200 tokens per second: a token in 5ms
+	b = module(t, "ratelimit").call("token_bucket", 200.0, 1)
+	b.call("wait").expect(objects.UndefinedValue)
+	start := time.Now()
+	b.call("wait").expect(objects.UndefinedValue)
+	b.call("wait").expect(objects.UndefinedValue)
+	assert.True(t, time.Since(start) >= 9*time.Millisecond)
+	b.call("wait", 2).expectError()
+
+	// aborted while waiting
+	b = module(t, "ratelimit").call("token_bucket", 0.001, 1)
+	b.call("wait").expect(objects.UndefinedValue)
+	rt := abortInterop{aborted: make(chan struct{})}
+	close(rt.aborted)
+	wait := b.o.(*objects.ImmutableMap).Value["wait"].(*objects.InteropFunction)
+	res, err := wait.Value(rt)
+	assert.NoError(t, err)
+	assert.Equal(t, objects.UndefinedValue, res)
+}
+
+func TestRatelimitLeakyBucket(t *testing.T) {
+	module(t, "ratelimit").call("leaky_bucket", 1).expectError()
+	module(t, "ratelimit").call("leaky_bucket", -1.0, 1).expectError()
+	module(t, "ratelimit").call("leaky_bucket", 1, 0).expectError()
+
+	// the permits are spaced by 1s
+	b := module(t, "ratelimit").call("leaky_bucket", 1, 2)
+	assert.NoError(t, b.e)
+	b.call("try_acquire").expect(true)
+	b.call("try_acquire").expect(false)
+	b.call("try_acquire", 3).expectError()
+
+	// 200 permits per second: a permit in 5ms
+	b = module(t, "ratelimit").call("leaky_bucket", 200, 2)
+	start := time.Now()
+	b.call("wait").expect(objects.UndefinedValue)
+	b.call("wait").expect(objects.UndefinedValue)
+	b.call("wait").expect(objects.UndefinedValue)
+	assert.True(t, time.Since(start) >= 9*time.Millisecond)
+
+	// full: 2 permits are waiting
+	b = module(t, "ratelimit").call("leaky_bucket", 0.001, 2)
+	b.call("try_acquire").expect(true)
+	rt := abortInterop{aborted: make(chan struct{})}
+	close(rt.aborted)
+	wait := b.o.(*objects.ImmutableMap).Value["wait"].(*objects.InteropFunction)
+	for i := 0; i < 2; i++ {
+		res, err := wait.Value(rt)
+		assert.NoError(t, err)
+		assert.Equal(t, objects.UndefinedValue, res)
+	}
+	res, err := wait.Value(rt)
+	assert.NoError(t, err)
+	assert.Equal(t, &objects.Error{Value: &objects.String{Value: "rate limiter is full"}}, res)
+}
@@ -0,0 +1,151 @@
+//go:build !tengo_no_reflect
+// +build !tengo_no_reflect
+
+package stdlib
+
+import (
+	"sort"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("reflect", reflectModule)
+}
+
+func reflectModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"type_name":  &objects.UserFunction{Name: "type_name", Value: reflectTypeName},      // type_name(x) => string
+		"fields":     &objects.UserFunction{Name: "fields", Value: reflectFields},           // fields(x) => [string]
+		"methods":    &objects.UserFunction{Name: "methods", Value: reflectMethods},         // methods(x) => [string]
+		"arity":      &objects.UserFunction{Name: "arity", Value: reflectArity},             // arity(fn) => int/undefined
+		"source_pos": &objects.InteropFunction{Name: "source_pos", Value: reflectSourcePos}, // source_pos(fn) => string/undefined
+	}
+}
+
+func reflectTypeName(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return &objects.String{Value: args[0].TypeName()}, nil
+}
+
+func reflectFields(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return reflectNames(args[0], false), nil
+}
+
+func reflectMethods(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return reflectNames(args[0], true), nil
+}
+
+func reflectArity(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	fn, err := reflectFunction(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// the number of the parameters of the other callable objects (e.g. the
+	// builtin functions) is unknown
+	if fn == nil {
+		return objects.UndefinedValue, nil
+	}
+
+	return &objects.Int{Value: int64(fn.NumParameters)}, nil
+}
+
+func reflectSourcePos(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	fn, err := reflectFunction(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	positioner, ok := rt.(objects.InteropPositioner)
+	if fn == nil || !ok {
+		return objects.UndefinedValue, nil
+	}
+
+	// the position is invalid if the bytecode does not have the debug
+	// information
+	pos := positioner.FilePos(fn.Pos)
+	if !pos.IsValid() {
+		return objects.UndefinedValue, nil
+	}
+
+	return &objects.String{Value: pos.String()}, nil
+}
+
+// reflectNames returns the names of the fields or the methods of the object.
+// The elements of a map are the methods if the values are callable, or, the
+// fields otherwise.
+func reflectNames(o objects.Object, methods bool) objects.Object {
+	var names []string
+	switch o := o.(type) {
+	case *objects.Map:
+		names = reflectMapNames(o.Value, methods)
+	case *objects.ImmutableMap:
+		names = reflectMapNames(o.Value, methods)
+	case objects.Reflectable:
+		if methods {
+			names = o.MethodNames()
+		} else {
+			names = o.FieldNames()
+		}
+	}
+
+	arr := make([]objects.Object, len(names))
+	for i, name := range names {
+		arr[i] = &objects.String{Value: name}
+	}
+
+	return &objects.Array{Value: arr}
+}
+
+func reflectMapNames(m map[string]objects.Object, methods bool) []string {
+	var names []string
+	for k, v := range m {
+		if isCallable(v) == methods {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// reflectFunction returns the compiled function of a compiled function or a
+// closure, or, nil for the other callable objects.
+func reflectFunction(o objects.Object) (*objects.CompiledFunction, error) {
+	switch o := o.(type) {
+	case *objects.CompiledFunction:
+		return o, nil
+	case *objects.Closure:
+		return o.Fn, nil
+	}
+
+	if !isCallable(o) {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "callable",
+			Found:    o.TypeName(),
+		}
+	}
+
+	return nil, nil
+}
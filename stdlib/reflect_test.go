@@ -0,0 +1,37 @@
+package stdlib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func TestReflect(t *testing.T) {
+	module(t, "reflect").call("type_name", 1).expect("int")
+	module(t, "reflect").call("type_name", MAP{}).expect("map")
+
+	m := MAP{"b": 1, "a": "x", "f": &objects.UserFunction{Value: func(args ...objects.Object) (objects.Object, error) {
+		return nil, nil
+	}}}
+	module(t, "reflect").call("fields", m).expect(ARR{"a", "b"})
+	module(t, "reflect").call("methods", m).expect(ARR{"f"})
+	module(t, "reflect").call("fields", IMAP{"a": 1}).expect(ARR{"a"})
+	module(t, "reflect").call("fields", 1).expect(ARR{})
+	module(t, "reflect").call("methods", "abc").expect(ARR{})
+
+	tm := &objects.Time{Value: time.Now()}
+	module(t, "reflect").call("fields", tm).expect(ARR{"year", "month", "day", "weekday", "year_day", "hour", "minute",
+		"second", "nanosecond", "unix", "unix_nano", "location", "is_zero"})
+	module(t, "reflect").call("methods", &objects.Buffer{}).expect(ARR{"append", "write_string", "truncate", "reset", "len", "bytes", "string"})
+	module(t, "reflect").call("fields", &objects.Buffer{}).expect(ARR{})
+
+	module(t, "reflect").call("arity", &objects.CompiledFunction{NumParameters: 2}).expect(2)
+	module(t, "reflect").call("arity", &objects.Closure{Fn: &objects.CompiledFunction{NumParameters: 1}}).expect(1)
+	module(t, "reflect").call("arity", &objects.UserFunction{}).expect(objects.UndefinedValue)
+	module(t, "reflect").call("arity", 1).expectError()
+
+	// the runtime of the test cannot resolve the positions
+	module(t, "reflect").call("source_pos", &objects.CompiledFunction{}).expect(objects.UndefinedValue)
+	module(t, "reflect").call("source_pos", "abc").expectError()
+}
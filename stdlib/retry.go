@@ -0,0 +1,218 @@
+//go:build !tengo_no_retry
+// +build !tengo_no_retry
+
+package stdlib
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("retry", retryModule)
+}
+
+func retryModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"run": &objects.InteropFunction{Name: "run", Value: retryRun}, // run(fn, options) => any/error
+	}
+}
+
+// retryOptions are the options of retry.run. The delays are in nanoseconds
+// like the durations of times module.
+type retryOptions struct {
+	attempts   int64
+	delay      int64
+	maxDelay   int64
+	multiplier float64
+	jitter     float64
+	retryOn    objects.Object
+}
+
+func retryRun(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	fn := args[0]
+	if !isCallable(fn) {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "callable",
+			Found:    fn.TypeName(),
+		}
+	}
+
+	opts := &retryOptions{
+		attempts:   3,
+		delay:      int64(100 * time.Millisecond),
+		multiplier: 2,
+	}
+	if len(args) == 2 {
+		if err := opts.parse(args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	var aborted <-chan struct{}
+	if aborter, ok := rt.(objects.InteropAborter); ok {
+		aborted = aborter.Aborted()
+	}
+
+	var failures []string
+	delay := float64(opts.delay)
+	for attempt := int64(1); ; attempt++ {
+		res, err := rt.Call(fn)
+		if err != nil {
+			return nil, err
+		}
+
+		failed, err := opts.failed(rt, res)
+		if err != nil {
+			return nil, err
+		}
+		if !failed {
+			return res, nil
+		}
+
+		failures = append(failures, retryMessage(res))
+		if attempt >= opts.attempts {
+			break
+		}
+
+		// the execution is stopped anyway if it's aborted while waiting
+		if !retryWait(opts.wait(delay), aborted) {
+			return objects.UndefinedValue, nil
+		}
+
+		delay *= opts.multiplier
+		if opts.maxDelay > 0 && delay > float64(opts.maxDelay) {
+			delay = float64(opts.maxDelay)
+		}
+	}
+
+	return &objects.Error{Value: &objects.String{
+		Value: fmt.Sprintf("%d attempts failed: %s", len(failures), strings.Join(failures, "; ")),
+	}}, nil
+}
+
+// parse reads the options from the map. The options that are not in the map
+// have the default values.
+func (opts *retryOptions) parse(o objects.Object) error {
+	var m map[string]objects.Object
+	switch o := o.(type) {
+	case *objects.Map:
+		m = o.Value
+	case *objects.ImmutableMap:
+		m = o.Value
+	default:
+		return objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "map",
+			Found:    o.TypeName(),
+		}
+	}
+
+	for k, v := range m {
+		var ok bool
+		switch k {
+		case "attempts":
+			opts.attempts, ok = retryInt(v)
+			ok = ok && opts.attempts > 0
+		case "delay":
+			opts.delay, ok = retryInt(v)
+			ok = ok && opts.delay >= 0
+		case "max_delay":
+			opts.maxDelay, ok = retryInt(v)
+			ok = ok && opts.maxDelay >= 0
+		case "multiplier":
+			opts.multiplier, ok = toNumber(v)
+			ok = ok && opts.multiplier >= 1
+		case "jitter":
+			opts.jitter, ok = toNumber(v)
+			ok = ok && opts.jitter >= 0 && opts.jitter <= 1
+		case "retry_on":
+			opts.retryOn, ok = v, isCallable(v)
+		default:
+			return fmt.Errorf("unknown retry option '%s'", k)
+		}
+
+		if !ok {
+			return fmt.Errorf("invalid retry option '%s': %s", k, v)
+		}
+	}
+
+	return nil
+}
+
+// failed returns true if the result should be retried: retry_on returns a
+// truthy value, or, the result is an error if retry_on is not set.
+func (opts *retryOptions) failed(rt objects.Interop, res objects.Object) (bool, error) {
+	if opts.retryOn == nil {
+		_, isErr := res.(*objects.Error)
+		return isErr, nil
+	}
+
+	ret, err := rt.Call(opts.retryOn, res)
+	if err != nil {
+		return false, err
+	}
+
+	return !ret.IsFalsy(), nil
+}
+
+// wait returns the delay with the jitter: the delay is reduced by up to the
+// jitter ratio at random, so the scripts retrying at the same time spread
+// out.
+func (opts *retryOptions) wait(delay float64) time.Duration {
+	if opts.jitter > 0 {
+		delay *= 1 - opts.jitter*rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
+
+// retryWait waits for the duration. It returns false if the execution is
+// aborted while waiting.
+func retryWait(d time.Duration, aborted <-chan struct{}) bool {
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-aborted:
+		return false
+	}
+}
+
+// retryMessage returns the message of a failed result for the aggregated
+// error.
+func retryMessage(res objects.Object) string {
+	if e, ok := res.(*objects.Error); ok {
+		res = e.Value
+	}
+
+	if s, ok := res.(*objects.String); ok {
+		return s.Value
+	}
+
+	return res.String()
+}
+
+func retryInt(o objects.Object) (int64, bool) {
+	i, ok := o.(*objects.Int)
+	if !ok {
+		return 0, false
+	}
+
+	return i.Value, true
+}
@@ -0,0 +1,94 @@
+package stdlib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+// abortInterop is an interop that is aborted when the channel is closed.
+type abortInterop struct {
+	interop
+	aborted chan struct{}
+}
+
+func (rt abortInterop) Aborted() <-chan struct{} {
+	return rt.aborted
+}
+
+func TestRetry(t *testing.T) {
+	// fails until the n-th call
+	failUntil := func(n int) (*objects.UserFunction, *int) {
+		calls := 0
+		return &objects.UserFunction{Value: func(args ...objects.Object) (objects.Object, error) {
+			calls++
+			if calls < n {
+				return &objects.Error{Value: &objects.String{Value: "timeout"}}, nil
+			}
+			return &objects.Int{Value: int64(calls)}, nil
+		}}, &calls
+	}
+	fast := MAP{"delay": 1000}
+
+	fn, calls := failUntil(1)
+	module(t, "retry").call("run", fn).expect(1)
+	assert.Equal(t, 1, *calls)
+
+	fn, calls = failUntil(3)
+	module(t, "retry").call("run", fn, fast).expect(3)
+	assert.Equal(t, 3, *calls)
+
+	fn, calls = failUntil(5)
+	module(t, "retry").call("run", fn, fast).
+		expect(&objects.Error{Value: &objects.String{Value: "3 attempts failed: timeout; timeout; timeout"}})
+	assert.Equal(t, 3, *calls)
+
+	fn, calls = failUntil(5)
+	module(t, "retry").call("run", fn, MAP{"attempts": 5, "delay": 1000, "multiplier": 1.5, "jitter": 0.5, "max_delay": 2000}).expect(5)
+	assert.Equal(t, 5, *calls)
+
+	// retry_on decides which results are retried
+	fn, calls = failUntil(5)
+	retryOn := &objects.UserFunction{Value: func(args ...objects.Object) (objects.Object, error) {
+		if i, ok := args[0].(*objects.Int); ok && i.Value < 10 {
+			return objects.TrueValue, nil
+		}
+		return objects.FalseValue, nil
+	}}
+	module(t, "retry").call("run", fn, MAP{"delay": 0, "retry_on": retryOn, "attempts": 10}).
+		expect(&objects.Error{Value: &objects.String{Value: "timeout"}})
+	fn, calls = failUntil(1)
+	module(t, "retry").call("run", fn, MAP{"delay": 0, "retry_on": retryOn}).
+		expect(&objects.Error{Value: &objects.String{Value: "3 attempts failed: 1; 2; 3"}})
+
+	// the delays grow exponentially
+	fn, _ = failUntil(3)
+	start := time.Now()
+	module(t, "retry").call("run", fn, MAP{"delay": int64(10 * time.Millisecond)}).expect(3)
+	assert.True(t, time.Since(start) >= 30*time.Millisecond)
+
+	// aborted while waiting
+	fn, calls = failUntil(3)
+	rt := abortInterop{aborted: make(chan struct{})}
+	close(rt.aborted)
+	res, err := retryModule(t).Value(rt, fn, object(MAP{"delay": int64(time.Hour)}))
+	assert.NoError(t, err)
+	assert.Equal(t, objects.UndefinedValue, res)
+	assert.Equal(t, 1, *calls)
+
+	module(t, "retry").call("run").expectError()
+	module(t, "retry").call("run", 1).expectError()
+	module(t, "retry").call("run", fn, 1).expectError()
+	module(t, "retry").call("run", fn, MAP{"attempts": 0}).expectError()
+	module(t, "retry").call("run", fn, MAP{"attempts": "3"}).expectError()
+	module(t, "retry").call("run", fn, MAP{"jitter": 2}).expectError()
+	module(t, "retry").call("run", fn, MAP{"multiplier": 0.5}).expectError()
+	module(t, "retry").call("run", fn, MAP{"retry_on": 1}).expectError()
+	module(t, "retry").call("run", fn, MAP{"foo": 1}).expectError()
+}
+
+func retryModule(t *testing.T) *objects.InteropFunction {
+	return module(t, "retry").o.(*objects.ImmutableMap).Value["run"].(*objects.InteropFunction)
+}
@@ -0,0 +1,386 @@
+//go:build !tengo_no_schema
+// +build !tengo_no_schema
+
+package stdlib
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("schema", schemaModule)
+}
+
+func schemaModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"validate": &objects.UserFunction{Name: "validate", Value: schemaValidate}, // validate(value, schema) => [{path:,message:}]/error
+		"is_valid": &objects.UserFunction{Name: "is_valid", Value: schemaIsValid},  // is_valid(value, schema) => bool/error
+	}
+}
+
+// schemaTypes are the type names that a schema can use. "number" is an int
+// or a float, and, "any" is any value.
+var schemaTypes = map[string]bool{
+	"int":       true,
+	"float":     true,
+	"number":    true,
+	"string":    true,
+	"bool":      true,
+	"char":      true,
+	"bytes":     true,
+	"array":     true,
+	"map":       true,
+	"time":      true,
+	"error":     true,
+	"undefined": true,
+	"any":       true,
+}
+
+// schemaKeys are the keys of a schema.
+var schemaKeys = map[string]bool{
+	"type":       true,
+	"required":   true,
+	"properties": true,
+	"additional": true,
+	"items":      true,
+	"min":        true,
+	"max":        true,
+	"min_len":    true,
+	"max_len":    true,
+	"pattern":    true,
+	"enum":       true,
+}
+
+func schemaValidate(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	v := &schemaValidator{}
+	if err := v.validate(args[0], args[1], "$"); err != nil {
+		return wrapError(err), nil
+	}
+
+	if v.errs == nil {
+		v.errs = []objects.Object{}
+	}
+
+	return &objects.Array{Value: v.errs}, nil
+}
+
+func schemaIsValid(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	v := &schemaValidator{}
+	if err := v.validate(args[0], args[1], "$"); err != nil {
+		return wrapError(err), nil
+	}
+
+	if len(v.errs) > 0 {
+		return objects.FalseValue, nil
+	}
+
+	return objects.TrueValue, nil
+}
+
+// schemaValidator collects the validation errors of a value. The errors of
+// the schema itself (e.g. an unknown type name) stop the validation.
+type schemaValidator struct {
+	errs     []objects.Object
+	patterns map[string]*regexp.Regexp
+}
+
+func (v *schemaValidator) fail(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, &objects.Map{Value: map[string]objects.Object{
+		"path":    &objects.String{Value: path},
+		"message": &objects.String{Value: fmt.Sprintf(format, args...)},
+	}})
+}
+
+func (v *schemaValidator) validate(value, schema objects.Object, path string) error {
+	s, ok := toStringMap(schema)
+	if !ok {
+		return fmt.Errorf("invalid schema at %s: expected map, found %s", path, schema.TypeName())
+	}
+
+	for k := range s {
+		if !schemaKeys[k] {
+			return fmt.Errorf("invalid schema at %s: unknown key '%s'", path, k)
+		}
+	}
+
+	if t, ok := s["type"]; ok {
+		matched, err := schemaMatchType(value, t, path)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			v.fail(path, "expected %s, found %s", schemaTypeString(t), schemaTypeName(value))
+			return nil
+		}
+	}
+
+	if enum, ok := s["enum"]; ok {
+		values, ok := toArray(enum)
+		if !ok {
+			return fmt.Errorf("invalid schema at %s: 'enum' must be an array", path)
+		}
+
+		found := false
+		for _, e := range values {
+			if e.Equals(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "must be one of %s", enum)
+		}
+	}
+
+	if err := v.validateRange(value, s, path); err != nil {
+		return err
+	}
+
+	if err := v.validateLen(value, s, path); err != nil {
+		return err
+	}
+
+	if pattern, ok := s["pattern"]; ok {
+		if err := v.validatePattern(value, pattern, path); err != nil {
+			return err
+		}
+	}
+
+	if elems, ok := toStringMap(value); ok {
+		return v.validateMap(elems, s, path)
+	}
+
+	if elems, ok := toArray(value); ok {
+		if items, ok := s["items"]; ok {
+			for i, elem := range elems {
+				if err := v.validate(elem, items, path+"["+strconv.Itoa(i)+"]"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRange checks the value of a number against "min" and "max".
+func (v *schemaValidator) validateRange(value objects.Object, s map[string]objects.Object, path string) error {
+	for _, key := range []string{"min", "max"} {
+		limit, ok := s[key]
+		if !ok {
+			continue
+		}
+
+		l, ok := toNumber(limit)
+		if !ok {
+			return fmt.Errorf("invalid schema at %s: '%s' must be a number", path, key)
+		}
+
+		n, ok := toNumber(value)
+		if !ok {
+			continue
+		}
+
+		if key == "min" && n < l {
+			v.fail(path, "must be greater than or equal to %s", limit)
+		} else if key == "max" && n > l {
+			v.fail(path, "must be less than or equal to %s", limit)
+		}
+	}
+
+	return nil
+}
+
+// validateLen checks the length of a string, bytes, array or map against
+// "min_len" and "max_len".
+func (v *schemaValidator) validateLen(value objects.Object, s map[string]objects.Object, path string) error {
+	for _, key := range []string{"min_len", "max_len"} {
+		limit, ok := s[key]
+		if !ok {
+			continue
+		}
+
+		l, ok := limit.(*objects.Int)
+		if !ok {
+			return fmt.Errorf("invalid schema at %s: '%s' must be an int", path, key)
+		}
+
+		n, ok := schemaLen(value)
+		if !ok {
+			continue
+		}
+
+		if key == "min_len" && int64(n) < l.Value {
+			v.fail(path, "length must be greater than or equal to %d", l.Value)
+		} else if key == "max_len" && int64(n) > l.Value {
+			v.fail(path, "length must be less than or equal to %d", l.Value)
+		}
+	}
+
+	return nil
+}
+
+// validatePattern checks a string against the regular expression.
+func (v *schemaValidator) validatePattern(value, pattern objects.Object, path string) error {
+	p, ok := pattern.(*objects.String)
+	if !ok {
+		return fmt.Errorf("invalid schema at %s: 'pattern' must be a string", path)
+	}
+
+	re, ok := v.patterns[p.Value]
+	if !ok {
+		var err error
+		re, err = regexp.Compile(p.Value)
+		if err != nil {
+			return fmt.Errorf("invalid schema at %s: %s", path, err.Error())
+		}
+
+		if v.patterns == nil {
+			v.patterns = make(map[string]*regexp.Regexp)
+		}
+		v.patterns[p.Value] = re
+	}
+
+	if str, ok := value.(*objects.String); ok && !re.MatchString(str.Value) {
+		v.fail(path, "must match pattern %q", p.Value)
+	}
+
+	return nil
+}
+
+// validateMap checks the elements of a map against "required",
+// "properties" and "additional".
+func (v *schemaValidator) validateMap(elems, s map[string]objects.Object, path string) error {
+	if required, ok := s["required"]; ok {
+		keys, ok := toArray(required)
+		if !ok {
+			return fmt.Errorf("invalid schema at %s: 'required' must be an array", path)
+		}
+
+		for _, key := range keys {
+			k, ok := key.(*objects.String)
+			if !ok {
+				return fmt.Errorf("invalid schema at %s: 'required' must be an array of strings", path)
+			}
+
+			if _, ok := elems[k.Value]; !ok {
+				v.fail(path, "missing required key '%s'", k.Value)
+			}
+		}
+	}
+
+	var properties map[string]objects.Object
+	if props, ok := s["properties"]; ok {
+		if properties, ok = toStringMap(props); !ok {
+			return fmt.Errorf("invalid schema at %s: 'properties' must be a map", path)
+		}
+	}
+
+	additional := true
+	if a, ok := s["additional"]; ok {
+		additional = !a.IsFalsy()
+	}
+
+	// the keys are sorted, so the order of the errors is deterministic
+	keys := make([]string, 0, len(elems))
+	for k := range elems {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		prop, ok := properties[k]
+		if !ok {
+			if !additional {
+				v.fail(path, "unexpected key '%s'", k)
+			}
+			continue
+		}
+
+		if err := v.validate(elems[k], prop, path+"."+k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// schemaMatchType returns true if the value is of the type (a type name or
+// an array of the type names).
+func schemaMatchType(value, t objects.Object, path string) (bool, error) {
+	var names []objects.Object
+	if arr, ok := toArray(t); ok {
+		names = arr
+	} else {
+		names = []objects.Object{t}
+	}
+
+	matched := false
+	for _, name := range names {
+		n, ok := name.(*objects.String)
+		if !ok || !schemaTypes[n.Value] {
+			return false, fmt.Errorf("invalid schema at %s: unknown type %s", path, name)
+		}
+
+		typeName := schemaTypeName(value)
+		if n.Value == "any" || n.Value == typeName ||
+			(n.Value == "number" && (typeName == "int" || typeName == "float")) {
+			matched = true
+		}
+	}
+
+	return matched, nil
+}
+
+// schemaTypeName returns the type name of the value used in the schemas:
+// the immutable arrays and maps are arrays and maps.
+func schemaTypeName(value objects.Object) string {
+	switch value.(type) {
+	case *objects.ImmutableArray:
+		return "array"
+	case *objects.ImmutableMap:
+		return "map"
+	}
+
+	return value.TypeName()
+}
+
+func schemaTypeString(t objects.Object) string {
+	if s, ok := t.(*objects.String); ok {
+		return s.Value
+	}
+
+	return t.String()
+}
+
+func schemaLen(o objects.Object) (int, bool) {
+	switch o := o.(type) {
+	case *objects.String:
+		return len(o.Value), true
+	case *objects.Bytes:
+		return len(o.Value), true
+	case *objects.Array:
+		return len(o.Value), true
+	case *objects.ImmutableArray:
+		return len(o.Value), true
+	case *objects.Map:
+		return len(o.Value), true
+	case *objects.ImmutableMap:
+		return len(o.Value), true
+	}
+
+	return 0, false
+}
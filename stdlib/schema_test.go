@@ -0,0 +1,65 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/objects"
+)
+
+func TestSchema(t *testing.T) {
+	fail := func(path, message string) MAP {
+		return MAP{"path": path, "message": message}
+	}
+
+	// types
+	module(t, "schema").call("validate", 1, MAP{"type": "int"}).expect(ARR{})
+	module(t, "schema").call("validate", 1.5, MAP{"type": "number"}).expect(ARR{})
+	module(t, "schema").call("validate", IARR{1}, MAP{"type": "array"}).expect(ARR{})
+	module(t, "schema").call("validate", "a", MAP{"type": ARR{"int", "string"}}).expect(ARR{})
+	module(t, "schema").call("validate", "a", MAP{"type": "any"}).expect(ARR{})
+	module(t, "schema").call("validate", "a", MAP{"type": "int"}).expect(ARR{fail("$", "expected int, found string")})
+	module(t, "schema").call("validate", true, MAP{"type": ARR{"int", "string"}}).expect(ARR{fail("$", `expected ["int", "string"], found bool`)})
+
+	// ranges, lengths, patterns and enums
+	module(t, "schema").call("validate", 5, MAP{"min": 1, "max": 5}).expect(ARR{})
+	module(t, "schema").call("validate", 0.5, MAP{"min": 1}).expect(ARR{fail("$", "must be greater than or equal to 1")})
+	module(t, "schema").call("validate", 6, MAP{"max": 5.5}).expect(ARR{fail("$", "must be less than or equal to 5.5")})
+	module(t, "schema").call("validate", "abc", MAP{"min_len": 1, "max_len": 2}).expect(ARR{fail("$", "length must be less than or equal to 2")})
+	module(t, "schema").call("validate", ARR{}, MAP{"min_len": 1}).expect(ARR{fail("$", "length must be greater than or equal to 1")})
+	module(t, "schema").call("validate", "a-1", MAP{"pattern": `^[a-z]+-\d+$`}).expect(ARR{})
+	module(t, "schema").call("validate", "a1", MAP{"pattern": `^[a-z]+-\d+$`}).expect(ARR{fail("$", "must match pattern \"^[a-z]+-\\\\d+$\"")})
+	module(t, "schema").call("validate", "b", MAP{"enum": ARR{"a", "b"}}).expect(ARR{})
+	module(t, "schema").call("validate", "c", MAP{"enum": ARR{"a", "b"}}).expect(ARR{fail("$", `must be one of ["a", "b"]`)})
+
+	// nested schemas
+	user := MAP{
+		"type":     "map",
+		"required": ARR{"name", "age"},
+		"properties": MAP{
+			"name": MAP{"type": "string", "min_len": 1},
+			"age":  MAP{"type": "int", "min": 0},
+			"tags": MAP{"type": "array", "items": MAP{"type": "string"}},
+		},
+	}
+	module(t, "schema").call("validate", MAP{"name": "a", "age": 1, "tags": ARR{"x"}, "other": 1}, user).expect(ARR{})
+	module(t, "schema").call("validate", MAP{"age": -1, "tags": ARR{"x", 1, 2}}, user).expect(ARR{
+		fail("$", "missing required key 'name'"),
+		fail("$.age", "must be greater than or equal to 0"),
+		fail("$.tags[1]", "expected string, found int"),
+		fail("$.tags[2]", "expected string, found int"),
+	})
+	module(t, "schema").call("validate", ARR{MAP{"a": 1, "b": 2}}, MAP{
+		"items": MAP{"properties": MAP{"a": MAP{"type": "int"}}, "additional": false},
+	}).expect(ARR{fail("$[0]", "unexpected key 'b'")})
+
+	module(t, "schema").call("is_valid", MAP{"name": "a", "age": 1}, user).expect(true)
+	module(t, "schema").call("is_valid", MAP{"name": "a"}, user).expect(false)
+
+	// invalid schemas
+	module(t, "schema").call("validate", 1, MAP{"type": "integer"}).expect(&objects.Error{Value: &objects.String{Value: `invalid schema at $: unknown type "integer"`}})
+	module(t, "schema").call("validate", 1, MAP{"typ": "int"}).expect(&objects.Error{Value: &objects.String{Value: "invalid schema at $: unknown key 'typ'"}})
+	module(t, "schema").call("validate", ARR{1}, MAP{"items": MAP{"min": "1"}}).expect(&objects.Error{Value: &objects.String{Value: "invalid schema at $[0]: 'min' must be a number"}})
+	module(t, "schema").call("validate", "a", MAP{"pattern": "("}).expect(&objects.Error{Value: &objects.String{Value: "invalid schema at $: error parsing regexp: missing closing ): `(`"}})
+	module(t, "schema").call("is_valid", 1, 1).expect(&objects.Error{Value: &objects.String{Value: "invalid schema at $: expected map, found int"}})
+	module(t, "schema").call("validate", 1).expectError()
+}
@@ -2,15 +2,77 @@ package stdlib
 
 import "github.com/d5/tengo/objects"
 
-// Modules contain the standard modules.
-var Modules = map[string]*objects.Object{
-	"math":  objectPtr(&objects.ImmutableMap{Value: mathModule}),
-	"os":    objectPtr(&objects.ImmutableMap{Value: osModule}),
-	"text":  objectPtr(&objects.ImmutableMap{Value: textModule}),
-	"times": objectPtr(&objects.ImmutableMap{Value: timesModule}),
-	"rand":  objectPtr(&objects.ImmutableMap{Value: randModule}),
+// Modules contain the standard modules. The members of a module are created
+// when it's imported for the first time, and, they are shared by all VMs.
+//
+// A module can be left out of the program with the build tag
+// "tengo_no_<name>" (e.g. "go build -tags tengo_no_os"), so the binary does
+// not include its functions. os, sysinfo, fswatch, graphql and x509 modules
+// are always left out of the embedded builds (TinyGo or "tengo_embedded"
+// build tag). passwd module depends on golang.org/x/crypto, so it's built
+// only with the build tag "tengo_passwd", and, it's not registered by
+// default (see PasswdModule).
+var Modules = make(map[string]*objects.Object)
+
+// osModuleWithArgs returns the members of os module where os.args() returns
+// the given arguments. It's nil if os module is left out.
+var osModuleWithArgs func(args []string) map[string]objects.Object
+
+// ModulesWithArgs returns the standard modules where os.args() returns the
+// given arguments instead of the command-line arguments of the program.
+func ModulesWithArgs(args []string) map[string]*objects.Object {
+	modules := make(map[string]*objects.Object, len(Modules))
+	for name, mod := range Modules {
+		modules[name] = mod
+	}
+
+	if osModuleWithArgs != nil {
+		modules["os"] = objectPtr(&objects.BuiltinModule{
+			Name: "os",
+			Members: func() map[string]objects.Object {
+				return osModuleWithArgs(args)
+			},
+		})
+	}
+
+	return modules
+}
+
+// Register adds the module with the members to Modules, so the scripts can
+// import it. It should be called before the scripts are compiled, e.g. to
+// add a module generated by tengobind.
+func Register(name string, members func() map[string]objects.Object) {
+	register(name, members)
+}
+
+// register adds the module to Modules. The modules register themselves
+// in their init functions.
+func register(name string, members func() map[string]objects.Object) {
+	Modules[name] = objectPtr(&objects.BuiltinModule{Name: name, Members: members})
+}
+
+// CheckNetwork is called with the address ("host:port") before graphql and
+// x509 modules connect to it, and, the connection fails with the error that
+// it returns, e.g. to let the scripts connect only to some hosts. It should
+// be set before the scripts run. All addresses are allowed if it's nil.
+var CheckNetwork func(address string) error
+
+// checkNetwork returns the error of CheckNetwork for the address.
+func checkNetwork(address string) error {
+	if CheckNetwork == nil {
+		return nil
+	}
+
+	return CheckNetwork(address)
 }
 
 func objectPtr(o objects.Object) *objects.Object {
 	return &o
 }
+
+// FunctionRunner calls the functions of a script from Go after the script
+// completes (e.g. the callbacks registered by the script). *runtime.VM and
+// *script.Compiled implement it.
+type FunctionRunner interface {
+	RunFunction(fn objects.Object, args ...objects.Object) (objects.Object, error)
+}
@@ -26,29 +26,58 @@ func (c callres) call(funcName string, args ...interface{}) callres {
 		return c
 	}
 
-	imap, ok := c.o.(*objects.ImmutableMap)
-	if !ok {
+	var m objects.Object
+	var ok bool
+	switch o := c.o.(type) {
+	case *objects.ImmutableMap:
+		m, ok = o.Value[funcName]
+	case objects.Reflectable:
+		// the methods of the objects like tables
+		if o, isIndexable := o.(objects.Indexable); isIndexable {
+			m, _ = o.IndexGet(&objects.String{Value: funcName})
+			ok = m != nil && m != objects.UndefinedValue
+		}
+	default:
 		return c
 	}
-
-	m, ok := imap.Value[funcName]
 	if !ok {
 		return callres{t: c.t, e: fmt.Errorf("function not found: %s", funcName)}
 	}
 
-	f, ok := m.(*objects.UserFunction)
-	if !ok {
-		return callres{t: c.t, e: fmt.Errorf("non-callable: %s", funcName)}
-	}
-
 	var oargs []objects.Object
 	for _, v := range args {
 		oargs = append(oargs, object(v))
 	}
 
-	res, err := f.Value(oargs...)
+	switch f := m.(type) {
+	case *objects.UserFunction:
+		res, err := f.Value(oargs...)
+		return callres{t: c.t, o: res, e: err}
+	case *objects.InteropFunction:
+		res, err := f.Value(interop{}, oargs...)
+		return callres{t: c.t, o: res, e: err}
+	}
+
+	return callres{t: c.t, e: fmt.Errorf("non-callable: %s", funcName)}
+}
+
+// interop calls back Go callable objects on behalf of the VM.
+type interop struct{}
+
+func (interop) Call(fn objects.Object, args ...objects.Object) (objects.Object, error) {
+	f, ok := fn.(objects.Callable)
+	if !ok {
+		return nil, fmt.Errorf("not callable: %s", fn.TypeName())
+	}
+
+	return f.Call(args...)
+}
+
+// runner calls back Go callable objects on behalf of the host application.
+type runner struct{}
 
-	return callres{t: c.t, o: res, e: err}
+func (runner) RunFunction(fn objects.Object, args ...objects.Object) (objects.Object, error) {
+	return interop{}.Call(fn, args...)
 }
 
 func (c callres) expect(expected interface{}, msgAndArgs ...interface{}) bool {
@@ -66,7 +95,7 @@ func module(t *testing.T, moduleName string) callres {
 		return callres{t: t, e: fmt.Errorf("module not found: %s", moduleName)}
 	}
 
-	return callres{t: t, o: (*mod).(*objects.ImmutableMap)}
+	return callres{t: t, o: (*mod).(*objects.BuiltinModule).Module()}
 }
 
 func object(v interface{}) objects.Object {
@@ -0,0 +1,81 @@
+//go:build !tengo_no_sysinfo && !tengo_embedded && !tinygo
+// +build !tengo_no_sysinfo,!tengo_embedded,!tinygo
+
+package stdlib
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("sysinfo", sysinfoModule)
+}
+
+func sysinfoModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"os":         &objects.String{Value: runtime.GOOS},
+		"arch":       &objects.String{Value: runtime.GOARCH},
+		"go_version": &objects.String{Value: runtime.Version()},
+		"hostname":   &objects.UserFunction{Name: "hostname", Value: FuncARSE(os.Hostname)},  // hostname() => string/error
+		"pid":        &objects.UserFunction{Name: "pid", Value: FuncARI(os.Getpid)},          // pid() => int
+		"num_cpu":    &objects.UserFunction{Name: "num_cpu", Value: FuncARI(runtime.NumCPU)}, // num_cpu() => int
+		"memory":     &objects.UserFunction{Name: "memory", Value: sysinfoMemory},            // memory() => {total:,free:,available:}/error
+		"go_memory":  &objects.UserFunction{Name: "go_memory", Value: sysinfoGoMemory},       // go_memory() => {alloc:,sys:,...}
+		"uptime":     &objects.UserFunction{Name: "uptime", Value: sysinfoUptime},            // uptime() => int/error
+	}
+}
+
+// sysinfoMemory returns the memory of the system in bytes.
+func sysinfoMemory(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	total, free, available, err := systemMemory()
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	return &objects.ImmutableMap{Value: map[string]objects.Object{
+		"total":     &objects.Int{Value: total},
+		"free":      &objects.Int{Value: free},
+		"available": &objects.Int{Value: available},
+	}}, nil
+}
+
+// sysinfoGoMemory returns the memory statistics of the Go runtime of the
+// process.
+func sysinfoGoMemory(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	return &objects.ImmutableMap{Value: map[string]objects.Object{
+		"alloc":        &objects.Int{Value: int64(ms.Alloc)},
+		"total_alloc":  &objects.Int{Value: int64(ms.TotalAlloc)},
+		"sys":          &objects.Int{Value: int64(ms.Sys)},
+		"heap_objects": &objects.Int{Value: int64(ms.HeapObjects)},
+		"num_gc":       &objects.Int{Value: int64(ms.NumGC)},
+	}}, nil
+}
+
+// sysinfoUptime returns the time since the system was booted as a duration
+// (in nanoseconds).
+func sysinfoUptime(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	d, err := systemUptime()
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	return &objects.Int{Value: int64(d)}, nil
+}
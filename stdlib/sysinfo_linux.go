@@ -0,0 +1,83 @@
+//go:build !tengo_no_sysinfo && !tengo_embedded && !tinygo
+// +build !tengo_no_sysinfo,!tengo_embedded,!tinygo
+
+package stdlib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemMemory reads the memory of the system (in bytes) from
+// /proc/meminfo.
+func systemMemory() (total, free, available int64, err error) {
+	data, err := ioutil.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	found := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// e.g. "MemTotal:       16384256 kB"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		var dst *int64
+		switch fields[0] {
+		case "MemTotal:":
+			dst = &total
+		case "MemFree:":
+			dst = &free
+		case "MemAvailable:":
+			dst = &available
+		default:
+			continue
+		}
+
+		n, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid /proc/meminfo: %s", scanner.Text())
+		}
+		if len(fields) > 2 && fields[2] == "kB" {
+			n *= 1024
+		}
+
+		*dst = n
+		found++
+	}
+
+	if found < 3 {
+		return 0, 0, 0, fmt.Errorf("invalid /proc/meminfo")
+	}
+
+	return total, free, available, nil
+}
+
+// systemUptime reads the uptime of the system from /proc/uptime.
+func systemUptime() (time.Duration, error) {
+	data, err := ioutil.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, err
+	}
+
+	// e.g. "350735.47 234388.90": the uptime and the idle time in seconds
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("invalid /proc/uptime")
+	}
+
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid /proc/uptime: %s", fields[0])
+	}
+
+	return time.Duration(secs * float64(time.Second)), nil
+}
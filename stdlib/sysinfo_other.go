@@ -0,0 +1,21 @@
+//go:build !linux && !tengo_no_sysinfo && !tengo_embedded && !tinygo
+// +build !linux,!tengo_no_sysinfo,!tengo_embedded,!tinygo
+
+package stdlib
+
+import (
+	"errors"
+	"time"
+)
+
+// errSysinfoUnsupported is the error of the system information that is not
+// available on the platform.
+var errSysinfoUnsupported = errors.New("not supported on this platform")
+
+func systemMemory() (total, free, available int64, err error) {
+	return 0, 0, 0, errSysinfoUnsupported
+}
+
+func systemUptime() (time.Duration, error) {
+	return 0, errSysinfoUnsupported
+}
@@ -0,0 +1,51 @@
+//go:build !tengo_no_sysinfo && !tengo_embedded && !tinygo
+// +build !tengo_no_sysinfo,!tengo_embedded,!tinygo
+
+package stdlib_test
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+)
+
+func TestSysinfo(t *testing.T) {
+	hostname, err := os.Hostname()
+	assert.NoError(t, err)
+
+	mod := module(t, "sysinfo")
+	members := mod.o.(*objects.ImmutableMap).Value
+	assert.Equal(t, objects.Object(&objects.String{Value: runtime.GOOS}), members["os"])
+	assert.Equal(t, objects.Object(&objects.String{Value: runtime.GOARCH}), members["arch"])
+
+	mod.call("hostname").expect(hostname)
+	mod.call("pid").expect(os.Getpid())
+	mod.call("num_cpu").expect(runtime.NumCPU())
+	mod.call("pid", 1).expectError()
+
+	mem := mod.call("go_memory")
+	if assert.NoError(t, mem.e) {
+		alloc := mem.o.(*objects.ImmutableMap).Value["alloc"].(*objects.Int)
+		assert.True(t, alloc.Value > 0)
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	mem = mod.call("memory")
+	if assert.NoError(t, mem.e) {
+		m := mem.o.(*objects.ImmutableMap).Value
+		total := m["total"].(*objects.Int).Value
+		available := m["available"].(*objects.Int).Value
+		assert.True(t, total > 0 && available > 0 && available <= total)
+	}
+
+	uptime := mod.call("uptime")
+	if assert.NoError(t, uptime.e) {
+		assert.True(t, uptime.o.(*objects.Int).Value > 0)
+	}
+}
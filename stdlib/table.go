@@ -0,0 +1,1292 @@
+//go:build !tengo_no_table
+// +build !tengo_no_table
+
+package stdlib
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("table", tableModule)
+}
+
+func tableModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"new":       &objects.UserFunction{Name: "new", Value: tableNew},            // new(rows, columns) => table
+		"from_csv":  &objects.UserFunction{Name: "from_csv", Value: tableFromCSV},   // from_csv(data, options) => table/error
+		"from_json": &objects.UserFunction{Name: "from_json", Value: tableFromJSON}, // from_json(data) => table/error
+	}
+}
+
+func tableNew(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	rows, ok := toArray(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "array",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	var columns []string
+	if len(args) == 2 {
+		var err error
+		if columns, err = tableColumnNames(args[1], "second"); err != nil {
+			return nil, err
+		}
+	}
+
+	return tableFromRows(rows, columns)
+}
+
+// tableFromRows returns the table of the rows (the maps). The columns are the
+// sorted keys of the rows if they are not given.
+func tableFromRows(arr []objects.Object, columns []string) (*table, error) {
+	t := &table{rows: make([]map[string]objects.Object, 0, len(arr))}
+	for i, elem := range arr {
+		row, ok := toStringMap(elem)
+		if !ok {
+			return nil, fmt.Errorf("invalid row at index %d: %s", i, elem)
+		}
+
+		r := make(map[string]objects.Object, len(row))
+		for k, v := range row {
+			if v != objects.UndefinedValue {
+				r[k] = v
+			}
+		}
+		t.rows = append(t.rows, r)
+	}
+
+	if columns != nil {
+		t.columns = columns
+		return t, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, row := range t.rows {
+		for k := range row {
+			if !seen[k] {
+				seen[k] = true
+				t.columns = append(t.columns, k)
+			}
+		}
+	}
+	sort.Strings(t.columns)
+
+	return t, nil
+}
+
+func tableFromCSV(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	data, ok := objects.ToByteSlice(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string/bytes",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	opts := &tableCSVOptions{header: true, comma: ','}
+	if len(args) == 2 {
+		if err := opts.parse(args[1], true); err != nil {
+			return nil, err
+		}
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = opts.comma
+	records, err := r.ReadAll()
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	columns := opts.columns
+	if opts.header {
+		if len(records) == 0 {
+			return wrapError(errors.New("missing header")), nil
+		}
+		if columns == nil {
+			columns = records[0]
+		}
+		records = records[1:]
+	} else if columns == nil {
+		return nil, errors.New("missing table option 'columns'")
+	}
+	if len(records) > objects.MaxArrayLen {
+		return nil, objects.ErrArrayLimit
+	}
+
+	t := &table{columns: columns, rows: make([]map[string]objects.Object, 0, len(records))}
+	for i, record := range records {
+		if len(record) != len(columns) {
+			return wrapError(fmt.Errorf("record %d: wrong number of fields", i+1)), nil
+		}
+
+		row := make(map[string]objects.Object, len(columns))
+		for j, field := range record {
+			if !opts.infer {
+				row[columns[j]] = &objects.String{Value: field}
+			} else if v := tableInfer(field); v != nil {
+				row[columns[j]] = v
+			}
+		}
+		t.rows = append(t.rows, row)
+	}
+
+	return t, nil
+}
+
+// tableInfer returns the int, float or bool value of the CSV field, or, nil
+// if the field is empty.
+func tableInfer(field string) objects.Object {
+	switch field {
+	case "":
+		return nil
+	case "true":
+		return objects.TrueValue
+	case "false":
+		return objects.FalseValue
+	}
+
+	if i, err := strconv.ParseInt(field, 10, 64); err == nil {
+		return &objects.Int{Value: i}
+	}
+	// "inf" and "nan" are strings
+	if c := field[len(field)-1]; c >= '0' && c <= '9' || c == '.' {
+		if f, err := strconv.ParseFloat(field, 64); err == nil {
+			return &objects.Float{Value: f}
+		}
+	}
+
+	return &objects.String{Value: field}
+}
+
+func tableFromJSON(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	res, err := fromJSON(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if _, isErr := res.(*objects.Error); isErr {
+		return res, nil
+	}
+
+	rows, ok := res.(*objects.Array)
+	if !ok {
+		return wrapError(fmt.Errorf("invalid rows: %s", res.TypeName())), nil
+	}
+
+	t, err := tableFromRows(rows.Value, nil)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	return t, nil
+}
+
+// tableCSVOptions are the options of table.from_csv and to_csv.
+type tableCSVOptions struct {
+	header  bool
+	comma   rune
+	columns []string
+	infer   bool
+}
+
+// parse reads the options from the map. The columns and infer options are
+// accepted only if read is true.
+func (opts *tableCSVOptions) parse(o objects.Object, read bool) error {
+	m, ok := toStringMap(o)
+	if !ok {
+		name := "second"
+		if !read {
+			name = "first"
+		}
+		return objects.ErrInvalidArgumentType{
+			Name:     name,
+			Expected: "map",
+			Found:    o.TypeName(),
+		}
+	}
+
+	for k, v := range m {
+		switch {
+		case k == "header":
+			b, ok := v.(*objects.Bool)
+			if !ok {
+				return fmt.Errorf("invalid table option '%s': %s", k, v)
+			}
+			opts.header = !b.IsFalsy()
+		case k == "comma":
+			s, ok := v.(*objects.String)
+			if !ok || utf8.RuneCountInString(s.Value) != 1 {
+				return fmt.Errorf("invalid table option '%s': %s", k, v)
+			}
+			opts.comma, _ = utf8.DecodeRuneInString(s.Value)
+			if opts.comma == '"' || opts.comma == '\r' || opts.comma == '\n' || opts.comma == utf8.RuneError {
+				return fmt.Errorf("invalid table option '%s': %s", k, v)
+			}
+		case k == "columns" && read:
+			columns, err := tableColumnNames(v, k)
+			if err != nil {
+				return fmt.Errorf("invalid table option '%s': %s", k, v)
+			}
+			opts.columns = columns
+		case k == "infer" && read:
+			b, ok := v.(*objects.Bool)
+			if !ok {
+				return fmt.Errorf("invalid table option '%s': %s", k, v)
+			}
+			opts.infer = !b.IsFalsy()
+		default:
+			return fmt.Errorf("unknown table option '%s'", k)
+		}
+	}
+
+	return nil
+}
+
+// table is an immutable list of the rows (the maps) with the ordered
+// columns. The operations return the new tables.
+type table struct {
+	columns []string
+	rows    []map[string]objects.Object
+}
+
+// TypeName returns the name of the type.
+func (t *table) TypeName() string {
+	return "table"
+}
+
+func (t *table) String() string {
+	return fmt.Sprintf("<table %d rows>", len(t.rows))
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (t *table) BinaryOp(op token.Token, rhs objects.Object) (objects.Object, error) {
+	return nil, objects.ErrInvalidOperator
+}
+
+// IsFalsy returns true if the table has no rows.
+func (t *table) IsFalsy() bool {
+	return len(t.rows) == 0
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (t *table) Equals(x objects.Object) bool {
+	return t == x
+}
+
+// Copy returns the table itself: it's immutable.
+func (t *table) Copy() objects.Object {
+	return t
+}
+
+// Iterate creates an iterator of the rows.
+func (t *table) Iterate() objects.Iterator {
+	return &tableIterator{t: t, idx: -1}
+}
+
+// IndexGet returns the row of the index, or, the method of the name.
+func (t *table) IndexGet(index objects.Object) (objects.Object, error) {
+	switch index := index.(type) {
+	case *objects.Int:
+		idx := index.Value
+		if idx < 0 {
+			idx += int64(len(t.rows))
+		}
+		if idx < 0 || idx >= int64(len(t.rows)) {
+			return objects.UndefinedValue, nil
+		}
+		return t.row(int(idx)), nil
+	case *objects.String:
+		switch index.Value {
+		case "columns":
+			return &objects.UserFunction{Name: "columns", Value: t.columnsFunc}, nil // columns() => [string]
+		case "rows":
+			return &objects.UserFunction{Name: "rows", Value: t.rowsFunc}, nil // rows() => [map]
+		case "len":
+			return &objects.UserFunction{Name: "len", Value: t.lenFunc}, nil // len() => int
+		case "head":
+			return &objects.UserFunction{Name: "head", Value: t.headFunc}, nil // head(n) => table
+		case "select":
+			return &objects.UserFunction{Name: "select", Value: t.selectFunc}, nil // select(columns...) => table
+		case "filter":
+			return &objects.InteropFunction{Name: "filter", Value: t.filterFunc}, nil // filter(fn) => table
+		case "with_column":
+			return &objects.InteropFunction{Name: "with_column", Value: t.withColumnFunc}, nil // with_column(name, fn) => table
+		case "sort_by":
+			return &objects.UserFunction{Name: "sort_by", Value: t.sortByFunc}, nil // sort_by(column, descending) => table
+		case "group_by":
+			return &objects.UserFunction{Name: "group_by", Value: t.groupByFunc}, nil // group_by(columns...) => groups
+		case "aggregate":
+			return &objects.InteropFunction{Name: "aggregate", Value: t.aggregateFunc}, nil // aggregate(aggregations) => map
+		case "join":
+			return &objects.UserFunction{Name: "join", Value: t.joinFunc}, nil // join(other, on, options) => table
+		case "to_csv":
+			return &objects.UserFunction{Name: "to_csv", Value: t.toCSVFunc}, nil // to_csv(options) => string
+		case "to_json":
+			return &objects.UserFunction{Name: "to_json", Value: t.toJSONFunc}, nil // to_json() => bytes/error
+		}
+		return objects.UndefinedValue, nil
+	}
+
+	return nil, objects.ErrInvalidIndexType
+}
+
+// FieldNames returns nil: a table has no fields.
+func (t *table) FieldNames() []string {
+	return nil
+}
+
+// MethodNames returns the names of the table methods.
+func (t *table) MethodNames() []string {
+	return []string{"columns", "rows", "len", "head", "select", "filter", "with_column", "sort_by",
+		"group_by", "aggregate", "join", "to_csv", "to_json"}
+}
+
+// row returns the row of the index for the scripts.
+func (t *table) row(idx int) objects.Object {
+	return &objects.ImmutableMap{Value: t.rows[idx]}
+}
+
+// checkColumns returns an error if the table does not have the columns.
+func (t *table) checkColumns(columns []string) error {
+	for _, c := range columns {
+		found := false
+		for _, tc := range t.columns {
+			if tc == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown column '%s'", c)
+		}
+	}
+
+	return nil
+}
+
+func (t *table) columnsFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return fromStrings(t.columns), nil
+}
+
+func (t *table) rowsFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	arr := make([]objects.Object, 0, len(t.rows))
+	for _, row := range t.rows {
+		m := make(map[string]objects.Object, len(row))
+		for k, v := range row {
+			m[k] = v
+		}
+		arr = append(arr, &objects.Map{Value: m})
+	}
+
+	return &objects.Array{Value: arr}, nil
+}
+
+func (t *table) lenFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	return &objects.Int{Value: int64(len(t.rows))}, nil
+}
+
+func (t *table) headFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	n, ok := objects.ToInt(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "int(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n > len(t.rows) {
+		n = len(t.rows)
+	}
+
+	return &table{columns: t.columns, rows: t.rows[:n:n]}, nil
+}
+
+func (t *table) selectFunc(args ...objects.Object) (objects.Object, error) {
+	columns, err := tableColumnArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkColumns(columns); err != nil {
+		return nil, err
+	}
+
+	res := &table{columns: columns, rows: make([]map[string]objects.Object, 0, len(t.rows))}
+	for _, row := range t.rows {
+		r := make(map[string]objects.Object, len(columns))
+		for _, c := range columns {
+			if v, ok := row[c]; ok {
+				r[c] = v
+			}
+		}
+		res.rows = append(res.rows, r)
+	}
+
+	return res, nil
+}
+
+func (t *table) filterFunc(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	fn, err := tableCallable(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &table{columns: t.columns}
+	for i, row := range t.rows {
+		ret, err := rt.Call(fn, t.row(i))
+		if err != nil {
+			return nil, err
+		}
+		if !ret.IsFalsy() {
+			res.rows = append(res.rows, row)
+		}
+	}
+
+	return res, nil
+}
+
+func (t *table) withColumnFunc(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	name, ok := args[0].(*objects.String)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	fn, err := tableCallable(args[1], "second")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &table{columns: t.columns, rows: make([]map[string]objects.Object, 0, len(t.rows))}
+	if t.checkColumns([]string{name.Value}) != nil {
+		res.columns = append(t.columns[:len(t.columns):len(t.columns)], name.Value)
+	}
+
+	for i, row := range t.rows {
+		v, err := rt.Call(fn, t.row(i))
+		if err != nil {
+			return nil, err
+		}
+
+		r := make(map[string]objects.Object, len(row)+1)
+		for k, v := range row {
+			r[k] = v
+		}
+		if v == objects.UndefinedValue {
+			delete(r, name.Value)
+		} else {
+			r[name.Value] = v
+		}
+		res.rows = append(res.rows, r)
+	}
+
+	return res, nil
+}
+
+func (t *table) sortByFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	column, ok := args[0].(*objects.String)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string",
+			Found:    args[0].TypeName(),
+		}
+	}
+	if err := t.checkColumns([]string{column.Value}); err != nil {
+		return nil, err
+	}
+
+	descending := len(args) == 2 && !args[1].IsFalsy()
+
+	rows := make([]map[string]objects.Object, len(t.rows))
+	copy(rows, t.rows)
+
+	// the rows without the value are the last in both orders
+	var err error
+	sort.SliceStable(rows, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+
+		a, aok := rows[i][column.Value]
+		b, bok := rows[j][column.Value]
+		if !aok || !bok {
+			return aok
+		}
+
+		var less bool
+		if descending {
+			less, err = tableLess(b, a)
+		} else {
+			less, err = tableLess(a, b)
+		}
+		return less
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &table{columns: t.columns, rows: rows}, nil
+}
+
+func (t *table) groupByFunc(args ...objects.Object) (objects.Object, error) {
+	columns, err := tableColumnArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkColumns(columns); err != nil {
+		return nil, err
+	}
+
+	// the groups are in the order of their first rows
+	var groups []*tableGroup
+	index := make(map[string]*tableGroup)
+	for _, row := range t.rows {
+		values := make([]objects.Object, len(columns))
+		for i, c := range columns {
+			values[i] = tableValue(row, c)
+		}
+
+		key := tableKey(values)
+		g := index[key]
+		if g == nil {
+			g = &tableGroup{values: values, rows: &table{columns: t.columns}}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.rows.rows = append(g.rows.rows, row)
+	}
+
+	return makeTableGroups(columns, groups), nil
+}
+
+// tableGroup is the rows of a group and the values of the group columns.
+type tableGroup struct {
+	values []objects.Object
+	rows   *table
+}
+
+func makeTableGroups(columns []string, groups []*tableGroup) *objects.ImmutableMap {
+	key := func(g *tableGroup) map[string]objects.Object {
+		m := make(map[string]objects.Object, len(columns))
+		for i, c := range columns {
+			if g.values[i] != objects.UndefinedValue {
+				m[c] = g.values[i]
+			}
+		}
+		return m
+	}
+
+	return &objects.ImmutableMap{
+		Value: map[string]objects.Object{
+			// len() => int
+			"len": &objects.UserFunction{
+				Name: "len",
+				Value: func(args ...objects.Object) (objects.Object, error) {
+					if len(args) != 0 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					return &objects.Int{Value: int64(len(groups))}, nil
+				},
+			},
+			// groups() => [{key, table}]
+			"groups": &objects.UserFunction{
+				Name: "groups",
+				Value: func(args ...objects.Object) (objects.Object, error) {
+					if len(args) != 0 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					arr := make([]objects.Object, 0, len(groups))
+					for _, g := range groups {
+						arr = append(arr, &objects.ImmutableMap{
+							Value: map[string]objects.Object{
+								"key":   &objects.ImmutableMap{Value: key(g)},
+								"table": g.rows,
+							},
+						})
+					}
+
+					return &objects.Array{Value: arr}, nil
+				},
+			},
+			// aggregate(aggregations) => table
+			"aggregate": &objects.InteropFunction{
+				Name: "aggregate",
+				Value: func(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+					if len(args) != 1 {
+						return nil, objects.ErrWrongNumArguments
+					}
+
+					aggs, err := tableParseAggregations(args[0])
+					if err != nil {
+						return nil, err
+					}
+
+					res := &table{columns: append(columns[:len(columns):len(columns)], tableAggregationNames(aggs)...)}
+					for _, g := range groups {
+						row := key(g)
+						if err := g.rows.aggregate(rt, aggs, row); err != nil {
+							return nil, err
+						}
+						res.rows = append(res.rows, row)
+					}
+
+					return res, nil
+				},
+			},
+		},
+	}
+}
+
+func (t *table) aggregateFunc(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	aggs, err := tableParseAggregations(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]objects.Object, len(aggs))
+	if err := t.aggregate(rt, aggs, res); err != nil {
+		return nil, err
+	}
+
+	return &objects.Map{Value: res}, nil
+}
+
+// tableAggregation is an aggregation of a column (e.g. ["sum", "amount"]),
+// or, a function that takes the table of the rows.
+type tableAggregation struct {
+	op     string
+	column string
+	fn     objects.Object
+}
+
+// tableAggregationOps are the operations of the aggregations. count without
+// the column counts the rows.
+var tableAggregationOps = map[string]bool{
+	"count":  true,
+	"sum":    true,
+	"mean":   true,
+	"min":    true,
+	"max":    true,
+	"first":  true,
+	"last":   true,
+	"values": true,
+}
+
+func tableParseAggregations(o objects.Object) (map[string]*tableAggregation, error) {
+	m, ok := toStringMap(o)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "map",
+			Found:    o.TypeName(),
+		}
+	}
+
+	aggs := make(map[string]*tableAggregation, len(m))
+	for name, v := range m {
+		if _, err := tableCallable(v, name); err == nil {
+			aggs[name] = &tableAggregation{fn: v}
+			continue
+		}
+
+		spec, ok := toArray(v)
+		if !ok || len(spec) < 1 || len(spec) > 2 {
+			return nil, fmt.Errorf("invalid aggregation '%s': %s", name, v)
+		}
+		op, ok := spec[0].(*objects.String)
+		if !ok || !tableAggregationOps[op.Value] {
+			return nil, fmt.Errorf("invalid aggregation '%s': %s", name, v)
+		}
+
+		agg := &tableAggregation{op: op.Value}
+		if len(spec) == 2 {
+			column, ok := spec[1].(*objects.String)
+			if !ok {
+				return nil, fmt.Errorf("invalid aggregation '%s': %s", name, v)
+			}
+			agg.column = column.Value
+		} else if op.Value != "count" {
+			return nil, fmt.Errorf("invalid aggregation '%s': %s", name, v)
+		}
+		aggs[name] = agg
+	}
+
+	return aggs, nil
+}
+
+// tableAggregationNames returns the sorted names of the aggregations.
+func tableAggregationNames(aggs map[string]*tableAggregation) []string {
+	names := make([]string, 0, len(aggs))
+	for name := range aggs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// aggregate adds the aggregated values of the rows to the row.
+func (t *table) aggregate(rt objects.Interop, aggs map[string]*tableAggregation, row map[string]objects.Object) error {
+	for _, name := range tableAggregationNames(aggs) {
+		agg := aggs[name]
+		if agg.fn != nil {
+			v, err := rt.Call(agg.fn, t)
+			if err != nil {
+				return err
+			}
+			if v != objects.UndefinedValue {
+				row[name] = v
+			}
+			continue
+		}
+
+		if agg.column != "" {
+			if err := t.checkColumns([]string{agg.column}); err != nil {
+				return err
+			}
+		}
+
+		v, err := t.aggregateColumn(agg)
+		if err != nil {
+			return fmt.Errorf("aggregation '%s': %s", name, err.Error())
+		}
+		if v != objects.UndefinedValue {
+			row[name] = v
+		}
+	}
+
+	return nil
+}
+
+// aggregateColumn returns the aggregated value of the column. The rows
+// without the value are skipped, and, the value is undefined if there are no
+// values (except count).
+func (t *table) aggregateColumn(agg *tableAggregation) (objects.Object, error) {
+	if agg.op == "count" && agg.column == "" {
+		return &objects.Int{Value: int64(len(t.rows))}, nil
+	}
+
+	var values []objects.Object
+	for _, row := range t.rows {
+		if v, ok := row[agg.column]; ok {
+			values = append(values, v)
+		}
+	}
+
+	switch agg.op {
+	case "count":
+		return &objects.Int{Value: int64(len(values))}, nil
+	case "values":
+		if len(values) > objects.MaxArrayLen {
+			return nil, objects.ErrArrayLimit
+		}
+		return &objects.Array{Value: values}, nil
+	}
+
+	if len(values) == 0 {
+		return objects.UndefinedValue, nil
+	}
+
+	switch agg.op {
+	case "first":
+		return values[0], nil
+	case "last":
+		return values[len(values)-1], nil
+	case "min", "max":
+		res := values[0]
+		for _, v := range values[1:] {
+			var less bool
+			var err error
+			if agg.op == "min" {
+				less, err = tableLess(v, res)
+			} else {
+				less, err = tableLess(res, v)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if less {
+				res = v
+			}
+		}
+		return res, nil
+	}
+
+	// sum and mean
+	var sum objects.Object = &objects.Int{}
+	for _, v := range values {
+		switch v.(type) {
+		case *objects.Int, *objects.Float, *objects.Char:
+		default:
+			return nil, fmt.Errorf("invalid value: %s", v)
+		}
+		var err error
+		if sum, err = sum.BinaryOp(token.Add, v); err != nil {
+			return nil, err
+		}
+	}
+	if agg.op == "sum" {
+		return sum, nil
+	}
+
+	f, _ := objects.ToFloat64(sum)
+	return &objects.Float{Value: f / float64(len(values))}, nil
+}
+
+func (t *table) joinFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	other, ok := args[0].(*table)
+	if !ok {
+		arr, ok := toArray(args[0])
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "first",
+				Expected: "table/array",
+				Found:    args[0].TypeName(),
+			}
+		}
+		var err error
+		if other, err = tableFromRows(arr, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	on, err := tableColumnNames(args[1], "second")
+	if err != nil {
+		return nil, err
+	}
+	if len(on) == 0 {
+		return nil, errors.New("missing join columns")
+	}
+	if err := t.checkColumns(on); err != nil {
+		return nil, err
+	}
+	if err := other.checkColumns(on); err != nil {
+		return nil, err
+	}
+
+	how, suffix := "inner", "_right"
+	if len(args) == 3 {
+		m, ok := toStringMap(args[2])
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "third",
+				Expected: "map",
+				Found:    args[2].TypeName(),
+			}
+		}
+		for k, v := range m {
+			s, ok := v.(*objects.String)
+			switch {
+			case k == "how":
+				if !ok || (s.Value != "inner" && s.Value != "left" && s.Value != "outer") {
+					return nil, fmt.Errorf("invalid table option '%s': %s", k, v)
+				}
+				how = s.Value
+			case k == "suffix":
+				if !ok || s.Value == "" {
+					return nil, fmt.Errorf("invalid table option '%s': %s", k, v)
+				}
+				suffix = s.Value
+			default:
+				return nil, fmt.Errorf("unknown table option '%s'", k)
+			}
+		}
+	}
+
+	// the columns of the other table except the join columns, renamed with
+	// the suffix if the table has the same columns
+	isOn := make(map[string]bool, len(on))
+	for _, c := range on {
+		isOn[c] = true
+	}
+	res := &table{columns: t.columns[:len(t.columns):len(t.columns)]}
+	renamed := make(map[string]string)
+	for _, c := range other.columns {
+		if isOn[c] {
+			continue
+		}
+		name := c
+		if t.checkColumns([]string{c}) == nil {
+			name = c + suffix
+		}
+		renamed[c] = name
+		res.columns = append(res.columns, name)
+	}
+
+	// the rows with the missing join values are not matched
+	joinKey := func(row map[string]objects.Object) (string, bool) {
+		values := make([]objects.Object, len(on))
+		for i, c := range on {
+			v, ok := row[c]
+			if !ok {
+				return "", false
+			}
+			values[i] = v
+		}
+		return tableKey(values), true
+	}
+
+	index := make(map[string][]int)
+	for i, row := range other.rows {
+		if key, ok := joinKey(row); ok {
+			index[key] = append(index[key], i)
+		}
+	}
+
+	merge := func(left, right map[string]objects.Object) error {
+		if len(res.rows) >= objects.MaxArrayLen {
+			return objects.ErrArrayLimit
+		}
+
+		row := make(map[string]objects.Object, len(left)+len(right))
+		for k, v := range left {
+			row[k] = v
+		}
+		for k, v := range right {
+			if isOn[k] {
+				if left == nil {
+					row[k] = v
+				}
+			} else if name, ok := renamed[k]; ok {
+				row[name] = v
+			}
+		}
+		res.rows = append(res.rows, row)
+
+		return nil
+	}
+
+	matched := make([]bool, len(other.rows))
+	for _, row := range t.rows {
+		var matches []int
+		if key, ok := joinKey(row); ok {
+			matches = index[key]
+		}
+		for _, i := range matches {
+			matched[i] = true
+			if err := merge(row, other.rows[i]); err != nil {
+				return nil, err
+			}
+		}
+		if len(matches) == 0 && how != "inner" {
+			if err := merge(row, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if how == "outer" {
+		for i, row := range other.rows {
+			if !matched[i] {
+				if err := merge(nil, row); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (t *table) toCSVFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) > 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	opts := &tableCSVOptions{header: true, comma: ','}
+	if len(args) == 1 {
+		if err := opts.parse(args[0], false); err != nil {
+			return nil, err
+		}
+	}
+
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.Comma = opts.comma
+	if opts.header {
+		_ = w.Write(t.columns)
+	}
+
+	record := make([]string, len(t.columns))
+	for _, row := range t.rows {
+		for i, c := range t.columns {
+			v, ok := row[c]
+			switch {
+			case !ok:
+				record[i] = ""
+			default:
+				if s, ok := objects.ToString(v); ok {
+					record[i] = s
+				} else {
+					record[i] = v.String()
+				}
+			}
+		}
+		_ = w.Write(record)
+
+		if sb.Len() > objects.MaxStringLen {
+			return nil, objects.ErrStringLimit
+		}
+	}
+	w.Flush()
+	if sb.Len() > objects.MaxStringLen {
+		return nil, objects.ErrStringLimit
+	}
+
+	return &objects.String{Value: sb.String()}, nil
+}
+
+func (t *table) toJSONFunc(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	rows, _ := t.rowsFunc()
+
+	return toJSON(rows)
+}
+
+type tableIterator struct {
+	t   *table
+	idx int
+}
+
+// TypeName returns the name of the type.
+func (i *tableIterator) TypeName() string {
+	return "table-iterator"
+}
+
+func (i *tableIterator) String() string {
+	return "<table-iterator>"
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (i *tableIterator) BinaryOp(op token.Token, rhs objects.Object) (objects.Object, error) {
+	return nil, objects.ErrInvalidOperator
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (i *tableIterator) IsFalsy() bool {
+	return true
+}
+
+// Equals returns true if the value of the type
+// is equal to the value of another object.
+func (i *tableIterator) Equals(objects.Object) bool {
+	return false
+}
+
+// Copy returns a copy of the type.
+func (i *tableIterator) Copy() objects.Object {
+	c := *i
+
+	return &c
+}
+
+// Next returns true if there are more rows to iterate.
+func (i *tableIterator) Next() bool {
+	i.idx++
+
+	return i.idx < len(i.t.rows)
+}
+
+// Key returns the index of the current row.
+func (i *tableIterator) Key() objects.Object {
+	return &objects.Int{Value: int64(i.idx)}
+}
+
+// Value returns the current row.
+func (i *tableIterator) Value() objects.Object {
+	return i.t.row(i.idx)
+}
+
+// tableLess returns true if a is less than b: the strings are compared
+// lexically, and, the other values are compared with '<' operator.
+func tableLess(a, b objects.Object) (bool, error) {
+	if a, ok := a.(*objects.String); ok {
+		if b, ok := b.(*objects.String); ok {
+			return a.Value < b.Value, nil
+		}
+	}
+
+	if c, ok := a.(objects.Comparable); ok {
+		r, err := c.Compare(b)
+		if err != nil {
+			return false, fmt.Errorf("cannot compare %s and %s", a.TypeName(), b.TypeName())
+		}
+		return r < 0, nil
+	}
+
+	res, err := a.BinaryOp(token.Less, b)
+	if err != nil {
+		return false, fmt.Errorf("cannot compare %s and %s", a.TypeName(), b.TypeName())
+	}
+
+	return !res.IsFalsy(), nil
+}
+
+// tableKey returns the key of the values to group or join the rows: the
+// values of the different types are different (e.g. 1 and "1").
+func tableKey(values []objects.Object) string {
+	var sb strings.Builder
+	for _, v := range values {
+		sb.WriteString(v.TypeName())
+		sb.WriteByte(':')
+		sb.WriteString(v.String())
+		sb.WriteByte(0)
+	}
+
+	return sb.String()
+}
+
+// tableValue returns the value of the column, or, undefined if the row does
+// not have the value.
+func tableValue(row map[string]objects.Object, column string) objects.Object {
+	if v, ok := row[column]; ok {
+		return v
+	}
+
+	return objects.UndefinedValue
+}
+
+// tableColumnArgs returns the column names of the arguments: the strings, or,
+// an array of the strings.
+func tableColumnArgs(args []objects.Object) ([]string, error) {
+	if len(args) == 1 {
+		if _, ok := toArray(args[0]); ok {
+			return tableColumnNames(args[0], "first")
+		}
+	}
+
+	columns := make([]string, 0, len(args))
+	for i, arg := range args {
+		s, ok := arg.(*objects.String)
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     fmt.Sprintf("args[%d]", i),
+				Expected: "string",
+				Found:    arg.TypeName(),
+			}
+		}
+		columns = append(columns, s.Value)
+	}
+
+	return columns, nil
+}
+
+// tableColumnNames returns the column names of the string or the array of the
+// strings.
+func tableColumnNames(o objects.Object, argName string) ([]string, error) {
+	if s, ok := o.(*objects.String); ok {
+		return []string{s.Value}, nil
+	}
+
+	arr, ok := toArray(o)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     argName,
+			Expected: "string/array",
+			Found:    o.TypeName(),
+		}
+	}
+
+	columns := make([]string, 0, len(arr))
+	for idx, elem := range arr {
+		s, ok := elem.(*objects.String)
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     fmt.Sprintf("%s[%d]", argName, idx),
+				Expected: "string",
+				Found:    elem.TypeName(),
+			}
+		}
+		columns = append(columns, s.Value)
+	}
+
+	return columns, nil
+}
+
+func tableCallable(o objects.Object, argName string) (objects.Object, error) {
+	switch o.(type) {
+	case *objects.CompiledFunction, *objects.Closure, objects.Callable, objects.InteropCallable:
+		return o, nil
+	}
+
+	return nil, objects.ErrInvalidArgumentType{
+		Name:     argName,
+		Expected: "callable",
+		Found:    o.TypeName(),
+	}
+}
@@ -0,0 +1,266 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+var tableSales = ARR{
+	MAP{"region": "east", "product": "a", "amount": 10},
+	MAP{"region": "west", "product": "a", "amount": 5},
+	MAP{"region": "east", "product": "b", "amount": 2.5},
+	MAP{"region": "north", "product": "c"},
+}
+
+func tableFunc(fn func(args ...objects.Object) (objects.Object, error)) *objects.UserFunction {
+	return &objects.UserFunction{Value: fn}
+}
+
+func TestTable(t *testing.T) {
+	module(t, "table").call("new").expectError()
+	module(t, "table").call("new", 1).expectError()
+	module(t, "table").call("new", ARR{1}).expectError()
+	module(t, "table").call("new", ARR{}, ARR{1}).expectError()
+
+	sales := module(t, "table").call("new", tableSales)
+	sales.call("columns").expect(ARR{"amount", "product", "region"})
+	sales.call("len").expect(4)
+	sales.call("rows").expect(tableSales)
+	sales.call("head", 2).call("rows").expect(tableSales[:2])
+	sales.call("head", 10).call("len").expect(4)
+	sales.call("head", -1).call("len").expect(0)
+	sales.call("head", "a").expectError()
+
+	// the rows are copied
+	rows := sales.call("rows")
+	rows.o.(*objects.Array).Value[0].(*objects.Map).Value["amount"] = &objects.Int{Value: 0}
+	sales.call("rows").expect(tableSales)
+
+	// the index and the iteration
+	tbl := sales.o.(objects.Indexable)
+	row, err := tbl.IndexGet(&objects.Int{Value: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, object(IMAP{"region": "north", "product": "c"}), row)
+	row, err = tbl.IndexGet(&objects.Int{Value: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, objects.UndefinedValue, row)
+	_, err = tbl.IndexGet(objects.TrueValue)
+	assert.Error(t, err)
+
+	it := sales.o.(objects.Iterable).Iterate()
+	var n int64
+	for it.Next() {
+		assert.Equal(t, &objects.Int{Value: n}, it.Key())
+		assert.Equal(t, object(tableSales[n]), &objects.Map{Value: it.Value().(*objects.ImmutableMap).Value})
+		n++
+	}
+	assert.Equal(t, int64(4), n)
+
+	// select
+	sales.call("select", "region", "amount").call("columns").expect(ARR{"region", "amount"})
+	sales.call("select", ARR{"product"}).call("rows").
+		expect(ARR{MAP{"product": "a"}, MAP{"product": "a"}, MAP{"product": "b"}, MAP{"product": "c"}})
+	sales.call("select", "price").expectError()
+	sales.call("select", 1).expectError()
+
+	// filter and with_column
+	sales.call("filter", tableFunc(func(args ...objects.Object) (objects.Object, error) {
+		v, _ := args[0].(*objects.ImmutableMap).IndexGet(&objects.String{Value: "region"})
+		return objects.FromInterface(v.(*objects.String).Value == "east")
+	})).call("rows").expect(ARR{tableSales[0], tableSales[2]})
+	sales.call("filter", 1).expectError()
+
+	withTax := sales.call("with_column", "tax", tableFunc(func(args ...objects.Object) (objects.Object, error) {
+		v, _ := args[0].(*objects.ImmutableMap).IndexGet(&objects.String{Value: "amount"})
+		if v == objects.UndefinedValue {
+			return v, nil
+		}
+		return v.BinaryOp(token.Mul, &objects.Float{Value: 0.5})
+	}))
+	withTax.call("columns").expect(ARR{"amount", "product", "region", "tax"})
+	withTax.call("select", "tax").call("rows").expect(ARR{MAP{"tax": 5.0}, MAP{"tax": 2.5}, MAP{"tax": 1.25}, MAP{}})
+	sales.call("columns").expect(ARR{"amount", "product", "region"})
+
+	// sort_by
+	sales.call("sort_by", "amount").call("select", "amount").call("rows").
+		expect(ARR{MAP{"amount": 2.5}, MAP{"amount": 5}, MAP{"amount": 10}, MAP{}})
+	sales.call("sort_by", "amount", true).call("select", "amount").call("rows").
+		expect(ARR{MAP{"amount": 10}, MAP{"amount": 5}, MAP{"amount": 2.5}, MAP{}})
+	sales.call("sort_by", "region").call("select", "product").call("rows").
+		expect(ARR{MAP{"product": "a"}, MAP{"product": "b"}, MAP{"product": "c"}, MAP{"product": "a"}})
+	sales.call("sort_by", "price").expectError()
+	module(t, "table").call("new", ARR{MAP{"a": 1}, MAP{"a": "x"}}).call("sort_by", "a").expectError()
+}
+
+func TestTableAggregate(t *testing.T) {
+	sales := module(t, "table").call("new", tableSales)
+
+	sales.call("aggregate", MAP{
+		"n":       ARR{"count"},
+		"amounts": ARR{"count", "amount"},
+		"total":   ARR{"sum", "amount"},
+		"mean":    ARR{"mean", "amount"},
+		"min":     ARR{"min", "amount"},
+		"max":     ARR{"max", "product"},
+		"first":   ARR{"first", "amount"},
+		"last":    ARR{"last", "amount"},
+		"regions": ARR{"values", "region"},
+		"fn": tableFunc(func(args ...objects.Object) (objects.Object, error) {
+			return args[0].(objects.Indexable).IndexGet(&objects.Int{Value: 0})
+		}),
+	}).expect(MAP{
+		"n":       4,
+		"amounts": 3,
+		"total":   17.5,
+		"mean":    17.5 / 3,
+		"min":     2.5,
+		"max":     "c",
+		"first":   10,
+		"last":    2.5,
+		"regions": ARR{"east", "west", "east", "north"},
+		"fn":      IMAP{"region": "east", "product": "a", "amount": 10},
+	})
+	sales.call("head", 2).call("aggregate", MAP{"total": ARR{"sum", "amount"}}).expect(MAP{"total": 15})
+	sales.call("head", 0).call("aggregate", MAP{"total": ARR{"sum", "amount"}, "n": ARR{"count"}}).
+		expect(MAP{"n": 0})
+
+	sales.call("aggregate", 1).expectError()
+	sales.call("aggregate", MAP{"a": ARR{"median", "amount"}}).expectError()
+	sales.call("aggregate", MAP{"a": ARR{"sum"}}).expectError()
+	sales.call("aggregate", MAP{"a": ARR{"sum", 1}}).expectError()
+	sales.call("aggregate", MAP{"a": ARR{"sum", "price"}}).expectError()
+	sales.call("aggregate", MAP{"a": ARR{"sum", "region"}}).expectError()
+
+	// group_by
+	groups := sales.call("group_by", "region")
+	groups.call("len").expect(3)
+	groups.call("aggregate", MAP{"total": ARR{"sum", "amount"}, "n": ARR{"count"}}).call("rows").
+		expect(ARR{
+			MAP{"region": "east", "total": 12.5, "n": 2},
+			MAP{"region": "west", "total": 5, "n": 1},
+			MAP{"region": "north", "n": 1},
+		})
+	sales.call("group_by", "region", "product").
+		call("aggregate", MAP{"n": ARR{"count"}}).call("columns").expect(ARR{"region", "product", "n"})
+
+	res := groups.call("groups")
+	assert.NoError(t, res.e)
+	assert.Equal(t, 3, len(res.o.(*objects.Array).Value))
+	group := res.o.(*objects.Array).Value[1].(*objects.ImmutableMap).Value
+	assert.Equal(t, object(IMAP{"region": "west"}), group["key"])
+	callres{t: t, o: group["table"]}.call("rows").expect(ARR{tableSales[1]})
+
+	// the rows without the values are grouped together
+	sales.call("group_by", ARR{"amount"}).call("len").expect(4)
+	module(t, "table").call("new", ARR{MAP{"a": 1}, MAP{"b": 1}, MAP{"b": 2}}).
+		call("group_by", "a").call("aggregate", MAP{"n": ARR{"count"}}).call("rows").
+		expect(ARR{MAP{"a": 1, "n": 1}, MAP{"n": 2}})
+
+	sales.call("group_by", "price").expectError()
+	sales.call("group_by", 1).expectError()
+}
+
+func TestTableJoin(t *testing.T) {
+	sales := module(t, "table").call("new", tableSales)
+	regions := module(t, "table").call("new", ARR{
+		MAP{"region": "east", "manager": "kim", "amount": 100},
+		MAP{"region": "south", "manager": "lee"},
+	})
+
+	sales.call("join", regions.o, "region").call("rows").
+		expect(ARR{
+			MAP{"region": "east", "product": "a", "amount": 10, "manager": "kim", "amount_right": 100},
+			MAP{"region": "east", "product": "b", "amount": 2.5, "manager": "kim", "amount_right": 100},
+		})
+	sales.call("join", regions.o, "region").call("columns").
+		expect(ARR{"amount", "product", "region", "amount_right", "manager"})
+	sales.call("join", regions.o, ARR{"region"}, MAP{"how": "left", "suffix": "_r"}).call("select", "region", "manager").call("rows").
+		expect(ARR{
+			MAP{"region": "east", "manager": "kim"},
+			MAP{"region": "west"},
+			MAP{"region": "east", "manager": "kim"},
+			MAP{"region": "north"},
+		})
+	sales.call("join", ARR{MAP{"region": "south", "manager": "lee"}}, "region", MAP{"how": "outer"}).call("rows").
+		expect(ARR{
+			tableSales[0], tableSales[1], tableSales[2], tableSales[3],
+			MAP{"region": "south", "manager": "lee"},
+		})
+
+	// the rows without the join values are not matched
+	module(t, "table").call("new", ARR{MAP{"a": 1}, MAP{"b": 1}}).
+		call("join", ARR{MAP{"a": 1, "c": "x"}, MAP{"c": "y"}}, "a", MAP{"how": "left"}).call("rows").
+		expect(ARR{MAP{"a": 1, "c": "x"}, MAP{"b": 1}})
+	// 1 and "1" are different values
+	module(t, "table").call("new", ARR{MAP{"a": 1}}).
+		call("join", ARR{MAP{"a": "1", "b": 2}}, "a").call("len").expect(0)
+
+	sales.call("join", regions.o).expectError()
+	sales.call("join", 1, "region").expectError()
+	sales.call("join", regions.o, ARR{}).expectError()
+	sales.call("join", regions.o, "manager").expectError()
+	sales.call("join", regions.o, "region", MAP{"how": "cross"}).expectError()
+	sales.call("join", regions.o, "region", MAP{"on": "region"}).expectError()
+}
+
+func TestTableCSV(t *testing.T) {
+	module(t, "table").call("from_csv").expectError()
+	module(t, "table").call("from_csv", 1).expectError()
+	module(t, "table").call("from_csv", "a", MAP{"comma": ",,"}).expectError()
+	module(t, "table").call("from_csv", "a", MAP{"header": false}).expectError()
+	module(t, "table").call("from_csv", "a", MAP{"quote": "'"}).expectError()
+
+	data := "name,age,score\nkim,30,1.5\nlee,,\"2\"\n"
+	people := module(t, "table").call("from_csv", data)
+	people.call("columns").expect(ARR{"name", "age", "score"})
+	people.call("rows").expect(ARR{
+		MAP{"name": "kim", "age": "30", "score": "1.5"},
+		MAP{"name": "lee", "age": "", "score": "2"},
+	})
+	module(t, "table").call("from_csv", []byte(data), MAP{"infer": true}).call("rows").
+		expect(ARR{
+			MAP{"name": "kim", "age": 30, "score": 1.5},
+			MAP{"name": "lee", "score": 2},
+		})
+	module(t, "table").call("from_csv", "a;true\nb;inf\n", MAP{"header": false, "columns": ARR{"x", "y"}, "comma": ";", "infer": true}).
+		call("rows").expect(ARR{MAP{"x": "a", "y": true}, MAP{"x": "b", "y": "inf"}})
+	module(t, "table").call("from_csv", data, MAP{"columns": ARR{"n", "a", "s"}}).call("columns").expect(ARR{"n", "a", "s"})
+
+	csvError := func(data string, opts MAP, msg string) {
+		module(t, "table").call("from_csv", data, opts).
+			expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	csvError("", MAP{}, "missing header")
+	csvError("a,b\n1\n", MAP{}, "record on line 2: wrong number of fields")
+	csvError("a,b\n1,2\n", MAP{"columns": ARR{"x"}}, "record 1: wrong number of fields")
+
+	// to_csv
+	people.call("to_csv").expect("name,age,score\nkim,30,1.5\nlee,,2\n")
+	module(t, "table").call("new", ARR{MAP{"a": 1, "b": "x;y"}, MAP{"a": 2.5, "c": ARR{1}}}).
+		call("to_csv", MAP{"header": false, "comma": ";"}).expect("1;\"x;y\";\n2.5;;[1]\n")
+	people.call("to_csv", MAP{"infer": true}).expectError()
+	people.call("to_csv", 1).expectError()
+}
+
+func TestTableJSON(t *testing.T) {
+	module(t, "table").call("from_json").expectError()
+
+	people := module(t, "table").call("from_json", `[{"name": "kim", "age": 30}, {"name": "lee", "tags": ["a"]}]`)
+	people.call("columns").expect(ARR{"age", "name", "tags"})
+	people.call("rows").expect(ARR{MAP{"name": "kim", "age": 30.0}, MAP{"name": "lee", "tags": ARR{"a"}}})
+	people.call("to_json").expect([]byte(`[{"age":30,"name":"kim"},{"name":"lee","tags":["a"]}]`))
+
+	jsonError := func(data, msg string) {
+		module(t, "table").call("from_json", data).
+			expect(&objects.Error{Value: &objects.String{Value: msg}})
+	}
+	jsonError(`{"a": 1}`, "invalid rows: map")
+	jsonError(`[1]`, "invalid row at index 0: 1")
+	res := module(t, "table").call("from_json", `[`)
+	assert.NoError(t, res.e)
+	_, isErr := res.o.(*objects.Error)
+	assert.True(t, isErr)
+}
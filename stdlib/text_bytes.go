@@ -0,0 +1,167 @@
+//go:build !tengo_no_text
+// +build !tengo_no_text
+
+package stdlib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/d5/tengo/objects"
+)
+
+// index_byte(b, c) => int
+func textIndexByte(args ...objects.Object) (ret objects.Object, err error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	b1, ok := objects.ToByteSlice(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "bytes(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	c2, ok := objects.ToRune(args[1])
+	if !ok || c2 < 0 || c2 > 255 {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "byte",
+			Found:    args[1].TypeName(),
+		}
+	}
+
+	return &objects.Int{Value: int64(bytes.IndexByte(b1, byte(c2)))}, nil
+}
+
+// concat(b...) => bytes
+func textConcat(args ...objects.Object) (ret objects.Object, err error) {
+	var l int
+	bs := make([][]byte, len(args))
+	for idx, arg := range args {
+		b, ok := objects.ToByteSlice(arg)
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     fmt.Sprintf("args[%d]", idx),
+				Expected: "bytes(compatible)",
+				Found:    arg.TypeName(),
+			}
+		}
+
+		l += len(b)
+		if l > objects.MaxBytesLen {
+			return nil, objects.ErrBytesLimit
+		}
+		bs[idx] = b
+	}
+
+	res := make([]byte, 0, l)
+	for _, b := range bs {
+		res = append(res, b...)
+	}
+
+	return &objects.Bytes{Value: res}, nil
+}
+
+// to_hex(b) => string
+func textToHex(args ...objects.Object) (ret objects.Object, err error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	b1, ok := objects.ToByteSlice(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "bytes(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	if len(b1) > objects.MaxStringLen/2 {
+		return nil, objects.ErrStringLimit
+	}
+
+	return &objects.String{Value: hex.EncodeToString(b1)}, nil
+}
+
+// from_hex(s) => bytes/error
+func textFromHex(args ...objects.Object) (ret objects.Object, err error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	s1, ok := objects.ToString(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	res, err := hex.DecodeString(s1)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	return &objects.Bytes{Value: res}, nil
+}
+
+// textReadUint returns the function that reads an unsigned integer of the
+// size (2, 4 or 8 bytes) at the offset of the bytes:
+//
+//	read_uintN(b, offset, little_endian) => int/error
+//
+// The integer is big-endian unless little_endian is true. A 64-bit integer
+// larger than the maximum int value wraps around to a negative int.
+func textReadUint(size int) objects.CallableFunc {
+	return func(args ...objects.Object) (ret objects.Object, err error) {
+		numArgs := len(args)
+		if numArgs != 2 && numArgs != 3 {
+			return nil, objects.ErrWrongNumArguments
+		}
+
+		b1, ok := objects.ToByteSlice(args[0])
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "first",
+				Expected: "bytes(compatible)",
+				Found:    args[0].TypeName(),
+			}
+		}
+
+		i2, ok := objects.ToInt(args[1])
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "second",
+				Expected: "int(compatible)",
+				Found:    args[1].TypeName(),
+			}
+		}
+
+		var order binary.ByteOrder = binary.BigEndian
+		if numArgs == 3 && !args[2].IsFalsy() {
+			order = binary.LittleEndian
+		}
+
+		if i2 < 0 || i2 > len(b1)-size {
+			return wrapError(objects.ErrIndexOutOfBounds), nil
+		}
+
+		b := b1[i2 : i2+size]
+		switch size {
+		case 2:
+			return &objects.Int{Value: int64(order.Uint16(b))}, nil
+		case 4:
+			return &objects.Int{Value: int64(order.Uint32(b))}, nil
+		default:
+			return &objects.Int{Value: int64(order.Uint64(b))}, nil
+		}
+	}
+}
@@ -1,3 +1,6 @@
+//go:build !tengo_no_text
+// +build !tengo_no_text
+
 package stdlib
 
 import (
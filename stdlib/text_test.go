@@ -198,3 +198,45 @@ func TestText(t *testing.T) {
 	module(t, "text").call("parse_float", "-19.84", 64).expect(-19.84)
 	module(t, "text").call("parse_int", "-1984", 10, 64).expect(-1984)
 }
+
+func TestTextBytes(t *testing.T) {
+	module(t, "text").call("index_byte", []byte("abc"), 'c').expect(2)
+	module(t, "text").call("index_byte", "abc", 98).expect(1)
+	module(t, "text").call("index_byte", []byte("abc"), 'x').expect(-1)
+	module(t, "text").call("index_byte", []byte("abc"), 256).expectError()
+	module(t, "text").call("compare", []byte("ab"), []byte("b")).expect(-1)
+
+	module(t, "text").call("concat").expect([]byte{})
+	module(t, "text").call("concat", []byte("ab"), "c", []byte("d")).expect([]byte("abcd"))
+	module(t, "text").call("concat", []byte("ab"), 1).expectError()
+
+	module(t, "text").call("to_hex", []byte{0x01, 0xab}).expect("01ab")
+	module(t, "text").call("from_hex", "01AB").expect([]byte{0x01, 0xab})
+	module(t, "text").call("from_hex", "0").expect(&objects.Error{Value: &objects.String{Value: "encoding/hex: odd length hex string"}})
+
+	b := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0xff}
+	module(t, "text").call("read_uint16", b, 0).expect(0x0102)
+	module(t, "text").call("read_uint16", b, 1, true).expect(0x0302)
+	module(t, "text").call("read_uint32", b, 0).expect(0x01020304)
+	module(t, "text").call("read_uint32", b, 0, true).expect(0x04030201)
+	module(t, "text").call("read_uint64", b, 0).expect(0x0102030405060708)
+	module(t, "text").call("read_uint64", b, 1, true).expect(int64(-0xf7f8f9fafbfcfe))
+	module(t, "text").call("read_uint16", b, 8).expect(&objects.Error{Value: &objects.String{Value: "index out of bounds"}})
+	module(t, "text").call("read_uint16", b, -1).expect(&objects.Error{Value: &objects.String{Value: "index out of bounds"}})
+	module(t, "text").call("read_uint16", b).expectError()
+}
+
+func TestTextSizeLimit(t *testing.T) {
+	defer func(n int) { objects.MaxStringLen = n }(objects.MaxStringLen)
+	objects.MaxStringLen = 8
+
+	module(t, "text").call("repeat", "ab", 4).expect("abababab")
+	module(t, "text").call("repeat", "ab", 5).expectError()
+	module(t, "text").call("repeat", "ab", 1<<62).expectError()
+	module(t, "text").call("join", ARR{"ab", "cd", "e"}, "-").expect("ab-cd-e")
+	module(t, "text").call("join", ARR{"ab", "cd", "ef"}, "--").expectError()
+	module(t, "text").call("replace", "aaaa", "a", "bb", 4).expect("bbbbbbbb")
+	module(t, "text").call("replace", "aaaaa", "a", "bb", -1).expectError()
+	module(t, "text").call("replace", "aaaaa", "a", "bb", 3).expect("bbbbbbaa")
+	module(t, "text").call("replace", "aaaaaaaaa", "aa", "b", -1).expect("bbbba")
+}
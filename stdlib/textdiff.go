@@ -0,0 +1,550 @@
+//go:build !tengo_no_textdiff
+// +build !tengo_no_textdiff
+
+package stdlib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("textdiff", textdiffModule)
+}
+
+func textdiffModule() map[string]objects.Object {
+	return map[string]objects.Object{
+		"lines":   &objects.UserFunction{Name: "lines", Value: textdiffLines},     // lines(a, b) => [edit]
+		"words":   &objects.UserFunction{Name: "words", Value: textdiffWords},     // words(a, b) => [edit]
+		"unified": &objects.UserFunction{Name: "unified", Value: textdiffUnified}, // unified(a, b, options) => string
+		"merge":   &objects.UserFunction{Name: "merge", Value: textdiffMerge},     // merge(base, ours, theirs) => {text, conflicts}
+	}
+}
+
+// the kinds of the edits
+const (
+	textdiffEqual = iota
+	textdiffInsert
+	textdiffDelete
+)
+
+var textdiffOps = [...]string{
+	textdiffEqual:  "equal",
+	textdiffInsert: "insert",
+	textdiffDelete: "delete",
+}
+
+// textdiffEdit is an edit of a token.
+type textdiffEdit struct {
+	kind  int
+	token string
+}
+
+// textdiffHunk is a changed range of the base tokens, and, the range of the
+// new tokens that replaces it.
+type textdiffHunk struct {
+	baseStart, baseEnd int
+	newStart, newEnd   int
+}
+
+func textdiffLines(args ...objects.Object) (objects.Object, error) {
+	return textdiffTokens(args, textdiffSplitLines)
+}
+
+func textdiffWords(args ...objects.Object) (objects.Object, error) {
+	return textdiffTokens(args, textdiffSplitWords)
+}
+
+// textdiffTokens returns the edits that change the first string to the
+// second. The consecutive edits of the same kind are joined.
+func textdiffTokens(args []objects.Object, split func(string) []string) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	a, err := stringArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := stringArg(args[1], "second")
+	if err != nil {
+		return nil, err
+	}
+
+	var res []objects.Object
+	var text strings.Builder
+	edits := textdiffDiff(split(a), split(b))
+	for i, e := range edits {
+		text.WriteString(e.token)
+		if i+1 < len(edits) && edits[i+1].kind == e.kind {
+			continue
+		}
+
+		res = append(res, &objects.Map{Value: map[string]objects.Object{
+			"op":   &objects.String{Value: textdiffOps[e.kind]},
+			"text": &objects.String{Value: text.String()},
+		}})
+		text.Reset()
+	}
+
+	return &objects.Array{Value: res}, nil
+}
+
+func textdiffUnified(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	a, err := stringArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := stringArg(args[1], "second")
+	if err != nil {
+		return nil, err
+	}
+
+	context, from, to := 3, "a", "b"
+	if len(args) == 3 {
+		opts, ok := args[2].(*objects.Map)
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{
+				Name:     "third",
+				Expected: "map",
+				Found:    args[2].TypeName(),
+			}
+		}
+
+		for k, v := range opts.Value {
+			switch k {
+			case "context":
+				n, ok := v.(*objects.Int)
+				if !ok || n.Value < 0 {
+					return nil, fmt.Errorf("invalid unified option '%s': %s", k, v)
+				}
+				context = int(n.Value)
+			case "from", "to":
+				s, ok := v.(*objects.String)
+				if !ok {
+					return nil, fmt.Errorf("invalid unified option '%s': %s", k, v)
+				}
+				if k == "from" {
+					from = s.Value
+				} else {
+					to = s.Value
+				}
+			default:
+				return nil, fmt.Errorf("unknown unified option '%s'", k)
+			}
+		}
+	}
+
+	res := textdiffFormatUnified(textdiffDiff(textdiffSplitLines(a), textdiffSplitLines(b)), context, from, to)
+	if len(res) > objects.MaxStringLen {
+		return nil, objects.ErrStringLimit
+	}
+
+	return &objects.String{Value: res}, nil
+}
+
+func textdiffMerge(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 3 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	base, err := stringArg(args[0], "first")
+	if err != nil {
+		return nil, err
+	}
+
+	ours, err := stringArg(args[1], "second")
+	if err != nil {
+		return nil, err
+	}
+
+	theirs, err := stringArg(args[2], "third")
+	if err != nil {
+		return nil, err
+	}
+
+	text, conflicts := textdiffMerge3(
+		textdiffSplitLines(base), textdiffSplitLines(ours), textdiffSplitLines(theirs))
+	if len(text) > objects.MaxStringLen {
+		return nil, objects.ErrStringLimit
+	}
+
+	return &objects.Map{Value: map[string]objects.Object{
+		"text":      &objects.String{Value: text},
+		"conflicts": &objects.Int{Value: int64(conflicts)},
+	}}, nil
+}
+
+// textdiffDiff returns the shortest edits that change a to b using Myers'
+// algorithm.
+func textdiffDiff(a, b []string) []textdiffEdit {
+	// the common prefix and suffix are equal anyway
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []textdiffEdit
+	for _, t := range a[:prefix] {
+		edits = append(edits, textdiffEdit{kind: textdiffEqual, token: t})
+	}
+	edits = append(edits, textdiffMyers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, t := range a[len(a)-suffix:] {
+		edits = append(edits, textdiffEdit{kind: textdiffEqual, token: t})
+	}
+
+	return edits
+}
+
+func textdiffMyers(a, b []string) []textdiffEdit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] is the furthest x of each diagonal k before the step d
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // down: insert
+			} else {
+				x = v[offset+k-1] + 1 // right: delete
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+
+		if done {
+			break
+		}
+	}
+
+	// backtrack from the end
+	var edits []textdiffEdit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, textdiffEdit{kind: textdiffEqual, token: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, textdiffEdit{kind: textdiffInsert, token: b[y-1]})
+			} else {
+				edits = append(edits, textdiffEdit{kind: textdiffDelete, token: a[x-1]})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
+
+// textdiffHunks returns the changed ranges of the edits.
+func textdiffHunks(edits []textdiffEdit) []textdiffHunk {
+	var hunks []textdiffHunk
+	var baseIdx, newIdx int
+	var cur *textdiffHunk
+	for _, e := range edits {
+		if e.kind == textdiffEqual {
+			if cur != nil {
+				hunks = append(hunks, *cur)
+				cur = nil
+			}
+			baseIdx++
+			newIdx++
+			continue
+		}
+
+		if cur == nil {
+			cur = &textdiffHunk{baseStart: baseIdx, baseEnd: baseIdx, newStart: newIdx, newEnd: newIdx}
+		}
+		if e.kind == textdiffDelete {
+			baseIdx++
+			cur.baseEnd = baseIdx
+		} else {
+			newIdx++
+			cur.newEnd = newIdx
+		}
+	}
+	if cur != nil {
+		hunks = append(hunks, *cur)
+	}
+
+	return hunks
+}
+
+// textdiffFormatUnified returns the edits of the lines in unified format.
+// It returns an empty string if there are no changes.
+func textdiffFormatUnified(edits []textdiffEdit, context int, from, to string) string {
+	hunks := textdiffHunks(edits)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	// the lines of a and b
+	var a, b []string
+	for _, e := range edits {
+		if e.kind != textdiffInsert {
+			a = append(a, e.token)
+		}
+		if e.kind != textdiffDelete {
+			b = append(b, e.token)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- " + from + "\n+++ " + to + "\n")
+
+	for i := 0; i < len(hunks); {
+		// the hunks closer than twice the context are shown together
+		j := i
+		for j+1 < len(hunks) && hunks[j+1].baseStart-hunks[j].baseEnd <= 2*context {
+			j++
+		}
+
+		first, last := hunks[i], hunks[j]
+		before := minInt(context, first.baseStart)
+		after := minInt(context, len(a)-last.baseEnd)
+		baseStart, baseEnd := first.baseStart-before, last.baseEnd+after
+		newStart, newEnd := first.newStart-before, last.newEnd+after
+
+		sb.WriteString("@@ -" + textdiffRange(baseStart, baseEnd-baseStart) +
+			" +" + textdiffRange(newStart, newEnd-newStart) + " @@\n")
+
+		pos := baseStart
+		for _, h := range hunks[i : j+1] {
+			textdiffWriteLines(&sb, " ", a[pos:h.baseStart])
+			textdiffWriteLines(&sb, "-", a[h.baseStart:h.baseEnd])
+			textdiffWriteLines(&sb, "+", b[h.newStart:h.newEnd])
+			pos = h.baseEnd
+		}
+		textdiffWriteLines(&sb, " ", a[pos:baseEnd])
+
+		i = j + 1
+	}
+
+	return sb.String()
+}
+
+// textdiffRange returns the range of the hunk header. The start of an empty
+// range is the line before it.
+func textdiffRange(start, count int) string {
+	switch count {
+	case 0:
+		return strconv.Itoa(start) + ",0"
+	case 1:
+		return strconv.Itoa(start + 1)
+	}
+
+	return strconv.Itoa(start+1) + "," + strconv.Itoa(count)
+}
+
+func textdiffWriteLines(sb *strings.Builder, prefix string, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(prefix + line)
+		if !strings.HasSuffix(line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// textdiffMerge3 merges the changes of ours and theirs from base. The
+// changes of the same lines are the conflicts unless they are the same, and,
+// both versions are in the text with the conflict markers.
+func textdiffMerge3(base, ours, theirs []string) (string, int) {
+	oursHunks := textdiffHunks(textdiffDiff(base, ours))
+	theirsHunks := textdiffHunks(textdiffDiff(base, theirs))
+
+	var sb strings.Builder
+	var conflicts, pos int
+	for len(oursHunks) > 0 || len(theirsHunks) > 0 {
+		// the hunks that overlap or touch each other are in the same group
+		var groupOurs, groupTheirs []textdiffHunk
+		if len(theirsHunks) == 0 ||
+			(len(oursHunks) > 0 && oursHunks[0].baseStart <= theirsHunks[0].baseStart) {
+			groupOurs, oursHunks = oursHunks[:1], oursHunks[1:]
+		} else {
+			groupTheirs, theirsHunks = theirsHunks[:1], theirsHunks[1:]
+		}
+
+		start := minInt(textdiffGroupStart(groupOurs), textdiffGroupStart(groupTheirs))
+		end := maxInt(textdiffGroupEnd(groupOurs), textdiffGroupEnd(groupTheirs))
+		for {
+			if len(oursHunks) > 0 && oursHunks[0].baseStart <= end {
+				groupOurs = append(groupOurs, oursHunks[0])
+				oursHunks = oursHunks[1:]
+			} else if len(theirsHunks) > 0 && theirsHunks[0].baseStart <= end {
+				groupTheirs = append(groupTheirs, theirsHunks[0])
+				theirsHunks = theirsHunks[1:]
+			} else {
+				break
+			}
+			end = maxInt(textdiffGroupEnd(groupOurs), textdiffGroupEnd(groupTheirs))
+		}
+
+		textdiffWriteLines3(&sb, base[pos:start])
+		oursText := textdiffGroupLines(base, ours, groupOurs, start, end)
+		theirsText := textdiffGroupLines(base, theirs, groupTheirs, start, end)
+		switch {
+		case groupTheirs == nil:
+			textdiffWriteLines3(&sb, oursText)
+		case groupOurs == nil:
+			textdiffWriteLines3(&sb, theirsText)
+		case textdiffEqualLines(oursText, theirsText):
+			textdiffWriteLines3(&sb, oursText)
+		default:
+			conflicts++
+			sb.WriteString("<<<<<<< ours\n")
+			textdiffWriteConflictLines(&sb, oursText)
+			sb.WriteString("=======\n")
+			textdiffWriteConflictLines(&sb, theirsText)
+			sb.WriteString(">>>>>>> theirs\n")
+		}
+
+		pos = end
+	}
+	textdiffWriteLines3(&sb, base[pos:])
+
+	return sb.String(), conflicts
+}
+
+// textdiffGroupLines returns the lines of a version that replace the base
+// lines from start to end. The version is the same as the base outside its
+// hunks.
+func textdiffGroupLines(base, lines []string, hunks []textdiffHunk, start, end int) []string {
+	if len(hunks) == 0 {
+		return base[start:end]
+	}
+
+	first, last := hunks[0], hunks[len(hunks)-1]
+
+	return lines[first.newStart-(first.baseStart-start) : last.newEnd+(end-last.baseEnd)]
+}
+
+func textdiffGroupStart(hunks []textdiffHunk) int {
+	if len(hunks) == 0 {
+		return int(^uint(0) >> 1)
+	}
+
+	return hunks[0].baseStart
+}
+
+func textdiffGroupEnd(hunks []textdiffHunk) int {
+	if len(hunks) == 0 {
+		return -1
+	}
+
+	return hunks[len(hunks)-1].baseEnd
+}
+
+func textdiffWriteLines3(sb *strings.Builder, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(line)
+	}
+}
+
+// textdiffWriteConflictLines writes the lines of a conflict, so the marker
+// after them is on its own line.
+func textdiffWriteConflictLines(sb *strings.Builder, lines []string) {
+	textdiffWriteLines3(sb, lines)
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		sb.WriteString("\n")
+	}
+}
+
+func textdiffEqualLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// textdiffSplitLines returns the lines with their line breaks.
+func textdiffSplitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// textdiffSplitWords returns the words and the white spaces between them.
+func textdiffSplitWords(s string) []string {
+	var words []string
+	start := 0
+	for i, r := range s {
+		if i > start && unicode.IsSpace(r) != textdiffIsSpaceAt(s, start) {
+			words = append(words, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+
+	return words
+}
+
+func textdiffIsSpaceAt(s string, i int) bool {
+	for _, r := range s[i:] {
+		return unicode.IsSpace(r)
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package stdlib_test
+
+import (
+	"testing"
+)
+
+func TestTextdiffLines(t *testing.T) {
+	module(t, "textdiff").call("lines", "a").expectError()
+	module(t, "textdiff").call("lines", "a", 1).expectError()
+
+	module(t, "textdiff").call("lines", "", "").expect(ARR{})
+	module(t, "textdiff").call("lines", "a\nb\n", "a\nb\n").
+		expect(ARR{MAP{"op": "equal", "text": "a\nb\n"}})
+	module(t, "textdiff").call("lines", "", "a\n").
+		expect(ARR{MAP{"op": "insert", "text": "a\n"}})
+	module(t, "textdiff").call("lines", "a\nb\nc\nd\n", "a\nx\nc\nd\ne").
+		expect(ARR{
+			MAP{"op": "equal", "text": "a\n"},
+			MAP{"op": "delete", "text": "b\n"},
+			MAP{"op": "insert", "text": "x\n"},
+			MAP{"op": "equal", "text": "c\nd\n"},
+			MAP{"op": "insert", "text": "e"},
+		})
+	module(t, "textdiff").call("lines", "a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n").
+		expect(ARR{
+			MAP{"op": "delete", "text": "a\nb\n"},
+			MAP{"op": "equal", "text": "c\n"},
+			MAP{"op": "insert", "text": "b\n"},
+			MAP{"op": "equal", "text": "a\nb\n"},
+			MAP{"op": "delete", "text": "b\n"},
+			MAP{"op": "equal", "text": "a\n"},
+			MAP{"op": "insert", "text": "c\n"},
+		})
+}
+
+func TestTextdiffWords(t *testing.T) {
+	module(t, "textdiff").call("words", "the quick  fox", "the slow  fox\n").
+		expect(ARR{
+			MAP{"op": "equal", "text": "the "},
+			MAP{"op": "delete", "text": "quick"},
+			MAP{"op": "insert", "text": "slow"},
+			MAP{"op": "equal", "text": "  fox"},
+			MAP{"op": "insert", "text": "\n"},
+		})
+	module(t, "textdiff").call("words", " héllo wörld", " héllo").
+		expect(ARR{
+			MAP{"op": "equal", "text": " héllo"},
+			MAP{"op": "delete", "text": " wörld"},
+		})
+}
+
+func TestTextdiffUnified(t *testing.T) {
+	module(t, "textdiff").call("unified", "a", "b", 1).expectError()
+	module(t, "textdiff").call("unified", "a", "b", MAP{"context": -1}).expectError()
+	module(t, "textdiff").call("unified", "a", "b", MAP{"from": 1}).expectError()
+	module(t, "textdiff").call("unified", "a", "b", MAP{"size": 1}).expectError()
+
+	module(t, "textdiff").call("unified", "a\n", "a\n").expect("")
+	module(t, "textdiff").call("unified", "", "a\nb\n").
+		expect("--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n")
+	module(t, "textdiff").call("unified", "a\n", "", MAP{"from": "old.txt", "to": "new.txt"}).
+		expect("--- old.txt\n+++ new.txt\n@@ -1 +0,0 @@\n-a\n")
+	module(t, "textdiff").call("unified", "a\nb", "a\nc").
+		expect("--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n")
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	module(t, "textdiff").call("unified", a, "1\nx\n3\n4\n5\n6\n7\n8\n9\n", MAP{"context": 1}).
+		expect("--- a\n+++ b\n@@ -1,3 +1,3 @@\n 1\n-2\n+x\n 3\n@@ -9,2 +9 @@\n 9\n-10\n")
+	module(t, "textdiff").call("unified", a, "1\nx\n3\n4\n5\n6\n7\n8\n9\n").
+		expect("--- a\n+++ b\n@@ -1,5 +1,5 @@\n 1\n-2\n+x\n 3\n 4\n 5\n@@ -7,4 +7,3 @@\n 7\n 8\n 9\n-10\n")
+	module(t, "textdiff").call("unified", a, "1\nx\n3\n4\n5\n6\n7\ny\n9\n10\n").
+		expect("--- a\n+++ b\n@@ -1,10 +1,10 @@\n 1\n-2\n+x\n 3\n 4\n 5\n 6\n 7\n-8\n+y\n 9\n 10\n")
+	module(t, "textdiff").call("unified", a, "1\n2\n3\n4\n5\nx\n6\n7\n8\n9\n10\n", MAP{"context": 0}).
+		expect("--- a\n+++ b\n@@ -5,0 +6 @@\n+x\n")
+}
+
+func TestTextdiffMerge(t *testing.T) {
+	module(t, "textdiff").call("merge", "a", "b").expectError()
+	module(t, "textdiff").call("merge", "a", "b", 1).expectError()
+
+	base := "a\nb\nc\nd\ne\n"
+	module(t, "textdiff").call("merge", base, base, base).
+		expect(MAP{"text": base, "conflicts": 0})
+
+	// the changes of the different lines
+	module(t, "textdiff").call("merge", base, "a\nB\nc\nd\ne\n", "a\nb\nc\nD\ne\nf\n").
+		expect(MAP{"text": "a\nB\nc\nD\ne\nf\n", "conflicts": 0})
+	module(t, "textdiff").call("merge", base, "x\n"+base, "a\nb\nd\ne\n").
+		expect(MAP{"text": "x\na\nb\nd\ne\n", "conflicts": 0})
+
+	// the same changes
+	module(t, "textdiff").call("merge", base, "a\nB\nc\nd\ne\n", "a\nB\nc\nd\ne\n").
+		expect(MAP{"text": "a\nB\nc\nd\ne\n", "conflicts": 0})
+
+	// the conflicts
+	module(t, "textdiff").call("merge", base, "a\nB\nc\nd\nE\n", "a\nb2\nc\nd\nE").
+		expect(MAP{
+			"text":      "a\n<<<<<<< ours\nB\n=======\nb2\n>>>>>>> theirs\nc\nd\n<<<<<<< ours\nE\n=======\nE\n>>>>>>> theirs\n",
+			"conflicts": 2,
+		})
+	module(t, "textdiff").call("merge", "", "a\n", "b\n").
+		expect(MAP{"text": "<<<<<<< ours\na\n=======\nb\n>>>>>>> theirs\n", "conflicts": 1})
+	module(t, "textdiff").call("merge", base, "a\nb\nc\nd\ne\n", "a\nb\ne\n").
+		expect(MAP{"text": "a\nb\ne\n", "conflicts": 0})
+	module(t, "textdiff").call("merge", base, "a\nb\nC\nd\ne\n", "a\nb\ne\n").
+		expect(MAP{"text": "a\nb\n<<<<<<< ours\nC\nd\n=======\n>>>>>>> theirs\ne\n", "conflicts": 1})
+}
@@ -0,0 +1,122 @@
+package stdlib
+
+import (
+	"strconv"
+
+	"github.com/d5/tengo/objects"
+)
+
+// toStringMap returns the elements of the map or the immutable map.
+func toStringMap(o objects.Object) (map[string]objects.Object, bool) {
+	switch o := o.(type) {
+	case *objects.Map:
+		return o.Value, true
+	case *objects.ImmutableMap:
+		return o.Value, true
+	}
+
+	return nil, false
+}
+
+// toArray returns the elements of the array or the immutable array.
+func toArray(o objects.Object) ([]objects.Object, bool) {
+	switch o := o.(type) {
+	case *objects.Array:
+		return o.Value, true
+	case *objects.ImmutableArray:
+		return o.Value, true
+	}
+
+	return nil, false
+}
+
+// fromStrings returns the array of the strings.
+func fromStrings(values []string) objects.Object {
+	arr := make([]objects.Object, 0, len(values))
+	for _, v := range values {
+		arr = append(arr, &objects.String{Value: v})
+	}
+
+	return &objects.Array{Value: arr}
+}
+
+// fromBool returns the boolean value of b.
+func fromBool(b bool) objects.Object {
+	if b {
+		return objects.TrueValue
+	}
+
+	return objects.FalseValue
+}
+
+// decodedKey returns the map key of a key decoded by msgpack or cbor module.
+// The integer keys are converted to the strings.
+func decodedKey(k objects.Object) (string, bool) {
+	switch k := k.(type) {
+	case *objects.String:
+		return k.Value, true
+	case *objects.Bytes:
+		return string(k.Value), true
+	case *objects.Int:
+		return strconv.FormatInt(k.Value, 10), true
+	case *objects.BigInt:
+		return k.Value.String(), true
+	}
+
+	return "", false
+}
+
+// toNumber returns the value of the int or the float as float64.
+func toNumber(o objects.Object) (float64, bool) {
+	switch o := o.(type) {
+	case *objects.Int:
+		return float64(o.Value), true
+	case *objects.Float:
+		return o.Value, true
+	}
+
+	return 0, false
+}
+
+// isCallable returns true if the value can be called by the VM.
+func isCallable(o objects.Object) bool {
+	switch o.(type) {
+	case *objects.CompiledFunction, *objects.Closure, objects.Callable, objects.InteropCallable:
+		return true
+	}
+
+	return false
+}
+
+// stringArg returns the value of the string argument. The name is used in the
+// error when the argument is not a string.
+func stringArg(o objects.Object, name string) (string, error) {
+	s, ok := o.(*objects.String)
+	if !ok {
+		return "", objects.ErrInvalidArgumentType{
+			Name:     name,
+			Expected: "string",
+			Found:    o.TypeName(),
+		}
+	}
+
+	return s.Value, nil
+}
+
+// minInt returns the smaller of the integers.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// maxInt returns the larger of the integers.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,491 @@
+//go:build !tengo_no_x509 && !tengo_embedded && !tinygo
+// +build !tengo_no_x509,!tengo_embedded,!tinygo
+
+package stdlib
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/d5/tengo/objects"
+)
+
+func init() {
+	register("x509", x509Module)
+}
+
+func x509Module() map[string]objects.Object {
+	return map[string]objects.Object{
+		"parse":  &objects.UserFunction{Name: "parse", Value: x509Parse},   // parse(data) => [certificate]/error
+		"verify": &objects.UserFunction{Name: "verify", Value: x509Verify}, // verify(certificates, options) => [chain]/error
+		"dial":   &objects.InteropFunction{Name: "dial", Value: x509Dial},  // dial(address, options) => connection/error
+	}
+}
+
+// x509KeyUsages are the names of the key usages in the order of the bits.
+var x509KeyUsages = []string{
+	"digital_signature",
+	"content_commitment",
+	"key_encipherment",
+	"data_encipherment",
+	"key_agreement",
+	"cert_sign",
+	"crl_sign",
+	"encipher_only",
+	"decipher_only",
+}
+
+var x509ExtKeyUsages = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "any",
+	x509.ExtKeyUsageServerAuth:      "server_auth",
+	x509.ExtKeyUsageClientAuth:      "client_auth",
+	x509.ExtKeyUsageCodeSigning:     "code_signing",
+	x509.ExtKeyUsageEmailProtection: "email_protection",
+	x509.ExtKeyUsageTimeStamping:    "time_stamping",
+	x509.ExtKeyUsageOCSPSigning:     "ocsp_signing",
+}
+
+var x509TLSVersions = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+func x509Parse(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	certs, err := x509ParseData(args[0], "first")
+	if err != nil {
+		if _, ok := err.(objects.ErrInvalidArgumentType); ok {
+			return nil, err
+		}
+		return wrapError(err), nil
+	}
+
+	return x509Certificates(certs), nil
+}
+
+// x509ParseData parses the certificates of the PEM data, or, the certificate
+// of the DER data. The other PEM blocks than the certificates (e.g. the
+// private keys) are skipped.
+func x509ParseData(o objects.Object, argName string) ([]*x509.Certificate, error) {
+	var data []byte
+	switch o := o.(type) {
+	case *objects.String:
+		data = []byte(o.Value)
+	case *objects.Bytes:
+		data = o.Value
+	default:
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     argName,
+			Expected: "string/bytes",
+			Found:    o.TypeName(),
+		}
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	if !bytes.Contains(data, []byte("-----BEGIN ")) {
+		cert, err := x509.ParseCertificate(data)
+		if err != nil {
+			return nil, err
+		}
+		return []*x509.Certificate{cert}, nil
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	return certs, nil
+}
+
+// x509ParseCertificates returns the certificates of the data (see
+// x509ParseData), or, of the array of the certificates that parse returned.
+func x509ParseCertificates(o objects.Object, argName string) ([]*x509.Certificate, error) {
+	var arr []objects.Object
+	switch o := o.(type) {
+	case *objects.Array:
+		arr = o.Value
+	case *objects.ImmutableArray:
+		arr = o.Value
+	default:
+		return x509ParseData(o, argName)
+	}
+
+	if len(arr) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(arr))
+	for _, elem := range arr {
+		var raw objects.Object
+		switch elem := elem.(type) {
+		case *objects.Map:
+			raw = elem.Value["raw"]
+		case *objects.ImmutableMap:
+			raw = elem.Value["raw"]
+		}
+		b, ok := raw.(*objects.Bytes)
+		if !ok {
+			return nil, fmt.Errorf("invalid certificate: %s", elem)
+		}
+		cert, err := x509.ParseCertificate(b.Value)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
+
+// x509VerifyOptions are the options of x509.verify and x509.dial.
+type x509VerifyOptions struct {
+	roots      *x509.CertPool
+	serverName string
+	time       time.Time
+	timeout    time.Duration
+}
+
+// parse reads the options from the map. The timeout option is accepted only
+// if dial is true.
+func (opts *x509VerifyOptions) parse(o objects.Object, dial bool) error {
+	var m map[string]objects.Object
+	switch o := o.(type) {
+	case *objects.Map:
+		m = o.Value
+	case *objects.ImmutableMap:
+		m = o.Value
+	default:
+		return objects.ErrInvalidArgumentType{
+			Name:     "second",
+			Expected: "map",
+			Found:    o.TypeName(),
+		}
+	}
+
+	for k, v := range m {
+		switch {
+		case k == "roots":
+			certs, err := x509ParseCertificates(v, "roots")
+			if err != nil {
+				return fmt.Errorf("invalid x509 option '%s': %s", k, err.Error())
+			}
+			opts.roots = x509.NewCertPool()
+			for _, cert := range certs {
+				opts.roots.AddCert(cert)
+			}
+		case k == "server_name":
+			s, ok := v.(*objects.String)
+			if !ok {
+				return fmt.Errorf("invalid x509 option '%s': %s", k, v)
+			}
+			opts.serverName = s.Value
+		case k == "time" && !dial:
+			t, ok := v.(*objects.Time)
+			if !ok {
+				return fmt.Errorf("invalid x509 option '%s': %s", k, v)
+			}
+			opts.time = t.Value
+		case k == "timeout" && dial:
+			i, ok := v.(*objects.Int)
+			if !ok || i.Value <= 0 {
+				return fmt.Errorf("invalid x509 option '%s': %s", k, v)
+			}
+			opts.timeout = time.Duration(i.Value)
+		default:
+			return fmt.Errorf("unknown x509 option '%s'", k)
+		}
+	}
+
+	return nil
+}
+
+// verify verifies the first certificate using the others as the
+// intermediates, and, returns the chains to the roots.
+func (opts *x509VerifyOptions) verify(certs []*x509.Certificate) ([][]*x509.Certificate, error) {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	return certs[0].Verify(x509.VerifyOptions{
+		DNSName:       opts.serverName,
+		Roots:         opts.roots,
+		Intermediates: intermediates,
+		CurrentTime:   opts.time,
+	})
+}
+
+func x509Verify(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	certs, err := x509ParseCertificates(args[0], "first")
+	if err != nil {
+		if _, ok := err.(objects.ErrInvalidArgumentType); ok {
+			return nil, err
+		}
+		return wrapError(err), nil
+	}
+
+	opts := &x509VerifyOptions{}
+	if len(args) == 2 {
+		if err := opts.parse(args[1], false); err != nil {
+			return nil, err
+		}
+	}
+
+	chains, err := opts.verify(certs)
+	if err != nil {
+		return wrapError(err), nil
+	}
+
+	return x509Chains(chains), nil
+}
+
+func x509Dial(rt objects.Interop, args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	address, ok := objects.ToString(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{
+			Name:     "first",
+			Expected: "string(compatible)",
+			Found:    args[0].TypeName(),
+		}
+	}
+
+	opts := &x509VerifyOptions{timeout: 30 * time.Second}
+	if len(args) == 2 {
+		if err := opts.parse(args[1], true); err != nil {
+			return nil, err
+		}
+	}
+
+	// the port is 443 if it's omitted
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+		address = net.JoinHostPort(address, "443")
+	}
+	if opts.serverName == "" {
+		opts.serverName = host
+	}
+	if err := checkNetwork(address); err != nil {
+		return wrapError(err), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	var aborted <-chan struct{}
+	if aborter, ok := rt.(objects.InteropAborter); ok {
+		aborted = aborter.Aborted()
+	}
+
+	// the connection is closed if the execution is aborted
+	go func() {
+		select {
+		case <-aborted:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var dialer net.Dialer
+	rawConn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return wrapError(err), nil
+	}
+	defer func() { _ = rawConn.Close() }()
+
+	deadline, _ := ctx.Deadline()
+	_ = rawConn.SetDeadline(deadline)
+	go func() {
+		<-ctx.Done()
+		_ = rawConn.Close()
+	}()
+
+	// the certificates are verified after the handshake, so the scripts can
+	// inspect the certificates that are not trusted (e.g. expired).
+	conn := tls.Client(rawConn, &tls.Config{
+		ServerName:         opts.serverName,
+		InsecureSkipVerify: true,
+	})
+	if err := conn.Handshake(); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return wrapError(err), nil
+	}
+	state := conn.ConnectionState()
+	_ = conn.Close()
+
+	version, ok := x509TLSVersions[state.Version]
+	if !ok {
+		version = fmt.Sprintf("0x%04x", state.Version)
+	}
+
+	res := map[string]objects.Object{
+		"address":      &objects.String{Value: rawConn.RemoteAddr().String()},
+		"server_name":  &objects.String{Value: opts.serverName},
+		"version":      &objects.String{Value: version},
+		"cipher_suite": &objects.String{Value: tls.CipherSuiteName(state.CipherSuite)},
+		"certificates": x509Certificates(state.PeerCertificates),
+	}
+
+	chains, err := opts.verify(state.PeerCertificates)
+	if err != nil {
+		res["verified"] = objects.FalseValue
+		res["verify_error"] = &objects.String{Value: err.Error()}
+	} else {
+		res["verified"] = objects.TrueValue
+		res["chains"] = x509Chains(chains)
+	}
+
+	return &objects.Map{Value: res}, nil
+}
+
+func x509Chains(chains [][]*x509.Certificate) objects.Object {
+	arr := make([]objects.Object, 0, len(chains))
+	for _, chain := range chains {
+		arr = append(arr, x509Certificates(chain))
+	}
+	return &objects.Array{Value: arr}
+}
+
+func x509Certificates(certs []*x509.Certificate) objects.Object {
+	arr := make([]objects.Object, 0, len(certs))
+	for _, cert := range certs {
+		arr = append(arr, x509Certificate(cert))
+	}
+	return &objects.Array{Value: arr}
+}
+
+func x509Certificate(cert *x509.Certificate) objects.Object {
+	ips := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	var keyUsage []string
+	for i, name := range x509KeyUsages {
+		if cert.KeyUsage&(1<<uint(i)) != 0 {
+			keyUsage = append(keyUsage, name)
+		}
+	}
+	var extKeyUsage []string
+	for _, usage := range cert.ExtKeyUsage {
+		if name, ok := x509ExtKeyUsages[usage]; ok {
+			extKeyUsage = append(extKeyUsage, name)
+		}
+	}
+
+	sha1Sum := sha1.Sum(cert.Raw)
+	sha256Sum := sha256.Sum256(cert.Raw)
+
+	return &objects.Map{
+		Value: map[string]objects.Object{
+			"version":              &objects.Int{Value: int64(cert.Version)},
+			"serial_number":        &objects.BigInt{Value: cert.SerialNumber},
+			"subject":              x509Name(cert.Subject),
+			"issuer":               x509Name(cert.Issuer),
+			"not_before":           &objects.Time{Value: cert.NotBefore},
+			"not_after":            &objects.Time{Value: cert.NotAfter},
+			"dns_names":            fromStrings(cert.DNSNames),
+			"email_addresses":      fromStrings(cert.EmailAddresses),
+			"ip_addresses":         fromStrings(ips),
+			"uris":                 fromStrings(uris),
+			"is_ca":                fromBool(cert.IsCA),
+			"self_signed":          fromBool(x509SelfSigned(cert)),
+			"key_usage":            fromStrings(keyUsage),
+			"ext_key_usage":        fromStrings(extKeyUsage),
+			"signature_algorithm":  &objects.String{Value: cert.SignatureAlgorithm.String()},
+			"public_key_algorithm": &objects.String{Value: cert.PublicKeyAlgorithm.String()},
+			"public_key_size":      &objects.Int{Value: int64(x509KeySize(cert.PublicKey))},
+			"ocsp_servers":         fromStrings(cert.OCSPServer),
+			"issuing_certificates": fromStrings(cert.IssuingCertificateURL),
+			"crl_distribution":     fromStrings(cert.CRLDistributionPoints),
+			"fingerprint_sha1":     &objects.String{Value: hex.EncodeToString(sha1Sum[:])},
+			"fingerprint_sha256":   &objects.String{Value: hex.EncodeToString(sha256Sum[:])},
+			"raw":                  &objects.Bytes{Value: cert.Raw},
+		},
+	}
+}
+
+func x509Name(name pkix.Name) objects.Object {
+	return &objects.Map{
+		Value: map[string]objects.Object{
+			"common_name":         &objects.String{Value: name.CommonName},
+			"serial_number":       &objects.String{Value: name.SerialNumber},
+			"organization":        fromStrings(name.Organization),
+			"organizational_unit": fromStrings(name.OrganizationalUnit),
+			"country":             fromStrings(name.Country),
+			"province":            fromStrings(name.Province),
+			"locality":            fromStrings(name.Locality),
+			"string":              &objects.String{Value: name.String()},
+		},
+	}
+}
+
+// x509SelfSigned returns true if the certificate is signed with its own key.
+func x509SelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// x509KeySize returns the size of the public key in bits.
+func x509KeySize(key interface{}) int {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 256
+	}
+	return 0
+}
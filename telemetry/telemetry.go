@@ -0,0 +1,142 @@
+//go:build tengo_otel
+// +build tengo_otel
+
+// Package telemetry runs the scripts in OpenTelemetry spans. It depends on
+// the OpenTelemetry API (go.opentelemetry.io/otel), so it's built only with
+// the tengo_otel build tag.
+package telemetry
+
+import (
+	"context"
+
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/runtime"
+	"github.com/d5/tengo/script"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span names.
+const (
+	RunSpanName  = "tengo.run"
+	CallSpanName = "tengo.call"
+)
+
+// Attribute keys.
+const (
+	InstructionsKey = attribute.Key("tengo.instructions") // number of the executed instructions
+	CallsKey        = attribute.Key("tengo.calls")        // number of the host function calls
+	FunctionKey     = attribute.Key("tengo.function")     // type name of the host function
+	FileKey         = attribute.Key("code.filepath")
+	LineKey         = attribute.Key("code.lineno")
+)
+
+// RunVM runs the VM in a span named "tengo.run", and, each call of a host
+// (Go) function in a child span named "tengo.call". The spans of the failed
+// runs and calls have the error status. RunVM replaces the Tracer of the VM.
+func RunVM(ctx context.Context, tracer trace.Tracer, v *runtime.VM) error {
+	ctx, span := tracer.Start(ctx, RunSpanName)
+	defer span.End()
+
+	t := &spanTracer{tracer: tracer, ctx: ctx}
+	v.SetTracer(t)
+	defer v.SetTracer(nil)
+
+	err := v.Run()
+	t.end(span, err)
+
+	return err
+}
+
+// RunCompiled is like RunVM, but, it runs the compiled script using
+// script.Compiled.RunContext, so the run is aborted when ctx is done.
+func RunCompiled(ctx context.Context, tracer trace.Tracer, c *script.Compiled) error {
+	ctx, span := tracer.Start(ctx, RunSpanName)
+	defer span.End()
+
+	t := &spanTracer{tracer: tracer, ctx: ctx}
+	c.SetTracer(t)
+	defer c.SetTracer(nil)
+
+	err := c.RunContext(ctx)
+	t.end(span, err)
+
+	return err
+}
+
+// spanTracer is the runtime.Tracer that starts a span for each host function
+// call.
+type spanTracer struct {
+	tracer trace.Tracer
+	ctx    context.Context // context of the run span
+	calls  []call          // calls in progress, the innermost last
+	insts  int64
+	hosts  int64
+}
+
+type call struct {
+	frame runtime.TraceFrame
+	ctx   context.Context
+	span  trace.Span // nil for the compiled functions
+}
+
+func (t *spanTracer) OnInstruction(frame runtime.TraceFrame) {
+	t.insts++
+}
+
+func (t *spanTracer) OnCall(frame runtime.TraceFrame, callee objects.Object, args []objects.Object) {
+	c := call{frame: frame, ctx: t.ctx}
+	if len(t.calls) > 0 {
+		c.ctx = t.calls[len(t.calls)-1].ctx
+	}
+
+	switch callee.(type) {
+	case *objects.CompiledFunction, *objects.Closure:
+	default:
+		t.hosts++
+		c.ctx, c.span = t.tracer.Start(c.ctx, CallSpanName, trace.WithAttributes(
+			FunctionKey.String(callee.TypeName()),
+			FileKey.String(frame.Pos.Filename),
+			LineKey.Int(frame.Pos.Line),
+		))
+	}
+
+	t.calls = append(t.calls, c)
+}
+
+func (t *spanTracer) OnReturn(frame runtime.TraceFrame, value objects.Object) {
+	// the tail calls do not return: their entries are dropped with the call
+	// of the reused frame.
+	for len(t.calls) > 0 {
+		c := t.calls[len(t.calls)-1]
+		t.calls = t.calls[:len(t.calls)-1]
+		if c.frame == frame {
+			if c.span != nil {
+				c.span.End()
+			}
+			return
+		}
+	}
+}
+
+func (t *spanTracer) OnError(err error) {
+	for i := len(t.calls) - 1; i >= 0; i-- {
+		if span := t.calls[i].span; span != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+		}
+	}
+
+	t.calls = nil
+}
+
+// end sets the attributes and the status of the run span.
+func (t *spanTracer) end(span trace.Span, err error) {
+	span.SetAttributes(InstructionsKey.Int64(t.insts), CallsKey.Int64(t.hosts))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
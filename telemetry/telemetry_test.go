@@ -0,0 +1,91 @@
+//go:build tengo_otel
+// +build tengo_otel
+
+package telemetry_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/script"
+	"github.com/d5/tengo/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRunCompiled(t *testing.T) {
+	spans := runScript(t, `
+f := func(x) { return x + len([1, 2]) }
+a := f(1) + len("abc")`)
+	if !assert.Equal(t, 3, len(spans)) {
+		return
+	}
+
+	// the call spans end before the run span
+	for _, s := range spans[:2] {
+		assert.Equal(t, telemetry.CallSpanName, s.Name())
+		assert.Equal(t, spans[2].SpanContext().SpanID().String(), s.Parent().SpanID().String())
+		assert.Equal(t, codes.Unset.String(), s.Status().Code.String())
+	}
+	assert.Equal(t, "builtin-function:len", attr(spans[0], telemetry.FunctionKey).AsString())
+	assert.Equal(t, int64(2), attr(spans[0], telemetry.LineKey).AsInt64())
+	assert.Equal(t, int64(3), attr(spans[1], telemetry.LineKey).AsInt64())
+
+	run := spans[2]
+	assert.Equal(t, telemetry.RunSpanName, run.Name())
+	assert.Equal(t, codes.Unset.String(), run.Status().Code.String())
+	assert.Equal(t, int64(2), attr(run, telemetry.CallsKey).AsInt64())
+	assert.True(t, attr(run, telemetry.InstructionsKey).AsInt64() > 10)
+
+	// a host function calling back a script function
+	spans = runScript(t, `
+g := func(acc, x) { return acc + len([x]) }
+a := stream([1, 2]).reduce(g, 0)`)
+	if !assert.Equal(t, 5, len(spans)) {
+		return
+	}
+	var names []string
+	for _, s := range spans[:4] {
+		names = append(names, attr(s, telemetry.FunctionKey).AsString())
+	}
+	assert.Equal(t, "builtin-function:stream builtin-function:len builtin-function:len builtin-function:reduce",
+		strings.Join(names, " "))
+	assert.Equal(t, spans[3].SpanContext().SpanID().String(), spans[1].Parent().SpanID().String())
+
+	// error
+	spans = runScript(t, `
+f := func() { return len(1) }
+a := f()`)
+	if assert.Equal(t, 2, len(spans)) {
+		assert.Equal(t, codes.Error.String(), spans[0].Status().Code.String())
+		assert.Equal(t, codes.Error.String(), spans[1].Status().Code.String())
+		assert.Equal(t, 1, len(spans[1].Events()))
+	}
+}
+
+func runScript(t *testing.T, input string) []sdktrace.ReadOnlySpan {
+	c, err := script.New([]byte(input)).Compile()
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_ = telemetry.RunCompiled(context.Background(), provider.Tracer("test"), c)
+
+	return recorder.Ended()
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
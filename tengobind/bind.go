@@ -0,0 +1,249 @@
+// Package tengobind generates the Go source code of a Tengo module that
+// exposes a Go package to the scripts, so the existing Go libraries can be
+// used by the scripts without writing the objects-based wrappers by hand.
+//
+// The module has the following members for the exported declarations of the
+// package:
+//
+//   - the functions (e.g. "ParseInt" becomes "parse_int"), which convert the
+//     arguments and the results between the objects and the Go values. An
+//     error result is returned as an error value, and, the functions that
+//     return only an error return true if it's nil. The functions with
+//     multiple results (besides an error) return an array.
+//   - the constants of the supported types.
+//   - a constructor for each struct type (e.g. "point({x: 1, y: 2})" for
+//     "Point") that takes an optional map of the field values.
+//
+// The struct values are wrapped in the generated object types (e.g.
+// PointObject for Point) that provide the exported fields (e.g. "p.x" and
+// "p.x = 1") and the exported methods (e.g. "p.move(1, 2)") of the structs.
+//
+// The supported types are bool, string, the integer and floating-point
+// types, rune (char), []byte (bytes), time.Time, time.Duration (int), the
+// exported named types of those types, the exported struct types of the
+// package and the pointers to them, and, the slices (array) and the maps
+// with string keys (map) of the supported types. The declarations that use
+// other types are skipped. The package is read without type checking, so
+// the types of the untyped constants are inferred from their expressions.
+package tengobind
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Options are the options of the generated code.
+type Options struct {
+	// ImportPath is the import path of the Go package. By default, it's
+	// resolved from the directory of the package in GOPATH.
+	ImportPath string
+
+	// Package is the package name of the generated code. By default, it's
+	// the name of the Go package followed by "mod" (e.g. "strconvmod").
+	Package string
+
+	// Module is the name of the module that is used in the type names of
+	// the struct objects (e.g. "geo.point"). By default, it's the name of
+	// the Go package.
+	Module string
+}
+
+// Result is the result of Bind.
+type Result struct {
+	// Source is the formatted Go source code of the module.
+	Source []byte
+
+	// Skipped describes the exported declarations that could not be bound
+	// (e.g. "Send: parameter 1: unsupported type: chan int").
+	Skipped []string
+}
+
+// Bind reads the Go package in the directory and generates the module for
+// it. Only the files that match the build constraints of the current
+// platform are read.
+func Bind(dir string, opts Options) (*Result, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ImportPath == "" {
+		if bp.ImportPath == "" || bp.ImportPath == "." || strings.HasPrefix(bp.ImportPath, "_") {
+			return nil, fmt.Errorf("cannot determine the import path of %s", dir)
+		}
+		opts.ImportPath = bp.ImportPath
+	}
+	if opts.Package == "" {
+		opts.Package = bp.Name + "mod"
+	}
+	if opts.Module == "" {
+		opts.Module = bp.Name
+	}
+
+	g := newGenerator(bp.Name, opts)
+
+	fileSet := token.NewFileSet()
+	for _, name := range bp.GoFiles {
+		file, err := parser.ParseFile(fileSet, filepath.Join(bp.Dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		g.collect(file)
+	}
+
+	src, err := g.generate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Source: src, Skipped: g.skipped}, nil
+}
+
+// funcDecl is an exported function or method.
+type funcDecl struct {
+	*ast.FuncDecl
+	time bool // the file imports time package
+}
+
+// structDecl is an exported struct type.
+type structDecl struct {
+	name    string
+	typ     *ast.StructType
+	methods []*funcDecl
+	time    bool
+}
+
+// constDecl is a constant. The type and the value are inherited from the
+// previous specification of the declaration if omitted.
+type constDecl struct {
+	name      string
+	typ       ast.Expr // nil if untyped
+	value     ast.Expr
+	time      bool
+	resolving bool
+}
+
+// collect adds the exported declarations of the file.
+func (g *generator) collect(file *ast.File) {
+	hasTime := importsTime(file)
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+
+			fn := &funcDecl{FuncDecl: decl, time: hasTime}
+			if decl.Recv == nil {
+				g.funcs = append(g.funcs, fn)
+				continue
+			}
+
+			recv := receiverName(decl.Recv.List[0].Type)
+			g.methods[recv] = append(g.methods[recv], fn)
+		case *ast.GenDecl:
+			switch decl.Tok {
+			case token.TYPE:
+				for _, spec := range decl.Specs {
+					g.collectType(spec.(*ast.TypeSpec), hasTime)
+				}
+			case token.CONST:
+				var typ ast.Expr
+				var values []ast.Expr
+				for _, spec := range decl.Specs {
+					vs := spec.(*ast.ValueSpec)
+					if vs.Type != nil || len(vs.Values) > 0 {
+						typ, values = vs.Type, vs.Values
+					}
+
+					for i, name := range vs.Names {
+						if name.Name == "_" {
+							continue
+						}
+
+						c := &constDecl{name: name.Name, typ: typ, time: hasTime}
+						if i < len(values) {
+							c.value = values[i]
+						}
+
+						g.constsByName[c.name] = c
+						if name.IsExported() {
+							g.consts = append(g.consts, c)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func (g *generator) collectType(spec *ast.TypeSpec, hasTime bool) {
+	if !spec.Name.IsExported() || spec.TypeParams != nil || spec.Assign.IsValid() {
+		return
+	}
+
+	switch typ := spec.Type.(type) {
+	case *ast.StructType:
+		g.structs[spec.Name.Name] = &structDecl{name: spec.Name.Name, typ: typ, time: hasTime}
+	case *ast.Ident:
+		g.named[spec.Name.Name] = typ
+	}
+}
+
+// importsTime returns true if the file imports time package without
+// renaming it.
+func importsTime(file *ast.File) bool {
+	for _, spec := range file.Imports {
+		if spec.Path.Value == `"time"` && spec.Name == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// receiverName returns the name of the receiver type without the pointer
+// and the type parameters.
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// snakeCase converts the Go name to the name used in the scripts, e.g.
+// "ParseInt" to "parse_int" and "HTTPServer" to "http_server".
+func snakeCase(name string) string {
+	runes := []rune(name)
+
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+package tengobind_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/script"
+	"github.com/d5/tengo/stdlib"
+	"github.com/d5/tengo/tengobind"
+	"github.com/d5/tengo/tengobind/internal/examplemod"
+)
+
+//go:generate go run ../cmd/tengobind -pkg examplemod -import github.com/d5/tengo/tengobind/internal/example -o internal/examplemod/examplemod.go ./internal/example
+
+func init() {
+	stdlib.Register("example", examplemod.Members)
+}
+
+func TestBind(t *testing.T) {
+	res, err := tengobind.Bind("internal/example", tengobind.Options{
+		ImportPath: "github.com/d5/tengo/tengobind/internal/example",
+		Package:    "examplemod",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	expected, err := ioutil.ReadFile("internal/examplemod/examplemod.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, string(expected) == string(res.Source), "generated code is outdated: run go generate")
+
+	assert.Equal(t, strings.Join([]string{
+		"Timeout: unknown type",
+		"Send: parameter 1: unsupported type: chan int",
+		"Point.Ch: unsupported type: chan int",
+	}, "\n"), strings.Join(res.Skipped, "\n"))
+}
+
+func TestBind_Module(t *testing.T) {
+	expect(t, `out = [example.meter, example.mile, example.name, example.max_size, example.enabled, example.initial, example.half]`,
+		[]interface{}{int64(0), int64(2), "example", int64(1024), true, 'a', 512.0})
+
+	// functions
+	expect(t, `out = example.add(1, 2)`, int64(3))
+	expect(t, `out = example.divide(7, 2)`, int64(3))
+	expect(t, `out = string(example.divide(1, 0))`, `error: "division by zero"`)
+	expect(t, `out = example.split("a,b", ",")`, []interface{}{"a", "b"})
+	expect(t, `out = example.join("-")`, "")
+	expect(t, `out = example.join("-", "a", "b", "c")`, "a-b-c")
+	expect(t, `out = example.count(["a", "b", "a"])`, map[string]interface{}{"a": int64(2), "b": int64(1)})
+	expect(t, `out = [example.check("a"), is_error(example.check(""))]`, []interface{}{true, true})
+	expect(t, `out = example.min_max(3, 1, 4, 1, 5)`, []interface{}{1.0, 5.0})
+	expect(t, `out = example.convert(3218.688, example.mile)`, 2.0)
+	expect(t, `out = [example.is_empty(""), example.is_empty(bytes("a"))]`, []interface{}{true, false})
+	expect(t, `
+times := import("times")
+out = times.time_unix(example.after(times.date(2000, 1, 1, 0, 0, 0, 0), times.hour))`, int64(946688400))
+
+	// structs
+	expect(t, `p := example.new_point(1, 2); p.move(2, 2); out = [p.x, p.y, string(p), type_name(p)]`,
+		[]interface{}{3.0, 4.0, "(3, 4)", "example.point"})
+	expect(t, `p := example.point({x: 3, tags: ["a"]}); p.label = "p"; p.tags = p.tags + ["b"]; out = [p.label, p.tags, p.distance(example.origin())]`,
+		[]interface{}{"p", []interface{}{"a", "b"}, 3.0})
+	expect(t, `p := example.origin(); q := p; q.x = 1; r := copy(p); r.x = 2; out = [p.x, p.label, p == q, p == r, p.unknown]`,
+		[]interface{}{1.0, "origin", true, false, nil})
+
+	expectError(t, `example.add(1)`, "wrong number of arguments")
+	expectError(t, `example.add(1, "a")`, "invalid type for argument 'second'")
+	expectError(t, `example.count("a")`, "invalid type for argument 'first'")
+	expectError(t, `example.min_max(1, 2, "x")`, "invalid type for argument 'second'")
+	expectError(t, `example.point({x: "a"})`, "invalid index value type")
+	expectError(t, `p := example.point(); p.z = 1`, "invalid index type")
+	expectError(t, `example.origin().distance(1)`, "expected example.point")
+}
+
+func expect(t *testing.T, input string, expected interface{}) {
+	s := script.New([]byte(`example := import("example")` + "\n" + input))
+	_ = s.Add("out", nil)
+
+	c, err := s.Run()
+	if !assert.NoError(t, err, input) {
+		return
+	}
+	expectedObj, err := objects.FromInterface(expected)
+	if !assert.NoError(t, err, input) {
+		return
+	}
+	assert.Equal(t, expectedObj, c.Get("out").Object(), input)
+}
+
+func expectError(t *testing.T, input, expected string) {
+	s := script.New([]byte(`example := import("example")` + "\n" + input))
+
+	_, err := s.Run()
+	if assert.Error(t, err, input) {
+		assert.True(t, strings.Contains(err.Error(), expected), "input %q: unexpected error: %s", input, err.Error())
+	}
+}
@@ -0,0 +1,665 @@
+package tengobind
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reservedNames are the names used in the generated code that the Go
+// package is not imported as.
+var reservedNames = map[string]bool{
+	"args":    true,
+	"err":     true,
+	"fmt":     true,
+	"index":   true,
+	"m":       true,
+	"o":       true,
+	"objects": true,
+	"ok":      true,
+	"res":     true,
+	"time":    true,
+	"token":   true,
+	"value":   true,
+}
+
+// ordinals are the names of the arguments in the error messages.
+var ordinals = []string{"first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth", "tenth"}
+
+type generator struct {
+	pkgName      string // name of the Go package
+	alias        string // name of the Go package in the generated code
+	opts         Options
+	funcs        []*funcDecl
+	methods      map[string][]*funcDecl // by the receiver type names
+	structs      map[string]*structDecl
+	named        map[string]*ast.Ident // underlying types of the named types
+	consts       []*constDecl          // exported constants
+	constsByName map[string]*constDecl
+	members      map[string]string // Go names of the module members by their names
+	skipped      []string
+	uses         map[string]bool // optional imports and helper functions
+	buf          bytes.Buffer
+	numTemps     int
+}
+
+func newGenerator(pkgName string, opts Options) *generator {
+	alias := pkgName
+	if reservedNames[alias] {
+		alias += "pkg"
+	}
+
+	return &generator{
+		pkgName:      pkgName,
+		alias:        alias,
+		opts:         opts,
+		methods:      make(map[string][]*funcDecl),
+		structs:      make(map[string]*structDecl),
+		named:        make(map[string]*ast.Ident),
+		constsByName: make(map[string]*constDecl),
+		members:      make(map[string]string),
+		uses:         make(map[string]bool),
+	}
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *generator) temp() string {
+	g.numTemps++
+
+	return fmt.Sprintf("t%d", g.numTemps)
+}
+
+func (g *generator) skip(name string, err error) {
+	g.skipped = append(g.skipped, fmt.Sprintf("%s: %s", name, err.Error()))
+}
+
+// signature is the supported signature of a function.
+type signature struct {
+	params   []*bindType
+	variadic bool // the last parameter is variadic, and, its type is the element type
+	results  []*bindType
+	err      bool // the last result is an error
+}
+
+func (g *generator) signature(fn *ast.FuncType, hasTime bool) (*signature, error) {
+	sig := &signature{}
+
+	for _, field := range fn.Params.List {
+		typ := field.Type
+		if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+			sig.variadic = true
+			typ = ellipsis.Elt
+		}
+
+		t, err := g.resolve(typ, hasTime)
+		if err == nil && t.kind == kindError {
+			err = fmt.Errorf("unsupported type: error")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d: %s", len(sig.params)+1, err.Error())
+		}
+
+		for i := 0; i < len(field.Names) || i == 0; i++ {
+			sig.params = append(sig.params, t)
+		}
+	}
+
+	if fn.Results != nil {
+		for _, field := range fn.Results.List {
+			t, err := g.resolve(field.Type, hasTime)
+			if err != nil {
+				return nil, fmt.Errorf("result %d: %s", len(sig.results)+1, err.Error())
+			}
+
+			for i := 0; i < len(field.Names) || i == 0; i++ {
+				sig.results = append(sig.results, t)
+			}
+		}
+	}
+
+	for i, t := range sig.results {
+		if t.kind != kindError {
+			continue
+		}
+		if i != len(sig.results)-1 {
+			return nil, fmt.Errorf("result %d: unsupported type: error", i+1)
+		}
+		sig.results = sig.results[:i]
+		sig.err = true
+	}
+
+	return sig, nil
+}
+
+// generate returns the formatted source code of the module.
+func (g *generator) generate() ([]byte, error) {
+	sort.Slice(g.funcs, func(i, j int) bool { return g.funcs[i].Name.Name < g.funcs[j].Name.Name })
+
+	var body bytes.Buffer
+	var members []string // member entries of the map literal
+	var constNames []string
+	consts := make(map[string]*bindType)
+
+	// constants
+	for _, c := range g.consts {
+		typ := g.constType(c)
+		if typ == nil {
+			g.skip(c.name, fmt.Errorf("unknown type"))
+			continue
+		}
+
+		t, err := g.resolve(typ, c.time)
+		if err == nil && (t.kind == kindError || t.kind == kindStruct || t.kind == kindStructPtr) {
+			err = fmt.Errorf("unsupported type: %s", types.ExprString(typ))
+		}
+		if err != nil {
+			g.skip(c.name, err)
+			continue
+		}
+
+		if g.addMember(snakeCase(c.name), c.name) {
+			consts[c.name] = t
+			constNames = append(constNames, c.name)
+		}
+	}
+
+	// functions
+	for _, fn := range g.funcs {
+		sig, err := g.signature(fn.Type, fn.time)
+		if err != nil {
+			g.skip(fn.Name.Name, err)
+			continue
+		}
+
+		name := snakeCase(fn.Name.Name)
+		if !g.addMember(name, fn.Name.Name) {
+			continue
+		}
+
+		goName := "func" + fn.Name.Name
+		members = append(members, fmt.Sprintf("%q: &objects.UserFunction{Name: %q, Value: %s},", name, name, goName))
+
+		g.printf("")
+		g.printf("// %s calls %s.%s.", goName, g.pkgName, fn.Name.Name)
+		g.printf("func %s(args ...objects.Object) (objects.Object, error) {", goName)
+		g.emitCall(sig, g.alias+"."+fn.Name.Name)
+		g.printf("}")
+	}
+	_, _ = body.Write(g.buf.Bytes())
+	g.buf.Reset()
+
+	// structs
+	var structNames []string
+	for name := range g.structs {
+		structNames = append(structNames, name)
+	}
+	sort.Strings(structNames)
+
+	for _, name := range structNames {
+		s := g.structs[name]
+		s.methods = g.methods[name]
+		sort.Slice(s.methods, func(i, j int) bool { return s.methods[i].Name.Name < s.methods[j].Name.Name })
+
+		if constructor := g.emitStruct(s); constructor != "" {
+			member := snakeCase(name)
+			if g.addMember(member, name) {
+				members = append(members, fmt.Sprintf("%q: &objects.UserFunction{Name: %q, Value: %s},", member, member, constructor))
+			}
+		}
+	}
+	_, _ = body.Write(g.buf.Bytes())
+	g.buf.Reset()
+
+	g.emitHelpers()
+	_, _ = body.Write(g.buf.Bytes())
+	g.buf.Reset()
+
+	// members
+	sort.Strings(members)
+	g.printf("")
+	g.printf("// Members returns the members of %q module. Register the module before", g.opts.Module)
+	g.printf("// compiling the scripts to import it:")
+	g.printf("//")
+	g.printf("//	stdlib.Register(%q, %s.Members)", g.opts.Module, g.opts.Package)
+	g.printf("func Members() map[string]objects.Object {")
+	g.printf("m := map[string]objects.Object{")
+	for _, m := range members {
+		g.printf("%s", m)
+	}
+	g.printf("}")
+	for _, name := range constNames {
+		g.emitTo(consts[name], g.alias+"."+name, fmt.Sprintf("m[%q]", snakeCase(name)))
+	}
+	g.printf("")
+	g.printf("return m")
+	g.printf("}")
+	membersFunc := append([]byte{}, g.buf.Bytes()...)
+	g.buf.Reset()
+
+	// header
+	g.printf("// Code generated by tengobind; DO NOT EDIT.")
+	g.printf("")
+	g.printf("// Package %s is the Tengo module of %s package.", g.opts.Package, g.opts.ImportPath)
+	g.printf("package %s", g.opts.Package)
+	g.printf("")
+	g.printf("import (")
+	for _, path := range []string{"fmt", "time"} {
+		if g.uses[path] {
+			g.printf("%q", path)
+		}
+	}
+	g.printf("")
+	if len(constNames) > 0 || len(body.Bytes()) > 0 {
+		if g.alias != g.pkgName {
+			g.printf("%s %q", g.alias, g.opts.ImportPath)
+		} else {
+			g.printf("%q", g.opts.ImportPath)
+		}
+	}
+	if len(structNames) > 0 {
+		g.printf("%q", "github.com/d5/tengo/compiler/token")
+	}
+	g.printf("%q", "github.com/d5/tengo/objects")
+	g.printf(")")
+
+	_, _ = g.buf.Write(membersFunc)
+	_, _ = g.buf.Write(body.Bytes())
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated code: %s", err.Error())
+	}
+
+	return src, nil
+}
+
+// addMember adds the module member, or, returns false if the name is used
+// by another member.
+func (g *generator) addMember(name, goName string) bool {
+	if other, exists := g.members[name]; exists {
+		g.skip(goName, fmt.Errorf("'%s' conflicts with %s", name, other))
+		return false
+	}
+	g.members[name] = goName
+
+	return true
+}
+
+// emitCall writes the body of the function that converts the arguments,
+// calls the callee, and, returns the converted results.
+func (g *generator) emitCall(sig *signature, callee string) {
+	numParams := len(sig.params)
+	if sig.variadic {
+		g.printf("if len(args) < %d {", numParams-1)
+	} else {
+		g.printf("if len(args) != %d {", numParams)
+	}
+	g.printf("return nil, objects.ErrWrongNumArguments")
+	g.printf("}")
+
+	var callArgs []string
+	for i, t := range sig.params {
+		arg := fmt.Sprintf("a%d", i)
+		onFail := argumentFail(i)
+
+		g.printf("")
+		if sig.variadic && i == numParams-1 {
+			idx := g.temp()
+			g.printf("%s := make([]%s, len(args)-%d)", arg, g.goType(t), i)
+			g.printf("for %s := range %s {", idx, arg)
+			g.emitFrom(t, fmt.Sprintf("args[%d+%s]", i, idx), arg+"["+idx+"]", onFail)
+			g.printf("}")
+			callArgs = append(callArgs, arg+"...")
+			continue
+		}
+
+		g.printf("var %s %s", arg, g.goType(t))
+		g.emitFrom(t, fmt.Sprintf("args[%d]", i), arg, onFail)
+		callArgs = append(callArgs, arg)
+	}
+
+	call := callee + "(" + strings.Join(callArgs, ", ") + ")"
+	g.printf("")
+
+	if len(sig.results) == 0 {
+		if sig.err {
+			g.printf("if err := %s; err != nil {", call)
+			g.printf("return &objects.Error{Value: &objects.String{Value: err.Error()}}, nil")
+			g.printf("}")
+			g.printf("")
+			g.printf("return objects.TrueValue, nil")
+			return
+		}
+
+		g.printf("%s", call)
+		g.printf("")
+		g.printf("return objects.UndefinedValue, nil")
+		return
+	}
+
+	var results []string
+	for i := range sig.results {
+		results = append(results, fmt.Sprintf("r%d", i))
+	}
+	if sig.err {
+		results = append(results, "err")
+	}
+	g.printf("%s := %s", strings.Join(results, ", "), call)
+	if sig.err {
+		g.printf("if err != nil {")
+		g.printf("return &objects.Error{Value: &objects.String{Value: err.Error()}}, nil")
+		g.printf("}")
+	}
+	g.printf("")
+
+	if len(sig.results) == 1 {
+		g.printf("var res objects.Object")
+		g.emitTo(sig.results[0], "r0", "res")
+		g.printf("")
+		g.printf("return res, nil")
+		return
+	}
+
+	g.printf("res := make([]objects.Object, %d)", len(sig.results))
+	for i, t := range sig.results {
+		g.emitTo(t, results[i], fmt.Sprintf("res[%d]", i))
+	}
+	g.printf("")
+	g.printf("return &objects.Array{Value: res}, nil")
+}
+
+func argumentFail(i int) fail {
+	name := strconv.Itoa(i+1) + "th"
+	if i < len(ordinals) {
+		name = ordinals[i]
+	}
+
+	return func(expected, obj string) string {
+		return fmt.Sprintf("return nil, objects.ErrInvalidArgumentType{Name: %q, Expected: %q, Found: %s.TypeName()}",
+			name, expected, obj)
+	}
+}
+
+// field is a supported exported field of a struct.
+type field struct {
+	name   string // name in the scripts
+	goName string
+	t      *bindType
+}
+
+// emitStruct writes the object type of the struct and its constructor, and,
+// returns the name of the constructor.
+func (g *generator) emitStruct(s *structDecl) string {
+	wrapper := s.name + "Object"
+	t := g.structType(kindStructPtr, s.name)
+	names := make(map[string]string) // Go names of the fields and the methods by their names
+
+	var fields []*field
+	for _, f := range s.typ.Fields.List {
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+
+			qualified := s.name + "." + name.Name
+			ft, err := g.resolve(f.Type, s.time)
+			if err == nil && ft.kind == kindError {
+				err = fmt.Errorf("unsupported type: error")
+			}
+			if err != nil {
+				g.skip(qualified, err)
+				continue
+			}
+
+			fd := &field{name: snakeCase(name.Name), goName: name.Name, t: ft}
+			if other, exists := names[fd.name]; exists {
+				g.skip(qualified, fmt.Errorf("'%s' conflicts with %s", fd.name, other))
+				continue
+			}
+			names[fd.name] = name.Name
+			fields = append(fields, fd)
+		}
+	}
+
+	type method struct {
+		name   string
+		goName string
+		sig    *signature
+	}
+
+	var methods []*method
+	hasString := false
+	for _, m := range s.methods {
+		qualified := s.name + "." + m.Name.Name
+		sig, err := g.signature(m.Type, m.time)
+		if err != nil {
+			g.skip(qualified, err)
+			continue
+		}
+
+		if m.Name.Name == "String" && len(sig.params) == 0 && len(sig.results) == 1 &&
+			sig.results[0].kind == kindString && !sig.err {
+			hasString = true
+		}
+
+		md := &method{name: snakeCase(m.Name.Name), goName: m.Name.Name, sig: sig}
+		if other, exists := names[md.name]; exists {
+			g.skip(qualified, fmt.Errorf("'%s' conflicts with %s", md.name, other))
+			continue
+		}
+		names[md.name] = m.Name.Name
+		methods = append(methods, md)
+	}
+
+	g.printf("")
+	g.printf("// %s is the object of %s.%s.", wrapper, g.pkgName, s.name)
+	g.printf("type %s struct {", wrapper)
+	g.printf("Value %s", t.goName)
+	g.printf("}")
+
+	g.printf("")
+	g.printf("// TypeName returns the name of the type.")
+	g.printf("func (o *%s) TypeName() string {", wrapper)
+	g.printf("return %q", t.module)
+	g.printf("}")
+
+	g.printf("")
+	g.printf("func (o *%s) String() string {", wrapper)
+	if hasString {
+		g.printf("return o.Value.String()")
+	} else {
+		g.uses["fmt"] = true
+		g.printf("return fmt.Sprintf(\"%%+v\", *o.Value)")
+	}
+	g.printf("}")
+
+	g.printf("")
+	g.printf("// BinaryOp returns another object that is the result of")
+	g.printf("// a given binary operator and a right-hand side object.")
+	g.printf("func (o *%s) BinaryOp(op token.Token, rhs objects.Object) (objects.Object, error) {", wrapper)
+	g.printf("return nil, objects.ErrInvalidOperator")
+	g.printf("}")
+
+	g.printf("")
+	g.printf("// IsFalsy returns true if the value of the type is falsy.")
+	g.printf("func (o *%s) IsFalsy() bool {", wrapper)
+	g.printf("return o.Value == nil")
+	g.printf("}")
+
+	g.printf("")
+	g.printf("// Equals returns true if the object refers to the same struct.")
+	g.printf("func (o *%s) Equals(x objects.Object) bool {", wrapper)
+	g.printf("t, ok := x.(*%s)", wrapper)
+	g.printf("")
+	g.printf("return ok && t.Value == o.Value")
+	g.printf("}")
+
+	g.printf("")
+	g.printf("// Copy returns a copy of the type that refers to a copy of the struct.")
+	g.printf("func (o *%s) Copy() objects.Object {", wrapper)
+	g.printf("v := *o.Value")
+	g.printf("")
+	g.printf("return &%s{Value: &v}", wrapper)
+	g.printf("}")
+
+	g.printf("")
+	g.printf("// IndexGet returns the value of a field or a method of the struct.")
+	g.printf("func (o *%s) IndexGet(index objects.Object) (objects.Object, error) {", wrapper)
+	g.printf("strIdx, ok := index.(*objects.String)")
+	g.printf("if !ok {")
+	g.printf("return nil, objects.ErrInvalidIndexType")
+	g.printf("}")
+	g.printf("")
+	g.printf("var res objects.Object")
+	g.printf("switch strIdx.Value {")
+	for _, f := range fields {
+		g.printf("case %q:", f.name)
+		g.emitTo(f.t, "o.Value."+f.goName, "res")
+	}
+	for _, m := range methods {
+		g.printf("case %q:", m.name)
+		g.printf("res = &objects.UserFunction{Name: %q, Value: o.method%s}", m.name, m.goName)
+	}
+	g.printf("default:")
+	g.printf("res = objects.UndefinedValue")
+	g.printf("}")
+	g.printf("")
+	g.printf("return res, nil")
+	g.printf("}")
+
+	fieldNames := make([]string, len(fields))
+	for i, f := range fields {
+		fieldNames[i] = fmt.Sprintf("%q", f.name)
+	}
+	methodNames := make([]string, len(methods))
+	for i, m := range methods {
+		methodNames[i] = fmt.Sprintf("%q", m.name)
+	}
+
+	g.printf("")
+	g.printf("// FieldNames returns the names of the fields of the struct.")
+	g.printf("func (o *%s) FieldNames() []string {", wrapper)
+	g.printf("return []string{%s}", strings.Join(fieldNames, ", "))
+	g.printf("}")
+
+	g.printf("")
+	g.printf("// MethodNames returns the names of the methods of the struct.")
+	g.printf("func (o *%s) MethodNames() []string {", wrapper)
+	g.printf("return []string{%s}", strings.Join(methodNames, ", "))
+	g.printf("}")
+
+	if len(fields) > 0 {
+		g.printf("")
+		g.printf("// IndexSet sets the value of a field of the struct.")
+		g.printf("func (o *%s) IndexSet(index, value objects.Object) error {", wrapper)
+		g.printf("strIdx, ok := index.(*objects.String)")
+		g.printf("if !ok {")
+		g.printf("return objects.ErrInvalidIndexType")
+		g.printf("}")
+		g.printf("")
+		g.printf("switch strIdx.Value {")
+		for _, f := range fields {
+			g.printf("case %q:", f.name)
+			g.emitFrom(f.t, "value", "o.Value."+f.goName, func(expected, obj string) string {
+				return "return objects.ErrInvalidIndexValueType"
+			})
+		}
+		g.printf("default:")
+		g.printf("return objects.ErrInvalidIndexType")
+		g.printf("}")
+		g.printf("")
+		g.printf("return nil")
+		g.printf("}")
+	}
+
+	for _, m := range methods {
+		g.printf("")
+		g.printf("// method%s calls %s.%s.%s.", m.goName, g.pkgName, s.name, m.goName)
+		g.printf("func (o *%s) method%s(args ...objects.Object) (objects.Object, error) {", wrapper, m.goName)
+		g.emitCall(m.sig, "o.Value."+m.goName)
+		g.printf("}")
+	}
+
+	constructor := "new" + s.name
+	g.printf("")
+	if len(fields) == 0 {
+		g.printf("// %s returns the object of a new %s.%s.", constructor, g.pkgName, s.name)
+		g.printf("func %s(args ...objects.Object) (objects.Object, error) {", constructor)
+		g.printf("if len(args) != 0 {")
+		g.printf("return nil, objects.ErrWrongNumArguments")
+		g.printf("}")
+		g.printf("")
+		g.printf("return &%s{Value: &%s.%s{}}, nil", wrapper, g.alias, s.name)
+		g.printf("}")
+		return constructor
+	}
+
+	g.uses["mapElements"] = true
+	g.printf("// %s returns the object of a new %s.%s. The optional argument is", constructor, g.pkgName, s.name)
+	g.printf("// the map of the field values.")
+	g.printf("func %s(args ...objects.Object) (objects.Object, error) {", constructor)
+	g.printf("if len(args) > 1 {")
+	g.printf("return nil, objects.ErrWrongNumArguments")
+	g.printf("}")
+	g.printf("")
+	g.printf("o := &%s{Value: &%s.%s{}}", wrapper, g.alias, s.name)
+	g.printf("if len(args) == 1 {")
+	g.printf("fields, ok := mapElements(args[0])")
+	g.printf("if !ok {")
+	g.printf("%s", argumentFail(0)("map", "args[0]"))
+	g.printf("}")
+	g.printf("")
+	g.printf("for name, value := range fields {")
+	g.printf("if err := o.IndexSet(&objects.String{Value: name}, value); err != nil {")
+	g.printf("return nil, err")
+	g.printf("}")
+	g.printf("}")
+	g.printf("}")
+	g.printf("")
+	g.printf("return o, nil")
+	g.printf("}")
+
+	return constructor
+}
+
+// emitHelpers writes the helper functions used by the generated code.
+func (g *generator) emitHelpers() {
+	if g.uses["arrayElements"] {
+		g.printf("")
+		g.printf("// arrayElements returns the elements of an array or an immutable array.")
+		g.printf("func arrayElements(o objects.Object) ([]objects.Object, bool) {")
+		g.printf("switch o := o.(type) {")
+		g.printf("case *objects.Array:")
+		g.printf("return o.Value, true")
+		g.printf("case *objects.ImmutableArray:")
+		g.printf("return o.Value, true")
+		g.printf("}")
+		g.printf("")
+		g.printf("return nil, false")
+		g.printf("}")
+	}
+
+	if g.uses["mapElements"] {
+		g.printf("")
+		g.printf("// mapElements returns the elements of a map or an immutable map.")
+		g.printf("func mapElements(o objects.Object) (map[string]objects.Object, bool) {")
+		g.printf("switch o := o.(type) {")
+		g.printf("case *objects.Map:")
+		g.printf("return o.Value, true")
+		g.printf("case *objects.ImmutableMap:")
+		g.printf("return o.Value, true")
+		g.printf("}")
+		g.printf("")
+		g.printf("return nil, false")
+		g.printf("}")
+	}
+}
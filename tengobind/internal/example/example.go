@@ -0,0 +1,150 @@
+// Package example is the Go package used to test the generated module.
+package example
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Unit is the unit of the lengths.
+type Unit int
+
+// Units
+const (
+	Meter Unit = iota
+	Kilometer
+	Mile
+)
+
+// Constants of the untyped constant expressions.
+const (
+	Pi      = 3.14159
+	Name    = "example"
+	MaxSize = 1 << 10
+	Enabled = MaxSize > 0
+	Initial = 'a'
+	Half    = MaxSize / 2.0
+)
+
+// Timeout is skipped because its type is not inferred without type checking.
+const Timeout = 3 * time.Second
+
+// Point is a point with a label.
+type Point struct {
+	X, Y  float64
+	Label string
+	Tags  []string
+	Ch    chan int
+	id    int
+}
+
+// NewPoint returns a new point.
+func NewPoint(x, y float64) *Point {
+	return &Point{X: x, Y: y}
+}
+
+// Move moves the point.
+func (p *Point) Move(dx, dy float64) {
+	p.X += dx
+	p.Y += dy
+}
+
+// Distance returns the distance to the other point.
+func (p Point) Distance(o Point) float64 {
+	return math.Hypot(o.X-p.X, o.Y-p.Y)
+}
+
+// String returns the string representation of the point.
+func (p *Point) String() string {
+	return fmt.Sprintf("(%g, %g)", p.X, p.Y)
+}
+
+// Add returns the sum of the integers.
+func Add(a, b int) int {
+	return a + b
+}
+
+// Divide returns a/b, or, an error if b is 0.
+func Divide(a, b int64) (int64, error) {
+	if b == 0 {
+		return 0, errors.New("division by zero")
+	}
+
+	return a / b, nil
+}
+
+// Split splits the string.
+func Split(s, sep string) []string {
+	return strings.Split(s, sep)
+}
+
+// Join joins the strings.
+func Join(sep string, elems ...string) string {
+	return strings.Join(elems, sep)
+}
+
+// Count returns the number of each word.
+func Count(words []string) map[string]int {
+	counts := make(map[string]int)
+	for _, w := range words {
+		counts[w]++
+	}
+
+	return counts
+}
+
+// Check returns an error if the string is empty.
+func Check(s string) error {
+	if s == "" {
+		return errors.New("empty")
+	}
+
+	return nil
+}
+
+// MinMax returns the minimum and the maximum values.
+func MinMax(first float64, rest ...float64) (min, max float64) {
+	min, max = first, first
+	for _, v := range rest {
+		min, max = math.Min(min, v), math.Max(max, v)
+	}
+
+	return
+}
+
+// After returns the time after the duration.
+func After(t time.Time, d time.Duration) time.Time {
+	return t.Add(d)
+}
+
+// Convert converts the length in meters.
+func Convert(v float64, u Unit) float64 {
+	switch u {
+	case Kilometer:
+		return v / 1000
+	case Mile:
+		return v / 1609.344
+	}
+
+	return v
+}
+
+// IsEmpty returns true if the bytes are empty.
+func IsEmpty(b []byte) bool {
+	return len(b) == 0
+}
+
+// Send is skipped because of the channel.
+func Send(ch chan int) {
+	ch <- 1
+}
+
+// Origin returns the origin.
+func Origin() Point {
+	return Point{Label: "origin"}
+}
+
+func unexported() {}
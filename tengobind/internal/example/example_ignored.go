@@ -0,0 +1,6 @@
+//go:build ignore
+
+package example
+
+// Ignored is in a file excluded by the build constraints.
+func Ignored() {}
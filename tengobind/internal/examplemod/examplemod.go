@@ -0,0 +1,618 @@
+// Code generated by tengobind; DO NOT EDIT.
+
+// Package examplemod is the Tengo module of github.com/d5/tengo/tengobind/internal/example package.
+package examplemod
+
+import (
+	"time"
+
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+	"github.com/d5/tengo/tengobind/internal/example"
+)
+
+// Members returns the members of "example" module. Register the module before
+// compiling the scripts to import it:
+//
+//	stdlib.Register("example", examplemod.Members)
+func Members() map[string]objects.Object {
+	m := map[string]objects.Object{
+		"add":       &objects.UserFunction{Name: "add", Value: funcAdd},
+		"after":     &objects.UserFunction{Name: "after", Value: funcAfter},
+		"check":     &objects.UserFunction{Name: "check", Value: funcCheck},
+		"convert":   &objects.UserFunction{Name: "convert", Value: funcConvert},
+		"count":     &objects.UserFunction{Name: "count", Value: funcCount},
+		"divide":    &objects.UserFunction{Name: "divide", Value: funcDivide},
+		"is_empty":  &objects.UserFunction{Name: "is_empty", Value: funcIsEmpty},
+		"join":      &objects.UserFunction{Name: "join", Value: funcJoin},
+		"min_max":   &objects.UserFunction{Name: "min_max", Value: funcMinMax},
+		"new_point": &objects.UserFunction{Name: "new_point", Value: funcNewPoint},
+		"origin":    &objects.UserFunction{Name: "origin", Value: funcOrigin},
+		"point":     &objects.UserFunction{Name: "point", Value: newPoint},
+		"split":     &objects.UserFunction{Name: "split", Value: funcSplit},
+	}
+	m["meter"] = &objects.Int{Value: int64(example.Meter)}
+	m["kilometer"] = &objects.Int{Value: int64(example.Kilometer)}
+	m["mile"] = &objects.Int{Value: int64(example.Mile)}
+	m["pi"] = &objects.Float{Value: example.Pi}
+	m["name"] = &objects.String{Value: example.Name}
+	m["max_size"] = &objects.Int{Value: int64(example.MaxSize)}
+	if example.Enabled {
+		m["enabled"] = objects.TrueValue
+	} else {
+		m["enabled"] = objects.FalseValue
+	}
+	m["initial"] = &objects.Char{Value: example.Initial}
+	m["half"] = &objects.Float{Value: example.Half}
+
+	return m
+}
+
+// funcAdd calls example.Add.
+func funcAdd(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 int
+	t1, ok := objects.ToInt64(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "int(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = int(t1)
+
+	var a1 int
+	t2, ok := objects.ToInt64(args[1])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "second", Expected: "int(compatible)", Found: args[1].TypeName()}
+	}
+	a1 = int(t2)
+
+	r0 := example.Add(a0, a1)
+
+	var res objects.Object
+	res = &objects.Int{Value: int64(r0)}
+
+	return res, nil
+}
+
+// funcAfter calls example.After.
+func funcAfter(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 time.Time
+	t3, ok := objects.ToTime(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "time(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t3
+
+	var a1 time.Duration
+	t4, ok := objects.ToInt64(args[1])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "second", Expected: "int(compatible)", Found: args[1].TypeName()}
+	}
+	a1 = time.Duration(t4)
+
+	r0 := example.After(a0, a1)
+
+	var res objects.Object
+	res = &objects.Time{Value: r0}
+
+	return res, nil
+}
+
+// funcCheck calls example.Check.
+func funcCheck(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 string
+	t5, ok := objects.ToString(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "string(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t5
+
+	if err := example.Check(a0); err != nil {
+		return &objects.Error{Value: &objects.String{Value: err.Error()}}, nil
+	}
+
+	return objects.TrueValue, nil
+}
+
+// funcConvert calls example.Convert.
+func funcConvert(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 float64
+	t6, ok := objects.ToFloat64(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "float(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t6
+
+	var a1 example.Unit
+	t7, ok := objects.ToInt64(args[1])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "second", Expected: "int(compatible)", Found: args[1].TypeName()}
+	}
+	a1 = example.Unit(t7)
+
+	r0 := example.Convert(a0, a1)
+
+	var res objects.Object
+	res = &objects.Float{Value: r0}
+
+	return res, nil
+}
+
+// funcCount calls example.Count.
+func funcCount(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 []string
+	t8, ok := arrayElements(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "array", Found: args[0].TypeName()}
+	}
+	a0 = make([]string, len(t8))
+	for t9, t10 := range t8 {
+		t11, ok := objects.ToString(t10)
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "string(compatible)", Found: t10.TypeName()}
+		}
+		a0[t9] = t11
+	}
+
+	r0 := example.Count(a0)
+
+	var res objects.Object
+	t12 := make(map[string]objects.Object, len(r0))
+	for t13, t14 := range r0 {
+		t12[t13] = &objects.Int{Value: int64(t14)}
+	}
+	res = &objects.Map{Value: t12}
+
+	return res, nil
+}
+
+// funcDivide calls example.Divide.
+func funcDivide(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 int64
+	t15, ok := objects.ToInt64(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "int(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t15
+
+	var a1 int64
+	t16, ok := objects.ToInt64(args[1])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "second", Expected: "int(compatible)", Found: args[1].TypeName()}
+	}
+	a1 = t16
+
+	r0, err := example.Divide(a0, a1)
+	if err != nil {
+		return &objects.Error{Value: &objects.String{Value: err.Error()}}, nil
+	}
+
+	var res objects.Object
+	res = &objects.Int{Value: r0}
+
+	return res, nil
+}
+
+// funcIsEmpty calls example.IsEmpty.
+func funcIsEmpty(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 []byte
+	t17, ok := objects.ToByteSlice(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "bytes(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t17
+
+	r0 := example.IsEmpty(a0)
+
+	var res objects.Object
+	if r0 {
+		res = objects.TrueValue
+	} else {
+		res = objects.FalseValue
+	}
+
+	return res, nil
+}
+
+// funcJoin calls example.Join.
+func funcJoin(args ...objects.Object) (objects.Object, error) {
+	if len(args) < 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 string
+	t18, ok := objects.ToString(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "string(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t18
+
+	a1 := make([]string, len(args)-1)
+	for t19 := range a1 {
+		t20, ok := objects.ToString(args[1+t19])
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{Name: "second", Expected: "string(compatible)", Found: args[1+t19].TypeName()}
+		}
+		a1[t19] = t20
+	}
+
+	r0 := example.Join(a0, a1...)
+
+	var res objects.Object
+	res = &objects.String{Value: r0}
+
+	return res, nil
+}
+
+// funcMinMax calls example.MinMax.
+func funcMinMax(args ...objects.Object) (objects.Object, error) {
+	if len(args) < 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 float64
+	t21, ok := objects.ToFloat64(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "float(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t21
+
+	a1 := make([]float64, len(args)-1)
+	for t22 := range a1 {
+		t23, ok := objects.ToFloat64(args[1+t22])
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{Name: "second", Expected: "float(compatible)", Found: args[1+t22].TypeName()}
+		}
+		a1[t22] = t23
+	}
+
+	r0, r1 := example.MinMax(a0, a1...)
+
+	res := make([]objects.Object, 2)
+	res[0] = &objects.Float{Value: r0}
+	res[1] = &objects.Float{Value: r1}
+
+	return &objects.Array{Value: res}, nil
+}
+
+// funcNewPoint calls example.NewPoint.
+func funcNewPoint(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 float64
+	t24, ok := objects.ToFloat64(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "float(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t24
+
+	var a1 float64
+	t25, ok := objects.ToFloat64(args[1])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "second", Expected: "float(compatible)", Found: args[1].TypeName()}
+	}
+	a1 = t25
+
+	r0 := example.NewPoint(a0, a1)
+
+	var res objects.Object
+	if r0 == nil {
+		res = objects.UndefinedValue
+	} else {
+		res = &PointObject{Value: r0}
+	}
+
+	return res, nil
+}
+
+// funcOrigin calls example.Origin.
+func funcOrigin(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	r0 := example.Origin()
+
+	var res objects.Object
+	t26 := r0
+	res = &PointObject{Value: &t26}
+
+	return res, nil
+}
+
+// funcSplit calls example.Split.
+func funcSplit(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 string
+	t27, ok := objects.ToString(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "string(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t27
+
+	var a1 string
+	t28, ok := objects.ToString(args[1])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "second", Expected: "string(compatible)", Found: args[1].TypeName()}
+	}
+	a1 = t28
+
+	r0 := example.Split(a0, a1)
+
+	var res objects.Object
+	t29 := make([]objects.Object, len(r0))
+	for t30, t31 := range r0 {
+		t29[t30] = &objects.String{Value: t31}
+	}
+	res = &objects.Array{Value: t29}
+
+	return res, nil
+}
+
+// PointObject is the object of example.Point.
+type PointObject struct {
+	Value *example.Point
+}
+
+// TypeName returns the name of the type.
+func (o *PointObject) TypeName() string {
+	return "example.point"
+}
+
+func (o *PointObject) String() string {
+	return o.Value.String()
+}
+
+// BinaryOp returns another object that is the result of
+// a given binary operator and a right-hand side object.
+func (o *PointObject) BinaryOp(op token.Token, rhs objects.Object) (objects.Object, error) {
+	return nil, objects.ErrInvalidOperator
+}
+
+// IsFalsy returns true if the value of the type is falsy.
+func (o *PointObject) IsFalsy() bool {
+	return o.Value == nil
+}
+
+// Equals returns true if the object refers to the same struct.
+func (o *PointObject) Equals(x objects.Object) bool {
+	t, ok := x.(*PointObject)
+
+	return ok && t.Value == o.Value
+}
+
+// Copy returns a copy of the type that refers to a copy of the struct.
+func (o *PointObject) Copy() objects.Object {
+	v := *o.Value
+
+	return &PointObject{Value: &v}
+}
+
+// IndexGet returns the value of a field or a method of the struct.
+func (o *PointObject) IndexGet(index objects.Object) (objects.Object, error) {
+	strIdx, ok := index.(*objects.String)
+	if !ok {
+		return nil, objects.ErrInvalidIndexType
+	}
+
+	var res objects.Object
+	switch strIdx.Value {
+	case "x":
+		res = &objects.Float{Value: o.Value.X}
+	case "y":
+		res = &objects.Float{Value: o.Value.Y}
+	case "label":
+		res = &objects.String{Value: o.Value.Label}
+	case "tags":
+		t32 := make([]objects.Object, len(o.Value.Tags))
+		for t33, t34 := range o.Value.Tags {
+			t32[t33] = &objects.String{Value: t34}
+		}
+		res = &objects.Array{Value: t32}
+	case "distance":
+		res = &objects.UserFunction{Name: "distance", Value: o.methodDistance}
+	case "move":
+		res = &objects.UserFunction{Name: "move", Value: o.methodMove}
+	case "string":
+		res = &objects.UserFunction{Name: "string", Value: o.methodString}
+	default:
+		res = objects.UndefinedValue
+	}
+
+	return res, nil
+}
+
+// FieldNames returns the names of the fields of the struct.
+func (o *PointObject) FieldNames() []string {
+	return []string{"x", "y", "label", "tags"}
+}
+
+// MethodNames returns the names of the methods of the struct.
+func (o *PointObject) MethodNames() []string {
+	return []string{"distance", "move", "string"}
+}
+
+// IndexSet sets the value of a field of the struct.
+func (o *PointObject) IndexSet(index, value objects.Object) error {
+	strIdx, ok := index.(*objects.String)
+	if !ok {
+		return objects.ErrInvalidIndexType
+	}
+
+	switch strIdx.Value {
+	case "x":
+		t35, ok := objects.ToFloat64(value)
+		if !ok {
+			return objects.ErrInvalidIndexValueType
+		}
+		o.Value.X = t35
+	case "y":
+		t36, ok := objects.ToFloat64(value)
+		if !ok {
+			return objects.ErrInvalidIndexValueType
+		}
+		o.Value.Y = t36
+	case "label":
+		t37, ok := objects.ToString(value)
+		if !ok {
+			return objects.ErrInvalidIndexValueType
+		}
+		o.Value.Label = t37
+	case "tags":
+		t38, ok := arrayElements(value)
+		if !ok {
+			return objects.ErrInvalidIndexValueType
+		}
+		o.Value.Tags = make([]string, len(t38))
+		for t39, t40 := range t38 {
+			t41, ok := objects.ToString(t40)
+			if !ok {
+				return objects.ErrInvalidIndexValueType
+			}
+			o.Value.Tags[t39] = t41
+		}
+	default:
+		return objects.ErrInvalidIndexType
+	}
+
+	return nil
+}
+
+// methodDistance calls example.Point.Distance.
+func (o *PointObject) methodDistance(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 example.Point
+	t42, ok := args[0].(*PointObject)
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "example.point", Found: args[0].TypeName()}
+	}
+	a0 = *t42.Value
+
+	r0 := o.Value.Distance(a0)
+
+	var res objects.Object
+	res = &objects.Float{Value: r0}
+
+	return res, nil
+}
+
+// methodMove calls example.Point.Move.
+func (o *PointObject) methodMove(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 2 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	var a0 float64
+	t43, ok := objects.ToFloat64(args[0])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "float(compatible)", Found: args[0].TypeName()}
+	}
+	a0 = t43
+
+	var a1 float64
+	t44, ok := objects.ToFloat64(args[1])
+	if !ok {
+		return nil, objects.ErrInvalidArgumentType{Name: "second", Expected: "float(compatible)", Found: args[1].TypeName()}
+	}
+	a1 = t44
+
+	o.Value.Move(a0, a1)
+
+	return objects.UndefinedValue, nil
+}
+
+// methodString calls example.Point.String.
+func (o *PointObject) methodString(args ...objects.Object) (objects.Object, error) {
+	if len(args) != 0 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	r0 := o.Value.String()
+
+	var res objects.Object
+	res = &objects.String{Value: r0}
+
+	return res, nil
+}
+
+// newPoint returns the object of a new example.Point. The optional argument is
+// the map of the field values.
+func newPoint(args ...objects.Object) (objects.Object, error) {
+	if len(args) > 1 {
+		return nil, objects.ErrWrongNumArguments
+	}
+
+	o := &PointObject{Value: &example.Point{}}
+	if len(args) == 1 {
+		fields, ok := mapElements(args[0])
+		if !ok {
+			return nil, objects.ErrInvalidArgumentType{Name: "first", Expected: "map", Found: args[0].TypeName()}
+		}
+
+		for name, value := range fields {
+			if err := o.IndexSet(&objects.String{Value: name}, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// arrayElements returns the elements of an array or an immutable array.
+func arrayElements(o objects.Object) ([]objects.Object, bool) {
+	switch o := o.(type) {
+	case *objects.Array:
+		return o.Value, true
+	case *objects.ImmutableArray:
+		return o.Value, true
+	}
+
+	return nil, false
+}
+
+// mapElements returns the elements of a map or an immutable map.
+func mapElements(o objects.Object) (map[string]objects.Object, bool) {
+	switch o := o.(type) {
+	case *objects.Map:
+		return o.Value, true
+	case *objects.ImmutableMap:
+		return o.Value, true
+	}
+
+	return nil, false
+}
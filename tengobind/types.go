@@ -0,0 +1,384 @@
+package tengobind
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+type kind int
+
+const (
+	kindBool kind = iota
+	kindString
+	kindInt
+	kindFloat
+	kindChar
+	kindBytes
+	kindTime
+	kindError
+	kindSlice
+	kindMap
+	kindStruct
+	kindStructPtr
+)
+
+// bindType is a supported Go type.
+type bindType struct {
+	kind    kind
+	goName  string    // name in the generated code (e.g. "int32", "[]geo.Point")
+	elem    *bindType // element type of slice and map
+	wrapper string    // object type of the struct (e.g. "PointObject")
+	module  string    // type name of the struct object (e.g. "geo.point")
+	time    bool      // the type refers to time package
+}
+
+// basicKinds are the kinds of the predeclared types.
+var basicKinds = map[string]kind{
+	"bool":    kindBool,
+	"string":  kindString,
+	"int":     kindInt,
+	"int8":    kindInt,
+	"int16":   kindInt,
+	"int32":   kindInt,
+	"int64":   kindInt,
+	"uint":    kindInt,
+	"uint8":   kindInt,
+	"uint16":  kindInt,
+	"uint32":  kindInt,
+	"uint64":  kindInt,
+	"byte":    kindInt,
+	"rune":    kindChar,
+	"float32": kindFloat,
+	"float64": kindFloat,
+}
+
+// scalarConversion is the conversion of an object to a Go value of the
+// scalar type using the objects.To* functions.
+type scalarConversion struct {
+	fn       string // conversion function of objects package
+	base     string // type of the converted value
+	expected string // expected type in the error message
+	object   string // object type to convert the Go value to
+}
+
+var scalarConversions = map[kind]scalarConversion{
+	kindString: {"ToString", "string", "string(compatible)", "objects.String"},
+	kindInt:    {"ToInt64", "int64", "int(compatible)", "objects.Int"},
+	kindFloat:  {"ToFloat64", "float64", "float(compatible)", "objects.Float"},
+	kindChar:   {"ToRune", "rune", "char(compatible)", "objects.Char"},
+	kindBytes:  {"ToByteSlice", "[]byte", "bytes(compatible)", "objects.Bytes"},
+	kindTime:   {"ToTime", "time.Time", "time(compatible)", "objects.Time"},
+}
+
+// resolve returns the supported type of the type expression. hasTime is
+// true if the file of the expression imports time package.
+func (g *generator) resolve(expr ast.Expr, hasTime bool) (*bindType, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if k, ok := basicKinds[e.Name]; ok {
+			return &bindType{kind: k, goName: e.Name}, nil
+		}
+
+		if e.Name == "error" {
+			return &bindType{kind: kindError, goName: e.Name}, nil
+		}
+
+		if underlying, ok := g.named[e.Name]; ok {
+			if k, ok := basicKinds[underlying.Name]; ok {
+				return &bindType{kind: k, goName: g.alias + "." + e.Name}, nil
+			}
+		}
+
+		if _, ok := g.structs[e.Name]; ok {
+			return g.structType(kindStruct, e.Name), nil
+		}
+	case *ast.StarExpr:
+		if id, ok := e.X.(*ast.Ident); ok {
+			if _, ok := g.structs[id.Name]; ok {
+				return g.structType(kindStructPtr, id.Name), nil
+			}
+		}
+	case *ast.ArrayType:
+		if e.Len != nil {
+			break
+		}
+
+		if id, ok := e.Elt.(*ast.Ident); ok && (id.Name == "byte" || id.Name == "uint8") {
+			return &bindType{kind: kindBytes, goName: "[]byte"}, nil
+		}
+
+		elem, err := g.resolve(e.Elt, hasTime)
+		if err != nil || elem.kind == kindError {
+			break
+		}
+
+		return &bindType{kind: kindSlice, goName: "[]" + elem.goName, elem: elem, time: elem.time}, nil
+	case *ast.MapType:
+		if id, ok := e.Key.(*ast.Ident); !ok || id.Name != "string" {
+			break
+		}
+
+		elem, err := g.resolve(e.Value, hasTime)
+		if err != nil || elem.kind == kindError {
+			break
+		}
+
+		return &bindType{kind: kindMap, goName: "map[string]" + elem.goName, elem: elem, time: elem.time}, nil
+	case *ast.SelectorExpr:
+		if id, ok := e.X.(*ast.Ident); !ok || id.Name != "time" || !hasTime {
+			break
+		}
+
+		switch e.Sel.Name {
+		case "Time":
+			return &bindType{kind: kindTime, goName: "time.Time", time: true}, nil
+		case "Duration":
+			return &bindType{kind: kindInt, goName: "time.Duration", time: true}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported type: %s", types.ExprString(expr))
+}
+
+func (g *generator) structType(k kind, name string) *bindType {
+	t := &bindType{
+		kind:    k,
+		goName:  g.alias + "." + name,
+		wrapper: name + "Object",
+		module:  g.opts.Module + "." + snakeCase(name),
+	}
+	if k == kindStructPtr {
+		t.goName = "*" + t.goName
+	}
+
+	return t
+}
+
+// goType returns the name of the type in the generated code.
+func (g *generator) goType(t *bindType) string {
+	if t.time {
+		g.uses["time"] = true
+	}
+
+	return t.goName
+}
+
+// fail returns the statement that is run when the conversion of an object
+// fails, given the expected type and the object expression.
+type fail func(expected, obj string) string
+
+// emitFrom writes the statements that convert the object src to the Go
+// value of the type t, and, assign it to dst.
+func (g *generator) emitFrom(t *bindType, src, dst string, onFail fail) {
+	switch t.kind {
+	case kindBool:
+		g.printf("%s = %s", dst, g.convert(t, "bool", "!"+src+".IsFalsy()"))
+	case kindStruct, kindStructPtr:
+		tmp := g.temp()
+		g.printf("%s, ok := %s.(*%s)", tmp, src, t.wrapper)
+		g.printf("if !ok {")
+		g.printf("%s", onFail(t.module, src))
+		g.printf("}")
+		if t.kind == kindStruct {
+			g.printf("%s = *%s.Value", dst, tmp)
+		} else {
+			g.printf("%s = %s.Value", dst, tmp)
+		}
+	case kindSlice:
+		g.uses["arrayElements"] = true
+		elems, i, e := g.temp(), g.temp(), g.temp()
+		g.printf("%s, ok := arrayElements(%s)", elems, src)
+		g.printf("if !ok {")
+		g.printf("%s", onFail("array", src))
+		g.printf("}")
+		g.printf("%s = make(%s, len(%s))", dst, g.goType(t), elems)
+		g.printf("for %s, %s := range %s {", i, e, elems)
+		g.emitFrom(t.elem, e, dst+"["+i+"]", onFail)
+		g.printf("}")
+	case kindMap:
+		g.uses["mapElements"] = true
+		elems, k, e := g.temp(), g.temp(), g.temp()
+		g.printf("%s, ok := mapElements(%s)", elems, src)
+		g.printf("if !ok {")
+		g.printf("%s", onFail("map", src))
+		g.printf("}")
+		g.printf("%s = make(%s, len(%s))", dst, g.goType(t), elems)
+		g.printf("for %s, %s := range %s {", k, e, elems)
+		g.emitFrom(t.elem, e, dst+"["+k+"]", onFail)
+		g.printf("}")
+	default:
+		conv := scalarConversions[t.kind]
+		tmp := g.temp()
+		g.printf("%s, ok := objects.%s(%s)", tmp, conv.fn, src)
+		g.printf("if !ok {")
+		g.printf("%s", onFail(conv.expected, src))
+		g.printf("}")
+		g.printf("%s = %s", dst, g.convert(t, conv.base, tmp))
+	}
+}
+
+// emitTo writes the statements that convert the Go value src of the type t
+// to an object, and, assign it to dst.
+func (g *generator) emitTo(t *bindType, src, dst string) {
+	switch t.kind {
+	case kindBool:
+		g.printf("if %s {", src)
+		g.printf("%s = objects.TrueValue", dst)
+		g.printf("} else {")
+		g.printf("%s = objects.FalseValue", dst)
+		g.printf("}")
+	case kindStruct:
+		tmp := g.temp()
+		g.printf("%s := %s", tmp, src)
+		g.printf("%s = &%s{Value: &%s}", dst, t.wrapper, tmp)
+	case kindStructPtr:
+		g.printf("if %s == nil {", src)
+		g.printf("%s = objects.UndefinedValue", dst)
+		g.printf("} else {")
+		g.printf("%s = &%s{Value: %s}", dst, t.wrapper, src)
+		g.printf("}")
+	case kindSlice:
+		arr, i, e := g.temp(), g.temp(), g.temp()
+		g.printf("%s := make([]objects.Object, len(%s))", arr, src)
+		g.printf("for %s, %s := range %s {", i, e, src)
+		g.emitTo(t.elem, e, arr+"["+i+"]")
+		g.printf("}")
+		g.printf("%s = &objects.Array{Value: %s}", dst, arr)
+	case kindMap:
+		m, k, e := g.temp(), g.temp(), g.temp()
+		g.printf("%s := make(map[string]objects.Object, len(%s))", m, src)
+		g.printf("for %s, %s := range %s {", k, e, src)
+		g.emitTo(t.elem, e, m+"["+k+"]")
+		g.printf("}")
+		g.printf("%s = &objects.Map{Value: %s}", dst, m)
+	default:
+		conv := scalarConversions[t.kind]
+		value := src
+		if t.goName != conv.base {
+			value = conv.base + "(" + src + ")"
+		}
+		g.printf("%s = &%s{Value: %s}", dst, conv.object, value)
+	}
+}
+
+// convert returns the expression that converts the value of the base type
+// to the type t.
+func (g *generator) convert(t *bindType, base, expr string) string {
+	if name := g.goType(t); name != base {
+		return name + "(" + expr + ")"
+	}
+
+	return expr
+}
+
+// constType returns the type of the constant: the declared type, or, the
+// default type of the untyped constant expression.
+func (g *generator) constType(c *constDecl) ast.Expr {
+	if c.typ != nil {
+		return c.typ
+	}
+
+	if c.resolving || c.value == nil {
+		return nil
+	}
+	c.resolving = true
+	defer func() { c.resolving = false }()
+
+	return g.untypedType(c.value)
+}
+
+// untypedDefaults are the ranks of the default types of the untyped numeric
+// constants: the operation of the constants of different kinds uses the
+// type of the higher rank.
+var untypedDefaults = map[string]int{
+	"int":     1,
+	"rune":    2,
+	"float64": 3,
+}
+
+func (g *generator) untypedType(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT:
+			return ast.NewIdent("int")
+		case token.FLOAT:
+			return ast.NewIdent("float64")
+		case token.CHAR:
+			return ast.NewIdent("rune")
+		case token.STRING:
+			return ast.NewIdent("string")
+		}
+	case *ast.Ident:
+		switch e.Name {
+		case "true", "false":
+			return ast.NewIdent("bool")
+		case "iota":
+			return ast.NewIdent("int")
+		}
+
+		if c, ok := g.constsByName[e.Name]; ok {
+			return g.constType(c)
+		}
+	case *ast.ParenExpr:
+		return g.untypedType(e.X)
+	case *ast.UnaryExpr:
+		if e.Op == token.NOT {
+			return ast.NewIdent("bool")
+		}
+		return g.untypedType(e.X)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ, token.LAND, token.LOR:
+			return ast.NewIdent("bool")
+		case token.SHL, token.SHR:
+			return g.untypedType(e.X)
+		}
+
+		x, y := g.untypedType(e.X), g.untypedType(e.Y)
+		if x == nil || y == nil {
+			return nil
+		}
+
+		// a typed operand determines the type of the operation
+		xRank, yRank := untypedRank(x), untypedRank(y)
+		switch {
+		case xRank == 0:
+			return x
+		case yRank == 0:
+			return y
+		case yRank > xRank:
+			return y
+		}
+		return x
+	case *ast.CallExpr:
+		switch fn := e.Fun.(type) {
+		case *ast.Ident:
+			if fn.Name == "len" {
+				return ast.NewIdent("int")
+			}
+			if _, ok := basicKinds[fn.Name]; ok {
+				return fn
+			}
+			if _, ok := g.named[fn.Name]; ok {
+				return fn
+			}
+		case *ast.SelectorExpr:
+			return fn
+		}
+	}
+
+	return nil
+}
+
+// untypedRank returns the rank of the default type, or, 0 if the type is
+// not a default type of the untyped numeric constants.
+func untypedRank(typ ast.Expr) int {
+	if id, ok := typ.(*ast.Ident); ok {
+		return untypedDefaults[id.Name]
+	}
+
+	return 0
+}
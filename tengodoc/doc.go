@@ -0,0 +1,245 @@
+// Package tengodoc extracts the documentation of the Tengo source files: the
+// doc comments of the module and its members, and, the signatures of the
+// functions. The documentation can be rendered as Markdown or HTML.
+//
+// A doc comment is the group of the line comments (or a block comment) that
+// ends on the line right before the documented statement or map element. The
+// module doc comment is the comment at the beginning of the file that is
+// separated from the first statement by a blank line, or, the doc comment of
+// the export statement.
+//
+// The members of a module are the elements of the exported map literal. The
+// elements that refer to the top-level variables (e.g. "export {add: add}")
+// are documented by the doc comments and the function signatures of the
+// variables unless the elements have their own doc comments. A file without
+// an export statement (e.g. a script) is documented by its top-level function
+// definitions.
+package tengodoc
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/scanner"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+)
+
+// Module represents the documentation of a Tengo source file.
+type Module struct {
+	Name    string // file name without the directory and the extension
+	Doc     string
+	Members []*Member
+}
+
+// Member represents the documentation of a module member.
+type Member struct {
+	Name string
+	Doc  string
+
+	// Signature is the parameter list of the function (e.g. "(a, b)"), or,
+	// empty if the member is not a function literal.
+	Signature string
+}
+
+// IsFunc returns true if the member is a function.
+func (m *Member) IsFunc() bool {
+	return m.Signature != ""
+}
+
+type comment struct {
+	endLine int
+	text    string
+	isLine  bool // line comment ("//")
+}
+
+type extractor struct {
+	file     *source.File
+	comments []*comment
+}
+
+// Parse parses the Tengo source file and extracts its documentation. It
+// returns an error if the source cannot be parsed.
+func Parse(filename string, src []byte) (*Module, error) {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile(filename, -1, len(src))
+
+	parsed, err := parser.ParseFile(file, src, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &extractor{
+		file:     file,
+		comments: scanComments(file, src),
+	}
+
+	return e.module(filename, parsed), nil
+}
+
+func (e *extractor) module(filename string, f *ast.File) *Module {
+	m := &Module{
+		Name: strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)),
+	}
+
+	// top-level definitions
+	defs := make(map[string]*Member)
+	var funcs []*Member
+	var export *ast.ExportStmt
+	for _, stmt := range f.Stmts {
+		switch stmt := stmt.(type) {
+		case *ast.AssignStmt:
+			if stmt.Token != token.Define || len(stmt.LHS) != 1 || len(stmt.RHS) != 1 {
+				continue
+			}
+
+			ident, ok := stmt.LHS[0].(*ast.Ident)
+			if !ok {
+				continue
+			}
+
+			member := &Member{
+				Name:      ident.Name,
+				Doc:       e.docComment(stmt),
+				Signature: signature(stmt.RHS[0]),
+			}
+			defs[ident.Name] = member
+			if member.IsFunc() {
+				funcs = append(funcs, member)
+			}
+		case *ast.ExportStmt:
+			export = stmt
+		}
+	}
+
+	// module doc comment
+	if len(e.comments) > 0 {
+		first := e.comments[0]
+		if len(f.Stmts) == 0 || first.endLine+1 < e.line(f.Stmts[0].Pos()) {
+			m.Doc = first.text
+		}
+	}
+
+	if export == nil {
+		m.Members = funcs
+		return m
+	}
+
+	if m.Doc == "" {
+		m.Doc = e.docComment(export)
+	}
+
+	switch result := export.Result.(type) {
+	case *ast.MapLit:
+		for _, elt := range result.Elements {
+			member := &Member{
+				Name:      elt.Key,
+				Doc:       e.docComment(elt),
+				Signature: signature(elt.Value),
+			}
+
+			if ident, ok := elt.Value.(*ast.Ident); ok && defs[ident.Name] != nil {
+				def := defs[ident.Name]
+				if member.Doc == "" {
+					member.Doc = def.Doc
+				}
+				member.Signature = def.Signature
+			}
+
+			m.Members = append(m.Members, member)
+		}
+	case *ast.FuncLit:
+		// the module is a function
+		m.Members = append(m.Members, &Member{
+			Name:      m.Name,
+			Signature: signature(result),
+		})
+	}
+
+	return m
+}
+
+// docComment returns the text of the comment that ends on the line before
+// the node.
+func (e *extractor) docComment(node ast.Node) string {
+	line := e.line(node.Pos())
+	for _, c := range e.comments {
+		if c.endLine == line-1 {
+			return c.text
+		}
+	}
+
+	return ""
+}
+
+func (e *extractor) line(pos source.Pos) int {
+	return e.file.Position(pos).Line
+}
+
+// signature returns the parameter list of the function literal.
+func signature(expr ast.Expr) string {
+	fn, ok := expr.(*ast.FuncLit)
+	if !ok {
+		return ""
+	}
+
+	return fn.Type.Params.String()
+}
+
+// scanComments returns the comments in the source order. The consecutive
+// line comments, each on its own line, are grouped into a single comment.
+func scanComments(file *source.File, src []byte) (comments []*comment) {
+	s := scanner.NewScanner(file, src, nil, scanner.ScanComments|scanner.DontInsertSemis)
+
+	var prevLine int // line of the last token that is not a comment
+	for {
+		tok, lit, pos := s.Scan()
+		if tok == token.EOF {
+			return
+		}
+
+		line := file.Position(pos).Line
+		if tok != token.Comment {
+			prevLine = line
+			continue
+		}
+
+		if line == prevLine {
+			// trailing comment of a statement
+			continue
+		}
+
+		isLine := strings.HasPrefix(lit, "//")
+		text := commentText(lit)
+
+		if n := len(comments); n > 0 && isLine && comments[n-1].isLine &&
+			comments[n-1].endLine == line-1 {
+			comments[n-1].endLine = line
+			comments[n-1].text += "\n" + text
+			continue
+		}
+
+		comments = append(comments, &comment{
+			endLine: line + strings.Count(lit, "\n"),
+			text:    text,
+			isLine:  isLine,
+		})
+	}
+}
+
+// commentText returns the text of the comment without the comment markers
+// and the leading space.
+func commentText(lit string) string {
+	if strings.HasPrefix(lit, "//") {
+		return strings.TrimRight(strings.TrimPrefix(strings.TrimPrefix(lit, "//"), " "), "\r")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(lit, "/*"), "*/"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "*"))
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
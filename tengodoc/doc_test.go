@@ -0,0 +1,100 @@
+package tengodoc_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/tengodoc"
+)
+
+func TestParse(t *testing.T) {
+	m, err := tengodoc.Parse("lib/mathx.tengo", []byte(`// Package mathx provides
+// extra math functions.
+
+// sq returns the square of x.
+sq := func(x) { return x * x }
+
+/*
+ * cube returns the cube of x.
+ */
+cube := func(x) {
+	return x * x * x // not a doc comment
+}
+
+export {
+	sq: sq,
+	// cube overrides the doc comment
+	cube: cube,
+	// pi is the ratio.
+	pi: 3.14,
+	// sum adds the numbers.
+	//
+	// It returns 0 if there are no numbers.
+	sum: func(a, b, c) {}
+}
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "mathx", m.Name)
+	assert.Equal(t, "Package mathx provides\nextra math functions.", m.Doc)
+	if !assert.Equal(t, 4, len(m.Members)) {
+		return
+	}
+	expectMember(t, m.Members[0], "sq", "(x)", "sq returns the square of x.")
+	expectMember(t, m.Members[1], "cube", "(x)", "cube overrides the doc comment")
+	expectMember(t, m.Members[2], "pi", "", "pi is the ratio.")
+	expectMember(t, m.Members[3], "sum", "(a, b, c)", "sum adds the numbers.\n\nIt returns 0 if there are no numbers.")
+
+	assert.Equal(t, "# mathx\n\nPackage mathx provides\nextra math functions.\n\n"+
+		"## sq\n\n```golang\nsq(x)\n```\n\nsq returns the square of x.\n\n"+
+		"## cube\n\n```golang\ncube(x)\n```\n\ncube overrides the doc comment\n\n"+
+		"## pi\n\npi is the ratio.\n\n"+
+		"## sum\n\n```golang\nsum(a, b, c)\n```\n\nsum adds the numbers.\n\nIt returns 0 if there are no numbers.\n",
+		string(m.Markdown()))
+
+	// script without export statement
+	m, err = tengodoc.Parse("script.tengo", []byte(`// f does <nothing>.
+f := func() {}
+a := 1
+
+// not a doc comment
+
+g := func(x, y) {}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "", m.Doc)
+	if !assert.Equal(t, 2, len(m.Members)) {
+		return
+	}
+	expectMember(t, m.Members[0], "f", "()", "f does <nothing>.")
+	expectMember(t, m.Members[1], "g", "(x, y)", "")
+	assert.Equal(t, `<h1 id="script">script</h1>
+<h2 id="script.f">f</h2>
+<pre><code>f()</code></pre>
+<p>f does &lt;nothing&gt;.</p>
+<h2 id="script.g">g</h2>
+<pre><code>g(x, y)</code></pre>
+`, string(m.HTML()))
+
+	// module exporting a function
+	m, err = tengodoc.Parse("fn.tengo", []byte("// fn is a function.\nexport func(a) {}"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "fn is a function.", m.Doc)
+	if assert.Equal(t, 1, len(m.Members)) {
+		expectMember(t, m.Members[0], "fn", "(a)", "")
+	}
+
+	_, err = tengodoc.Parse("bad.tengo", []byte("a := "))
+	assert.Error(t, err)
+}
+
+func expectMember(t *testing.T, m *tengodoc.Member, name, signature, doc string) {
+	assert.Equal(t, name, m.Name)
+	assert.Equal(t, signature, m.Signature)
+	assert.Equal(t, doc, m.Doc)
+}
@@ -0,0 +1,63 @@
+package tengodoc
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Markdown renders the documentation in Markdown: the module name as the
+// heading, the module doc comment, and, a section for each member.
+func (m *Module) Markdown() []byte {
+	var buf bytes.Buffer
+
+	_, _ = fmt.Fprintf(&buf, "# %s\n", m.Name)
+	if m.Doc != "" {
+		_, _ = fmt.Fprintf(&buf, "\n%s\n", m.Doc)
+	}
+
+	for _, member := range m.Members {
+		_, _ = fmt.Fprintf(&buf, "\n## %s\n", member.Name)
+		if member.IsFunc() {
+			_, _ = fmt.Fprintf(&buf, "\n```golang\n%s%s\n```\n", member.Name, member.Signature)
+		}
+		if member.Doc != "" {
+			_, _ = fmt.Fprintf(&buf, "\n%s\n", member.Doc)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// HTML renders the documentation as an HTML fragment with the same structure
+// as Markdown. The blank lines in the doc comments separate the paragraphs.
+func (m *Module) HTML() []byte {
+	var buf bytes.Buffer
+
+	_, _ = fmt.Fprintf(&buf, "<h1 id=\"%s\">%s</h1>\n", html.EscapeString(m.Name), html.EscapeString(m.Name))
+	writeHTMLDoc(&buf, m.Doc)
+
+	for _, member := range m.Members {
+		id := html.EscapeString(m.Name + "." + member.Name)
+		_, _ = fmt.Fprintf(&buf, "<h2 id=\"%s\">%s</h2>\n", id, html.EscapeString(member.Name))
+		if member.IsFunc() {
+			_, _ = fmt.Fprintf(&buf, "<pre><code>%s</code></pre>\n", html.EscapeString(member.Name+member.Signature))
+		}
+		writeHTMLDoc(&buf, member.Doc)
+	}
+
+	return buf.Bytes()
+}
+
+func writeHTMLDoc(buf *bytes.Buffer, doc string) {
+	if doc == "" {
+		return
+	}
+
+	for _, p := range strings.Split(doc, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			_, _ = fmt.Fprintf(buf, "<p>%s</p>\n", html.EscapeString(p))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package tengofmt
+
+import (
+	"bytes"
+
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/scanner"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+)
+
+// Format parses the Tengo source code and returns it printed in the
+// canonical style:
+//
+//   - statements and block contents are indented with tabs
+//   - binary operators and assignments are surrounded by single spaces
+//   - list elements are separated by ", " on a single line, or put one per
+//     line with a comma after each element but the last one (the grammar
+//     does not allow a trailing comma before the closing bracket) if the
+//     first element was on a new line in the source
+//   - consecutive blank lines are collapsed into one
+//
+// Comments and the interpreter line ("#!...") are preserved. It returns an
+// error if the source cannot be parsed.
+func Format(src []byte) ([]byte, error) {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile("", -1, len(src))
+
+	parsed, err := parser.ParseFile(file, src, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &printer{
+		file:     file,
+		comments: scanComments(file, src),
+	}
+	p.printFile(parsed)
+
+	if bytes.HasPrefix(src, []byte("#!")) {
+		line := src
+		if n := bytes.IndexByte(src, '\n'); n >= 0 {
+			line = src[:n]
+		}
+		out := append([]byte{}, bytes.TrimRight(line, "\r")...)
+		out = append(out, '\n')
+
+		return append(out, p.buf.Bytes()...), nil
+	}
+
+	return p.buf.Bytes(), nil
+}
+
+func scanComments(file *source.File, src []byte) (comments []*comment) {
+	s := scanner.NewScanner(file, src, nil, scanner.ScanComments|scanner.DontInsertSemis)
+	for {
+		tok, lit, pos := s.Scan()
+		if tok == token.EOF {
+			return
+		}
+
+		if tok == token.Comment {
+			comments = append(comments, &comment{pos: pos, text: lit})
+		}
+	}
+}
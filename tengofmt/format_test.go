@@ -0,0 +1,131 @@
+package tengofmt_test
+
+import (
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/tengofmt"
+)
+
+func TestFormat(t *testing.T) {
+	expect(t, ``, ``)
+	expect(t, `a:=1`, "a := 1\n")
+	expect(t, `a:=1;b=a+2*-c`, "a := 1\nb = a + 2 * -c\n")
+	expect(t, `a, b = b, a`, "a, b = b, a\n")
+	expect(t, `x := - -1`, "x := - -1\n")
+	expect(t, `x := !(a||b) ? "y" : undefined`, "x := !(a || b) ? \"y\" : undefined\n")
+	expect(t, `x := s[1:len(s)] + s[:2] + s[a:] + s[:]`, "x := s[1:len(s)] + s[:2] + s[a:] + s[:]\n")
+	expect(t, `m.a.b[0]++`, "m.a.b[0]++\n")
+	expect(t, `os := import( "os" )`, "os := import(\"os\")\n")
+	expect(t, `x := immutable( [1,2 ,3] )`, "x := immutable([1, 2, 3])\n")
+	expect(t, `x := error( "e" )`, "x := error(\"e\")\n")
+	expect(t, `export {a:1,b:"x"}`, "export {a: 1, b: \"x\"}\n")
+
+	// interpreter line
+	expect(t, "#!/usr/bin/env tengo\na:=1", "#!/usr/bin/env tengo\na := 1\n")
+	expect(t, "#!/usr/bin/env tengo", "#!/usr/bin/env tengo\n")
+
+	// blank lines
+	expect(t, "a := 1\n\n\n\nb := 2\nc := 3", "a := 1\n\nb := 2\nc := 3\n")
+	expect(t, "\n\na := 1\n\n", "a := 1\n")
+
+	// blocks
+	expect(t, `if a { b() } else if c { d() } else { e() }`, `if a {
+	b()
+} else if c {
+	d()
+} else {
+	e()
+}
+`)
+	expect(t, `if x := f(); x > 0 {}`, "if x := f(); x > 0 {}\n")
+	expect(t, `for { break }`, "for {\n\tbreak\n}\n")
+	expect(t, `for a < 10 { a++ }`, "for a < 10 {\n\ta++\n}\n")
+	expect(t, `for i:=0;i<10;i++ { continue }`, "for i := 0; i < 10; i++ {\n\tcontinue\n}\n")
+	expect(t, `for v in x {}`, "for v in x {}\n")
+	expect(t, `for k,v in x {}`, "for k, v in x {}\n")
+	expect(t, `for _,v in x {}`, "for _, v in x {}\n")
+
+	// function literals
+	expect(t, `f := func(a,b){ return a+b }`, "f := func(a, b) { return a + b }\n")
+	expect(t, `f := func() { a := 1; return a }`, "f := func() {\n\ta := 1\n\treturn a\n}\n")
+	expect(t, `f := func() {
+return 1 }`, "f := func() {\n\treturn 1\n}\n")
+	expect(t, `f := func() { if a { return 1 } }`, "f := func() {\n\tif a {\n\t\treturn 1\n\t}\n}\n")
+
+	// multi-line lists
+	expect(t, `x := [
+1,2,
+   3
+]`, "x := [\n\t1,\n\t2,\n\t3\n]\n")
+	expect(t, `x := {
+  a: 1,
+
+
+  b: [
+    2]}`, "x := {\n\ta: 1,\n\n\tb: [\n\t\t2\n\t]\n}\n")
+	expect(t, `f(
+a, b)`, "f(\n\ta,\n\tb\n)\n")
+	expect(t, `f(func() {
+a()
+}, b)`, "f(func() {\n\ta()\n}, b)\n")
+
+	// comments
+	expect(t, `// header
+
+a := 1 // one
+/* two */ b := 2
+c := 3 /* three */ + 3`, `// header
+
+a := 1 // one
+/* two */
+b := 2
+c := 3 + 3 /* three */
+`)
+	expect(t, `f := func() { // f
+// inside
+a()
+
+// end
+}
+// last`, `f := func() { // f
+	// inside
+	a()
+
+	// end
+}
+// last
+`)
+	expect(t, `x := [
+// first
+1, // one
+2
+// end
+]`, "x := [\n\t// first\n\t1, // one\n\t2\n\t// end\n]\n")
+
+	expectError(t, `a := `)
+	expectError(t, `x := [1, 2,]`)
+}
+
+func expect(t *testing.T, input, expected string) {
+	actual, err := tengofmt.Format([]byte(input))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if !assert.Equal(t, expected, string(actual)) {
+		return
+	}
+
+	// formatting must be stable
+	again, err := tengofmt.Format(actual)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, expected, string(again))
+}
+
+func expectError(t *testing.T, input string) {
+	_, err := tengofmt.Format([]byte(input))
+	assert.Error(t, err)
+}
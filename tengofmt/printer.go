@@ -0,0 +1,419 @@
+package tengofmt
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+)
+
+type comment struct {
+	pos  source.Pos
+	text string
+}
+
+type printer struct {
+	file        *source.File
+	buf         bytes.Buffer
+	indent      int
+	lineStarted bool
+	comments    []*comment // comments not printed yet, in source order
+}
+
+func (p *printer) printFile(f *ast.File) {
+	p.printStmtList(f.Stmts, f.End())
+
+	if p.buf.Len() > 0 {
+		p.buf.WriteByte('\n')
+	}
+}
+
+func (p *printer) print(s string) {
+	if s == "" {
+		return
+	}
+
+	if !p.lineStarted {
+		p.buf.WriteString(strings.Repeat("\t", p.indent))
+		p.lineStarted = true
+	}
+
+	p.buf.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.buf.WriteByte('\n')
+	p.lineStarted = false
+}
+
+func (p *printer) line(pos source.Pos) int {
+	return p.file.Position(pos).Line
+}
+
+// lineBreak starts a new line for an item at the source line 'line'. It
+// keeps a single blank line if there was one or more blank lines after the
+// previous item at the source line 'prevLine'. Zero 'prevLine' means there's
+// no previous item in the same list.
+func (p *printer) lineBreak(line, prevLine int) {
+	if p.buf.Len() == 0 {
+		return
+	}
+
+	p.newline()
+	if prevLine > 0 && line > prevLine+1 {
+		p.newline()
+	}
+}
+
+// printComments prints the comments located before 'before' on their own
+// lines, and returns the source line where the last comment ends.
+func (p *printer) printComments(before source.Pos, prevLine int) int {
+	for len(p.comments) > 0 && p.comments[0].pos < before {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.lineBreak(p.line(c.pos), prevLine)
+		p.print(c.text)
+		prevLine = p.line(c.pos + source.Pos(len(c.text)-1))
+	}
+
+	return prevLine
+}
+
+// printTrailingComments appends the comments located inside the node ending
+// at 'end', or on the same source line 'endLine' after the node.
+func (p *printer) printTrailingComments(end source.Pos, endLine int) {
+	for len(p.comments) > 0 {
+		c := p.comments[0]
+		if c.pos >= end && p.line(c.pos) != endLine {
+			return
+		}
+		p.comments = p.comments[1:]
+
+		p.print(" " + c.text)
+	}
+}
+
+func (p *printer) hasComments(from, to source.Pos) bool {
+	for _, c := range p.comments {
+		if c.pos >= to {
+			return false
+		}
+		if c.pos > from {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *printer) printStmtList(stmts []ast.Stmt, end source.Pos) {
+	prevLine := 0
+	for _, s := range stmts {
+		if _, isEmpty := s.(*ast.EmptyStmt); isEmpty {
+			continue
+		}
+
+		prevLine = p.printComments(s.Pos(), prevLine)
+		p.lineBreak(p.line(s.Pos()), prevLine)
+		p.printStmt(s)
+
+		prevLine = p.line(s.End() - 1)
+		p.printTrailingComments(s.End(), prevLine)
+	}
+
+	p.printComments(end, prevLine)
+}
+
+func (p *printer) printStmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case *ast.ExprStmt:
+		p.printExpr(s.Expr)
+	case *ast.AssignStmt:
+		p.printExprList(s.LHS)
+		p.print(" " + s.Token.String() + " ")
+		p.printExprList(s.RHS)
+	case *ast.IncDecStmt:
+		p.printExpr(s.Expr)
+		p.print(s.Token.String())
+	case *ast.ReturnStmt:
+		p.print("return")
+		if s.Result != nil {
+			p.print(" ")
+			p.printExpr(s.Result)
+		}
+	case *ast.ExportStmt:
+		p.print("export ")
+		p.printExpr(s.Result)
+	case *ast.BranchStmt:
+		p.print(s.Token.String())
+		if s.Label != nil {
+			p.print(" " + s.Label.Name)
+		}
+	case *ast.IfStmt:
+		p.printIfStmt(s)
+	case *ast.ForStmt:
+		p.print("for ")
+		if s.Init != nil || s.Post != nil {
+			p.printStmt(s.Init)
+			p.print("; ")
+			p.printExpr(s.Cond)
+			p.print("; ")
+			p.printStmt(s.Post)
+			p.print(" ")
+		} else if s.Cond != nil {
+			p.printExpr(s.Cond)
+			p.print(" ")
+		}
+		p.printBlock(s.Body)
+	case *ast.ForInStmt:
+		p.print("for ")
+		if s.Key.NamePos != s.Value.NamePos {
+			// 'for key, value in ...', not 'for value in ...'
+			p.print(s.Key.Name + ", ")
+		}
+		p.print(s.Value.Name + " in ")
+		p.printExpr(s.Iterable)
+		p.print(" ")
+		p.printBlock(s.Body)
+	case *ast.BlockStmt:
+		p.printBlock(s)
+	case *ast.EmptyStmt, nil:
+		// nothing to print
+	default:
+		p.print(s.String())
+	}
+}
+
+func (p *printer) printIfStmt(s *ast.IfStmt) {
+	p.print("if ")
+	if s.Init != nil {
+		p.printStmt(s.Init)
+		p.print("; ")
+	}
+	p.printExpr(s.Cond)
+	p.print(" ")
+	p.printBlock(s.Body)
+
+	switch e := s.Else.(type) {
+	case *ast.IfStmt:
+		p.print(" else ")
+		p.printIfStmt(e)
+	case *ast.BlockStmt:
+		p.print(" else ")
+		p.printBlock(e)
+	}
+}
+
+func (p *printer) printBlock(b *ast.BlockStmt) {
+	p.print("{")
+
+	if numStmts(b.Stmts) == 0 && !p.hasComments(b.LBrace, b.RBrace) {
+		p.print("}")
+		return
+	}
+
+	p.indent++
+
+	// comment after the opening brace
+	if len(p.comments) > 0 {
+		if c := p.comments[0]; c.pos < b.RBrace && p.line(c.pos) == p.line(b.LBrace) {
+			p.comments = p.comments[1:]
+			p.print(" " + c.text)
+		}
+	}
+
+	p.printStmtList(b.Stmts, b.RBrace)
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+// printFuncBody prints the function body on a single line if it was on a
+// single line in the source and it contains a single simple statement.
+// Otherwise it's printed as a regular block.
+func (p *printer) printFuncBody(b *ast.BlockStmt) {
+	if p.line(b.LBrace) == p.line(b.RBrace) && numStmts(b.Stmts) == 1 && !p.hasComments(b.LBrace, b.RBrace) {
+		for _, s := range b.Stmts {
+			switch s.(type) {
+			case *ast.ExprStmt, *ast.AssignStmt, *ast.IncDecStmt, *ast.ReturnStmt, *ast.ExportStmt, *ast.BranchStmt:
+				sub := &printer{file: p.file, indent: p.indent, lineStarted: true}
+				sub.printStmt(s)
+				if !bytes.ContainsRune(sub.buf.Bytes(), '\n') {
+					p.print("{ " + sub.buf.String() + " }")
+					return
+				}
+			}
+		}
+	}
+
+	p.printBlock(b)
+}
+
+func (p *printer) printExprList(list []ast.Expr) {
+	for i, e := range list {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.printExpr(e)
+	}
+}
+
+// printList prints the list elements separated by commas. The elements are
+// printed one per line if the first element was not on the same line as the
+// opening bracket in the source.
+func (p *printer) printList(open, close source.Pos, elems []ast.Node, printElem func(ast.Node)) {
+	if len(elems) == 0 {
+		return
+	}
+
+	if p.line(elems[0].Pos()) == p.line(open) {
+		for i, e := range elems {
+			if i > 0 {
+				p.print(", ")
+			}
+			printElem(e)
+		}
+		return
+	}
+
+	p.indent++
+	prevLine := 0
+	for i, e := range elems {
+		prevLine = p.printComments(e.Pos(), prevLine)
+		p.lineBreak(p.line(e.Pos()), prevLine)
+		printElem(e)
+		if i < len(elems)-1 {
+			p.print(",")
+		}
+
+		prevLine = p.line(e.End() - 1)
+		p.printTrailingComments(e.End(), prevLine)
+	}
+	p.printComments(close, prevLine)
+	p.indent--
+	p.newline()
+}
+
+func (p *printer) printExpr(e ast.Expr) {
+	switch e := e.(type) {
+	case *ast.BinaryExpr:
+		p.printExpr(e.LHS)
+		p.print(" " + e.Token.String() + " ")
+		p.printExpr(e.RHS)
+	case *ast.UnaryExpr:
+		p.print(e.Token.String())
+		if x, ok := e.Expr.(*ast.UnaryExpr); ok && x.Token == e.Token && (x.Token == token.Add || x.Token == token.Sub) {
+			// '- -x' must not be printed as '--x'
+			p.print(" ")
+		}
+		p.printExpr(e.Expr)
+	case *ast.ParenExpr:
+		p.print("(")
+		p.printExpr(e.Expr)
+		p.print(")")
+	case *ast.CondExpr:
+		p.printExpr(e.Cond)
+		p.print(" ? ")
+		p.printExpr(e.True)
+		p.print(" : ")
+		p.printExpr(e.False)
+	case *ast.CallExpr:
+		p.printExpr(e.Func)
+		p.print("(")
+		p.printList(e.LParen, e.RParen, exprNodes(e.Args), func(n ast.Node) {
+			p.printExpr(n.(ast.Expr))
+		})
+		p.print(")")
+	case *ast.IndexExpr:
+		p.printExpr(e.Expr)
+		p.print("[")
+		p.printExpr(e.Index)
+		p.print("]")
+	case *ast.SliceExpr:
+		p.printExpr(e.Expr)
+		p.print("[")
+		if e.Low != nil {
+			p.printExpr(e.Low)
+		}
+		p.print(":")
+		if e.High != nil {
+			p.printExpr(e.High)
+		}
+		p.print("]")
+	case *ast.SelectorExpr:
+		p.printExpr(e.Expr)
+		p.print(".")
+		if sel, ok := e.Sel.(*ast.StringLit); ok {
+			p.print(sel.Value)
+		} else {
+			p.printExpr(e.Sel)
+		}
+	case *ast.ArrayLit:
+		p.print("[")
+		p.printList(e.LBrack, e.RBrack, exprNodes(e.Elements), func(n ast.Node) {
+			p.printExpr(n.(ast.Expr))
+		})
+		p.print("]")
+	case *ast.MapLit:
+		var elems []ast.Node
+		for _, m := range e.Elements {
+			elems = append(elems, m)
+		}
+
+		p.print("{")
+		p.printList(e.LBrace, e.RBrace, elems, func(n ast.Node) {
+			m := n.(*ast.MapElementLit)
+			p.print(m.Key + ": ")
+			p.printExpr(m.Value)
+		})
+		p.print("}")
+	case *ast.FuncLit:
+		p.print("func(")
+		for i, param := range e.Type.Params.List {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(param.Name)
+		}
+		p.print(") ")
+		p.printFuncBody(e.Body)
+	case *ast.ErrorExpr:
+		p.print("error(")
+		p.printExpr(e.Expr)
+		p.print(")")
+	case *ast.ImmutableExpr:
+		p.print("immutable(")
+		p.printExpr(e.Expr)
+		p.print(")")
+	case *ast.ImportExpr:
+		p.print("import(" + strconv.Quote(e.ModuleName) + ")")
+	case *ast.UndefinedLit:
+		p.print("undefined")
+	case nil:
+		// nothing to print
+	default:
+		p.print(e.String())
+	}
+}
+
+func numStmts(stmts []ast.Stmt) (n int) {
+	for _, s := range stmts {
+		if _, isEmpty := s.(*ast.EmptyStmt); !isEmpty {
+			n++
+		}
+	}
+
+	return
+}
+
+func exprNodes(exprs []ast.Expr) (nodes []ast.Node) {
+	for _, e := range exprs {
+		nodes = append(nodes, e)
+	}
+
+	return
+}
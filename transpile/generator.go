@@ -0,0 +1,850 @@
+package transpile
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/source"
+	"github.com/d5/tengo/compiler/token"
+	"github.com/d5/tengo/objects"
+)
+
+// binaryOps are the names of the operator tokens in the generated code.
+var binaryOps = map[token.Token]string{
+	token.Add:       "token.Add",
+	token.Sub:       "token.Sub",
+	token.Mul:       "token.Mul",
+	token.Quo:       "token.Quo",
+	token.Rem:       "token.Rem",
+	token.And:       "token.And",
+	token.Or:        "token.Or",
+	token.Xor:       "token.Xor",
+	token.Shl:       "token.Shl",
+	token.Shr:       "token.Shr",
+	token.AndNot:    "token.AndNot",
+	token.Greater:   "token.Greater",
+	token.GreaterEq: "token.GreaterEq",
+}
+
+// assignOps are the binary operators of the compound assignments.
+var assignOps = map[token.Token]token.Token{
+	token.AddAssign:    token.Add,
+	token.SubAssign:    token.Sub,
+	token.MulAssign:    token.Mul,
+	token.QuoAssign:    token.Quo,
+	token.RemAssign:    token.Rem,
+	token.AndAssign:    token.And,
+	token.OrAssign:     token.Or,
+	token.XorAssign:    token.Xor,
+	token.ShlAssign:    token.Shl,
+	token.ShrAssign:    token.Shr,
+	token.AndNotAssign: token.AndNot,
+}
+
+type generator struct {
+	file     *source.File
+	funcs    map[string]*ast.FuncLit // top-level functions by their names
+	names    map[string]string       // top-level function names by their Go names
+	buf      bytes.Buffer
+	numTemps int
+	scopes   []map[string]bool // local variables of the current function
+	reads    map[string]bool   // variables read in the current function
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *generator) errorf(node ast.Node, format string, args ...interface{}) error {
+	return &Error{
+		Pos:     g.file.Set().Position(node.Pos()),
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// position returns the quoted source position of the node.
+func (g *generator) position(node ast.Node) string {
+	return strconv.Quote(g.file.Set().Position(node.Pos()).String())
+}
+
+func (g *generator) temp() string {
+	g.numTemps++
+
+	return fmt.Sprintf("_t%d", g.numTemps)
+}
+
+func (g *generator) checkError(node ast.Node) {
+	g.printf("if err != nil {")
+	g.printf("return nil, rtError(%s, err)", g.position(node))
+	g.printf("}")
+}
+
+func (g *generator) openScope() {
+	g.scopes = append(g.scopes, make(map[string]bool))
+}
+
+func (g *generator) closeScope() {
+	g.scopes = g.scopes[:len(g.scopes)-1]
+}
+
+func (g *generator) define(name string) {
+	g.scopes[len(g.scopes)-1][name] = true
+}
+
+func (g *generator) isLocal(name string) bool {
+	for _, scope := range g.scopes {
+		if scope[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *generator) function(name string, fn *ast.FuncLit) error {
+	g.numTemps = 0
+	g.reads = readVariables(fn.Body)
+	g.scopes = nil
+
+	g.openScope()
+	defer g.closeScope()
+
+	var params []string
+	for _, param := range fn.Type.Params.List {
+		g.define(param.Name)
+		params = append(params, g.localName(param.Name))
+	}
+
+	var signature string
+	if len(params) > 0 {
+		signature = strings.Join(params, ", ") + " objects.Object"
+	}
+
+	g.printf("// %s is transpiled from '%s' (%s).", exportedName(name), name,
+		g.file.Set().Position(fn.Pos()))
+	g.printf("func %s(%s) (objects.Object, error) {", exportedName(name), signature)
+
+	if err := g.stmts(fn.Body.Stmts); err != nil {
+		return err
+	}
+
+	if n := len(fn.Body.Stmts); n == 0 || !isReturn(fn.Body.Stmts[n-1]) {
+		g.printf("return objects.UndefinedValue, nil")
+	}
+
+	g.printf("}")
+
+	return nil
+}
+
+func isReturn(stmt ast.Stmt) bool {
+	_, ok := stmt.(*ast.ReturnStmt)
+
+	return ok
+}
+
+// readVariables returns the names of the variables that are read in the
+// function body. The variables that are only assigned are not used in Go.
+func readVariables(body *ast.BlockStmt) map[string]bool {
+	reads := make(map[string]bool)
+
+	var inspect func(node ast.Node) bool
+	inspect = func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Ident:
+			reads[node.Name] = true
+		case *ast.AssignStmt:
+			if len(node.LHS) == 1 && (node.Token == token.Define || node.Token == token.Assign) {
+				if _, ok := node.LHS[0].(*ast.Ident); ok {
+					for _, rhs := range node.RHS {
+						ast.Inspect(rhs, inspect)
+					}
+
+					return false
+				}
+			}
+		case *ast.ForInStmt:
+			ast.Inspect(node.Iterable, inspect)
+			ast.Inspect(node.Body, inspect)
+
+			return false
+		}
+
+		return true
+	}
+	ast.Inspect(body, inspect)
+
+	return reads
+}
+
+func (g *generator) stmts(stmts []ast.Stmt) error {
+	for _, stmt := range stmts {
+		if err := g.stmt(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) block(block *ast.BlockStmt) error {
+	g.openScope()
+	defer g.closeScope()
+
+	return g.stmts(block.Stmts)
+}
+
+func (g *generator) stmt(stmt ast.Stmt) error {
+	switch stmt := stmt.(type) {
+	case *ast.EmptyStmt:
+		// nothing to generate
+
+	case *ast.ExprStmt:
+		value, err := g.expr(stmt.Expr)
+		if err != nil {
+			return err
+		}
+
+		g.printf("_ = %s", value)
+
+	case *ast.AssignStmt:
+		return g.assign(stmt, stmt.LHS, stmt.RHS, stmt.Token)
+
+	case *ast.IncDecStmt:
+		op := token.AddAssign
+		if stmt.Token == token.Dec {
+			op = token.SubAssign
+		}
+
+		return g.assign(stmt, []ast.Expr{stmt.Expr}, []ast.Expr{&ast.IntLit{Value: 1, ValuePos: stmt.TokenPos}}, op)
+
+	case *ast.BlockStmt:
+		g.printf("{")
+		if err := g.block(stmt); err != nil {
+			return err
+		}
+		g.printf("}")
+
+	case *ast.IfStmt:
+		return g.ifStmt(stmt)
+
+	case *ast.ForStmt:
+		return g.forStmt(stmt)
+
+	case *ast.ForInStmt:
+		return g.forInStmt(stmt)
+
+	case *ast.ReturnStmt:
+		if stmt.Result == nil {
+			g.printf("return objects.UndefinedValue, nil")
+			break
+		}
+
+		value, err := g.expr(stmt.Result)
+		if err != nil {
+			return err
+		}
+
+		g.printf("return %s, nil", value)
+
+	case *ast.BranchStmt:
+		if stmt.Label != nil {
+			return g.errorf(stmt, "labels are not supported")
+		}
+
+		switch stmt.Token {
+		case token.Break:
+			g.printf("break")
+		case token.Continue:
+			g.printf("continue")
+		default:
+			return g.errorf(stmt, "unsupported statement: %s", stmt.Token.String())
+		}
+
+	case *ast.ExportStmt:
+		return g.errorf(stmt, "export is not supported")
+
+	default:
+		return g.errorf(stmt, "unsupported statement")
+	}
+
+	return nil
+}
+
+func (g *generator) assign(node ast.Node, lhs, rhs []ast.Expr, op token.Token) error {
+	if len(lhs) != 1 || len(rhs) != 1 {
+		return g.errorf(node, "tuple assignment not allowed")
+	}
+
+	switch target := lhs[0].(type) {
+	case *ast.Ident:
+		name := g.localName(target.Name)
+
+		if op == token.Define {
+			if g.isLocal(target.Name) {
+				return g.errorf(node, "'%s' redeclared in this block", target.Name)
+			}
+
+			value, err := g.expr(rhs[0])
+			if err != nil {
+				return err
+			}
+
+			g.define(target.Name)
+			g.printf("var %s objects.Object = %s", name, value)
+			if !g.reads[target.Name] {
+				g.printf("_ = %s", name)
+			}
+
+			return nil
+		}
+
+		if !g.isLocal(target.Name) {
+			return g.errorf(node, "unresolved reference '%s'", target.Name)
+		}
+
+		value, err := g.assignValue(node, name, rhs[0], op)
+		if err != nil {
+			return err
+		}
+
+		g.printf("%s = %s", name, value)
+
+	case *ast.IndexExpr, *ast.SelectorExpr:
+		if op == token.Define {
+			return g.errorf(node, "operator ':=' not allowed with selector")
+		}
+
+		var value string
+		if op == token.Assign {
+			var err error
+			if value, err = g.expr(rhs[0]); err != nil {
+				return err
+			}
+		}
+
+		container, index, err := g.indexTarget(target)
+		if err != nil {
+			return err
+		}
+
+		if op != token.Assign {
+			current := g.temp()
+			g.printf("%s, err := rtIndex(%s, %s)", current, container, index)
+			g.checkError(node)
+
+			if value, err = g.assignValue(node, current, rhs[0], op); err != nil {
+				return err
+			}
+		}
+
+		g.printf("if err := rtIndexSet(%s, %s, %s); err != nil {", container, index, value)
+		g.printf("return nil, rtError(%s, err)", g.position(node))
+		g.printf("}")
+
+	default:
+		return g.errorf(node, "invalid assignment target")
+	}
+
+	return nil
+}
+
+// assignValue returns the value to assign to the current value: the
+// right-hand side, or, the result of the compound assignment operator.
+func (g *generator) assignValue(node ast.Node, current string, rhs ast.Expr, op token.Token) (string, error) {
+	value, err := g.expr(rhs)
+	if err != nil {
+		return "", err
+	}
+
+	if op == token.Assign {
+		return value, nil
+	}
+
+	binaryOp, ok := assignOps[op]
+	if !ok {
+		return "", g.errorf(node, "invalid assignment operator: %s", op.String())
+	}
+
+	res := g.temp()
+	g.printf("%s, err := rtBinaryOp(%s, %s, %s)", res, binaryOps[binaryOp], current, value)
+	g.checkError(node)
+
+	return res, nil
+}
+
+// indexTarget returns the container and the index of the index or selector
+// expression being assigned.
+func (g *generator) indexTarget(target ast.Expr) (container, index string, err error) {
+	var containerExpr, indexExpr ast.Expr
+	switch target := target.(type) {
+	case *ast.IndexExpr:
+		containerExpr, indexExpr = target.Expr, target.Index
+	case *ast.SelectorExpr:
+		containerExpr, indexExpr = target.Expr, target.Sel
+	}
+
+	if container, err = g.expr(containerExpr); err != nil {
+		return
+	}
+
+	index, err = g.expr(indexExpr)
+
+	return
+}
+
+func (g *generator) ifStmt(stmt *ast.IfStmt) error {
+	g.openScope()
+	defer g.closeScope()
+
+	if stmt.Init != nil {
+		g.printf("{")
+		if err := g.stmt(stmt.Init); err != nil {
+			return err
+		}
+	}
+
+	cond, err := g.expr(stmt.Cond)
+	if err != nil {
+		return err
+	}
+
+	g.printf("if %s {", truthy(cond))
+	if err := g.block(stmt.Body); err != nil {
+		return err
+	}
+
+	if stmt.Else != nil {
+		g.printf("} else {")
+		if err := g.stmt(stmt.Else); err != nil {
+			return err
+		}
+	}
+	g.printf("}")
+
+	if stmt.Init != nil {
+		g.printf("}")
+	}
+
+	return nil
+}
+
+func (g *generator) forStmt(stmt *ast.ForStmt) error {
+	g.openScope()
+	defer g.closeScope()
+
+	g.printf("{")
+	if stmt.Init != nil {
+		if err := g.stmt(stmt.Init); err != nil {
+			return err
+		}
+	}
+
+	if stmt.Post == nil {
+		g.printf("for {")
+	} else {
+		// the post statement runs before the condition of all iterations
+		// but the first one, so it's not skipped by continue
+		first := g.temp()
+		g.printf("for %s := true; ; %s = false {", first, first)
+		g.printf("if !%s {", first)
+		if err := g.stmt(stmt.Post); err != nil {
+			return err
+		}
+		g.printf("}")
+	}
+
+	if stmt.Cond != nil {
+		cond, err := g.expr(stmt.Cond)
+		if err != nil {
+			return err
+		}
+
+		g.printf("if %s {", falsy(cond))
+		g.printf("break")
+		g.printf("}")
+	}
+
+	if err := g.block(stmt.Body); err != nil {
+		return err
+	}
+	g.printf("}")
+	g.printf("}")
+
+	return nil
+}
+
+func (g *generator) forInStmt(stmt *ast.ForInStmt) error {
+	g.openScope()
+	defer g.closeScope()
+
+	iterable, err := g.expr(stmt.Iterable)
+	if err != nil {
+		return err
+	}
+
+	iterator := g.temp()
+	g.printf("%s, err := rtIterate(%s)", iterator, iterable)
+	g.checkError(stmt)
+	g.printf("for %s.Next() {", iterator)
+
+	if stmt.Key.Name != "_" {
+		g.define(stmt.Key.Name)
+		g.printf("%s := %s.Key()", g.localName(stmt.Key.Name), iterator)
+		if !g.reads[stmt.Key.Name] {
+			g.printf("_ = %s", g.localName(stmt.Key.Name))
+		}
+	}
+
+	if stmt.Value.Name != "_" {
+		g.define(stmt.Value.Name)
+		g.printf("%s := %s.Value()", g.localName(stmt.Value.Name), iterator)
+		if !g.reads[stmt.Value.Name] {
+			g.printf("_ = %s", g.localName(stmt.Value.Name))
+		}
+	}
+
+	if err := g.block(stmt.Body); err != nil {
+		return err
+	}
+	g.printf("}")
+
+	return nil
+}
+
+// expr generates the statements evaluating the expression, and, returns the
+// Go expression of its value.
+func (g *generator) expr(expr ast.Expr) (string, error) {
+	switch expr := expr.(type) {
+	case *ast.IntLit:
+		return fmt.Sprintf("&objects.Int{Value: %d}", expr.Value), nil
+
+	case *ast.FloatLit:
+		return fmt.Sprintf("&objects.Float{Value: %s}", strconv.FormatFloat(expr.Value, 'g', -1, 64)), nil
+
+	case *ast.StringLit:
+		return fmt.Sprintf("&objects.String{Value: %s}", strconv.Quote(expr.Value)), nil
+
+	case *ast.CharLit:
+		return fmt.Sprintf("&objects.Char{Value: %s}", strconv.QuoteRune(expr.Value)), nil
+
+	case *ast.BoolLit:
+		if expr.Value {
+			return "objects.TrueValue", nil
+		}
+
+		return "objects.FalseValue", nil
+
+	case *ast.UndefinedLit:
+		return "objects.UndefinedValue", nil
+
+	case *ast.Ident:
+		if g.isLocal(expr.Name) {
+			return g.localName(expr.Name), nil
+		}
+
+		if _, isFunc := g.funcs[expr.Name]; isFunc || isBuiltin(expr.Name) {
+			return "", g.errorf(expr, "function values are not supported: '%s'", expr.Name)
+		}
+
+		return "", g.errorf(expr, "unresolved reference '%s'", expr.Name)
+
+	case *ast.ParenExpr:
+		return g.expr(expr.Expr)
+
+	case *ast.ArrayLit:
+		var elements []string
+		for _, elem := range expr.Elements {
+			value, err := g.expr(elem)
+			if err != nil {
+				return "", err
+			}
+
+			elements = append(elements, value)
+		}
+
+		return fmt.Sprintf("&objects.Array{Value: []objects.Object{%s}}", strings.Join(elements, ", ")), nil
+
+	case *ast.MapLit:
+		// the later elements overwrite the earlier ones with the same key
+		m := g.temp()
+		g.printf("%s := make(map[string]objects.Object)", m)
+		for _, elem := range expr.Elements {
+			value, err := g.expr(elem.Value)
+			if err != nil {
+				return "", err
+			}
+
+			g.printf("%s[%s] = %s", m, strconv.Quote(elem.Key), value)
+		}
+
+		return fmt.Sprintf("&objects.Map{Value: %s}", m), nil
+
+	case *ast.ErrorExpr:
+		value, err := g.expr(expr.Expr)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("&objects.Error{Value: %s}", value), nil
+
+	case *ast.UnaryExpr:
+		return g.unaryExpr(expr)
+
+	case *ast.BinaryExpr:
+		return g.binaryExpr(expr)
+
+	case *ast.CondExpr:
+		res := g.temp()
+		g.printf("var %s objects.Object", res)
+
+		cond, err := g.expr(expr.Cond)
+		if err != nil {
+			return "", err
+		}
+
+		g.printf("if %s {", truthy(cond))
+		if err := g.assignTemp(res, expr.True); err != nil {
+			return "", err
+		}
+		g.printf("} else {")
+		if err := g.assignTemp(res, expr.False); err != nil {
+			return "", err
+		}
+		g.printf("}")
+
+		return res, nil
+
+	case *ast.IndexExpr:
+		return g.indexExpr(expr, expr.Expr, expr.Index)
+
+	case *ast.SelectorExpr:
+		return g.indexExpr(expr, expr.Expr, expr.Sel)
+
+	case *ast.CallExpr:
+		return g.callExpr(expr)
+
+	case *ast.FuncLit:
+		return "", g.errorf(expr, "function literals are not supported")
+
+	case *ast.ImportExpr:
+		return "", g.errorf(expr, "imports are not supported")
+
+	case *ast.SliceExpr:
+		return "", g.errorf(expr, "slice expressions are not supported")
+
+	case *ast.ImmutableExpr:
+		return "", g.errorf(expr, "immutable expressions are not supported")
+
+	default:
+		return "", g.errorf(expr, "unsupported expression")
+	}
+}
+
+// assignTemp assigns the value of the expression to the temporary variable
+// declared already.
+func (g *generator) assignTemp(temp string, expr ast.Expr) error {
+	value, err := g.expr(expr)
+	if err != nil {
+		return err
+	}
+
+	g.printf("%s = %s", temp, value)
+
+	return nil
+}
+
+func (g *generator) unaryExpr(expr *ast.UnaryExpr) (string, error) {
+	operand, err := g.expr(expr.Expr)
+	if err != nil {
+		return "", err
+	}
+
+	switch expr.Token {
+	case token.Add:
+		return operand, nil
+	case token.Not:
+		return fmt.Sprintf("rtBool(%s.IsFalsy())", receiver(operand)), nil
+	case token.Sub, token.Xor:
+		res := g.temp()
+		g.printf("%s, err := rtUnaryOp(%s, %s)", res, binaryOps[expr.Token], operand)
+		g.checkError(expr)
+
+		return res, nil
+	}
+
+	return "", g.errorf(expr, "invalid unary operator: %s", expr.Token.String())
+}
+
+func (g *generator) binaryExpr(expr *ast.BinaryExpr) (string, error) {
+	switch expr.Token {
+	case token.LAnd, token.LOr:
+		res := g.temp()
+		g.printf("var %s objects.Object", res)
+
+		left, err := g.expr(expr.LHS)
+		if err != nil {
+			return "", err
+		}
+
+		// the left-hand side is the result if it decides the result
+		if expr.Token == token.LAnd {
+			g.printf("if %s {", falsy(left))
+		} else {
+			g.printf("if %s {", truthy(left))
+		}
+		g.printf("%s = %s", res, left)
+		g.printf("} else {")
+		if err := g.assignTemp(res, expr.RHS); err != nil {
+			return "", err
+		}
+		g.printf("}")
+
+		return res, nil
+	}
+
+	// the operands of < and <= are evaluated in the reverse order as they
+	// are compiled into > and >= with the operands swapped
+	first, second := expr.LHS, expr.RHS
+	op := expr.Token
+	switch op {
+	case token.Less:
+		first, second, op = second, first, token.Greater
+	case token.LessEq:
+		first, second, op = second, first, token.GreaterEq
+	}
+
+	left, err := g.expr(first)
+	if err != nil {
+		return "", err
+	}
+
+	right, err := g.expr(second)
+	if err != nil {
+		return "", err
+	}
+
+	switch op {
+	case token.Equal:
+		return fmt.Sprintf("rtBool(%s.Equals(%s))", receiver(left), right), nil
+	case token.NotEqual:
+		return fmt.Sprintf("rtBool(!%s.Equals(%s))", receiver(left), right), nil
+	}
+
+	name, ok := binaryOps[op]
+	if !ok {
+		return "", g.errorf(expr, "invalid binary operator: %s", expr.Token.String())
+	}
+
+	helper := "rtBinaryOp"
+	if op == token.Greater || op == token.GreaterEq {
+		helper = "rtCompare"
+	}
+
+	res := g.temp()
+	g.printf("%s, err := %s(%s, %s, %s)", res, helper, name, left, right)
+	g.checkError(expr)
+
+	return res, nil
+}
+
+func (g *generator) indexExpr(node ast.Node, container, index ast.Expr) (string, error) {
+	left, err := g.expr(container)
+	if err != nil {
+		return "", err
+	}
+
+	idx, err := g.expr(index)
+	if err != nil {
+		return "", err
+	}
+
+	res := g.temp()
+	g.printf("%s, err := rtIndex(%s, %s)", res, left, idx)
+	g.checkError(node)
+
+	return res, nil
+}
+
+func (g *generator) callExpr(expr *ast.CallExpr) (string, error) {
+	ident, ok := expr.Func.(*ast.Ident)
+	if !ok || g.isLocal(ident.Name) {
+		return "", g.errorf(expr, "only the top-level functions and the builtin functions can be called")
+	}
+
+	var args []string
+	for _, arg := range expr.Args {
+		value, err := g.expr(arg)
+		if err != nil {
+			return "", err
+		}
+
+		args = append(args, value)
+	}
+
+	res := g.temp()
+
+	if fn, ok := g.funcs[ident.Name]; ok {
+		if numParams := len(fn.Type.Params.List); numParams != len(args) {
+			return "", g.errorf(expr, "wrong number of arguments: want=%d, got=%d", numParams, len(args))
+		}
+
+		g.printf("%s, err := %s(%s)", res, exportedName(ident.Name), strings.Join(args, ", "))
+		g.printf("if err != nil {")
+		g.printf("return nil, err")
+		g.printf("}")
+
+		return res, nil
+	}
+
+	if isBuiltin(ident.Name) {
+		g.printf("%s, err := rtCallBuiltin(%s)", res, strings.Join(append([]string{strconv.Quote(ident.Name)}, args...), ", "))
+		g.checkError(expr)
+
+		return res, nil
+	}
+
+	return "", g.errorf(expr, "unresolved reference '%s'", ident.Name)
+}
+
+// truthy returns the Go condition that is true if the value is not falsy.
+func truthy(value string) string {
+	if strings.HasPrefix(value, "rtBool(") {
+		return strings.TrimSuffix(strings.TrimPrefix(value, "rtBool("), ")")
+	}
+
+	return "!" + receiver(value) + ".IsFalsy()"
+}
+
+// falsy returns the Go condition that is true if the value is falsy.
+func falsy(value string) string {
+	if strings.HasPrefix(value, "rtBool(") {
+		return "!(" + strings.TrimSuffix(strings.TrimPrefix(value, "rtBool("), ")") + ")"
+	}
+
+	return receiver(value) + ".IsFalsy()"
+}
+
+// receiver returns the Go expression that can be used to call a method of
+// the value. The composite literals are parenthesized.
+func receiver(value string) string {
+	if strings.HasPrefix(value, "&") {
+		return "(" + value + ")"
+	}
+
+	return value
+}
+
+func isBuiltin(name string) bool {
+	for _, fn := range objects.Builtins {
+		if fn.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
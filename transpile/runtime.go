@@ -0,0 +1,130 @@
+package transpile
+
+// runtimeSrc is the source code of the helper functions included in every
+// generated file. They implement the operations the same way the VM does.
+const runtimeSrc = `
+var rtBuiltins = make(map[string]objects.CallableFunc)
+
+func init() {
+	for _, fn := range objects.Builtins {
+		rtBuiltins[fn.Name] = fn.Func
+	}
+}
+
+func rtError(pos string, err error) error {
+	return fmt.Errorf("%s: %s", pos, err.Error())
+}
+
+func rtBool(b bool) objects.Object {
+	if b {
+		return objects.TrueValue
+	}
+
+	return objects.FalseValue
+}
+
+func rtBinaryOp(op token.Token, left, right objects.Object) (objects.Object, error) {
+	res, err := left.BinaryOp(op, right)
+	if err == objects.ErrInvalidOperator {
+		return nil, fmt.Errorf("invalid operation: %s %s %s", left.TypeName(), op.String(), right.TypeName())
+	}
+
+	return res, err
+}
+
+// rtCompare evaluates > and >= (< and <= are evaluated with the operands
+// swapped).
+func rtCompare(op token.Token, left, right objects.Object) (objects.Object, error) {
+	var c int
+	var err error
+	if cmp, ok := left.(objects.Comparable); ok {
+		c, err = cmp.Compare(right)
+	} else if cmp, ok := right.(objects.Comparable); ok {
+		c, err = cmp.Compare(left)
+		c = -c
+	} else {
+		return rtBinaryOp(op, left, right)
+	}
+
+	if err == objects.ErrInvalidOperator {
+		return nil, fmt.Errorf("invalid operation: %s %s %s", left.TypeName(), op.String(), right.TypeName())
+	} else if err != nil {
+		return nil, err
+	}
+
+	return rtBool((op == token.Greater && c > 0) || (op == token.GreaterEq && c >= 0)), nil
+}
+
+func rtUnaryOp(op token.Token, operand objects.Object) (objects.Object, error) {
+	switch x := operand.(type) {
+	case *objects.Int:
+		if op == token.Sub {
+			return &objects.Int{Value: -x.Value}, nil
+		}
+
+		return &objects.Int{Value: ^x.Value}, nil
+	case *objects.Float:
+		if op == token.Sub {
+			return &objects.Float{Value: -x.Value}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid operation: %s%s", op.String(), operand.TypeName())
+}
+
+func rtIndex(left, index objects.Object) (objects.Object, error) {
+	indexable, ok := left.(objects.Indexable)
+	if !ok {
+		return nil, fmt.Errorf("not indexable: %s", left.TypeName())
+	}
+
+	res, err := indexable.IndexGet(index)
+	if err == objects.ErrInvalidIndexType {
+		return nil, fmt.Errorf("invalid index type: %s", index.TypeName())
+	} else if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		return objects.UndefinedValue, nil
+	}
+
+	return res, nil
+}
+
+func rtIndexSet(left, index, value objects.Object) error {
+	assignable, ok := left.(objects.IndexAssignable)
+	if !ok {
+		return fmt.Errorf("not index-assignable: %s", left.TypeName())
+	}
+
+	err := assignable.IndexSet(index, value)
+	if err == objects.ErrInvalidIndexValueType {
+		return fmt.Errorf("invalid index value type: %s", value.TypeName())
+	}
+
+	return err
+}
+
+func rtIterate(x objects.Object) (objects.Iterator, error) {
+	iterable, ok := x.(objects.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("not iterable: %s", x.TypeName())
+	}
+
+	return iterable.Iterate(), nil
+}
+
+func rtCallBuiltin(name string, args ...objects.Object) (objects.Object, error) {
+	res, err := rtBuiltins[name](args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		return objects.UndefinedValue, nil
+	}
+
+	return res, nil
+}
+`
@@ -0,0 +1,167 @@
+// Package transpile converts Tengo source code into Go source code that uses
+// the objects API, so the scripts prototyped in Tengo can be compiled into
+// the host application.
+//
+// Only a restricted subset of Tengo is supported. The top-level statements
+// must be function definitions (e.g. "add := func(a, b) { return a + b }"),
+// and each of them becomes an exported Go function that takes and returns
+// objects.Object values:
+//
+//	func Add(a, b objects.Object) (objects.Object, error)
+//
+// The function bodies can use the local variables, the literals (except the
+// function literals), the operators, the index and selector expressions, the
+// calls to the other top-level functions and the builtin functions, and, the
+// if, for, for-in, return, break and continue statements. The function
+// literals, closures, imports, slice expressions, immutable expressions, and,
+// the function values (e.g. passing a function as an argument) are not
+// supported.
+package transpile
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+
+	"github.com/d5/tengo/compiler/ast"
+	"github.com/d5/tengo/compiler/parser"
+	"github.com/d5/tengo/compiler/source"
+)
+
+// Error represents an error of the code that cannot be transpiled.
+type Error struct {
+	Pos     source.FilePos
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// Position returns the position in the source code.
+func (e *Error) Position() source.FilePos {
+	return e.Pos
+}
+
+// Transpile parses the Tengo source file and returns the formatted Go source
+// code of the package pkgName. The filename is used for the positions of the
+// errors, including the runtime errors returned by the generated functions.
+func Transpile(filename string, src []byte, pkgName string) ([]byte, error) {
+	fileSet := source.NewFileSet()
+	file := fileSet.AddFile(filename, -1, len(src))
+
+	parsed, err := parser.ParseFile(file, src, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		file:  file,
+		funcs: make(map[string]*ast.FuncLit),
+		names: make(map[string]string),
+	}
+
+	// collect the top-level functions first so they can call each other
+	// regardless of the order
+	var names []string
+	for _, stmt := range parsed.Stmts {
+		name, fn, err := g.topLevelFunc(stmt)
+		if err != nil {
+			return nil, err
+		}
+
+		goName := exportedName(name)
+		if other, exists := g.names[goName]; exists {
+			return nil, g.errorf(stmt, "'%s' conflicts with '%s'", name, other)
+		}
+
+		g.funcs[name] = fn
+		g.names[goName] = name
+		names = append(names, name)
+	}
+
+	g.printf("// Code generated by tengo transpile from %s. DO NOT EDIT.", filename)
+	g.printf("")
+	g.printf("package %s", pkgName)
+	g.printf("")
+	g.printf("import (")
+	g.printf(`"fmt"`)
+	g.printf("")
+	g.printf(`"github.com/d5/tengo/compiler/token"`)
+	g.printf(`"github.com/d5/tengo/objects"`)
+	g.printf(")")
+
+	for _, name := range names {
+		g.printf("")
+		if err := g.function(name, g.funcs[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	g.buf.WriteString(runtimeSrc)
+
+	return format.Source(g.buf.Bytes())
+}
+
+func (g *generator) topLevelFunc(stmt ast.Stmt) (string, *ast.FuncLit, error) {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if ok && len(assign.LHS) == 1 && len(assign.RHS) == 1 {
+		ident, isIdent := assign.LHS[0].(*ast.Ident)
+		fn, isFunc := assign.RHS[0].(*ast.FuncLit)
+		if isIdent && isFunc {
+			if !unicode.IsLetter([]rune(ident.Name)[0]) {
+				return "", nil, g.errorf(ident, "function name must start with a letter: '%s'", ident.Name)
+			}
+
+			if _, exists := g.funcs[ident.Name]; exists {
+				return "", nil, g.errorf(ident, "'%s' redeclared", ident.Name)
+			}
+
+			return ident.Name, fn, nil
+		}
+	}
+
+	return "", nil, g.errorf(stmt, "only function definitions are allowed at the top level")
+}
+
+// exportedName returns the name of the Go function for the Tengo function.
+func exportedName(name string) string {
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+
+	return string(r)
+}
+
+// goKeywords are the Go keywords and the predeclared identifiers that are not
+// Tengo keywords, and, the identifiers used by the generated code.
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true,
+	"continue": true, "default": true, "defer": true, "else": true,
+	"fallthrough": true, "for": true, "func": true, "go": true, "goto": true,
+	"if": true, "import": true, "interface": true, "map": true,
+	"package": true, "range": true, "return": true, "select": true,
+	"struct": true, "switch": true, "type": true, "var": true,
+
+	"bool": true, "byte": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true,
+	"int8": true, "int16": true, "int32": true, "int64": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true,
+	"uint32": true, "uint64": true, "uintptr": true, "true": true,
+	"false": true, "iota": true, "nil": true, "append": true, "cap": true,
+	"close": true, "complex": true, "copy": true, "delete": true,
+	"imag": true, "len": true, "make": true, "new": true, "panic": true,
+	"print": true, "println": true, "real": true, "recover": true,
+
+	"err": true, "fmt": true, "objects": true, "token": true,
+}
+
+// localName returns the name of the Go variable for the Tengo variable.
+func (g *generator) localName(name string) string {
+	if _, isFunc := g.names[name]; isFunc || goKeywords[name] ||
+		strings.HasPrefix(name, "_t") || strings.HasPrefix(name, "rt") {
+		return name + "_"
+	}
+
+	return name
+}
@@ -0,0 +1,163 @@
+package transpile_test
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/transpile"
+)
+
+func TestTranspile(t *testing.T) {
+	expect(t, `add := func(a, b) { return a + b }`, `
+// Add is transpiled from 'add' (test.tengo:1:8).
+func Add(a, b objects.Object) (objects.Object, error) {
+	_t1, err := rtBinaryOp(token.Add, a, b)
+	if err != nil {
+		return nil, rtError("test.tengo:1:28", err)
+	}
+	return _t1, nil
+}`)
+
+	expect(t, `f := func() {}; g := func(x) { f(); return len(x) }`, `
+// F is transpiled from 'f' (test.tengo:1:6).
+func F() (objects.Object, error) {
+	return objects.UndefinedValue, nil
+}
+
+// G is transpiled from 'g' (test.tengo:1:22).
+func G(x objects.Object) (objects.Object, error) {
+	_t1, err := F()
+	if err != nil {
+		return nil, err
+	}
+	_ = _t1
+	_t2, err := rtCallBuiltin("len", x)
+	if err != nil {
+		return nil, rtError("test.tengo:1:44", err)
+	}
+	return _t2, nil
+}`)
+
+	expect(t, `f := func(a) { b := a < 1 && !a; c := 1; c = 2; type := [b] }`, `
+// F is transpiled from 'f' (test.tengo:1:6).
+func F(a objects.Object) (objects.Object, error) {
+	var _t1 objects.Object
+	_t2, err := rtCompare(token.Greater, &objects.Int{Value: 1}, a)
+	if err != nil {
+		return nil, rtError("test.tengo:1:21", err)
+	}
+	if _t2.IsFalsy() {
+		_t1 = _t2
+	} else {
+		_t1 = rtBool(a.IsFalsy())
+	}
+	var b objects.Object = _t1
+	var c objects.Object = &objects.Int{Value: 1}
+	_ = c
+	c = &objects.Int{Value: 2}
+	var type_ objects.Object = &objects.Array{Value: []objects.Object{b}}
+	_ = type_
+	return objects.UndefinedValue, nil
+}`)
+
+	expect(t, `f := func(m) { for k, _ in m { if k == "a" { continue } }; for i := 0; i < 3; i++ { m[i] += 1 } }`, `
+// F is transpiled from 'f' (test.tengo:1:6).
+func F(m objects.Object) (objects.Object, error) {
+	_t1, err := rtIterate(m)
+	if err != nil {
+		return nil, rtError("test.tengo:1:16", err)
+	}
+	for _t1.Next() {
+		k := _t1.Key()
+		if k.Equals(&objects.String{Value: "a"}) {
+			continue
+		}
+	}
+	{
+		var i objects.Object = &objects.Int{Value: 0}
+		for _t2 := true; ; _t2 = false {
+			if !_t2 {
+				_t3, err := rtBinaryOp(token.Add, i, &objects.Int{Value: 1})
+				if err != nil {
+					return nil, rtError("test.tengo:1:79", err)
+				}
+				i = _t3
+			}
+			_t4, err := rtCompare(token.Greater, &objects.Int{Value: 3}, i)
+			if err != nil {
+				return nil, rtError("test.tengo:1:72", err)
+			}
+			if _t4.IsFalsy() {
+				break
+			}
+			_t5, err := rtIndex(m, i)
+			if err != nil {
+				return nil, rtError("test.tengo:1:85", err)
+			}
+			_t6, err := rtBinaryOp(token.Add, _t5, &objects.Int{Value: 1})
+			if err != nil {
+				return nil, rtError("test.tengo:1:85", err)
+			}
+			if err := rtIndexSet(m, i, _t6); err != nil {
+				return nil, rtError("test.tengo:1:85", err)
+			}
+		}
+	}
+	return objects.UndefinedValue, nil
+}`)
+
+	// the generated code is valid Go code
+	out, err := transpile.Transpile("test.tengo", []byte(`
+fib := func(n) {
+	if n < 2 { return n }
+	return fib(n - 1) + fib(n - 2)
+}
+stats := func(xs) {
+	sum := 0
+	m := {count: len(xs), name: "x" + 'y', neg: -1.5, err: error(true)}
+	for x in xs { sum += x }
+	m.sum = sum
+	m.count++
+	return m.count > 1 ? m : undefined
+}`), "stats")
+	if assert.NoError(t, err) {
+		_, err := parser.ParseFile(token.NewFileSet(), "stats.go", out, 0)
+		assert.NoError(t, err)
+	}
+
+	// not supported
+	expectError(t, `a := 1`, "test.tengo:1:1: only function definitions are allowed at the top level")
+	expectError(t, `f := func() {}; f := func() {}`, "test.tengo:1:17: 'f' redeclared")
+	expectError(t, `f := func() {}; F := func() {}`, "test.tengo:1:17: 'F' conflicts with 'f'")
+	expectError(t, `f := func() { return func() {} }`, "test.tengo:1:22: function literals are not supported")
+	expectError(t, `f := func() { return import("os") }`, "test.tengo:1:22: imports are not supported")
+	expectError(t, `f := func(a) { return a[1:] }`, "test.tengo:1:23: slice expressions are not supported")
+	expectError(t, `f := func(a) { return f }`, "test.tengo:1:23: function values are not supported: 'f'")
+	expectError(t, `f := func(a) { return a() }`, "test.tengo:1:23: only the top-level functions and the builtin functions can be called")
+	expectError(t, `f := func(a) { return f() }`, "test.tengo:1:23: wrong number of arguments: want=1, got=0")
+	expectError(t, `f := func(a) { return b }`, "test.tengo:1:23: unresolved reference 'b'")
+	expectError(t, `f := func(a) { a := 1 }`, "test.tengo:1:16: 'a' redeclared in this block")
+}
+
+func expect(t *testing.T, input, expected string) {
+	out, err := transpile.Transpile("test.tengo", []byte(input), "test")
+	if !assert.NoError(t, err, input) {
+		return
+	}
+
+	// skip the header and the runtime helpers
+	actual := string(out)
+	actual = actual[strings.Index(actual, ")\n")+2 : strings.Index(actual, "\nvar rtBuiltins")]
+
+	assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(actual), input)
+}
+
+func expectError(t *testing.T, input, expected string) {
+	_, err := transpile.Transpile("test.tengo", []byte(input), "test")
+	if assert.Error(t, err, input) {
+		assert.Equal(t, expected, err.Error(), input)
+	}
+}